@@ -81,6 +81,13 @@ type Config interface {
 	// APIServerDetails returns the details needed to run an API server.
 	APIServerDetails() (port int, cert, key []byte)
 
+	// APIAddresses returns the addresses of the API servers.
+	APIAddresses() []string
+
+	// SetAPIAddresses replaces the addresses of the API servers.
+	// The configuration is not written.
+	SetAPIAddresses(addrs []string)
+
 	// Value returns the value associated with the key, or an empty string if
 	// the key is not found.
 	Value(key string) string
@@ -299,6 +306,24 @@ func (c *configInternal) APIServerDetails() (port int, cert, key []byte) {
 	return c.apiPort, c.stateServerCert, c.stateServerKey
 }
 
+func (c *configInternal) APIAddresses() []string {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if c.apiDetails == nil {
+		return nil
+	}
+	return append([]string(nil), c.apiDetails.addresses...)
+}
+
+func (c *configInternal) SetAPIAddresses(addrs []string) {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	if c.apiDetails == nil {
+		c.apiDetails = &connectionDetails{}
+	}
+	c.apiDetails.addresses = append([]string(nil), addrs...)
+}
+
 func (c *configInternal) Tag() string {
 	return c.tag
 }
@@ -380,7 +405,7 @@ func (c *configInternal) WriteCommands() ([]string, error) {
 
 func (c *configInternal) OpenAPI(dialOpts api.DialOpts) (st *api.State, newPassword string, err error) {
 	info := api.Info{
-		Addrs:    c.apiDetails.addresses,
+		Addrs:    c.APIAddresses(),
 		Password: c.apiDetails.password,
 		CACert:   c.caCert,
 		Tag:      c.tag,
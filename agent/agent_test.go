@@ -203,6 +203,21 @@ func (*suite) TestWriteAndRead(c *gc.C) {
 	c.Assert(confCommands, gc.DeepEquals, rereadCommands)
 }
 
+func (*suite) TestSetAPIAddresses(c *gc.C) {
+	testParams := attributeParams
+	testParams.DataDir = c.MkDir()
+	conf, err := agent.NewAgentConfig(testParams)
+	c.Assert(err, gc.IsNil)
+	c.Assert(conf.APIAddresses(), gc.HasLen, 0)
+
+	conf.SetAPIAddresses([]string{"10.0.0.1:17070", "10.0.0.2:17070"})
+	c.Assert(conf.APIAddresses(), gc.DeepEquals, []string{"10.0.0.1:17070", "10.0.0.2:17070"})
+	c.Assert(conf.Write(), gc.IsNil)
+	reread, err := agent.ReadConf(conf.DataDir(), conf.Tag())
+	c.Assert(err, gc.IsNil)
+	c.Assert(reread.APIAddresses(), gc.DeepEquals, []string{"10.0.0.1:17070", "10.0.0.2:17070"})
+}
+
 func (*suite) TestWriteNewPassword(c *gc.C) {
 
 	for i, test := range []struct {
@@ -5,11 +5,15 @@ package agent
 
 import (
 	"fmt"
+	"net"
+
+	"labix.org/v2/mgo"
 
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/environs/config"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/replicaset"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/version"
 )
@@ -47,6 +51,10 @@ type BootstrapMachineConfig struct {
 	// Characteristics holds hardware information on the
 	// bootstrap machine.
 	Characteristics instance.HardwareCharacteristics
+
+	// Addresses holds the addresses of the bootstrap machine's
+	// instance, as reported by the provider.
+	Addresses []instance.Address
 }
 
 const bootstrapMachineId = "0"
@@ -60,6 +68,9 @@ func (c *configInternal) InitializeState(envCfg *config.Config, machineCfg Boots
 		CACert: c.caCert,
 	}
 	logger.Debugf("initializing address %v", info.Addrs)
+	if err := maybeInitiateMongoServer(&info, machineCfg.Addresses, timeout); err != nil {
+		return nil, nil, err
+	}
 	st, err := state.Initialize(&info, envCfg, timeout)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to initialize state: %v", err)
@@ -73,6 +84,48 @@ func (c *configInternal) InitializeState(envCfg *config.Config, machineCfg Boots
 	return st, m, nil
 }
 
+// maybeInitiateMongoServer initiates the replica set of the bootstrap
+// machine's state server, which is always the first of the
+// given addresses. The state server joins the replica set at the
+// internal address chosen from the machine's addresses, which is the
+// address the peergrouper worker uses for it too. State servers that
+// are not running as replica set members, such as the local
+// provider's, are left alone, as are replica sets that have already
+// been initiated.
+func maybeInitiateMongoServer(info *state.Info, machineAddrs []instance.Address, opts state.DialOpts) error {
+	di, err := state.DialInfo(info, opts)
+	if err != nil {
+		return err
+	}
+	di.Addrs = di.Addrs[:1]
+	di.Direct = true
+	session, err := mgo.DialWithInfo(di)
+	if err != nil {
+		return fmt.Errorf("cannot dial state server: %v", err)
+	}
+	defer session.Close()
+	session.SetMode(mgo.Monotonic, true)
+	status, err := replicaset.IsMaster(session)
+	if err != nil {
+		return fmt.Errorf("cannot get state server status: %v", err)
+	}
+	if status.IsMaster || status.ReplicaSetName != "" {
+		return nil
+	}
+	_, port, err := net.SplitHostPort(info.Addrs[0])
+	if err != nil {
+		return err
+	}
+	host := instance.SelectInternalAddress(machineAddrs, false)
+	if host == "" {
+		return fmt.Errorf("no internal address for bootstrap machine in %v", machineAddrs)
+	}
+	addr := net.JoinHostPort(host, port)
+	logger.Debugf("initiating replica set with %s", addr)
+	tags := map[string]string{replicaset.MachineIdTag: bootstrapMachineId}
+	return replicaset.Initiate(session, addr, replicaset.Name, tags)
+}
+
 func (c *configInternal) initUsersAndBootstrapMachine(st *state.State, cfg BootstrapMachineConfig) (*state.Machine, error) {
 	if err := initBootstrapUser(st, c.oldPassword); err != nil {
 		return nil, fmt.Errorf("cannot initialize bootstrap user: %v", err)
@@ -133,6 +186,11 @@ func (c *configInternal) initBootstrapMachine(st *state.State, cfg BootstrapMach
 	if m.Id() != bootstrapMachineId {
 		return nil, fmt.Errorf("bootstrap machine expected id 0, got %q", m.Id())
 	}
+	// The addresses are recorded straight away, so that the peergrouper
+	// sees the same address the replica set was initiated with.
+	if err := m.SetAddresses(cfg.Addresses); err != nil {
+		return nil, err
+	}
 	// Read the machine agent's password and change it to
 	// a new password (other agents will change their password
 	// via the API connection).
@@ -59,11 +59,13 @@ func (s *bootstrapSuite) TestInitializeState(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	expectConstraints := constraints.MustParse("mem=1024M")
 	expectHW := instance.MustParseHardware("mem=2048M")
+	expectAddrs := []instance.Address{instance.NewAddress("0.1.2.3")}
 	mcfg := agent.BootstrapMachineConfig{
 		Constraints:     expectConstraints,
 		Jobs:            []state.MachineJob{state.JobHostUnits},
 		InstanceId:      "i-bootstrap",
 		Characteristics: expectHW,
+		Addresses:       expectAddrs,
 	}
 	envAttrs := testing.FakeConfig().Delete("admin-secret").Merge(testing.Attrs{
 		"agent-version": version.Current.Number.String(),
@@ -98,6 +100,7 @@ func (s *bootstrapSuite) TestInitializeState(c *gc.C) {
 	gotHW, err := m.HardwareCharacteristics()
 	c.Assert(err, gc.IsNil)
 	c.Assert(*gotHW, gc.DeepEquals, expectHW)
+	c.Assert(m.Addresses(), gc.DeepEquals, expectAddrs)
 
 	// Check that the machine agent's config has been written
 	// and that we can use it to connect to the state.
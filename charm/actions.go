@@ -0,0 +1,133 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/schema"
+)
+
+// ActionSpec represents a single action that may be invoked on the units
+// of a charm, as declared in its actions.yaml file.
+type ActionSpec struct {
+	Description string
+	Params      map[string]Option `bson:",omitempty"`
+}
+
+var validActionName = regexp.MustCompile("^[a-z][a-z0-9]*(-[a-z0-9]+)*$")
+
+// IsValidActionName returns whether name is a valid action name.
+func IsValidActionName(name string) bool {
+	return validActionName.MatchString(name)
+}
+
+// ReadActions reads the content of an actions.yaml file and returns
+// the action specifications it declares, indexed by action name.
+func ReadActions(r io.Reader) (map[string]ActionSpec, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[interface{}]interface{})
+	if err := goyaml.Unmarshal(data, raw); err != nil {
+		return nil, err
+	}
+	v, err := actionsSchema.Coerce(raw, nil)
+	if err != nil {
+		return nil, errors.New("actions: " + err.Error())
+	}
+	actions := make(map[string]ActionSpec)
+	for name, rawSpec := range v.(map[string]interface{}) {
+		if !IsValidActionName(name) {
+			return nil, fmt.Errorf("actions: invalid action name %q", name)
+		}
+		specMap := rawSpec.(map[string]interface{})
+		spec := ActionSpec{}
+		if description := specMap["description"]; description != nil {
+			spec.Description = description.(string)
+		}
+		if rawParams := specMap["params"]; rawParams != nil {
+			spec.Params = make(map[string]Option)
+			for pname, rawParam := range rawParams.(map[string]interface{}) {
+				paramMap := rawParam.(map[string]interface{})
+				option := Option{Type: paramMap["type"].(string)}
+				if description := paramMap["description"]; description != nil {
+					option.Description = description.(string)
+				}
+				if option.Default, err = option.validate(pname, paramMap["default"]); err != nil {
+					return nil, fmt.Errorf("actions: action %q has invalid default: %v", name, err)
+				}
+				spec.Params[pname] = option
+			}
+		}
+		actions[name] = spec
+	}
+	return actions, nil
+}
+
+// ValidateParams checks the supplied parameter values against the spec,
+// and returns a complete set of parameters in which every value has the
+// type declared for it and every omitted parameter takes its default
+// value. String values are parsed according to the declared type, so
+// that parameters supplied on a command line may be validated directly.
+func (spec ActionSpec) ValidateParams(values map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for name, value := range values {
+		option, ok := spec.Params[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		var err error
+		if str, ok := value.(string); ok {
+			value, err = option.parse(name, str)
+		} else if f, ok := value.(float64); ok && option.Type == "int" && f == float64(int64(f)) {
+			// Values that travelled through JSON lose their
+			// integer type; accept them if nothing is lost.
+			value = int64(f)
+		} else {
+			value, err = option.validate(name, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[name] = value
+	}
+	for name, option := range spec.Params {
+		if _, ok := result[name]; !ok && option.Default != nil {
+			result[name] = option.Default
+		}
+	}
+	return result, nil
+}
+
+var actionParamSchema = schema.FieldMap(
+	schema.Fields{
+		"type":        schema.OneOf(schema.Const("string"), schema.Const("int"), schema.Const("float"), schema.Const("boolean")),
+		"description": schema.String(),
+		"default":     schema.Any(),
+	},
+	schema.Defaults{
+		"type":        "string",
+		"description": schema.Omit,
+		"default":     schema.Omit,
+	},
+)
+
+var actionsSchema = schema.StringMap(schema.FieldMap(
+	schema.Fields{
+		"description": schema.String(),
+		"params":      schema.StringMap(actionParamSchema),
+	},
+	schema.Defaults{
+		"description": schema.Omit,
+		"params":      schema.Omit,
+	},
+))
@@ -0,0 +1,134 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"bytes"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+)
+
+type ActionsSuite struct{}
+
+var _ = gc.Suite(&ActionsSuite{})
+
+func readActions(c *gc.C, yaml string) (map[string]charm.ActionSpec, error) {
+	return charm.ReadActions(bytes.NewBuffer([]byte(yaml)))
+}
+
+func (s *ActionsSuite) TestReadActions(c *gc.C) {
+	actions, err := readActions(c, `
+snapshot:
+  description: Take a snapshot of the database.
+  params:
+    outfile:
+      description: The file to write out to.
+      default: foo.bz2
+    compression:
+      type: int
+      default: 9
+reindex:
+  description: Rebuild the indexes.
+`)
+	c.Assert(err, gc.IsNil)
+	c.Assert(actions, gc.DeepEquals, map[string]charm.ActionSpec{
+		"snapshot": {
+			Description: "Take a snapshot of the database.",
+			Params: map[string]charm.Option{
+				"outfile": {
+					Type:        "string",
+					Description: "The file to write out to.",
+					Default:     "foo.bz2",
+				},
+				"compression": {
+					Type:    "int",
+					Default: int64(9),
+				},
+			},
+		},
+		"reindex": {
+			Description: "Rebuild the indexes.",
+		},
+	})
+}
+
+var readActionsErrorTests = []struct {
+	yaml string
+	err  string
+}{{
+	yaml: "snapshot: 42\n",
+	err:  `actions: snapshot: expected map, got int\(42\)`,
+}, {
+	yaml: "Snapshot:\n  description: Bad name.\n",
+	err:  `actions: invalid action name "Snapshot"`,
+}, {
+	yaml: "snap--shot:\n  description: Bad name.\n",
+	err:  `actions: invalid action name "snap--shot"`,
+}, {
+	yaml: "snapshot:\n  params:\n    outfile:\n      type: blob\n",
+	err:  `actions: snapshot.params.outfile.type: unexpected value "blob"`,
+}, {
+	yaml: "snapshot:\n  params:\n    level:\n      type: int\n      default: high\n",
+	err:  `actions: action "snapshot" has invalid default: option "level" expected int, got "high"`,
+}}
+
+func (s *ActionsSuite) TestReadActionsErrors(c *gc.C) {
+	for i, t := range readActionsErrorTests {
+		c.Logf("test %d: %q", i, t.yaml)
+		_, err := readActions(c, t.yaml)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+var validateParamsTests = []struct {
+	params map[string]interface{}
+	expect map[string]interface{}
+	err    string
+}{{
+	params: nil,
+	expect: map[string]interface{}{"outfile": "foo.bz2"},
+}, {
+	params: map[string]interface{}{"outfile": "bar.bz2", "level": "3"},
+	expect: map[string]interface{}{"outfile": "bar.bz2", "level": int64(3)},
+}, {
+	params: map[string]interface{}{"level": 7},
+	expect: map[string]interface{}{"outfile": "foo.bz2", "level": int64(7)},
+}, {
+	params: map[string]interface{}{"level": float64(7)},
+	expect: map[string]interface{}{"outfile": "foo.bz2", "level": int64(7)},
+}, {
+	params: map[string]interface{}{"level": 7.5},
+	err:    `option "level" expected int, got 7.5`,
+}, {
+	params: map[string]interface{}{"level": "high"},
+	err:    `option "level" expected int, got "high"`,
+}, {
+	params: map[string]interface{}{"colour": "blue"},
+	err:    `unknown parameter "colour"`,
+}}
+
+func (s *ActionsSuite) TestValidateParams(c *gc.C) {
+	actions, err := readActions(c, `
+snapshot:
+  params:
+    outfile:
+      default: foo.bz2
+    level:
+      type: int
+`)
+	c.Assert(err, gc.IsNil)
+	spec := actions["snapshot"]
+	for i, t := range validateParamsTests {
+		c.Logf("test %d: %v", i, t.params)
+		result, err := spec.ValidateParams(t.params)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Check(err, gc.IsNil)
+		c.Check(result, gc.DeepEquals, t.expect)
+	}
+}
@@ -83,6 +83,32 @@ func readBundle(r io.ReaderAt, size int64) (bundle *Bundle, err error) {
 		}
 	}
 
+	reader, err = zipOpen(zipr, "actions.yaml")
+	if _, ok := err.(*noBundleFile); ok {
+		// No actions; that's fine.
+	} else if err != nil {
+		return nil, err
+	} else {
+		b.meta.Actions, err = ReadActions(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err = zipOpen(zipr, "metrics.yaml")
+	if _, ok := err.(*noBundleFile); ok {
+		// No metrics; that's fine.
+	} else if err != nil {
+		return nil, err
+	} else {
+		b.meta.Metrics, err = ReadMetrics(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	reader, err = zipOpen(zipr, "revision")
 	if err != nil {
 		if _, ok := err.(*noBundleFile); !ok {
@@ -236,6 +262,13 @@ func (b *Bundle) expand(hooks map[string]bool, dir string, zfile *zip.File) erro
 			mode = mode | 0100
 		}
 	}
+	if filepath.Dir(cleanName) == "actions" {
+		actionName := filepath.Base(cleanName)
+		if _, ok := b.meta.Actions[actionName]; mode&os.ModeType == 0 && ok {
+			// Set all actions executable (by owner)
+			mode = mode | 0100
+		}
+	}
 
 	if err := checkFileType(cleanName, mode); err != nil {
 		return err
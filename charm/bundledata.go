@@ -0,0 +1,430 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/names"
+)
+
+// BundleData holds the contents of a bundle: a set of services to be
+// deployed together, and the relations between them. It is named so
+// as not to be confused with a charm Bundle, which holds a single
+// charm.
+type BundleData struct {
+	// Services holds the services to deploy, keyed by service name.
+	Services map[string]*ServiceSpec
+
+	// Relations holds the relations between the services. Each
+	// relation holds two endpoints, of the form "service[:relation]".
+	Relations [][]string `yaml:",omitempty"`
+}
+
+// ServiceSpec describes a single service in a bundle.
+type ServiceSpec struct {
+	// Charm holds the URL of the service's charm.
+	Charm string
+
+	// NumUnits holds the number of units of the service.
+	NumUnits int `yaml:"num_units,omitempty"`
+
+	// To holds the placement of the service's units, one entry per
+	// unit, starting with the first; any further units are placed
+	// automatically. Each entry is either a machine specification,
+	// as accepted by "juju deploy --to", or the name of a unit of
+	// another service in the bundle, as in "mysql/0", to place the
+	// unit on the same machine as that one.
+	To []string `yaml:",omitempty"`
+
+	// Expose holds whether the service is exposed.
+	Expose bool `yaml:",omitempty"`
+
+	// Options holds the configuration settings of the service.
+	Options map[string]interface{} `yaml:",omitempty"`
+
+	// Constraints holds the constraints of the service's machines,
+	// in the format accepted by "juju set-constraints".
+	Constraints string `yaml:",omitempty"`
+}
+
+// ReadBundleData reads bundle data in YAML format. The data is not
+// verified.
+func ReadBundleData(r io.Reader) (*BundleData, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var bd BundleData
+	if err := goyaml.Unmarshal(data, &bd); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal bundle data: %v", err)
+	}
+	return &bd, nil
+}
+
+// VerificationError holds all the problems found when verifying
+// bundle data.
+type VerificationError struct {
+	Errors []error
+}
+
+func (err *VerificationError) Error() string {
+	switch len(err.Errors) {
+	case 0:
+		return "no verification errors!"
+	case 1:
+		return err.Errors[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", err.Errors[0], len(err.Errors)-1)
+}
+
+type bundleDataVerifier struct {
+	bd                *BundleData
+	charms            map[string]Charm
+	verifyConstraints func(string) error
+	errors            []error
+}
+
+func (verifier *bundleDataVerifier) addErrorf(f string, a ...interface{}) {
+	verifier.errors = append(verifier.errors, fmt.Errorf(f, a...))
+}
+
+// Verify checks that the bundle data is internally consistent, without
+// looking at the charms it uses. The verifyConstraints function is
+// called to check the constraints of each service. All the problems
+// found are returned in a *VerificationError.
+func (bd *BundleData) Verify(verifyConstraints func(string) error) error {
+	return bd.VerifyWithCharms(verifyConstraints, nil)
+}
+
+// VerifyWithCharms is like Verify, but also checks the bundle data
+// against the given charms, keyed by the charm URLs used in the
+// bundle: every relation must connect compatible endpoints of the
+// services' charms, and the options of each service must be valid for
+// its charm. If charms is nil, those checks are skipped.
+func (bd *BundleData) VerifyWithCharms(verifyConstraints func(string) error, charms map[string]Charm) error {
+	verifier := &bundleDataVerifier{
+		bd:                bd,
+		charms:            charms,
+		verifyConstraints: verifyConstraints,
+	}
+	verifier.verifyServices()
+	verifier.verifyPlacement()
+	verifier.verifyRelations()
+	if len(verifier.errors) > 0 {
+		return &VerificationError{verifier.errors}
+	}
+	return nil
+}
+
+func (verifier *bundleDataVerifier) verifyServices() {
+	if len(verifier.bd.Services) == 0 {
+		verifier.addErrorf("bundle has no services")
+	}
+	for _, name := range verifier.bd.ServiceNames() {
+		svc := verifier.bd.Services[name]
+		if !names.IsService(name) {
+			verifier.addErrorf("invalid service name %q", name)
+		}
+		if svc == nil {
+			verifier.addErrorf("service %q has no definition", name)
+			continue
+		}
+		if _, err := ParseURL(svc.Charm); err != nil {
+			verifier.addErrorf("invalid charm URL in service %q: %v", name, err)
+			continue
+		}
+		if svc.NumUnits < 0 {
+			verifier.addErrorf("negative number of units specified on service %q", name)
+		} else if len(svc.To) > svc.NumUnits {
+			verifier.addErrorf("too many units placed for service %q", name)
+		}
+		if svc.Constraints != "" {
+			if err := verifier.verifyConstraints(svc.Constraints); err != nil {
+				verifier.addErrorf("invalid constraints %q in service %q: %v", svc.Constraints, name, err)
+			}
+		}
+		if verifier.charms == nil {
+			continue
+		}
+		ch := verifier.charms[svc.Charm]
+		if ch == nil {
+			verifier.addErrorf("service %q refers to non-existent charm %q", name, svc.Charm)
+			continue
+		}
+		if ch.Meta().Subordinate {
+			if svc.NumUnits > 0 || svc.Constraints != "" {
+				verifier.addErrorf("service %q is subordinate but has units or constraints", name)
+			}
+		}
+		if _, err := ch.Config().ValidateSettings(svc.Options); err != nil {
+			verifier.addErrorf("cannot validate options of service %q: %v", name, err)
+		}
+	}
+}
+
+// verifyPlacement checks the placement of every service's units, and
+// that no service's placement depends, directly or indirectly, on
+// itself.
+func (verifier *bundleDataVerifier) verifyPlacement() {
+	for _, name := range verifier.bd.ServiceNames() {
+		svc := verifier.bd.Services[name]
+		if svc == nil {
+			continue
+		}
+		for _, to := range svc.To {
+			if err := verifier.verifyPlacementSpec(to); err != nil {
+				verifier.addErrorf("invalid placement %q in service %q: %v", to, name, err)
+			}
+		}
+	}
+	if _, err := verifier.bd.DeploymentOrder(); err != nil {
+		verifier.errors = append(verifier.errors, err)
+	}
+}
+
+func (verifier *bundleDataVerifier) verifyPlacementSpec(to string) error {
+	if svcName, unit, ok := ParseBundleUnitPlacement(to); ok {
+		svc := verifier.bd.Services[svcName]
+		if svc == nil {
+			return fmt.Errorf("service %q not found in bundle", svcName)
+		}
+		if unit >= svc.NumUnits {
+			return fmt.Errorf("service %q has no unit %d", svcName, unit)
+		}
+		return nil
+	}
+	machine := to
+	if i := strings.Index(to, ":"); i >= 0 {
+		machine = to[i+1:]
+	}
+	if !names.IsMachine(machine) {
+		return fmt.Errorf("not a machine or unit")
+	}
+	return nil
+}
+
+// ParseBundleUnitPlacement returns the service name and unit number
+// held in a placement entry that refers to a unit of another service
+// in a bundle, as in "mysql/0". If the entry does not refer to a unit,
+// ok is false.
+func ParseBundleUnitPlacement(to string) (service string, unit int, ok bool) {
+	if !names.IsUnit(to) {
+		return "", 0, false
+	}
+	i := strings.Index(to, "/")
+	unit, err := strconv.Atoi(to[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return to[:i], unit, true
+}
+
+// DeploymentOrder returns the names of the bundle's services in an
+// order that deploys every service after the services whose units its
+// own units are placed with. Services are otherwise sorted by name.
+func (bd *BundleData) DeploymentOrder() ([]string, error) {
+	var order []string
+	done := make(map[string]bool)
+	visiting := make(map[string]bool)
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("placement of service %q depends on itself", name)
+		}
+		visiting[name] = true
+		if svc := bd.Services[name]; svc != nil {
+			for _, to := range svc.To {
+				if dep, _, ok := ParseBundleUnitPlacement(to); ok {
+					if _, found := bd.Services[dep]; !found {
+						continue
+					}
+					if err := visit(dep); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		visiting[name] = false
+		done[name] = true
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range bd.ServiceNames() {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (verifier *bundleDataVerifier) verifyRelations() {
+	seen := make(map[string]bool)
+	for i, relation := range verifier.bd.Relations {
+		if len(relation) != 2 {
+			verifier.addErrorf("relation %d has %d endpoints, not 2", i, len(relation))
+			continue
+		}
+		var eps [2]bundleEndpoint
+		ok := true
+		for j, ep := range relation {
+			eps[j] = parseBundleEndpoint(ep)
+			if _, found := verifier.bd.Services[eps[j].service]; !found {
+				verifier.addErrorf("relation %q refers to service %q not defined in this bundle", relation, eps[j].service)
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		if eps[0].service == eps[1].service {
+			verifier.addErrorf("relation %q relates a service to itself", relation)
+			continue
+		}
+		key := relationKey(relation[0], relation[1])
+		if seen[key] {
+			verifier.addErrorf("relation %q is defined more than once", relation)
+			continue
+		}
+		seen[key] = true
+		if verifier.charms != nil {
+			verifier.verifyRelationEndpoints(relation, eps)
+		}
+	}
+}
+
+// verifyRelationEndpoints checks that there is exactly one
+// way of relating the given endpoints of the services' charms.
+func (verifier *bundleDataVerifier) verifyRelationEndpoints(relation []string, eps [2]bundleEndpoint) {
+	var rels [2]map[string]Relation
+	for i, ep := range eps {
+		ch := verifier.charms[verifier.bd.Services[ep.service].Charm]
+		if ch == nil {
+			// Already reported.
+			return
+		}
+		rels[i] = charmRelations(ch)
+		if ep.relation == "" {
+			continue
+		}
+		if _, ok := rels[i][ep.relation]; !ok {
+			verifier.addErrorf("charm %q used by service %q does not define relation %q", verifier.bd.Services[ep.service].Charm, ep.service, ep.relation)
+			return
+		}
+	}
+	matches := 0
+	for name0, rel0 := range rels[0] {
+		if eps[0].relation != "" && name0 != eps[0].relation {
+			continue
+		}
+		for name1, rel1 := range rels[1] {
+			if eps[1].relation != "" && name1 != eps[1].relation {
+				continue
+			}
+			if canRelate(rel0, rel1) {
+				matches++
+			}
+		}
+	}
+	switch {
+	case matches == 0:
+		verifier.addErrorf("no relations found for %q", relation)
+	case matches > 1:
+		verifier.addErrorf("ambiguous relation %q; specify the relation names", relation)
+	}
+}
+
+// charmRelations returns all the provider and requirer relations
+// of the given charm, including the implicit juju-info relation.
+func charmRelations(ch Charm) map[string]Relation {
+	rels := map[string]Relation{
+		"juju-info": {
+			Name:      "juju-info",
+			Role:      RoleProvider,
+			Interface: "juju-info",
+			Scope:     ScopeGlobal,
+		},
+	}
+	meta := ch.Meta()
+	for name, rel := range meta.Provides {
+		rels[name] = rel
+	}
+	for name, rel := range meta.Requires {
+		rels[name] = rel
+	}
+	return rels
+}
+
+// canRelate returns whether the two relations may be related.
+func canRelate(rel0, rel1 Relation) bool {
+	if rel0.Interface != rel1.Interface {
+		return false
+	}
+	switch rel0.Role {
+	case RoleProvider:
+		return rel1.Role == RoleRequirer
+	case RoleRequirer:
+		// Implicit relations may only be used in explicitly
+		// named container relations, which is checked by state
+		// when the relation is added.
+		return rel1.Role == RoleProvider
+	}
+	return false
+}
+
+type bundleEndpoint struct {
+	service  string
+	relation string
+}
+
+func parseBundleEndpoint(ep string) bundleEndpoint {
+	if i := strings.Index(ep, ":"); i >= 0 {
+		return bundleEndpoint{ep[:i], ep[i+1:]}
+	}
+	return bundleEndpoint{service: ep}
+}
+
+func relationKey(ep0, ep1 string) string {
+	if ep0 > ep1 {
+		ep0, ep1 = ep1, ep0
+	}
+	return ep0 + " " + ep1
+}
+
+// ServiceNames returns the names of the bundle's services, in
+// alphabetical order.
+func (bd *BundleData) ServiceNames() []string {
+	var serviceNames []string
+	for name := range bd.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	return serviceNames
+}
+
+// RequiredCharms returns the URLs of the charms used by the bundle's
+// services, in alphabetical order and without duplicates.
+func (bd *BundleData) RequiredCharms() []string {
+	seen := make(map[string]bool)
+	var charms []string
+	for _, svc := range bd.Services {
+		if svc == nil || seen[svc.Charm] {
+			continue
+		}
+		seen[svc.Charm] = true
+		charms = append(charms, svc.Charm)
+	}
+	sort.Strings(charms)
+	return charms
+}
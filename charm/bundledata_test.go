@@ -0,0 +1,240 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"fmt"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/testing"
+)
+
+type BundleDataSuite struct{}
+
+var _ = gc.Suite(&BundleDataSuite{})
+
+const wordpressBundle = `
+services:
+    wordpress:
+        charm: cs:quantal/wordpress
+        num_units: 2
+        to: ["mysql/0"]
+        expose: true
+        options:
+            blog-title: My Blog
+    mysql:
+        charm: cs:quantal/mysql-3
+        num_units: 1
+        constraints: mem=4G
+    logging:
+        charm: cs:quantal/logging
+relations:
+    - ["wordpress:db", "mysql"]
+    - ["wordpress:logging-dir", "logging:logging-directory"]
+`
+
+func (*BundleDataSuite) TestReadBundleData(c *gc.C) {
+	bd, err := charm.ReadBundleData(strings.NewReader(wordpressBundle))
+	c.Assert(err, gc.IsNil)
+	c.Assert(bd, gc.DeepEquals, &charm.BundleData{
+		Services: map[string]*charm.ServiceSpec{
+			"wordpress": {
+				Charm:    "cs:quantal/wordpress",
+				NumUnits: 2,
+				To:       []string{"mysql/0"},
+				Expose:   true,
+				Options: map[string]interface{}{
+					"blog-title": "My Blog",
+				},
+			},
+			"mysql": {
+				Charm:       "cs:quantal/mysql-3",
+				NumUnits:    1,
+				Constraints: "mem=4G",
+			},
+			"logging": {
+				Charm: "cs:quantal/logging",
+			},
+		},
+		Relations: [][]string{
+			{"wordpress:db", "mysql"},
+			{"wordpress:logging-dir", "logging:logging-directory"},
+		},
+	})
+	c.Assert(bd.ServiceNames(), gc.DeepEquals, []string{"logging", "mysql", "wordpress"})
+	c.Assert(bd.RequiredCharms(), gc.DeepEquals, []string{
+		"cs:quantal/logging",
+		"cs:quantal/mysql-3",
+		"cs:quantal/wordpress",
+	})
+}
+
+func (*BundleDataSuite) TestReadBundleDataError(c *gc.C) {
+	_, err := charm.ReadBundleData(strings.NewReader("services: [1, 2]"))
+	c.Assert(err, gc.ErrorMatches, "cannot unmarshal bundle data: .*")
+}
+
+func verifyConstraints(cons string) error {
+	if strings.HasPrefix(cons, "bad") {
+		return fmt.Errorf("bad constraint")
+	}
+	return nil
+}
+
+func bundleCharms() map[string]charm.Charm {
+	return map[string]charm.Charm{
+		"cs:quantal/wordpress": testing.Charms.Dir("wordpress"),
+		"cs:quantal/mysql-3":   testing.Charms.Dir("mysql"),
+		"cs:quantal/logging":   testing.Charms.Dir("logging"),
+	}
+}
+
+func (*BundleDataSuite) TestVerifyValid(c *gc.C) {
+	bd, err := charm.ReadBundleData(strings.NewReader(wordpressBundle))
+	c.Assert(err, gc.IsNil)
+	err = bd.Verify(verifyConstraints)
+	c.Assert(err, gc.IsNil)
+	err = bd.VerifyWithCharms(verifyConstraints, bundleCharms())
+	c.Assert(err, gc.IsNil)
+	order, err := bd.DeploymentOrder()
+	c.Assert(err, gc.IsNil)
+	c.Assert(order, gc.DeepEquals, []string{"logging", "mysql", "wordpress"})
+}
+
+var verifyErrorTests = []struct {
+	about  string
+	change func(bd *charm.BundleData)
+	errors []string
+}{{
+	about: "no services",
+	change: func(bd *charm.BundleData) {
+		bd.Services = nil
+		bd.Relations = nil
+	},
+	errors: []string{"bundle has no services"},
+}, {
+	about: "bad service name and charm URL",
+	change: func(bd *charm.BundleData) {
+		bd.Services["Bad"] = &charm.ServiceSpec{Charm: "bogus"}
+	},
+	errors: []string{
+		`invalid service name "Bad"`,
+		`invalid charm URL in service "Bad": .*`,
+	},
+}, {
+	about: "bad units, placement and constraints",
+	change: func(bd *charm.BundleData) {
+		bd.Services["mysql"].NumUnits = -1
+		bd.Services["mysql"].Constraints = "bad"
+		bd.Services["wordpress"].To = []string{"mysql/0", "0/lxc/0", "nowhere"}
+	},
+	errors: []string{
+		`negative number of units specified on service "mysql"`,
+		`invalid constraints "bad" in service "mysql": bad constraint`,
+		`too many units placed for service "wordpress"`,
+		`invalid placement "mysql/0" in service "wordpress": service "mysql" has no unit 0`,
+		`invalid placement "nowhere" in service "wordpress": not a machine or unit`,
+	},
+}, {
+	about: "placement cycle",
+	change: func(bd *charm.BundleData) {
+		bd.Services["mysql"].To = []string{"wordpress/1"}
+	},
+	errors: []string{`placement of service "(mysql|wordpress)" depends on itself`},
+}, {
+	about: "bad relations",
+	change: func(bd *charm.BundleData) {
+		bd.Relations = append(bd.Relations,
+			[]string{"wordpress"},
+			[]string{"wordpress", "haproxy"},
+			[]string{"mysql", "mysql"},
+			[]string{"mysql", "wordpress:db"},
+		)
+	},
+	errors: []string{
+		`relation 2 has 1 endpoints, not 2`,
+		`relation \["wordpress" "haproxy"\] refers to service "haproxy" not defined in this bundle`,
+		`relation \["mysql" "mysql"\] relates a service to itself`,
+		`relation \["mysql" "wordpress:db"\] is defined more than once`,
+	},
+}}
+
+func (*BundleDataSuite) TestVerifyErrors(c *gc.C) {
+	for i, test := range verifyErrorTests {
+		c.Logf("test %d: %s", i, test.about)
+		bd, err := charm.ReadBundleData(strings.NewReader(wordpressBundle))
+		c.Assert(err, gc.IsNil)
+		test.change(bd)
+		err = bd.Verify(verifyConstraints)
+		assertVerifyErrors(c, err, test.errors)
+	}
+}
+
+var verifyWithCharmsErrorTests = []struct {
+	about  string
+	change func(bd *charm.BundleData)
+	errors []string
+}{{
+	about: "unknown charm",
+	change: func(bd *charm.BundleData) {
+		bd.Services["mysql"].Charm = "cs:quantal/mysql-4"
+	},
+	errors: []string{`service "mysql" refers to non-existent charm "cs:quantal/mysql-4"`},
+}, {
+	about: "subordinate with units",
+	change: func(bd *charm.BundleData) {
+		bd.Services["logging"].NumUnits = 1
+	},
+	errors: []string{`service "logging" is subordinate but has units or constraints`},
+}, {
+	about: "bad options",
+	change: func(bd *charm.BundleData) {
+		bd.Services["wordpress"].Options = map[string]interface{}{
+			"blog-title": 42,
+			"unknown":    "x",
+		}
+	},
+	errors: []string{`cannot validate options of service "wordpress": .*`},
+}, {
+	about: "unknown relation",
+	change: func(bd *charm.BundleData) {
+		bd.Relations[0] = []string{"wordpress:database", "mysql"}
+	},
+	errors: []string{`charm "cs:quantal/wordpress" used by service "wordpress" does not define relation "database"`},
+}, {
+	about: "incompatible relation",
+	change: func(bd *charm.BundleData) {
+		bd.Relations[0] = []string{"wordpress:cache", "mysql"}
+	},
+	errors: []string{`no relations found for \["wordpress:cache" "mysql"\]`},
+}, {
+	about: "ambiguous relation",
+	change: func(bd *charm.BundleData) {
+		bd.Relations[1] = []string{"wordpress", "logging"}
+	},
+	errors: []string{`ambiguous relation \["wordpress" "logging"\]; specify the relation names`},
+}}
+
+func (*BundleDataSuite) TestVerifyWithCharmsErrors(c *gc.C) {
+	for i, test := range verifyWithCharmsErrorTests {
+		c.Logf("test %d: %s", i, test.about)
+		bd, err := charm.ReadBundleData(strings.NewReader(wordpressBundle))
+		c.Assert(err, gc.IsNil)
+		test.change(bd)
+		err = bd.VerifyWithCharms(verifyConstraints, bundleCharms())
+		assertVerifyErrors(c, err, test.errors)
+	}
+}
+
+func assertVerifyErrors(c *gc.C, err error, expect []string) {
+	c.Assert(err, gc.FitsTypeOf, (*charm.VerificationError)(nil))
+	errors := err.(*charm.VerificationError).Errors
+	c.Assert(errors, gc.HasLen, len(expect))
+	for i, err := range errors {
+		c.Check(err, gc.ErrorMatches, expect[i])
+	}
+}
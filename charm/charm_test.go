@@ -70,6 +70,7 @@ func checkDummy(c *gc.C, f charm.Charm, path string) {
 	c.Assert(f.Revision(), gc.Equals, 1)
 	c.Assert(f.Meta().Name, gc.Equals, "dummy")
 	c.Assert(f.Config().Options["title"].Default, gc.Equals, "My Title")
+	c.Assert(f.Meta().Actions["snapshot"].Params["outfile"].Default, gc.Equals, "foo.bz2")
 	switch f := f.(type) {
 	case *charm.Bundle:
 		c.Assert(f.Path, gc.Equals, path)
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
 	"strconv"
 
 	"launchpad.net/goyaml"
@@ -168,6 +169,32 @@ func (c *Config) FilterSettings(settings Settings) Settings {
 	return out
 }
 
+// MigrateSettings returns the settings from the supplied Settings that
+// are valid for this Config, converting a value of another type when
+// its string form parses as the option's type, as happens when a new
+// revision of a charm changes an option from int to string. It also
+// returns the sorted names of the settings that could not be kept.
+func (c *Config) MigrateSettings(settings Settings) (Settings, []string) {
+	out := make(Settings)
+	var lost []string
+	for name, value := range settings {
+		option, err := c.option(name)
+		if err != nil {
+			lost = append(lost, name)
+			continue
+		}
+		if valid, err := option.validate(name, value); err == nil {
+			out[name] = valid
+		} else if parsed, err := option.parse(name, fmt.Sprint(value)); err == nil {
+			out[name] = parsed
+		} else {
+			lost = append(lost, name)
+		}
+	}
+	sort.Strings(lost)
+	return out, lost
+}
+
 // ParseSettingsStrings returns settings derived from the supplied map. Every
 // value in the map must be parseable to the correct type for the option
 // identified by its key. Empty values are interpreted as nil.
@@ -116,6 +116,25 @@ func (s *ConfigSuite) TestFilterSettings(c *gc.C) {
 	})
 }
 
+func (s *ConfigSuite) TestMigrateSettings(c *gc.C) {
+	settings, lost := s.config.MigrateSettings(charm.Settings{
+		"title":              "something valid",
+		"username":           nil,
+		"unknown":            "whatever",
+		"outlook":            42,
+		"skill-level":        "7",
+		"agility-ratio":      true,
+		"reticulate-splines": "yes please",
+	})
+	c.Assert(settings, gc.DeepEquals, charm.Settings{
+		"title":       "something valid",
+		"username":    nil,
+		"outlook":     "42",
+		"skill-level": int64(7),
+	})
+	c.Assert(lost, gc.DeepEquals, []string{"agility-ratio", "reticulate-splines", "unknown"})
+}
+
 func (s *ConfigSuite) TestValidateSettings(c *gc.C) {
 	for i, test := range []struct {
 		info   string
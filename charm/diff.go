@@ -0,0 +1,95 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"sort"
+)
+
+// RetypedOption describes a config option declared by two charms with
+// different types.
+type RetypedOption struct {
+	Name    string
+	OldType string
+	NewType string
+}
+
+// Diff describes the differences between a charm and one that
+// replaces it which matter when a service is upgraded.
+type Diff struct {
+	AddedOptions   []string
+	RemovedOptions []string
+	RetypedOptions []RetypedOption
+
+	AddedRelations   []string
+	RemovedRelations []string
+	// ChangedRelations holds the names of the relations declared by
+	// both charms with a different role, interface or scope.
+	ChangedRelations []string
+}
+
+// NewDiff returns the differences between the charm with oldMeta and
+// oldConfig and the one with newMeta and newConfig. All names are
+// sorted.
+func NewDiff(oldMeta *Meta, oldConfig *Config, newMeta *Meta, newConfig *Config) *Diff {
+	d := &Diff{}
+	for name, option := range newConfig.Options {
+		if oldOption, ok := oldConfig.Options[name]; !ok {
+			d.AddedOptions = append(d.AddedOptions, name)
+		} else if oldOption.Type != option.Type {
+			d.RetypedOptions = append(d.RetypedOptions, RetypedOption{name, oldOption.Type, option.Type})
+		}
+	}
+	for name := range oldConfig.Options {
+		if _, ok := newConfig.Options[name]; !ok {
+			d.RemovedOptions = append(d.RemovedOptions, name)
+		}
+	}
+	oldRelations := allRelations(oldMeta)
+	newRelations := allRelations(newMeta)
+	for name, rel := range newRelations {
+		if oldRel, ok := oldRelations[name]; !ok {
+			d.AddedRelations = append(d.AddedRelations, name)
+		} else if oldRel.Role != rel.Role || oldRel.Interface != rel.Interface || oldRel.Scope != rel.Scope {
+			d.ChangedRelations = append(d.ChangedRelations, name)
+		}
+	}
+	for name := range oldRelations {
+		if _, ok := newRelations[name]; !ok {
+			d.RemovedRelations = append(d.RemovedRelations, name)
+		}
+	}
+	sort.Strings(d.AddedOptions)
+	sort.Strings(d.RemovedOptions)
+	sort.Sort(retypedOptions(d.RetypedOptions))
+	sort.Strings(d.AddedRelations)
+	sort.Strings(d.RemovedRelations)
+	sort.Strings(d.ChangedRelations)
+	return d
+}
+
+// Empty returns whether the charms have the same config options and
+// relations.
+func (d *Diff) Empty() bool {
+	return len(d.AddedOptions) == 0 && len(d.RemovedOptions) == 0 &&
+		len(d.RetypedOptions) == 0 && len(d.AddedRelations) == 0 &&
+		len(d.RemovedRelations) == 0 && len(d.ChangedRelations) == 0
+}
+
+// allRelations returns the relations declared by meta, keyed by name.
+func allRelations(meta *Meta) map[string]Relation {
+	relations := make(map[string]Relation)
+	for _, m := range []map[string]Relation{meta.Provides, meta.Requires, meta.Peers} {
+		for name, rel := range m {
+			relations[name] = rel
+		}
+	}
+	return relations
+}
+
+type retypedOptions []RetypedOption
+
+func (r retypedOptions) Len() int           { return len(r) }
+func (r retypedOptions) Less(i, j int) bool { return r[i].Name < r[j].Name }
+func (r retypedOptions) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
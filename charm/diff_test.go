@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+)
+
+type DiffSuite struct{}
+
+var _ = gc.Suite(&DiffSuite{})
+
+func readMetaConfig(c *gc.C, meta, config string) (*charm.Meta, *charm.Config) {
+	m, err := charm.ReadMeta(strings.NewReader(meta))
+	c.Assert(err, gc.IsNil)
+	cfg, err := charm.ReadConfig(strings.NewReader(config))
+	c.Assert(err, gc.IsNil)
+	return m, cfg
+}
+
+func (s *DiffSuite) TestNewDiff(c *gc.C) {
+	oldMeta, oldConfig := readMetaConfig(c, `
+name: mysql
+summary: none
+description: none
+provides:
+  server: mysql
+  monitoring: munin
+requires:
+  backup: storage
+peers:
+  cluster: mysql-ha
+`, `
+options:
+  port: {type: int, default: 3306, description: Port}
+  tuning: {type: string, default: safest, description: Tuning}
+  legacy: {type: boolean, default: false, description: Legacy}
+`)
+	newMeta, newConfig := readMetaConfig(c, `
+name: mysql
+summary: none
+description: none
+provides:
+  server: mysql
+  metrics: prometheus
+requires:
+  backup: s3
+peers:
+  cluster: mysql-ha
+`, `
+options:
+  port: {type: string, default: "3306", description: Port}
+  tuning: {type: string, default: safest, description: Tuning}
+  dataset-size: {type: string, default: 80%, description: Size}
+`)
+	diff := charm.NewDiff(oldMeta, oldConfig, newMeta, newConfig)
+	c.Assert(diff, gc.DeepEquals, &charm.Diff{
+		AddedOptions:     []string{"dataset-size"},
+		RemovedOptions:   []string{"legacy"},
+		RetypedOptions:   []charm.RetypedOption{{"port", "int", "string"}},
+		AddedRelations:   []string{"metrics"},
+		RemovedRelations: []string{"monitoring"},
+		ChangedRelations: []string{"backup"},
+	})
+	c.Assert(diff.Empty(), gc.Equals, false)
+
+	diff = charm.NewDiff(oldMeta, oldConfig, oldMeta, oldConfig)
+	c.Assert(diff.Empty(), gc.Equals, true)
+}
@@ -53,6 +53,30 @@ func ReadDir(path string) (dir *Dir, err error) {
 			return nil, err
 		}
 	}
+	file, err = os.Open(dir.join("actions.yaml"))
+	if _, ok := err.(*os.PathError); ok {
+		// No actions; that's fine.
+	} else if err != nil {
+		return nil, err
+	} else {
+		dir.meta.Actions, err = ReadActions(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	file, err = os.Open(dir.join("metrics.yaml"))
+	if _, ok := err.(*os.PathError); ok {
+		// No metrics; that's fine.
+	} else if err != nil {
+		return nil, err
+	} else {
+		dir.meta.Metrics, err = ReadMetrics(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
 	if file, err = os.Open(dir.join("revision")); err == nil {
 		_, err = fmt.Fscan(file, &dir.revision)
 		file.Close()
@@ -117,15 +141,16 @@ func (dir *Dir) SetDiskRevision(revision int) error {
 func (dir *Dir) BundleTo(w io.Writer) (err error) {
 	zipw := zip.NewWriter(w)
 	defer zipw.Close()
-	zp := zipPacker{zipw, dir.Path, dir.Meta().Hooks()}
+	zp := zipPacker{zipw, dir.Path, dir.Meta().Hooks(), dir.Meta().Actions}
 	zp.AddRevision(dir.revision)
 	return filepath.Walk(dir.Path, zp.WalkFunc())
 }
 
 type zipPacker struct {
 	*zip.Writer
-	root  string
-	hooks map[string]bool
+	root    string
+	hooks   map[string]bool
+	actions map[string]ActionSpec
 }
 
 func (zp *zipPacker) WalkFunc() filepath.WalkFunc {
@@ -193,6 +218,13 @@ func (zp *zipPacker) visit(path string, fi os.FileInfo, err error) error {
 			perm = perm | 0100
 		}
 	}
+	if filepath.Dir(relpath) == "actions" {
+		actionName := filepath.Base(relpath)
+		if _, ok := zp.actions[actionName]; !fi.IsDir() && ok && mode&0100 == 0 {
+			log.Warningf("charm: making %q executable in charm", path)
+			perm = perm | 0100
+		}
+	}
 	h.SetMode(mode&^0777 | perm)
 
 	w, err := zp.CreateHeader(h)
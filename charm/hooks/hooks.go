@@ -17,6 +17,16 @@ const (
 	UpgradeCharm  Kind = "upgrade-charm"
 	Stop          Kind = "stop"
 
+	// CollectMetrics is run periodically, for charms that declare
+	// metrics, so that units may report them with add-metric.
+	CollectMetrics Kind = "collect-metrics"
+
+	// LeaderElected is run when the unit becomes the leader of its
+	// service, and LeaderSettingsChanged is run on the other units of
+	// the service when the settings published by the leader change.
+	LeaderElected         Kind = "leader-elected"
+	LeaderSettingsChanged Kind = "leader-settings-changed"
+
 	// These hooks require an associated relation, and the name of the relation
 	// unit whose change triggered the hook. The hook file names that these
 	// kinds represent will be prefixed by the relation name; for example,
@@ -29,6 +39,11 @@ const (
 	// will be prefixed by the relation name, just like the other Relation* Kind
 	// values.
 	RelationBroken Kind = "relation-broken"
+
+	// ActionRequested is not a hook file name; it represents the execution
+	// of a charm action, whose name must be supplied separately, in the
+	// context of a hook.
+	ActionRequested Kind = "action-requested"
 )
 
 var unitHooks = []Kind{
@@ -37,6 +52,9 @@ var unitHooks = []Kind{
 	ConfigChanged,
 	UpgradeCharm,
 	Stop,
+	CollectMetrics,
+	LeaderElected,
+	LeaderSettingsChanged,
 }
 
 // UnitHooks returns all known unit hook kinds.
@@ -68,3 +86,8 @@ func (kind Kind) IsRelation() bool {
 	}
 	return false
 }
+
+// IsAction returns whether the Kind represents the execution of an action.
+func (kind Kind) IsAction() bool {
+	return kind == ActionRequested
+}
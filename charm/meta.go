@@ -9,11 +9,13 @@ import (
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"launchpad.net/goyaml"
 
 	"launchpad.net/juju-core/charm/hooks"
 	"launchpad.net/juju-core/schema"
+	"launchpad.net/juju-core/utils"
 )
 
 // RelationScope describes the scope of a relation.
@@ -89,6 +91,40 @@ func (r Relation) IsImplicit() bool {
 		r.Role == RoleProvider)
 }
 
+// StorageType defines the type of a store declared in the charm
+// metadata.yaml file.
+type StorageType string
+
+const (
+	// StorageFilesystem storage is mounted on a directory in the
+	// unit's machine.
+	StorageFilesystem StorageType = "filesystem"
+
+	// StorageBlock storage is presented to the unit as a block device.
+	StorageBlock StorageType = "block"
+)
+
+// Storage represents a single store defined in the charm
+// metadata.yaml file.
+type Storage struct {
+	Name        string
+	Description string
+	Type        StorageType
+
+	// Location is the absolute path at which filesystem storage is
+	// mounted. If empty, juju chooses a location. It must be empty
+	// for block storage.
+	Location string
+
+	// MinimumSize is the minimum size, in megabytes, of each
+	// storage instance.
+	MinimumSize uint64
+
+	// Count is the minimum number of instances of the store that
+	// each unit requires.
+	Count int
+}
+
 // Meta represents all the known content that may be defined
 // within a charm's metadata.yaml file.
 type Meta struct {
@@ -102,6 +138,20 @@ type Meta struct {
 	Format      int                 `bson:",omitempty"`
 	OldRevision int                 `bson:",omitempty"` // Obsolete
 	Categories  []string            `bson:",omitempty"`
+	Storage     map[string]Storage  `bson:",omitempty"`
+
+	// HookTimeout holds how long the charm's hooks may run before
+	// they are killed. If it is zero, the environment's default
+	// applies.
+	HookTimeout time.Duration `bson:",omitempty"`
+
+	// Actions holds the specifications of the actions the charm
+	// supports, as read from its actions.yaml file.
+	Actions map[string]ActionSpec `bson:",omitempty"`
+
+	// Metrics holds the specifications of the metrics the charm's
+	// units may report, as read from its metrics.yaml file.
+	Metrics map[string]MetricSpec `bson:",omitempty"`
 }
 
 func generateRelationHooks(relName string, allHooks map[string]bool) {
@@ -172,9 +222,18 @@ func ReadMeta(r io.Reader) (meta *Meta, err error) {
 	meta.Peers = parseRelations(m["peers"], RolePeer)
 	meta.Format = int(m["format"].(int64))
 	meta.Categories = parseCategories(m["categories"])
+	if meta.Storage, err = parseStorage(m["storage"]); err != nil {
+		return nil, err
+	}
 	if subordinate := m["subordinate"]; subordinate != nil {
 		meta.Subordinate = subordinate.(bool)
 	}
+	if timeout := m["hook-timeout"]; timeout != nil {
+		meta.HookTimeout, err = time.ParseDuration(timeout.(string))
+		if err != nil || meta.HookTimeout <= 0 {
+			return nil, fmt.Errorf("metadata: invalid hook-timeout %q", timeout)
+		}
+	}
 	if rev := m["revision"]; rev != nil {
 		// Obsolete
 		meta.OldRevision = int(m["revision"].(int64))
@@ -226,6 +285,30 @@ func (meta Meta) Check() error {
 		return err
 	}
 
+	for name, store := range meta.Storage {
+		if store.Name != name {
+			return fmt.Errorf("charm %q has mismatched storage name %q; expected %q", meta.Name, store.Name, name)
+		}
+		if reservedName(name) {
+			return fmt.Errorf("charm %q using a reserved storage name: %q", meta.Name, name)
+		}
+		switch store.Type {
+		case StorageFilesystem:
+			if store.Location != "" && !strings.HasPrefix(store.Location, "/") {
+				return fmt.Errorf("charm %q storage %q has relative location %q", meta.Name, name, store.Location)
+			}
+		case StorageBlock:
+			if store.Location != "" {
+				return fmt.Errorf("charm %q block storage %q may not specify a location", meta.Name, name)
+			}
+		default:
+			return fmt.Errorf("charm %q storage %q has unknown type %q", meta.Name, name, store.Type)
+		}
+		if store.Count < 1 {
+			return fmt.Errorf("charm %q storage %q must have a count of at least 1", meta.Name, name)
+		}
+	}
+
 	// Subordinate charms must have at least one relation that
 	// has container scope, otherwise they can't relate to the
 	// principal.
@@ -276,6 +359,40 @@ func parseRelations(relations interface{}, role RelationRole) map[string]Relatio
 	return result
 }
 
+func parseStorage(stores interface{}) (map[string]Storage, error) {
+	if stores == nil {
+		return nil, nil
+	}
+	result := make(map[string]Storage)
+	for name, store := range stores.(map[string]interface{}) {
+		storeMap := store.(map[string]interface{})
+		var minSize uint64
+		switch size := storeMap["minimum-size"].(type) {
+		case int64:
+			if size < 0 {
+				return nil, fmt.Errorf("metadata: storage.%s.minimum-size: must not be negative", name)
+			}
+			minSize = uint64(size)
+		case string:
+			var err error
+			if minSize, err = utils.ParseSize(size); err != nil {
+				return nil, fmt.Errorf("metadata: storage.%s.minimum-size: %v", name, err)
+			}
+		}
+		result[name] = Storage{
+			Name:        name,
+			Description: storeMap["description"].(string),
+			Type:        StorageType(storeMap["type"].(string)),
+			Location:    storeMap["location"].(string),
+			MinimumSize: minSize,
+			// Schema decodes as int64, but the int range is
+			// more than enough.
+			Count: int(storeMap["count"].(int64)),
+		}
+	}
+	return result, nil
+}
+
 // Schema coercer that expands the interface shorthand notation.
 // A consistent format is easier to work with than considering the
 // potential difference everywhere.
@@ -345,26 +462,46 @@ var ifaceSchema = schema.FieldMap(
 	},
 )
 
+var storageSchema = schema.FieldMap(
+	schema.Fields{
+		"type":         schema.OneOf(schema.Const(string(StorageFilesystem)), schema.Const(string(StorageBlock))),
+		"description":  schema.String(),
+		"location":     schema.String(),
+		"minimum-size": schema.OneOf(schema.String(), schema.Int()),
+		"count":        schema.Int(),
+	},
+	schema.Defaults{
+		"description":  "",
+		"location":     "",
+		"minimum-size": "",
+		"count":        int64(1),
+	},
+)
+
 var charmSchema = schema.FieldMap(
 	schema.Fields{
-		"name":        schema.String(),
-		"summary":     schema.String(),
-		"description": schema.String(),
-		"peers":       schema.StringMap(ifaceExpander(int64(1))),
-		"provides":    schema.StringMap(ifaceExpander(nil)),
-		"requires":    schema.StringMap(ifaceExpander(int64(1))),
-		"revision":    schema.Int(), // Obsolete
-		"format":      schema.Int(),
-		"subordinate": schema.Bool(),
-		"categories":  schema.List(schema.String()),
+		"name":         schema.String(),
+		"summary":      schema.String(),
+		"description":  schema.String(),
+		"peers":        schema.StringMap(ifaceExpander(int64(1))),
+		"provides":     schema.StringMap(ifaceExpander(nil)),
+		"requires":     schema.StringMap(ifaceExpander(int64(1))),
+		"revision":     schema.Int(), // Obsolete
+		"format":       schema.Int(),
+		"subordinate":  schema.Bool(),
+		"categories":   schema.List(schema.String()),
+		"storage":      schema.StringMap(storageSchema),
+		"hook-timeout": schema.String(),
 	},
 	schema.Defaults{
-		"provides":    schema.Omit,
-		"requires":    schema.Omit,
-		"peers":       schema.Omit,
-		"revision":    schema.Omit,
-		"format":      1,
-		"subordinate": schema.Omit,
-		"categories":  schema.Omit,
+		"provides":     schema.Omit,
+		"requires":     schema.Omit,
+		"peers":        schema.Omit,
+		"revision":     schema.Omit,
+		"format":       1,
+		"subordinate":  schema.Omit,
+		"categories":   schema.Omit,
+		"storage":      schema.Omit,
+		"hook-timeout": schema.Omit,
 	},
 )
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -239,6 +240,92 @@ requires:
   innocuous: juju-info`, "")
 }
 
+func (s *MetaSuite) TestParseMetaStorage(c *gc.C) {
+	meta, err := charm.ReadMeta(strings.NewReader(`
+name: a
+summary: b
+description: c
+storage:
+  data:
+    type: filesystem
+    description: the database files
+    location: /srv/data
+    minimum-size: 10G
+  scratch:
+    type: block
+    minimum-size: 512
+    count: 2
+`))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.Storage, gc.DeepEquals, map[string]charm.Storage{
+		"data": {
+			Name:        "data",
+			Description: "the database files",
+			Type:        charm.StorageFilesystem,
+			Location:    "/srv/data",
+			MinimumSize: 10240,
+			Count:       1,
+		},
+		"scratch": {
+			Name:        "scratch",
+			Type:        charm.StorageBlock,
+			MinimumSize: 512,
+			Count:       2,
+		},
+	})
+}
+
+var storageConstraintsTests = []struct {
+	storage, err string
+}{{
+	"storage:\n  data:\n    type: tape",
+	`metadata: storage.data.type: unexpected value "tape"`,
+}, {
+	"storage:\n  data:\n    type: filesystem\n    minimum-size: 10K",
+	`metadata: storage.data.minimum-size: must be a non-negative float with optional M/G/T/P suffix`,
+}, {
+	"storage:\n  data:\n    type: filesystem\n    minimum-size: -1",
+	`metadata: storage.data.minimum-size: must not be negative`,
+}, {
+	"storage:\n  data:\n    type: filesystem\n    location: srv/data",
+	`charm "a" storage "data" has relative location "srv/data"`,
+}, {
+	"storage:\n  data:\n    type: block\n    location: /srv/data",
+	`charm "a" block storage "data" may not specify a location`,
+}, {
+	"storage:\n  data:\n    type: block\n    count: 0",
+	`charm "a" storage "data" must have a count of at least 1`,
+}, {
+	"storage:\n  juju-data:\n    type: block",
+	`charm "a" using a reserved storage name: "juju-data"`,
+}}
+
+func (s *MetaSuite) TestStorageConstraints(c *gc.C) {
+	prefix := "name: a\nsummary: b\ndescription: c\n"
+	for i, t := range storageConstraintsTests {
+		c.Logf("test %d", i)
+		meta, err := charm.ReadMeta(strings.NewReader(prefix + t.storage))
+		c.Check(err, gc.ErrorMatches, t.err)
+		c.Check(meta, gc.IsNil)
+	}
+}
+
+func (s *MetaSuite) TestParseHookTimeout(c *gc.C) {
+	prefix := "name: a\nsummary: b\ndescription: c\n"
+	meta, err := charm.ReadMeta(strings.NewReader(prefix))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.HookTimeout, gc.Equals, time.Duration(0))
+
+	meta, err = charm.ReadMeta(strings.NewReader(prefix + "hook-timeout: 1h30m\n"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(meta.HookTimeout, gc.Equals, 90*time.Minute)
+
+	for _, timeout := range []string{"forever", "0s", "-5m"} {
+		_, err = charm.ReadMeta(strings.NewReader(prefix + "hook-timeout: " + timeout + "\n"))
+		c.Check(err, gc.ErrorMatches, `metadata: invalid hook-timeout "`+timeout+`"`)
+	}
+}
+
 func (s *MetaSuite) TestCheckMismatchedRelationName(c *gc.C) {
 	// This  Check case cannot be covered by the above
 	// TestRelationsConstraints tests.
@@ -336,6 +423,9 @@ func (s *MetaSuite) TestMetaHooks(c *gc.C) {
 		"config-changed":                    true,
 		"upgrade-charm":                     true,
 		"stop":                              true,
+		"collect-metrics":                   true,
+		"leader-elected":                    true,
+		"leader-settings-changed":           true,
 		"cache-relation-joined":             true,
 		"cache-relation-changed":            true,
 		"cache-relation-departed":           true,
@@ -406,6 +496,15 @@ func (s *MetaSuite) TestCodecRoundTrip(c *gc.C) {
 		Categories:  []string{"quxxxx", "quxxxxx"},
 		Format:      10,
 		OldRevision: 11,
+		Storage: map[string]charm.Storage{
+			"qux": {
+				Name:        "qux",
+				Type:        charm.StorageFilesystem,
+				Location:    "/srv/qux",
+				MinimumSize: 1024,
+				Count:       2,
+			},
+		},
 	}
 	for i, codec := range codecs {
 		c.Logf("codec %d", i)
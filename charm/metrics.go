@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/schema"
+)
+
+// MetricType enumerates the kinds of metric a charm may declare.
+type MetricType string
+
+const (
+	// MetricTypeGauge metrics may take any value.
+	MetricTypeGauge MetricType = "gauge"
+
+	// MetricTypeAbsolute metrics may not be negative.
+	MetricTypeAbsolute MetricType = "absolute"
+)
+
+// MetricSpec represents a single metric that may be reported by the
+// units of a charm, as declared in its metrics.yaml file. Metrics are
+// gauges unless declared otherwise.
+type MetricSpec struct {
+	Type        MetricType
+	Description string `bson:",omitempty"`
+}
+
+var validMetricName = regexp.MustCompile("^[a-z][a-z0-9._-]*$")
+
+// IsValidMetricName returns whether name is a valid metric name.
+func IsValidMetricName(name string) bool {
+	return validMetricName.MatchString(name)
+}
+
+// ReadMetrics reads the content of a metrics.yaml file and returns
+// the metric specifications it declares, indexed by metric name.
+func ReadMetrics(r io.Reader) (map[string]MetricSpec, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[interface{}]interface{})
+	if err := goyaml.Unmarshal(data, raw); err != nil {
+		return nil, err
+	}
+	v, err := metricsSchema.Coerce(raw, nil)
+	if err != nil {
+		return nil, errors.New("metrics: " + err.Error())
+	}
+	metrics := make(map[string]MetricSpec)
+	rawMetrics, _ := v.(map[string]interface{})["metrics"].(map[string]interface{})
+	for name, rawSpec := range rawMetrics {
+		if !IsValidMetricName(name) {
+			return nil, fmt.Errorf("metrics: invalid metric name %q", name)
+		}
+		specMap := rawSpec.(map[string]interface{})
+		spec := MetricSpec{Type: MetricType(specMap["type"].(string))}
+		if description := specMap["description"]; description != nil {
+			spec.Description = description.(string)
+		}
+		metrics[name] = spec
+	}
+	return metrics, nil
+}
+
+// ValidateValue returns an error if value may not be reported for a
+// metric with the spec.
+func (spec MetricSpec) ValidateValue(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("value %v is not finite", value)
+	}
+	if spec.Type == MetricTypeAbsolute && value < 0 {
+		return fmt.Errorf("value %v is negative", value)
+	}
+	return nil
+}
+
+var metricsSchema = schema.StrictFieldMap(
+	schema.Fields{
+		"metrics": schema.StringMap(schema.FieldMap(
+			schema.Fields{
+				"type":        schema.OneOf(schema.Const(string(MetricTypeGauge)), schema.Const(string(MetricTypeAbsolute))),
+				"description": schema.String(),
+			},
+			schema.Defaults{
+				"type":        string(MetricTypeGauge),
+				"description": schema.Omit,
+			},
+		)),
+	},
+	schema.Defaults{
+		"metrics": schema.Omit,
+	},
+)
@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package charm_test
+
+import (
+	"bytes"
+	"math"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/testing"
+)
+
+type MetricsSuite struct{}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func readMetrics(c *gc.C, yaml string) (map[string]charm.MetricSpec, error) {
+	return charm.ReadMetrics(bytes.NewBuffer([]byte(yaml)))
+}
+
+func (s *MetricsSuite) TestReadMetrics(c *gc.C) {
+	metrics, err := readMetrics(c, `
+metrics:
+  requests:
+    type: gauge
+    description: Requests per second served.
+  queue.depth:
+    type: absolute
+  latency:
+    description: Average response time in seconds.
+`)
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.DeepEquals, map[string]charm.MetricSpec{
+		"requests": {
+			Type:        charm.MetricTypeGauge,
+			Description: "Requests per second served.",
+		},
+		"queue.depth": {
+			Type: charm.MetricTypeAbsolute,
+		},
+		"latency": {
+			Type:        charm.MetricTypeGauge,
+			Description: "Average response time in seconds.",
+		},
+	})
+
+	metrics, err = readMetrics(c, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.HasLen, 0)
+}
+
+var readMetricsErrorTests = []struct {
+	yaml string
+	err  string
+}{{
+	yaml: "metrics:\n  requests:\n    type: counter\n",
+	err:  `metrics: metrics.requests.type: unexpected value "counter"`,
+}, {
+	yaml: "metrics:\n  Requests:\n    type: gauge\n",
+	err:  `metrics: invalid metric name "Requests"`,
+}, {
+	yaml: "requests:\n  type: gauge\n",
+	err:  `metrics: requests: expected nothing, got .*`,
+}}
+
+func (s *MetricsSuite) TestReadMetricsErrors(c *gc.C) {
+	for i, t := range readMetricsErrorTests {
+		c.Logf("test %d", i)
+		_, err := readMetrics(c, t.yaml)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *MetricsSuite) TestValidateValue(c *gc.C) {
+	gauge := charm.MetricSpec{Type: charm.MetricTypeGauge}
+	absolute := charm.MetricSpec{Type: charm.MetricTypeAbsolute}
+	c.Assert(gauge.ValidateValue(-1.5), gc.IsNil)
+	c.Assert(absolute.ValidateValue(0), gc.IsNil)
+	c.Assert(absolute.ValidateValue(-1.5), gc.ErrorMatches, "value -1.5 is negative")
+	c.Assert(gauge.ValidateValue(math.NaN()), gc.ErrorMatches, "value NaN is not finite")
+	c.Assert(gauge.ValidateValue(math.Inf(1)), gc.ErrorMatches, `value \+Inf is not finite`)
+}
+
+func (s *MetricsSuite) TestReadCharmMetrics(c *gc.C) {
+	expected := map[string]charm.MetricSpec{
+		"requests": {
+			Type:        charm.MetricTypeGauge,
+			Description: "Requests per second served.",
+		},
+		"connections": {
+			Type: charm.MetricTypeAbsolute,
+		},
+	}
+	dir := testing.Charms.Dir("metered")
+	c.Assert(dir.Meta().Metrics, gc.DeepEquals, expected)
+	bundle := testing.Charms.Bundle(c.MkDir(), "metered")
+	c.Assert(bundle.Meta().Metrics, gc.DeepEquals, expected)
+}
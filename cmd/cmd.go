@@ -27,6 +27,22 @@ func init() {
 // code 1 without producing error output.
 var ErrSilent = errors.New("cmd: error out silently")
 
+// RcPassthroughError can be returned from Run to signal that Main should
+// exit with the given code without producing error output.
+type RcPassthroughError struct {
+	Code int
+}
+
+func (e *RcPassthroughError) Error() string {
+	return fmt.Sprintf("subprocess encountered error code %d", e.Code)
+}
+
+// NewRcPassthroughError returns an error that causes Main to exit with
+// the given code.
+func NewRcPassthroughError(code int) error {
+	return &RcPassthroughError{code}
+}
+
 // Command is implemented by types that interpret command-line arguments.
 type Command interface {
 	// Info returns information about the Command.
@@ -165,6 +181,9 @@ func Main(c Command, ctx *Context, args []string) int {
 		return rc
 	}
 	if err := c.Run(ctx); err != nil {
+		if err, ok := err.(*RcPassthroughError); ok {
+			return err.Code
+		}
 		if err != ErrSilent {
 			fmt.Fprintf(ctx.Stderr, "error: %v\n", err)
 		}
@@ -86,6 +86,14 @@ func (s *CmdSuite) TestMainRunSilentError(c *gc.C) {
 	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
 }
 
+func (s *CmdSuite) TestMainRunPassthroughError(c *gc.C) {
+	ctx := testing.Context(c)
+	result := cmd.Main(&TestCommand{Name: "verb"}, ctx, []string{"--option", "passthrough-error"})
+	c.Assert(result, gc.Equals, 42)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "")
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+}
+
 func (s *CmdSuite) TestMainSuccess(c *gc.C) {
 	ctx := testing.Context(c)
 	result := cmd.Main(&TestCommand{Name: "verb"}, ctx, []string{"--option", "success!"})
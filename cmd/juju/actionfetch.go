@@ -0,0 +1,80 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+)
+
+// ActionFetchCommand retrieves the progress and results of a queued action.
+type ActionFetchCommand struct {
+	cmd.EnvCommandBase
+	ActionId string
+	out      cmd.Output
+}
+
+func (c *ActionFetchCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "action-fetch",
+		Args:    "<action id>",
+		Purpose: "show the status and results of a queued action",
+	}
+}
+
+func (c *ActionFetchCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *ActionFetchCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no action id specified")
+	}
+	c.ActionId = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// Run fetches the action and formats its details.
+func (c *ActionFetchCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	result, err := client.FetchAction(c.ActionId)
+	if err != nil {
+		return err
+	}
+	out := map[string]interface{}{
+		"id":       result.ActionId,
+		"unit":     result.UnitName,
+		"action":   result.ActionName,
+		"status":   result.Status,
+		"enqueued": result.Enqueued.Format(time.RFC3339),
+	}
+	if len(result.Params) > 0 {
+		out["params"] = result.Params
+	}
+	if !result.Started.IsZero() {
+		out["started"] = result.Started.Format(time.RFC3339)
+	}
+	if !result.Completed.IsZero() {
+		out["completed"] = result.Completed.Format(time.RFC3339)
+		out["results"] = result.Results
+	}
+	if result.Message != "" {
+		out["message"] = result.Message
+	}
+	return c.out.Write(ctx, out)
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+)
+
+type ActionFetchSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&ActionFetchSuite{})
+
+func (s *ActionFetchSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, &ActionFetchCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no action id specified")
+	_, err = testing.RunCommand(c, &ActionFetchCommand{}, []string{"1", "2"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["2"\]`)
+}
+
+func (s *ActionFetchSuite) TestActionFetch(c *gc.C) {
+	testing.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "dummy")
+	c.Assert(err, gc.IsNil)
+	unit, err := s.State.Unit("dummy/0")
+	c.Assert(err, gc.IsNil)
+	action, err := unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	_, err = testing.RunCommand(c, &ActionFetchCommand{}, []string{"42"})
+	c.Assert(err, gc.ErrorMatches, `action "42" not found`)
+
+	fetch := func() map[string]interface{} {
+		ctx, err := testing.RunCommand(c, &ActionFetchCommand{}, []string{action.Id()})
+		c.Assert(err, gc.IsNil)
+		out := make(map[string]interface{})
+		err = goyaml.Unmarshal([]byte(testing.Stdout(ctx)), &out)
+		c.Assert(err, gc.IsNil)
+		return out
+	}
+	out := fetch()
+	c.Assert(out["id"], gc.Equals, action.Id())
+	c.Assert(out["unit"], gc.Equals, "dummy/0")
+	c.Assert(out["action"], gc.Equals, "snapshot")
+	c.Assert(out["status"], gc.Equals, "pending")
+	c.Assert(out["params"], gc.DeepEquals, map[interface{}]interface{}{"outfile": "foo.bz2"})
+	c.Assert(out["results"], gc.IsNil)
+
+	err = action.Finish(params.ActionFailed, map[string]interface{}{"written": "0"}, "disk full")
+	c.Assert(err, gc.IsNil)
+	out = fetch()
+	c.Assert(out["status"], gc.Equals, "failed")
+	c.Assert(out["message"], gc.Equals, "disk full")
+	c.Assert(out["results"], gc.DeepEquals, map[interface{}]interface{}{"written": "0"})
+}
@@ -31,10 +31,11 @@ To add a container to an existing machine, use the <container>:<machinenumber>
 format.
 
 When adding a new machine, you may specify constraints for the machine to be
-provisioned.  Constraints cannot be combined with deploying a container to an
-existing machine.
+provisioned.  Constraints cannot be combined with deploying an lxc container to
+an existing machine.
 
-Currently, the only supported container type is lxc.
+The supported container types are lxc and kvm.  The mem, cpu-cores and
+root-disk constraints set the size of a kvm container's virtual machine.
 
 Machines are created in a clean state and ready to have units deployed.
 
@@ -46,6 +47,8 @@ Examples:
    juju add-machine                      (starts a new machine)
    juju add-machine lxc                  (starts a new machine with an lxc container)
    juju add-machine lxc:4                (starts a new lxc container on machine 4)
+   juju add-machine kvm:4 --constraints mem=2G
+                                         (starts a new kvm container with 2GB RAM on machine 4)
    juju add-machine --constraints mem=8G (starts a machine with at least 8GB RAM)
 
 See Also:
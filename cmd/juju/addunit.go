@@ -10,6 +10,8 @@ import (
 	"launchpad.net/gnuflag"
 
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju"
 )
 
@@ -22,7 +24,7 @@ type UnitCommandBase struct {
 
 func (c *UnitCommandBase) SetFlags(f *gnuflag.FlagSet) {
 	f.IntVar(&c.NumUnits, "num-units", 1, "")
-	f.StringVar(&c.ToMachineSpec, "to", "", "the machine or container to deploy the unit in, bypasses constraints")
+	f.StringVar(&c.ToMachineSpec, "to", "", "a comma-separated list of placement directives for the units, bypasses constraints")
 }
 
 func (c *UnitCommandBase) Init(args []string) error {
@@ -30,11 +32,12 @@ func (c *UnitCommandBase) Init(args []string) error {
 		return errors.New("--num-units must be a positive integer")
 	}
 	if c.ToMachineSpec != "" {
-		if c.NumUnits > 1 {
-			return errors.New("cannot use --num-units > 1 with --to")
+		placements, err := instance.ParsePlacements(c.ToMachineSpec)
+		if err != nil {
+			return fmt.Errorf("invalid --to parameter %q: %v", c.ToMachineSpec, err)
 		}
-		if !cmd.IsMachineOrNewContainer(c.ToMachineSpec) {
-			return fmt.Errorf("invalid --to parameter %q", c.ToMachineSpec)
+		if len(placements) > c.NumUnits {
+			return fmt.Errorf("cannot use %d placement directives with --num-units %d", len(placements), c.NumUnits)
 		}
 	}
 	return nil
@@ -45,6 +48,7 @@ type AddUnitCommand struct {
 	cmd.EnvCommandBase
 	UnitCommandBase
 	ServiceName string
+	Storage     map[string]constraints.Storage
 }
 
 const addUnitDoc = `
@@ -53,14 +57,27 @@ deploying more instances of a service.  Add-unit must be called on services that
 have already been deployed via juju deploy.  
 
 By default, services are deployed to newly provisioned machines.  Alternatively,
-service units can be added to a specific existing machine using the --to
-argument.
+service units can be placed using the --to argument, which takes a
+comma-separated list of placement directives, one for each of the first units
+added. A directive may name an existing machine or container, a new container
+on an existing or new machine, or be passed to the provider, which may
+understand directives such as zone=<availability zone> or the name of a MAAS
+node.
+
+Storage constraints for the charm's stores can be given with --storage, in
+the form <store>=<size>[,<count>]. They are recorded against the service, so
+they also apply to units added later.
 
 Examples:
  juju add-unit mysql -n 5          (Add 5 mysql units on 5 new machines)
  juju add-unit mysql --to 23       (Add a mysql unit to machine 23)
  juju add-unit mysql --to 24/lxc/3 (Add unit to lxc container 3 on host machine 24)
  juju add-unit mysql --to lxc:25   (Add unit to a new lxc container on host machine 25)
+ juju add-unit mysql --to lxc      (Add unit to a new lxc container on a new machine)
+ juju add-unit mysql -n 2 --to zone=us-east-1a,zone=us-east-1b
+                                   (Add 2 units to new machines in different zones)
+ juju add-unit mysql --to node7.maas (Add unit to the MAAS node named node7.maas)
+ juju add-unit mysql --storage data=20G (Add a unit with a 20 GB "data" store)
 `
 
 func (c *AddUnitCommand) Info() *cmd.Info {
@@ -76,6 +93,7 @@ func (c *AddUnitCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.EnvCommandBase.SetFlags(f)
 	c.UnitCommandBase.SetFlags(f)
 	f.IntVar(&c.NumUnits, "n", 1, "number of service units to add")
+	f.Var(constraints.StorageValue{&c.Storage}, "storage", "set storage constraints for a charm store")
 }
 
 func (c *AddUnitCommand) Init(args []string) error {
@@ -100,6 +118,6 @@ func (c *AddUnitCommand) Run(_ *cmd.Context) error {
 	}
 	defer apiclient.Close()
 
-	_, err = apiclient.AddServiceUnits(c.ServiceName, c.NumUnits, c.ToMachineSpec)
+	_, err = apiclient.AddServiceUnitsWithStorage(c.ServiceName, c.NumUnits, c.ToMachineSpec, c.Storage)
 	return err
 }
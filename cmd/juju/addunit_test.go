@@ -7,6 +7,7 @@ import (
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/instance"
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
@@ -27,11 +28,17 @@ var initAddUnitErrorTests = []struct {
 		args: []string{"some-service-name", "-n", "0"},
 		err:  `--num-units must be a positive integer`,
 	}, {
-		args: []string{"some-service-name", "--to", "bigglesplop"},
-		err:  `invalid --to parameter "bigglesplop"`,
+		args: []string{"some-service-name", "--to", "lxc:bigglesplop"},
+		err:  `invalid --to parameter "lxc:bigglesplop": invalid placement directive "lxc:bigglesplop": invalid machine id "bigglesplop"`,
 	}, {
-		args: []string{"some-service-name", "-n", "2", "--to", "123"},
-		err:  `cannot use --num-units > 1 with --to`,
+		args: []string{"some-service-name", "--to", "1,,2"},
+		err:  `invalid --to parameter "1,,2": invalid placement directive ""`,
+	}, {
+		args: []string{"some-service-name", "-n", "2", "--to", "123,lxc:123,zone=a"},
+		err:  `cannot use 3 placement directives with --num-units 2`,
+	}, {
+		args: []string{"some-service-name", "--storage", "data=big"},
+		err:  `invalid value "data=big" for flag --storage: bad storage size "big": .*`,
 	},
 }
 
@@ -69,6 +76,29 @@ func (s *AddUnitSuite) TestAddUnit(c *gc.C) {
 	s.AssertService(c, "some-service-name", curl, 4, 0)
 }
 
+func (s *AddUnitSuite) TestAddUnitWithStorage(c *gc.C) {
+	testing.Charms.BundlePath(s.SeriesPath, "storage-filesystem")
+	err := runDeploy(c, "local:storage-filesystem", "storage")
+	c.Assert(err, gc.IsNil)
+
+	err = runAddUnit(c, "storage", "--storage", "data=4G")
+	c.Assert(err, gc.IsNil)
+	svc, err := s.State.Service("storage")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.StorageConstraints(), gc.DeepEquals, map[string]constraints.Storage{
+		"data": {Size: 4096},
+	})
+	units, err := svc.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 2)
+	for i, expectSize := range []uint64{1024, 4096} {
+		stores, err := units[i].StorageInstances()
+		c.Assert(err, gc.IsNil)
+		c.Assert(stores, gc.HasLen, 1)
+		c.Check(stores[0].Size(), gc.Equals, expectSize)
+	}
+}
+
 // assertForceMachine ensures that the result of assigning a unit with --to
 // is as expected.
 func (s *AddUnitSuite) assertForceMachine(c *gc.C, svc *state.Service, expectedNumMachines, unitNum int, machineId string) {
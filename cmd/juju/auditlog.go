@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// AuditLogCommand shows the changes made to the environment through
+// the API.
+type AuditLogCommand struct {
+	cmd.EnvCommandBase
+	out    cmd.Output
+	since  string
+	until  string
+	user   string
+	limit  int
+	filter params.AuditLogFilter
+}
+
+const auditLogDoc = `
+Show the changes requested through the API, oldest first, with the user
+that requested each, the address the request came from, its arguments,
+and its outcome. Secret values, such as passwords and provider
+credentials, are masked.
+
+The --since and --until options take either a time in RFC3339 format,
+such as "2014-03-01T12:00:00Z", or a duration, such as "2h", which
+selects the time that long ago.
+
+Only environment administrators can view the audit log.
+`
+
+func (c *AuditLogCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "audit-log",
+		Purpose: "show the changes made to the environment",
+		Doc:     auditLogDoc,
+	}
+}
+
+func (c *AuditLogCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.StringVar(&c.since, "since", "", "only show changes made since this time")
+	f.StringVar(&c.until, "until", "", "only show changes made before this time")
+	f.StringVar(&c.user, "user", "", "only show changes made by this user")
+	f.IntVar(&c.limit, "n", 50, "show at most this many of the most recent changes, or all if 0")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"tabular": cmd.FormatTabular,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+	})
+}
+
+// auditLogNow returns the current time. It is patched in tests.
+var auditLogNow = time.Now
+
+// parseAuditTime parses a time given as either an RFC3339 time or a
+// duration before now.
+func parseAuditTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return auditLogNow().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
+
+func (c *AuditLogCommand) Init(args []string) error {
+	var err error
+	if c.since != "" {
+		if c.filter.Since, err = parseAuditTime(c.since); err != nil {
+			return err
+		}
+	}
+	if c.until != "" {
+		if c.filter.Until, err = parseAuditTime(c.until); err != nil {
+			return err
+		}
+	}
+	if c.user != "" {
+		if !names.IsUser(c.user) {
+			return fmt.Errorf("invalid user name %q", c.user)
+		}
+		c.filter.Tag = "user-" + c.user
+	}
+	if c.limit < 0 {
+		return fmt.Errorf("invalid number of changes %d", c.limit)
+	}
+	c.filter.Limit = c.limit
+	return cmd.CheckEmpty(args)
+}
+
+type auditLogEntry struct {
+	Time      string `json:"time" yaml:"time"`
+	User      string `json:"user" yaml:"user"`
+	Address   string `json:"address" yaml:"address"`
+	Method    string `json:"method" yaml:"method"`
+	Args      string `json:"args" yaml:"args"`
+	Result    string `json:"result,omitempty" yaml:"result,omitempty"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+	ErrorCode string `json:"error-code,omitempty" yaml:"error-code,omitempty"`
+}
+
+// auditLog is the formatted audit log, oldest first.
+type auditLog []auditLogEntry
+
+func (l auditLog) Tabulate() []cmd.TableSection {
+	section := cmd.TableSection{
+		Headings: []string{"TIME", "USER", "ADDRESS", "METHOD", "ARGS", "OUTCOME"},
+	}
+	for _, entry := range l {
+		outcome := "ok"
+		if entry.Error != "" {
+			outcome = "error: " + entry.Error
+		}
+		section.Rows = append(section.Rows, []string{
+			entry.Time, entry.User, entry.Address, entry.Method, entry.Args, outcome,
+		})
+	}
+	return []cmd.TableSection{section}
+}
+
+func (c *AuditLogCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	entries, err := client.AuditLog(c.filter)
+	if err != nil {
+		return err
+	}
+	log := make(auditLog, len(entries))
+	for i, entry := range entries {
+		log[i] = auditLogEntry{
+			Time:      entry.Time.UTC().Format(time.RFC3339),
+			User:      strings.TrimPrefix(entry.Tag, "user-"),
+			Address:   entry.RemoteAddress,
+			Method:    entry.Method,
+			Args:      entry.Args,
+			Result:    entry.Result,
+			Error:     entry.Error,
+			ErrorCode: entry.ErrorCode,
+		}
+	}
+	return c.out.Write(ctx, log)
+}
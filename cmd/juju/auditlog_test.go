@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type AuditLogSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&AuditLogSuite{})
+
+var auditLogInitTests = []struct {
+	args   []string
+	err    string
+	filter params.AuditLogFilter
+}{{
+	filter: params.AuditLogFilter{Limit: 50},
+}, {
+	args: []string{"--since", "2h", "--until", "2014-03-01T12:00:00Z", "--user", "bob", "-n", "0"},
+	filter: params.AuditLogFilter{
+		Since: time.Date(2014, 3, 1, 10, 0, 0, 0, time.UTC),
+		Until: time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC),
+		Tag:   "user-bob",
+	},
+}, {
+	args: []string{"--since", "yesterday"},
+	err:  `invalid time "yesterday"`,
+}, {
+	args: []string{"--user", "bob/0"},
+	err:  `invalid user name "bob/0"`,
+}, {
+	args: []string{"-n", "-1"},
+	err:  "invalid number of changes -1",
+}, {
+	args: []string{"bob"},
+	err:  `unrecognized args: \["bob"\]`,
+}}
+
+func (s *AuditLogSuite) TestInit(c *gc.C) {
+	s.PatchValue(&auditLogNow, func() time.Time {
+		return time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	})
+	for i, test := range auditLogInitTests {
+		c.Logf("test %d: %v", i, test.args)
+		command := &AuditLogCommand{}
+		err := coretesting.InitCommand(command, test.args)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(command.filter.Since.Equal(test.filter.Since), gc.Equals, true)
+		c.Check(command.filter.Until.Equal(test.filter.Until), gc.Equals, true)
+		c.Check(command.filter.Tag, gc.Equals, test.filter.Tag)
+		c.Check(command.filter.Limit, gc.Equals, test.filter.Limit)
+	}
+}
+
+func (s *AuditLogSuite) TestAuditLog(c *gc.C) {
+	err := s.APIState.Client().EnvironmentSet(map[string]interface{}{
+		"default-series": "precise",
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().ServiceExpose("unknown")
+	c.Assert(err, gc.NotNil)
+
+	ctx, err := coretesting.RunCommand(c, &AuditLogCommand{}, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, ""+
+		"TIME +USER +ADDRESS +METHOD +ARGS +OUTCOME\n"+
+		`\S+Z +admin +\S+ +Client.EnvironmentSet +{"Config":{"default-series":"precise"}} +ok\n`+
+		`\S+Z +admin +\S+ +Client.ServiceExpose +{"ServiceName":"unknown"} +error: service "unknown" not found\n`)
+
+	ctx, err = coretesting.RunCommand(c, &AuditLogCommand{}, []string{"-n", "1", "--format", "yaml"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, ""+
+		`- time: \S+Z\n`+
+		`  user: admin\n`+
+		`  address: \S+\n`+
+		`  method: Client.ServiceExpose\n`+
+		`  args: '{"ServiceName":"unknown"}'\n`+
+		`  error: service "unknown" not found\n`+
+		`  error-code: not found\n`)
+}
@@ -0,0 +1,140 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/backup"
+	"launchpad.net/juju-core/utils/ssh"
+)
+
+// BackupCommand takes a backup of the state of an environment.
+type BackupCommand struct {
+	SSHCommon
+	Filename string
+}
+
+const backupDoc = `
+Backup captures everything needed to rebuild the environment's state server
+into a single archive on the local machine: a dump of the state database, the
+state server's agent configuration and certificates, and a manifest that
+describes the archive. The state server is briefly stopped while its database
+is dumped.
+
+If no filename is given, the archive is written to
+juju-backup-<environment>-<date>.tgz in the current directory.
+
+See Also:
+   juju help restore
+`
+
+func (c *BackupCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "backup",
+		Args:    "[<filename>]",
+		Purpose: "take a backup of the environment's state server",
+		Doc:     backupDoc,
+	}
+}
+
+func (c *BackupCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.Filename, args = args[0], args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// AllowInterspersedFlags is true for backup, which passes no arguments
+// through to ssh.
+func (c *BackupCommand) AllowInterspersedFlags() bool {
+	return true
+}
+
+func (c *BackupCommand) Run(ctx *cmd.Context) (err error) {
+	client, err := c.initAPIClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	cfg, err := client.EnvironmentGet()
+	if err != nil {
+		return err
+	}
+	envName, _ := cfg["name"].(string)
+	manifest := backup.NewManifest(envName)
+	filename := c.Filename
+	if filename == "" {
+		filename = fmt.Sprintf("juju-backup-%s-%s.tgz", envName, manifest.Created.Format("20060102-150405"))
+	}
+	script, err := backup.BackupScript(environs.DataDir, manifest)
+	if err != nil {
+		return err
+	}
+	host, err := c.hostFromTarget("0")
+	if err != nil {
+		return err
+	}
+
+	path := ctx.AbsPath(filename)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	err = runOnHost(host, script, f, ctx.Stderr)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("cannot back up environment: %v", err)
+	}
+
+	// Check that what we wrote can be used to restore the environment.
+	if f, err = os.Open(path); err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = backup.ReadArchive(f); err != nil {
+		return fmt.Errorf("backup archive is unusable: %v", err)
+	}
+	fmt.Fprintf(ctx.Stdout, "wrote backup of environment %q to %s\n", envName, filename)
+	return nil
+}
+
+// runOnHost runs the supplied shell script as root on the given host.
+// The script is passed to the remote shell on its standard input, so
+// that any secrets it holds do not show in the process list of either
+// machine.
+var runOnHost = func(host, script string, stdout, stderr io.Writer) error {
+	cmd, err := ssh.Command("ubuntu", host, "sudo bash -s", knownHostsFile())
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// copyToHost copies the local file at path to the home directory of the
+// ubuntu user on the given host, and returns the remote path.
+var copyToHost = func(host, path string, stderr io.Writer) (string, error) {
+	remotePath := "/home/ubuntu/juju-backup.tgz"
+	cmd, err := ssh.CopyCommand("ubuntu", host, path, remotePath, knownHostsFile())
+	if err != nil {
+		return "", err
+	}
+	cmd.Stderr = stderr
+	return remotePath, cmd.Run()
+}
@@ -0,0 +1,136 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/backup"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type BackupSuite struct {
+	SSHCommonSuite
+}
+
+var _ = gc.Suite(&BackupSuite{})
+
+// writeTestArchive writes a valid backup archive of the named
+// environment to w.
+func writeTestArchive(c *gc.C, w io.Writer, envName string) {
+	agentConf := fmt.Sprintf("tag: machine-0\ncacert: %s\nstatepassword: sekrit\n",
+		base64.StdEncoding.EncodeToString([]byte(coretesting.CACert)))
+	err := backup.WriteArchive(w,
+		backup.NewManifest(envName),
+		[]byte(agentConf),
+		[]byte(coretesting.ServerCert+coretesting.ServerKey),
+		map[string][]byte{"juju/machines.bson": []byte("machines")},
+	)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *BackupSuite) TestBackupInit(c *gc.C) {
+	com := &BackupCommand{}
+	err := coretesting.InitCommand(com, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.Filename, gc.Equals, "")
+
+	com = &BackupCommand{}
+	err = coretesting.InitCommand(com, []string{"foo.tgz"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.Filename, gc.Equals, "foo.tgz")
+
+	err = coretesting.InitCommand(&BackupCommand{}, []string{"foo.tgz", "bar"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["bar"\]`)
+}
+
+func (s *BackupSuite) TestBackup(c *gc.C) {
+	s.makeMachines(1, c, true)
+	var gotHost, gotScript string
+	s.PatchValue(&runOnHost, func(host, script string, stdout, stderr io.Writer) error {
+		gotHost, gotScript = host, script
+		writeTestArchive(c, stdout, "dummyenv")
+		return nil
+	})
+	ctx := coretesting.Context(c)
+	code := cmd.Main(&BackupCommand{}, ctx, []string{"backup.tgz"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(gotHost, gc.Equals, "dummyenv-0.dns")
+	c.Assert(gotScript, gc.Matches, `(?s).*mongodump .*`)
+	c.Assert(ctx.Stdout.(*bytes.Buffer).String(), gc.Equals,
+		`wrote backup of environment "dummyenv" to backup.tgz`+"\n")
+
+	f, err := os.Open(filepath.Join(ctx.Dir, "backup.tgz"))
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	archive, err := backup.ReadArchive(f)
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.Manifest.Environment, gc.Equals, "dummyenv")
+}
+
+func (s *BackupSuite) TestBackupUnusableArchive(c *gc.C) {
+	s.makeMachines(1, c, true)
+	s.PatchValue(&runOnHost, func(host, script string, stdout, stderr io.Writer) error {
+		_, err := stdout.Write([]byte("not an archive"))
+		return err
+	})
+	ctx := coretesting.Context(c)
+	code := cmd.Main(&BackupCommand{}, ctx, []string{"backup.tgz"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Matches, "error: backup archive is unusable: .*\n")
+	_, err := os.Stat(filepath.Join(ctx.Dir, "backup.tgz"))
+	c.Assert(os.IsNotExist(err), gc.Equals, true)
+}
+
+type RestoreSuite struct {
+	SSHCommonSuite
+}
+
+var _ = gc.Suite(&RestoreSuite{})
+
+func (s *RestoreSuite) TestRestoreInit(c *gc.C) {
+	err := coretesting.InitCommand(&RestoreCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no backup archive specified")
+
+	com := &RestoreCommand{}
+	err = coretesting.InitCommand(com, []string{"backup.tgz"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.ArchivePath, gc.Equals, "backup.tgz")
+
+	err = coretesting.InitCommand(&RestoreCommand{}, []string{"backup.tgz", "bar"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["bar"\]`)
+}
+
+func (s *RestoreSuite) writeArchive(c *gc.C, dir, envName string) {
+	f, err := os.Create(filepath.Join(dir, "backup.tgz"))
+	c.Assert(err, gc.IsNil)
+	defer f.Close()
+	writeTestArchive(c, f, envName)
+}
+
+func (s *RestoreSuite) TestRestoreWrongEnvironment(c *gc.C) {
+	ctx := coretesting.Context(c)
+	s.writeArchive(c, ctx.Dir, "elsewhere")
+	code := cmd.Main(&RestoreCommand{}, ctx, []string{"backup.tgz"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Equals,
+		`error: backup is of environment "elsewhere", not "dummyenv"`+"\n")
+}
+
+func (s *RestoreSuite) TestRestoreStateServerRunning(c *gc.C) {
+	ctx := coretesting.Context(c)
+	s.writeArchive(c, ctx.Dir, "dummyenv")
+	code := cmd.Main(&RestoreCommand{}, ctx, []string{"backup.tgz"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(ctx.Stderr.(*bytes.Buffer).String(), gc.Matches,
+		`error: state server instance ".*" is still running`+"\n")
+}
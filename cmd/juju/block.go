@@ -0,0 +1,166 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
+)
+
+const blockTypesDoc = `
+The block types are, from the least to the most restrictive:
+
+    destroy        prevents destroy-environment
+    remove-object  also prevents destroy-machine, destroy-service,
+                   destroy-unit, destroy-relation and removing users
+    all-changes    prevents every change to the environment, while
+                   still allowing its state to be inspected
+`
+
+// BlockCommand switches on a block, which prevents operations on the
+// environment until it is switched off by UnblockCommand.
+type BlockCommand struct {
+	cmd.EnvCommandBase
+	blockType string
+	message   string
+}
+
+const blockDoc = `
+Switch on a block, which prevents a kind of operation on the environment
+until it is switched off with "juju unblock". The optional message gives
+the reason for the block, and is included in the errors returned by the
+blocked operations. Blocking an already blocked type of operation only
+changes the message.
+
+With no arguments, the blocks switched on in the environment are listed.
+` + blockTypesDoc
+
+func (c *BlockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "block",
+		Args:    "[<block type> [<message>]]",
+		Purpose: "block operations on the environment",
+		Doc:     blockDoc,
+	}
+}
+
+func (c *BlockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	t, err := state.ParseBlockType(args[0])
+	if err != nil {
+		return err
+	}
+	c.blockType = string(t)
+	c.message = strings.Join(args[1:], " ")
+	return nil
+}
+
+func (c *BlockCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.blockType != "" {
+		return client.SwitchBlockOn(c.blockType, c.message)
+	}
+	blocks, err := client.ListBlocks()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if b.Message == "" {
+			fmt.Fprintf(ctx.Stdout, "%s\n", b.Type)
+		} else {
+			fmt.Fprintf(ctx.Stdout, "%s: %s\n", b.Type, b.Message)
+		}
+	}
+	return nil
+}
+
+// UnblockCommand switches off a block.
+type UnblockCommand struct {
+	cmd.EnvCommandBase
+	blockType string
+}
+
+const unblockDoc = `
+Switch off a block switched on with "juju block", allowing the operations
+it prevented.
+` + blockTypesDoc
+
+func (c *UnblockCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "unblock",
+		Args:    "<block type>",
+		Purpose: "allow operations blocked on the environment",
+		Doc:     unblockDoc,
+	}
+}
+
+func (c *UnblockCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no block type specified")
+	}
+	t, err := state.ParseBlockType(args[0])
+	if err != nil {
+		return err
+	}
+	c.blockType = string(t)
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *UnblockCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SwitchBlockOff(c.blockType)
+}
+
+// blockCheckTimeout bounds how long destroy-environment waits for the
+// API server when checking for blocks. Environments are often destroyed
+// because they are broken, so an unreachable API server does not
+// prevent destruction.
+var blockCheckTimeout = 30 * time.Second
+
+// checkDestroyNotBlocked returns an error if a block prevents environ
+// from being destroyed. Every type of block does so.
+func checkDestroyNotBlocked(environ environs.Environ) error {
+	apiConn, err := juju.NewAPIConn(environ, api.DialOpts{
+		Timeout:    blockCheckTimeout,
+		RetryDelay: 2 * time.Second,
+	})
+	if err == environs.ErrNotBootstrapped {
+		return nil
+	} else if err != nil {
+		logger.Warningf("cannot check whether the environment may be destroyed: %v", err)
+		return nil
+	}
+	defer apiConn.Close()
+	blocks, err := apiConn.State.Client().ListBlocks()
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	b := blocks[0]
+	message := fmt.Sprintf("cannot destroy environment: blocked by %q block", b.Type)
+	if b.Message != "" {
+		message += ": " + b.Message
+	}
+	return errors.New(message)
+}
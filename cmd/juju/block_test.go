@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+)
+
+type BlockSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&BlockSuite{})
+
+func (s *BlockSuite) TestInitErrors(c *gc.C) {
+	err := testing.InitCommand(&BlockCommand{}, []string{"everything"})
+	c.Assert(err, gc.ErrorMatches, `unknown block type "everything"`)
+	err = testing.InitCommand(&UnblockCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no block type specified")
+	err = testing.InitCommand(&UnblockCommand{}, []string{"destroy", "now"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["now"\]`)
+}
+
+func (s *BlockSuite) TestBlockUnblock(c *gc.C) {
+	_, err := testing.RunCommand(c, &BlockCommand{}, []string{"all-changes", "product", "launch"})
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &BlockCommand{}, []string{"destroy"})
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &BlockCommand{}, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "destroy\nall-changes: product launch\n")
+
+	_, err = testing.RunCommand(c, &UnblockCommand{}, []string{"all-changes"})
+	c.Assert(err, gc.IsNil)
+	blocks, err := s.State.Blocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, gc.HasLen, 1)
+	c.Assert(blocks[0].Type(), gc.Equals, state.DestroyBlock)
+}
+
+func (s *BlockSuite) TestBlockedCommand(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.ChangeBlock, "product launch")
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &AddMachineCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, `operation blocked by "all-changes" block: product launch`)
+}
+
+func (s *BlockSuite) TestDestroyEnvironmentBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.DestroyBlock, "production")
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &DestroyEnvironmentCommand{}, []string{"dummyenv", "--yes"})
+	c.Assert(err, gc.ErrorMatches, `cannot destroy environment: blocked by "destroy" block: production`)
+}
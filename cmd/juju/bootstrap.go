@@ -60,6 +60,8 @@ type BootstrapCommand struct {
 	Source      string
 }
 
+var uploadTools = sync.Upload
+
 func (c *BootstrapCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "bootstrap",
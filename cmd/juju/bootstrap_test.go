@@ -422,6 +422,30 @@ func (s *BootstrapSuite) TestMissingToolsError(c *gc.C) {
 	c.Assert(errText, gc.Matches, expectedErrText)
 }
 
+// mockUploadTools simulates the effect of tools.Upload, but skips the time-
+// consuming build from source.
+// TODO(fwereade) better factor agent/tools such that build logic is
+// exposed and can itself be neatly mocked?
+func mockUploadTools(stor storage.Storage, forceVersion *version.Number, series ...string) (*coretools.Tools, error) {
+	vers := version.Current
+	if forceVersion != nil {
+		vers.Number = *forceVersion
+	}
+	versions := []version.Binary{vers}
+	for _, series := range series {
+		if series != version.Current.Series {
+			newVers := vers
+			newVers.Series = series
+			versions = append(versions, newVers)
+		}
+	}
+	agentTools, err := envtesting.UploadFakeToolsVersions(stor, versions...)
+	if err != nil {
+		return nil, err
+	}
+	return agentTools[0], nil
+}
+
 func uploadToolsAlwaysFails(stor storage.Storage, forceVersion *version.Number, series ...string) (*coretools.Tools, error) {
 	return nil, fmt.Errorf("an error")
 }
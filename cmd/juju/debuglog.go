@@ -5,91 +5,168 @@ package main
 
 import (
 	"fmt"
-	"strconv"
+	"io"
+	"strings"
 
 	"launchpad.net/gnuflag"
+	"launchpad.net/loggo"
 
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
 )
 
 type DebugLogCommand struct {
-	// The debug log command simply invokes juju ssh with the required arguments.
-	sshCmd cmd.Command
-	lines  linesValue
+	cmd.EnvCommandBase
+
+	level  string
+	params api.DebugLogParams
 }
 
 // defaultLineCount is the default number of lines to
 // display, from the end of the consolidated log.
 const defaultLineCount = 10
 
-// linesValue implements gnuflag.Value, and represents
-// a -n/--lines flag value compatible with "tail".
-//
-// A negative value (-K) corresponds to --lines=K,
-// i.e. the last K lines; a positive value (+K)
-// corresponds to --lines=+K, i.e. from line K onwards.
-type linesValue int
-
-func (v *linesValue) String() string {
-	if *v > 0 {
-		return fmt.Sprintf("+%d", *v)
-	}
-	return fmt.Sprint(-*v)
-}
-
-func (v *linesValue) Set(value string) error {
-	if len(value) > 0 {
-		sign := -1
-		if value[0] == '+' {
-			value = value[1:]
-			sign = 1
-		}
-		n, err := strconv.ParseInt(value, 10, 0)
-		if err == nil && n > 0 {
-			*v = linesValue(sign * int(n))
-			return nil
-		}
-		// err is quite verbose, and doesn't convey
-		// any additional useful information.
-	}
-	return fmt.Errorf("invalid number of lines")
-}
-
 const debuglogDoc = `
-Launch an ssh shell on the state server machine and tail the consolidated log file.
-The consolidated log file contains log messages from all nodes in the environment.
+Stream the consolidated log of the environment. The log contains the messages
+written by all the agents in the environment; each line starts with the tag
+of the agent that wrote it.
+
+By default the last 10 lines of the log are shown before following new lines
+as they are written; use --lines to change that number, or --replay to show
+the whole log.
+
+The messages shown can be filtered by agent with --include and --exclude,
+which take machine ids, unit names or agent tags, and may be repeated. A
+trailing "*" matches any name with the preceding prefix. Messages can also be
+filtered by the logging module that wrote them, with --include-module and
+--exclude-module; each also matches the module's submodules. --level shows
+only messages of at least the given level.
+
+Examples:
+   juju debug-log --include mysql/0            (show one unit's messages)
+   juju debug-log --include "mysql/*" --level WARNING
+   juju debug-log --exclude 0 --exclude-module juju.worker.uniter
+   juju debug-log --replay --include-module unit.mysql (show all hook output)
 `
 
 func (c *DebugLogCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "debug-log",
-		Purpose: "display the consolidated log file",
+		Purpose: "display the consolidated log",
 		Doc:     debuglogDoc,
 	}
 }
 
 func (c *DebugLogCommand) SetFlags(f *gnuflag.FlagSet) {
-	c.sshCmd.SetFlags(f)
+	c.EnvCommandBase.SetFlags(f)
+	f.Var(stringsValue{&c.params.IncludeEntity}, "i", "only show messages from these agents")
+	f.Var(stringsValue{&c.params.IncludeEntity}, "include", "")
+	f.Var(stringsValue{&c.params.ExcludeEntity}, "x", "do not show messages from these agents")
+	f.Var(stringsValue{&c.params.ExcludeEntity}, "exclude", "")
+	f.Var(stringsValue{&c.params.IncludeModule}, "include-module", "only show messages from these logging modules")
+	f.Var(stringsValue{&c.params.ExcludeModule}, "exclude-module", "do not show messages from these logging modules")
+	f.StringVar(&c.level, "l", "", "only show messages of at least this level")
+	f.StringVar(&c.level, "level", "", "")
+	f.IntVar(&c.params.Backlog, "n", defaultLineCount, "show this many lines from the end of the log before following it")
+	f.IntVar(&c.params.Backlog, "lines", defaultLineCount, "")
+	f.BoolVar(&c.params.Replay, "replay", false, "show the whole log before following it")
+}
+
+func (c *DebugLogCommand) Init(args []string) error {
+	if c.level != "" {
+		level, ok := loggo.ParseLevel(c.level)
+		if !ok || level < loggo.TRACE {
+			return fmt.Errorf("level value %q is not one of %q, %q, %q, %q, %q, %q",
+				c.level, loggo.TRACE, loggo.DEBUG, loggo.INFO, loggo.WARNING, loggo.ERROR, loggo.CRITICAL)
+		}
+		c.params.Level = level
+	}
+	if c.params.Backlog < 0 {
+		return fmt.Errorf("invalid number of lines")
+	}
+	var err error
+	if c.params.IncludeEntity, err = entityTags(c.params.IncludeEntity); err != nil {
+		return err
+	}
+	if c.params.ExcludeEntity, err = entityTags(c.params.ExcludeEntity); err != nil {
+		return err
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// entityTags returns the agent tags corresponding to the given
+// machine ids, unit names and tags, any of which may end in "*".
+func entityTags(values []string) ([]string, error) {
+	tags := make([]string, len(values))
+	for i, name := range values {
+		tag, err := entityTag(name)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = tag
+	}
+	return tags, nil
+}
 
-	c.lines = -defaultLineCount
-	f.Var(&c.lines, "n", "output the last K lines; or use -n +K to output lines starting with the Kth")
-	f.Var(&c.lines, "lines", "")
+func entityTag(name string) (string, error) {
+	wildcard := strings.HasSuffix(name, "*")
+	base := strings.TrimSuffix(name, "*")
+	switch {
+	case strings.HasPrefix(name, "machine-"), strings.HasPrefix(name, "unit-"):
+		return name, nil
+	case names.IsMachine(name):
+		return names.MachineTag(name), nil
+	case names.IsUnit(name):
+		return names.UnitTag(name), nil
+	case wildcard && strings.HasSuffix(base, "/"):
+		// A service's units, as in "mysql/*".
+		return "unit-" + strings.TrimSuffix(base, "/") + "-*", nil
+	case wildcard && base == "":
+		return "*", nil
+	}
+	return "", fmt.Errorf("%q is not a machine id, unit name or agent tag", name)
 }
 
-func (c *DebugLogCommand) AllowInterspersedFlags() bool {
-	return true
+// stringsValue implements gnuflag.Value for a flag that may be
+// repeated, appending each value given to the target slice.
+type stringsValue struct {
+	target *[]string
 }
 
-func (c *DebugLogCommand) Init(args []string) error {
-	tailcmd := fmt.Sprintf("tail -n %s -f /var/log/juju/all-machines.log", &c.lines)
-	args = append([]string{"0"}, args...)
-	args = append(args, tailcmd)
-	return c.sshCmd.Init(args)
+func (v stringsValue) Set(value string) error {
+	*v.target = append(*v.target, value)
+	return nil
 }
 
-// Run uses "juju ssh" to log into the state server node
-// and tails the consolidated log file which captures log
-// messages from all nodes.
+func (v stringsValue) String() string {
+	return strings.Join(*v.target, ",")
+}
+
+// DebugLogAPI is the API used by DebugLogCommand.
+type DebugLogAPI interface {
+	WatchDebugLog(params api.DebugLogParams) (io.ReadCloser, error)
+	Close() error
+}
+
+var getDebugLogAPI = func(envName string) (DebugLogAPI, error) {
+	return juju.NewAPIClientFromName(envName)
+}
+
+// Run connects to the API server and copies the consolidated log
+// to the output until the connection is closed.
 func (c *DebugLogCommand) Run(ctx *cmd.Context) error {
-	return c.sshCmd.Run(ctx)
+	client, err := getDebugLogAPI(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	stream, err := client.WatchDebugLog(c.params)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(ctx.Stdout, stream)
+	return err
 }
@@ -4,72 +4,113 @@
 package main
 
 import (
+	"io"
+	"io/ioutil"
+	"strings"
+
 	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
 
-	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
 )
 
 type DebugLogSuite struct {
+	testbase.LoggingSuite
 }
 
 var _ = gc.Suite(&DebugLogSuite{})
 
-func runDebugLog(c *gc.C, args ...string) (*DebugLogCommand, error) {
-	cmd := &DebugLogCommand{
-		sshCmd: &dummySSHCommand{},
-	}
-	_, err := testing.RunCommand(c, cmd, args)
-	return cmd, err
-}
-
-type dummySSHCommand struct {
-	SSHCommand
-	runCalled bool
-}
-
-func (c *dummySSHCommand) Run(ctx *cmd.Context) error {
-	c.runCalled = true
-	return nil
-}
+var debugLogInitTests = []struct {
+	args   []string
+	params api.DebugLogParams
+	err    string
+}{{
+	params: api.DebugLogParams{
+		IncludeEntity: []string{},
+		ExcludeEntity: []string{},
+		Backlog:       10,
+	},
+}, {
+	args: []string{"-n", "100", "--replay", "--level", "warning"},
+	params: api.DebugLogParams{
+		IncludeEntity: []string{},
+		ExcludeEntity: []string{},
+		Backlog:       100,
+		Replay:        true,
+		Level:         loggo.WARNING,
+	},
+}, {
+	args: []string{"--include", "0", "-i", "mysql/1", "--include", "mysql/*", "-x", "unit-wordpress-0"},
+	params: api.DebugLogParams{
+		IncludeEntity: []string{"machine-0", "unit-mysql-1", "unit-mysql-*"},
+		ExcludeEntity: []string{"unit-wordpress-0"},
+		Backlog:       10,
+	},
+}, {
+	args: []string{"--include-module", "juju.worker", "--exclude-module", "juju.worker.uniter"},
+	params: api.DebugLogParams{
+		IncludeEntity: []string{},
+		ExcludeEntity: []string{},
+		IncludeModule: []string{"juju.worker"},
+		ExcludeModule: []string{"juju.worker.uniter"},
+		Backlog:       10,
+	},
+}, {
+	args: []string{"--level", "loud"},
+	err:  `level value "loud" is not one of "TRACE", "DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL"`,
+}, {
+	args: []string{"--lines", "-1"},
+	err:  `invalid number of lines`,
+}, {
+	args: []string{"--include", "mysql"},
+	err:  `"mysql" is not a machine id, unit name or agent tag`,
+}, {
+	args: []string{"extra"},
+	err:  `unrecognized args: \["extra"\]`,
+}}
 
-// debug-log is implemented by invoking juju ssh with the correct arguments.
-// This test helper checks for the expected invocation.
-func (s *DebugLogSuite) assertDebugLogInvokesSSHCommand(c *gc.C, expected string, args ...string) {
-	defer testing.MakeEmptyFakeHome(c).Restore()
-	debugLogCmd, err := runDebugLog(c, args...)
-	c.Assert(err, gc.IsNil)
-	debugCmd := debugLogCmd.sshCmd.(*dummySSHCommand)
-	c.Assert(debugCmd.runCalled, gc.Equals, true)
-	c.Assert(debugCmd.Target, gc.Equals, "0")
-	c.Assert([]string{expected}, gc.DeepEquals, debugCmd.Args)
+func (s *DebugLogSuite) TestInit(c *gc.C) {
+	for i, t := range debugLogInitTests {
+		c.Logf("test %d: %v", i, t.args)
+		command := &DebugLogCommand{}
+		err := testing.InitCommand(command, t.args)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(command.params, gc.DeepEquals, t.params)
+	}
 }
 
-const logLocation = "/var/log/juju/all-machines.log"
-
-func (s *DebugLogSuite) TestDebugLog(c *gc.C) {
-	const expected = "tail -n 10 -f " + logLocation
-	s.assertDebugLogInvokesSSHCommand(c, expected)
+type fakeDebugLogAPI struct {
+	params api.DebugLogParams
+	log    io.Reader
+	closed bool
 }
 
-func (s *DebugLogSuite) TestDebugLogFrom(c *gc.C) {
-	const expected = "tail -n +1 -f " + logLocation
-	s.assertDebugLogInvokesSSHCommand(c, expected, "-n", "+1")
-	s.assertDebugLogInvokesSSHCommand(c, expected, "--lines=+1")
+func (f *fakeDebugLogAPI) WatchDebugLog(params api.DebugLogParams) (io.ReadCloser, error) {
+	f.params = params
+	return ioutil.NopCloser(f.log), nil
 }
 
-func (s *DebugLogSuite) TestDebugLogLast(c *gc.C) {
-	const expected = "tail -n 100 -f " + logLocation
-	s.assertDebugLogInvokesSSHCommand(c, expected, "-n", "100")
-	s.assertDebugLogInvokesSSHCommand(c, expected, "--lines=100")
+func (f *fakeDebugLogAPI) Close() error {
+	f.closed = true
+	return nil
 }
 
-func (s *DebugLogSuite) TestDebugLogValidation(c *gc.C) {
-	defer testing.MakeEmptyFakeHome(c).Restore()
-	_, err := runDebugLog(c, "-n", "0")
-	c.Assert(err, gc.ErrorMatches, "invalid value \"0\" for flag -n: invalid number of lines")
-	_, err = runDebugLog(c, "-n", "-1")
-	c.Assert(err, gc.ErrorMatches, "invalid value \"-1\" for flag -n: invalid number of lines")
-	_, err = runDebugLog(c, "-n", "fnord")
-	c.Assert(err, gc.ErrorMatches, "invalid value \"fnord\" for flag -n: invalid number of lines")
+func (s *DebugLogSuite) TestRun(c *gc.C) {
+	fake := &fakeDebugLogAPI{
+		log: strings.NewReader("machine-0: all is well\nunit-mysql-0: hook failed\n"),
+	}
+	s.PatchValue(&getDebugLogAPI, func(envName string) (DebugLogAPI, error) {
+		return fake, nil
+	})
+	ctx, err := testing.RunCommand(c, &DebugLogCommand{}, []string{"--include", "mysql/0"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, "machine-0: all is well\nunit-mysql-0: hook failed\n")
+	c.Assert(fake.params.IncludeEntity, gc.DeepEquals, []string{"unit-mysql-0"})
+	c.Assert(fake.closed, gc.Equals, true)
 }
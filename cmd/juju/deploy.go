@@ -4,11 +4,16 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
 
 	"launchpad.net/gnuflag"
+	"launchpad.net/goyaml"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
@@ -16,15 +21,20 @@ import (
 	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/juju/osenv"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/params"
 )
 
 type DeployCommand struct {
 	cmd.EnvCommandBase
 	UnitCommandBase
 	CharmName    string
+	BundlePath   string
 	ServiceName  string
 	Config       cmd.FileVar
 	Constraints  constraints.Value
+	Storage      map[string]constraints.Storage
+	Bindings     map[string]string
 	BumpRevision bool
 	RepoPath     string // defaults to JUJU_REPOSITORY
 }
@@ -54,15 +64,65 @@ flag.  When used with deploy, service-specific constraints are set so that later
 machines provisioned with add-unit will use the same constraints (unless changed
 by set-constraints).
 
-Charms can be deployed to a specific machine using the --to argument.
+Storage constraints can be specified for each of the stores declared by the
+charm using the --storage flag, which may be repeated. Each takes the form
+<store>=<size>[,<count>]; they are recorded against the service, and used by
+later add-unit calls too.
+
+The traffic of the service's relations can be bound to specific networks
+using the --bind flag, which may be repeated. Each takes the form
+<relation>=<network>, binding that relation's endpoint, or just <network>,
+binding all endpoints without a binding of their own. A unit's private
+address on a relation is then its address on the bound network.
+
+Units can be placed using the --to argument, which takes a comma-separated
+list of placement directives, one for each of the first units deployed. A
+directive may name an existing machine or container, a new container on an
+existing or new machine, or be passed to the provider, which may understand
+directives such as zone=<availability zone> or the name of a MAAS node.
 
 Examples:
    juju deploy mysql --to 23       (Deploy to machine 23)
    juju deploy mysql --to 24/lxc/3 (Deploy to lxc container 3 on host machine 24)
    juju deploy mysql --to lxc:25   (Deploy to a new lxc container on host machine 25)
+   juju deploy mysql --to lxc      (Deploy to a new lxc container on a new machine)
+   juju deploy mysql -n 2 --to zone=us-east-1a,zone=us-east-1b
+                                   (Deploy 2 units to machines in different zones)
+   juju deploy mysql --to node7.maas (Deploy to the MAAS node named node7.maas)
    
    juju deploy mysql -n 5 --constraints mem=8G (deploy 5 instances of mysql with at least 8 GB of RAM each)
 
+   juju deploy mysql --storage data=10G,2 (give each unit two 10 GB "data" stores)
+
+   juju deploy mysql --bind db=storage (keep the "db" relation on the "storage" network)
+
+A whole set of services and the relations between them can be deployed at
+once by giving the path of a bundle file, whose name must end in ".yaml",
+instead of a charm name. Local charms used by the bundle are taken from the
+local charm repository. Services in the bundle that already exist with the
+same charm are reused, and only the units, relations and settings that are
+missing are added, so deploying a bundle again has no further effect.
+
+A bundle file looks like this:
+
+   services:
+       wordpress:
+           charm: cs:precise/wordpress
+           num_units: 2
+           to: ["mysql/0"]
+           expose: true
+           options:
+               blog-title: My Blog
+       mysql:
+           charm: local:precise/mysql
+           num_units: 1
+           constraints: mem=4G
+   relations:
+       - ["wordpress:db", "mysql"]
+
+Each entry in "to" places one of the service's units, either on a machine
+given as for --to, or alongside a unit of another service in the bundle.
+
 See Also:
    juju help constraints
    juju help set-constraints
@@ -72,7 +132,7 @@ See Also:
 func (c *DeployCommand) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:    "deploy",
-		Args:    "<charm name> [<service name>]",
+		Args:    "<charm name> [<service name>] | <bundle file>",
 		Purpose: "deploy a new service",
 		Doc:     deployDoc,
 	}
@@ -86,6 +146,8 @@ func (c *DeployCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.BoolVar(&c.BumpRevision, "upgrade", false, "")
 	f.Var(&c.Config, "config", "path to yaml-formatted service config")
 	f.Var(constraints.ConstraintsValue{&c.Constraints}, "constraints", "set service constraints")
+	f.Var(constraints.StorageValue{&c.Storage}, "storage", "set storage constraints for a charm store")
+	f.Var(bindingsValue{&c.Bindings}, "bind", "bind relation endpoints to networks")
 	f.StringVar(&c.RepoPath, "repository", os.Getenv(osenv.JujuRepository), "local charm repository")
 }
 
@@ -98,6 +160,10 @@ func (c *DeployCommand) Init(args []string) error {
 		c.ServiceName = args[1]
 		fallthrough
 	case 1:
+		if strings.HasSuffix(args[0], ".yaml") {
+			c.BundlePath = args[0]
+			return c.initBundle()
+		}
 		if _, err := charm.InferURL(args[0], "fake"); err != nil {
 			return fmt.Errorf("invalid charm name %q", args[0])
 		}
@@ -110,17 +176,33 @@ func (c *DeployCommand) Init(args []string) error {
 	return c.UnitCommandBase.Init(args)
 }
 
+// initBundle checks that no options that only apply to a single
+// service are used when deploying a bundle.
+func (c *DeployCommand) initBundle() error {
+	if c.ServiceName != "" {
+		return errors.New("cannot specify a service name with a bundle")
+	}
+	if c.NumUnits != 1 || c.ToMachineSpec != "" || c.Config.Path != "" ||
+		!constraints.IsEmpty(&c.Constraints) || len(c.Storage) > 0 || len(c.Bindings) > 0 {
+		return errors.New("cannot use --num-units, --to, --config, --constraints, --storage or --bind with a bundle")
+	}
+	return nil
+}
+
 func (c *DeployCommand) Run(ctx *cmd.Context) error {
-	conn, err := juju.NewConnFromName(c.EnvName)
+	if c.BundlePath != "" {
+		return c.deployBundle(ctx)
+	}
+	client, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
-	conf, err := conn.State.EnvironConfig()
+	defer client.Close()
+	envInfo, err := client.EnvironmentInfo()
 	if err != nil {
 		return err
 	}
-	curl, err := charm.InferURL(c.CharmName, conf.DefaultSeries())
+	curl, err := charm.InferURL(c.CharmName, envInfo.DefaultSeries)
 	if err != nil {
 		return err
 	}
@@ -128,17 +210,16 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
-	// TODO(fwereade) it's annoying to roundtrip the bytes through the client
-	// here, but it's the original behaviour and not convenient to change.
-	// PutCharm will always be required in some form for local charms; and we
-	// will need an EnsureStoreCharm method somewhere that gets the state.Charm
-	// for use in the following checks.
-	ch, err := conn.PutCharm(curl, repo, c.BumpRevision)
+	curl, err = addCharmViaAPI(client, curl, repo, c.BumpRevision)
+	if err != nil {
+		return err
+	}
+	charmInfo, err := client.CharmInfo(curl.String())
 	if err != nil {
 		return err
 	}
 	numUnits := c.NumUnits
-	if ch.Meta().Subordinate {
+	if charmInfo.Meta.Subordinate {
 		if !constraints.IsEmpty(&c.Constraints) {
 			return errors.New("cannot use --constraints with subordinate service")
 		}
@@ -150,26 +231,160 @@ func (c *DeployCommand) Run(ctx *cmd.Context) error {
 	}
 	serviceName := c.ServiceName
 	if serviceName == "" {
-		serviceName = ch.Meta().Name
+		serviceName = charmInfo.Meta.Name
 	}
-	var settings charm.Settings
+	var configYAML []byte
 	if c.Config.Path != "" {
-		configYAML, err := c.Config.Read(ctx)
+		configYAML, err = c.Config.Read(ctx)
 		if err != nil {
 			return err
 		}
-		settings, err = ch.Config().ParseSettingsYAML(configYAML, serviceName)
+	}
+	return client.ServiceDeployWithParams(params.ServiceDeploy{
+		ServiceName:      serviceName,
+		CharmUrl:         curl.String(),
+		NumUnits:         numUnits,
+		ConfigYAML:       string(configYAML),
+		Constraints:      c.Constraints,
+		Storage:          c.Storage,
+		ToMachineSpec:    c.ToMachineSpec,
+		EndpointBindings: c.Bindings,
+	})
+}
+
+// addCharmViaAPI adds the charm with the given URL, read from repo, to
+// the environment, and returns the URL it was added with. Local charms
+// are uploaded to the API server; charm store charms are fetched by the
+// API server itself. If bumpRevision is true, the charm must be a local
+// directory, and its revision is incremented before it is uploaded.
+func addCharmViaAPI(client *api.Client, curl *charm.URL, repo charm.Repository, bumpRevision bool) (*charm.URL, error) {
+	if curl.Revision < 0 {
+		rev, err := repo.Latest(curl)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get latest charm revision: %v", err)
+		}
+		curl = curl.WithRevision(rev)
+	}
+	switch curl.Schema {
+	case "local":
+		ch, err := repo.Get(curl)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get charm: %v", err)
+		}
+		if bumpRevision {
+			chd, ok := ch.(*charm.Dir)
+			if !ok {
+				return nil, fmt.Errorf("cannot increment revision of charm %q: not a directory", curl)
+			}
+			if err = chd.SetDiskRevision(chd.Revision() + 1); err != nil {
+				return nil, fmt.Errorf("cannot increment revision of charm %q: %v", curl, err)
+			}
+			curl = curl.WithRevision(chd.Revision())
+		}
+		return client.AddLocalCharm(curl, ch)
+	case "cs":
+		if bumpRevision {
+			return nil, fmt.Errorf("cannot increment revision of charm %q: not a directory", curl)
+		}
+		if err := client.AddCharm(curl); err != nil {
+			return nil, err
+		}
+		return curl, nil
+	}
+	return nil, fmt.Errorf("unsupported charm URL schema: %q", curl.Schema)
+}
+
+// bindingsValue implements gnuflag.Value for the --bind flag, which
+// takes a binding of the form <relation>=<network>, or just <network>
+// for the default binding.
+type bindingsValue struct {
+	target *map[string]string
+}
+
+func (v bindingsValue) Set(s string) error {
+	relation, network := "", s
+	if i := strings.Index(s, "="); i >= 0 {
+		relation, network = s[:i], s[i+1:]
+		if relation == "" {
+			return fmt.Errorf("malformed binding %q", s)
+		}
+	}
+	if !names.IsNetwork(network) {
+		return fmt.Errorf("invalid network name %q", network)
+	}
+	if *v.target == nil {
+		*v.target = make(map[string]string)
+	}
+	(*v.target)[relation] = network
+	return nil
+}
+
+func (v bindingsValue) String() string {
+	var bindings []string
+	for relation, network := range *v.target {
+		if relation == "" {
+			bindings = append(bindings, network)
+		} else {
+			bindings = append(bindings, relation+"="+network)
+		}
+	}
+	sort.Strings(bindings)
+	return strings.Join(bindings, " ")
+}
+
+// deployBundle deploys the bundle read from c.BundlePath. Local charms
+// are uploaded to the environment first.
+func (c *DeployCommand) deployBundle(ctx *cmd.Context) error {
+	data, err := ioutil.ReadFile(ctx.AbsPath(c.BundlePath))
+	if err != nil {
+		return err
+	}
+	bd, err := charm.ReadBundleData(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := c.putLocalCharms(ctx, client, bd); err != nil {
+		return err
+	}
+	data, err = goyaml.Marshal(bd)
+	if err != nil {
+		return err
+	}
+	return client.DeployBundle(string(data))
+}
+
+// putLocalCharms adds the local charms used by the bundle to the
+// environment, and changes the bundle to refer to the added charms.
+func (c *DeployCommand) putLocalCharms(ctx *cmd.Context, client *api.Client, bd *charm.BundleData) error {
+	added := make(map[string]string)
+	for _, name := range bd.ServiceNames() {
+		svc := bd.Services[name]
+		if svc == nil || !strings.HasPrefix(svc.Charm, "local:") {
+			continue
+		}
+		if url, ok := added[svc.Charm]; ok {
+			svc.Charm = url
+			continue
+		}
+		curl, err := charm.ParseURL(svc.Charm)
+		if err != nil {
+			return fmt.Errorf("invalid charm URL in service %q: %v", name, err)
+		}
+		repo, err := charm.InferRepository(curl, ctx.AbsPath(c.RepoPath))
 		if err != nil {
 			return err
 		}
+		curl, err = addCharmViaAPI(client, curl, repo, c.BumpRevision)
+		if err != nil {
+			return err
+		}
+		added[svc.Charm] = curl.String()
+		svc.Charm = curl.String()
 	}
-	_, err = conn.DeployService(juju.DeployServiceParams{
-		ServiceName:    serviceName,
-		Charm:          ch,
-		NumUnits:       numUnits,
-		ConfigSettings: settings,
-		Constraints:    c.Constraints,
-		ToMachineSpec:  c.ToMachineSpec,
-	})
-	return err
+	return nil
 }
@@ -4,6 +4,9 @@
 package main
 
 import (
+	"io/ioutil"
+	"path/filepath"
+
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
@@ -44,14 +47,32 @@ var initErrorTests = []struct {
 		args: []string{"craziness", "burble1", "-n", "0"},
 		err:  `--num-units must be a positive integer`,
 	}, {
-		args: []string{"craziness", "burble1", "--to", "bigglesplop"},
-		err:  `invalid --to parameter "bigglesplop"`,
+		args: []string{"craziness", "burble1", "--to", "lxc:bigglesplop"},
+		err:  `invalid --to parameter "lxc:bigglesplop": invalid placement directive "lxc:bigglesplop": invalid machine id "bigglesplop"`,
+	}, {
+		args: []string{"craziness", "burble1", "--to", "1,,2"},
+		err:  `invalid --to parameter "1,,2": invalid placement directive ""`,
 	}, {
-		args: []string{"craziness", "burble1", "-n", "2", "--to", "123"},
-		err:  `cannot use --num-units > 1 with --to`,
+		args: []string{"craziness", "burble1", "-n", "2", "--to", "123,lxc:123,zone=a"},
+		err:  `cannot use 3 placement directives with --num-units 2`,
 	}, {
 		args: []string{"craziness", "burble1", "--constraints", "gibber=plop"},
 		err:  `invalid value "gibber=plop" for flag --constraints: unknown constraint "gibber"`,
+	}, {
+		args: []string{"craziness", "burble1", "--storage", "data"},
+		err:  `invalid value "data" for flag --storage: malformed storage constraint "data"`,
+	}, {
+		args: []string{"bundle.yaml", "burble1"},
+		err:  `cannot specify a service name with a bundle`,
+	}, {
+		args: []string{"bundle.yaml", "-n", "2"},
+		err:  `cannot use --num-units, --to, --config, --constraints, --storage or --bind with a bundle`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "db=bad network"},
+		err:  `invalid value "db=bad network" for flag --bind: invalid network name "bad network"`,
+	}, {
+		args: []string{"craziness", "burble1", "--bind", "=storage"},
+		err:  `invalid value "=storage" for flag --bind: malformed binding "=storage"`,
 	},
 }
 
@@ -151,6 +172,82 @@ func (s *DeploySuite) TestConstraints(c *gc.C) {
 	c.Assert(cons, gc.DeepEquals, constraints.MustParse("mem=2G cpu-cores=2"))
 }
 
+func (s *DeploySuite) TestBind(c *gc.C) {
+	coretesting.Charms.BundlePath(s.SeriesPath, "wordpress")
+	err := runDeploy(c, "local:wordpress", "--bind", "public", "--bind", "db=storage")
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:precise/wordpress-3")
+	service, _ := s.AssertService(c, "wordpress", curl, 1, 0)
+	c.Assert(service.EndpointBindings(), gc.DeepEquals, map[string]string{
+		"":   "public",
+		"db": "storage",
+	})
+}
+
+func (s *DeploySuite) TestBindUnknownRelation(c *gc.C) {
+	coretesting.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "--bind", "db=storage")
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "dummy": charm "local:precise/dummy-1" has no relation "db"`)
+}
+
+func (s *DeploySuite) TestStorage(c *gc.C) {
+	coretesting.Charms.BundlePath(s.SeriesPath, "storage-filesystem")
+	err := runDeploy(c, "local:storage-filesystem", "--storage", "data=2G,2")
+	c.Assert(err, gc.IsNil)
+	curl := charm.MustParseURL("local:precise/storage-filesystem-1")
+	service, _ := s.AssertService(c, "storage-filesystem", curl, 1, 0)
+	c.Assert(service.StorageConstraints(), gc.DeepEquals, map[string]constraints.Storage{
+		"data": {Size: 2048, Count: 2},
+	})
+	units, err := service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	stores, err := units[0].StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stores, gc.HasLen, 2)
+	for _, store := range stores {
+		c.Check(store.StorageName(), gc.Equals, "data")
+		c.Check(store.Size(), gc.Equals, uint64(2048))
+	}
+}
+
+const deployTestBundle = `
+services:
+    wordpress:
+        charm: local:precise/wordpress
+        num_units: 1
+        expose: true
+    mysql:
+        charm: local:precise/mysql
+        num_units: 1
+relations:
+    - ["wordpress", "mysql"]
+`
+
+func (s *DeploySuite) TestBundle(c *gc.C) {
+	coretesting.Charms.BundlePath(s.SeriesPath, "wordpress")
+	coretesting.Charms.BundlePath(s.SeriesPath, "mysql")
+	path := filepath.Join(c.MkDir(), "bundle.yaml")
+	err := ioutil.WriteFile(path, []byte(deployTestBundle), 0644)
+	c.Assert(err, gc.IsNil)
+	err = runDeploy(c, path)
+	c.Assert(err, gc.IsNil)
+	wordpress, _ := s.AssertService(c, "wordpress", charm.MustParseURL("local:precise/wordpress-3"), 1, 1)
+	c.Assert(wordpress.IsExposed(), gc.Equals, true)
+	s.AssertService(c, "mysql", charm.MustParseURL("local:precise/mysql-1"), 1, 1)
+
+	// Deploying the bundle again changes nothing.
+	err = runDeploy(c, path)
+	c.Assert(err, gc.IsNil)
+	s.AssertService(c, "wordpress", charm.MustParseURL("local:precise/wordpress-3"), 1, 1)
+	s.AssertService(c, "mysql", charm.MustParseURL("local:precise/mysql-1"), 1, 1)
+}
+
+func (s *DeploySuite) TestStorageTooSmall(c *gc.C) {
+	coretesting.Charms.BundlePath(s.SeriesPath, "storage-filesystem")
+	err := runDeploy(c, "local:storage-filesystem", "--storage", "data=512M")
+	c.Assert(err, gc.ErrorMatches, `cannot set storage constraints: store "data" requires at least 1024M`)
+}
+
 func (s *DeploySuite) TestSubordinateConstraints(c *gc.C) {
 	coretesting.Charms.BundlePath(s.SeriesPath, "logging")
 	err := runDeploy(c, "local:logging", "--constraints", "mem=1G")
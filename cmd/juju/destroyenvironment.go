@@ -48,6 +48,9 @@ func (c *DestroyEnvironmentCommand) Run(ctx *cmd.Context) error {
 	if err != nil {
 		return err
 	}
+	if err := checkDestroyNotBlocked(environ); err != nil {
+		return err
+	}
 	if !c.assumeYes {
 		fmt.Fprintf(ctx.Stdout, destroyEnvMsg, environ.Name(), environ.Config().Type())
 
@@ -0,0 +1,91 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// DoCommand queues a charm action for execution on a unit.
+type DoCommand struct {
+	cmd.EnvCommandBase
+	UnitName   string
+	ActionName string
+	Params     map[string]string
+}
+
+const doDoc = `
+Queue an action defined in the unit's charm for execution on the unit. Action
+parameters may be given as key=value pairs; values are converted to the types
+declared in the charm's actions.yaml, and parameters left unspecified take
+their declared default values.
+
+The id of the queued action is printed on success; its progress and results
+may be retrieved with "juju action-fetch".
+
+Example:
+    juju do mysql/0 backup outfile=/tmp/db.bz2
+`
+
+func (c *DoCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "do",
+		Args:    "<unit> <action> [key=value ...]",
+		Purpose: "queue an action for execution on a unit",
+		Doc:     doDoc,
+	}
+}
+
+func (c *DoCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+}
+
+func (c *DoCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no unit specified")
+	case 1:
+		return errors.New("no action specified")
+	}
+	c.UnitName, c.ActionName = args[0], args[1]
+	if !names.IsUnit(c.UnitName) {
+		return fmt.Errorf("invalid unit name %q", c.UnitName)
+	}
+	if !charm.IsValidActionName(c.ActionName) {
+		return fmt.Errorf("invalid action name %q", c.ActionName)
+	}
+	params, err := parse(args[2:])
+	if err != nil {
+		return err
+	}
+	c.Params = params
+	return nil
+}
+
+// Run queues the action and prints its id.
+func (c *DoCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	params := make(map[string]interface{})
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	id, err := client.EnqueueAction(c.UnitName, c.ActionName, params)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "action queued with id: %s\n", id)
+	return nil
+}
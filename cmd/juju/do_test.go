@@ -0,0 +1,82 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+)
+
+type DoSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&DoSuite{})
+
+var doTests = []struct {
+	args   []string
+	err    string
+	params map[string]interface{}
+}{
+	{
+		err: `no unit specified`,
+	}, {
+		args: []string{"dummy/0"},
+		err:  `no action specified`,
+	}, {
+		args: []string{"dummy", "snapshot"},
+		err:  `invalid unit name "dummy"`,
+	}, {
+		args: []string{"dummy/0", "Snapshot"},
+		err:  `invalid action name "Snapshot"`,
+	}, {
+		args: []string{"dummy/0", "snapshot", "outfile"},
+		err:  `invalid option: "outfile"`,
+	}, {
+		args: []string{"dummy/9", "snapshot"},
+		err:  `unit "dummy/9" not found`,
+	}, {
+		args: []string{"dummy/0", "explode"},
+		err:  `cannot add action "explode" to unit "dummy/0": action not defined by charm "local:quantal/dummy-1"`,
+	}, {
+		args: []string{"dummy/0", "snapshot", "colour=blue"},
+		err:  `cannot add action "snapshot" to unit "dummy/0": unknown parameter "colour"`,
+	}, {
+		args:   []string{"dummy/0", "snapshot"},
+		params: map[string]interface{}{"outfile": "foo.bz2"},
+	}, {
+		args:   []string{"dummy/0", "snapshot", "outfile=bar.bz2"},
+		params: map[string]interface{}{"outfile": "bar.bz2"},
+	},
+}
+
+func (s *DoSuite) TestDo(c *gc.C) {
+	testing.Charms.BundlePath(s.SeriesPath, "dummy")
+	err := runDeploy(c, "local:dummy", "dummy")
+	c.Assert(err, gc.IsNil)
+	unit, err := s.State.Unit("dummy/0")
+	c.Assert(err, gc.IsNil)
+
+	for i, t := range doTests {
+		c.Logf("test %d: %v", i, t.args)
+		ctx, err := testing.RunCommand(c, &DoCommand{}, t.args)
+		if t.err != "" {
+			c.Assert(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		actions, err := unit.Actions()
+		c.Assert(err, gc.IsNil)
+		action := actions[len(actions)-1]
+		c.Assert(action.Status(), gc.Equals, params.ActionPending)
+		c.Assert(action.Params(), gc.DeepEquals, t.params)
+		out := strings.TrimSpace(testing.Stdout(ctx))
+		c.Assert(out, gc.Equals, "action queued with id: "+action.Id())
+	}
+}
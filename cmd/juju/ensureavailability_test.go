@@ -0,0 +1,58 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type EnsureAvailabilitySuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&EnsureAvailabilitySuite{})
+
+func runEnsureAvailability(c *gc.C, args ...string) error {
+	_, err := coretesting.RunCommand(c, &EnsureAvailabilityCommand{}, args)
+	return err
+}
+
+func (s *EnsureAvailabilitySuite) TestEnsureAvailabilityInit(c *gc.C) {
+	for _, args := range [][]string{{"-n", "0"}, {"-n", "2"}, {"-n", "-1"}} {
+		err := coretesting.InitCommand(&EnsureAvailabilityCommand{}, args)
+		c.Check(err, gc.ErrorMatches, "must specify a number of state servers odd and greater than zero")
+	}
+	com := &EnsureAvailabilityCommand{}
+	err := coretesting.InitCommand(com, nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(com.NumStateServers, gc.Equals, 1)
+
+	err = coretesting.InitCommand(&EnsureAvailabilityCommand{}, []string{"-n", "3", "extra"})
+	c.Assert(err, gc.ErrorMatches, `unrecognized args: \["extra"\]`)
+}
+
+func (s *EnsureAvailabilitySuite) TestEnsureAvailability(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobManageEnviron, state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	err = runEnsureAvailability(c, "-n", "3", "--series", "precise", "--constraints", "mem=4G")
+	c.Assert(err, gc.IsNil)
+
+	machines, err := s.State.StateServerMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 3)
+	for _, m := range machines[1:] {
+		c.Assert(m.Series(), gc.Equals, "precise")
+		cons, err := m.Constraints()
+		c.Assert(err, gc.IsNil)
+		c.Assert(cons, gc.DeepEquals, constraints.MustParse("mem=4G"))
+	}
+
+	err = runEnsureAvailability(c, "-n", "1")
+	c.Assert(err, gc.ErrorMatches, "cannot reduce state server count")
+}
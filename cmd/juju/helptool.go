@@ -5,11 +5,13 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/gnuflag"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/worker/uniter/jujuc"
 )
 
@@ -47,6 +49,36 @@ func (dummyHookContext) Relation(id int) (jujuc.ContextRelation, bool) {
 func (dummyHookContext) RelationIds() []int {
 	return []int{}
 }
+func (dummyHookContext) StorageInstances() ([]params.StorageInstance, error) {
+	return nil, nil
+}
+func (dummyHookContext) ActionParams() (map[string]interface{}, error) {
+	return nil, fmt.Errorf("not running an action")
+}
+func (dummyHookContext) UpdateActionResults(keys []string, value string) error {
+	return fmt.Errorf("not running an action")
+}
+func (dummyHookContext) SetActionFailed(message string) error {
+	return fmt.Errorf("not running an action")
+}
+func (dummyHookContext) AddMetric(key string, value float64, created time.Time) error {
+	return nil
+}
+func (dummyHookContext) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	return nil
+}
+func (dummyHookContext) WorkloadStatus() (params.WorkloadStatus, string, error) {
+	return params.WorkloadUnknown, "", nil
+}
+func (dummyHookContext) IsLeader() (bool, error) {
+	return false, nil
+}
+func (dummyHookContext) LeaderSettings() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+func (dummyHookContext) WriteLeaderSettings(settings map[string]string) error {
+	return nil
+}
 
 type HelpToolCommand struct {
 	cmd.CommandBase
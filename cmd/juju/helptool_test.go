@@ -34,14 +34,24 @@ purpose: show help on a juju charm tool
 
 func (suite *HelpToolSuite) TestHelpTool(c *gc.C) {
 	expectedNames := []string{
+		"action-fail",
+		"action-get",
+		"action-set",
+		"add-metric",
 		"close-port",
 		"config-get",
+		"is-leader",
 		"juju-log",
+		"leader-get",
+		"leader-set",
 		"open-port",
 		"relation-get",
 		"relation-ids",
 		"relation-list",
 		"relation-set",
+		"status-get",
+		"status-set",
+		"storage-get",
 		"unit-get",
 	}
 	output := badrun(c, 0, "help-tool")
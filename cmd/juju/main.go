@@ -82,6 +82,9 @@ func Main(args []string) {
 
 	// Reporting commands.
 	jujucmd.Register(wrap(&StatusCommand{}))
+	jujucmd.Register(wrap(&StatusHistoryCommand{}))
+	jujucmd.Register(wrap(&MetricsCommand{}))
+	jujucmd.Register(wrap(&AuditLogCommand{}))
 	jujucmd.Register(wrap(&SwitchCommand{}))
 	jujucmd.Register(wrap(&EndpointCommand{}))
 
@@ -89,8 +92,28 @@ func Main(args []string) {
 	jujucmd.Register(wrap(&SCPCommand{}))
 	jujucmd.Register(wrap(&SSHCommand{}))
 	jujucmd.Register(wrap(&ResolvedCommand{}))
-	jujucmd.Register(wrap(&DebugLogCommand{sshCmd: &SSHCommand{}}))
+	jujucmd.Register(wrap(&RetryProvisioningCommand{}))
+	jujucmd.Register(wrap(&DebugLogCommand{}))
 	jujucmd.Register(wrap(&DebugHooksCommand{}))
+	jujucmd.Register(wrap(&RunCommand{}))
+
+	// Action commands.
+	jujucmd.Register(wrap(&DoCommand{}))
+	jujucmd.Register(wrap(&ActionFetchCommand{}))
+
+	// Backup and restore commands.
+	jujucmd.Register(wrap(&BackupCommand{}))
+	jujucmd.Register(wrap(&RestoreCommand{}))
+
+	// Manage state server availability.
+	jujucmd.Register(wrap(&EnsureAvailabilityCommand{}))
+
+	// Change control commands.
+	jujucmd.Register(wrap(&BlockCommand{}))
+	jujucmd.Register(wrap(&UnblockCommand{}))
+
+	// User management commands.
+	jujucmd.Register(newUserCommand())
 
 	// Configuration commands.
 	jujucmd.Register(wrap(&InitCommand{}))
@@ -99,6 +122,9 @@ func Main(args []string) {
 	jujucmd.Register(wrap(&UnsetCommand{}))
 	jujucmd.Register(wrap(&GetConstraintsCommand{}))
 	jujucmd.Register(wrap(&SetConstraintsCommand{}))
+	jujucmd.Register(wrap(&SetRecoveryCommand{}))
+	jujucmd.Register(wrap(&GetScalingCommand{}))
+	jujucmd.Register(wrap(&SetScalingCommand{}))
 	jujucmd.Register(wrap(&GetEnvironmentCommand{}))
 	jujucmd.Register(wrap(&SetEnvironmentCommand{}))
 	jujucmd.Register(wrap(&ExposeCommand{}))
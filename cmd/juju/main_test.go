@@ -216,10 +216,14 @@ func (s *MainSuite) TestActualRunJujuArgsAfterCommand(c *gc.C) {
 }
 
 var commandNames = []string{
+	"action-fetch",
 	"add-machine",
 	"add-relation",
 	"add-unit",
 	"api-endpoints",
+	"audit-log",
+	"backup",
+	"block",
 	"bootstrap",
 	"debug-hooks",
 	"debug-log",
@@ -229,6 +233,8 @@ var commandNames = []string{
 	"destroy-relation",
 	"destroy-service",
 	"destroy-unit",
+	"do",
+	"ensure-availability",
 	"env", // alias for switch
 	"expose",
 	"generate-config", // alias for init
@@ -236,28 +242,38 @@ var commandNames = []string{
 	"get-constraints",
 	"get-env", // alias for get-environment
 	"get-environment",
+	"get-scaling",
 	"help",
 	"help-tool",
 	"init",
+	"metrics",
 	"publish",
 	"remove-relation", // alias for destroy-relation
 	"remove-unit",     // alias for destroy-unit
 	"resolved",
+	"restore",
+	"retry-provisioning",
+	"run",
 	"scp",
 	"set",
 	"set-constraints",
 	"set-env", // alias for set-environment
 	"set-environment",
+	"set-recovery",
+	"set-scaling",
 	"ssh",
 	"stat", // alias for status
 	"status",
+	"status-history",
 	"switch",
 	"sync-tools",
 	"terminate-machine", // alias for destroy-machine
+	"unblock",
 	"unexpose",
 	"unset",
 	"upgrade-charm",
 	"upgrade-juju",
+	"user",
 	"version",
 }
 
@@ -0,0 +1,111 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+// MetricsCommand shows the metrics recently reported by a unit, or by
+// the units of a service.
+type MetricsCommand struct {
+	cmd.EnvCommandBase
+	out   cmd.Output
+	name  string
+	since time.Duration
+}
+
+const metricsDoc = `
+Show the metrics reported by a unit, such as "mysql/0", or by every unit of
+a service, such as "mysql", oldest first. Units report the metrics declared
+in their charm's metrics.yaml with the add-metric hook tool, usually from
+the collect-metrics hook, which is run periodically.
+`
+
+func (c *MetricsCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "metrics",
+		Args:    "<unit or service>",
+		Purpose: "output metrics reported by a unit or service",
+		Doc:     metricsDoc,
+	}
+}
+
+func (c *MetricsCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.DurationVar(&c.since, "since", time.Hour, "show metrics reported within this duration")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"tabular": cmd.FormatTabular,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+	})
+}
+
+func (c *MetricsCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no unit or service specified")
+	}
+	c.name = args[0]
+	if !names.IsUnit(c.name) && !names.IsService(c.name) {
+		return fmt.Errorf("invalid unit or service %q", c.name)
+	}
+	if c.since <= 0 {
+		return fmt.Errorf("invalid duration %v", c.since)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+type metricEntry struct {
+	Unit  string  `json:"unit" yaml:"unit"`
+	Time  string  `json:"time" yaml:"time"`
+	Key   string  `json:"key" yaml:"key"`
+	Value float64 `json:"value" yaml:"value"`
+}
+
+// metricEntries holds formatted metrics, oldest first.
+type metricEntries []metricEntry
+
+func (m metricEntries) Tabulate() []cmd.TableSection {
+	section := cmd.TableSection{
+		Headings: []string{"UNIT", "TIME", "METRIC", "VALUE"},
+	}
+	for _, entry := range m {
+		section.Rows = append(section.Rows, []string{
+			entry.Unit, entry.Time, entry.Key, strconv.FormatFloat(entry.Value, 'g', -1, 64),
+		})
+	}
+	return []cmd.TableSection{section}
+}
+
+func (c *MetricsCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	metrics, err := client.Metrics(c.name, time.Now().Add(-c.since))
+	if err != nil {
+		return err
+	}
+	entries := make(metricEntries, len(metrics))
+	for i, m := range metrics {
+		entries[i] = metricEntry{
+			Unit:  m.Unit,
+			Time:  m.Time.UTC().Format(time.RFC3339),
+			Key:   m.Key,
+			Value: m.Value,
+		}
+	}
+	return c.out.Write(ctx, entries)
+}
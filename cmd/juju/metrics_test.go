@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type MetricsSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+var metricsInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no unit or service specified",
+}, {
+	args: []string{"Mysql"},
+	err:  `invalid unit or service "Mysql"`,
+}, {
+	args: []string{"mysql", "--since", "0"},
+	err:  "invalid duration 0",
+}, {
+	args: []string{"mysql/0", "1"},
+	err:  `unrecognized args: \["1"\]`,
+}}
+
+func (s *MetricsSuite) TestInitErrors(c *gc.C) {
+	for i, test := range metricsInitErrorTests {
+		c.Logf("test %d: %v", i, test.args)
+		err := coretesting.InitCommand(&MetricsCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *MetricsSuite) TestMetrics(c *gc.C) {
+	svc, err := s.State.AddService("metered", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	when := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	err = unit.AddMetrics([]state.Metric{
+		{Key: "requests", Value: 12.5, Time: when},
+		{Key: "connections", Value: 3, Time: when},
+	})
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := coretesting.RunCommand(c, &MetricsCommand{}, []string{"metered"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, ""+
+		"UNIT +TIME +METRIC +VALUE\n"+
+		"metered/0 +2014-05-01T12:00:00Z +requests +12.5\n"+
+		"metered/0 +2014-05-01T12:00:00Z +connections +3\n")
+
+	ctx, err = coretesting.RunCommand(c, &MetricsCommand{}, []string{"metered/0", "--format", "yaml"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, ""+
+		`- unit: metered/0\n  time: "?2014-05-01T12:00:00Z"?\n  key: requests\n  value: 12.5\n`+
+		`- unit: metered/0\n  time: "?2014-05-01T12:00:00Z"?\n  key: connections\n  value: 3\n`)
+
+	_, err = coretesting.RunCommand(c, &MetricsCommand{}, []string{"metered/1"})
+	c.Assert(err, gc.ErrorMatches, `unit "metered/1" not found`)
+}
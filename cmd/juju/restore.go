@@ -0,0 +1,257 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/backup"
+	"launchpad.net/juju-core/environs/bootstrap"
+	"launchpad.net/juju-core/environs/configstore"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/provider/common"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/utils"
+)
+
+// RestoreCommand rebuilds a lost state server from a backup archive.
+type RestoreCommand struct {
+	SSHCommon
+	Constraints constraints.Value
+	ArchivePath string
+}
+
+const restoreDoc = `
+Restore replaces a lost state server with a new one whose state is taken from
+a backup archive created by "juju backup".
+
+A new state server machine is bootstrapped, exactly as by "juju bootstrap",
+and the database, agent configuration and certificates held in the archive
+are restored onto it. Every other machine in the environment is then updated
+so that its agents connect to the new state server.
+
+Restore refuses to run while the environment's original state server is
+still running.
+
+See Also:
+   juju help backup
+   juju help bootstrap
+`
+
+func (c *RestoreCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "restore",
+		Args:    "<filename>",
+		Purpose: "restore a lost state server from a backup",
+		Doc:     restoreDoc,
+	}
+}
+
+func (c *RestoreCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.Var(constraints.ConstraintsValue{&c.Constraints}, "constraints", "set constraints for the new state server")
+}
+
+func (c *RestoreCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no backup archive specified")
+	}
+	c.ArchivePath, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// AllowInterspersedFlags is true for restore, which passes no arguments
+// through to ssh.
+func (c *RestoreCommand) AllowInterspersedFlags() bool {
+	return true
+}
+
+// restoreAttemptStrategy governs how long we wait for a newly
+// bootstrapped state server to become reachable.
+var restoreAttemptStrategy = utils.AttemptStrategy{
+	Total: 10 * time.Minute,
+	Delay: 5 * time.Second,
+}
+
+func (c *RestoreCommand) Run(ctx *cmd.Context) error {
+	path := ctx.AbsPath(c.ArchivePath)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	archive, err := backup.ReadArchive(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	store, err := configstore.Default()
+	if err != nil {
+		return err
+	}
+	environ, err := environs.NewFromName(c.EnvName, store)
+	if err != nil {
+		return err
+	}
+	if name := archive.Manifest.Environment; name != environ.Name() {
+		return fmt.Errorf("backup is of environment %q, not %q", name, environ.Name())
+	}
+	if err := ensureStateServerLost(environ); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Stderr, "bootstrapping a new state server\n")
+	if err := environ.Storage().Remove(common.StateFile); err != nil {
+		return err
+	}
+	// The new state server knows nothing of the environment's other
+	// machines until it is restored, so its provisioner must not stop
+	// their instances in the meantime. The restored configuration
+	// takes effect when the restored state server restarts.
+	cfg, err := environ.Config().Apply(map[string]interface{}{
+		"provisioner-safe-mode": true,
+	})
+	if err == nil {
+		err = environ.SetConfig(cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot enable provisioner safe mode: %v", err)
+	}
+	if err := bootstrap.Bootstrap(environ, c.Constraints); err != nil {
+		return fmt.Errorf("cannot bootstrap new state server: %v", err)
+	}
+	bootstrapState, err := common.LoadState(environ.Storage())
+	if err != nil {
+		return err
+	}
+	if len(bootstrapState.StateInstances) == 0 {
+		return fmt.Errorf("no state server instance after bootstrap")
+	}
+	instId := bootstrapState.StateInstances[0]
+	stateInfo, apiInfo, err := environ.StateInfo()
+	if err != nil {
+		return err
+	}
+	host, err := waitForHost(environ, instId)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Stderr, "restoring state server %s from %s\n", host, c.ArchivePath)
+	remotePath, err := copyToHost(host, path, ctx.Stderr)
+	if err != nil {
+		return fmt.Errorf("cannot copy backup archive to state server: %v", err)
+	}
+	script := backup.RestoreScript(backup.RestoreParams{
+		DataDir:        environs.DataDir,
+		ArchivePath:    remotePath,
+		Agent:          archive.Agent,
+		InstanceId:     instId,
+		StatePort:      environ.Config().StatePort(),
+		StateAddresses: stateInfo.Addrs,
+		APIAddresses:   apiInfo.Addrs,
+	})
+	if err := runOnHost(host, script, ctx.Stderr, ctx.Stderr); err != nil {
+		return fmt.Errorf("cannot restore state server: %v", err)
+	}
+
+	conn, err := juju.NewAPIConn(environ, api.DefaultDialOpts())
+	if err != nil {
+		return fmt.Errorf("cannot connect to restored state server: %v", err)
+	}
+	defer conn.Close()
+	c.apiClient = conn.State.Client()
+	if err := c.updateAgents(ctx, stateInfo.Addrs, apiInfo.Addrs); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "restored environment %q\n", environ.Name())
+	return nil
+}
+
+// ensureStateServerLost returns an error unless none of the environment's
+// state server instances is still running.
+func ensureStateServerLost(environ environs.Environ) error {
+	bootstrapState, err := common.LoadState(environ.Storage())
+	if err == environs.ErrNotBootstrapped {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	insts, err := environ.Instances(bootstrapState.StateInstances)
+	if err == environs.ErrNoInstances {
+		return nil
+	} else if err != nil && err != environs.ErrPartialInstances {
+		return err
+	}
+	for _, inst := range insts {
+		if inst != nil {
+			return fmt.Errorf("state server instance %q is still running", inst.Id())
+		}
+	}
+	return nil
+}
+
+// waitForHost waits until the instance with the given id has an address
+// and accepts ssh connections, and returns the address.
+func waitForHost(environ environs.Environ, id instance.Id) (string, error) {
+	var err error
+	for a := restoreAttemptStrategy.Start(); a.Next(); {
+		var insts []instance.Instance
+		insts, err = environ.Instances([]instance.Id{id})
+		if err != nil {
+			continue
+		}
+		var host string
+		if host, err = insts[0].DNSName(); err != nil {
+			continue
+		}
+		if err = runOnHost(host, "true", nil, nil); err == nil {
+			return host, nil
+		}
+	}
+	return "", fmt.Errorf("new state server is not reachable: %v", err)
+}
+
+// updateAgents points the agents on every machine other than the state
+// server at the restored state server. Failures are reported, but do not
+// stop the remaining machines from being updated.
+func (c *RestoreCommand) updateAgents(ctx *cmd.Context, stateAddrs, apiAddrs []string) error {
+	status, err := c.apiClient.Status()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for id := range status.Machines {
+		if id != "0" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	script := backup.UpdateAgentsScript(environs.DataDir, stateAddrs, apiAddrs)
+	failed := 0
+	for _, id := range ids {
+		host, err := c.hostFromTarget(id)
+		if err == nil {
+			fmt.Fprintf(ctx.Stderr, "updating agents on machine %s\n", id)
+			err = runOnHost(host, script, ctx.Stderr, ctx.Stderr)
+		}
+		if err != nil {
+			fmt.Fprintf(ctx.Stderr, "cannot update agents on machine %s: %v\n", id, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("cannot update agents on %d of %d machines", failed, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+)
+
+const retryProvisioningDoc = `
+Machines that failed to start are left in an error state. Once the
+cause of the failure has been dealt with, retry-provisioning queues them
+to be started again.
+
+Failures that are likely to go away by themselves, such as an exceeded
+quota or API rate limit, are retried automatically a number of times
+before the machine is left in an error state.
+`
+
+// RetryProvisioningCommand queues machines that failed to start to be
+// provisioned again.
+type RetryProvisioningCommand struct {
+	cmd.EnvCommandBase
+	MachineIds []string
+}
+
+func (c *RetryProvisioningCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "retry-provisioning",
+		Args:    "<machine> ...",
+		Purpose: "retry provisioning of machines that failed to start",
+		Doc:     retryProvisioningDoc,
+	}
+}
+
+func (c *RetryProvisioningCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no machines specified")
+	}
+	for _, id := range args {
+		if !names.IsMachine(id) {
+			return fmt.Errorf("invalid machine id %q", id)
+		}
+	}
+	c.MachineIds = args
+	return nil
+}
+
+func (c *RetryProvisioningCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	results, err := client.RetryProvisioning(c.MachineIds...)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			fmt.Fprintf(ctx.Stderr, "%v\n", result.Error)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("cannot retry provisioning of %d of %d machines", failed, len(results))
+	}
+	return nil
+}
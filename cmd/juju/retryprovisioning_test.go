@@ -0,0 +1,44 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+)
+
+type RetryProvisioningSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&RetryProvisioningSuite{})
+
+func (s *RetryProvisioningSuite) TestInit(c *gc.C) {
+	_, err := testing.RunCommand(c, &RetryProvisioningCommand{}, nil)
+	c.Assert(err, gc.ErrorMatches, "no machines specified")
+	_, err = testing.RunCommand(c, &RetryProvisioningCommand{}, []string{"foo"})
+	c.Assert(err, gc.ErrorMatches, `invalid machine id "foo"`)
+}
+
+func (s *RetryProvisioningSuite) TestRetryProvisioning(c *gc.C) {
+	failed, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = failed.SetStatus(params.StatusError, "quota exceeded", params.StatusData{"attempts": 10})
+	c.Assert(err, gc.IsNil)
+	pending, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &RetryProvisioningCommand{}, []string{failed.Id(), pending.Id()})
+	c.Assert(err, gc.ErrorMatches, "cannot retry provisioning of 1 of 2 machines")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "machine 1 is not in an error state\n")
+
+	_, info, data, err := failed.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.Equals, "quota exceeded")
+	c.Assert(data, gc.DeepEquals, params.StatusData{"transient": true})
+}
@@ -0,0 +1,312 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/utils/parallel"
+	"launchpad.net/juju-core/utils/ssh"
+)
+
+// RunCommand runs commands on machines and units, in parallel.
+type RunCommand struct {
+	cmd.EnvCommandBase
+	out      cmd.Output
+	timeout  time.Duration
+	machines []string
+	services []string
+	units    []string
+	relation string
+	commands string
+}
+
+const runDoc = `
+Run the commands on the specified targets, which are given as machine
+ids, service names and unit names.
+
+Commands run on a machine are run as root, outside any hook context.
+Commands run on a unit are run in the unit's hook context, so hook tools
+such as config-get and relation-get work; a service target runs them on
+each of the service's units. With --relation, they are run in the
+context of the relation with the given id (as found in
+JUJU_RELATION_ID, eg "db:2"), so relation-get and relation-set act on
+that relation by default. Commands never run at the same time as a
+hook on the same machine.
+
+The commands are run by bash on all the targets in parallel. The
+output, errors and exit code of each target are reported as yaml or
+json.
+
+The key of each machine is recorded in $JUJU_HOME/ssh/known_hosts when
+it is first reached, and commands are not run on a machine whose key
+has changed since.
+`
+
+// maxParallelRuns holds the greatest number of targets on which
+// commands are run at once.
+const maxParallelRuns = 50
+
+func (c *RunCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "run",
+		Args:    "<commands>",
+		Purpose: "run commands on remote targets, in the hook context of units",
+		Doc:     runDoc,
+	}
+}
+
+func (c *RunCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+	f.DurationVar(&c.timeout, "timeout", 5*time.Minute, "how long to wait before the remote commands are considered to have failed")
+	f.Var(stringsValue{&c.machines}, "machine", "one or more machine ids, comma separated")
+	f.Var(stringsValue{&c.services}, "service", "one or more service names, comma separated")
+	f.Var(stringsValue{&c.units}, "unit", "one or more unit names, comma separated")
+	f.StringVar(&c.relation, "relation", "", "run the commands on units in the context of this relation")
+}
+
+func (c *RunCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no commands specified")
+	}
+	c.commands, args = args[0], args[1:]
+	c.machines = splitTargets(c.machines)
+	c.services = splitTargets(c.services)
+	c.units = splitTargets(c.units)
+	if len(c.machines) == 0 && len(c.services) == 0 && len(c.units) == 0 {
+		return errors.New("you must specify a target, either through --machine, --service or --unit")
+	}
+	for _, machineId := range c.machines {
+		if !names.IsMachine(machineId) {
+			return fmt.Errorf("invalid machine id %q", machineId)
+		}
+	}
+	for _, service := range c.services {
+		if !names.IsService(service) {
+			return fmt.Errorf("invalid service name %q", service)
+		}
+	}
+	for _, unit := range c.units {
+		if !names.IsUnit(unit) {
+			return fmt.Errorf("invalid unit name %q", unit)
+		}
+	}
+	if c.relation != "" && len(c.services) == 0 && len(c.units) == 0 {
+		return errors.New("--relation can only be used with --service or --unit")
+	}
+	if c.timeout <= 0 {
+		return fmt.Errorf("invalid timeout %v", c.timeout)
+	}
+	return cmd.CheckEmpty(args)
+}
+
+// splitTargets splits each comma separated list of targets into its
+// elements.
+func splitTargets(lists []string) []string {
+	var targets []string
+	for _, list := range lists {
+		for _, target := range strings.Split(list, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// runTarget identifies a machine, or a unit on a machine, on which
+// commands are run.
+type runTarget struct {
+	machineId string
+	unit      string
+	address   string
+}
+
+// runResult holds the outcome of running the commands on a target.
+type runResult struct {
+	MachineId string `json:"machine" yaml:"machine"`
+	Unit      string `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Stdout    string `json:"stdout" yaml:"stdout"`
+	Stderr    string `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+	Code      int    `json:"code" yaml:"code"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (c *RunCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	targets, err := c.resolveTargets(client)
+	if err != nil {
+		return err
+	}
+	results := make([]runResult, len(targets))
+	run := parallel.NewRun(maxParallelRuns)
+	for i, target := range targets {
+		i, target := i, target
+		run.Do(func() error {
+			results[i] = c.runOn(target)
+			return nil
+		})
+	}
+	if err := run.Wait(); err != nil {
+		return err
+	}
+	return c.out.Write(ctx, results)
+}
+
+// resolveTargets returns the machines and units on which the commands
+// are run, in the order they were specified.
+func (c *RunCommand) resolveTargets(client *api.Client) ([]runTarget, error) {
+	status, err := client.FullStatus(nil)
+	if err != nil {
+		return nil, err
+	}
+	machines := make(map[string]api.MachineStatus)
+	var addMachines func(map[string]api.MachineStatus)
+	addMachines = func(ms map[string]api.MachineStatus) {
+		for id, m := range ms {
+			machines[id] = m
+			addMachines(m.Containers)
+		}
+	}
+	addMachines(status.Machines)
+	units := make(map[string]runTarget)
+	serviceUnits := make(map[string][]string)
+	var addUnits func(map[string]api.UnitStatus, string)
+	addUnits = func(us map[string]api.UnitStatus, machineId string) {
+		for name, u := range us {
+			if u.Machine != "" {
+				machineId = u.Machine
+			}
+			units[name] = runTarget{
+				machineId: machineId,
+				unit:      name,
+				address:   machines[machineId].DNSName,
+			}
+			service := names.UnitService(name)
+			serviceUnits[service] = append(serviceUnits[service], name)
+			addUnits(u.Subordinates, machineId)
+		}
+	}
+	for _, s := range status.Services {
+		addUnits(s.Units, "")
+	}
+
+	var targets []runTarget
+	for _, machineId := range c.machines {
+		m, ok := machines[machineId]
+		if !ok {
+			return nil, fmt.Errorf("machine %q not found", machineId)
+		}
+		targets = append(targets, runTarget{machineId: machineId, address: m.DNSName})
+	}
+	unitNames := c.units
+	for _, service := range c.services {
+		if _, ok := status.Services[service]; !ok {
+			return nil, fmt.Errorf("service %q not found", service)
+		}
+		serviceUnitNames := serviceUnits[service]
+		sort.Strings(serviceUnitNames)
+		unitNames = append(unitNames, serviceUnitNames...)
+	}
+	seen := make(map[string]bool)
+	for _, name := range unitNames {
+		target, ok := units[name]
+		if !ok {
+			return nil, fmt.Errorf("unit %q not found", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// remoteCommand returns the command run by ssh on the target's machine.
+func (c *RunCommand) remoteCommand(target runTarget) string {
+	if target.unit == "" {
+		return "sudo juju-run --no-context " + utils.ShQuote(c.commands)
+	}
+	args := []string{"sudo", "juju-run"}
+	if c.relation != "" {
+		args = append(args, "--relation", utils.ShQuote(c.relation))
+	}
+	args = append(args, target.unit, utils.ShQuote(c.commands))
+	return strings.Join(args, " ")
+}
+
+// knownHostsFile returns the path of the file holding the keys of the
+// environment's machines, as recorded when they were first reached.
+func knownHostsFile() string {
+	return config.JujuHomePath("ssh", "known_hosts")
+}
+
+// runOn runs the commands on the target over ssh, and returns the
+// outcome.
+func (c *RunCommand) runOn(target runTarget) runResult {
+	result := runResult{MachineId: target.machineId, Unit: target.unit}
+	if target.address == "" {
+		result.Error = "no public address"
+		return result
+	}
+	command, err := ssh.Command("ubuntu", target.address, c.remoteCommand(target), knownHostsFile())
+	if err != nil {
+		result.Code = 1
+		result.Error = err.Error()
+		return result
+	}
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	err = command.Start()
+	if err == nil {
+		done := make(chan error, 1)
+		go func() {
+			done <- command.Wait()
+		}()
+		select {
+		case err = <-done:
+		case <-time.After(c.timeout):
+			command.Process.Kill()
+			<-done
+			err = fmt.Errorf("command timed out after %v", c.timeout)
+		}
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			result.Code = status.ExitStatus()
+			return result
+		}
+	}
+	if err != nil {
+		result.Code = 1
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs/config"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type RunSuite struct {
+	SSHCommonSuite
+}
+
+var _ = gc.Suite(&RunSuite{})
+
+var runInitTests = []struct {
+	about    string
+	args     []string
+	err      string
+	machines []string
+	services []string
+	units    []string
+}{{
+	about: "no commands",
+	err:   "no commands specified",
+}, {
+	about: "no target",
+	args:  []string{"uname -a"},
+	err:   "you must specify a target, either through --machine, --service or --unit",
+}, {
+	about:    "comma separated and repeated targets",
+	args:     []string{"--machine", "0,1", "--machine", "2", "--service", "mysql", "--unit", "wordpress/0", "uname -a"},
+	machines: []string{"0", "1", "2"},
+	services: []string{"mysql"},
+	units:    []string{"wordpress/0"},
+}, {
+	about: "bad machine id",
+	args:  []string{"--machine", "foo", "uname -a"},
+	err:   `invalid machine id "foo"`,
+}, {
+	about: "bad service name",
+	args:  []string{"--service", "foo/0", "uname -a"},
+	err:   `invalid service name "foo/0"`,
+}, {
+	about: "bad unit name",
+	args:  []string{"--unit", "foo", "uname -a"},
+	err:   `invalid unit name "foo"`,
+}, {
+	about: "relation without units",
+	args:  []string{"--machine", "0", "--relation", "db:1", "uname -a"},
+	err:   "--relation can only be used with --service or --unit",
+}, {
+	about: "extra arguments",
+	args:  []string{"--machine", "0", "uname", "-a"},
+	err:   `unrecognized args: \["-a"\]`,
+}}
+
+func (s *RunSuite) TestInit(c *gc.C) {
+	for i, test := range runInitTests {
+		c.Logf("test %d: %s", i, test.about)
+		runCmd := &RunCommand{}
+		err := coretesting.InitCommand(runCmd, test.args)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(runCmd.machines, gc.DeepEquals, test.machines)
+		c.Check(runCmd.services, gc.DeepEquals, test.services)
+		c.Check(runCmd.units, gc.DeepEquals, test.units)
+	}
+}
+
+func (s *RunSuite) setUpServices(c *gc.C) {
+	m := s.makeMachines(2, c, true)
+	ch := coretesting.Charms.Dir("dummy")
+	curl := charm.MustParseURL(
+		fmt.Sprintf("local:quantal/%s-%d", ch.Meta().Name, ch.Revision()),
+	)
+	bundleURL, err := url.Parse("http://bundles.testing.invalid/dummy-1")
+	c.Assert(err, gc.IsNil)
+	dummy, err := s.State.AddCharm(ch, curl, bundleURL, "dummy-1-sha256")
+	c.Assert(err, gc.IsNil)
+	srv, err := s.State.AddService("mysql", dummy)
+	c.Assert(err, gc.IsNil)
+	s.addUnit(srv, m[0], c)
+	s.addUnit(srv, m[1], c)
+}
+
+func (s *RunSuite) runResults(c *gc.C, args ...string) []map[string]interface{} {
+	ctx, err := coretesting.RunCommand(c, &RunCommand{}, args)
+	c.Assert(err, gc.IsNil)
+	var results []map[string]interface{}
+	err = goyaml.Unmarshal([]byte(coretesting.Stdout(ctx)), &results)
+	c.Assert(err, gc.IsNil)
+	return results
+}
+
+func (s *RunSuite) TestRunOnMachinesAndUnits(c *gc.C) {
+	s.setUpServices(c)
+	results := s.runResults(c, "--machine", "1", "--service", "mysql", "echo 'hi'")
+	sshArgs := runSSHArgs("no")
+	c.Assert(results, gc.DeepEquals, []map[string]interface{}{{
+		"machine": "1",
+		"stdout":  sshArgs + `dummyenv-1.dns sudo juju-run --no-context 'echo '"'"'hi'"'"''` + "\n",
+		"code":    0,
+	}, {
+		"machine": "0",
+		"unit":    "mysql/0",
+		"stdout":  sshArgs + `dummyenv-0.dns sudo juju-run mysql/0 'echo '"'"'hi'"'"''` + "\n",
+		"code":    0,
+	}, {
+		"machine": "1",
+		"unit":    "mysql/1",
+		"stdout":  sshArgs + `dummyenv-1.dns sudo juju-run mysql/1 'echo '"'"'hi'"'"''` + "\n",
+		"code":    0,
+	}})
+}
+
+func (s *RunSuite) TestRunInRelationContext(c *gc.C) {
+	s.setUpServices(c)
+	results := s.runResults(c, "--unit", "mysql/1", "--relation", "db:2", "relation-get")
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0]["stdout"], gc.Equals,
+		runSSHArgs("no")+"dummyenv-1.dns sudo juju-run --relation 'db:2' mysql/1 'relation-get'\n")
+}
+
+func (s *RunSuite) TestRunReportsExitCode(c *gc.C) {
+	s.setUpServices(c)
+	s.writeSSH(c, "#!/bin/bash\necho failed >&2\nexit 3\n")
+	results := s.runResults(c, "--unit", "mysql/0", "false")
+	c.Assert(results, gc.DeepEquals, []map[string]interface{}{{
+		"machine": "0",
+		"unit":    "mysql/0",
+		"stdout":  "",
+		"stderr":  "failed\n",
+		"code":    3,
+	}})
+}
+
+func (s *RunSuite) TestRunTimeout(c *gc.C) {
+	s.setUpServices(c)
+	s.writeSSH(c, "#!/bin/bash\nexec sleep 10\n")
+	start := time.Now()
+	results := s.runResults(c, "--timeout", "100ms", "--machine", "0", "true")
+	c.Assert(time.Since(start) < 5*time.Second, gc.Equals, true)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0]["error"], gc.Equals, "command timed out after 100ms")
+	c.Assert(results[0]["code"], gc.Equals, 1)
+}
+
+func (s *RunSuite) TestRunUnknownTarget(c *gc.C) {
+	s.setUpServices(c)
+	_, err := coretesting.RunCommand(c, &RunCommand{}, []string{"--unit", "mysql/5", "true"})
+	c.Assert(err, gc.ErrorMatches, `unit "mysql/5" not found`)
+	_, err = coretesting.RunCommand(c, &RunCommand{}, []string{"--service", "wordpress", "true"})
+	c.Assert(err, gc.ErrorMatches, `service "wordpress" not found`)
+	_, err = coretesting.RunCommand(c, &RunCommand{}, []string{"--machine", "7", "true"})
+	c.Assert(err, gc.ErrorMatches, `machine "7" not found`)
+}
+
+func (s *RunSuite) TestRunChecksKnownHostKey(c *gc.C) {
+	s.setUpServices(c)
+	err := os.MkdirAll(config.JujuHomePath("ssh"), 0700)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(config.JujuHomePath("ssh", "known_hosts"), []byte("dummyenv-1.dns ssh-rsa AAAAB3Nza\n"), 0600)
+	c.Assert(err, gc.IsNil)
+	results := s.runResults(c, "--machine", "0,1", "true")
+	c.Assert(results, gc.HasLen, 2)
+	c.Assert(results[0]["stdout"], gc.Equals, runSSHArgs("no")+"dummyenv-0.dns sudo juju-run --no-context 'true'\n")
+	c.Assert(results[1]["stdout"], gc.Equals, runSSHArgs("yes")+"dummyenv-1.dns sudo juju-run --no-context 'true'\n")
+}
+
+// runSSHArgs returns the arguments, as echoed by the fake ssh command,
+// with which juju run invokes ssh before the host, given the host key
+// checking it expects.
+func runSSHArgs(strict string) string {
+	return "-l ubuntu -o PasswordAuthentication no -o UserKnownHostsFile " +
+		config.JujuHomePath("ssh", "known_hosts") +
+		" -o HashKnownHosts no -o StrictHostKeyChecking " + strict + " "
+}
+
+// writeSSH replaces the fake ssh command with the given script.
+func (s *RunSuite) writeSSH(c *gc.C, script string) {
+	path, err := exec.LookPath("ssh")
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(path, []byte(script), 0777)
+	c.Assert(err, gc.IsNil)
+}
@@ -0,0 +1,233 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+const setScalingDoc = `
+set-scaling sets the policy used to add and remove units of a service
+automatically. The number of alive units is kept between min and max, and
+is otherwise driven by the policy's signal, which is one of:
+
+   metric:<key>           the average of the metric with the given key, as
+                          reported by the service's units with add-metric
+                          over the last five minutes; a unit is added when
+                          it is above up, and removed when it is below down.
+                          The charm must declare the metric in metrics.yaml
+   schedule:<entries>     a comma-separated list of HH:MM=N entries in UTC,
+                          each of which applies until the next one
+
+After the number of units has been changed, it is left alone for the
+cooldown period, unless it falls outside the bounds of the policy. Every
+change is recorded, and the most recent ones are shown by get-scaling.
+
+With --remove, the service is no longer scaled automatically.
+
+Examples:
+
+   set-scaling wordpress min=2 max=10 signal=metric:load up=0.8 down=0.2 cooldown=10m
+   set-scaling wordpress min=1 max=4 signal=schedule:08:00=4,20:00=1
+   set-scaling --remove wordpress
+`
+
+// SetScalingCommand sets the scaling policy of a service.
+type SetScalingCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	Remove      bool
+	Policy      state.ScalingPolicy
+}
+
+func (c *SetScalingCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-scaling",
+		Args:    "<service> min=<n> max=<n> signal=<signal> [up=<value>] [down=<value>] [cooldown=<duration>]",
+		Purpose: "set the scaling policy of a service",
+		Doc:     setScalingDoc,
+	}
+}
+
+func (c *SetScalingCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.BoolVar(&c.Remove, "remove", false, "stop scaling the service automatically")
+}
+
+func (c *SetScalingCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName, args = args[0], args[1:]
+	if c.Remove {
+		return cmd.CheckEmpty(args)
+	}
+	if len(args) == 0 {
+		return errors.New("no scaling policy specified")
+	}
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf(`expected "key=value", got %q`, arg)
+		}
+		key, value := parts[0], parts[1]
+		if seen[key] {
+			return fmt.Errorf("%s specified more than once", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "min":
+			c.Policy.MinUnits, err = strconv.Atoi(value)
+		case "max":
+			c.Policy.MaxUnits, err = strconv.Atoi(value)
+		case "signal":
+			c.Policy.Signal = value
+		case "up":
+			c.Policy.ScaleUp, err = strconv.ParseFloat(value, 64)
+		case "down":
+			c.Policy.ScaleDown, err = strconv.ParseFloat(value, 64)
+		case "cooldown":
+			c.Policy.Cooldown, err = time.ParseDuration(value)
+		default:
+			return fmt.Errorf("unknown scaling policy key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s", value, key)
+		}
+	}
+	for _, key := range []string{"min", "max", "signal"} {
+		if !seen[key] {
+			return fmt.Errorf("%s not specified", key)
+		}
+	}
+	return c.Policy.Validate()
+}
+
+func (c *SetScalingCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if c.Remove {
+		return client.ServiceSetScaling(c.ServiceName, nil)
+	}
+	return client.ServiceSetScaling(c.ServiceName, &params.ScalingPolicy{
+		MinUnits:  c.Policy.MinUnits,
+		MaxUnits:  c.Policy.MaxUnits,
+		Signal:    c.Policy.Signal,
+		ScaleUp:   c.Policy.ScaleUp,
+		ScaleDown: c.Policy.ScaleDown,
+		Cooldown:  c.Policy.Cooldown,
+	})
+}
+
+const getScalingDoc = `
+get-scaling shows the scaling policy of a service, along with the most
+recent changes made to its number of units by applying the policy, oldest
+first.
+
+See Also:
+   juju help set-scaling
+`
+
+// GetScalingCommand shows the scaling policy of a service.
+type GetScalingCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	out         cmd.Output
+}
+
+func (c *GetScalingCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "get-scaling",
+		Args:    "<service>",
+		Purpose: "show the scaling policy of a service",
+		Doc:     getScalingDoc,
+	}
+}
+
+func (c *GetScalingCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *GetScalingCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no service name specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+type scalingInfo struct {
+	MinUnits  int                   `json:"min-units" yaml:"min-units"`
+	MaxUnits  int                   `json:"max-units" yaml:"max-units"`
+	Signal    string                `json:"signal" yaml:"signal"`
+	ScaleUp   float64               `json:"up,omitempty" yaml:"up,omitempty"`
+	ScaleDown float64               `json:"down,omitempty" yaml:"down,omitempty"`
+	Cooldown  string                `json:"cooldown,omitempty" yaml:"cooldown,omitempty"`
+	Decisions []scalingDecisionInfo `json:"decisions,omitempty" yaml:"decisions,omitempty"`
+}
+
+type scalingDecisionInfo struct {
+	Time   string `json:"time" yaml:"time"`
+	From   int    `json:"from" yaml:"from"`
+	To     int    `json:"to" yaml:"to"`
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+func (c *GetScalingCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	results, err := client.ServiceGetScaling(c.ServiceName)
+	if err != nil {
+		return err
+	}
+	info := scalingInfo{
+		MinUnits:  results.Policy.MinUnits,
+		MaxUnits:  results.Policy.MaxUnits,
+		Signal:    results.Policy.Signal,
+		ScaleUp:   results.Policy.ScaleUp,
+		ScaleDown: results.Policy.ScaleDown,
+	}
+	if results.Policy.Cooldown != 0 {
+		info.Cooldown = results.Policy.Cooldown.String()
+	}
+	for _, d := range results.Decisions {
+		info.Decisions = append(info.Decisions, scalingDecisionInfo{
+			Time:   d.Time.Format(time.RFC3339),
+			From:   d.From,
+			To:     d.To,
+			Reason: d.Reason,
+		})
+	}
+	return c.out.Write(ctx, info)
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type ScalingSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&ScalingSuite{})
+
+var setScalingInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	err: "no service name specified",
+}, {
+	args: []string{"Wordpress", "min=1"},
+	err:  `invalid service name "Wordpress"`,
+}, {
+	args: []string{"wordpress"},
+	err:  "no scaling policy specified",
+}, {
+	args: []string{"wordpress", "min"},
+	err:  `expected "key=value", got "min"`,
+}, {
+	args: []string{"wordpress", "min=one"},
+	err:  `invalid value "one" for min`,
+}, {
+	args: []string{"wordpress", "min=1", "min=2"},
+	err:  "min specified more than once",
+}, {
+	args: []string{"wordpress", "size=1"},
+	err:  `unknown scaling policy key "size"`,
+}, {
+	args: []string{"wordpress", "min=1", "signal=metric:load"},
+	err:  "max not specified",
+}, {
+	args: []string{"wordpress", "min=3", "max=2", "signal=metric:load"},
+	err:  "invalid maximum number of units 2",
+}, {
+	args: []string{"wordpress", "min=1", "max=2", "signal=metric"},
+	err:  `invalid signal "metric"`,
+}, {
+	args: []string{"--remove", "wordpress", "min=1"},
+	err:  `unrecognized args: \["min=1"\]`,
+}}
+
+func (s *ScalingSuite) TestSetScalingInitErrors(c *gc.C) {
+	for i, test := range setScalingInitErrorTests {
+		c.Logf("test %d: %q", i, test.args)
+		err := testing.InitCommand(&SetScalingCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *ScalingSuite) TestSetScaling(c *gc.C) {
+	svc, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &SetScalingCommand{}, []string{
+		"wordpress", "min=2", "max=10", "signal=metric:requests", "up=0.8", "down=0.2", "cooldown=10m",
+	})
+	c.Assert(err, gc.IsNil)
+	policy, err := svc.ScalingPolicy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(*policy, gc.DeepEquals, state.ScalingPolicy{
+		MinUnits:  2,
+		MaxUnits:  10,
+		Signal:    "metric:requests",
+		ScaleUp:   0.8,
+		ScaleDown: 0.2,
+		Cooldown:  10 * time.Minute,
+	})
+
+	_, err = testing.RunCommand(c, &SetScalingCommand{}, []string{"--remove", "wordpress"})
+	c.Assert(err, gc.IsNil)
+	_, err = svc.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	_, err = testing.RunCommand(c, &SetScalingCommand{}, []string{"mysql", "min=1", "max=2", "signal=metric:requests"})
+	c.Assert(err, gc.ErrorMatches, `service "mysql" not found`)
+}
+
+func (s *ScalingSuite) TestGetScaling(c *gc.C) {
+	svc, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &GetScalingCommand{}, []string{"wordpress"})
+	c.Assert(err, gc.ErrorMatches, `scaling policy for service "wordpress" not found`)
+
+	err = svc.SetScalingPolicy(state.ScalingPolicy{
+		MinUnits: 1,
+		MaxUnits: 4,
+		Signal:   "schedule:08:00=4,20:00=1",
+		Cooldown: 5 * time.Minute,
+	})
+	c.Assert(err, gc.IsNil)
+	when := time.Date(2014, 3, 1, 8, 0, 0, 0, time.UTC)
+	err = svc.AddScalingDecision(state.ScalingDecision{
+		Time:   when,
+		From:   1,
+		To:     4,
+		Reason: "scheduled 4 units from 08:00",
+	})
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &GetScalingCommand{}, []string{"--format", "json", "wordpress"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, `{"min-units":1,"max-units":4,`+
+		`"signal":"schedule:08:00=4,20:00=1","cooldown":"5m0s","decisions":[`+
+		`{"time":"2014-03-01T08:00:00Z","from":1,"to":4,"reason":"scheduled 4 units from 08:00"}]}`+"\n")
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+const setRecoveryDoc = `
+set-recovery sets what happens to the units of a service when the machine
+they are deployed to fails, because its instance has disappeared from the
+provider or its agent has been down for too long.
+
+With the "manual" policy, which is the default, the units are left on the
+failed machine. With the "auto" policy, the failed machine is marked as such,
+a replacement machine with the same constraints is provisioned, and the
+service's units are deployed there. State server machines are never
+replaced.
+
+Examples:
+
+   set-recovery wordpress auto
+   set-recovery mysql manual
+`
+
+// SetRecoveryCommand sets the recovery policy of a service.
+type SetRecoveryCommand struct {
+	cmd.EnvCommandBase
+	ServiceName string
+	Policy      state.RecoveryPolicy
+}
+
+func (c *SetRecoveryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "set-recovery",
+		Args:    "<service> auto|manual",
+		Purpose: "set the recovery policy of a service",
+		Doc:     setRecoveryDoc,
+	}
+}
+
+func (c *SetRecoveryCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("no service name specified")
+	case 1:
+		return errors.New("no recovery policy specified")
+	}
+	if !names.IsService(args[0]) {
+		return fmt.Errorf("invalid service name %q", args[0])
+	}
+	c.ServiceName = args[0]
+	policy, err := state.ParseRecoveryPolicy(args[1])
+	if err != nil {
+		return err
+	}
+	c.Policy = policy
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *SetRecoveryCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.ServiceUpdate(params.ServiceUpdate{
+		ServiceName:    c.ServiceName,
+		RecoveryPolicy: string(c.Policy),
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+)
+
+type SetRecoverySuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&SetRecoverySuite{})
+
+var setRecoveryInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	err: "no service name specified",
+}, {
+	args: []string{"wordpress"},
+	err:  "no recovery policy specified",
+}, {
+	args: []string{"Wordpress", "auto"},
+	err:  `invalid service name "Wordpress"`,
+}, {
+	args: []string{"wordpress", "sometimes"},
+	err:  `invalid recovery policy "sometimes"`,
+}, {
+	args: []string{"wordpress", "auto", "manual"},
+	err:  `unrecognized args: \["manual"\]`,
+}}
+
+func (s *SetRecoverySuite) TestInitErrors(c *gc.C) {
+	for i, test := range setRecoveryInitErrorTests {
+		c.Logf("test %d: %q", i, test.args)
+		err := testing.InitCommand(&SetRecoveryCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *SetRecoverySuite) TestSetRecovery(c *gc.C) {
+	svc, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	_, err = testing.RunCommand(c, &SetRecoveryCommand{}, []string{"wordpress", "auto"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Refresh(), gc.IsNil)
+	c.Assert(svc.RecoveryPolicy(), gc.Equals, state.RecoveryAuto)
+
+	_, err = testing.RunCommand(c, &SetRecoveryCommand{}, []string{"wordpress", "manual"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.Refresh(), gc.IsNil)
+	c.Assert(svc.RecoveryPolicy(), gc.Equals, state.RecoveryManual)
+
+	_, err = testing.RunCommand(c, &SetRecoveryCommand{}, []string{"mysql", "auto"})
+	c.Assert(err, gc.ErrorMatches, `service "mysql" not found`)
+}
@@ -5,29 +5,25 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"path"
-	"regexp"
-	"strings"
+	"time"
 
 	"launchpad.net/gnuflag"
 
-	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
-	"launchpad.net/juju-core/environs"
-	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju"
-	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
-	"launchpad.net/juju-core/tools"
-	"launchpad.net/juju-core/utils/set"
 )
 
 type StatusCommand struct {
 	cmd.EnvCommandBase
 	out      cmd.Output
 	patterns []string
+	color    bool
+	watch    bool
 }
 
 var statusDoc = `
@@ -42,6 +38,17 @@ will be displayed.
 Wildcards ('*') may be specified in service/unit names to match any sequence
 of characters. For example, 'nova-*' will match any service whose name begins
 with 'nova-': 'nova-compute', 'nova-volume', etc.
+
+While the environment is being upgraded, or if the last upgrade failed, the
+progress of the upgrade is reported, along with the agents that have yet to
+run the new version.
+
+Besides yaml and json, status can be shown in a tabular format, with a
+section each for machines, services and units, or in the oneline format,
+which shows one line for each unit. With --color, agents in an error state
+are shown in red and pending ones in yellow in those formats. With --watch,
+the status is shown again whenever the environment changes, until the
+command is interrupted.
 `
 
 func (c *StatusCommand) Info() *cmd.Info {
@@ -57,9 +64,13 @@ func (c *StatusCommand) Info() *cmd.Info {
 func (c *StatusCommand) SetFlags(f *gnuflag.FlagSet) {
 	c.EnvCommandBase.SetFlags(f)
 	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
-		"yaml": cmd.FormatYaml,
-		"json": cmd.FormatJson,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+		"tabular": cmd.FormatTabular,
+		"oneline": cmd.FormatOneline,
 	})
+	f.BoolVar(&c.color, "color", false, "highlight error and pending states in tabular and oneline formats")
+	f.BoolVar(&c.watch, "watch", false, "show the status again whenever the environment changes")
 }
 
 func (c *StatusCommand) Init(args []string) error {
@@ -67,102 +78,6 @@ func (c *StatusCommand) Init(args []string) error {
 	return nil
 }
 
-type statusContext struct {
-	instances map[instance.Id]instance.Instance
-	machines  map[string][]*state.Machine
-	services  map[string]*state.Service
-	units     map[string]map[string]*state.Unit
-}
-
-type unitMatcher struct {
-	patterns []string
-}
-
-// matchesAny returns true if the unitMatcher will
-// match any unit, regardless of its attributes.
-func (m unitMatcher) matchesAny() bool {
-	return len(m.patterns) == 0
-}
-
-// matchUnit attempts to match a state.Unit to one of
-// a set of patterns, taking into account subordinate
-// relationships.
-func (m unitMatcher) matchUnit(u *state.Unit) bool {
-	if m.matchesAny() {
-		return true
-	}
-
-	// Keep the unit if:
-	//  (a) its name matches a pattern, or
-	//  (b) it's a principal and one of its subordinates matches, or
-	//  (c) it's a subordinate and its principal matches.
-	//
-	// Note: do *not* include a second subordinate if the principal is
-	// only matched on account of a first subordinate matching.
-	if m.matchString(u.Name()) {
-		return true
-	}
-	if u.IsPrincipal() {
-		for _, s := range u.SubordinateNames() {
-			if m.matchString(s) {
-				return true
-			}
-		}
-		return false
-	}
-	principal, valid := u.PrincipalName()
-	if !valid {
-		panic("PrincipalName failed for subordinate unit")
-	}
-	return m.matchString(principal)
-}
-
-// matchString matches a string to one of the patterns in
-// the unit matcher, returning an error if a pattern with
-// invalid syntax is encountered.
-func (m unitMatcher) matchString(s string) bool {
-	for _, pattern := range m.patterns {
-		ok, err := path.Match(pattern, s)
-		if err != nil {
-			// We validate patterns, so should never get here.
-			panic(fmt.Errorf("pattern syntax error in %q", pattern))
-		} else if ok {
-			return true
-		}
-	}
-	return false
-}
-
-// validPattern must match the parts of a unit or service name
-// pattern either side of the '/' for it to be valid.
-var validPattern = regexp.MustCompile("^[a-z0-9-*]+$")
-
-// newUnitMatcher returns a unitMatcher that matches units
-// with one of the specified patterns, or all units if no
-// patterns are specified.
-//
-// An error will be returned if any of the specified patterns
-// is invalid. Patterns are valid if they contain only
-// alpha-numeric characters, hyphens, or asterisks (and one
-// optional '/' to separate service/unit).
-func newUnitMatcher(patterns []string) (unitMatcher, error) {
-	for i, pattern := range patterns {
-		fields := strings.Split(pattern, "/")
-		if len(fields) > 2 {
-			return unitMatcher{}, fmt.Errorf("pattern %q contains too many '/' characters", pattern)
-		}
-		for _, f := range fields {
-			if !validPattern.MatchString(f) {
-				return unitMatcher{}, fmt.Errorf("pattern %q contains invalid characters", pattern)
-			}
-		}
-		if len(fields) == 1 {
-			patterns[i] += "/*"
-		}
-	}
-	return unitMatcher{patterns}, nil
-}
-
 var connectionError = `Unable to connect to environment "%s".
 Please check your credentials or use 'juju bootstrap' to create a new environment.
 
@@ -170,374 +85,187 @@ Error details:
 %v
 `
 
+// clearScreen holds the ANSI escape sequences that clear a terminal
+// and move the cursor to its top left corner.
+const clearScreen = "\x1b[H\x1b[2J"
+
 func (c *StatusCommand) Run(ctx *cmd.Context) error {
-	conn, err := juju.NewConnFromName(c.EnvName)
+	client, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return fmt.Errorf(connectionError, c.EnvName, err)
 	}
-	defer conn.Close()
-
-	var context statusContext
-	unitMatcher, err := newUnitMatcher(c.patterns)
-	if err != nil {
-		return err
+	defer client.Close()
+	if !c.watch {
+		return c.writeStatus(ctx, client)
 	}
-	if context.services, context.units, err = fetchAllServicesAndUnits(conn.State, unitMatcher); err != nil {
+	watcher, err := client.WatchAll()
+	if err != nil {
 		return err
 	}
-
-	// Filter machines by units in scope.
-	var machineIds *set.Strings
-	if !unitMatcher.matchesAny() {
-		machineIds, err = fetchUnitMachineIds(context.units)
-		if err != nil {
+	defer watcher.Stop()
+	for {
+		// The first call returns at once, with the initial state
+		// of the environment.
+		if _, err := watcher.Next(); err != nil {
 			return err
 		}
-	}
-	if context.machines, err = fetchMachines(conn.State, machineIds); err != nil {
-		return err
-	}
-
-	context.instances, err = fetchAllInstances(conn.Environ)
-	if err != nil {
-		// We cannot see instances from the environment, but
-		// there's still lots of potentially useful info to print.
-		fmt.Fprintf(ctx.Stderr, "cannot retrieve instances from the environment: %v\n", err)
-	}
-	result := struct {
-		Environment string                   `json:"environment"`
-		Machines    map[string]machineStatus `json:"machines"`
-		Services    map[string]serviceStatus `json:"services"`
-	}{
-		Environment: conn.Environ.Name(),
-		Machines:    context.processMachines(),
-		Services:    context.processServices(),
-	}
-	return c.out.Write(ctx, result)
-}
-
-// fetchAllInstances returns a map from instance id to instance.
-func fetchAllInstances(env environs.Environ) (map[instance.Id]instance.Instance, error) {
-	m := make(map[instance.Id]instance.Instance)
-	insts, err := env.AllInstances()
-	if err != nil {
-		return nil, err
-	}
-	for _, i := range insts {
-		m[i.Id()] = i
-	}
-	return m, nil
-}
-
-// fetchMachines returns a map from top level machine id to machines, where machines[0] is the host
-// machine and machines[1..n] are any containers (including nested ones).
-//
-// If machineIds is non-nil, only machines whose IDs are in the set are returned.
-func fetchMachines(st *state.State, machineIds *set.Strings) (map[string][]*state.Machine, error) {
-	v := make(map[string][]*state.Machine)
-	machines, err := st.AllMachines()
-	if err != nil {
-		return nil, err
-	}
-	// AllMachines gives us machines sorted by id.
-	for _, m := range machines {
-		if machineIds != nil && !machineIds.Contains(m.Id()) {
-			continue
-		}
-		parentId, ok := m.ParentId()
-		if !ok {
-			// Only top level host machines go directly into the machine map.
-			v[m.Id()] = []*state.Machine{m}
-		} else {
-			topParentId := state.TopParentId(m.Id())
-			machines, ok := v[topParentId]
-			if !ok {
-				panic(fmt.Errorf("unexpected machine id %q", parentId))
-			}
-			machines = append(machines, m)
-			v[topParentId] = machines
+		fmt.Fprint(ctx.Stdout, clearScreen)
+		if err := c.writeStatus(ctx, client); err != nil {
+			return err
 		}
 	}
-	return v, nil
 }
 
-// fetchAllServicesAndUnits returns a map from service name to service
-// and a map from service name to unit name to unit.
-func fetchAllServicesAndUnits(st *state.State, unitMatcher unitMatcher) (map[string]*state.Service, map[string]map[string]*state.Unit, error) {
-	svcMap := make(map[string]*state.Service)
-	unitMap := make(map[string]map[string]*state.Unit)
-	services, err := st.AllServices()
+// writeStatus fetches the status of the environment and writes it in
+// the chosen format.
+func (c *StatusCommand) writeStatus(ctx *cmd.Context, client *api.Client) error {
+	status, err := client.FullStatus(c.patterns)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-	for _, s := range services {
-		units, err := s.AllUnits()
-		if err != nil {
-			return nil, nil, err
-		}
-		svcUnitMap := make(map[string]*state.Unit)
-		for _, u := range units {
-			if !unitMatcher.matchUnit(u) {
-				continue
-			}
-			svcUnitMap[u.Name()] = u
-		}
-		if unitMatcher.matchesAny() || len(svcUnitMap) > 0 {
-			unitMap[s.Name()] = svcUnitMap
-			svcMap[s.Name()] = s
-		}
+	result := formattedStatus{
+		Environment: status.EnvironmentName,
+		Upgrade:     formatUpgrade(status.Upgrade),
+		Machines:    formatMachines(status.Machines),
+		Services:    formatServices(status.Services),
 	}
-	return svcMap, unitMap, nil
-}
-
-// fetchUnitMachineIds returns a set of IDs for machines that
-// the specified units reside on, and those machines' ancestors.
-func fetchUnitMachineIds(units map[string]map[string]*state.Unit) (*set.Strings, error) {
-	machineIds := new(set.Strings)
-	for _, svcUnitMap := range units {
-		for _, unit := range svcUnitMap {
-			if !unit.IsPrincipal() {
-				continue
-			}
-			mid, err := unit.AssignedMachineId()
-			if err != nil {
-				return nil, err
-			}
-			for mid != "" {
-				machineIds.Add(mid)
-				mid = state.ParentId(mid)
-			}
-		}
+	switch c.out.Name() {
+	case "tabular", "oneline":
+		return c.out.Write(ctx, &statusView{result, c.color})
 	}
-	return machineIds, nil
+	return c.out.Write(ctx, result)
 }
 
-func (context *statusContext) processMachines() map[string]machineStatus {
-	machinesMap := make(map[string]machineStatus)
-	for id, machines := range context.machines {
-		hostStatus := context.makeMachineStatus(machines[0])
-		context.processMachine(machines, &hostStatus, 0)
-		machinesMap[id] = hostStatus
-	}
-	return machinesMap
+type formattedStatus struct {
+	Environment string                   `json:"environment"`
+	Upgrade     *upgradeStatus           `json:"upgrade,omitempty" yaml:"upgrade,omitempty"`
+	Machines    map[string]machineStatus `json:"machines"`
+	Services    map[string]serviceStatus `json:"services"`
 }
 
-func (context *statusContext) processMachine(machines []*state.Machine, host *machineStatus, startIndex int) (nextIndex int) {
-	nextIndex = startIndex + 1
-	currentHost := host
-	var previousContainer *machineStatus
-	for nextIndex < len(machines) {
-		machine := machines[nextIndex]
-		container := context.makeMachineStatus(machine)
-		if currentHost.Id == state.ParentId(machine.Id()) {
-			currentHost.Containers[machine.Id()] = container
-			previousContainer = &container
-			nextIndex++
-		} else {
-			if state.NestingLevel(machine.Id()) > state.NestingLevel(previousContainer.Id) {
-				nextIndex = context.processMachine(machines, previousContainer, nextIndex-1)
-			} else {
-				break
-			}
-		}
+// statusError returns the error described by the given message, or
+// nil if the message is empty.
+func statusError(msg string) error {
+	if msg == "" {
+		return nil
 	}
-	return
+	return errors.New(msg)
 }
 
-func (context *statusContext) makeMachineStatus(machine *state.Machine) (status machineStatus) {
-	status.Id = machine.Id()
-	status.Life,
-		status.AgentVersion,
-		status.AgentState,
-		status.AgentStateInfo,
-		status.Err = processAgent(machine)
-	status.Series = machine.Series()
-	instid, err := machine.InstanceId()
-	if err == nil {
-		status.InstanceId = instid
-		inst, ok := context.instances[instid]
-		if ok {
-			status.DNSName, _ = inst.DNSName()
-			status.InstanceState = inst.Status()
-		} else {
-			// Double plus ungood.  There is an instance id recorded
-			// for this machine in the state, yet the environ cannot
-			// find that id.
-			status.InstanceState = "missing"
-		}
-	} else {
-		if state.IsNotProvisionedError(err) {
-			status.InstanceId = "pending"
-		} else {
-			status.InstanceId = "error"
-		}
-		// There's no point in reporting a pending agent state
-		// if the machine hasn't been provisioned. This
-		// also makes unprovisioned machines visually distinct
-		// in the output.
-		status.AgentState = ""
+func formatUpgrade(upgrade *api.UpgradeStatus) *upgradeStatus {
+	if upgrade == nil {
+		return nil
 	}
-	hc, err := machine.HardwareCharacteristics()
-	if err != nil {
-		if !errors.IsNotFoundError(err) {
-			status.Hardware = "error"
-		}
-	} else {
-		status.Hardware = hc.String()
+	return &upgradeStatus{
+		From:          upgrade.From.String(),
+		To:            upgrade.To.String(),
+		Status:        upgrade.Status,
+		Error:         upgrade.Error,
+		PendingAgents: upgrade.PendingAgents,
 	}
-	status.Containers = make(map[string]machineStatus)
-	return
 }
 
-func (context *statusContext) processServices() map[string]serviceStatus {
-	servicesMap := make(map[string]serviceStatus)
-	for _, s := range context.services {
-		servicesMap[s.Name()] = context.processService(s)
+func formatMachines(machines map[string]api.MachineStatus) map[string]machineStatus {
+	out := make(map[string]machineStatus)
+	for id, m := range machines {
+		out[id] = formatMachine(m)
 	}
-	return servicesMap
+	return out
 }
 
-func (context *statusContext) processService(service *state.Service) (status serviceStatus) {
-	url, _ := service.CharmURL()
-	status.Charm = url.String()
-	status.Exposed = service.IsExposed()
-	status.Life = processLife(service)
-	var err error
-	status.Relations, status.SubordinateTo, err = context.processRelations(service)
-	if err != nil {
-		status.Err = err
-		return
+func formatMachine(machine api.MachineStatus) machineStatus {
+	out := machineStatus{
+		Err:            statusError(machine.Err),
+		AgentState:     machine.AgentState,
+		AgentStateInfo: machine.AgentStateInfo,
+		AgentVersion:   machine.AgentVersion,
+		DNSName:        machine.DNSName,
+		InstanceId:     machine.InstanceId,
+		InstanceState:  machine.InstanceState,
+		Life:           machine.Life,
+		Series:         machine.Series,
+		Id:             machine.Id,
+		Containers:     make(map[string]machineStatus),
+		Hardware:       machine.Hardware,
 	}
-	if service.IsPrincipal() {
-		status.Units = context.processUnits(context.units[service.Name()])
+	for id, container := range machine.Containers {
+		out.Containers[id] = formatMachine(container)
 	}
-	return status
+	return out
 }
 
-func (context *statusContext) processUnits(units map[string]*state.Unit) map[string]unitStatus {
-	unitsMap := make(map[string]unitStatus)
-	for _, unit := range units {
-		unitsMap[unit.Name()] = context.processUnit(unit)
+func formatServices(services map[string]api.ServiceStatus) map[string]serviceStatus {
+	out := make(map[string]serviceStatus)
+	for name, service := range services {
+		out[name] = serviceStatus{
+			Err:           statusError(service.Err),
+			Charm:         service.Charm,
+			Exposed:       service.Exposed,
+			Life:          service.Life,
+			Relations:     service.Relations,
+			SubordinateTo: service.SubordinateTo,
+			Units:         formatUnits(service.Units),
+			Scaling:       formatScaling(service.Scaling),
+			Status:        formatWorkloadStatus(service.Status),
+		}
 	}
-	return unitsMap
+	return out
 }
 
-func (context *statusContext) processUnit(unit *state.Unit) (status unitStatus) {
-	status.PublicAddress, _ = unit.PublicAddress()
-	for _, port := range unit.OpenedPorts() {
-		status.OpenedPorts = append(status.OpenedPorts, port.String())
+func formatScaling(scaling *api.ScalingStatus) *scalingStatus {
+	if scaling == nil {
+		return nil
 	}
-	if unit.IsPrincipal() {
-		status.Machine, _ = unit.AssignedMachineId()
+	out := &scalingStatus{
+		MinUnits: scaling.MinUnits,
+		MaxUnits: scaling.MaxUnits,
+		Signal:   scaling.Signal,
 	}
-	status.Life,
-		status.AgentVersion,
-		status.AgentState,
-		status.AgentStateInfo,
-		status.Err = processAgent(unit)
-	if subUnits := unit.SubordinateNames(); len(subUnits) > 0 {
-		status.Subordinates = make(map[string]unitStatus)
-		for _, name := range subUnits {
-			subUnit := context.unitByName(name)
-			// subUnit may be nil if subordinate was filtered out.
-			if subUnit != nil {
-				status.Subordinates[name] = context.processUnit(subUnit)
-			}
-		}
+	if last := scaling.LastDecision; last != nil {
+		out.LastChange = fmt.Sprintf("%s: %d -> %d units (%s)", last.Time.Format(time.RFC3339), last.From, last.To, last.Reason)
 	}
-	return
-}
-
-func (context *statusContext) unitByName(name string) *state.Unit {
-	serviceName := strings.Split(name, "/")[0]
-	return context.units[serviceName][name]
+	return out
 }
 
-func (*statusContext) processRelations(service *state.Service) (related map[string][]string, subord []string, err error) {
-	// TODO(mue) This way the same relation is read twice (for each service).
-	// Maybe add Relations() to state, read them only once and pass them to each
-	// call of this function.
-	relations, err := service.Relations()
-	if err != nil {
-		return nil, nil, err
+func formatUnits(units map[string]api.UnitStatus) map[string]unitStatus {
+	if len(units) == 0 {
+		return nil
 	}
-	var subordSet set.Strings
-	related = make(map[string][]string)
-	for _, relation := range relations {
-		ep, err := relation.Endpoint(service.Name())
-		if err != nil {
-			return nil, nil, err
-		}
-		relationName := ep.Relation.Name
-		eps, err := relation.RelatedEndpoints(service.Name())
-		if err != nil {
-			return nil, nil, err
-		}
-		for _, ep := range eps {
-			if ep.Scope == charm.ScopeContainer && !service.IsPrincipal() {
-				subordSet.Add(ep.ServiceName)
-			}
-			related[relationName] = append(related[relationName], ep.ServiceName)
+	out := make(map[string]unitStatus)
+	for name, unit := range units {
+		out[name] = unitStatus{
+			Err:                statusError(unit.Err),
+			AgentState:         unit.AgentState,
+			AgentStateInfo:     unit.AgentStateInfo,
+			AgentVersion:       unit.AgentVersion,
+			WorkloadStatus:     formatWorkloadStatus(unit.WorkloadStatus),
+			WorkloadStatusInfo: unit.WorkloadStatusInfo,
+			Life:               unit.Life,
+			Machine:            unit.Machine,
+			OpenedPorts:        unit.OpenedPorts,
+			PublicAddress:      unit.PublicAddress,
+			Subordinates:       formatUnits(unit.Subordinates),
 		}
 	}
-	for relationName, serviceNames := range related {
-		sn := set.NewStrings(serviceNames...)
-		related[relationName] = sn.SortedValues()
-	}
-	return related, subordSet.SortedValues(), nil
-}
-
-type lifer interface {
-	Life() state.Life
-}
-
-type stateAgent interface {
-	lifer
-	AgentAlive() (bool, error)
-	AgentTools() (*tools.Tools, error)
-	Status() (params.Status, string, params.StatusData, error)
+	return out
 }
 
-// processAgent retrieves version and status information from the given entity
-// and sets the destination version, status and info values accordingly.
-func processAgent(entity stateAgent) (life string, version string, status params.Status, info string, err error) {
-	life = processLife(entity)
-	if t, err := entity.AgentTools(); err == nil {
-		version = t.Version.Number.String()
+// formatWorkloadStatus returns the workload status to show, which is
+// empty when the charm has not reported it, so that the status of
+// charms that do not use status-set is unchanged.
+func formatWorkloadStatus(status params.WorkloadStatus) params.WorkloadStatus {
+	if status == params.WorkloadUnknown {
+		return ""
 	}
-	// TODO(mue) StatusData may be useful here too.
-	status, info, _, err = entity.Status()
-	if err != nil {
-		return
-	}
-	if status == params.StatusPending {
-		// The status is pending - there's no point
-		// in enquiring about the agent liveness.
-		return
-	}
-	agentAlive, err := entity.AgentAlive()
-	if err != nil {
-		return
-	}
-	if entity.Life() != state.Dead && !agentAlive {
-		// The agent *should* be alive but is not.
-		// Add the original status to the info, so it's not lost.
-		if info != "" {
-			info = fmt.Sprintf("(%s: %s)", status, info)
-		} else {
-			info = fmt.Sprintf("(%s)", status)
-		}
-		status = params.StatusDown
-	}
-	return
+	return status
 }
 
-func processLife(entity lifer) string {
-	if life := entity.Life(); life != state.Alive {
-		// alive is the usual state so omit it by default.
-		return life.String()
-	}
-	return ""
+type upgradeStatus struct {
+	From          string   `json:"from" yaml:"from"`
+	To            string   `json:"to" yaml:"to"`
+	Status        string   `json:"status" yaml:"status"`
+	Error         string   `json:"error,omitempty" yaml:"error,omitempty"`
+	PendingAgents []string `json:"pending-agents,omitempty" yaml:"pending-agents,omitempty"`
 }
 
 type machineStatus struct {
@@ -589,9 +317,18 @@ type serviceStatus struct {
 	Relations     map[string][]string   `json:"relations,omitempty" yaml:"relations,omitempty"`
 	SubordinateTo []string              `json:"subordinate-to,omitempty" yaml:"subordinate-to,omitempty"`
 	Units         map[string]unitStatus `json:"units,omitempty" yaml:"units,omitempty"`
+	Scaling       *scalingStatus        `json:"scaling,omitempty" yaml:"scaling,omitempty"`
+	Status        params.WorkloadStatus `json:"status,omitempty" yaml:"status,omitempty"`
 }
 type serviceStatusNoMarshal serviceStatus
 
+type scalingStatus struct {
+	MinUnits   int    `json:"min-units" yaml:"min-units"`
+	MaxUnits   int    `json:"max-units" yaml:"max-units"`
+	Signal     string `json:"signal" yaml:"signal"`
+	LastChange string `json:"last-change,omitempty" yaml:"last-change,omitempty"`
+}
+
 func (s serviceStatus) MarshalJSON() ([]byte, error) {
 	if s.Err != nil {
 		return json.Marshal(errorStatus{s.Err.Error()})
@@ -609,15 +346,17 @@ func (s serviceStatus) GetYAML() (tag string, value interface{}) {
 }
 
 type unitStatus struct {
-	Err            error                 `json:"-" yaml:",omitempty"`
-	AgentState     params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
-	AgentStateInfo string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
-	AgentVersion   string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
-	Life           string                `json:"life,omitempty" yaml:"life,omitempty"`
-	Machine        string                `json:"machine,omitempty" yaml:"machine,omitempty"`
-	OpenedPorts    []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
-	PublicAddress  string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
-	Subordinates   map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
+	Err                error                 `json:"-" yaml:",omitempty"`
+	AgentState         params.Status         `json:"agent-state,omitempty" yaml:"agent-state,omitempty"`
+	AgentStateInfo     string                `json:"agent-state-info,omitempty" yaml:"agent-state-info,omitempty"`
+	AgentVersion       string                `json:"agent-version,omitempty" yaml:"agent-version,omitempty"`
+	WorkloadStatus     params.WorkloadStatus `json:"workload-status,omitempty" yaml:"workload-status,omitempty"`
+	WorkloadStatusInfo string                `json:"workload-status-info,omitempty" yaml:"workload-status-info,omitempty"`
+	Life               string                `json:"life,omitempty" yaml:"life,omitempty"`
+	Machine            string                `json:"machine,omitempty" yaml:"machine,omitempty"`
+	OpenedPorts        []string              `json:"open-ports,omitempty" yaml:"open-ports,omitempty"`
+	PublicAddress      string                `json:"public-address,omitempty" yaml:"public-address,omitempty"`
+	Subordinates       map[string]unitStatus `json:"subordinates,omitempty" yaml:"subordinates,omitempty"`
 }
 
 type unitStatusNoMarshal unitStatus
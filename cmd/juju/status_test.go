@@ -16,8 +16,8 @@ import (
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/instance"
-	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
@@ -59,15 +59,18 @@ type stepper interface {
 
 type context struct {
 	st      *state.State
-	conn    *juju.Conn
+	env     environs.Environ
 	charms  map[string]*state.Charm
 	pingers map[string]*presence.Pinger
 }
 
 func (s *StatusSuite) newContext() *context {
+	// The status command gets the status from the API server, so the
+	// changes are made through the API server's own state, whose
+	// presence watcher then notices the agents started at once.
 	return &context{
-		st:      s.State,
-		conn:    s.Conn,
+		st:      s.BackingState,
+		env:     s.Conn.Environ,
 		charms:  make(map[string]*state.Charm),
 		pingers: make(map[string]*presence.Pinger),
 	}
@@ -1213,6 +1216,94 @@ var statusTests = []testCase{
 				},
 			},
 		},
+	), test(
+		"upgrade progress",
+		addMachine{machineId: "0", job: state.JobManageEnviron},
+		setTools{"0", version.MustParseBinary("1.2.3-quantal-amd64")},
+		startUpgrade{"1.2.3", "1.2.4"},
+		expect{
+			"the upgrade has started and machine 0 has yet to upgrade",
+			M{
+				"environment": "dummyenv",
+				"upgrade": M{
+					"from":           "1.2.3",
+					"to":             "1.2.4",
+					"status":         "started",
+					"pending-agents": L{"machine-0"},
+				},
+				"machines": M{
+					"0": M{
+						"agent-version": "1.2.3",
+						"instance-id":   "pending",
+						"series":        "quantal",
+					},
+				},
+				"services": M{},
+			},
+		},
+
+		abortUpgrade{"cannot migrate"},
+		expect{
+			"the upgrade failed and was rolled back",
+			M{
+				"environment": "dummyenv",
+				"upgrade": M{
+					"from":   "1.2.3",
+					"to":     "1.2.4",
+					"status": "failed",
+					"error":  "cannot migrate",
+				},
+				"machines": M{
+					"0": M{
+						"agent-version": "1.2.3",
+						"instance-id":   "pending",
+						"series":        "quantal",
+					},
+				},
+				"services": M{},
+			},
+		},
+	), test(
+		"workload status reported by charms",
+		addCharm{"dummy"},
+		addService{"dummy-service", "dummy"},
+		addMachine{machineId: "0", job: state.JobHostUnits},
+		addUnit{"dummy-service", "0"},
+		addUnit{"dummy-service", "0"},
+		setUnitWorkloadStatus{"dummy-service/0", params.WorkloadActive, ""},
+		setUnitWorkloadStatus{"dummy-service/1", params.WorkloadWaiting, "syncing database"},
+		expect{
+			"the service status is that of its unit needing the most attention",
+			M{
+				"environment": "dummyenv",
+				"machines": M{
+					"0": M{
+						"instance-id": "pending",
+						"series":      "quantal",
+					},
+				},
+				"services": M{
+					"dummy-service": M{
+						"charm":   "local:quantal/dummy-1",
+						"exposed": false,
+						"status":  "waiting",
+						"units": M{
+							"dummy-service/0": M{
+								"machine":         "0",
+								"agent-state":     "pending",
+								"workload-status": "active",
+							},
+							"dummy-service/1": M{
+								"machine":              "0",
+								"agent-state":          "pending",
+								"workload-status":      "waiting",
+								"workload-status-info": "syncing database",
+							},
+						},
+					},
+				},
+			},
+		},
 	),
 }
 
@@ -1262,7 +1353,7 @@ func (sm startMachine) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 	cons, err := m.Constraints()
 	c.Assert(err, gc.IsNil)
-	inst, hc := testing.AssertStartInstanceWithConstraints(c, ctx.conn.Environ, m.Id(), cons)
+	inst, hc := testing.AssertStartInstanceWithConstraints(c, ctx.env, m.Id(), cons)
 	err = m.SetProvisioned(inst.Id(), "fake_nonce", hc)
 	c.Assert(err, gc.IsNil)
 }
@@ -1276,7 +1367,7 @@ func (sm startMissingMachine) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 	cons, err := m.Constraints()
 	c.Assert(err, gc.IsNil)
-	_, hc := testing.AssertStartInstanceWithConstraints(c, ctx.conn.Environ, m.Id(), cons)
+	_, hc := testing.AssertStartInstanceWithConstraints(c, ctx.env, m.Id(), cons)
 	err = m.SetProvisioned("i-missing", "fake_nonce", hc)
 	c.Assert(err, gc.IsNil)
 }
@@ -1298,7 +1389,7 @@ func (sam startAliveMachine) step(c *gc.C, ctx *context) {
 	c.Assert(agentAlive, gc.Equals, true)
 	cons, err := m.Constraints()
 	c.Assert(err, gc.IsNil)
-	inst, hc := testing.AssertStartInstanceWithConstraints(c, ctx.conn.Environ, m.Id(), cons)
+	inst, hc := testing.AssertStartInstanceWithConstraints(c, ctx.env, m.Id(), cons)
 	err = m.SetProvisioned(inst.Id(), "fake_nonce", hc)
 	c.Assert(err, gc.IsNil)
 	ctx.pingers[m.Id()] = pinger
@@ -1316,6 +1407,29 @@ func (st setTools) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type startUpgrade struct {
+	from string
+	to   string
+}
+
+func (su startUpgrade) step(c *gc.C, ctx *context) {
+	err := ctx.st.SetEnvironAgentVersion(version.MustParse(su.from))
+	c.Assert(err, gc.IsNil)
+	err = ctx.st.StartUpgrade(version.MustParse(su.to))
+	c.Assert(err, gc.IsNil)
+}
+
+type abortUpgrade struct {
+	reason string
+}
+
+func (au abortUpgrade) step(c *gc.C, ctx *context) {
+	info, err := ctx.st.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	err = info.Abort(fmt.Errorf("%s", au.reason))
+	c.Assert(err, gc.IsNil)
+}
+
 type addCharm struct {
 	name string
 }
@@ -1433,6 +1547,19 @@ func (sus setUnitStatus) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type setUnitWorkloadStatus struct {
+	unitName string
+	status   params.WorkloadStatus
+	info     string
+}
+
+func (sws setUnitWorkloadStatus) step(c *gc.C, ctx *context) {
+	u, err := ctx.st.Unit(sws.unitName)
+	c.Assert(err, gc.IsNil)
+	err = u.SetWorkloadStatus(sws.status, sws.info)
+	c.Assert(err, gc.IsNil)
+}
+
 type openUnitPort struct {
 	unitName string
 	protocol string
@@ -0,0 +1,191 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// statusView presents the status of an environment in the tabular
+// and oneline formats.
+type statusView struct {
+	status formattedStatus
+	// color holds whether error and pending states are highlighted.
+	color bool
+}
+
+// Tabulate implements cmd.Tabular.
+func (v *statusView) Tabulate() []cmd.TableSection {
+	var sections []cmd.TableSection
+	if u := v.status.Upgrade; u != nil {
+		sections = append(sections, cmd.TableSection{
+			Title:    "Upgrade",
+			Headings: []string{"FROM", "TO", "STATUS", "PENDING-AGENTS", "ERROR"},
+			Rows: [][]string{{
+				u.From, u.To, u.Status, strings.Join(u.PendingAgents, ","), u.Error,
+			}},
+		})
+	}
+	machines := cmd.TableSection{
+		Title:    "Machines",
+		Headings: []string{"ID", "STATE", "VERSION", "DNS", "INS-ID", "SERIES", "HARDWARE"},
+	}
+	var addMachines func(map[string]machineStatus)
+	addMachines = func(ms map[string]machineStatus) {
+		for _, id := range sortedMachineIds(ms) {
+			m := ms[id]
+			machines.Rows = append(machines.Rows, []string{
+				id,
+				v.agentState(m.AgentState, m.Err),
+				m.AgentVersion,
+				m.DNSName,
+				string(m.InstanceId),
+				m.Series,
+				m.Hardware,
+			})
+			addMachines(m.Containers)
+		}
+	}
+	addMachines(v.status.Machines)
+
+	services := cmd.TableSection{
+		Title:    "Services",
+		Headings: []string{"NAME", "EXPOSED", "CHARM", "STATUS"},
+	}
+	units := cmd.TableSection{
+		Title:    "Units",
+		Headings: []string{"ID", "STATE", "WORKLOAD", "VERSION", "MACHINE", "PORTS", "PUBLIC-ADDRESS"},
+	}
+	var addUnits func(map[string]unitStatus, string)
+	addUnits = func(us map[string]unitStatus, indent string) {
+		for _, name := range sortedUnitNames(us) {
+			u := us[name]
+			units.Rows = append(units.Rows, []string{
+				indent + name,
+				v.agentState(u.AgentState, u.Err),
+				string(u.WorkloadStatus),
+				u.AgentVersion,
+				u.Machine,
+				strings.Join(u.OpenedPorts, ","),
+				u.PublicAddress,
+			})
+			addUnits(u.Subordinates, indent+"  ")
+		}
+	}
+	for _, name := range sortedServiceNames(v.status.Services) {
+		s := v.status.Services[name]
+		charm := s.Charm
+		if s.Err != nil {
+			charm = v.agentState("", s.Err)
+		}
+		services.Rows = append(services.Rows, []string{name, fmt.Sprint(s.Exposed), charm, string(s.Status)})
+		addUnits(s.Units, "")
+	}
+	return append(sections, machines, services, units)
+}
+
+// OneLine implements cmd.OneLiner. It returns a line for each unit,
+// followed by lines for its subordinates.
+func (v *statusView) OneLine() []string {
+	var lines []string
+	var addUnits func(map[string]unitStatus, string)
+	addUnits = func(us map[string]unitStatus, indent string) {
+		for _, name := range sortedUnitNames(us) {
+			u := us[name]
+			line := fmt.Sprintf("%s- %s:", indent, name)
+			if u.PublicAddress != "" {
+				line += " " + u.PublicAddress
+			}
+			line += " (" + v.agentState(u.AgentState, u.Err) + ")"
+			if len(u.OpenedPorts) > 0 {
+				line += " " + strings.Join(u.OpenedPorts, " ")
+			}
+			lines = append(lines, line)
+			addUnits(u.Subordinates, indent+"  ")
+		}
+	}
+	for _, name := range sortedServiceNames(v.status.Services) {
+		addUnits(v.status.Services[name].Units, "")
+	}
+	return lines
+}
+
+// agentState returns the text shown for an agent in the given state,
+// highlighted if v.color is set.
+func (v *statusView) agentState(state params.Status, err error) string {
+	text := string(state)
+	if err != nil {
+		text = "error: " + err.Error()
+	}
+	if !v.color {
+		return text
+	}
+	switch {
+	case err != nil, state == params.StatusError, state == params.StatusDown:
+		return cmd.Colorize(text, cmd.ColorRed)
+	case state == params.StatusPending:
+		return cmd.Colorize(text, cmd.ColorYellow)
+	}
+	return text
+}
+
+// sortedServiceNames returns the names of the given services, in order.
+func sortedServiceNames(services map[string]serviceStatus) []string {
+	var names []string
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedUnitNames returns the names of the given units, in order.
+func sortedUnitNames(units map[string]unitStatus) []string {
+	var names []string
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Sort(naturally(names))
+	return names
+}
+
+// sortedMachineIds returns the ids of the given machines, in order.
+func sortedMachineIds(machines map[string]machineStatus) []string {
+	var ids []string
+	for id := range machines {
+		ids = append(ids, id)
+	}
+	sort.Sort(naturally(ids))
+	return ids
+}
+
+// naturally sorts machine ids and unit names so that numbers within
+// them are in numeric order: "2" comes before "10", and "mysql/2"
+// before "mysql/10".
+type naturally []string
+
+func (n naturally) Len() int      { return len(n) }
+func (n naturally) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+
+func (n naturally) Less(i, j int) bool {
+	a, b := strings.Split(n[i], "/"), strings.Split(n[j], "/")
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] == b[k] {
+			continue
+		}
+		x, errx := strconv.Atoi(a[k])
+		y, erry := strconv.Atoi(b[k])
+		if errx == nil && erry == nil {
+			return x < y
+		}
+		return a[k] < b[k]
+	}
+	return len(a) < len(b)
+}
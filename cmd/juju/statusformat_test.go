@@ -0,0 +1,129 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type StatusFormatSuite struct{}
+
+var _ = gc.Suite(&StatusFormatSuite{})
+
+var formatTestStatus = formattedStatus{
+	Environment: "dummyenv",
+	Machines: map[string]machineStatus{
+		"0": {
+			AgentState:   params.StatusStarted,
+			AgentVersion: "1.2.3",
+			DNSName:      "dummyenv-0.dns",
+			InstanceId:   "dummyenv-0",
+			Series:       "precise",
+		},
+		"10": {
+			AgentState: params.StatusPending,
+			InstanceId: "dummyenv-10",
+			Series:     "precise",
+		},
+		"2": {
+			Err: errors.New("boom"),
+			Containers: map[string]machineStatus{
+				"2/lxc/0": {AgentState: params.StatusDown, Series: "precise"},
+			},
+		},
+	},
+	Services: map[string]serviceStatus{
+		"mysql": {
+			Charm:   "cs:precise/mysql-1",
+			Exposed: true,
+			Status:  params.WorkloadWaiting,
+			Units: map[string]unitStatus{
+				"mysql/0": {
+					AgentState:     params.StatusStarted,
+					AgentVersion:   "1.2.3",
+					WorkloadStatus: params.WorkloadActive,
+					Machine:        "0",
+					OpenedPorts:    []string{"3306/tcp"},
+					PublicAddress:  "dummyenv-0.dns",
+					Subordinates: map[string]unitStatus{
+						"logging/0": {AgentState: params.StatusError},
+					},
+				},
+				"mysql/10": {
+					AgentState:         params.StatusPending,
+					WorkloadStatus:     params.WorkloadWaiting,
+					WorkloadStatusInfo: "syncing database",
+					Machine:            "10",
+				},
+			},
+		},
+		"logging": {
+			Charm:         "cs:precise/logging-1",
+			SubordinateTo: []string{"mysql"},
+		},
+	},
+}
+
+func (s *StatusFormatSuite) TestTabular(c *gc.C) {
+	out, err := cmd.FormatTabular(&statusView{status: formatTestStatus})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"[Machines]\n"+
+		"ID      STATE       VERSION DNS            INS-ID      SERIES  HARDWARE\n"+
+		"0       started     1.2.3   dummyenv-0.dns dummyenv-0  precise\n"+
+		"2       error: boom\n"+
+		"2/lxc/0 down                                           precise\n"+
+		"10      pending                            dummyenv-10 precise\n"+
+		"\n"+
+		"[Services]\n"+
+		"NAME    EXPOSED CHARM                STATUS\n"+
+		"logging false   cs:precise/logging-1\n"+
+		"mysql   true    cs:precise/mysql-1   waiting\n"+
+		"\n"+
+		"[Units]\n"+
+		"ID          STATE   WORKLOAD VERSION MACHINE PORTS    PUBLIC-ADDRESS\n"+
+		"mysql/0     started active   1.2.3   0       3306/tcp dummyenv-0.dns\n"+
+		"  logging/0 error\n"+
+		"mysql/10    pending waiting          10")
+}
+
+func (s *StatusFormatSuite) TestTabularUpgrade(c *gc.C) {
+	status := formattedStatus{
+		Upgrade: &upgradeStatus{
+			From:          "1.2.3",
+			To:            "1.2.4",
+			Status:        "agents",
+			PendingAgents: []string{"machine-1", "unit-mysql-0"},
+		},
+	}
+	out, err := cmd.FormatTabular(&statusView{status: status})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"[Upgrade]\n"+
+		"FROM  TO    STATUS PENDING-AGENTS         ERROR\n"+
+		"1.2.3 1.2.4 agents machine-1,unit-mysql-0")
+}
+
+func (s *StatusFormatSuite) TestOneline(c *gc.C) {
+	out, err := cmd.FormatOneline(&statusView{status: formatTestStatus})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"- mysql/0: dummyenv-0.dns (started) 3306/tcp\n"+
+		"  - logging/0: (error)\n"+
+		"- mysql/10: (pending)")
+}
+
+func (s *StatusFormatSuite) TestColor(c *gc.C) {
+	out, err := cmd.FormatOneline(&statusView{status: formatTestStatus, color: true})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"- mysql/0: dummyenv-0.dns (started) 3306/tcp\n"+
+		"  - logging/0: (\x1b[31merror\x1b[0m)\n"+
+		"- mysql/10: (\x1b[33mpending\x1b[0m)")
+}
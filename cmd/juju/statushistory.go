@@ -0,0 +1,116 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// StatusHistoryCommand shows the recent statuses of a unit or machine.
+type StatusHistoryCommand struct {
+	cmd.EnvCommandBase
+	out  cmd.Output
+	tag  string
+	size int
+}
+
+const statusHistoryDoc = `
+Show the most recent agent statuses of a unit or machine, newest first,
+with the time each was set. The entity is given as a unit name, such as
+"mysql/0", or a machine id, such as "1".
+
+Entries older than the configured age, and the oldest entries of an
+entity with too many, are removed periodically by the state servers.
+`
+
+func (c *StatusHistoryCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "status-history",
+		Args:    "<entity>",
+		Purpose: "output past statuses of a unit or machine",
+		Doc:     statusHistoryDoc,
+	}
+}
+
+func (c *StatusHistoryCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.IntVar(&c.size, "n", 20, "number of statuses to show")
+	c.out.AddFlags(f, "tabular", map[string]cmd.Formatter{
+		"tabular": cmd.FormatTabular,
+		"yaml":    cmd.FormatYaml,
+		"json":    cmd.FormatJson,
+	})
+}
+
+func (c *StatusHistoryCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no unit or machine specified")
+	}
+	switch entity := args[0]; {
+	case names.IsUnit(entity):
+		c.tag = names.UnitTag(entity)
+	case names.IsMachine(entity):
+		c.tag = names.MachineTag(entity)
+	default:
+		return fmt.Errorf("invalid unit or machine %q", entity)
+	}
+	if c.size < 1 {
+		return fmt.Errorf("invalid number of statuses %d", c.size)
+	}
+	return cmd.CheckEmpty(args[1:])
+}
+
+type statusHistoryEntry struct {
+	Since  string            `json:"since" yaml:"since"`
+	Status params.Status     `json:"status" yaml:"status"`
+	Info   string            `json:"info,omitempty" yaml:"info,omitempty"`
+	Data   params.StatusData `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// statusHistory is the formatted history of an entity, newest first.
+type statusHistory []statusHistoryEntry
+
+func (h statusHistory) Tabulate() []cmd.TableSection {
+	section := cmd.TableSection{
+		Headings: []string{"TIME", "STATUS", "INFO"},
+	}
+	for _, entry := range h {
+		section.Rows = append(section.Rows, []string{
+			entry.Since, string(entry.Status), entry.Info,
+		})
+	}
+	return []cmd.TableSection{section}
+}
+
+func (c *StatusHistoryCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	entries, err := client.StatusHistory(c.tag, c.size)
+	if err != nil {
+		return err
+	}
+	history := make(statusHistory, len(entries))
+	for i, entry := range entries {
+		history[i] = statusHistoryEntry{
+			Since:  entry.Since.UTC().Format(time.RFC3339),
+			Status: entry.Status,
+			Info:   entry.Info,
+			Data:   entry.Data,
+		}
+	}
+	return c.out.Write(ctx, history)
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type StatusHistorySuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&StatusHistorySuite{})
+
+var statusHistoryInitErrorTests = []struct {
+	args []string
+	err  string
+}{{
+	args: nil,
+	err:  "no unit or machine specified",
+}, {
+	args: []string{"mysql"},
+	err:  `invalid unit or machine "mysql"`,
+}, {
+	args: []string{"mysql/0", "-n", "0"},
+	err:  "invalid number of statuses 0",
+}, {
+	args: []string{"mysql/0", "1"},
+	err:  `unrecognized args: \["1"\]`,
+}}
+
+func (s *StatusHistorySuite) TestInitErrors(c *gc.C) {
+	for i, test := range statusHistoryInitErrorTests {
+		c.Logf("test %d: %v", i, test.args)
+		err := coretesting.InitCommand(&StatusHistoryCommand{}, test.args)
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+}
+
+func (s *StatusHistorySuite) TestStatusHistory(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = m.SetStatus(params.StatusError, "cannot start instance", nil)
+	c.Assert(err, gc.IsNil)
+	err = m.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := coretesting.RunCommand(c, &StatusHistoryCommand{}, []string{m.Id()})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, ""+
+		"TIME +STATUS +INFO\n"+
+		`\S+Z +started\n`+
+		`\S+Z +error +cannot start instance\n`)
+
+	ctx, err = coretesting.RunCommand(c, &StatusHistoryCommand{}, []string{m.Id(), "-n", "1", "--format", "yaml"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(coretesting.Stdout(ctx), gc.Matches, `- since: \S+Z\n  status: started\n`)
+}
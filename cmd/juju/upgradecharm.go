@@ -6,7 +6,10 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 
 	"launchpad.net/gnuflag"
 
@@ -14,6 +17,7 @@ import (
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state/api"
 )
 
 // UpgradeCharm is responsible for upgrading a service's charm.
@@ -24,6 +28,7 @@ type UpgradeCharmCommand struct {
 	RepoPath    string // defaults to JUJU_REPOSITORY
 	SwitchURL   string
 	Revision    int // defaults to -1 (latest)
+	DryRun      bool
 }
 
 const upgradeCharmDoc = `
@@ -59,6 +64,15 @@ The new charm may add new relations and configuration settings.
 number with --switch, give it in the charm URL, for instance "cs:wordpress-5"
 would specify revision number 5 of the wordpress charm.
 
+The upgrade is refused, listing the relations concerned, when the new charm
+does not implement the endpoints of all the service's live relations.
+
+The --dry-run flag shows how the new charm differs from the current one,
+without upgrading: the config options added, removed and changed in type, the
+relations added, removed and changed, and the current settings that would be
+lost because the new charm has no such option or the value cannot be converted
+to the option's new type.
+
 Use of the --force flag is not generally recommended; units upgraded while in an
 error state will not have upgrade-charm hooks executed, and may cause unexpected
 behavior. 
@@ -79,6 +93,7 @@ func (c *UpgradeCharmCommand) SetFlags(f *gnuflag.FlagSet) {
 	f.StringVar(&c.RepoPath, "repository", os.Getenv("JUJU_REPOSITORY"), "local charm repository path")
 	f.StringVar(&c.SwitchURL, "switch", "", "crossgrade to a different charm")
 	f.IntVar(&c.Revision, "revision", -1, "explicit revision of current charm")
+	f.BoolVar(&c.DryRun, "dry-run", false, "show the changes the upgrade would make, without upgrading")
 }
 
 func (c *UpgradeCharmCommand) Init(args []string) error {
@@ -102,24 +117,23 @@ func (c *UpgradeCharmCommand) Init(args []string) error {
 // Run connects to the specified environment and starts the charm
 // upgrade process.
 func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
-	conn, err := juju.NewConnFromName(c.EnvName)
+	client, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
-	service, err := conn.State.Service(c.ServiceName)
+	defer client.Close()
+	oldURL, err := client.ServiceGetCharmURL(c.ServiceName)
 	if err != nil {
 		return err
 	}
-	oldURL, _ := service.CharmURL()
 	var newURL *charm.URL
 	if c.SwitchURL != "" {
 		// A new charm URL was explicitly specified.
-		conf, err := conn.State.EnvironConfig()
+		envInfo, err := client.EnvironmentInfo()
 		if err != nil {
 			return err
 		}
-		newURL, err = charm.InferURL(c.SwitchURL, conf.DefaultSeries())
+		newURL, err = charm.InferURL(c.SwitchURL, envInfo.DefaultSeries)
 		if err != nil {
 			return err
 		}
@@ -162,9 +176,131 @@ func (c *UpgradeCharmCommand) Run(ctx *cmd.Context) error {
 			return fmt.Errorf("cannot increment revision of charm %q: not a directory", newURL)
 		}
 	}
-	sch, err := conn.PutCharm(newURL, repo, bumpRevision)
+	newCharm, err := repo.Get(newURL)
+	if err != nil {
+		return err
+	}
+	// The revision of a local charm directory is only incremented
+	// when the charm is uploaded.
+	targetURL := newURL
+	if bumpRevision {
+		targetURL = newURL.WithRevision(newURL.Revision + 1)
+	}
+	if err := c.checkUpgrade(ctx, client, oldURL, targetURL, newCharm); err != nil || c.DryRun {
+		return err
+	}
+	addedURL, err := addCharmViaAPI(client, newURL, repo, bumpRevision)
+	if err != nil {
+		return err
+	}
+	return client.ServiceSetCharm(c.ServiceName, addedURL.String(), c.Force)
+}
+
+// checkUpgrade refuses an upgrade to newCharm that would break any of
+// the service's live relations. When --dry-run is set, it first writes
+// how newCharm differs from the service's current charm.
+func (c *UpgradeCharmCommand) checkUpgrade(ctx *cmd.Context, client *api.Client, oldURL, newURL *charm.URL, newCharm charm.Charm) error {
+	oldInfo, err := client.CharmInfo(oldURL.String())
+	if err != nil {
+		return err
+	}
+	status, err := client.FullStatus([]string{c.ServiceName})
 	if err != nil {
 		return err
 	}
-	return service.SetCharm(sch, c.Force)
+	liveRelations := status.Services[c.ServiceName].Relations
+	var broken []string
+	for _, name := range sortedRelationNames(liveRelations) {
+		rel, ok := charmRelation(oldInfo.Meta, name)
+		if !ok || !rel.ImplementedBy(newCharm) {
+			broken = append(broken, fmt.Sprintf("%q with %s", name, strings.Join(liveRelations[name], ", ")))
+		}
+	}
+	if c.DryRun {
+		results, err := client.ServiceGet(c.ServiceName)
+		if err != nil {
+			return err
+		}
+		_, lost := newCharm.Config().MigrateSettings(explicitSettings(results.Config))
+		diff := charm.NewDiff(oldInfo.Meta, oldInfo.Config, newCharm.Meta(), newCharm.Config())
+		fmt.Fprintf(ctx.Stdout, "upgrade %q from %s to %s\n", c.ServiceName, oldURL, newURL)
+		writeCharmDiff(ctx.Stdout, diff, lost)
+	}
+	switch len(broken) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("cannot upgrade service %q to charm %q: would break relation %s", c.ServiceName, newURL, broken[0])
+	}
+	return fmt.Errorf("cannot upgrade service %q to charm %q: would break relations %s", c.ServiceName, newURL, strings.Join(broken, ", "))
+}
+
+// charmRelation returns the relation with the given name declared by
+// meta, or the implicit juju-info relation.
+func charmRelation(meta *charm.Meta, name string) (charm.Relation, bool) {
+	for _, relations := range []map[string]charm.Relation{meta.Provides, meta.Requires, meta.Peers} {
+		if rel, ok := relations[name]; ok {
+			return rel, true
+		}
+	}
+	if name == "juju-info" {
+		return charm.Relation{
+			Name:      name,
+			Role:      charm.RoleProvider,
+			Interface: "juju-info",
+			Scope:     charm.ScopeGlobal,
+		}, true
+	}
+	return charm.Relation{}, false
+}
+
+// explicitSettings returns the settings that were set on a service,
+// given the config description returned by ServiceGet.
+func explicitSettings(config map[string]interface{}) charm.Settings {
+	settings := make(charm.Settings)
+	for name, info := range config {
+		info, ok := info.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, isDefault := info["default"]; isDefault {
+			continue
+		}
+		settings[name] = info["value"]
+	}
+	return settings
+}
+
+// writeCharmDiff writes diff, and the names of the settings that would
+// be lost, one kind of change per line.
+func writeCharmDiff(w io.Writer, diff *charm.Diff, lost []string) {
+	if diff.Empty() && len(lost) == 0 {
+		fmt.Fprintf(w, "no changes to config options or relations\n")
+		return
+	}
+	write := func(title string, names []string) {
+		if len(names) > 0 {
+			fmt.Fprintf(w, "%s: %s\n", title, strings.Join(names, ", "))
+		}
+	}
+	var retyped []string
+	for _, option := range diff.RetypedOptions {
+		retyped = append(retyped, fmt.Sprintf("%s (%s -> %s)", option.Name, option.OldType, option.NewType))
+	}
+	write("added options", diff.AddedOptions)
+	write("removed options", diff.RemovedOptions)
+	write("retyped options", retyped)
+	write("added relations", diff.AddedRelations)
+	write("removed relations", diff.RemovedRelations)
+	write("changed relations", diff.ChangedRelations)
+	write("settings lost", lost)
+}
+
+func sortedRelationNames(relations map[string][]string) []string {
+	var names []string
+	for name := range relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
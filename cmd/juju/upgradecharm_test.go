@@ -218,3 +218,71 @@ func (s *UpgradeCharmSuccessSuite) TestSwitch(c *gc.C) {
 	c.Assert(curl.String(), gc.Equals, "local:precise/myriak-42")
 	s.assertLocalRevision(c, 42, myriakPath)
 }
+
+var myriakDiffMeta = []byte(`
+name: myriak
+summary: "K/V storage engine"
+description: "Scalable K/V Store in Erlang with Clocks :-)"
+provides:
+  endpoint:
+    interface: http
+  metrics:
+    interface: prometheus
+peers:
+  ring:
+    interface: riak
+`)
+
+var myriakDiffConfig = []byte(`
+options:
+  port: {type: int, default: 8098, description: HTTP port}
+`)
+
+func (s *UpgradeCharmSuccessSuite) TestDryRun(c *gc.C) {
+	myriakPath := testing.Charms.RenamedClonedDirPath(s.SeriesPath, "riak", "myriak")
+	err := ioutil.WriteFile(path.Join(myriakPath, "metadata.yaml"), myriakDiffMeta, 0644)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(path.Join(myriakPath, "config.yaml"), myriakDiffConfig, 0644)
+	c.Assert(err, gc.IsNil)
+
+	ctx, err := testing.RunCommand(c, &UpgradeCharmCommand{}, []string{"riak", "--switch=local:myriak", "--dry-run"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"upgrade \"riak\" from local:precise/riak-7 to local:precise/myriak-7\n"+
+		"added options: port\n"+
+		"added relations: metrics\n"+
+		"removed relations: admin\n")
+	curl := s.assertUpgraded(c, 7, false)
+	c.Assert(curl.String(), gc.Equals, "local:precise/riak-7")
+	_, err = s.State.Charm(charm.MustParseURL("local:precise/myriak-7"))
+	c.Assert(err, gc.ErrorMatches, `charm "local:precise/myriak-7" not found`)
+
+	ctx, err = testing.RunCommand(c, &UpgradeCharmCommand{}, []string{"riak", "--dry-run"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(testing.Stdout(ctx), gc.Equals, ""+
+		"upgrade \"riak\" from local:precise/riak-7 to local:precise/riak-8\n"+
+		"no changes to config options or relations\n")
+	s.assertUpgraded(c, 7, false)
+}
+
+var myriakBrokenMeta = []byte(`
+name: myriak
+summary: "K/V storage engine"
+description: "Scalable K/V Store in Erlang with Clocks :-)"
+provides:
+  endpoint:
+    interface: http
+peers:
+  ring:
+    interface: riak2
+`)
+
+func (s *UpgradeCharmSuccessSuite) TestSwitchRefusesToBreakRelations(c *gc.C) {
+	myriakPath := testing.Charms.RenamedClonedDirPath(s.SeriesPath, "riak", "myriak")
+	err := ioutil.WriteFile(path.Join(myriakPath, "metadata.yaml"), myriakBrokenMeta, 0644)
+	c.Assert(err, gc.IsNil)
+
+	err = runUpgradeCharm(c, "riak", "--switch=local:myriak")
+	c.Assert(err, gc.ErrorMatches, `cannot upgrade service "riak" to charm "local:precise/myriak-7": would break relation "ring" with riak`)
+	s.assertUpgraded(c, 7, false)
+}
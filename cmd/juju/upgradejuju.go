@@ -4,20 +4,20 @@
 package main
 
 import (
-	stderrors "errors"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 
 	"launchpad.net/gnuflag"
 
 	"launchpad.net/juju-core/cmd"
-	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/config"
-	"launchpad.net/juju-core/environs/storage"
-	"launchpad.net/juju-core/environs/sync"
 	envtools "launchpad.net/juju-core/environs/tools"
-	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/log"
+	"launchpad.net/juju-core/state/api"
+	"launchpad.net/juju-core/state/api/params"
 	coretools "launchpad.net/juju-core/tools"
 	"launchpad.net/juju-core/version"
 )
@@ -32,7 +32,7 @@ type UpgradeJujuCommand struct {
 	Series      []string
 }
 
-var uploadTools = sync.Upload
+var bundleTools = envtools.BundleTools
 
 var upgradeJujuDoc = `
 The upgrade-juju command upgrades a running environment by setting a version
@@ -49,8 +49,17 @@ chosen if any of the following conditions hold:
  - the environment "development" setting is true
  - the --dev flag is specified
 
+Before the upgrade starts, the command checks that tools of the chosen version
+are available for the series and architecture of every machine, that every
+agent is running, and that no unit is in an error state. The state servers are
+upgraded first, and migrate the data in state to the new version; the other
+agents upgrade once the migration is done. If the migration fails, all agents
+are rolled back to the previous version. The progress of the upgrade is shown
+by the status command.
+
 For development use, the --upload-tools flag specifies that the juju tools will
-be compiled locally and uploaded before the version is set. Currently the tools
+be compiled locally and uploaded to the API server, which stores them in the
+environment, before the version is set. Currently the tools
 will be uploaded as if they had the version of the current juju tool, unless
 specified otherwise by the --version flag.
 `
@@ -97,15 +106,15 @@ func (c *UpgradeJujuCommand) Init(args []string) error {
 	return cmd.CheckEmpty(args)
 }
 
-var errUpToDate = stderrors.New("no upgrades available")
+var errUpToDate = errors.New("no upgrades available")
 
 // Run changes the version proposed for the juju envtools.
 func (c *UpgradeJujuCommand) Run(_ *cmd.Context) (err error) {
-	conn, err := juju.NewConnFromName(c.EnvName)
+	client, err := juju.NewAPIClientFromName(c.EnvName)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	defer client.Close()
 	defer func() {
 		if err == errUpToDate {
 			log.Noticef(err.Error())
@@ -114,18 +123,21 @@ func (c *UpgradeJujuCommand) Run(_ *cmd.Context) (err error) {
 	}()
 
 	// Determine the version to upgrade to, uploading tools if necessary.
-	env := conn.Environ
-	cfg, err := conn.State.EnvironConfig()
+	attrs, err := client.EnvironmentGet()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.New(config.NoDefaults, attrs)
 	if err != nil {
 		return err
 	}
-	v, err := c.initVersions(cfg, env)
+	v, err := c.initVersions(client, cfg)
 	if err != nil {
 		return err
 	}
 	if c.UploadTools {
 		series := getUploadSeries(cfg, c.Series)
-		if err := v.uploadTools(env.Storage(), series); err != nil {
+		if err := v.uploadTools(client, series); err != nil {
 			return err
 		}
 	}
@@ -136,19 +148,7 @@ func (c *UpgradeJujuCommand) Run(_ *cmd.Context) (err error) {
 	// TODO(fwereade): this list may be incomplete, pending envtools.Upload change.
 	log.Infof("available tools: %s", v.tools)
 
-	// Write updated config back to state if necessary. Note that this is
-	// crackful and racy, because we have no idea what incompatible agent-
-	// version might be set by another administrator in the meantime. If
-	// this happens, tough: I'm not going to pretend to do it right when
-	// I'm not.
-	// TODO(fwereade): Do this right. Warning: scope unclear.
-	cfg, err = cfg.Apply(map[string]interface{}{
-		"agent-version": v.chosen.String(),
-	})
-	if err != nil {
-		return err
-	}
-	if err := conn.State.SetEnvironConfig(cfg); err != nil {
+	if err := client.SetEnvironAgentVersion(v.chosen); err != nil {
 		return err
 	}
 	log.Noticef("started upgrade to %s", v.chosen)
@@ -159,7 +159,7 @@ func (c *UpgradeJujuCommand) Run(_ *cmd.Context) (err error) {
 // agent and client versions, and the list of currently available tools, will
 // always be accurate; the chosen version, and the flag indicating development
 // mode, may remain blank until uploadTools or validate is called.
-func (c *UpgradeJujuCommand) initVersions(cfg *config.Config, env environs.Environ) (*upgradeVersions, error) {
+func (c *UpgradeJujuCommand) initVersions(client *api.Client, cfg *config.Config) (*upgradeVersions, error) {
 	agent, ok := cfg.AgentVersion()
 	if !ok {
 		// Can't happen. In theory.
@@ -168,10 +168,10 @@ func (c *UpgradeJujuCommand) initVersions(cfg *config.Config, env environs.Envir
 	if c.Version == agent {
 		return nil, errUpToDate
 	}
-	client := version.Current.Number
-	available, err := envtools.FindTools(env, client.Major, -1, coretools.Filter{}, envtools.DoNotAllowRetry)
+	clientVersion := version.Current.Number
+	available, err := client.FindTools(clientVersion.Major, -1, "", "")
 	if err != nil {
-		if !errors.IsNotFoundError(err) {
+		if !params.IsCodeNotFound(err) {
 			return nil, err
 		}
 		if !c.UploadTools {
@@ -181,11 +181,11 @@ func (c *UpgradeJujuCommand) initVersions(cfg *config.Config, env environs.Envir
 			return nil, err
 		}
 	}
-	dev := c.Development || cfg.Development() || agent.IsDev() || client.IsDev()
+	dev := c.Development || cfg.Development() || agent.IsDev() || clientVersion.IsDev()
 	return &upgradeVersions{
 		dev:    dev,
 		agent:  agent,
-		client: client,
+		client: clientVersion,
 		chosen: c.Version,
 		tools:  available,
 	}, nil
@@ -200,14 +200,15 @@ type upgradeVersions struct {
 	tools  coretools.List
 }
 
-// uploadTools compiles jujud from $GOPATH and uploads it into the supplied
-// storage. If no version has been explicitly chosen, the version number
-// reported by the built tools will be based on the client version number.
-// In any case, the version number reported will have a build component higher
-// than that of any otherwise-matching available envtools.
+// uploadTools compiles jujud from $GOPATH and uploads it to the API server,
+// which stores it for each of the given series. If no version has been
+// explicitly chosen, the version number reported by the built tools will be
+// based on the client version number. In any case, the version number
+// reported will have a build component higher than that of any
+// otherwise-matching available envtools.
 // uploadTools resets the chosen version and replaces the available tools
 // with the ones just uploaded.
-func (v *upgradeVersions) uploadTools(storage storage.Storage, series []string) error {
+func (v *upgradeVersions) uploadTools(client *api.Client, series []string) error {
 	// TODO(fwereade): this is kinda crack: we should not assume that
 	// version.Current matches whatever source happens to be built. The
 	// ideal would be:
@@ -225,11 +226,17 @@ func (v *upgradeVersions) uploadTools(storage storage.Storage, series []string)
 	}
 	v.chosen = uploadVersion(v.chosen, v.tools)
 
-	// TODO(fwereade): envtools.Upload should return envtools.List, and should
-	// include all the extra series we build, so we can set *that* onto
-	// v.available and maybe one day be able to check that a given upgrade
-	// won't leave out-of-date machines lying around, starved of envtools.
-	uploaded, err := uploadTools(storage, &v.chosen, series...)
+	archive, err := ioutil.TempFile("", "juju-tgz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	toolsVersion, _, err := bundleTools(archive, &v.chosen)
+	if err != nil {
+		return err
+	}
+	uploaded, err := client.UploadTools(archive, toolsVersion, series...)
 	if err != nil {
 		return err
 	}
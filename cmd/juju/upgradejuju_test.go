@@ -15,13 +15,13 @@ import (
 
 	"launchpad.net/juju-core/environs/filestorage"
 	"launchpad.net/juju-core/environs/storage"
-	"launchpad.net/juju-core/environs/sync"
 	envtesting "launchpad.net/juju-core/environs/testing"
 	envtools "launchpad.net/juju-core/environs/tools"
 	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
 	coretesting "launchpad.net/juju-core/testing"
 	jc "launchpad.net/juju-core/testing/checkers"
-	coretools "launchpad.net/juju-core/tools"
 	"launchpad.net/juju-core/version"
 )
 
@@ -256,36 +256,25 @@ var upgradeJujuTests = []struct {
 	expectUploaded: []string{"2.7.3.2-quantal-amd64", "2.7.3.2-precise-amd64", "2.7.3.2-raring-amd64"},
 }}
 
-// mockUploadTools simulates the effect of tools.Upload, but skips the time-
-// consuming build from source.
-// TODO(fwereade) better factor agent/tools such that build logic is
-// exposed and can itself be neatly mocked?
-func mockUploadTools(stor storage.Storage, forceVersion *version.Number, series ...string) (*coretools.Tools, error) {
+// mockBundleTools simulates the effect of envtools.BundleTools, but skips
+// the time-consuming build from source.
+func mockBundleTools(w io.Writer, forceVersion *version.Number) (version.Binary, string, error) {
 	vers := version.Current
 	if forceVersion != nil {
 		vers.Number = *forceVersion
 	}
-	versions := []version.Binary{vers}
-	for _, series := range series {
-		if series != version.Current.Series {
-			newVers := vers
-			newVers.Series = series
-			versions = append(versions, newVers)
-		}
-	}
-	agentTools, err := envtesting.UploadFakeToolsVersions(stor, versions...)
-	if err != nil {
-		return nil, err
+	data, checksum := coretesting.TarGz(coretesting.NewTarFile("jujud", 0777, "jujud contents "+vers.String()))
+	if _, err := w.Write(data); err != nil {
+		return version.Binary{}, "", err
 	}
-	return agentTools[0], nil
+	return vers, checksum, nil
 }
 
 func (s *UpgradeJujuSuite) TestUpgradeJuju(c *gc.C) {
 	oldVersion := version.Current
-	uploadTools = mockUploadTools
+	s.PatchValue(&bundleTools, mockBundleTools)
 	defer func() {
 		version.Current = oldVersion
-		uploadTools = sync.Upload
 	}()
 
 	for i, test := range upgradeJujuTests {
@@ -345,6 +334,8 @@ func (s *UpgradeJujuSuite) TestUpgradeJuju(c *gc.C) {
 		c.Check(agentVersion, gc.Equals, version.MustParse(test.expectVersion))
 		c.Check(cfg.Development(), gc.Equals, test.development)
 
+		// The tools are built for the client's series, and stored by
+		// the API server for every uploaded series.
 		for _, uploaded := range test.expectUploaded {
 			vers := version.MustParseBinary(uploaded)
 			r, err := storage.Get(s.Conn.Environ.Storage(), envtools.StorageName(vers))
@@ -354,7 +345,8 @@ func (s *UpgradeJujuSuite) TestUpgradeJuju(c *gc.C) {
 			data, err := ioutil.ReadAll(r)
 			r.Close()
 			c.Check(err, gc.IsNil)
-			checkToolsContent(c, data, "jujud contents "+uploaded)
+			vers.Series = version.Current.Series
+			checkToolsContent(c, data, "jujud contents "+vers.String())
 		}
 	}
 }
@@ -413,3 +405,95 @@ func (s *UpgradeJujuSuite) TestUpgradeJujuWithRealUpload(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(len(tools), gc.Equals, 1)
 }
+
+func (s *UpgradeJujuSuite) TestUpgradeJujuChecksAgents(c *gc.C) {
+	s.Reset(c)
+	s.PatchValue(&version.Current, version.MustParseBinary("1.2.4-precise-amd64"))
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	cfg, err = cfg.Apply(map[string]interface{}{
+		"tools-metadata-url": "file://" + c.MkDir(),
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	stor := s.Conn.Environ.Storage()
+	envtesting.MustUploadFakeToolsVersions(stor, version.MustParseBinary("1.2.4-precise-amd64"))
+	oldTools := version.MustParseBinary("1.2.3-raring-amd64")
+
+	machine, err := s.State.AddMachine("raring", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAgentVersion(oldTools)
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(machine)
+	c.Assert(err, gc.IsNil)
+	err = unit.SetAgentVersion(oldTools)
+	c.Assert(err, gc.IsNil)
+	err = unit.SetStatus(params.StatusError, "hook failed", nil)
+	c.Assert(err, gc.IsNil)
+
+	run := func() error {
+		_, err := coretesting.RunCommand(c, &UpgradeJujuCommand{}, []string{"--version", "1.2.4"})
+		return err
+	}
+	err = run()
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4: no tools available for raring/amd64")
+
+	envtesting.MustUploadFakeToolsVersions(stor, version.MustParseBinary("1.2.4-raring-amd64"))
+	err = run()
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4: agents not running: machine-0, unit-wordpress-0")
+
+	// The agents are checked by the API server, so they are started
+	// through its own state, whose presence watcher notices them at once.
+	apiMachine, err := s.BackingState.Machine(machine.Id())
+	c.Assert(err, gc.IsNil)
+	apiUnit, err := s.BackingState.Unit(unit.Name())
+	c.Assert(err, gc.IsNil)
+	machinePinger, err := apiMachine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer machinePinger.Kill()
+	unitPinger, err := apiUnit.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer unitPinger.Kill()
+	s.BackingState.StartSync()
+	err = apiMachine.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+	err = apiUnit.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+	err = run()
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4: units in error state: wordpress/0")
+
+	err = unit.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	err = run()
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.PreviousVersion(), gc.Equals, version.MustParse("1.2.3"))
+	c.Assert(info.TargetVersion(), gc.Equals, version.MustParse("1.2.4"))
+	c.Assert(info.Status(), gc.Equals, state.UpgradeStarted)
+}
+
+func (s *UpgradeJujuSuite) TestUpgradeJujuUploadedToolsChecked(c *gc.C) {
+	s.Reset(c)
+	s.PatchValue(&version.Current, version.MustParseBinary("1.2.4-precise-amd64"))
+	s.PatchValue(&bundleTools, mockBundleTools)
+	machine, err := s.State.AddMachine("saucy", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAgentVersion(version.MustParseBinary("1.2.3-saucy-amd64"))
+	c.Assert(err, gc.IsNil)
+
+	// The uploaded tools do not cover the machine's series, so the
+	// API server refuses to start the upgrade.
+	_, err = coretesting.RunCommand(c, &UpgradeJujuCommand{}, []string{"--upload-tools", "--series", "raring"})
+	c.Assert(err, gc.ErrorMatches, `cannot upgrade to 1.2.4.1: no tools available for saucy/amd64`)
+	_, err = s.State.UpgradeInfo()
+	c.Assert(err, gc.NotNil)
+	vers := version.MustParseBinary("1.2.4.1-raring-amd64")
+	_, err = storage.Get(s.Conn.Environ.Storage(), envtools.StorageName(vers))
+	c.Assert(err, gc.IsNil)
+}
@@ -0,0 +1,288 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/utils"
+)
+
+const userCommandDoc = `
+"juju user" is used to manage the users who may connect to an
+environment.
+
+Each user holds one of the following permissions:
+
+    read    may view the environment, but not change it
+    write   may also deploy and change services and machines
+    admin   may also manage users and view environment credentials
+`
+
+// newUserCommand returns the "juju user" super-command, which
+// groups the user management commands.
+func newUserCommand() cmd.Command {
+	usercmd := cmd.NewSuperCommand(cmd.SuperCommandParams{
+		Name:        "user",
+		UsagePrefix: "juju",
+		Doc:         userCommandDoc,
+		Purpose:     "manage the users of an environment",
+	})
+	usercmd.Register(&UserAddCommand{})
+	usercmd.Register(&UserRemoveCommand{})
+	usercmd.Register(&UserDisableCommand{})
+	usercmd.Register(&UserEnableCommand{})
+	usercmd.Register(&UserListCommand{})
+	usercmd.Register(&UserChangePasswordCommand{})
+	return usercmd
+}
+
+// UserAddCommand adds a user to the environment.
+type UserAddCommand struct {
+	cmd.EnvCommandBase
+	Username   string
+	Password   string
+	Permission string
+}
+
+const userAddDoc = `
+Adds a user with the given name to the environment. If no password
+is specified, a random one is generated and printed.
+`
+
+func (c *UserAddCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "add",
+		Args:    "<username>",
+		Purpose: "add a user to the environment",
+		Doc:     userAddDoc,
+	}
+}
+
+func (c *UserAddCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	f.StringVar(&c.Password, "password", "", "the password of the new user")
+	f.StringVar(&c.Permission, "permission", string(state.ReadPermission), "the permission of the new user: read, write or admin")
+}
+
+func (c *UserAddCommand) Init(args []string) (err error) {
+	if c.Username, err = userNameArg(args); err != nil {
+		return err
+	}
+	return state.Permission(c.Permission).Validate()
+}
+
+func (c *UserAddCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	password := c.Password
+	if password == "" {
+		if password, err = utils.RandomPassword(); err != nil {
+			return err
+		}
+	}
+	if err := client.AddUser(c.Username, password, c.Permission); err != nil {
+		return err
+	}
+	if c.Password == "" {
+		fmt.Fprintf(ctx.Stdout, "password: %s\n", password)
+	}
+	return nil
+}
+
+// UserRemoveCommand removes a user from the environment.
+type UserRemoveCommand struct {
+	cmd.EnvCommandBase
+	Username string
+}
+
+func (c *UserRemoveCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "remove",
+		Args:    "<username>",
+		Purpose: "remove a user from the environment",
+	}
+}
+
+func (c *UserRemoveCommand) Init(args []string) (err error) {
+	c.Username, err = userNameArg(args)
+	return err
+}
+
+func (c *UserRemoveCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.RemoveUser(c.Username)
+}
+
+// UserDisableCommand prevents a user from logging in.
+type UserDisableCommand struct {
+	cmd.EnvCommandBase
+	Username string
+}
+
+func (c *UserDisableCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "disable",
+		Args:    "<username>",
+		Purpose: "prevent a user from logging in",
+	}
+}
+
+func (c *UserDisableCommand) Init(args []string) (err error) {
+	c.Username, err = userNameArg(args)
+	return err
+}
+
+func (c *UserDisableCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.DisableUser(c.Username)
+}
+
+// UserEnableCommand allows a disabled user to log in again.
+type UserEnableCommand struct {
+	cmd.EnvCommandBase
+	Username string
+}
+
+func (c *UserEnableCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "enable",
+		Args:    "<username>",
+		Purpose: "allow a disabled user to log in",
+	}
+}
+
+func (c *UserEnableCommand) Init(args []string) (err error) {
+	c.Username, err = userNameArg(args)
+	return err
+}
+
+func (c *UserEnableCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.EnableUser(c.Username)
+}
+
+// UserListCommand shows the users of the environment.
+type UserListCommand struct {
+	cmd.EnvCommandBase
+	out cmd.Output
+}
+
+func (c *UserListCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "list",
+		Purpose: "show the users of the environment",
+	}
+}
+
+func (c *UserListCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.EnvCommandBase.SetFlags(f)
+	c.out.AddFlags(f, "yaml", map[string]cmd.Formatter{
+		"yaml": cmd.FormatYaml,
+		"json": cmd.FormatJson,
+	})
+}
+
+func (c *UserListCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+// userInfo holds the details of a user shown by "juju user list".
+type userInfo struct {
+	Permission string `yaml:"permission" json:"permission"`
+	Disabled   bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+func (c *UserListCommand) Run(ctx *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	users, err := client.Users()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]userInfo)
+	for _, u := range users {
+		result[u.Username] = userInfo{
+			Permission: u.Permission,
+			Disabled:   u.Disabled,
+		}
+	}
+	return c.out.Write(ctx, result)
+}
+
+// UserChangePasswordCommand changes the password of a user.
+type UserChangePasswordCommand struct {
+	cmd.EnvCommandBase
+	Username string
+	Password string
+}
+
+const userChangePasswordDoc = `
+Changes the password of the given user. Admin users may change the
+password of any user; other users may only change their own.
+`
+
+func (c *UserChangePasswordCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "change-password",
+		Args:    "<username> <password>",
+		Purpose: "change the password of a user",
+		Doc:     userChangePasswordDoc,
+	}
+}
+
+func (c *UserChangePasswordCommand) Init(args []string) error {
+	switch len(args) {
+	case 0:
+		return fmt.Errorf("no username specified")
+	case 1:
+		return fmt.Errorf("no password specified")
+	}
+	c.Username, c.Password = args[0], args[1]
+	return cmd.CheckEmpty(args[2:])
+}
+
+func (c *UserChangePasswordCommand) Run(_ *cmd.Context) error {
+	client, err := juju.NewAPIClientFromName(c.EnvName)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.SetUserPassword(c.Username, c.Password)
+}
+
+// userNameArg returns the single username in args.
+func userNameArg(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("no username specified")
+	}
+	if !names.IsUser(args[0]) {
+		return "", fmt.Errorf("invalid username %q", args[0])
+	}
+	return args[0], cmd.CheckEmpty(args[1:])
+}
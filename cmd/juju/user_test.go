@@ -0,0 +1,108 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type UserSuite struct {
+	jujutesting.RepoSuite
+}
+
+var _ = gc.Suite(&UserSuite{})
+
+func runUser(c *gc.C, args ...string) (string, error) {
+	ctx, err := coretesting.RunCommand(c, newUserCommand(), args)
+	if err != nil {
+		return "", err
+	}
+	return coretesting.Stdout(ctx), nil
+}
+
+func (s *UserSuite) TestInit(c *gc.C) {
+	for i, t := range []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"add"},
+		err:  "no username specified",
+	}, {
+		args: []string{"add", "bob", "--permission", "root"},
+		err:  `invalid permission "root"`,
+	}, {
+		args: []string{"remove", "bob", "extra"},
+		err:  `unrecognized args: \["extra"\]`,
+	}, {
+		args: []string{"disable", "bob/1"},
+		err:  `invalid username "bob/1"`,
+	}, {
+		args: []string{"change-password", "bob"},
+		err:  "no password specified",
+	}, {
+		args: []string{"list", "bob"},
+		err:  `unrecognized args: \["bob"\]`,
+	}} {
+		c.Logf("test %d: %v", i, t.args)
+		err := coretesting.InitCommand(newUserCommand(), t.args)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *UserSuite) TestAddUser(c *gc.C) {
+	out, err := runUser(c, "add", "bob", "--password", "sekrit", "--permission", "write")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, "")
+	u, err := s.State.User("bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.WritePermission)
+	c.Assert(u.PasswordValid("sekrit"), jc.IsTrue)
+
+	out, err = runUser(c, "add", "alice")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Matches, "password: .+\n")
+	u, err = s.State.User("alice")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.ReadPermission)
+	c.Assert(u.PasswordValid(out[len("password: "):len(out)-1]), jc.IsTrue)
+}
+
+func (s *UserSuite) TestDisableEnableRemoveUser(c *gc.C) {
+	_, err := s.State.AddUser("bob", "sekrit")
+	c.Assert(err, gc.IsNil)
+
+	_, err = runUser(c, "disable", "bob")
+	c.Assert(err, gc.IsNil)
+	out, err := runUser(c, "list")
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, ""+
+		"admin:\n"+
+		"  permission: admin\n"+
+		"bob:\n"+
+		"  permission: admin\n"+
+		"  disabled: true\n")
+
+	_, err = runUser(c, "enable", "bob")
+	c.Assert(err, gc.IsNil)
+	u, err := s.State.User("bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsFalse)
+
+	_, err = runUser(c, "change-password", "bob", "new sekrit")
+	c.Assert(err, gc.IsNil)
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.PasswordValid("new sekrit"), jc.IsTrue)
+
+	_, err = runUser(c, "remove", "bob")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.User("bob")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
@@ -15,6 +15,7 @@ import (
 	"launchpad.net/juju-core/agent"
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/cloudinit"
 	"launchpad.net/juju-core/environs/config"
 	"launchpad.net/juju-core/instance"
@@ -26,6 +27,21 @@ import (
 // A variable is used here to allow tests to override.
 var providerStateURLFile = cloudinit.BootstrapStateURLFile
 
+// instanceAddresses returns the addresses of the instance with the
+// given id, as reported by the provider. It is a variable so that it
+// can be replaced in tests.
+var instanceAddresses = func(envCfg *config.Config, id instance.Id) ([]instance.Address, error) {
+	env, err := environs.New(envCfg)
+	if err != nil {
+		return nil, err
+	}
+	insts, err := env.Instances([]instance.Id{id})
+	if err != nil {
+		return nil, err
+	}
+	return insts[0].Addresses()
+}
+
 type BootstrapCommand struct {
 	cmd.CommandBase
 	Conf        AgentConf
@@ -96,11 +112,17 @@ func (c *BootstrapCommand) Run(_ *cmd.Context) error {
 	if len(bsState.Characteristics) > 0 {
 		characteristics = bsState.Characteristics[0]
 	}
+	instanceId := bsState.StateInstances[0]
+	addrs, err := instanceAddresses(envCfg, instanceId)
+	if err != nil {
+		return fmt.Errorf("cannot get addresses of bootstrap instance %q: %v", instanceId, err)
+	}
 	st, _, err := c.Conf.config.InitializeState(envCfg, agent.BootstrapMachineConfig{
 		Constraints:     c.Constraints,
 		Jobs:            jobs,
-		InstanceId:      bsState.StateInstances[0],
+		InstanceId:      instanceId,
 		Characteristics: characteristics,
+		Addresses:       addrs,
 	}, state.DefaultDialOpts())
 	if err != nil {
 		return err
@@ -13,6 +13,7 @@ import (
 
 	"launchpad.net/juju-core/agent"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs/config"
 	"launchpad.net/juju-core/environs/jujutest"
 	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/instance"
@@ -66,6 +67,15 @@ func (s *BootstrapSuite) SetUpTest(c *gc.C) {
 	s.LoggingSuite.SetUpTest(c)
 	s.MgoSuite.SetUpTest(c)
 	s.dataDir = c.MkDir()
+	s.PatchValue(&instanceAddresses, func(envCfg *config.Config, id instance.Id) ([]instance.Address, error) {
+		c.Check(id, gc.Equals, instance.Id("dummy.instance.id"))
+		return testAddresses, nil
+	})
+}
+
+var testAddresses = []instance.Address{
+	instance.NewAddress("0.1.2.3"),
+	{Value: "10.0.0.1", Type: instance.Ipv4Address, NetworkScope: instance.NetworkCloudLocal},
 }
 
 func (s *BootstrapSuite) TearDownTest(c *gc.C) {
@@ -128,6 +138,7 @@ func (s *BootstrapSuite) TestInitializeEnvironment(c *gc.C) {
 	instid, err := machines[0].InstanceId()
 	c.Assert(err, gc.IsNil)
 	c.Assert(instid, gc.Equals, instance.Id("dummy.instance.id"))
+	c.Assert(machines[0].Addresses(), gc.DeepEquals, testAddresses)
 
 	cons, err := st.EnvironConstraints()
 	c.Assert(err, gc.IsNil)
@@ -14,6 +14,7 @@ import (
 	"launchpad.net/tomb"
 
 	"launchpad.net/juju-core/agent"
+	"launchpad.net/juju-core/agent/tools"
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/cmd"
 	"launchpad.net/juju-core/instance"
@@ -24,18 +25,28 @@ import (
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/apiserver"
+	"launchpad.net/juju-core/upgrades"
 	"launchpad.net/juju-core/upstart"
 	"launchpad.net/juju-core/worker"
 	"launchpad.net/juju-core/worker/addressupdater"
+	"launchpad.net/juju-core/worker/apiaddressupdater"
+	"launchpad.net/juju-core/worker/auditlogpruner"
 	"launchpad.net/juju-core/worker/cleaner"
 	"launchpad.net/juju-core/worker/deployer"
 	"launchpad.net/juju-core/worker/firewaller"
 	"launchpad.net/juju-core/worker/localstorage"
 	"launchpad.net/juju-core/worker/logger"
+	"launchpad.net/juju-core/worker/logsender"
 	"launchpad.net/juju-core/worker/machiner"
+	"launchpad.net/juju-core/worker/metricspruner"
 	"launchpad.net/juju-core/worker/minunitsworker"
+	"launchpad.net/juju-core/worker/peergrouper"
 	"launchpad.net/juju-core/worker/provisioner"
+	"launchpad.net/juju-core/worker/recoverer"
 	"launchpad.net/juju-core/worker/resumer"
+	"launchpad.net/juju-core/worker/scaler"
+	"launchpad.net/juju-core/worker/statushistorypruner"
+	"launchpad.net/juju-core/worker/storageprovisioner"
 	"launchpad.net/juju-core/worker/upgrader"
 )
 
@@ -53,6 +64,10 @@ const bootstrapMachineId = "0"
 
 var retryDelay = 3 * time.Second
 
+// jujuRun holds the path of the juju-run command, which the machine
+// agent makes available on its machine.
+var jujuRun = "/usr/local/bin/juju-run"
+
 // MachineAgent is a cmd.Command responsible for running a machine agent.
 type MachineAgent struct {
 	cmd.CommandBase
@@ -111,6 +126,7 @@ func (a *MachineAgent) Run(_ *cmd.Context) error {
 		return err
 	}
 	charm.CacheDir = filepath.Join(a.Conf.dataDir, "charmcache")
+	a.ensureJujuRunSymlink()
 
 	// ensureStateWorker ensures that there is a worker that
 	// connects to the state that runs within itself all the workers
@@ -173,6 +189,12 @@ func (a *MachineAgent) APIWorker(ensureStateWorker func()) (worker.Worker, error
 	runner.StartWorker("logger", func() (worker.Worker, error) {
 		return logger.NewLogger(st.Logger(), agentConfig), nil
 	})
+	runner.StartWorker("logsender", func() (worker.Worker, error) {
+		return logsender.New(logRecords, st.Logger()), nil
+	})
+	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
+		return apiaddressupdater.NewAPIAddressUpdater(st.Machiner(), agentConfig), nil
+	})
 	// At this stage, since we don't embed LXC containers, just start an lxc
 	// provisioner task for non-lxc containers.  Since we have only LXC
 	// containers and normal machines, this effectively means that we only
@@ -192,6 +214,14 @@ func (a *MachineAgent) APIWorker(ensureStateWorker func()) (worker.Worker, error
 			return provisioner.NewProvisioner(provisioner.LXC, st.Provisioner(), agentConfig), nil
 		})
 	}
+	// KVM containers cannot be nested, so only machines that are not
+	// themselves containers get a KVM provisioner.
+	if providerType != provider.Local && entity.ContainerType() == "" {
+		workerName := fmt.Sprintf("%s-provisioner", provisioner.KVM)
+		runner.StartWorker(workerName, func() (worker.Worker, error) {
+			return provisioner.NewProvisioner(provisioner.KVM, st.Provisioner(), agentConfig), nil
+		})
+	}
 	for _, job := range entity.Jobs() {
 		switch job {
 		case params.JobHostUnits:
@@ -226,6 +256,18 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 	reportOpenedState(st)
 	m := entity.(*state.Machine)
 
+	// When the environment is being upgraded, the data in state must
+	// be migrated before the API is served to the other agents.
+	for _, job := range m.Jobs() {
+		if job != state.JobManageState {
+			continue
+		}
+		if err := upgrades.MigrateState(st, m.Id()); err != nil {
+			st.Close()
+			return nil, err
+		}
+	}
+
 	runner := newRunner(connectionIsFatal(st), moreImportant)
 	// Take advantage of special knowledge here in that we will only ever want
 	// the storage provider on one machine, and that is the "bootstrap" node.
@@ -252,6 +294,18 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 			runner.StartWorker("addressupdater", func() (worker.Worker, error) {
 				return addressupdater.NewWorker(st), nil
 			})
+			runner.StartWorker("storageprovisioner", func() (worker.Worker, error) {
+				return storageprovisioner.New(st), nil
+			})
+			runner.StartWorker("recoverer", func() (worker.Worker, error) {
+				return recoverer.New(st, recoverer.DefaultParams), nil
+			})
+			// Like the recoverer, the scaler adds and removes units
+			// without coordinating with other instances of itself, so
+			// it must not run on every state server.
+			runner.StartWorker("scaler", func() (worker.Worker, error) {
+				return scaler.New(st, scaler.DefaultParams), nil
+			})
 		case state.JobManageState:
 			runner.StartWorker("apiserver", func() (worker.Worker, error) {
 				// If the configuration does not have the required information,
@@ -275,9 +329,21 @@ func (a *MachineAgent) StateWorker() (worker.Worker, error) {
 				// the transaction log.
 				return resumer.NewResumer(st), nil
 			})
+			runner.StartWorker("statushistorypruner", func() (worker.Worker, error) {
+				return statushistorypruner.New(st, statushistorypruner.DefaultParams), nil
+			})
+			runner.StartWorker("auditlogpruner", func() (worker.Worker, error) {
+				return auditlogpruner.New(st, auditlogpruner.DefaultParams), nil
+			})
+			runner.StartWorker("metricspruner", func() (worker.Worker, error) {
+				return metricspruner.New(st, metricspruner.DefaultParams), nil
+			})
 			runner.StartWorker("minunitsworker", func() (worker.Worker, error) {
 				return minunitsworker.NewMinUnitsWorker(st), nil
 			})
+			runner.StartWorker("peergrouper", func() (worker.Worker, error) {
+				return peergrouper.New(st, a.MachineId), nil
+			})
 		default:
 			log.Warningf("ignoring unknown job %q", job)
 		}
@@ -304,6 +370,19 @@ func (a *MachineAgent) Tag() string {
 	return names.MachineTag(a.MachineId)
 }
 
+// ensureJujuRunSymlink makes juju-run a link to the agent's jujud. A
+// failure is only logged, because the agent can work without it.
+func (a *MachineAgent) ensureJujuRunSymlink() {
+	jujud := filepath.Join(tools.ToolsDir(a.Conf.dataDir, a.Tag()), "jujud")
+	err := os.Symlink(jujud, jujuRun)
+	if e, ok := err.(*os.LinkError); ok && os.IsExist(e.Err) {
+		err = nil
+	}
+	if err != nil {
+		log.Warningf("cannot make juju-run available: %v", err)
+	}
+}
+
 func (m *MachineAgent) uninstallAgent() error {
 	// TODO(axw) get this from agent config when it's available
 	name := os.Getenv("UPSTART_JOB")
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"os"
 	"path/filepath"
 	"reflect"
 	"time"
@@ -45,6 +46,8 @@ func (s *MachineSuite) SetUpSuite(c *gc.C) {
 	s.TestSuite.SetUpSuite(c)
 	restore := testbase.PatchValue(&charm.CacheDir, c.MkDir())
 	s.AddSuiteCleanup(func(*gc.C) { restore() })
+	restore = testbase.PatchValue(&jujuRun, filepath.Join(c.MkDir(), "juju-run"))
+	s.AddSuiteCleanup(func(*gc.C) { restore() })
 }
 
 func (s *MachineSuite) TearDownSuite(c *gc.C) {
@@ -142,6 +145,22 @@ func (s *MachineSuite) TestRunStop(c *gc.C) {
 	c.Assert(charm.CacheDir, gc.Equals, filepath.Join(ac.DataDir(), "charmcache"))
 }
 
+func (s *MachineSuite) TestMakesJujuRunSymlink(c *gc.C) {
+	os.Remove(jujuRun)
+	m, ac, _ := s.primeAgent(c, state.JobHostUnits)
+	a := s.newAgent(c, m)
+	done := make(chan error)
+	go func() {
+		done <- a.Run(nil)
+	}()
+	err := a.Stop()
+	c.Assert(err, gc.IsNil)
+	c.Assert(<-done, gc.IsNil)
+	link, err := os.Readlink(jujuRun)
+	c.Assert(err, gc.IsNil)
+	c.Assert(link, gc.Equals, filepath.Join(ac.DataDir(), "tools", m.Tag(), "jujud"))
+}
+
 func (s *MachineSuite) TestWithDeadMachine(c *gc.C) {
 	m, _, _ := s.primeAgent(c, state.JobHostUnits, state.JobManageState)
 	err := m.EnsureDead()
@@ -9,7 +9,10 @@ import (
 	"os"
 	"path/filepath"
 
+	"launchpad.net/loggo"
+
 	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/worker/logsender"
 	"launchpad.net/juju-core/worker/uniter/jujuc"
 
 	// Import the providers.
@@ -26,8 +29,19 @@ The jujud command can also forward invocations over RPC for execution by the
 juju unit agent. When used in this way, it expects to be called via a symlink
 named for the desired remote command, and expects JUJU_AGENT_SOCKET and
 JUJU_CONTEXT_ID be set in its environment.
+
+When called via a symlink named juju-run, jujud runs commands in the hook
+context of a unit on the machine; see "juju-run --help".
 `
 
+// logBufferSize holds the number of log records buffered by an agent
+// while they wait to be sent to the API server.
+const logBufferSize = 1000
+
+// logRecords collects the log records written by the running agent,
+// which are sent to the API server by the logsender worker.
+var logRecords = logsender.NewBufferedLogWriter(logBufferSize)
+
 func getenv(name string) (string, error) {
 	value := os.Getenv(name)
 	if value == "" {
@@ -98,6 +112,9 @@ func jujuDMain(args []string) (code int, err error) {
 	jujud.Register(&MachineAgent{})
 	jujud.Register(&UnitAgent{})
 	jujud.Register(&cmd.VersionCommand{})
+	if err := loggo.RegisterWriter("logsender", logRecords, loggo.TRACE); err != nil {
+		return 1, err
+	}
 	code = cmd.Main(jujud, cmd.DefaultContext(), args[1:])
 	return code, nil
 }
@@ -110,6 +127,8 @@ func Main(args []string) {
 	commandName := filepath.Base(args[0])
 	if commandName == "jujud" {
 		code, err = jujuDMain(args)
+	} else if commandName == "juju-run" {
+		code = cmd.Main(&RunCommand{}, cmd.DefaultContext(), args[1:])
 	} else if commandName == "jujuc" {
 		fmt.Fprint(os.Stderr, jujudDoc)
 		code = 2
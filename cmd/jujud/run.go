@@ -0,0 +1,184 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"fmt"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/log"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils/exec"
+	"launchpad.net/juju-core/utils/fslock"
+	"launchpad.net/juju-core/worker/uniter"
+)
+
+// RunCommand runs commands on a machine, in the hook context of one of
+// its units unless --no-context is given.
+type RunCommand struct {
+	cmd.CommandBase
+	dataDir    string
+	unit       string
+	commands   string
+	relation   string
+	relationId int
+	noContext  bool
+}
+
+const runCommandDoc = `
+Run the specified commands in the hook context for the unit.
+
+unit-name can be either the unit tag:
+ i.e.  unit-ubuntu-0
+or the unit id:
+ i.e.  ubuntu/0
+
+The commands are run by bash, and never at the same time as a hook.
+If --relation is specified, the commands are run in the context of the
+relation with that id, so relation-get and relation-set act on it by
+default.
+
+If --no-context is specified, the <unit-name> positional argument is
+not needed, and the commands are run on the machine outside any hook
+context, though still never at the same time as a hook.
+
+The exit code of juju-run is that of the commands.
+`
+
+func (c *RunCommand) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:    "juju-run",
+		Args:    "<unit-name> <commands>",
+		Purpose: "run commands in a unit's hook context",
+		Doc:     runCommandDoc,
+	}
+}
+
+func (c *RunCommand) SetFlags(f *gnuflag.FlagSet) {
+	f.StringVar(&c.dataDir, "data-dir", "/var/lib/juju", "directory for juju data")
+	f.BoolVar(&c.noContext, "no-context", false, "do not run the commands in a unit context")
+	f.StringVar(&c.relation, "r", "", "run the commands in the context of this relation id")
+	f.StringVar(&c.relation, "relation", "", "")
+}
+
+func (c *RunCommand) Init(args []string) error {
+	// make sure we aren't in an existing hook context
+	if contextId, err := getenv("JUJU_CONTEXT_ID"); err == nil && contextId != "" {
+		return fmt.Errorf("juju-run cannot be called from within a hook, have context %q", contextId)
+	}
+	if !c.noContext {
+		if len(args) < 1 {
+			return fmt.Errorf("missing unit-name")
+		}
+		c.unit, args = args[0], args[1:]
+		// If the command line param is a unit id (like service/2) we need to
+		// change it to the unit tag as that is the format of the agent directory
+		// on disk (unit-service-2).
+		if names.IsUnit(c.unit) {
+			c.unit = names.UnitTag(c.unit)
+		} else if _, err := names.ParseTag(c.unit, names.UnitTagKind); err != nil {
+			return fmt.Errorf("invalid unit name %q", c.unit)
+		}
+		relationId, err := parseRelationId(c.relation)
+		if err != nil {
+			return err
+		}
+		c.relationId = relationId
+	} else if c.relation != "" {
+		return fmt.Errorf("--relation cannot be used with --no-context")
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("missing commands")
+	}
+	c.commands, args = args[0], args[1:]
+	return cmd.CheckEmpty(args)
+}
+
+// parseRelationId returns the numeric id of the relation given as either
+// an id or a "<name>:<id>" pair as found in JUJU_RELATION_ID, or -1 if
+// no relation is given.
+func parseRelationId(relation string) (int, error) {
+	if relation == "" {
+		return -1, nil
+	}
+	idString := relation
+	if i := strings.LastIndex(relation, ":"); i != -1 {
+		idString = relation[i+1:]
+	}
+	id, err := strconv.Atoi(idString)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid relation id %q", relation)
+	}
+	return id, nil
+}
+
+func (c *RunCommand) Run(ctx *cmd.Context) error {
+	var result *exec.ExecResponse
+	var err error
+	if c.noContext {
+		result, err = c.executeNoContext()
+	} else {
+		result, err = c.executeInUnitContext()
+	}
+	if err != nil {
+		return err
+	}
+	ctx.Stdout.Write(result.Stdout)
+	ctx.Stderr.Write(result.Stderr)
+	if result.Code != 0 {
+		return cmd.NewRcPassthroughError(result.Code)
+	}
+	return nil
+}
+
+func (c *RunCommand) executeInUnitContext() (*exec.ExecResponse, error) {
+	unitDir := filepath.Join(c.dataDir, "agents", c.unit)
+	log.Debugf("looking for unit dir %s", unitDir)
+	// make sure the unit exists
+	_, err := os.Stat(unitDir)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("unit %q not found on this machine", c.unit)
+	} else if err != nil {
+		return nil, err
+	}
+	socketPath := filepath.Join(unitDir, uniter.RunSocketName)
+	client, err := rpc.Dial("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var result exec.ExecResponse
+	args := uniter.RunCommandsArgs{
+		Commands:   c.commands,
+		RelationId: c.relationId,
+	}
+	err = client.Call(uniter.JujuRunEndpoint, args, &result)
+	return &result, err
+}
+
+func (c *RunCommand) executeNoContext() (*exec.ExecResponse, error) {
+	// Acquire the uniter hook execution lock to make sure we don't
+	// stomp on any hooks that are running.
+	lock, err := fslock.NewLock(filepath.Join(c.dataDir, "locks"), "uniter-hook-execution")
+	if err != nil {
+		return nil, err
+	}
+	if err := lock.Lock("juju-run"); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	return exec.RunCommands(exec.RunParams{
+		Commands:    c.commands,
+		Environment: os.Environ(),
+	})
+}
@@ -0,0 +1,177 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/utils/exec"
+	"launchpad.net/juju-core/utils/fslock"
+	"launchpad.net/juju-core/worker/uniter"
+)
+
+type RunTestSuite struct {
+	testbase.LoggingSuite
+	dataDir string
+}
+
+var _ = gc.Suite(&RunTestSuite{})
+
+func (s *RunTestSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	s.dataDir = c.MkDir()
+	s.PatchEnvironment("JUJU_CONTEXT_ID", "")
+}
+
+func (*RunTestSuite) TestArgParsing(c *gc.C) {
+	for i, test := range []struct {
+		title      string
+		args       []string
+		errMatch   string
+		unit       string
+		commands   string
+		relationId int
+		noContext  bool
+	}{{
+		title:    "no args",
+		errMatch: "missing unit-name",
+	}, {
+		title:    "one arg",
+		args:     []string{"foo"},
+		errMatch: `invalid unit name "foo"`,
+	}, {
+		title:    "more than two arg",
+		args:     []string{"foo/2", "sudo reboot", "now"},
+		errMatch: `unrecognized args: \["now"\]`,
+	}, {
+		title:      "unit and command assignment",
+		args:       []string{"unit-name-2", "command"},
+		unit:       "unit-name-2",
+		commands:   "command",
+		relationId: -1,
+	}, {
+		title:      "unit id converted to tag",
+		args:       []string{"foo/1", "command"},
+		unit:       "unit-foo-1",
+		commands:   "command",
+		relationId: -1,
+	}, {
+		title:      "relation id",
+		args:       []string{"--relation", "db:3", "foo/1", "command"},
+		unit:       "unit-foo-1",
+		commands:   "command",
+		relationId: 3,
+	}, {
+		title:    "bad relation id",
+		args:     []string{"--relation", "db:x", "foo/1", "command"},
+		errMatch: `invalid relation id "db:x"`,
+	}, {
+		title:     "execute not in a context",
+		args:      []string{"--no-context", "command"},
+		commands:  "command",
+		noContext: true,
+	}, {
+		title:    "relation without a context",
+		args:     []string{"--no-context", "--relation", "3", "command"},
+		errMatch: "--relation cannot be used with --no-context",
+	}} {
+		c.Logf("%d: %s", i, test.title)
+		runCommand := &RunCommand{}
+		err := testing.InitCommand(runCommand, test.args)
+		if test.errMatch == "" {
+			c.Assert(err, gc.IsNil)
+			c.Assert(runCommand.unit, gc.Equals, test.unit)
+			c.Assert(runCommand.commands, gc.Equals, test.commands)
+			c.Assert(runCommand.relationId, gc.Equals, test.relationId)
+			c.Assert(runCommand.noContext, gc.Equals, test.noContext)
+		} else {
+			c.Assert(err, gc.ErrorMatches, test.errMatch)
+		}
+	}
+}
+
+func (s *RunTestSuite) TestInsideContext(c *gc.C) {
+	s.PatchEnvironment("JUJU_CONTEXT_ID", "fake-id")
+	runCommand := &RunCommand{}
+	err := runCommand.Init([]string{"foo", "bar"})
+	c.Assert(err, gc.ErrorMatches, "juju-run cannot be called from within a hook.*")
+}
+
+func (s *RunTestSuite) runCommand(c *gc.C, args ...string) (*cmd.Context, error) {
+	args = append([]string{"--data-dir", s.dataDir}, args...)
+	return testing.RunCommand(c, &RunCommand{}, args)
+}
+
+func (s *RunTestSuite) TestMissingAgent(c *gc.C) {
+	_, err := s.runCommand(c, "foo/2", "bar")
+	c.Assert(err, gc.ErrorMatches, `unit "unit-foo-2" not found on this machine`)
+}
+
+func (s *RunTestSuite) TestNoContext(c *gc.C) {
+	ctx, err := s.runCommand(c, "--no-context", "echo done; echo oops >&2; exit 42")
+	c.Assert(err, gc.DeepEquals, cmd.NewRcPassthroughError(42))
+	c.Assert(testing.Stdout(ctx), gc.Equals, "done\n")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "oops\n")
+}
+
+func (s *RunTestSuite) TestNoContextWaitsForHookLock(c *gc.C) {
+	lock, err := fslock.NewLock(filepath.Join(s.dataDir, "locks"), "uniter-hook-execution")
+	c.Assert(err, gc.IsNil)
+	err = lock.Lock("running hook")
+	c.Assert(err, gc.IsNil)
+	done := make(chan error)
+	go func() {
+		_, err := s.runCommand(c, "--no-context", "echo done")
+		done <- err
+	}()
+	select {
+	case <-done:
+		c.Fatalf("commands ran while a hook held the lock")
+	case <-time.After(testing.ShortWait):
+	}
+	err = lock.Unlock()
+	c.Assert(err, gc.IsNil)
+	select {
+	case err := <-done:
+		c.Assert(err, gc.IsNil)
+	case <-time.After(testing.LongWait):
+		c.Fatalf("commands did not run")
+	}
+}
+
+func (s *RunTestSuite) TestRunInUnitContext(c *gc.C) {
+	unitDir := filepath.Join(s.dataDir, "agents", "unit-foo-1")
+	err := os.MkdirAll(unitDir, 0755)
+	c.Assert(err, gc.IsNil)
+	socketPath := filepath.Join(unitDir, uniter.RunSocketName)
+	listener, err := uniter.NewRunListener(&mockRunner{c}, "unix", socketPath)
+	c.Assert(err, gc.IsNil)
+	go listener.Run()
+	defer listener.Close()
+
+	ctx, err := s.runCommand(c, "--relation", "db:2", "foo/1", "bar")
+	c.Assert(err, gc.DeepEquals, cmd.NewRcPassthroughError(2))
+	c.Assert(testing.Stdout(ctx), gc.Equals, "bar stdout")
+	c.Assert(testing.Stderr(ctx), gc.Equals, "bar stderr")
+}
+
+type mockRunner struct {
+	c *gc.C
+}
+
+func (r *mockRunner) RunCommands(args uniter.RunCommandsArgs) (*exec.ExecResponse, error) {
+	r.c.Log("mock runner: " + args.Commands)
+	return &exec.ExecResponse{
+		Code:   args.RelationId,
+		Stdout: []byte(args.Commands + " stdout"),
+		Stderr: []byte(args.Commands + " stderr"),
+	}, nil
+}
@@ -14,7 +14,9 @@ import (
 	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/apiaddressupdater"
 	"launchpad.net/juju-core/worker/logger"
+	"launchpad.net/juju-core/worker/logsender"
 	"launchpad.net/juju-core/worker/uniter"
 	"launchpad.net/juju-core/worker/upgrader"
 )
@@ -91,6 +93,12 @@ func (a *UnitAgent) APIWorkers() (worker.Worker, error) {
 	runner.StartWorker("logger", func() (worker.Worker, error) {
 		return logger.NewLogger(st.Logger(), agentConfig), nil
 	})
+	runner.StartWorker("logsender", func() (worker.Worker, error) {
+		return logsender.New(logRecords, st.Logger()), nil
+	})
+	runner.StartWorker("apiaddressupdater", func() (worker.Worker, error) {
+		return apiaddressupdater.NewAPIAddressUpdater(st.Uniter(), agentConfig), nil
+	})
 	runner.StartWorker("uniter", func() (worker.Worker, error) {
 		return uniter.NewUniter(st.Uniter(), entity.Tag(), dataDir), nil
 	})
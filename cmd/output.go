@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -72,6 +73,111 @@ func FormatSmart(value interface{}) ([]byte, error) {
 	return FormatYaml(value)
 }
 
+// TableSection holds one section of the output written by
+// FormatTabular: a title, the column headings, and a row of cells
+// for each item.
+type TableSection struct {
+	Title    string
+	Headings []string
+	Rows     [][]string
+}
+
+// Tabular is implemented by values that can be written by
+// FormatTabular.
+type Tabular interface {
+	Tabulate() []TableSection
+}
+
+// OneLiner is implemented by values that can be written by
+// FormatOneline.
+type OneLiner interface {
+	OneLine() []string
+}
+
+// FormatTabular writes each section of a Tabular value as a title
+// followed by the headings and rows, with the columns aligned.
+// Sections without rows are omitted.
+func FormatTabular(value interface{}) ([]byte, error) {
+	tabular, ok := value.(Tabular)
+	if !ok {
+		return nil, fmt.Errorf("cannot format %T as a table", value)
+	}
+	var buf bytes.Buffer
+	for _, section := range tabular.Tabulate() {
+		if len(section.Rows) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		if section.Title != "" {
+			fmt.Fprintf(&buf, "[%s]\n", section.Title)
+		}
+		rows := append([][]string{section.Headings}, section.Rows...)
+		var widths []int
+		for _, row := range rows {
+			for i, cell := range row {
+				if i == len(widths) {
+					widths = append(widths, 0)
+				}
+				if n := textWidth(cell); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+		for _, row := range rows {
+			var line string
+			for i, cell := range row {
+				line += cell + strings.Repeat(" ", widths[i]-textWidth(cell)+1)
+			}
+			buf.WriteString(strings.TrimRight(line, " "))
+			buf.WriteByte('\n')
+		}
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// FormatOneline writes the lines of a OneLiner value.
+func FormatOneline(value interface{}) ([]byte, error) {
+	oneLiner, ok := value.(OneLiner)
+	if !ok {
+		return nil, fmt.Errorf("cannot format %T as lines", value)
+	}
+	return []byte(strings.Join(oneLiner.OneLine(), "\n")), nil
+}
+
+// Colors that can be given to Colorize.
+const (
+	ColorRed    = "31"
+	ColorGreen  = "32"
+	ColorYellow = "33"
+)
+
+// Colorize returns text wrapped in the ANSI escape sequences that
+// show it in the given color on a terminal. FormatTabular ignores
+// the escape sequences when aligning columns.
+func Colorize(text, color string) string {
+	return "\x1b[" + color + "m" + text + "\x1b[0m"
+}
+
+// textWidth returns the number of characters shown when text is
+// written to a terminal, not counting ANSI escape sequences.
+func textWidth(text string) int {
+	n := 0
+	escape := false
+	for _, r := range text {
+		switch {
+		case escape:
+			escape = r != 'm'
+		case r == '\x1b':
+			escape = true
+		default:
+			n++
+		}
+	}
+	return n
+}
+
 // DefaultFormatters holds the formatters that can be
 // specified with the --format flag.
 var DefaultFormatters = map[string]Formatter{
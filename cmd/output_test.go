@@ -144,3 +144,56 @@ func (s *CmdSuite) TestFormatAlternativeSyntax(c *gc.C) {
 	c.Assert(result, gc.Equals, 0)
 	c.Assert(bufferString(ctx.Stdout), gc.Equals, "null\n")
 }
+
+type tabularValue []cmd.TableSection
+
+func (v tabularValue) Tabulate() []cmd.TableSection {
+	return v
+}
+
+func (s *CmdSuite) TestFormatTabular(c *gc.C) {
+	value := tabularValue{{
+		Title:    "Machines",
+		Headings: []string{"ID", "STATE", "DNS"},
+		Rows: [][]string{
+			{"0", cmd.Colorize("error", cmd.ColorRed), "10.0.0.1"},
+			{"10", "started", ""},
+		},
+	}, {
+		Title:    "Empty",
+		Headings: []string{"NAME"},
+	}, {
+		Title:    "Services",
+		Headings: []string{"NAME", "EXPOSED"},
+		Rows:     [][]string{{"mysql", "false"}},
+	}}
+	out, err := cmd.FormatTabular(value)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, ""+
+		"[Machines]\n"+
+		"ID STATE   DNS\n"+
+		"0  \x1b[31merror\x1b[0m   10.0.0.1\n"+
+		"10 started\n"+
+		"\n"+
+		"[Services]\n"+
+		"NAME  EXPOSED\n"+
+		"mysql false")
+
+	_, err = cmd.FormatTabular("foo")
+	c.Assert(err, gc.ErrorMatches, "cannot format string as a table")
+}
+
+type oneLinerValue []string
+
+func (v oneLinerValue) OneLine() []string {
+	return v
+}
+
+func (s *CmdSuite) TestFormatOneline(c *gc.C) {
+	out, err := cmd.FormatOneline(oneLinerValue{"- mysql/0: 10.0.0.1 (started)", "- mysql/1: (pending)"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(out), gc.Equals, "- mysql/0: 10.0.0.1 (started)\n- mysql/1: (pending)")
+
+	_, err = cmd.FormatOneline(42)
+	c.Assert(err, gc.ErrorMatches, "cannot format int as lines")
+}
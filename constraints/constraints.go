@@ -5,11 +5,12 @@ package constraints
 
 import (
 	"fmt"
-	"math"
 	"strconv"
 	"strings"
 
 	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
 )
 
 // Value describes a user's requirements of the hardware on which units
@@ -49,6 +50,12 @@ type Value struct {
 	// An empty list is treated the same as a nil (unspecified) list, except an
 	// empty list will override any default tags, where a nil list will not.
 	Tags *[]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Networks, if not nil, holds a list of juju network names that
+	// should be available (or not) on the machine. Positive and
+	// negative values are accepted, and the difference is the latter
+	// have a "^" prefix to the name.
+	Networks *[]string `json:"networks,omitempty" yaml:"networks,omitempty"`
 }
 
 // IsEmpty returns if the given constraints value has no constraints set
@@ -60,7 +67,8 @@ func IsEmpty(v *Value) bool {
 			v.CpuPower == nil &&
 			v.Mem == nil &&
 			v.RootDisk == nil &&
-			v.Tags == nil
+			v.Tags == nil &&
+			v.Networks == nil
 }
 
 // String expresses a constraints.Value in the language in which it was specified.
@@ -96,6 +104,10 @@ func (v Value) String() string {
 		s := strings.Join(*v.Tags, ",")
 		strs = append(strs, "tags="+s)
 	}
+	if v.Networks != nil {
+		s := strings.Join(*v.Networks, ",")
+		strs = append(strs, "networks="+s)
+	}
 	return strings.Join(strs, " ")
 }
 
@@ -123,9 +135,41 @@ func (v Value) WithFallbacks(v0 Value) Value {
 	if v.Tags != nil {
 		v1.Tags = v.Tags
 	}
+	if v.Networks != nil {
+		v1.Networks = v.Networks
+	}
 	return v1
 }
 
+// IncludeNetworks returns a list of networks to include when creating
+// a new machine.
+func (v *Value) IncludeNetworks() []string {
+	return v.extractNetworks(false)
+}
+
+// ExcludeNetworks returns a list of networks to exclude when creating
+// a new machine.
+func (v *Value) ExcludeNetworks() []string {
+	return v.extractNetworks(true)
+}
+
+// extractNetworks returns the networks of v with a "^" prefix, without
+// it, if negatives is true, and the networks without the prefix
+// otherwise.
+func (v *Value) extractNetworks(negatives bool) []string {
+	if v.Networks == nil {
+		return nil
+	}
+	var networks []string
+	for _, name := range *v.Networks {
+		isNegative := strings.HasPrefix(name, "^")
+		if isNegative == negatives {
+			networks = append(networks, strings.TrimPrefix(name, "^"))
+		}
+	}
+	return networks
+}
+
 func uintStr(i uint64) string {
 	if i == 0 {
 		return ""
@@ -203,6 +247,8 @@ func (v *Value) setRaw(raw string) error {
 		err = v.setRootDisk(str)
 	case "tags":
 		err = v.setTags(str)
+	case "networks":
+		err = v.setNetworks(str)
 	default:
 		return fmt.Errorf("unknown constraint %q", name)
 	}
@@ -241,6 +287,12 @@ func (v *Value) SetYAML(tag string, value interface{}) bool {
 			v.RootDisk, err = parseUint64(vstr)
 		case "tags":
 			v.Tags, err = parseYamlTags(val)
+		case "networks":
+			var networks *[]string
+			if networks, err = parseYamlTags(val); err == nil {
+				err = verifyNetworks(*networks)
+				v.Networks = networks
+			}
 		default:
 			return false
 		}
@@ -327,6 +379,29 @@ func (v *Value) setTags(str string) error {
 	return nil
 }
 
+func (v *Value) setNetworks(str string) error {
+	if v.Networks != nil {
+		return fmt.Errorf("already set")
+	}
+	networks := parseTags(str)
+	if err := verifyNetworks(*networks); err != nil {
+		return err
+	}
+	v.Networks = networks
+	return nil
+}
+
+// verifyNetworks returns an error if any of the given network names,
+// ignoring any "^" prefix, is not valid.
+func verifyNetworks(networks []string) error {
+	for _, name := range networks {
+		if !names.IsNetwork(strings.TrimPrefix(name, "^")) {
+			return fmt.Errorf("%q is not a valid network name", name)
+		}
+	}
+	return nil
+}
+
 func parseUint64(str string) (*uint64, error) {
 	var value uint64
 	if str != "" {
@@ -340,19 +415,9 @@ func parseUint64(str string) (*uint64, error) {
 }
 
 func parseSize(str string) (*uint64, error) {
-	var value uint64
-	if str != "" {
-		mult := 1.0
-		if m, ok := mbSuffixes[str[len(str)-1:]]; ok {
-			str = str[:len(str)-1]
-			mult = m
-		}
-		val, err := strconv.ParseFloat(str, 64)
-		if err != nil || val < 0 {
-			return nil, fmt.Errorf("must be a non-negative float with optional M/G/T/P suffix")
-		}
-		val *= mult
-		value = uint64(math.Ceil(val))
+	value, err := utils.ParseSize(str)
+	if err != nil {
+		return nil, err
 	}
 	return &value, nil
 }
@@ -381,10 +446,3 @@ func parseYamlTags(val interface{}) (*[]string, error) {
 	}
 	return &tags, nil
 }
-
-var mbSuffixes = map[string]float64{
-	"M": 1,
-	"G": 1024,
-	"T": 1024 * 1024,
-	"P": 1024 * 1024 * 1024,
-}
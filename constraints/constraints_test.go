@@ -263,6 +263,26 @@ var parseConstraintsTests = []struct {
 		args:    []string{"tags="},
 	},
 
+	// networks
+	{
+		summary: "single network",
+		args:    []string{"networks=net1"},
+	}, {
+		summary: "included and excluded networks",
+		args:    []string{"networks=net1,^net2,net3"},
+	}, {
+		summary: "no networks",
+		args:    []string{"networks="},
+	}, {
+		summary: "invalid network name",
+		args:    []string{"networks=net1,^net/2"},
+		err:     `bad "networks" constraint: "\^net/2" is not a valid network name`,
+	}, {
+		summary: "double set networks",
+		args:    []string{"networks=net1", "networks=net2"},
+		err:     `bad "networks" constraint: already set`,
+	},
+
 	// Everything at once.
 	{
 		summary: "kitchen sink together",
@@ -300,6 +320,16 @@ func (s *ConstraintsSuite) TestParseNoTags(c *gc.C) {
 	c.Check(*con.Tags, gc.HasLen, 0)
 }
 
+func (s *ConstraintsSuite) TestIncludeExcludeNetworks(c *gc.C) {
+	con := constraints.MustParse("networks=net1,^net2,net3,^net4")
+	c.Check(con.IncludeNetworks(), gc.DeepEquals, []string{"net1", "net3"})
+	c.Check(con.ExcludeNetworks(), gc.DeepEquals, []string{"net2", "net4"})
+
+	con = constraints.MustParse("mem=4G")
+	c.Check(con.IncludeNetworks(), gc.HasLen, 0)
+	c.Check(con.ExcludeNetworks(), gc.HasLen, 0)
+}
+
 func (s *ConstraintsSuite) TestIsEmpty(c *gc.C) {
 	con := constraints.Value{}
 	c.Check(&con, jc.Satisfies, constraints.IsEmpty)
@@ -321,6 +351,8 @@ func (s *ConstraintsSuite) TestIsEmpty(c *gc.C) {
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 	con = constraints.MustParse("container=")
 	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
+	con = constraints.MustParse("networks=")
+	c.Check(&con, gc.Not(jc.Satisfies), constraints.IsEmpty)
 }
 
 func uint64p(i uint64) *uint64 {
@@ -363,6 +395,9 @@ var constraintsRoundtripTests = []roundTrip{
 	{"Tags1", constraints.Value{Tags: nil}},
 	{"Tags2", constraints.Value{Tags: &[]string{}}},
 	{"Tags3", constraints.Value{Tags: &[]string{"foo", "bar"}}},
+	{"Networks1", constraints.Value{Networks: nil}},
+	{"Networks2", constraints.Value{Networks: &[]string{}}},
+	{"Networks3", constraints.Value{Networks: &[]string{"net1", "^net2"}}},
 	{"All", constraints.Value{
 		Arch:      strp("i386"),
 		Container: ctypep("lxc"),
@@ -371,6 +406,7 @@ var constraintsRoundtripTests = []roundTrip{
 		Mem:       uint64p(18000000000),
 		RootDisk:  uint64p(24000000000),
 		Tags:      &[]string{"foo", "bar"},
+		Networks:  &[]string{"net1", "^net2"},
 	}},
 }
 
@@ -491,6 +527,15 @@ var withFallbacksTests = []struct {
 		initial:   "tags=",
 		fallbacks: "tags=foo,bar",
 		final:     "tags=",
+	}, {
+		desc:      "networks with ignored fallback",
+		initial:   "networks=net1,^net2",
+		fallbacks: "networks=net3",
+		final:     "networks=net1,^net2",
+	}, {
+		desc:      "networks from fallback",
+		fallbacks: "networks=net1,^net2",
+		final:     "networks=net1,^net2",
 	}, {
 		desc:    "mem with empty fallback",
 		initial: "mem=4G",
@@ -0,0 +1,99 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package constraints
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"launchpad.net/juju-core/utils"
+)
+
+// Storage describes a user's requirements of the storage given to each
+// unit of a service, for one of the stores declared in the metadata of
+// the service's charm.
+type Storage struct {
+
+	// Size, if not zero, indicates the size in megabytes of each
+	// storage instance. It may not be less than the minimum size
+	// required by the charm.
+	Size uint64 `json:"size,omitempty" yaml:"size,omitempty"`
+
+	// Count, if not zero, indicates the number of storage instances
+	// each unit is given. It may not be less than the count required
+	// by the charm.
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+}
+
+// String expresses the storage constraint in the language in which
+// it was specified.
+func (s Storage) String() string {
+	str := uintStr(s.Size)
+	if str != "" {
+		str += "M"
+	}
+	if s.Count != 0 {
+		str += fmt.Sprintf(",%d", s.Count)
+	}
+	return str
+}
+
+// ParseStorage parses a storage constraint of the form
+// "<size>[,<count>]", where size is a float with an optional
+// M/G/T/P suffix. Either part may be empty.
+func ParseStorage(str string) (Storage, error) {
+	var s Storage
+	sizeStr, countStr := str, ""
+	if i := strings.Index(str, ","); i != -1 {
+		sizeStr, countStr = str[:i], str[i+1:]
+	}
+	var err error
+	if s.Size, err = utils.ParseSize(sizeStr); err != nil {
+		return Storage{}, fmt.Errorf("bad storage size %q: %v", sizeStr, err)
+	}
+	if countStr != "" {
+		if s.Count, err = strconv.Atoi(countStr); err != nil || s.Count < 0 {
+			return Storage{}, fmt.Errorf("bad storage count %q: must be a non-negative integer", countStr)
+		}
+	}
+	return s, nil
+}
+
+// StorageValue implements gnuflag.Value for the storage constraints
+// of a service, which are specified as repeated "<store>=<constraint>"
+// arguments.
+type StorageValue struct {
+	Target *map[string]Storage
+}
+
+func (v StorageValue) Set(str string) error {
+	eq := strings.Index(str, "=")
+	if eq <= 0 {
+		return fmt.Errorf("malformed storage constraint %q", str)
+	}
+	name := str[:eq]
+	s, err := ParseStorage(str[eq+1:])
+	if err != nil {
+		return err
+	}
+	if *v.Target == nil {
+		*v.Target = make(map[string]Storage)
+	}
+	if _, ok := (*v.Target)[name]; ok {
+		return fmt.Errorf("storage constraint for %q already set", name)
+	}
+	(*v.Target)[name] = s
+	return nil
+}
+
+func (v StorageValue) String() string {
+	var strs []string
+	for name, s := range *v.Target {
+		strs = append(strs, name+"="+s.String())
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, " ")
+}
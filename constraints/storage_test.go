@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package constraints_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+)
+
+type StorageSuite struct{}
+
+var _ = gc.Suite(&StorageSuite{})
+
+var parseStorageTests = []struct {
+	in  string
+	out constraints.Storage
+	str string
+	err string
+}{{
+	in:  "",
+	str: "",
+}, {
+	in:  "10G",
+	out: constraints.Storage{Size: 10240},
+	str: "10240M",
+}, {
+	in:  "512,3",
+	out: constraints.Storage{Size: 512, Count: 3},
+	str: "512M,3",
+}, {
+	in:  ",2",
+	out: constraints.Storage{Count: 2},
+	str: ",2",
+}, {
+	in:  "10K",
+	err: `bad storage size "10K": must be a non-negative float with optional M/G/T/P suffix`,
+}, {
+	in:  "10G,-1",
+	err: `bad storage count "-1": must be a non-negative integer`,
+}, {
+	in:  "10G,x",
+	err: `bad storage count "x": must be a non-negative integer`,
+}}
+
+func (s *StorageSuite) TestParseStorage(c *gc.C) {
+	for i, t := range parseStorageTests {
+		c.Logf("test %d: %q", i, t.in)
+		cons, err := constraints.ParseStorage(t.in)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+			continue
+		}
+		c.Check(err, gc.IsNil)
+		c.Check(cons, gc.Equals, t.out)
+		c.Check(cons.String(), gc.Equals, t.str)
+	}
+}
+
+func (s *StorageSuite) TestStorageValue(c *gc.C) {
+	var target map[string]constraints.Storage
+	v := constraints.StorageValue{&target}
+	err := v.Set("data=10G,2")
+	c.Assert(err, gc.IsNil)
+	err = v.Set("logs=1G")
+	c.Assert(err, gc.IsNil)
+	c.Assert(target, gc.DeepEquals, map[string]constraints.Storage{
+		"data": {Size: 10240, Count: 2},
+		"logs": {Size: 1024},
+	})
+	c.Assert(v.String(), gc.Equals, "data=10240M,2 logs=1024M")
+
+	err = v.Set("data=1G")
+	c.Assert(err, gc.ErrorMatches, `storage constraint for "data" already set`)
+	err = v.Set("=1G")
+	c.Assert(err, gc.ErrorMatches, `malformed storage constraint "=1G"`)
+}
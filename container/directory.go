@@ -0,0 +1,74 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ContainerDir holds a directory for each container, containing
+	// its cloud-init user data and logs.
+	ContainerDir = "/var/lib/juju/containers"
+	// RemovedContainerDir holds the directories of containers that
+	// have been stopped, kept for debugging.
+	RemovedContainerDir = "/var/lib/juju/removed-containers"
+)
+
+// NewDirectory creates the directory of the named container, and
+// returns its path.
+func NewDirectory(containerName string) (string, error) {
+	directory := jujuContainerDirectory(containerName)
+	logger.Tracef("create directory: %s", directory)
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		logger.Errorf("failed to create container directory: %v", err)
+		return "", err
+	}
+	return directory, nil
+}
+
+// RemoveDirectory moves the directory of the named container into
+// RemovedContainerDir.
+func RemoveDirectory(containerName string) error {
+	logger.Tracef("create old container dir: %s", RemovedContainerDir)
+	if err := os.MkdirAll(RemovedContainerDir, 0755); err != nil {
+		logger.Errorf("failed to create removed container directory: %v", err)
+		return err
+	}
+	removedDir, err := uniqueDirectory(RemovedContainerDir, containerName)
+	if err != nil {
+		logger.Errorf("was not able to generate a unique directory: %v", err)
+		return err
+	}
+	if err := os.Rename(jujuContainerDirectory(containerName), removedDir); err != nil {
+		logger.Errorf("failed to rename container directory: %v", err)
+		return err
+	}
+	return nil
+}
+
+func jujuContainerDirectory(containerName string) string {
+	return filepath.Join(ContainerDir, containerName)
+}
+
+// uniqueDirectory returns "path/name" if that directory doesn't exist.  If it
+// does, the method starts appending .1, .2, etc until a unique name is found.
+func uniqueDirectory(path, name string) (string, error) {
+	dir := filepath.Join(path, name)
+	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return dir, nil
+	}
+	for i := 1; ; i++ {
+		dir := filepath.Join(path, fmt.Sprintf("%s.%d", name, i))
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			return dir, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm
+
+var (
+	RunCommand        = &runCommand
+	InstallPackages   = &installPackages
+	LookPath          = &lookPath
+	DependenciesReady = &dependenciesReady
+	ListMachines      = listMachines
+	NewContainer      = (*containerFactory).New
+)
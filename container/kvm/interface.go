@@ -0,0 +1,56 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm
+
+import (
+	"launchpad.net/juju-core/container"
+)
+
+// StartParams holds the arguments used to create and start a kvm
+// virtual machine.
+type StartParams struct {
+	Series       string
+	Arch         string
+	UserDataFile string
+	Network      *container.NetworkConfig
+
+	// Memory holds the memory of the machine, in megabytes.
+	Memory uint64
+	// CpuCores holds the number of virtual CPUs of the machine.
+	CpuCores uint64
+	// RootDisk holds the size of the root disk, in gigabytes.
+	RootDisk uint64
+}
+
+// Container represents a virtualized container instance and provides
+// operations to create, maintain and destroy the container.
+type Container interface {
+	// Name returns the name of the container.
+	Name() string
+
+	// Start is responsible for creating and starting the container.
+	Start(params StartParams) error
+
+	// Stop terminates the running container and destroys it.
+	Stop() error
+
+	// IsRunning returns whether or not the container is running and
+	// active.
+	IsRunning() bool
+
+	// String returns information about the container, like the name,
+	// state, and process id.
+	String() string
+}
+
+// ContainerFactory represents the methods used to create Containers,
+// and to list the containers on the machine.
+type ContainerFactory interface {
+	// New returns a container instance which can then be used for
+	// operations like Start() and Stop().
+	New(name string) Container
+
+	// List returns all the existing containers on the system.
+	List() ([]Container, error)
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/environs/cloudinit"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/names"
+)
+
+var (
+	logger = loggo.GetLogger("juju.container.kvm")
+
+	// KvmObjectFactory creates the kvm containers. It is replaced by a
+	// mock implementation in tests.
+	KvmObjectFactory ContainerFactory = &containerFactory{}
+)
+
+const (
+	// DefaultKvmBridge is the bridge created by libvirt for its
+	// default network.
+	DefaultKvmBridge = "virbr0"
+
+	// The sizes of the virtual machines used when no constraints
+	// are given.
+	DefaultMemory   uint64 = 512 // MB
+	DefaultCpuCores uint64 = 1
+	DefaultRootDisk uint64 = 8 // GB
+)
+
+type containerManager struct {
+	name   string
+	logdir string
+}
+
+var _ container.ContainerManager = (*containerManager)(nil)
+
+// NewContainerManager returns a manager object that can start and stop kvm
+// containers. The containers that are created are namespaced by the name
+// parameter.
+func NewContainerManager(conf container.ManagerConfig) container.ContainerManager {
+	logdir := "/var/log/juju"
+	if conf.LogDir != "" {
+		logdir = conf.LogDir
+	}
+	return &containerManager{name: conf.Name, logdir: logdir}
+}
+
+// StartContainer creates and starts a kvm virtual machine for the
+// specified machine. The machine is sized according to the
+// constraints in machineConfig.
+func (manager *containerManager) StartContainer(
+	machineConfig *cloudinit.MachineConfig,
+	series string,
+	network *container.NetworkConfig) (instance.Instance, error) {
+
+	name := names.MachineTag(machineConfig.MachineId)
+	if manager.name != "" {
+		name = fmt.Sprintf("%s-%s", manager.name, name)
+	}
+	kvmContainer := KvmObjectFactory.New(name)
+
+	// Create the cloud-init.
+	directory, err := container.NewDirectory(name)
+	if err != nil {
+		return nil, err
+	}
+	logger.Tracef("write cloud-init")
+	userDataFilename, err := container.WriteUserData(machineConfig, directory)
+	if err != nil {
+		logger.Errorf("failed to write user data: %v", err)
+		return nil, err
+	}
+
+	startParams := ParseConstraintsToStartParams(machineConfig.Constraints)
+	startParams.Series = series
+	startParams.Arch = machineConfig.Tools.Version.Arch
+	startParams.Network = network
+	startParams.UserDataFile = userDataFilename
+
+	logger.Tracef("create the container, constraints: %v", machineConfig.Constraints)
+	if err := kvmContainer.Start(startParams); err != nil {
+		logger.Errorf("kvm container creation failed: %v", err)
+		return nil, err
+	}
+	logger.Tracef("kvm container created")
+	return &kvmInstance{kvmContainer, name}, nil
+}
+
+func (manager *containerManager) StopContainer(instance instance.Instance) error {
+	name := string(instance.Id())
+	kvmContainer := KvmObjectFactory.New(name)
+	if err := kvmContainer.Stop(); err != nil {
+		logger.Errorf("failed to stop kvm container: %v", err)
+		return err
+	}
+	return container.RemoveDirectory(name)
+}
+
+func (manager *containerManager) ListContainers() (result []instance.Instance, err error) {
+	containers, err := KvmObjectFactory.List()
+	if err != nil {
+		logger.Errorf("failed getting all instances: %v", err)
+		return
+	}
+	managerPrefix := ""
+	if manager.name != "" {
+		managerPrefix = fmt.Sprintf("%s-", manager.name)
+	}
+
+	for _, kvmContainer := range containers {
+		// Filter out those not starting with our name.
+		name := kvmContainer.Name()
+		if !strings.HasPrefix(name, managerPrefix) {
+			continue
+		}
+		if kvmContainer.IsRunning() {
+			result = append(result, &kvmInstance{kvmContainer, name})
+		}
+	}
+	return
+}
+
+// ParseConstraintsToStartParams returns the sizes of the virtual
+// machine that satisfies the hardware constraints. The defaults are
+// used for unspecified constraints, and constraints that do not apply
+// to virtual machines are ignored.
+func ParseConstraintsToStartParams(cons constraints.Value) StartParams {
+	params := StartParams{
+		Memory:   DefaultMemory,
+		CpuCores: DefaultCpuCores,
+		RootDisk: DefaultRootDisk,
+	}
+	if cons.Mem != nil && *cons.Mem > 0 {
+		params.Memory = *cons.Mem
+	}
+	if cons.CpuCores != nil && *cons.CpuCores > 0 {
+		params.CpuCores = *cons.CpuCores
+	}
+	if cons.RootDisk != nil && *cons.RootDisk > 0 {
+		// Disks are sized in whole gigabytes, rounding up.
+		params.RootDisk = (*cons.RootDisk + 1023) / 1024
+	}
+	if cons.CpuPower != nil {
+		logger.Warningf("cpu-power constraint %v ignored by kvm", *cons.CpuPower)
+	}
+	if cons.Tags != nil {
+		logger.Warningf("tags constraint %v ignored by kvm", *cons.Tags)
+	}
+	return params
+}
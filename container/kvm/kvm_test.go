@@ -0,0 +1,183 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/container/kvm"
+	"launchpad.net/juju-core/container/kvm/mock"
+	kvmtesting "launchpad.net/juju-core/container/kvm/testing"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+	instancetest "launchpad.net/juju-core/instance/testing"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type KVMSuite struct {
+	kvmtesting.TestSuite
+}
+
+var _ = gc.Suite(&KVMSuite{})
+
+func (s *KVMSuite) SetUpSuite(c *gc.C) {
+	s.TestSuite.SetUpSuite(c)
+	tmpDir := c.MkDir()
+	restore := testbase.PatchEnvironment("PATH", tmpDir)
+	s.AddSuiteCleanup(func(*gc.C) { restore() })
+	err := ioutil.WriteFile(
+		filepath.Join(tmpDir, "apt-config"),
+		[]byte("#!/bin/sh\n"),
+		0755)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *KVMSuite) SetUpTest(c *gc.C) {
+	s.TestSuite.SetUpTest(c)
+	loggo.GetLogger("juju.container.kvm").SetLogLevel(loggo.TRACE)
+}
+
+func startContainer(c *gc.C, manager container.ContainerManager, machineId string, cons constraints.Value) instance.Instance {
+	stateInfo := jujutesting.FakeStateInfo(machineId)
+	apiInfo := jujutesting.FakeAPIInfo(machineId)
+	machineConfig := environs.NewMachineConfig(machineId, "fake-nonce", stateInfo, apiInfo)
+	machineConfig.Tools = &tools.Tools{
+		Version: version.MustParseBinary("2.3.4-foo-bar"),
+		URL:     "http://tools.testing.invalid/2.3.4-foo-bar.tgz",
+	}
+	machineConfig.Constraints = cons
+
+	network := container.BridgeNetworkConfig("virbr0")
+	inst, err := manager.StartContainer(machineConfig, "precise", network)
+	c.Assert(err, gc.IsNil)
+	return inst
+}
+
+func (s *KVMSuite) TestStartContainer(c *gc.C) {
+	manager := kvm.NewContainerManager(container.ManagerConfig{Name: "juju"})
+	inst := startContainer(c, manager, "1/kvm/0", constraints.Value{})
+	c.Assert(inst.Id(), gc.Equals, instance.Id("juju-machine-1-kvm-0"))
+	c.Assert(inst.Status(), gc.Equals, "running")
+
+	userDataFilename := filepath.Join(s.ContainerDir, "juju-machine-1-kvm-0", "cloud-init")
+	c.Assert(userDataFilename, jc.IsNonEmptyFile)
+
+	mockContainer := s.Factory.New("juju-machine-1-kvm-0").(*mock.MockContainer)
+	c.Assert(mockContainer.StartParams, gc.DeepEquals, kvm.StartParams{
+		Series:       "precise",
+		Arch:         "bar",
+		UserDataFile: userDataFilename,
+		Network:      container.BridgeNetworkConfig("virbr0"),
+		Memory:       kvm.DefaultMemory,
+		CpuCores:     kvm.DefaultCpuCores,
+		RootDisk:     kvm.DefaultRootDisk,
+	})
+}
+
+func (s *KVMSuite) TestStartContainerWithConstraints(c *gc.C) {
+	manager := kvm.NewContainerManager(container.ManagerConfig{})
+	cons := constraints.MustParse("mem=2G cpu-cores=4 root-disk=20G")
+	inst := startContainer(c, manager, "1/kvm/0", cons)
+	mockContainer := s.Factory.New(string(inst.Id())).(*mock.MockContainer)
+	c.Assert(mockContainer.StartParams.Memory, gc.Equals, uint64(2048))
+	c.Assert(mockContainer.StartParams.CpuCores, gc.Equals, uint64(4))
+	c.Assert(mockContainer.StartParams.RootDisk, gc.Equals, uint64(20))
+}
+
+func (s *KVMSuite) TestStopContainer(c *gc.C) {
+	manager := kvm.NewContainerManager(container.ManagerConfig{})
+	inst := startContainer(c, manager, "1/kvm/0", constraints.Value{})
+
+	err := manager.StopContainer(inst)
+	c.Assert(err, gc.IsNil)
+
+	name := string(inst.Id())
+	// The directory is no longer in the container dir...
+	c.Assert(filepath.Join(s.ContainerDir, name), jc.DoesNotExist)
+	// but instead, in the removed container dir.
+	c.Assert(filepath.Join(s.RemovedDir, name), jc.IsDirectory)
+}
+
+func (s *KVMSuite) TestListContainers(c *gc.C) {
+	foo := kvm.NewContainerManager(container.ManagerConfig{Name: "foo"})
+	bar := kvm.NewContainerManager(container.ManagerConfig{Name: "bar"})
+
+	foo1 := startContainer(c, foo, "1/kvm/0", constraints.Value{})
+	foo2 := startContainer(c, foo, "1/kvm/1", constraints.Value{})
+	bar1 := startContainer(c, bar, "1/kvm/2", constraints.Value{})
+
+	result, err := foo.ListContainers()
+	c.Assert(err, gc.IsNil)
+	instancetest.MatchInstances(c, result, foo1, foo2)
+
+	result, err = bar.ListContainers()
+	c.Assert(err, gc.IsNil)
+	instancetest.MatchInstances(c, result, bar1)
+}
+
+type ConstraintsSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&ConstraintsSuite{})
+
+func (*ConstraintsSuite) TestParseConstraintsToStartParams(c *gc.C) {
+	for i, test := range []struct {
+		cons     string
+		memory   uint64
+		cpuCores uint64
+		rootDisk uint64
+	}{{
+		cons:     "",
+		memory:   kvm.DefaultMemory,
+		cpuCores: kvm.DefaultCpuCores,
+		rootDisk: kvm.DefaultRootDisk,
+	}, {
+		cons:     "mem=4G",
+		memory:   4096,
+		cpuCores: kvm.DefaultCpuCores,
+		rootDisk: kvm.DefaultRootDisk,
+	}, {
+		cons:     "cpu-cores=2",
+		memory:   kvm.DefaultMemory,
+		cpuCores: 2,
+		rootDisk: kvm.DefaultRootDisk,
+	}, {
+		cons:     "root-disk=10G",
+		memory:   kvm.DefaultMemory,
+		cpuCores: kvm.DefaultCpuCores,
+		rootDisk: 10,
+	}, {
+		cons:     "root-disk=1500M",
+		memory:   kvm.DefaultMemory,
+		cpuCores: kvm.DefaultCpuCores,
+		rootDisk: 2,
+	}, {
+		cons:     "mem=1G cpu-cores=3 cpu-power=100 root-disk=30G",
+		memory:   1024,
+		cpuCores: 3,
+		rootDisk: 30,
+	}} {
+		c.Logf("test %d: %q", i, test.cons)
+		params := kvm.ParseConstraintsToStartParams(constraints.MustParse(test.cons))
+		c.Check(params.Memory, gc.Equals, test.memory)
+		c.Check(params.CpuCores, gc.Equals, test.cpuCores)
+		c.Check(params.RootDisk, gc.Equals, test.rootDisk)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm
+
+// This file contains wrappers around the uvtool and libvirt command
+// line tools used to manage kvm virtual machines.
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/utils"
+)
+
+// runCommand runs the named command with the given arguments, and
+// returns its combined output. It is patched in tests.
+var runCommand = func(command string, args ...string) (string, error) {
+	out, err := exec.Command(command, args...).CombinedOutput()
+	output := string(out)
+	if err != nil {
+		logger.Debugf("%s %s failed, output:\n%s", command, strings.Join(args, " "), output)
+		return output, fmt.Errorf("%s failed: %v", command, err)
+	}
+	return output, nil
+}
+
+// kvmPackages holds the packages that provide the tools used to
+// manage kvm virtual machines.
+var kvmPackages = []string{"uvtool-libvirt", "uvtool", "libvirt-bin", "qemu-kvm"}
+
+// installPackages and lookPath are patched in tests.
+var (
+	installPackages = utils.AptGetInstall
+	lookPath        = exec.LookPath
+)
+
+// libvirtNetworkActive matches the output of virsh net-info for an
+// active network.
+var libvirtNetworkActive = regexp.MustCompile(`(?m)^Active:\s+yes\s*$`)
+
+var (
+	dependenciesMutex sync.Mutex
+	dependenciesReady bool
+)
+
+// ensureDependencies makes sure the host can run kvm virtual machines
+// the first time one is started: it installs the kvm packages if the
+// uvtool commands are missing, and starts libvirt's default network,
+// which provides the default bridge.
+func ensureDependencies() error {
+	dependenciesMutex.Lock()
+	defer dependenciesMutex.Unlock()
+	if dependenciesReady {
+		return nil
+	}
+	if _, err := lookPath("uvt-kvm"); err != nil {
+		logger.Infof("installing %s", strings.Join(kvmPackages, " "))
+		if err := installPackages(kvmPackages...); err != nil {
+			return fmt.Errorf("cannot install kvm packages: %v", err)
+		}
+	}
+	if _, err := runCommand("virsh", "net-autostart", "default"); err != nil {
+		return err
+	}
+	output, err := runCommand("virsh", "net-info", "default")
+	if err != nil {
+		return err
+	}
+	if !libvirtNetworkActive.MatchString(output) {
+		if _, err := runCommand("virsh", "net-start", "default"); err != nil {
+			return err
+		}
+	}
+	dependenciesReady = true
+	return nil
+}
+
+// syncImages makes sure the cloud image for the series and
+// architecture is available to libvirt.
+func syncImages(series, arch string) error {
+	_, err := runCommand(
+		"uvt-simplestreams-libvirt", "sync",
+		fmt.Sprintf("arch=%s", arch),
+		fmt.Sprintf("release=%s", series),
+	)
+	return err
+}
+
+// createMachine creates and starts the named virtual machine.
+func createMachine(name string, params StartParams) error {
+	bridge := DefaultKvmBridge
+	if params.Network != nil && params.Network.Device != "" {
+		if params.Network.NetworkType != container.BridgeNetwork {
+			logger.Warningf("network type %q not supported by kvm: using bridge", params.Network.NetworkType)
+		}
+		bridge = params.Network.Device
+	}
+	args := []string{
+		"create",
+		"--memory", fmt.Sprint(params.Memory),
+		"--cpu", fmt.Sprint(params.CpuCores),
+		"--disk", fmt.Sprint(params.RootDisk),
+		"--bridge", bridge,
+	}
+	if params.UserDataFile != "" {
+		args = append(args, "--user-data", params.UserDataFile)
+	}
+	args = append(args,
+		name,
+		fmt.Sprintf("release=%s", params.Series),
+		fmt.Sprintf("arch=%s", params.Arch),
+	)
+	_, err := runCommand("uvt-kvm", args...)
+	return err
+}
+
+// destroyMachine stops the named virtual machine and removes its
+// disk images.
+func destroyMachine(name string) error {
+	_, err := runCommand("uvt-kvm", "destroy", name)
+	return err
+}
+
+// listMachines returns the state of every virtual machine known to
+// libvirt, keyed by name.
+func listMachines() (map[string]string, error) {
+	output, err := runCommand("virsh", "-q", "list", "--all")
+	if err != nil {
+		return nil, err
+	}
+	// The output has the form:
+	//  1     juju-machine-1-kvm-0    running
+	//  -     juju-machine-1-kvm-1    shut off
+	result := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		result[fields[1]] = strings.Join(fields[2:], " ")
+	}
+	return result, nil
+}
+
+// kvmContainer is a Container backed by a libvirt virtual machine.
+type kvmContainer struct {
+	name string
+}
+
+var _ Container = (*kvmContainer)(nil)
+
+func (c *kvmContainer) Name() string {
+	return c.name
+}
+
+func (c *kvmContainer) Start(params StartParams) error {
+	if err := ensureDependencies(); err != nil {
+		return err
+	}
+	logger.Debugf("synchronise images for %s %s", params.Series, params.Arch)
+	if err := syncImages(params.Series, params.Arch); err != nil {
+		return err
+	}
+	logger.Debugf("create the machine %s", c.name)
+	return createMachine(c.name, params)
+}
+
+func (c *kvmContainer) Stop() error {
+	return destroyMachine(c.name)
+}
+
+func (c *kvmContainer) IsRunning() bool {
+	machines, err := listMachines()
+	if err != nil {
+		return false
+	}
+	return machines[c.name] == "running"
+}
+
+func (c *kvmContainer) String() string {
+	return fmt.Sprintf("<KVM container %s>", c.name)
+}
+
+// containerFactory is the ContainerFactory that manages libvirt
+// virtual machines.
+type containerFactory struct{}
+
+var _ ContainerFactory = (*containerFactory)(nil)
+
+func (*containerFactory) New(name string) Container {
+	return &kvmContainer{name: name}
+}
+
+func (*containerFactory) List() ([]Container, error) {
+	machines, err := listMachines()
+	if err != nil {
+		return nil, err
+	}
+	var result []Container
+	for name := range machines {
+		result = append(result, &kvmContainer{name: name})
+	}
+	return result, nil
+}
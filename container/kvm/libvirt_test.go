@@ -0,0 +1,143 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package kvm_test
+
+import (
+	"errors"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/container/kvm"
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+type LibVirtSuite struct {
+	testbase.LoggingSuite
+	commands  []string
+	output    string
+	installed [][]string
+}
+
+var _ = gc.Suite(&LibVirtSuite{})
+
+func (s *LibVirtSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	s.commands = nil
+	s.output = ""
+	s.PatchValue(kvm.RunCommand, func(command string, args ...string) (string, error) {
+		s.commands = append(s.commands, command+" "+strings.Join(args, " "))
+		return s.output, nil
+	})
+	s.installed = nil
+	s.PatchValue(kvm.InstallPackages, func(packages ...string) error {
+		s.installed = append(s.installed, packages)
+		return nil
+	})
+	s.PatchValue(kvm.LookPath, func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	})
+	s.PatchValue(kvm.DependenciesReady, false)
+}
+
+func (s *LibVirtSuite) TestStart(c *gc.C) {
+	kvmContainer := kvm.NewContainer(nil, "juju-machine-1-kvm-0")
+	err := kvmContainer.Start(kvm.StartParams{
+		Series:       "trusty",
+		Arch:         "amd64",
+		UserDataFile: "/path/to/cloud-init",
+		Network:      container.BridgeNetworkConfig("br0"),
+		Memory:       1024,
+		CpuCores:     2,
+		RootDisk:     10,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.commands, gc.DeepEquals, []string{
+		"virsh net-autostart default",
+		"virsh net-info default",
+		"virsh net-start default",
+		"uvt-simplestreams-libvirt sync arch=amd64 release=trusty",
+		"uvt-kvm create --memory 1024 --cpu 2 --disk 10 --bridge br0 " +
+			"--user-data /path/to/cloud-init juju-machine-1-kvm-0 release=trusty arch=amd64",
+	})
+}
+
+func (s *LibVirtSuite) TestStartDefaultBridge(c *gc.C) {
+	kvmContainer := kvm.NewContainer(nil, "juju-machine-1-kvm-0")
+	err := kvmContainer.Start(kvm.StartParams{
+		Series:   "trusty",
+		Arch:     "amd64",
+		Memory:   512,
+		CpuCores: 1,
+		RootDisk: 8,
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.commands[len(s.commands)-1], gc.Equals,
+		"uvt-kvm create --memory 512 --cpu 1 --disk 8 --bridge virbr0 "+
+			"juju-machine-1-kvm-0 release=trusty arch=amd64")
+}
+
+func (s *LibVirtSuite) TestStartInstallsPackages(c *gc.C) {
+	s.PatchValue(kvm.LookPath, func(file string) (string, error) {
+		return "", errors.New("not found")
+	})
+	// The default network is already active.
+	s.output = "Name:           default\nActive:         yes\n"
+	params := kvm.StartParams{Series: "trusty", Arch: "amd64"}
+	err := kvm.NewContainer(nil, "juju-machine-1-kvm-0").Start(params)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.installed, gc.DeepEquals, [][]string{
+		{"uvtool-libvirt", "uvtool", "libvirt-bin", "qemu-kvm"},
+	})
+	c.Assert(s.commands[:2], gc.DeepEquals, []string{
+		"virsh net-autostart default",
+		"virsh net-info default",
+	})
+
+	// The host is only prepared once.
+	s.commands = nil
+	err = kvm.NewContainer(nil, "juju-machine-1-kvm-1").Start(params)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.installed, gc.HasLen, 1)
+	c.Assert(s.commands[0], gc.Equals, "uvt-simplestreams-libvirt sync arch=amd64 release=trusty")
+}
+
+func (s *LibVirtSuite) TestStartInstallFailure(c *gc.C) {
+	s.PatchValue(kvm.LookPath, func(file string) (string, error) {
+		return "", errors.New("not found")
+	})
+	s.PatchValue(kvm.InstallPackages, func(packages ...string) error {
+		return errors.New("apt-get failed: exit status 100")
+	})
+	err := kvm.NewContainer(nil, "juju-machine-1-kvm-0").Start(kvm.StartParams{Series: "trusty", Arch: "amd64"})
+	c.Assert(err, gc.ErrorMatches, "cannot install kvm packages: apt-get failed: exit status 100")
+	c.Assert(s.commands, gc.HasLen, 0)
+}
+
+func (s *LibVirtSuite) TestStop(c *gc.C) {
+	kvmContainer := kvm.NewContainer(nil, "juju-machine-1-kvm-0")
+	err := kvmContainer.Stop()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.commands, gc.DeepEquals, []string{"uvt-kvm destroy juju-machine-1-kvm-0"})
+}
+
+func (s *LibVirtSuite) TestListMachines(c *gc.C) {
+	s.output = ` 1     juju-machine-1-kvm-0           running
+ -     juju-machine-1-kvm-1           shut off
+
+`
+	machines, err := kvm.ListMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.DeepEquals, map[string]string{
+		"juju-machine-1-kvm-0": "running",
+		"juju-machine-1-kvm-1": "shut off",
+	})
+	c.Assert(s.commands, gc.DeepEquals, []string{"virsh -q list --all"})
+
+	kvmContainer := kvm.NewContainer(nil, "juju-machine-1-kvm-0")
+	c.Assert(kvmContainer.IsRunning(), gc.Equals, true)
+	kvmContainer = kvm.NewContainer(nil, "juju-machine-1-kvm-1")
+	c.Assert(kvmContainer.IsRunning(), gc.Equals, false)
+}
@@ -0,0 +1,146 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package mock
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/container/kvm"
+)
+
+// This file provides a mock implementation of the kvm interfaces
+// ContainerFactory and Container.
+
+type Action int
+
+const (
+	// A container has been started.
+	Started Action = iota
+	// A container has been stopped.
+	Stopped
+)
+
+func (action Action) String() string {
+	switch action {
+	case Started:
+		return "Started"
+	case Stopped:
+		return "Stopped"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Action     Action
+	InstanceId string
+}
+
+type ContainerFactory interface {
+	kvm.ContainerFactory
+
+	AddListener(chan<- Event)
+	RemoveListener(chan<- Event)
+}
+
+type mockFactory struct {
+	instances map[string]kvm.Container
+	listeners []chan<- Event
+}
+
+func MockFactory() ContainerFactory {
+	return &mockFactory{
+		instances: make(map[string]kvm.Container),
+	}
+}
+
+// MockContainer is a kvm container that records the parameters it
+// was started with.
+type MockContainer struct {
+	StartParams kvm.StartParams
+
+	factory *mockFactory
+	name    string
+	started bool
+}
+
+// Name returns the name of the container.
+func (mock *MockContainer) Name() string {
+	return mock.name
+}
+
+// Start creates and starts the container.
+func (mock *MockContainer) Start(params kvm.StartParams) error {
+	if mock.started {
+		return fmt.Errorf("container is already running")
+	}
+	mock.started = true
+	mock.StartParams = params
+	mock.factory.instances[mock.name] = mock
+	mock.factory.notify(Started, mock.name)
+	return nil
+}
+
+// Stop terminates the running container and destroys it.
+func (mock *MockContainer) Stop() error {
+	if !mock.started {
+		return fmt.Errorf("container is not running")
+	}
+	mock.started = false
+	delete(mock.factory.instances, mock.name)
+	mock.factory.notify(Stopped, mock.name)
+	return nil
+}
+
+// IsRunning returns whether the container is running.
+func (mock *MockContainer) IsRunning() bool {
+	return mock.started
+}
+
+// String returns information about the container.
+func (mock *MockContainer) String() string {
+	return fmt.Sprintf("<MockContainer %q>", mock.name)
+}
+
+func (mock *mockFactory) String() string {
+	return fmt.Sprintf("mock kvm factory")
+}
+
+func (mock *mockFactory) New(name string) kvm.Container {
+	container, ok := mock.instances[name]
+	if ok {
+		return container
+	}
+	return &MockContainer{
+		factory: mock,
+		name:    name,
+	}
+}
+
+func (mock *mockFactory) List() (result []kvm.Container, err error) {
+	for _, container := range mock.instances {
+		result = append(result, container)
+	}
+	return
+}
+
+func (mock *mockFactory) notify(action Action, instanceId string) {
+	event := Event{action, instanceId}
+	for _, c := range mock.listeners {
+		c <- event
+	}
+}
+
+func (mock *mockFactory) AddListener(listener chan<- Event) {
+	mock.listeners = append(mock.listeners, listener)
+}
+
+func (mock *mockFactory) RemoveListener(listener chan<- Event) {
+	pos := 0
+	for i, c := range mock.listeners {
+		if c == listener {
+			pos = i
+		}
+	}
+	mock.listeners = append(mock.listeners[:pos], mock.listeners[pos+1:]...)
+}
@@ -8,83 +8,35 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"launchpad.net/golxc"
 	"launchpad.net/loggo"
 
+	"launchpad.net/juju-core/container"
 	"launchpad.net/juju-core/environs/cloudinit"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/names"
-	"launchpad.net/juju-core/utils"
 )
 
 var logger = loggo.GetLogger("juju.container.lxc")
 
 var (
-	defaultTemplate     = "ubuntu-cloud"
-	containerDir        = "/var/lib/juju/containers"
-	removedContainerDir = "/var/lib/juju/removed-containers"
-	lxcContainerDir     = "/var/lib/lxc"
-	lxcRestartDir       = "/etc/lxc/auto"
-	lxcObjectFactory    = golxc.Factory()
-	aptHTTPProxyRE      = regexp.MustCompile(`(?i)^Acquire::HTTP::Proxy\s+"([^"]+)";$`)
+	defaultTemplate  = "ubuntu-cloud"
+	lxcContainerDir  = "/var/lib/lxc"
+	lxcRestartDir    = "/etc/lxc/auto"
+	lxcObjectFactory = golxc.Factory()
 )
 
 const (
-	// BridgeNetwork will have the container use the lxc bridge.
-	bridgeNetwork = "bridge"
-	// PhyscialNetwork will have the container use a specified network device.
-	physicalNetwork = "physical"
 	// DefaultLxcBridge is the package created container bridge
 	DefaultLxcBridge = "lxcbr0"
 )
 
-// NetworkConfig defines how the container network will be configured.
-type NetworkConfig struct {
-	networkType string
-	device      string
-}
-
 // DefaultNetworkConfig returns a valid NetworkConfig to use the
 // defaultLxcBridge that is created by the lxc package.
-func DefaultNetworkConfig() *NetworkConfig {
-	return &NetworkConfig{bridgeNetwork, DefaultLxcBridge}
-}
-
-// BridgeNetworkConfig returns a valid NetworkConfig to use the specified
-// device as a network bridge for the container.
-func BridgeNetworkConfig(device string) *NetworkConfig {
-	return &NetworkConfig{bridgeNetwork, device}
-}
-
-// PhysicalNetworkConfig returns a valid NetworkConfig to use the specified
-// device as the network device for the container.
-func PhysicalNetworkConfig(device string) *NetworkConfig {
-	return &NetworkConfig{physicalNetwork, device}
-}
-
-// ManagerConfig contains the initialization parameters for the ContainerManager.
-type ManagerConfig struct {
-	Name   string
-	LogDir string
-}
-
-// ContainerManager is responsible for starting containers, and stopping and
-// listing containers that it has started.  The name of the manager is used to
-// namespace the lxc containers on the machine.
-type ContainerManager interface {
-	// StartContainer creates and starts a new lxc container for the specified machine.
-	StartContainer(
-		machineConfig *cloudinit.MachineConfig,
-		series string,
-		network *NetworkConfig) (instance.Instance, error)
-	// StopContainer stops and destroyes the lxc container identified by Instance.
-	StopContainer(instance.Instance) error
-	// ListContainers return a list of containers that have been started by
-	// this manager.
-	ListContainers() ([]instance.Instance, error)
+func DefaultNetworkConfig() *container.NetworkConfig {
+	return container.BridgeNetworkConfig(DefaultLxcBridge)
 }
 
 type containerManager struct {
@@ -92,10 +44,12 @@ type containerManager struct {
 	logdir string
 }
 
+var _ container.ContainerManager = (*containerManager)(nil)
+
 // NewContainerManager returns a manager object that can start and stop lxc
 // containers. The containers that are created are namespaced by the name
 // parameter.
-func NewContainerManager(conf ManagerConfig) ContainerManager {
+func NewContainerManager(conf container.ManagerConfig) container.ContainerManager {
 	logdir := "/var/log/juju"
 	if conf.LogDir != "" {
 		logdir = conf.LogDir
@@ -106,7 +60,7 @@ func NewContainerManager(conf ManagerConfig) ContainerManager {
 func (manager *containerManager) StartContainer(
 	machineConfig *cloudinit.MachineConfig,
 	series string,
-	network *NetworkConfig) (instance.Instance, error) {
+	network *container.NetworkConfig) (instance.Instance, error) {
 
 	name := names.MachineTag(machineConfig.MachineId)
 	if manager.name != "" {
@@ -115,17 +69,15 @@ func (manager *containerManager) StartContainer(
 	// Note here that the lxcObjectFacotry only returns a valid container
 	// object, and doesn't actually construct the underlying lxc container on
 	// disk.
-	container := lxcObjectFactory.New(name)
+	lxcContainer := lxcObjectFactory.New(name)
 
 	// Create the cloud-init.
-	directory := jujuContainerDirectory(name)
-	logger.Tracef("create directory: %s", directory)
-	if err := os.MkdirAll(directory, 0755); err != nil {
-		logger.Errorf("failed to create container directory: %v", err)
+	directory, err := container.NewDirectory(name)
+	if err != nil {
 		return nil, err
 	}
 	logger.Tracef("write cloud-init")
-	userDataFilename, err := writeUserData(machineConfig, directory)
+	userDataFilename, err := container.WriteUserData(machineConfig, directory)
 	if err != nil {
 		logger.Errorf("failed to write user data: %v", err)
 		return nil, err
@@ -144,7 +96,7 @@ func (manager *containerManager) StartContainer(
 	}
 	// Create the container.
 	logger.Tracef("create the container")
-	if err := container.Create(configFile, defaultTemplate, templateParams...); err != nil {
+	if err := lxcContainer.Create(configFile, defaultTemplate, templateParams...); err != nil {
 		logger.Errorf("lxc container creation failed: %v", err)
 		return nil, err
 	}
@@ -165,49 +117,33 @@ func (manager *containerManager) StartContainer(
 	// Start the lxc container with the appropriate settings for grabbing the
 	// console output and a log file.
 	consoleFile := filepath.Join(directory, "console.log")
-	container.SetLogFile(filepath.Join(directory, "container.log"), golxc.LogDebug)
+	lxcContainer.SetLogFile(filepath.Join(directory, "container.log"), golxc.LogDebug)
 	logger.Tracef("start the container")
 	// We explicitly don't pass through the config file to the container.Start
 	// method as we have passed it through at container creation time.  This
 	// is necessary to get the appropriate rootfs reference without explicitly
 	// setting it ourselves.
-	if err = container.Start("", consoleFile); err != nil {
+	if err = lxcContainer.Start("", consoleFile); err != nil {
 		logger.Errorf("container failed to start: %v", err)
 		return nil, err
 	}
 	logger.Tracef("container started")
-	return &lxcInstance{container, name}, nil
+	return &lxcInstance{lxcContainer, name}, nil
 }
 
 func (manager *containerManager) StopContainer(instance instance.Instance) error {
 	name := string(instance.Id())
-	container := lxcObjectFactory.New(name)
+	lxcContainer := lxcObjectFactory.New(name)
 	// Remove the autostart link.
 	if err := os.Remove(restartSymlink(name)); err != nil {
 		logger.Errorf("failed to remove restart symlink: %v", err)
 		return err
 	}
-	if err := container.Destroy(); err != nil {
+	if err := lxcContainer.Destroy(); err != nil {
 		logger.Errorf("failed to destroy lxc container: %v", err)
 		return err
 	}
-
-	// Move the directory.
-	logger.Tracef("create old container dir: %s", removedContainerDir)
-	if err := os.MkdirAll(removedContainerDir, 0755); err != nil {
-		logger.Errorf("failed to create removed container directory: %v", err)
-		return err
-	}
-	removedDir, err := uniqueDirectory(removedContainerDir, name)
-	if err != nil {
-		logger.Errorf("was not able to generate a unique directory: %v", err)
-		return err
-	}
-	if err := os.Rename(jujuContainerDirectory(name), removedDir); err != nil {
-		logger.Errorf("failed to rename container directory: %v", err)
-		return err
-	}
-	return nil
+	return container.RemoveDirectory(name)
 }
 
 func (manager *containerManager) ListContainers() (result []instance.Instance, err error) {
@@ -234,10 +170,6 @@ func (manager *containerManager) ListContainers() (result []instance.Instance, e
 	return
 }
 
-func jujuContainerDirectory(containerName string) string {
-	return filepath.Join(containerDir, containerName)
-}
-
 const internalLogDirTemplate = "%s/%s/rootfs/var/log/juju"
 
 func internalLogDir(containerName string) string {
@@ -262,23 +194,41 @@ func networkConfigTemplate(networkType, networkLink string) string {
 	return fmt.Sprintf(networkTemplate, networkType, networkLink)
 }
 
-func generateNetworkConfig(network *NetworkConfig) string {
+func interfaceConfigTemplate(iface container.NetworkInterface) string {
+	config := networkConfigTemplate("veth", iface.Link)
+	if iface.Name != "" {
+		config += fmt.Sprintf("lxc.network.name = %s\n", iface.Name)
+	}
+	if iface.MACAddress != "" {
+		config += fmt.Sprintf("lxc.network.hwaddr = %s\n", iface.MACAddress)
+	}
+	return config
+}
+
+func generateNetworkConfig(network *container.NetworkConfig) string {
 	if network == nil {
 		logger.Warningf("network unspecified, using default networking config")
 		network = DefaultNetworkConfig()
 	}
-	switch network.networkType {
-	case physicalNetwork:
-		return networkConfigTemplate("phys", network.device)
+	if len(network.Interfaces) > 0 {
+		var config string
+		for _, iface := range network.Interfaces {
+			config += interfaceConfigTemplate(iface)
+		}
+		return config
+	}
+	switch network.NetworkType {
+	case container.PhysicalNetwork:
+		return networkConfigTemplate("phys", network.Device)
 	default:
-		logger.Warningf("Unknown network config type %q: using bridge", network.networkType)
+		logger.Warningf("Unknown network config type %q: using bridge", network.NetworkType)
 		fallthrough
-	case bridgeNetwork:
-		return networkConfigTemplate("veth", network.device)
+	case container.BridgeNetwork:
+		return networkConfigTemplate("veth", network.Device)
 	}
 }
 
-func writeLxcConfig(network *NetworkConfig, directory, logdir string) (string, error) {
+func writeLxcConfig(network *container.NetworkConfig, directory, logdir string) (string, error) {
 	networkConfig := generateNetworkConfig(network)
 	configFilename := filepath.Join(directory, "lxc.conf")
 	configContent := fmt.Sprintf(localConfig, networkConfig, logdir)
@@ -287,81 +237,3 @@ func writeLxcConfig(network *NetworkConfig, directory, logdir string) (string, e
 	}
 	return configFilename, nil
 }
-
-func writeUserData(machineConfig *cloudinit.MachineConfig, directory string) (string, error) {
-	userData, err := cloudInitUserData(machineConfig)
-	if err != nil {
-		logger.Errorf("failed to create user data: %v", err)
-		return "", err
-	}
-	userDataFilename := filepath.Join(directory, "cloud-init")
-	if err := ioutil.WriteFile(userDataFilename, userData, 0644); err != nil {
-		logger.Errorf("failed to write user data: %v", err)
-		return "", err
-	}
-	return userDataFilename, nil
-}
-
-func cloudInitUserData(machineConfig *cloudinit.MachineConfig) ([]byte, error) {
-	machineConfig.DataDir = "/var/lib/juju"
-	cloudConfig, err := cloudinit.New(machineConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	// Run apt-config to fetch proxy settings from host. If no proxy
-	// settings are configured, then we don't set up any proxy information
-	// on the container.
-	proxyConfig, err := utils.AptConfigProxy()
-	if err != nil {
-		return nil, err
-	}
-	if proxyConfig != "" {
-		var proxyLines []string
-		for _, line := range strings.Split(proxyConfig, "\n") {
-			line = strings.TrimSpace(line)
-			if len(line) > 0 {
-				if m := aptHTTPProxyRE.FindStringSubmatch(line); m != nil {
-					cloudConfig.SetAptProxy(m[1])
-				} else {
-					proxyLines = append(proxyLines, line)
-				}
-			}
-		}
-		if len(proxyLines) > 0 {
-			cloudConfig.AddFile(
-				"/etc/apt/apt.conf.d/99proxy-extra",
-				strings.Join(proxyLines, "\n"),
-				0644)
-		}
-	}
-
-	// Run ifconfig to get the addresses of the internal container at least
-	// logged in the host.
-	cloudConfig.AddRunCmd("ifconfig")
-
-	data, err := cloudConfig.Render()
-	if err != nil {
-		return nil, err
-	}
-	return data, nil
-}
-
-// uniqueDirectory returns "path/name" if that directory doesn't exist.  If it
-// does, the method starts appending .1, .2, etc until a unique name is found.
-func uniqueDirectory(path, name string) (string, error) {
-	dir := filepath.Join(path, name)
-	_, err := os.Stat(dir)
-	if os.IsNotExist(err) {
-		return dir, nil
-	}
-	for i := 1; ; i++ {
-		dir := filepath.Join(path, fmt.Sprintf("%s.%d", name, i))
-		_, err := os.Stat(dir)
-		if os.IsNotExist(err) {
-			return dir, nil
-		} else if err != nil {
-			return "", err
-		}
-	}
-}
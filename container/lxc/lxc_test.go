@@ -15,6 +15,7 @@ import (
 	"launchpad.net/goyaml"
 	"launchpad.net/loggo"
 
+	"launchpad.net/juju-core/container"
 	"launchpad.net/juju-core/container/lxc"
 	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/instance"
@@ -64,7 +65,7 @@ var (
 	aptConfigScript = fmt.Sprintf("#!/bin/sh\n echo '%s\n%s'", configHttpProxy, configProxyExtra)
 )
 
-func StartContainer(c *gc.C, manager lxc.ContainerManager, machineId string) instance.Instance {
+func StartContainer(c *gc.C, manager container.ContainerManager, machineId string) instance.Instance {
 	stateInfo := jujutesting.FakeStateInfo(machineId)
 	apiInfo := jujutesting.FakeAPIInfo(machineId)
 	machineConfig := environs.NewMachineConfig(machineId, "fake-nonce", stateInfo, apiInfo)
@@ -74,14 +75,14 @@ func StartContainer(c *gc.C, manager lxc.ContainerManager, machineId string) ins
 	}
 
 	series := "series"
-	network := lxc.BridgeNetworkConfig("nic42")
+	network := container.BridgeNetworkConfig("nic42")
 	inst, err := manager.StartContainer(machineConfig, series, network)
 	c.Assert(err, gc.IsNil)
 	return inst
 }
 
 func (s *LxcSuite) TestStartContainer(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 
 	name := string(instance.Id())
@@ -129,7 +130,7 @@ func (s *LxcSuite) TestStartContainer(c *gc.C) {
 }
 
 func (s *LxcSuite) TestContainerState(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 
 	// The mock container will be immediately "running".
@@ -143,7 +144,7 @@ func (s *LxcSuite) TestContainerState(c *gc.C) {
 }
 
 func (s *LxcSuite) TestStopContainer(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 
 	err := manager.StopContainer(instance)
@@ -157,7 +158,7 @@ func (s *LxcSuite) TestStopContainer(c *gc.C) {
 }
 
 func (s *LxcSuite) TestStopContainerNameClash(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 
 	name := string(instance.Id())
@@ -175,14 +176,14 @@ func (s *LxcSuite) TestStopContainerNameClash(c *gc.C) {
 }
 
 func (s *LxcSuite) TestNamedManagerPrefix(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{Name: "eric"})
+	manager := lxc.NewContainerManager(container.ManagerConfig{Name: "eric"})
 	instance := StartContainer(c, manager, "1/lxc/0")
 	c.Assert(string(instance.Id()), gc.Equals, "eric-machine-1-lxc-0")
 }
 
 func (s *LxcSuite) TestListContainers(c *gc.C) {
-	foo := lxc.NewContainerManager(lxc.ManagerConfig{Name: "foo"})
-	bar := lxc.NewContainerManager(lxc.ManagerConfig{Name: "bar"})
+	foo := lxc.NewContainerManager(container.ManagerConfig{Name: "foo"})
+	bar := lxc.NewContainerManager(container.ManagerConfig{Name: "bar"})
 
 	foo1 := StartContainer(c, foo, "1/lxc/0")
 	foo2 := StartContainer(c, foo, "1/lxc/1")
@@ -201,14 +202,14 @@ func (s *LxcSuite) TestListContainers(c *gc.C) {
 }
 
 func (s *LxcSuite) TestStartContainerAutostarts(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 	autostartLink := lxc.RestartSymlink(string(instance.Id()))
 	c.Assert(autostartLink, jc.IsSymlink)
 }
 
 func (s *LxcSuite) TestStopContainerRemovesAutostartLink(c *gc.C) {
-	manager := lxc.NewContainerManager(lxc.ManagerConfig{})
+	manager := lxc.NewContainerManager(container.ManagerConfig{})
 	instance := StartContainer(c, manager, "1/lxc/0")
 	err := manager.StopContainer(instance)
 	c.Assert(err, gc.IsNil)
@@ -224,7 +225,7 @@ var _ = gc.Suite(&NetworkSuite{})
 
 func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 	for _, test := range []struct {
-		config *lxc.NetworkConfig
+		config *container.NetworkConfig
 		net    string
 		link   string
 	}{{
@@ -236,11 +237,11 @@ func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 		net:    "veth",
 		link:   "lxcbr0",
 	}, {
-		config: lxc.BridgeNetworkConfig("foo"),
+		config: container.BridgeNetworkConfig("foo"),
 		net:    "veth",
 		link:   "foo",
 	}, {
-		config: lxc.PhysicalNetworkConfig("foo"),
+		config: container.PhysicalNetworkConfig("foo"),
 		net:    "phys",
 		link:   "foo",
 	}} {
@@ -250,6 +251,28 @@ func (*NetworkSuite) TestGenerateNetworkConfig(c *gc.C) {
 	}
 }
 
+func (*NetworkSuite) TestGenerateMultiBridgeNetworkConfig(c *gc.C) {
+	config := lxc.GenerateNetworkConfig(container.MultiBridgeNetworkConfig([]container.NetworkInterface{{
+		Link:       "br0",
+		Name:       "eth0",
+		MACAddress: "00:16:3e:00:00:01",
+	}, {
+		Link: "br-storage",
+	}}))
+	expected := `
+lxc.network.type = veth
+lxc.network.link = br0
+lxc.network.flags = up
+lxc.network.name = eth0
+lxc.network.hwaddr = 00:16:3e:00:00:01
+
+lxc.network.type = veth
+lxc.network.link = br-storage
+lxc.network.flags = up
+`
+	c.Assert(config, gc.Equals, expected)
+}
+
 func (*NetworkSuite) TestNetworkConfigTemplate(c *gc.C) {
 	config := lxc.NetworkConfigTemplate("foo", "bar")
 	expected := `
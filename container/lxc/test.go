@@ -10,6 +10,7 @@ package lxc
 import (
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/container"
 	"launchpad.net/juju-core/container/lxc/mock"
 	"launchpad.net/juju-core/testing/testbase"
 )
@@ -28,9 +29,9 @@ type TestSuite struct {
 func (s *TestSuite) SetUpTest(c *gc.C) {
 	s.LoggingSuite.SetUpTest(c)
 	s.ContainerDir = c.MkDir()
-	s.PatchValue(&containerDir, s.ContainerDir)
+	s.PatchValue(&container.ContainerDir, s.ContainerDir)
 	s.RemovedDir = c.MkDir()
-	s.PatchValue(&removedContainerDir, s.RemovedDir)
+	s.PatchValue(&container.RemovedContainerDir, s.RemovedDir)
 	s.LxcDir = c.MkDir()
 	s.PatchValue(&lxcContainerDir, s.LxcDir)
 	s.RestartDir = c.MkDir()
@@ -0,0 +1,52 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package container
+
+const (
+	// BridgeNetwork will have the container use the network bridge.
+	BridgeNetwork = "bridge"
+	// PhyscialNetwork will have the container use a specified network device.
+	PhysicalNetwork = "physical"
+)
+
+// NetworkConfig defines how the container network will be configured.
+type NetworkConfig struct {
+	NetworkType string
+	Device      string
+	Interfaces  []NetworkInterface
+}
+
+// NetworkInterface describes a single network interface of a
+// container, connected to a host bridge.
+type NetworkInterface struct {
+	// Link is the host bridge the interface is connected to.
+	Link string
+
+	// Name is the name of the interface inside the container
+	// (e.g. "eth1"). If empty, the container chooses the name.
+	Name string
+
+	// MACAddress is the hardware address of the interface. If empty,
+	// a random address is generated.
+	MACAddress string
+}
+
+// BridgeNetworkConfig returns a valid NetworkConfig to use the specified
+// device as a network bridge for the container.
+func BridgeNetworkConfig(device string) *NetworkConfig {
+	return &NetworkConfig{NetworkType: BridgeNetwork, Device: device}
+}
+
+// PhysicalNetworkConfig returns a valid NetworkConfig to use the specified
+// device as the network device for the container.
+func PhysicalNetworkConfig(device string) *NetworkConfig {
+	return &NetworkConfig{NetworkType: PhysicalNetwork, Device: device}
+}
+
+// MultiBridgeNetworkConfig returns a valid NetworkConfig that gives
+// the container one network interface for each of the given
+// interfaces, each connected to its own host bridge.
+func MultiBridgeNetworkConfig(interfaces []NetworkInterface) *NetworkConfig {
+	return &NetworkConfig{NetworkType: BridgeNetwork, Interfaces: interfaces}
+}
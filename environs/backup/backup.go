@@ -0,0 +1,293 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The backup package knows how to capture the state of a juju environment
+// into a single archive, and how to rehydrate a fresh state server from
+// such an archive.
+//
+// A backup archive is a gzipped tarball with the following layout:
+//
+//	juju-backup/manifest.yaml  describes the archive
+//	juju-backup/agent.conf     the state server's agent configuration
+//	juju-backup/server.pem     the state server's certificate and key
+//	juju-backup/dump/          a mongodump of the state database
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/cert"
+	"launchpad.net/juju-core/utils"
+	"launchpad.net/juju-core/version"
+)
+
+// FormatVersion is the version of the archive layout written by this
+// package. Archives with a different format version are rejected.
+const FormatVersion = 1
+
+// The names of the entries in a backup archive.
+const (
+	archiveRoot  = "juju-backup"
+	manifestFile = "manifest.yaml"
+	agentConf    = "agent.conf"
+	serverPEM    = "server.pem"
+	dumpDir      = "dump"
+)
+
+// The names of the state server's upstart services.
+const (
+	dbService      = "juju-db"
+	machineService = "jujud-machine-0"
+)
+
+// Manifest describes the contents of a backup archive.
+type Manifest struct {
+	FormatVersion int       `yaml:"format-version"`
+	Environment   string    `yaml:"environment"`
+	JujuVersion   string    `yaml:"juju-version"`
+	Created       time.Time `yaml:"-"`
+	CreatedString string    `yaml:"created"`
+	Contents      []string  `yaml:"contents"`
+}
+
+// NewManifest returns a manifest describing a backup of the named
+// environment taken now by the running version of juju.
+func NewManifest(envName string) *Manifest {
+	return &Manifest{
+		FormatVersion: FormatVersion,
+		Environment:   envName,
+		JujuVersion:   version.Current.Number.String(),
+		Created:       time.Now().UTC(),
+		Contents:      []string{agentConf, serverPEM, dumpDir},
+	}
+}
+
+func (m *Manifest) marshal() ([]byte, error) {
+	m.CreatedString = m.Created.Format(time.RFC3339)
+	return goyaml.Marshal(m)
+}
+
+func readManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := goyaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	if m.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", m.FormatVersion)
+	}
+	var err error
+	if m.Created, err = time.Parse(time.RFC3339, m.CreatedString); err != nil {
+		return nil, fmt.Errorf("invalid manifest: bad creation time %q", m.CreatedString)
+	}
+	return &m, nil
+}
+
+// BackupScript returns a shell script that, when run as root on the state
+// server whose juju data lives in dataDir, writes a backup archive with
+// the supplied manifest to its standard output. The state server is
+// stopped while its database is dumped, and restarted afterwards; all
+// other output is written to standard error.
+func BackupScript(dataDir string, m *Manifest) (string, error) {
+	manifest, err := m.marshal()
+	if err != nil {
+		return "", err
+	}
+	root := `"$tmpdir"/` + archiveRoot
+	return strings.Join([]string{
+		`set -e`,
+		`tmpdir=$(mktemp -d)`,
+		`trap 'start ` + dbService + ` >&2 || true; start ` + machineService + ` >&2 || true; rm -rf "$tmpdir"' EXIT`,
+		`mkdir -p ` + root,
+		fmt.Sprintf("cat > %s/%s << 'EOF'\n%sEOF", root, manifestFile, manifest),
+		fmt.Sprintf(`cp %s %s/%s`, utils.ShQuote(path.Join(dataDir, "agents", "machine-0", agentConf)), root, agentConf),
+		fmt.Sprintf(`cp %s %s/%s`, utils.ShQuote(path.Join(dataDir, serverPEM)), root, serverPEM),
+		`stop ` + machineService + ` >&2 || true`,
+		`stop ` + dbService + ` >&2 || true`,
+		fmt.Sprintf(`mongodump --dbpath %s --out %s/%s >&2`, utils.ShQuote(path.Join(dataDir, "db")), root, dumpDir),
+		`tar -C "$tmpdir" -czf - ` + archiveRoot,
+	}, "\n") + "\n", nil
+}
+
+// Archive holds the contents of a backup archive, other than the database
+// dump, which is only ever needed on the state server being restored.
+type Archive struct {
+	Manifest *Manifest
+
+	// AgentConf holds the state server's agent configuration file.
+	AgentConf []byte
+
+	// ServerPEM holds the state server's certificate and private key.
+	ServerPEM []byte
+
+	// Agent holds the parts of AgentConf needed to restore the
+	// state server.
+	Agent AgentInfo
+}
+
+// AgentInfo holds the parts of an agent configuration used when
+// restoring a state server.
+type AgentInfo struct {
+	Tag            string
+	CACert         []byte
+	StatePassword  string
+	StateAddresses []string
+	APIAddresses   []string
+}
+
+// agentConfFormat mirrors the fields of the agent configuration file
+// that are needed here.
+type agentConfFormat struct {
+	Tag            string
+	CACert         string
+	StatePassword  string
+	StateAddresses []string
+	APIAddresses   []string
+}
+
+// ReadArchive reads a backup archive from r and checks that it is
+// complete and consistent.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read backup archive: %v", err)
+	}
+	defer gzr.Close()
+	files := make(map[string][]byte)
+	hasDump := false
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read backup archive: %v", err)
+		}
+		name := path.Clean(hdr.Name)
+		switch name {
+		case path.Join(archiveRoot, manifestFile), path.Join(archiveRoot, agentConf), path.Join(archiveRoot, serverPEM):
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("cannot read %q from backup archive: %v", name, err)
+			}
+			files[path.Base(name)] = data
+		default:
+			if strings.HasPrefix(name, path.Join(archiveRoot, dumpDir)+"/") {
+				hasDump = true
+			}
+		}
+	}
+	for _, name := range []string{manifestFile, agentConf, serverPEM} {
+		if files[name] == nil {
+			return nil, fmt.Errorf("backup archive has no %s", name)
+		}
+	}
+	if !hasDump {
+		return nil, fmt.Errorf("backup archive has no database dump")
+	}
+	m, err := readManifest(files[manifestFile])
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		Manifest:  m,
+		AgentConf: files[agentConf],
+		ServerPEM: files[serverPEM],
+	}
+	if err := a.readAgentInfo(); err != nil {
+		return nil, err
+	}
+	if err := a.checkServerPEM(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *Archive) readAgentInfo() error {
+	var format agentConfFormat
+	if err := goyaml.Unmarshal(a.AgentConf, &format); err != nil {
+		return fmt.Errorf("invalid agent configuration: %v", err)
+	}
+	if format.Tag != "machine-0" {
+		return fmt.Errorf("agent configuration is for %q, not the state server", format.Tag)
+	}
+	caCert, err := base64.StdEncoding.DecodeString(format.CACert)
+	if err != nil {
+		return fmt.Errorf("invalid agent configuration: bad CA certificate: %v", err)
+	}
+	a.Agent = AgentInfo{
+		Tag:            format.Tag,
+		CACert:         caCert,
+		StatePassword:  format.StatePassword,
+		StateAddresses: format.StateAddresses,
+		APIAddresses:   format.APIAddresses,
+	}
+	return nil
+}
+
+// checkServerPEM verifies that the state server certificate is intact
+// and was issued by the environment's certificate authority.
+func (a *Archive) checkServerPEM() error {
+	if _, _, err := cert.ParseCertAndKey(a.ServerPEM, a.ServerPEM); err != nil {
+		return fmt.Errorf("invalid state server certificate: %v", err)
+	}
+	if err := cert.Verify(a.ServerPEM, a.Agent.CACert, time.Now()); err != nil {
+		return fmt.Errorf("invalid state server certificate: %v", err)
+	}
+	return nil
+}
+
+// WriteArchive writes a backup archive holding the supplied manifest and
+// files to w. The dump map holds the contents of the database dump, keyed
+// by path relative to the dump directory. It is intended for testing, and
+// for tools that assemble archives themselves.
+func WriteArchive(w io.Writer, m *Manifest, agentConfData, serverPEMData []byte, dump map[string][]byte) error {
+	manifest, err := m.marshal()
+	if err != nil {
+		return err
+	}
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    path.Join(archiveRoot, name),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: m.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, bytes.NewReader(data))
+		return err
+	}
+	if err := add(manifestFile, manifest); err != nil {
+		return err
+	}
+	if err := add(agentConf, agentConfData); err != nil {
+		return err
+	}
+	if err := add(serverPEM, serverPEMData); err != nil {
+		return err
+	}
+	for name, data := range dump {
+		if err := add(path.Join(dumpDir, name), data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
@@ -0,0 +1,169 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package backup_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/environs/backup"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/version"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type backupSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&backupSuite{})
+
+func agentConf(tag string) []byte {
+	return []byte(fmt.Sprintf(`tag: %s
+nonce: user-admin:bootstrap
+cacert: %s
+stateaddresses:
+- localhost:37017
+statepassword: sekrit
+apiaddresses:
+- localhost:17070
+apipassword: sekrit
+oldpassword: ""
+values: {}
+`, tag, base64.StdEncoding.EncodeToString([]byte(coretesting.CACert))))
+}
+
+var serverPEM = []byte(coretesting.ServerCert + coretesting.ServerKey)
+
+var dump = map[string][]byte{"juju/machines.bson": []byte("machines")}
+
+func writeArchive(c *gc.C, m *backup.Manifest, agentConf, serverPEM []byte, dump map[string][]byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	err := backup.WriteArchive(&buf, m, agentConf, serverPEM, dump)
+	c.Assert(err, gc.IsNil)
+	return &buf
+}
+
+func (s *backupSuite) TestReadArchive(c *gc.C) {
+	m := backup.NewManifest("erewhon")
+	buf := writeArchive(c, m, agentConf("machine-0"), serverPEM, dump)
+
+	archive, err := backup.ReadArchive(buf)
+	c.Assert(err, gc.IsNil)
+	c.Assert(archive.Manifest.FormatVersion, gc.Equals, backup.FormatVersion)
+	c.Assert(archive.Manifest.Environment, gc.Equals, "erewhon")
+	c.Assert(archive.Manifest.JujuVersion, gc.Equals, version.Current.Number.String())
+	c.Assert(archive.Manifest.Created.Equal(m.Created.Truncate(time.Second)), gc.Equals, true)
+	c.Assert(archive.Manifest.Contents, gc.DeepEquals, []string{"agent.conf", "server.pem", "dump"})
+	c.Assert(archive.AgentConf, gc.DeepEquals, agentConf("machine-0"))
+	c.Assert(archive.ServerPEM, gc.DeepEquals, serverPEM)
+	c.Assert(archive.Agent, gc.DeepEquals, backup.AgentInfo{
+		Tag:            "machine-0",
+		CACert:         []byte(coretesting.CACert),
+		StatePassword:  "sekrit",
+		StateAddresses: []string{"localhost:37017"},
+		APIAddresses:   []string{"localhost:17070"},
+	})
+}
+
+func (s *backupSuite) TestReadArchiveErrors(c *gc.C) {
+	m := backup.NewManifest("erewhon")
+	for i, t := range []struct {
+		about     string
+		agentConf []byte
+		serverPEM []byte
+		dump      map[string][]byte
+		err       string
+	}{{
+		about:     "missing dump",
+		agentConf: agentConf("machine-0"),
+		serverPEM: serverPEM,
+		err:       "backup archive has no database dump",
+	}, {
+		about:     "wrong agent",
+		agentConf: agentConf("machine-1"),
+		serverPEM: serverPEM,
+		dump:      dump,
+		err:       `agent configuration is for "machine-1", not the state server`,
+	}, {
+		about:     "bad certificate",
+		agentConf: agentConf("machine-0"),
+		serverPEM: []byte(coretesting.ServerCert),
+		dump:      dump,
+		err:       "invalid state server certificate: .*",
+	}} {
+		c.Logf("test %d: %s", i, t.about)
+		buf := writeArchive(c, m, t.agentConf, t.serverPEM, t.dump)
+		_, err := backup.ReadArchive(buf)
+		c.Check(err, gc.ErrorMatches, t.err)
+	}
+}
+
+func (s *backupSuite) TestReadArchiveNotGzipped(c *gc.C) {
+	_, err := backup.ReadArchive(bytes.NewBufferString("not an archive"))
+	c.Assert(err, gc.ErrorMatches, "cannot read backup archive: .*")
+}
+
+func (s *backupSuite) TestReadArchiveBadFormatVersion(c *gc.C) {
+	m := backup.NewManifest("erewhon")
+	m.FormatVersion = 99
+	buf := writeArchive(c, m, agentConf("machine-0"), serverPEM, dump)
+	_, err := backup.ReadArchive(buf)
+	c.Assert(err, gc.ErrorMatches, "unsupported backup format version 99")
+}
+
+func (s *backupSuite) TestBackupScript(c *gc.C) {
+	script, err := backup.BackupScript("/var/lib/juju", backup.NewManifest("erewhon"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(script, gc.Matches, `(?s)set -e\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*environment: erewhon\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*cp '/var/lib/juju/agents/machine-0/agent.conf' .*`)
+	c.Assert(script, gc.Matches, `(?s).*cp '/var/lib/juju/server.pem' .*`)
+	c.Assert(script, gc.Matches, `(?s).*stop jujud-machine-0 .*stop juju-db .*mongodump --dbpath '/var/lib/juju/db' .*`)
+	c.Assert(script, gc.Matches, `(?s).*tar -C "\$tmpdir" -czf - juju-backup\n$`)
+}
+
+func (s *backupSuite) TestRestoreScript(c *gc.C) {
+	script := backup.RestoreScript(backup.RestoreParams{
+		DataDir:     "/var/lib/juju",
+		ArchivePath: "/home/ubuntu/juju-backup.tgz",
+		Agent: backup.AgentInfo{
+			Tag:           "machine-0",
+			StatePassword: "sekrit",
+		},
+		InstanceId:     "i-new",
+		StatePort:      37017,
+		StateAddresses: []string{"10.0.0.1:37017"},
+		APIAddresses:   []string{"10.0.0.1:17070"},
+	})
+	c.Assert(script, gc.Matches, `(?s)set -e\nstop jujud-machine-0 .*stop juju-db .*`)
+	c.Assert(script, gc.Matches, `(?s).*tar -C "\$tmpdir" -xzf '/home/ubuntu/juju-backup.tgz'\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*mongorestore --drop --dbpath '/var/lib/juju/db' .*`)
+	c.Assert(script, gc.Matches, `(?s).*-v state='- 10.0.0.1:37017' -v api='- 10.0.0.1:17070' .*`)
+	c.Assert(script, gc.Matches, `(?s).*install -m 600 /dev/null "\$tmpdir"/auth.js\ncat >> "\$tmpdir"/auth.js <<'EOF'\n.*auth\("machine-0", "sekrit"\).*\nEOF\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*cat > "\$tmpdir"/update.js <<'EOF'\n.*instanceid: "i-new".*\nEOF\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*mongo --ssl localhost:37017/admin "\$tmpdir"/auth.js "\$tmpdir"/update.js\n.*`)
+	// The password must not be given to mongo on its command line.
+	c.Assert(script, gc.Not(gc.Matches), `(?s).*mongo [^\n]*sekrit.*`)
+	c.Assert(script, gc.Matches, `(?s).*start jujud-machine-0\n$`)
+}
+
+func (s *backupSuite) TestUpdateAgentsScript(c *gc.C) {
+	script := backup.UpdateAgentsScript("/var/lib/juju",
+		[]string{"10.0.0.1:37017", "10.0.0.2:37017"},
+		[]string{"10.0.0.1:17070"},
+	)
+	c.Assert(script, gc.Matches, `(?s)set -e\nfor agentdir in '/var/lib/juju/agents'/\*; do\n.*`)
+	c.Assert(script, gc.Matches, `(?s).*-v state='- 10.0.0.1:37017\\n- 10.0.0.2:37017' -v api='- 10.0.0.1:17070' .*`)
+	c.Assert(script, gc.Matches, `(?s).*restart "\$service" \|\| start "\$service"\ndone\n$`)
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package backup
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/utils"
+)
+
+// RestoreParams holds the information needed to rehydrate a freshly
+// bootstrapped state server from a backup archive.
+type RestoreParams struct {
+	// DataDir is the directory holding the state server's juju data.
+	DataDir string
+
+	// ArchivePath is the location of the backup archive on the state
+	// server.
+	ArchivePath string
+
+	// Agent holds the agent information read from the archive.
+	Agent AgentInfo
+
+	// InstanceId is the id of the new state server instance.
+	InstanceId instance.Id
+
+	// StatePort is the port the state database listens on.
+	StatePort int
+
+	// StateAddresses and APIAddresses hold the addresses that agents
+	// should use to connect to the new state server.
+	StateAddresses []string
+	APIAddresses   []string
+}
+
+// RestoreScript returns a shell script that, when run as root on a freshly
+// bootstrapped state server, replaces its state with that held in the
+// backup archive described by p. The restored database is updated to
+// refer to the new state server instance, so that it is not mistaken for
+// an unknown instance and shut down.
+func RestoreScript(p RestoreParams) string {
+	root := `"$tmpdir"/` + archiveRoot
+	agentDir := path.Join(p.DataDir, "agents", "machine-0")
+	update := fmt.Sprintf(
+		`db = db.getSiblingDB("juju"); `+
+			`db.machines.update({_id: "0"}, {$set: {instanceid: %q, addresses: []}}); `+
+			`db.instanceData.update({_id: "0"}, {$set: {instanceid: %q}})`,
+		p.InstanceId, p.InstanceId,
+	)
+	// The state password is written to a file readable only by root,
+	// rather than passed to mongo as an argument, so that it cannot be
+	// seen in the process list.
+	auth := fmt.Sprintf(
+		`if (!db.getSiblingDB("admin").auth(%q, %q)) { quit(1) }`,
+		p.Agent.Tag, p.Agent.StatePassword,
+	)
+	mongo := fmt.Sprintf(`mongo --ssl localhost:%d/admin "$tmpdir"/auth.js`, p.StatePort)
+	return strings.Join([]string{
+		`set -e`,
+		`stop ` + machineService + ` || true`,
+		`stop ` + dbService + ` || true`,
+		`tmpdir=$(mktemp -d)`,
+		`trap 'rm -rf "$tmpdir"' EXIT`,
+		`install -m 600 /dev/null "$tmpdir"/auth.js`,
+		`cat >> "$tmpdir"/auth.js <<'EOF'`,
+		auth,
+		`EOF`,
+		`cat > "$tmpdir"/update.js <<'EOF'`,
+		update,
+		`EOF`,
+		`tar -C "$tmpdir" -xzf ` + utils.ShQuote(p.ArchivePath),
+		fmt.Sprintf(`install -m 600 %s/%s %s`, root, agentConf, utils.ShQuote(path.Join(agentDir, agentConf))),
+		fmt.Sprintf(`install -m 600 %s/%s %s`, root, serverPEM, utils.ShQuote(path.Join(p.DataDir, serverPEM))),
+		fmt.Sprintf(`mongorestore --drop --dbpath %s %s/%s`, utils.ShQuote(path.Join(p.DataDir, "db")), root, dumpDir),
+		agentAddressesScript(utils.ShQuote(path.Join(agentDir, agentConf)), p.StateAddresses, p.APIAddresses),
+		`start ` + dbService,
+		fmt.Sprintf(`for i in $(seq 1 60); do %s > /dev/null && break; sleep 1; done`, mongo),
+		mongo + ` "$tmpdir"/update.js`,
+		`start ` + machineService,
+	}, "\n") + "\n"
+}
+
+// UpdateAgentsScript returns a shell script that, when run as root on a
+// machine whose juju data lives in dataDir, points every agent on that
+// machine at the supplied state server addresses and restarts them.
+func UpdateAgentsScript(dataDir string, stateAddrs, apiAddrs []string) string {
+	return strings.Join([]string{
+		`set -e`,
+		fmt.Sprintf(`for agentdir in %s/*; do`, utils.ShQuote(path.Join(dataDir, "agents"))),
+		agentAddressesScript(`"$agentdir"/`+agentConf, stateAddrs, apiAddrs),
+		`done`,
+		`for conf in /etc/init/jujud-*.conf; do`,
+		`service=$(basename "$conf" .conf)`,
+		`restart "$service" || start "$service"`,
+		`done`,
+	}, "\n") + "\n"
+}
+
+// agentAddressesScript returns a shell script fragment that replaces the
+// state and API addresses in the agent configuration file conf, which
+// must already be quoted for the shell.
+func agentAddressesScript(conf string, stateAddrs, apiAddrs []string) string {
+	return fmt.Sprintf(
+		`awk -v state=%s -v api=%s '`+
+			`/^stateaddresses:/ {print; print state; skip=1; next} `+
+			`/^apiaddresses:/ {print; print api; skip=1; next} `+
+			`skip && /^- / {next} `+
+			`{skip=0; print}' %s > %s.new && mv %s.new %s`,
+		utils.ShQuote(yamlList(stateAddrs)), utils.ShQuote(yamlList(apiAddrs)),
+		conf, conf, conf, conf,
+	)
+}
+
+// yamlList formats addrs as the items of a yaml list, separated by
+// escaped newlines as understood by awk.
+func yamlList(addrs []string) string {
+	items := make([]string, len(addrs))
+	for i, addr := range addrs {
+		items[i] = "- " + addr
+	}
+	return strings.Join(items, `\n`)
+}
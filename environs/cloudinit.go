@@ -47,6 +47,7 @@ func NewBootstrapMachineConfig(stateInfoURL string) *cloudinit.MachineConfig {
 	// state.Info and the api.Info. The machine id must *always* be "0".
 	mcfg := NewMachineConfig("0", state.BootstrapNonce, nil, nil)
 	mcfg.StateServer = true
+	mcfg.Bootstrap = true
 	mcfg.StateInfoURL = stateInfoURL
 	return mcfg
 }
@@ -85,10 +86,10 @@ func FinishMachineConfig(mcfg *cloudinit.MachineConfig, cfg *config.Config, cons
 		return err
 	}
 
-	// The following settings are only appropriate at bootstrap time. At the
-	// moment, the only state server is the bootstrap node, but this
-	// will probably change.
-	if !mcfg.StateServer {
+	// The following settings are only appropriate at bootstrap time.
+	// Other state servers are given their state server details
+	// by the provisioner.
+	if !mcfg.Bootstrap {
 		return nil
 	}
 	if mcfg.APIInfo != nil || mcfg.StateInfo != nil {
@@ -4,6 +4,7 @@
 package cloudinit
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -20,6 +21,7 @@ import (
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/log/syslog"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/replicaset"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
 	coretools "launchpad.net/juju-core/tools"
@@ -33,6 +35,11 @@ import (
 // is bootstrapping.
 const BootstrapStateURLFile = "/tmp/provider-state-url"
 
+// SharedSecretFile is the name of the file, relative to the data
+// directory, that holds the secret state servers use to authenticate
+// to each other as members of the replica set.
+const SharedSecretFile = "shared-secret"
+
 // fileSchemePrefix is the prefix for file:// URLs.
 const fileSchemePrefix = "file://"
 
@@ -42,6 +49,11 @@ type MachineConfig struct {
 	// mongo and API servers.
 	StateServer bool
 
+	// Bootstrap specifies whether the new machine is the first
+	// state server in the environment, which initializes the
+	// state database. It implies StateServer.
+	Bootstrap bool
+
 	// StateServerCert and StateServerKey hold the state server
 	// certificate and private key in PEM format; they are required when
 	// StateServer is set, and ignored otherwise.
@@ -59,17 +71,17 @@ type MachineConfig struct {
 	APIPort int
 
 	// StateInfo holds the means for the new instance to communicate with the
-	// juju state. Unless the new machine is running a state server (StateServer is
+	// juju state. Unless the new machine is bootstrapping (Bootstrap is
 	// set), there must be at least one state server address supplied.
 	// The entity name must match that of the machine being started,
-	// or be empty when starting a state server.
+	// or be empty when bootstrapping.
 	StateInfo *state.Info
 
 	// APIInfo holds the means for the new instance to communicate with the
-	// juju state API. Unless the new machine is running a state server (StateServer is
+	// juju state API. Unless the new machine is bootstrapping (Bootstrap is
 	// set), there must be at least one state server address supplied.
 	// The entity name must match that of the machine being started,
-	// or be empty when starting a state server.
+	// or be empty when bootstrapping.
 	APIInfo *api.Info
 
 	// MachineNonce is set at provisioning/bootstrap time and used to
@@ -125,6 +137,14 @@ func base64yaml(m *config.Config) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
+// SharedSecret returns the replica set key derived from
+// the given state server private key.
+func SharedSecret(stateServerKey []byte) string {
+	h := sha256.New()
+	h.Write(stateServerKey)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
 func New(cfg *MachineConfig) (*cloudinit.Config, error) {
 	c := cloudinit.New()
 	return Configure(cfg, c)
@@ -214,6 +234,9 @@ func Configure(cfg *MachineConfig, c *cloudinit.Config) (*cloudinit.Config, erro
 		if err := cfg.addMongoToBoot(c); err != nil {
 			return nil, err
 		}
+	}
+
+	if cfg.Bootstrap {
 		// We temporarily give bootstrap-state a directory
 		// of its own so that it can get the state info via the
 		// same mechanism as other jujud commands.
@@ -350,7 +373,13 @@ func (cfg *MachineConfig) addMongoToBoot(c *cloudinit.Config) error {
 		"dd bs=1M count=1 if=/dev/zero of="+dbDir+"/journal/prealloc.2",
 	)
 
-	conf := upstart.MongoUpstartService("juju-db", cfg.DataDir, dbDir, cfg.StatePort)
+	// All state servers share the same private key, so they can
+	// derive the same secret to authenticate to each other as
+	// members of the replica set.
+	keyFile := cfg.dataFile(SharedSecretFile)
+	c.AddFile(keyFile, SharedSecret(cfg.StateServerKey), 0600)
+
+	conf := upstart.MongoReplicaSetUpstartService("juju-db", cfg.DataDir, dbDir, cfg.StatePort, replicaset.Name, keyFile)
 	cmds, err := conf.InstallCommands()
 	if err != nil {
 		return fmt.Errorf("cannot make cloud-init upstart script for the state database: %v", err)
@@ -502,15 +531,24 @@ func verifyConfig(cfg *MachineConfig) (err error) {
 	if len(cfg.APIInfo.CACert) == 0 {
 		return fmt.Errorf("missing API CA certificate")
 	}
+	if cfg.Bootstrap && !cfg.StateServer {
+		return fmt.Errorf("bootstrap machine must be a state server")
+	}
 	if cfg.StateServer {
-		if cfg.Config == nil {
-			return fmt.Errorf("missing environment configuration")
-		}
-		if cfg.StateInfo.Tag != "" {
-			return fmt.Errorf("entity tag must be blank when starting a state server")
-		}
-		if cfg.APIInfo.Tag != "" {
-			return fmt.Errorf("entity tag must be blank when starting a state server")
+		// Only the bootstrap state server initializes the state, so
+		// only it needs the environment configuration and connects
+		// with a blank entity tag; other state servers connect as
+		// their own machines.
+		if cfg.Bootstrap {
+			if cfg.Config == nil {
+				return fmt.Errorf("missing environment configuration")
+			}
+			if cfg.StateInfo.Tag != "" {
+				return fmt.Errorf("entity tag must be blank when starting a state server")
+			}
+			if cfg.APIInfo.Tag != "" {
+				return fmt.Errorf("entity tag must be blank when starting a state server")
+			}
 		}
 		if len(cfg.StateServerCert) == 0 {
 			return fmt.Errorf("missing state server certificate")
@@ -524,7 +562,8 @@ func verifyConfig(cfg *MachineConfig) (err error) {
 		if cfg.APIPort == 0 {
 			return fmt.Errorf("missing API port")
 		}
-	} else {
+	}
+	if !cfg.Bootstrap {
 		if len(cfg.StateInfo.Addrs) == 0 {
 			return fmt.Errorf("missing state hosts")
 		}
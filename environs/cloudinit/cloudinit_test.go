@@ -65,6 +65,7 @@ var cloudinitTests = []cloudinitTest{
 			// precise currently needs mongo from PPA
 			Tools:           newSimpleTools("1.2.3-precise-amd64"),
 			StateServer:     true,
+			Bootstrap:       true,
 			StateServerCert: serverCert,
 			StateServerKey:  serverKey,
 			StatePort:       37017,
@@ -111,7 +112,9 @@ chmod 0700 /var/lib/juju/db
 dd bs=1M count=1 if=/dev/zero of=/var/lib/juju/db/journal/prealloc\.0
 dd bs=1M count=1 if=/dev/zero of=/var/lib/juju/db/journal/prealloc\.1
 dd bs=1M count=1 if=/dev/zero of=/var/lib/juju/db/journal/prealloc\.2
-cat >> /etc/init/juju-db\.conf << 'EOF'\\ndescription "juju state database"\\nauthor "Juju Team <juju@lists\.ubuntu\.com>"\\nstart on runlevel \[2345\]\\nstop on runlevel \[!2345\]\\nrespawn\\nnormal exit 0\\n\\nlimit nofile 65000 65000\\nlimit nproc 20000 20000\\n\\nexec /usr/bin/mongod --auth --dbpath=/var/lib/juju/db --sslOnNormalPorts --sslPEMKeyFile '/var/lib/juju/server\.pem' --sslPEMKeyPassword ignored --bind_ip 0\.0\.0\.0 --port 37017 --noprealloc --syslog --smallfiles\\nEOF\\n
+install -m 600 /dev/null '/var/lib/juju/shared-secret'
+printf '%s\\n' '[^']+' > '/var/lib/juju/shared-secret'
+cat >> /etc/init/juju-db\.conf << 'EOF'\\ndescription "juju state database"\\nauthor "Juju Team <juju@lists\.ubuntu\.com>"\\nstart on runlevel \[2345\]\\nstop on runlevel \[!2345\]\\nrespawn\\nnormal exit 0\\n\\nlimit nofile 65000 65000\\nlimit nproc 20000 20000\\n\\nexec /usr/bin/mongod --auth --dbpath=/var/lib/juju/db --sslOnNormalPorts --sslPEMKeyFile '/var/lib/juju/server\.pem' --sslPEMKeyPassword ignored --bind_ip 0\.0\.0\.0 --port 37017 --noprealloc --syslog --smallfiles --replSet 'juju' --keyFile '/var/lib/juju/shared-secret'\\nEOF\\n
 start juju-db
 mkdir -p '/var/lib/juju/agents/bootstrap'
 install -m 644 /dev/null '/var/lib/juju/agents/bootstrap/format'
@@ -134,6 +137,7 @@ start jujud-machine-0
 			// raring provides mongo in the archive
 			Tools:           newSimpleTools("1.2.3-raring-amd64"),
 			StateServer:     true,
+			Bootstrap:       true,
 			StateServerCert: serverCert,
 			StateServerKey:  serverKey,
 			StatePort:       37017,
@@ -210,6 +214,42 @@ printf '%s\\n' '.*' > '/var/lib/juju/agents/machine-99/agent\.conf'
 ln -s 1\.2\.3-linux-amd64 '/var/lib/juju/tools/machine-99'
 cat >> /etc/init/jujud-machine-99\.conf << 'EOF'\\ndescription "juju machine-99 agent"\\nauthor "Juju Team <juju@lists\.ubuntu\.com>"\\nstart on runlevel \[2345\]\\nstop on runlevel \[!2345\]\\nrespawn\\nnormal exit 0\\n\\nlimit nofile 20000 20000\\n\\nexec /var/lib/juju/tools/machine-99/jujud machine --data-dir '/var/lib/juju' --machine-id 99 --debug >> /var/log/juju/machine-99\.log 2>&1\\nEOF\\n
 start jujud-machine-99
+`,
+	}, {
+		// additional state server.
+		cfg: cloudinit.MachineConfig{
+			MachineId:        "1",
+			AuthorizedKeys:   "sshkey1",
+			AgentEnvironment: map[string]string{agent.ProviderType: "dummy"},
+			DataDir:          environs.DataDir,
+			Tools:            newSimpleTools("1.2.3-raring-amd64"),
+			StateServer:      true,
+			StateServerCert:  serverCert,
+			StateServerKey:   serverKey,
+			StatePort:        37017,
+			APIPort:          17070,
+			MachineNonce:     "FAKE_NONCE",
+			StateInfo: &state.Info{
+				Addrs:    []string{"state-addr.testing.invalid:37017"},
+				Tag:      "machine-1",
+				Password: "arble",
+				CACert:   []byte("CA CERT\n" + testing.CACert),
+			},
+			APIInfo: &api.Info{
+				Addrs:    []string{"state-addr.testing.invalid:17070"},
+				Tag:      "machine-1",
+				Password: "bletch",
+				CACert:   []byte("CA CERT\n" + testing.CACert),
+			},
+		},
+		inexactMatch: true,
+		expectScripts: `
+install -m 600 /dev/null '/var/lib/juju/server\.pem'
+install -m 600 /dev/null '/var/lib/juju/shared-secret'
+cat >> /etc/init/juju-db\.conf << 'EOF'\\n.* --replSet 'juju' --keyFile '/var/lib/juju/shared-secret'\\nEOF\\n
+start juju-db
+ln -s 1\.2\.3-raring-amd64 '/var/lib/juju/tools/machine-1'
+start jujud-machine-1
 `,
 	}, {
 		// check that it works ok with compound machine ids.
@@ -285,6 +325,7 @@ wget --no-check-certificate --no-verbose -O \$bin/tools\.tar\.gz 'http://foo\.co
 			// precise currently needs mongo from PPA
 			Tools:           newSimpleTools("1.2.3-precise-amd64"),
 			StateServer:     true,
+			Bootstrap:       true,
 			StateServerCert: serverCert,
 			StateServerKey:  serverKey,
 			StatePort:       37017,
@@ -564,6 +605,7 @@ var verifyTests = []struct {
 	}},
 	{"missing state hosts", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		cfg.StateInfo = &state.Info{
 			Tag:    "machine-99",
 			CACert: []byte(testing.CACert),
@@ -576,6 +618,7 @@ var verifyTests = []struct {
 	}},
 	{"missing API hosts", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		cfg.StateInfo = &state.Info{
 			Addrs:  []string{"foo:35"},
 			Tag:    "machine-99",
@@ -591,6 +634,7 @@ var verifyTests = []struct {
 	}},
 	{"missing CA certificate", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		cfg.StateInfo = &state.Info{
 			Tag:   "machine-99",
 			Addrs: []string{"host:98765"},
@@ -613,28 +657,35 @@ var verifyTests = []struct {
 	}},
 	{"entity tag must match started machine", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		info := *cfg.StateInfo
 		info.Tag = "machine-0"
 		cfg.StateInfo = &info
 	}},
 	{"entity tag must match started machine", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		info := *cfg.StateInfo
 		info.Tag = ""
 		cfg.StateInfo = &info
 	}},
 	{"entity tag must match started machine", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		info := *cfg.APIInfo
 		info.Tag = "machine-0"
 		cfg.APIInfo = &info
 	}},
 	{"entity tag must match started machine", func(cfg *cloudinit.MachineConfig) {
 		cfg.StateServer = false
+		cfg.Bootstrap = false
 		info := *cfg.APIInfo
 		info.Tag = ""
 		cfg.APIInfo = &info
 	}},
+	{"bootstrap machine must be a state server", func(cfg *cloudinit.MachineConfig) {
+		cfg.StateServer = false
+	}},
 	{"entity tag must be blank when starting a state server", func(cfg *cloudinit.MachineConfig) {
 		info := *cfg.StateInfo
 		info.Tag = "machine-0"
@@ -645,6 +696,11 @@ var verifyTests = []struct {
 		info.Tag = "machine-0"
 		cfg.APIInfo = &info
 	}},
+	{"entity tag must match started machine", func(cfg *cloudinit.MachineConfig) {
+		// A state server that is not bootstrapping connects
+		// as its own machine.
+		cfg.Bootstrap = false
+	}},
 	{"missing state port", func(cfg *cloudinit.MachineConfig) {
 		cfg.StatePort = 0
 	}},
@@ -661,6 +717,7 @@ var verifyTests = []struct {
 func (*cloudinitSuite) TestCloudInitVerify(c *gc.C) {
 	cfg := &cloudinit.MachineConfig{
 		StateServer:      true,
+		Bootstrap:        true,
 		StateServerCert:  serverCert,
 		StateServerKey:   serverKey,
 		StatePort:        1234,
@@ -102,6 +102,7 @@ func (s *CloudInitSuite) TestFinishBootstrapConfig(c *gc.C) {
 	oldAttrs := cfg.AllAttrs()
 	mcfg := &cloudinit.MachineConfig{
 		StateServer: true,
+		Bootstrap:   true,
 	}
 	cons := constraints.MustParse("mem=1T cpu-power=999999999")
 	err = environs.FinishMachineConfig(mcfg, cfg, cons)
@@ -165,6 +166,7 @@ func (*CloudInitSuite) TestUserData(c *gc.C) {
 		StatePort:        envConfig.StatePort(),
 		APIPort:          envConfig.APIPort(),
 		StateServer:      true,
+		Bootstrap:        true,
 		AgentEnvironment: map[string]string{agent.ProviderType: "dummy"},
 	}
 	script1 := "script1"
@@ -218,6 +218,13 @@ func Validate(cfg, old *Config) error {
 		}
 	}
 
+	// If the hook timeout is set, make sure it is valid.
+	if v, ok := cfg.m["hook-timeout"].(string); ok {
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			return fmt.Errorf("invalid hook timeout in environment configuration: %q", v)
+		}
+	}
+
 	// Check firewall mode.
 	if mode := cfg.FirewallMode(); mode != FwInstance && mode != FwGlobal {
 		return fmt.Errorf("invalid firewall mode in environment configuration: %q", mode)
@@ -440,11 +447,27 @@ func (c *Config) SSLHostnameVerification() bool {
 	return c.m["ssl-hostname-verification"].(bool)
 }
 
+// ProvisionerSafeMode reports whether the provisioner should refrain
+// from stopping instances that it does not know about.
+func (c *Config) ProvisionerSafeMode() bool {
+	v, _ := c.m["provisioner-safe-mode"].(bool)
+	return v
+}
+
 // LoggingConfig returns the configuration string for the loggers.
 func (c *Config) LoggingConfig() string {
 	return c.asString("logging-config")
 }
 
+// HookTimeout returns how long a unit agent lets a hook run before
+// killing it, unless the charm specifies otherwise. A zero duration
+// means hooks are not limited.
+func (c *Config) HookTimeout() time.Duration {
+	// The value has been validated by Validate.
+	d, _ := time.ParseDuration(c.asString("hook-timeout"))
+	return d
+}
+
 // UnknownAttrs returns a copy of the raw configuration attributes
 // that are supposedly specific to the environment type. They could
 // also be wrong attributes, though. Only the specific environment
@@ -495,6 +518,8 @@ var fields = schema.Fields{
 	"state-port":                schema.ForceInt(),
 	"api-port":                  schema.ForceInt(),
 	"logging-config":            schema.String(),
+	"provisioner-safe-mode":     schema.Bool(),
+	"hook-timeout":              schema.String(),
 }
 
 // alwaysOptional holds configuration defaults for attributes that may
@@ -513,6 +538,9 @@ var alwaysOptional = schema.Defaults{
 	"ca-cert-path":         schema.Omit,
 	"ca-private-key-path":  schema.Omit,
 	"logging-config":       schema.Omit,
+	// Safe mode is only needed while an environment is being restored.
+	"provisioner-safe-mode": schema.Omit,
+	"hook-timeout":          schema.Omit,
 
 	// For backward compatibility reasons, the following
 	// attributes default to empty strings rather than being
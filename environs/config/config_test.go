@@ -400,6 +400,14 @@ var configTests = []configTest{
 			"ssl-hostname-verification": "yes please",
 		},
 		err: `ssl-hostname-verification: expected bool, got string\("yes please"\)`,
+	}, {
+		about:       "provisioner-safe-mode on",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type": "my-type",
+			"name": "my-name",
+			"provisioner-safe-mode": true,
+		},
 	}, {
 		about:       "Explicit state port",
 		useDefaults: config.UseDefaults,
@@ -443,6 +451,23 @@ var configTests = []configTest{
 			"logging-config": "foo=bar",
 		},
 		err: `unknown severity level "bar"`,
+	}, {
+		about:       "Explicit hook timeout",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"hook-timeout": "30m",
+		},
+	}, {
+		about:       "Invalid hook timeout",
+		useDefaults: config.UseDefaults,
+		attrs: testing.Attrs{
+			"type":         "my-type",
+			"name":         "my-name",
+			"hook-timeout": "forever",
+		},
+		err: `invalid hook timeout in environment configuration: "forever"`,
 	}, {
 		about:       "Sample configuration",
 		useDefaults: config.UseDefaults,
@@ -732,12 +757,26 @@ func (test configTest) check(c *gc.C, home *testing.FakeHome) {
 		c.Assert(cfg.SSLHostnameVerification(), gc.Equals, v)
 	}
 
+	if v, ok := test.attrs["provisioner-safe-mode"]; ok {
+		c.Assert(cfg.ProvisionerSafeMode(), gc.Equals, v)
+	} else {
+		c.Assert(cfg.ProvisionerSafeMode(), jc.IsFalse)
+	}
+
 	if v, ok := test.attrs["logging-config"]; ok {
 		c.Assert(cfg.LoggingConfig(), gc.Equals, v)
 	} else {
 		c.Assert(cfg.LoggingConfig(), gc.Equals, "<root>=DEBUG")
 	}
 
+	if v, ok := test.attrs["hook-timeout"].(string); ok {
+		d, err := time.ParseDuration(v)
+		c.Assert(err, gc.IsNil)
+		c.Assert(cfg.HookTimeout(), gc.Equals, d)
+	} else {
+		c.Assert(cfg.HookTimeout(), gc.Equals, time.Duration(0))
+	}
+
 	url, urlPresent := cfg.ImageMetadataURL()
 	if v, _ := test.attrs["image-metadata-url"].(string); v != "" {
 		c.Assert(url, gc.Equals, v)
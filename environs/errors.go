@@ -36,3 +36,27 @@ func IsContainersUnsupportedError(err error) bool {
 func NewContainersUnsupported(msg string) error {
 	return &containersUnsupportedError{msg: msg}
 }
+
+// transientError indicates that an operation failed for a reason that
+// may go away by itself, such as an exceeded quota or API rate limit.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+// NewTransientError returns an error that reports the same message as
+// err, and satisfies IsTransientError. Providers use it to mark errors
+// after which the operation may succeed if tried again later.
+func NewTransientError(err error) error {
+	return &transientError{err: err}
+}
+
+// IsTransientError reports whether the error was created by
+// NewTransientError.
+func IsTransientError(err error) bool {
+	_, ok := err.(*transientError)
+	return ok
+}
@@ -4,7 +4,9 @@
 package environs
 
 import (
+	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs/cloudinit"
 	"launchpad.net/juju-core/environs/config"
 	"launchpad.net/juju-core/environs/storage"
 	"launchpad.net/juju-core/instance"
@@ -105,6 +107,24 @@ type Prechecker interface {
 	PrecheckContainer(series string, kind instance.ContainerType) error
 }
 
+// InstancePlacer is an optional interface that an Environ may implement,
+// in order to support placement directives: provider-specific
+// instructions, such as "zone=us-east-1a" or the name of a node, that
+// determine where a new instance is started.
+type InstancePlacer interface {
+	// PrecheckPlacement performs a preflight check on the placement
+	// directive, returning an error if the environment does not
+	// understand it, or cannot honour it.
+	PrecheckPlacement(placement string) error
+
+	// StartInstanceWithPlacement acts as StartInstance, but starts the
+	// instance as directed by the given placement directive.
+	StartInstanceWithPlacement(
+		placement string, cons constraints.Value, possibleTools tools.List,
+		machineConfig *cloudinit.MachineConfig,
+	) (instance.Instance, *instance.HardwareCharacteristics, error)
+}
+
 // An Environ represents a juju environment as specified
 // in the environments.yaml file.
 //
@@ -189,3 +209,58 @@ type Environ interface {
 	// Provider returns the EnvironProvider that created this Environ.
 	Provider() EnvironProvider
 }
+
+// VolumeSource is an optional interface that an Environ may implement,
+// in order to provide the storage required by charms.
+type VolumeSource interface {
+	// CreateVolume creates a volume of the given kind and size and
+	// attaches it to the given instance, returning details of how
+	// the volume may be accessed from within that instance.
+	CreateVolume(params VolumeParams) (*VolumeInfo, error)
+
+	// DestroyVolume detaches the volume with the given id from any
+	// instance it is attached to, and destroys it.
+	DestroyVolume(volumeId string) error
+}
+
+// VolumeParams holds the parameters of a VolumeSource.CreateVolume call.
+type VolumeParams struct {
+	// Name uniquely identifies the storage instance for which the
+	// volume is created.
+	Name string
+
+	// Kind specifies whether the volume should hold a filesystem
+	// or be presented as a block device.
+	Kind charm.StorageType
+
+	// Size is the minimum size of the volume, in megabytes.
+	Size uint64
+
+	// Location, if not empty, is where a filesystem volume must be
+	// mounted within the instance.
+	Location string
+
+	// InstanceId is the id of the instance to attach the volume to.
+	InstanceId instance.Id
+}
+
+// VolumeInfo holds the details of a volume created by a VolumeSource.
+type VolumeInfo struct {
+	// VolumeId is the provider's identifier for the volume.
+	VolumeId string
+
+	// Size is the actual size of the volume, in megabytes.
+	Size uint64
+
+	// Location is the mount point of a filesystem volume, or the
+	// device path of a block volume, within the instance.
+	Location string
+}
+
+// Networking is an optional interface that an Environ may implement,
+// in order to report the networks its instances are connected to.
+type Networking interface {
+	// InstanceNetworks returns the networks the given instance is
+	// connected to, and the instance's network interfaces.
+	InstanceNetworks(inst instance.Instance) ([]instance.NetworkInfo, []instance.InterfaceInfo, error)
+}
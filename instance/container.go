@@ -18,6 +18,7 @@ const (
 // SupportedContainerTypes is used to validate add-machine arguments.
 var SupportedContainerTypes []ContainerType = []ContainerType{
 	LXC,
+	KVM,
 }
 
 // ParseSupportedContainerTypeOrNone converts the specified string into a supported
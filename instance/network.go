@@ -0,0 +1,51 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package instance
+
+// NetworkInfo describes a single network, which machines may be
+// connected to through their network interfaces.
+type NetworkInfo struct {
+	// Name is the juju name of the network, as used in networks
+	// constraints and endpoint bindings.
+	Name string
+
+	// ProviderId is the provider-specific id of the network.
+	ProviderId string
+
+	// CIDR of the network, in 123.45.67.89/24 format.
+	CIDR string
+
+	// VLANTag needs to be between 1 and 4094 for VLANs and 0 for
+	// normal networks. It's defined by IEEE 802.1Q standard.
+	VLANTag int
+}
+
+// IsVLAN returns whether the network is a VLAN.
+func (n NetworkInfo) IsVLAN() bool {
+	return n.VLANTag > 0
+}
+
+// InterfaceInfo describes a single network interface of a machine,
+// connecting it to a network.
+type InterfaceInfo struct {
+	// MACAddress is the network interface's hardware MAC address
+	// (e.g. "aa:bb:cc:dd:ee:ff").
+	MACAddress string
+
+	// InterfaceName is the OS-specific network device name (e.g.
+	// "eth0", or "eth1.42" for a VLAN virtual interface).
+	InterfaceName string
+
+	// NetworkName is the juju name of the network the interface
+	// is connected to.
+	NetworkName string
+
+	// Address, if known, is the address of the machine on the
+	// network, as reached through the interface.
+	Address string
+
+	// IsVirtual is true when the interface is a virtual device, as
+	// opposed to a physical device (e.g. a VLAN interface).
+	IsVirtual bool
+}
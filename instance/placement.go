@@ -0,0 +1,78 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package instance
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/juju-core/names"
+)
+
+// Placement describes where a new unit or machine should be put: on an
+// existing machine or container (MachineId is set), in a new container
+// on an existing machine (ContainerType and MachineId are set), in a new
+// container on a new machine (ContainerType is set), or on a new machine
+// started as directed by the provider (Directive is set).
+type Placement struct {
+	MachineId     string
+	ContainerType ContainerType
+	// Directive holds a provider-specific placement directive, such
+	// as "zone=us-east-1a" or the name of a MAAS node.
+	Directive string
+}
+
+// ParsePlacement parses a placement directive, which is one of:
+// - an existing machine or container id, eg "3" or "3/lxc/1";
+// - a new container on an existing machine, eg "lxc:3";
+// - a new container on a new machine, eg "lxc";
+// - any other directive, which is passed to the provider.
+func ParsePlacement(directive string) (*Placement, error) {
+	if directive == "" || strings.ContainsAny(directive, ", \t\n") {
+		return nil, fmt.Errorf("invalid placement directive %q", directive)
+	}
+	if names.IsMachine(directive) {
+		return &Placement{MachineId: directive}, nil
+	}
+	parts := strings.SplitN(directive, ":", 2)
+	ctype, err := ParseSupportedContainerType(parts[0])
+	if err != nil {
+		return &Placement{Directive: directive}, nil
+	}
+	p := &Placement{ContainerType: ctype}
+	if len(parts) == 2 {
+		if !names.IsMachine(parts[1]) {
+			return nil, fmt.Errorf("invalid placement directive %q: invalid machine id %q", directive, parts[1])
+		}
+		p.MachineId = parts[1]
+	}
+	return p, nil
+}
+
+// ParsePlacements parses a comma-separated list of placement
+// directives, one for each unit or machine to be added.
+func ParsePlacements(directives string) ([]*Placement, error) {
+	var placements []*Placement
+	for _, directive := range strings.Split(directives, ",") {
+		p, err := ParsePlacement(strings.TrimSpace(directive))
+		if err != nil {
+			return nil, err
+		}
+		placements = append(placements, p)
+	}
+	return placements, nil
+}
+
+// String returns the placement in the form accepted by ParsePlacement.
+func (p *Placement) String() string {
+	switch {
+	case p.Directive != "":
+		return p.Directive
+	case p.ContainerType == "":
+		return p.MachineId
+	case p.MachineId == "":
+		return string(p.ContainerType)
+	}
+	return fmt.Sprintf("%s:%s", p.ContainerType, p.MachineId)
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package instance_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/instance"
+)
+
+type PlacementSuite struct{}
+
+var _ = gc.Suite(&PlacementSuite{})
+
+var parsePlacementTests = []struct {
+	directive string
+	expect    instance.Placement
+	err       string
+}{{
+	directive: "3",
+	expect:    instance.Placement{MachineId: "3"},
+}, {
+	directive: "3/lxc/1",
+	expect:    instance.Placement{MachineId: "3/lxc/1"},
+}, {
+	directive: "lxc:3",
+	expect:    instance.Placement{ContainerType: instance.LXC, MachineId: "3"},
+}, {
+	directive: "lxc",
+	expect:    instance.Placement{ContainerType: instance.LXC},
+}, {
+	directive: "zone=us-east-1a",
+	expect:    instance.Placement{Directive: "zone=us-east-1a"},
+}, {
+	directive: "node-7.maas",
+	expect:    instance.Placement{Directive: "node-7.maas"},
+}, {
+	directive: "lxc:zone=us-east-1a",
+	err:       `invalid placement directive "lxc:zone=us-east-1a": invalid machine id "zone=us-east-1a"`,
+}, {
+	directive: "",
+	err:       `invalid placement directive ""`,
+}, {
+	directive: "node 7",
+	err:       `invalid placement directive "node 7"`,
+}}
+
+func (s *PlacementSuite) TestParsePlacement(c *gc.C) {
+	for i, test := range parsePlacementTests {
+		c.Logf("test %d: %q", i, test.directive)
+		p, err := instance.ParsePlacement(test.directive)
+		if test.err != "" {
+			c.Check(err, gc.ErrorMatches, test.err)
+			continue
+		}
+		c.Assert(err, gc.IsNil)
+		c.Check(*p, gc.Equals, test.expect)
+		c.Check(p.String(), gc.Equals, test.directive)
+	}
+}
+
+func (s *PlacementSuite) TestParsePlacements(c *gc.C) {
+	placements, err := instance.ParsePlacements("lxc:1, zone=us-east-1a,2")
+	c.Assert(err, gc.IsNil)
+	c.Assert(placements, gc.HasLen, 3)
+	c.Check(*placements[0], gc.Equals, instance.Placement{ContainerType: instance.LXC, MachineId: "1"})
+	c.Check(*placements[1], gc.Equals, instance.Placement{Directive: "zone=us-east-1a"})
+	c.Check(*placements[2], gc.Equals, instance.Placement{MachineId: "2"})
+
+	_, err = instance.ParsePlacements("1,,2")
+	c.Assert(err, gc.ErrorMatches, `invalid placement directive ""`)
+}
@@ -13,7 +13,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"launchpad.net/juju-core/charm"
@@ -25,7 +24,6 @@ import (
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju/osenv"
 	"launchpad.net/juju-core/log"
-	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/utils"
 )
@@ -194,6 +192,13 @@ func (conn *Conn) PutCharm(curl *charm.URL, repo charm.Repository, bumpRevision
 		}
 		curl = curl.WithRevision(chd.Revision())
 	}
+	return conn.EnsureCharm(curl, ch)
+}
+
+// EnsureCharm adds the given charm to the environment under the given
+// URL, unless a charm with that URL has been added already, and returns
+// the charm held in state.
+func (conn *Conn) EnsureCharm(curl *charm.URL, ch charm.Charm) (*state.Charm, error) {
 	if sch, err := conn.State.Charm(curl); err == nil {
 		return sch, nil
 	}
@@ -207,19 +212,26 @@ type DeployServiceParams struct {
 	Charm          *state.Charm
 	ConfigSettings charm.Settings
 	Constraints    constraints.Value
-	NumUnits       int
-	// ToMachineSpec is either:
+	// Storage holds the storage constraints for the service, keyed
+	// by the name of the charm's store.
+	Storage  map[string]constraints.Storage
+	NumUnits int
+	// ToMachineSpec holds a comma-separated list of placement
+	// directives, one for each of the first units; see AddUnits.
+	// Each directive is one of:
 	// - an existing machine/container id eg "1" or "1/lxc/2"
 	// - a new container on an existing machine eg "lxc:1"
+	// - a new container on a new machine eg "lxc"
+	// - a provider-specific directive eg "zone=us-east-1a"
 	// Use string to avoid ambiguity around machine 0.
 	ToMachineSpec string
+	// EndpointBindings maps relation names to the networks their
+	// traffic should use; see state.Service.SetEndpointBindings.
+	EndpointBindings map[string]string
 }
 
 // DeployService takes a charm and various parameters and deploys it.
 func (conn *Conn) DeployService(args DeployServiceParams) (*state.Service, error) {
-	if args.NumUnits > 1 && args.ToMachineSpec != "" {
-		return nil, stderrors.New("cannot use --num-units with --to")
-	}
 	settings, err := args.Charm.Config().ValidateSettings(args.ConfigSettings)
 	if err != nil {
 		return nil, err
@@ -243,6 +255,16 @@ func (conn *Conn) DeployService(args DeployServiceParams) (*state.Service, error
 			return nil, err
 		}
 	}
+	if len(args.Storage) > 0 {
+		if err := service.SetStorageConstraints(args.Storage); err != nil {
+			return nil, err
+		}
+	}
+	if len(args.EndpointBindings) > 0 {
+		if err := service.SetEndpointBindings(args.EndpointBindings); err != nil {
+			return nil, err
+		}
+	}
 	if args.Charm.Meta().Subordinate {
 		return service, nil
 	}
@@ -317,8 +339,26 @@ func (conn *Conn) addCharm(curl *charm.URL, ch charm.Charm) (*state.Charm, error
 }
 
 // AddUnits starts n units of the given service and allocates machines
-// to them as necessary.
-func (conn *Conn) AddUnits(svc *state.Service, n int, machineIdSpec string) ([]*state.Unit, error) {
+// to them as necessary. The placement, if not empty, holds a comma-separated
+// list of placement directives, as accepted by instance.ParsePlacement;
+// each directive places one unit, and any further units are placed
+// according to the service's constraints.
+func (conn *Conn) AddUnits(svc *state.Service, n int, placement string) ([]*state.Unit, error) {
+	var placements []*instance.Placement
+	if placement != "" {
+		var err error
+		if placements, err = instance.ParsePlacements(placement); err != nil {
+			return nil, err
+		}
+		if len(placements) > n {
+			return nil, fmt.Errorf("cannot add %d unit(s) of service %q: too many placement directives", n, svc.Name())
+		}
+		for _, p := range placements {
+			if err := conn.precheckPlacement(p); err != nil {
+				return nil, err
+			}
+		}
+	}
 	units := make([]*state.Unit, n)
 	// Hard code for now till we implement a different approach.
 	policy := state.AssignCleanEmpty
@@ -328,49 +368,8 @@ func (conn *Conn) AddUnits(svc *state.Service, n int, machineIdSpec string) ([]*
 		if err != nil {
 			return nil, fmt.Errorf("cannot add unit %d/%d to service %q: %v", i+1, n, svc.Name(), err)
 		}
-		if machineIdSpec != "" {
-			if n != 1 {
-				return nil, fmt.Errorf("cannot add multiple units of service %q to a single machine", svc.Name())
-			}
-			// machineIdSpec may be an existing machine or container, eg 3/lxc/2
-			// or a new container on a machine, eg lxc:3
-			mid := machineIdSpec
-			var containerType instance.ContainerType
-			specParts := strings.Split(machineIdSpec, ":")
-			if len(specParts) > 1 {
-				firstPart := specParts[0]
-				var err error
-				if containerType, err = instance.ParseSupportedContainerType(firstPart); err == nil {
-					mid = strings.Join(specParts[1:], "/")
-				} else {
-					mid = machineIdSpec
-				}
-			}
-			if !names.IsMachine(mid) {
-				return nil, fmt.Errorf("invalid force machine id %q", mid)
-			}
-
-			var err error
-			var m *state.Machine
-			// If a container is to be used, create it.
-			if containerType != "" {
-				params := state.AddMachineParams{
-					Series:        unit.Series(),
-					ParentId:      mid,
-					ContainerType: containerType,
-					Jobs:          []state.MachineJob{state.JobHostUnits},
-				}
-				m, err = conn.State.AddMachineWithConstraints(&params)
-
-			} else {
-				m, err = conn.State.Machine(mid)
-			}
-			if err != nil {
-				return nil, fmt.Errorf("cannot assign unit %q to machine: %v", unit.Name(), err)
-			}
-			err = unit.AssignToMachine(m)
-
-			if err != nil {
+		if i < len(placements) {
+			if err := conn.assignUnit(unit, placements[i]); err != nil {
 				return nil, err
 			}
 		} else if err := conn.State.AssignUnit(unit, policy); err != nil {
@@ -381,6 +380,48 @@ func (conn *Conn) AddUnits(svc *state.Service, n int, machineIdSpec string) ([]*
 	return units, nil
 }
 
+// precheckPlacement returns an error if the environment cannot honour
+// the given placement.
+func (conn *Conn) precheckPlacement(p *instance.Placement) error {
+	if p.Directive == "" {
+		return nil
+	}
+	placer, ok := conn.Environ.(environs.InstancePlacer)
+	if !ok {
+		return fmt.Errorf("cannot use placement directive %q: environment does not support placement directives", p.Directive)
+	}
+	if err := placer.PrecheckPlacement(p.Directive); err != nil {
+		return fmt.Errorf("cannot use placement directive %q: %v", p.Directive, err)
+	}
+	return nil
+}
+
+// assignUnit assigns the unit as directed by the given placement.
+func (conn *Conn) assignUnit(unit *state.Unit, p *instance.Placement) error {
+	if p.MachineId == "" {
+		// A new machine, possibly with a new container, is needed.
+		return unit.AssignToNewMachineWithPlacement(p.ContainerType, p.Directive)
+	}
+	var m *state.Machine
+	var err error
+	if p.ContainerType != "" {
+		// Create a new container on the existing machine.
+		params := state.AddMachineParams{
+			Series:        unit.Series(),
+			ParentId:      p.MachineId,
+			ContainerType: p.ContainerType,
+			Jobs:          []state.MachineJob{state.JobHostUnits},
+		}
+		m, err = conn.State.AddMachineWithConstraints(&params)
+	} else {
+		m, err = conn.State.Machine(p.MachineId)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot assign unit %q to machine: %v", unit.Name(), err)
+	}
+	return unit.AssignToMachine(m)
+}
+
 // InitJujuHome initializes the charm and environs/config packages to use
 // default paths based on the $JUJU_HOME or $HOME environment variables.
 // This function should be called before calling NewConn or Conn.Deploy.
@@ -392,8 +392,8 @@ func (s *ConnSuite) TestAddUnits(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(id0, gc.Not(gc.Equals), id1)
 
-	units, err = s.conn.AddUnits(svc, 2, "0")
-	c.Assert(err, gc.ErrorMatches, `cannot add multiple units of service "testriak" to a single machine`)
+	units, err = s.conn.AddUnits(svc, 1, "0,1")
+	c.Assert(err, gc.ErrorMatches, `cannot add 1 unit\(s\) of service "testriak": too many placement directives`)
 
 	units, err = s.conn.AddUnits(svc, 1, "0")
 	c.Assert(err, gc.IsNil)
@@ -404,6 +404,48 @@ func (s *ConnSuite) TestAddUnits(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	id3, err := units[0].AssignedMachineId()
 	c.Assert(id3, gc.Equals, id0+"/lxc/0")
+
+	// Each directive places one unit; the rest are assigned as usual.
+	units, err = s.conn.AddUnits(svc, 3, "1, lxc:1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 3)
+	id4, err := units[0].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id4, gc.Equals, id1)
+	id5, err := units[1].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id5, gc.Equals, id1+"/lxc/0")
+	_, err = units[2].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ConnSuite) TestAddUnitsWithPlacementDirective(c *gc.C) {
+	curl := coretesting.Charms.ClonedURL(s.repo.Path, "quantal", "riak")
+	sch, err := s.conn.PutCharm(curl, s.repo, false)
+	c.Assert(err, gc.IsNil)
+	svc, err := s.conn.State.AddService("testriak", sch)
+	c.Assert(err, gc.IsNil)
+
+	units, err := s.conn.AddUnits(svc, 2, "zone=zone1,lxc")
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 2)
+	id, err := units[0].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	m, err := s.conn.State.Machine(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Placement(), gc.Equals, "zone=zone1")
+
+	id, err = units[1].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	m, err = s.conn.State.Machine(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.ContainerType(), gc.Equals, instance.LXC)
+	c.Assert(m.Placement(), gc.Equals, "")
+
+	_, err = s.conn.AddUnits(svc, 1, "zone=nowhere")
+	c.Assert(err, gc.ErrorMatches, `cannot use placement directive "zone=nowhere": unknown availability zone "nowhere"`)
+	_, err = s.conn.AddUnits(svc, 1, "lxc:zone=zone1")
+	c.Assert(err, gc.ErrorMatches, `invalid placement directive "lxc:zone=zone1": invalid machine id "zone=zone1"`)
 }
 
 // DeployLocalSuite uses a fresh copy of the same local dummy charm for each
@@ -506,17 +548,17 @@ func (s *DeployLocalSuite) TestDeployNumUnits(c *gc.C) {
 	s.assertMachines(c, service, constraints.MustParse("mem=2G cpu-cores=2"), "0", "1")
 }
 
-func (s *DeployLocalSuite) TestDeployWithForceMachineRejectsTooManyUnits(c *gc.C) {
+func (s *DeployLocalSuite) TestDeployWithForceMachineRejectsTooManyDirectives(c *gc.C) {
 	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
 	c.Assert(err, gc.IsNil)
 	c.Assert(machine.Id(), gc.Equals, "0")
 	_, err = s.Conn.DeployService(juju.DeployServiceParams{
 		ServiceName:   "bob",
 		Charm:         s.charm,
-		NumUnits:      2,
-		ToMachineSpec: "0",
+		NumUnits:      1,
+		ToMachineSpec: "0,lxc:0",
 	})
-	c.Assert(err, gc.ErrorMatches, "cannot use --num-units with --to")
+	c.Assert(err, gc.ErrorMatches, `cannot add 1 unit\(s\) of service "bob": too many placement directives`)
 }
 
 func (s *DeployLocalSuite) TestDeployForceMachineId(c *gc.C) {
@@ -0,0 +1,17 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package names
+
+import (
+	"regexp"
+)
+
+const NetworkSnippet = "([a-zA-Z0-9_-]+)"
+
+var validNetwork = regexp.MustCompile("^" + NetworkSnippet + "$")
+
+// IsNetwork reports whether name is a valid network name.
+func IsNetwork(name string) bool {
+	return validNetwork.MatchString(name)
+}
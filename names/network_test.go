@@ -0,0 +1,36 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package names_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/names"
+)
+
+type networkSuite struct{}
+
+var _ = gc.Suite(&networkSuite{})
+
+var networkNameTests = []struct {
+	pattern string
+	valid   bool
+}{
+	{pattern: "", valid: false},
+	{pattern: "eth0", valid: true},
+	{pattern: "-my-net-", valid: true},
+	{pattern: "42", valid: true},
+	{pattern: "Storage_VLAN", valid: true},
+	{pattern: "%not", valid: false},
+	{pattern: "no/slashes", valid: false},
+	{pattern: "^dmz", valid: false},
+	{pattern: "no spaces", valid: false},
+}
+
+func (s *networkSuite) TestNetworkNames(c *gc.C) {
+	for i, test := range networkNameTests {
+		c.Logf("test %d: %q", i, test.pattern)
+		c.Check(names.IsNetwork(test.pattern), gc.Equals, test.valid)
+	}
+}
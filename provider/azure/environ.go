@@ -6,6 +6,7 @@ package azure
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -381,6 +382,12 @@ func (env *azureEnviron) StartInstance(cons constraints.Value, possibleTools too
 	// Declaring "err" in the function signature so that we can "defer"
 	// any cleanup that needs to run during error returns.
 
+	defer func() {
+		if err != nil && isTransientAzureError(err) {
+			err = environs.NewTransientError(err)
+		}
+	}()
+
 	err = environs.FinishMachineConfig(machineConfig, env.Config(), cons)
 	if err != nil {
 		return nil, nil, err
@@ -752,6 +759,22 @@ var (
 		Delay: 10 * time.Second}
 )
 
+// isTransientAzureError reports whether err is an Azure error that may
+// go away if the request is repeated later: one with a status that
+// retryPolicy retries, or one reporting an exceeded quota.
+func isTransientAzureError(err error) bool {
+	httpErr, ok := err.(gwacl.HTTPError)
+	if !ok {
+		return false
+	}
+	for _, status := range retryPolicy.HttpStatusCodes {
+		if httpErr.StatusCode() == status {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(httpErr.Error()), "quota")
+}
+
 // getManagementAPI obtains a context object for interfacing with Azure's
 // management API.
 // For now, each invocation just returns a separate object.  This is probably
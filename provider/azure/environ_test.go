@@ -1278,3 +1278,28 @@ func (s *environSuite) TestGetToolsMetadataSources(c *gc.C) {
 	c.Assert(len(sources), gc.Equals, 1)
 	assertSourceContents(c, sources[0], "filename", data)
 }
+
+// fakeHTTPError is a gwacl.HTTPError with the given status and message.
+type fakeHTTPError struct {
+	status  int
+	message string
+}
+
+func (e *fakeHTTPError) Error() string   { return e.message }
+func (e *fakeHTTPError) StatusCode() int { return e.status }
+
+func (*environSuite) TestIsTransientAzureError(c *gc.C) {
+	for i, test := range []struct {
+		err       error
+		transient bool
+	}{
+		{&fakeHTTPError{http.StatusServiceUnavailable, "service unavailable"}, true},
+		{&fakeHTTPError{http.StatusConflict, "conflict"}, true},
+		{&fakeHTTPError{http.StatusBadRequest, "the operation results in exceeding the allowed core quota"}, true},
+		{&fakeHTTPError{http.StatusBadRequest, "invalid role size"}, false},
+		{fmt.Errorf("service unavailable"), false},
+	} {
+		c.Logf("test %d: %v", i, test.err)
+		c.Check(isTransientAzureError(test.err), gc.Equals, test.transient)
+	}
+}
@@ -13,6 +13,9 @@
 // of type boolean. If this is non-empty, any operation
 // after the environment has been opened will return
 // the error "broken environment", and will also log that.
+// A method named with a "/transient" suffix, for example
+// "StartInstance/transient", fails with an error that satisfies
+// environs.IsTransientError.
 //
 // The DNS name of instances is the same as the Id,
 // with ".dns" appended.
@@ -112,6 +115,7 @@ type OpStartInstance struct {
 	MachineNonce string
 	Instance     instance.Instance
 	Constraints  constraints.Value
+	Placement    string
 	Info         *state.Info
 	APIInfo      *api.Info
 	Secret       string
@@ -136,6 +140,17 @@ type OpClosePorts struct {
 	Ports      []instance.Port
 }
 
+type OpCreateVolume struct {
+	Env    string
+	Params environs.VolumeParams
+	Info   environs.VolumeInfo
+}
+
+type OpDestroyVolume struct {
+	Env      string
+	VolumeId string
+}
+
 type OpPutFile struct {
 	Env      string
 	FileName string
@@ -166,6 +181,8 @@ type environState struct {
 	maxId        int // maximum instance id allocated so far.
 	insts        map[instance.Id]*dummyInstance
 	globalPorts  map[instance.Port]bool
+	maxVolumeId  int
+	volumes      map[string]environs.VolumeParams
 	bootstrapped bool
 	storageDelay time.Duration
 	storage      *storageServer
@@ -185,6 +202,7 @@ type environ struct {
 var _ imagemetadata.SupportsCustomSources = (*environ)(nil)
 var _ tools.SupportsCustomSources = (*environ)(nil)
 var _ environs.Environ = (*environ)(nil)
+var _ environs.VolumeSource = (*environ)(nil)
 
 // discardOperations discards all Operations written to it.
 var discardOperations chan<- Operation
@@ -266,6 +284,7 @@ func newState(name string, ops chan<- Operation) *environState {
 		ops:         ops,
 		insts:       make(map[instance.Id]*dummyInstance),
 		globalPorts: make(map[instance.Port]bool),
+		volumes:     make(map[string]environs.VolumeParams),
 	}
 	s.storage = newStorageServer(s, "/"+name+"/private")
 	s.listen()
@@ -497,8 +516,11 @@ func (e *environ) ecfg() *environConfig {
 
 func (e *environ) checkBroken(method string) error {
 	for _, m := range strings.Fields(e.ecfg().broken()) {
-		if m == method {
+		switch m {
+		case method:
 			return fmt.Errorf("dummy.%s is broken", method)
+		case method + "/transient":
+			return environs.NewTransientError(fmt.Errorf("dummy.%s is broken", method))
 		}
 	}
 	return nil
@@ -653,9 +675,50 @@ func (e *environ) Destroy() error {
 	return nil
 }
 
+// availabilityZones holds the zones that the dummy environment
+// accepts in "zone=" placement directives.
+var availabilityZones = []string{"zone1", "zone2"}
+
+// PrecheckPlacement is specified in the environs.InstancePlacer interface.
+func (e *environ) PrecheckPlacement(placement string) error {
+	zone, err := parsePlacement(placement)
+	if err != nil {
+		return err
+	}
+	for _, z := range availabilityZones {
+		if z == zone {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown availability zone %q", zone)
+}
+
+// parsePlacement returns the availability zone named by the given
+// placement directive.
+func parsePlacement(placement string) (zone string, err error) {
+	if !strings.HasPrefix(placement, "zone=") {
+		return "", fmt.Errorf("unknown placement directive: %v", placement)
+	}
+	return strings.TrimPrefix(placement, "zone="), nil
+}
+
 // StartInstance is specified in the InstanceBroker interface.
 func (e *environ) StartInstance(cons constraints.Value, possibleTools coretools.List,
 	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	return e.startInstance("", cons, possibleTools, machineConfig)
+}
+
+// StartInstanceWithPlacement is specified in the environs.InstancePlacer interface.
+func (e *environ) StartInstanceWithPlacement(placement string, cons constraints.Value, possibleTools coretools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	if err := e.PrecheckPlacement(placement); err != nil {
+		return nil, nil, err
+	}
+	return e.startInstance(placement, cons, possibleTools, machineConfig)
+}
+
+func (e *environ) startInstance(placement string, cons constraints.Value, possibleTools coretools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
 
 	defer delay()
 	machineId := machineConfig.MachineId
@@ -730,6 +793,7 @@ func (e *environ) StartInstance(cons constraints.Value, possibleTools coretools.
 		MachineId:    machineId,
 		MachineNonce: machineConfig.MachineNonce,
 		Constraints:  cons,
+		Placement:    placement,
 		Instance:     i,
 		Info:         machineConfig.StateInfo,
 		APIInfo:      machineConfig.APIInfo,
@@ -8,9 +8,14 @@ import (
 
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/jujutest"
+	envtesting "launchpad.net/juju-core/environs/testing"
+	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/provider/dummy"
 	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/version"
 )
 
 func TestPackage(t *stdtesting.T) {
@@ -38,3 +43,50 @@ func (s *suite) TearDownTest(c *gc.C) {
 	s.Tests.TearDownTest(c)
 	dummy.Reset()
 }
+
+func (s *suite) TestVolumes(c *gc.C) {
+	e := s.Prepare(c)
+	envtesting.UploadFakeTools(c, e.Storage())
+	cfg, err := e.Config().Apply(map[string]interface{}{
+		"agent-version": version.Current.Number.String(),
+	})
+	c.Assert(err, gc.IsNil)
+	err = e.SetConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	inst, _ := jujutesting.AssertStartInstance(c, e, "0")
+
+	vs := e.(environs.VolumeSource)
+	_, err = vs.CreateVolume(environs.VolumeParams{
+		Name:       "data/0",
+		Kind:       charm.StorageFilesystem,
+		Size:       1024,
+		InstanceId: "nonexistent",
+	})
+	c.Assert(err, gc.ErrorMatches, `instance "nonexistent" not found`)
+
+	info, err := vs.CreateVolume(environs.VolumeParams{
+		Name:       "data/0",
+		Kind:       charm.StorageFilesystem,
+		Size:       1024,
+		InstanceId: inst.Id(),
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(*info, gc.Equals, environs.VolumeInfo{
+		VolumeId: "vol-0",
+		Size:     1024,
+		Location: "/srv/juju/storage/vol-0",
+	})
+	info, err = vs.CreateVolume(environs.VolumeParams{
+		Name:       "scratch/1",
+		Kind:       charm.StorageBlock,
+		Size:       512,
+		InstanceId: inst.Id(),
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Location, gc.Equals, "/dev/dummy1")
+
+	err = vs.DestroyVolume("vol-0")
+	c.Assert(err, gc.IsNil)
+	err = vs.DestroyVolume("vol-0")
+	c.Assert(err, gc.ErrorMatches, `volume "vol-0" not found`)
+}
@@ -0,0 +1,75 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package dummy
+
+import (
+	"fmt"
+	"path"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs"
+)
+
+// CreateVolume is specified in the environs.VolumeSource interface.
+// Filesystem volumes are mounted under /srv/juju/storage unless the
+// charm specifies a location; block volumes appear as /dev/dummyN.
+func (e *environ) CreateVolume(params environs.VolumeParams) (*environs.VolumeInfo, error) {
+	defer delay()
+	if err := e.checkBroken("CreateVolume"); err != nil {
+		return nil, err
+	}
+	estate, err := e.state()
+	if err != nil {
+		return nil, err
+	}
+	estate.mu.Lock()
+	defer estate.mu.Unlock()
+	if _, ok := estate.insts[params.InstanceId]; !ok {
+		return nil, fmt.Errorf("instance %q not found", params.InstanceId)
+	}
+	id := fmt.Sprintf("vol-%d", estate.maxVolumeId)
+	estate.maxVolumeId++
+	info := environs.VolumeInfo{
+		VolumeId: id,
+		Size:     params.Size,
+	}
+	switch {
+	case params.Kind == charm.StorageBlock:
+		info.Location = fmt.Sprintf("/dev/dummy%d", estate.maxVolumeId-1)
+	case params.Location != "":
+		info.Location = params.Location
+	default:
+		info.Location = path.Join("/srv/juju/storage", id)
+	}
+	estate.volumes[id] = params
+	estate.ops <- OpCreateVolume{
+		Env:    e.name,
+		Params: params,
+		Info:   info,
+	}
+	return &info, nil
+}
+
+// DestroyVolume is specified in the environs.VolumeSource interface.
+func (e *environ) DestroyVolume(volumeId string) error {
+	defer delay()
+	if err := e.checkBroken("DestroyVolume"); err != nil {
+		return err
+	}
+	estate, err := e.state()
+	if err != nil {
+		return err
+	}
+	estate.mu.Lock()
+	defer estate.mu.Unlock()
+	if _, ok := estate.volumes[volumeId]; !ok {
+		return fmt.Errorf("volume %q not found", volumeId)
+	}
+	delete(estate.volumes, volumeId)
+	estate.ops <- OpDestroyVolume{
+		Env:      e.name,
+		VolumeId: volumeId,
+	}
+	return nil
+}
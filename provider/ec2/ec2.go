@@ -345,9 +345,45 @@ func (e *environ) Region() (simplestreams.CloudSpec, error) {
 
 const ebsStorage = "ebs"
 
+// PrecheckPlacement is specified in the environs.InstancePlacer interface.
+func (e *environ) PrecheckPlacement(placement string) error {
+	_, err := e.parsePlacement(placement)
+	return err
+}
+
+// parsePlacement returns the availability zone named by the given
+// placement directive, which must be of the form "zone=<zone>".
+func (e *environ) parsePlacement(placement string) (zone string, err error) {
+	if !strings.HasPrefix(placement, "zone=") {
+		return "", fmt.Errorf("unknown placement directive: %v", placement)
+	}
+	zone = strings.TrimPrefix(placement, "zone=")
+	if region := e.ecfg().region(); !strings.HasPrefix(zone, region) {
+		return "", fmt.Errorf("availability zone %q is not in region %q", zone, region)
+	}
+	return zone, nil
+}
+
 // StartInstance is specified in the InstanceBroker interface.
 func (e *environ) StartInstance(cons constraints.Value, possibleTools tools.List,
 	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	return e.startInstance("", cons, possibleTools, machineConfig)
+}
+
+// StartInstanceWithPlacement is specified in the environs.InstancePlacer interface.
+func (e *environ) StartInstanceWithPlacement(placement string, cons constraints.Value, possibleTools tools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	zone, err := e.parsePlacement(placement)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e.startInstance(zone, cons, possibleTools, machineConfig)
+}
+
+// startInstance starts an instance in the given availability zone, or
+// in one chosen by EC2 if zone is empty.
+func (e *environ) startInstance(zone string, cons constraints.Value, possibleTools tools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
 
 	arches := possibleTools.Arches()
 	stor := ebsStorage
@@ -398,6 +434,7 @@ func (e *environ) StartInstance(cons constraints.Value, possibleTools tools.List
 			UserData:            userData,
 			InstanceType:        spec.InstanceType.Name,
 			SecurityGroups:      groups,
+			AvailZone:           zone,
 			BlockDeviceMappings: []ec2.BlockDeviceMapping{device},
 		})
 		if err == nil || ec2ErrCode(err) != "InvalidGroup.NotFound" {
@@ -405,6 +442,9 @@ func (e *environ) StartInstance(cons constraints.Value, possibleTools tools.List
 		}
 	}
 	if err != nil {
+		if isTransientEC2Error(err) {
+			return nil, nil, environs.NewTransientError(fmt.Errorf("cannot run instances: %v", err))
+		}
 		return nil, nil, fmt.Errorf("cannot run instances: %v", err)
 	}
 	if len(instResp.Instances) != 1 {
@@ -1010,6 +1050,22 @@ func ec2ErrCode(err error) string {
 	return ec2err.Code
 }
 
+// transientEC2ErrorCodes holds the codes of the EC2 errors that may go
+// away if the request is repeated later.
+var transientEC2ErrorCodes = map[string]bool{
+	"RequestLimitExceeded":         true,
+	"InstanceLimitExceeded":        true,
+	"InsufficientInstanceCapacity": true,
+	"Unavailable":                  true,
+	"InternalError":                true,
+}
+
+// isTransientEC2Error reports whether err is an EC2 error that may go
+// away if the request is repeated later.
+func isTransientEC2Error(err error) bool {
+	return transientEC2ErrorCodes[ec2ErrCode(err)]
+}
+
 // metadataHost holds the address of the instance metadata service.
 // It is a variable so that tests can change it to refer to a local
 // server when needed.
@@ -4,6 +4,8 @@
 package ec2
 
 import (
+	"fmt"
+
 	amzec2 "launchpad.net/goamz/ec2"
 	gc "launchpad.net/gocheck"
 
@@ -61,3 +63,19 @@ func (*Suite) TestRootDisk(c *gc.C) {
 func pInt(i uint64) *uint64 {
 	return &i
 }
+
+func (*Suite) TestIsTransientEC2Error(c *gc.C) {
+	for code, transient := range map[string]bool{
+		"RequestLimitExceeded":         true,
+		"InstanceLimitExceeded":        true,
+		"InsufficientInstanceCapacity": true,
+		"Unavailable":                  true,
+		"InternalError":                true,
+		"InvalidAMIID.NotFound":        false,
+		"UnauthorizedOperation":        false,
+	} {
+		c.Logf("code %s", code)
+		c.Check(isTransientEC2Error(&amzec2.Error{Code: code}), gc.Equals, transient)
+	}
+	c.Check(isTransientEC2Error(fmt.Errorf("RequestLimitExceeded")), gc.Equals, false)
+}
@@ -203,6 +203,18 @@ func (t *localServerSuite) TestPrecheck(c *gc.C) {
 	c.Check(err, gc.ErrorMatches, "ec2 provider does not support containers")
 }
 
+func (t *localServerSuite) TestPrecheckPlacement(c *gc.C) {
+	env := t.Prepare(c)
+	placer, ok := env.(environs.InstancePlacer)
+	c.Assert(ok, jc.IsTrue)
+	err := placer.PrecheckPlacement("zone=test-1a")
+	c.Check(err, gc.IsNil)
+	err = placer.PrecheckPlacement("zone=us-east-1a")
+	c.Check(err, gc.ErrorMatches, `availability zone "us-east-1a" is not in region "test"`)
+	err = placer.PrecheckPlacement("node1")
+	c.Check(err, gc.ErrorMatches, "unknown placement directive: node1")
+}
+
 func (t *localServerSuite) TestBootstrapInstanceUserDataAndState(c *gc.C) {
 	env := t.Prepare(c)
 	envtesting.UploadFakeTools(c, env.Storage())
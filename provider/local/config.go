@@ -22,6 +22,7 @@ var (
 		"root-dir":            schema.String(),
 		"bootstrap-ip":        schema.String(),
 		"network-bridge":      schema.String(),
+		"networks":            schema.StringMap(schema.String()),
 		"storage-port":        schema.ForceInt(),
 		"shared-storage-port": schema.ForceInt(),
 	}
@@ -32,6 +33,7 @@ var (
 	configDefaults = schema.Defaults{
 		"root-dir":            "",
 		"network-bridge":      "lxcbr0",
+		"networks":            schema.Omit,
 		"bootstrap-ip":        schema.Omit,
 		"storage-port":        8040,
 		"shared-storage-port": 8041,
@@ -77,6 +79,22 @@ func (c *environConfig) networkBridge() string {
 	return c.attrs["network-bridge"].(string)
 }
 
+// networks returns the host bridges that containers are connected to,
+// keyed by network name. The network-bridge is always included, as
+// the network named "local", unless that name is configured
+// explicitly.
+func (c *environConfig) networks() map[string]string {
+	networks := map[string]string{
+		defaultNetworkName: c.networkBridge(),
+	}
+	if attr, ok := c.attrs["networks"]; ok {
+		for name, bridge := range attr.(map[string]interface{}) {
+			networks[name] = bridge.(string)
+		}
+	}
+	return networks
+}
+
 func (c *environConfig) sharedStorageDir() string {
 	return filepath.Join(c.rootDir(), "shared-storage")
 }
@@ -85,6 +103,10 @@ func (c *environConfig) storageDir() string {
 	return filepath.Join(c.rootDir(), "storage")
 }
 
+func (c *environConfig) volumesDir() string {
+	return filepath.Join(c.rootDir(), "volumes")
+}
+
 func (c *environConfig) mongoDir() string {
 	return filepath.Join(c.rootDir(), "db")
 }
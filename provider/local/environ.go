@@ -16,6 +16,7 @@ import (
 	"launchpad.net/juju-core/agent"
 	agenttools "launchpad.net/juju-core/agent/tools"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
 	"launchpad.net/juju-core/container/lxc"
 	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/cloudinit"
@@ -58,7 +59,7 @@ type localEnviron struct {
 	name                  string
 	sharedStorageListener net.Listener
 	storageListener       net.Listener
-	containerManager      lxc.ContainerManager
+	containerManager      container.ContainerManager
 }
 
 // GetToolsSources returns a list of sources which are used to search for simplestreams tools metadata.
@@ -175,7 +176,7 @@ func (env *localEnviron) SetConfig(cfg *config.Config) error {
 	env.name = ecfg.Name()
 
 	env.containerManager = lxc.NewContainerManager(
-		lxc.ManagerConfig{
+		container.ManagerConfig{
 			Name:   env.config.namespace(),
 			LogDir: env.config.logDir(),
 		})
@@ -266,7 +267,13 @@ func (env *localEnviron) StartInstance(cons constraints.Value, possibleTools too
 	machineConfig.Tools = possibleTools[0]
 	machineConfig.MachineContainerType = instance.LXC
 	logger.Debugf("tools: %#v", machineConfig.Tools)
-	network := lxc.BridgeNetworkConfig(env.config.networkBridge())
+	networks := env.config.networks()
+	if err := checkNetworks(cons, networks); err != nil {
+		return nil, nil, err
+	}
+	containerName := fmt.Sprintf("%s-%s", env.config.namespace(), names.MachineTag(machineConfig.MachineId))
+	lxcInterfaces, _ := containerInterfaces(containerName, networks)
+	network := container.MultiBridgeNetworkConfig(lxcInterfaces)
 	if err := environs.FinishMachineConfig(machineConfig, env.config.Config, cons); err != nil {
 		return nil, nil, err
 	}
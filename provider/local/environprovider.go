@@ -12,6 +12,7 @@ import (
 
 	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/provider"
 	"launchpad.net/juju-core/utils"
 	"launchpad.net/juju-core/version"
@@ -102,6 +103,11 @@ func (provider environProvider) Validate(cfg, old *config.Config) (valid *config
 		return nil, err
 	}
 	localConfig := newEnvironConfig(cfg, validated)
+	for name := range localConfig.networks() {
+		if !names.IsNetwork(name) {
+			return nil, fmt.Errorf("invalid network name %q", name)
+		}
+	}
 	// Before potentially creating directories, make sure that the
 	// root directory has not changed.
 	if old != nil {
@@ -163,6 +169,11 @@ local:
     
     # Override the network bridge if you have changed the default lxc bridge
     # network-bridge: lxcbr0
+    
+    # Connect containers to additional networks, each provided by a host
+    # bridge. The network-bridge provides the network named "local".
+    # networks:
+    #     storage: br-storage
 
 `[1:]
 }
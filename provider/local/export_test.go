@@ -67,3 +67,10 @@ func MockAddressForInterface() func() {
 }
 
 var CheckLocalPort = checkLocalPort
+
+var (
+	RunCommand      = &runCommand
+	LxcContainerDir = &lxcContainerDir
+	BridgeCIDR      = &bridgeCIDR
+	CheckNetworks   = checkNetworks
+)
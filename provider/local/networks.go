@@ -0,0 +1,129 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package local
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net"
+	"sort"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+)
+
+// defaultNetworkName is the name of the network provided by the
+// network-bridge.
+const defaultNetworkName = "local"
+
+var _ environs.Networking = (*localEnviron)(nil)
+
+// networkNames returns the names of the networks every container is
+// connected to, in the order of the containers' interfaces: the
+// default network comes first, followed by the others in name order.
+func networkNames(networks map[string]string) []string {
+	var names []string
+	for name := range networks {
+		if name != defaultNetworkName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{defaultNetworkName}, names...)
+}
+
+// checkNetworks returns an error if the networks constraint cannot be
+// satisfied: every container is connected to all the configured
+// networks, and only those.
+func checkNetworks(cons constraints.Value, networks map[string]string) error {
+	for _, name := range cons.IncludeNetworks() {
+		if _, ok := networks[name]; !ok {
+			return fmt.Errorf("network %q is not configured", name)
+		}
+	}
+	for _, name := range cons.ExcludeNetworks() {
+		if _, ok := networks[name]; ok {
+			return fmt.Errorf("cannot exclude network %q: all containers are connected to it", name)
+		}
+	}
+	return nil
+}
+
+// macAddress returns the MAC address of the interface with the given
+// index of the named container. It is derived from the container name,
+// so the same address is always given to the same interface, and is
+// in the range reserved for LXC containers.
+func macAddress(containerName string, index int) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%s/%d", containerName, index)
+	sum := hash.Sum(nil)
+	return fmt.Sprintf("00:16:3e:%02x:%02x:%02x", sum[0], sum[1], sum[2])
+}
+
+// containerInterfaces returns the interfaces of the named container,
+// one for each of the given networks.
+func containerInterfaces(containerName string, networks map[string]string) ([]container.NetworkInterface, []instance.InterfaceInfo) {
+	var lxcInterfaces []container.NetworkInterface
+	var interfaces []instance.InterfaceInfo
+	for i, name := range networkNames(networks) {
+		iface := container.NetworkInterface{
+			Link:       networks[name],
+			Name:       fmt.Sprintf("eth%d", i),
+			MACAddress: macAddress(containerName, i),
+		}
+		lxcInterfaces = append(lxcInterfaces, iface)
+		interfaces = append(interfaces, instance.InterfaceInfo{
+			MACAddress:    iface.MACAddress,
+			InterfaceName: iface.Name,
+			NetworkName:   name,
+		})
+	}
+	return lxcInterfaces, interfaces
+}
+
+// bridgeCIDR returns the CIDR of the network on the given host bridge.
+// It is a variable so that tests can replace it.
+var bridgeCIDR = func(bridge string) (string, error) {
+	iface, err := net.InterfaceByName(bridge)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			ones, _ := ipNet.Mask.Size()
+			return fmt.Sprintf("%s/%d", ipNet.IP.Mask(ipNet.Mask), ones), nil
+		}
+	}
+	return "", fmt.Errorf("no IPv4 address found on %q", bridge)
+}
+
+// InstanceNetworks is specified in the environs.Networking interface.
+func (env *localEnviron) InstanceNetworks(inst instance.Instance) ([]instance.NetworkInfo, []instance.InterfaceInfo, error) {
+	if inst.Id() == bootstrapInstanceId {
+		// The bootstrap instance is the host itself.
+		return nil, nil, nil
+	}
+	configured := env.config.networks()
+	var networks []instance.NetworkInfo
+	for _, name := range networkNames(configured) {
+		bridge := configured[name]
+		cidr, err := bridgeCIDR(bridge)
+		if err != nil {
+			logger.Warningf("cannot get CIDR of network %q: %v", name, err)
+		}
+		networks = append(networks, instance.NetworkInfo{
+			Name:       name,
+			ProviderId: bridge,
+			CIDR:       cidr,
+		})
+	}
+	_, interfaces := containerInterfaces(string(inst.Id()), configured)
+	return networks, interfaces, nil
+}
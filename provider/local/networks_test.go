@@ -0,0 +1,110 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package local_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/provider/local"
+)
+
+type networksSuite struct {
+	baseProviderSuite
+	env environs.Environ
+}
+
+var _ = gc.Suite(&networksSuite{})
+
+func (s *networksSuite) SetUpTest(c *gc.C) {
+	s.baseProviderSuite.SetUpTest(c)
+	s.PatchValue(local.BridgeCIDR, func(bridge string) (string, error) {
+		switch bridge {
+		case "lxcbr0":
+			return "10.0.3.0/24", nil
+		case "br-storage":
+			return "10.0.42.0/24", nil
+		}
+		return "", fmt.Errorf("no such bridge")
+	})
+	restore := local.SetRootCheckFunction(func() bool { return true })
+	s.AddCleanup(func(*gc.C) { restore() })
+	// Setting bootstrap-ip avoids the setup of local storage.
+	cfg := localConfig(c, map[string]interface{}{
+		"root-dir":     c.MkDir(),
+		"bootstrap-ip": "127.0.0.1",
+		"networks":     map[string]interface{}{"storage": "br-storage"},
+	})
+	var err error
+	s.env, err = local.Provider.Open(cfg)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *networksSuite) TestInstanceNetworks(c *gc.C) {
+	insts, err := s.env.Instances([]instance.Id{"test-machine-1"})
+	c.Assert(err, gc.IsNil)
+	networking := s.env.(environs.Networking)
+	networks, interfaces, err := networking.InstanceNetworks(insts[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.DeepEquals, []instance.NetworkInfo{
+		{Name: "local", ProviderId: "lxcbr0", CIDR: "10.0.3.0/24"},
+		{Name: "storage", ProviderId: "br-storage", CIDR: "10.0.42.0/24"},
+	})
+	c.Assert(interfaces, gc.HasLen, 2)
+	for i, iface := range interfaces {
+		c.Check(iface.InterfaceName, gc.Equals, fmt.Sprintf("eth%d", i))
+		c.Check(iface.NetworkName, gc.Equals, networks[i].Name)
+		c.Check(iface.MACAddress, gc.Matches, "00:16:3e(:[0-9a-f]{2}){3}")
+	}
+	c.Assert(interfaces[0].MACAddress, gc.Not(gc.Equals), interfaces[1].MACAddress)
+
+	// The interfaces are the same every time.
+	_, again, err := networking.InstanceNetworks(insts[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.DeepEquals, interfaces)
+
+	// The bootstrap instance is not a container.
+	insts, err = s.env.Instances([]instance.Id{"localhost"})
+	c.Assert(err, gc.IsNil)
+	networks, interfaces, err = networking.InstanceNetworks(insts[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 0)
+	c.Assert(interfaces, gc.HasLen, 0)
+}
+
+func (s *networksSuite) TestCheckNetworks(c *gc.C) {
+	configured := map[string]string{"local": "lxcbr0", "storage": "br-storage"}
+	for i, test := range []struct {
+		cons string
+		err  string
+	}{
+		{"", ""},
+		{"networks=storage", ""},
+		{"networks=local,storage", ""},
+		{"networks=dmz", `network "dmz" is not configured`},
+		{"networks=^dmz", ""},
+		{"networks=^storage", `cannot exclude network "storage": all containers are connected to it`},
+	} {
+		c.Logf("test %d: %s", i, test.cons)
+		err := local.CheckNetworks(constraints.MustParse(test.cons), configured)
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *networksSuite) TestInvalidNetworkName(c *gc.C) {
+	cfg, err := minimalConfig(c).Apply(map[string]interface{}{
+		"networks": map[string]interface{}{"bad name": "br0"},
+	})
+	c.Assert(err, gc.IsNil)
+	_, err = local.Provider.Validate(cfg, nil)
+	c.Assert(err, gc.ErrorMatches, `invalid network name "bad name"`)
+}
@@ -0,0 +1,199 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package local
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+)
+
+var _ environs.VolumeSource = (*localEnviron)(nil)
+
+var (
+	// lxcContainerDir holds the configuration of the containers, to
+	// which mount entries for filesystem volumes are added.
+	lxcContainerDir = "/var/lib/lxc"
+
+	// defaultVolumeDir is where filesystem volumes are mounted within
+	// an instance when the charm does not specify a location.
+	defaultVolumeDir = "/srv/juju/storage"
+)
+
+// runCommand runs the given command, returning its combined output. It
+// is a variable so that it can be replaced in tests.
+var runCommand = func(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v (%s)", name, err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
+// volumeFile returns the path of the file backing the volume.
+func (env *localEnviron) volumeFile(volumeId string) string {
+	return filepath.Join(env.config.volumesDir(), volumeId+".img")
+}
+
+// CreateVolume is specified in the environs.VolumeSource interface.
+// Volumes are sparse files in the environment's root directory, made
+// available through loop devices. Filesystem volumes are formatted
+// and mounted into the container.
+func (env *localEnviron) CreateVolume(params environs.VolumeParams) (*environs.VolumeInfo, error) {
+	if !env.config.runningAsRoot {
+		return nil, fmt.Errorf("creating volumes in a local environment must be done as root")
+	}
+	volumeId := fmt.Sprintf("%s-%s", env.config.namespace(), strings.Replace(params.Name, "/", "-", -1))
+	file := env.volumeFile(volumeId)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create volume file: %v", err)
+	}
+	err = f.Truncate(int64(params.Size) * 1024 * 1024)
+	f.Close()
+	if err != nil {
+		os.Remove(file)
+		return nil, fmt.Errorf("cannot size volume file: %v", err)
+	}
+	out, err := runCommand("losetup", "--find", "--show", file)
+	if err != nil {
+		os.Remove(file)
+		return nil, err
+	}
+	device := strings.TrimSpace(out)
+	info := &environs.VolumeInfo{
+		VolumeId: volumeId,
+		Size:     params.Size,
+		Location: device,
+	}
+	if params.Kind == charm.StorageBlock {
+		return info, nil
+	}
+	location := params.Location
+	if location == "" {
+		location = filepath.Join(defaultVolumeDir, volumeId)
+	}
+	if err := env.mountVolume(device, params.InstanceId, location); err != nil {
+		env.DestroyVolume(volumeId)
+		return nil, err
+	}
+	info.Location = location
+	return info, nil
+}
+
+// mountVolume formats the device and mounts it at the given location
+// within the instance. Mounts made on the host are not seen inside a
+// running container, so a container gets a mount entry in its
+// configuration instead, and is rebooted for the entry to take effect;
+// the entry also mounts the volume whenever the container restarts.
+func (env *localEnviron) mountVolume(device string, instId instance.Id, location string) error {
+	if _, err := runCommand("mkfs", "-t", "ext4", "-q", device); err != nil {
+		return err
+	}
+	if instId == bootstrapInstanceId {
+		if err := os.MkdirAll(location, 0755); err != nil {
+			return err
+		}
+		_, err := runCommand("mount", device, location)
+		return err
+	}
+	// Mount entry targets are relative to the container's root filesystem.
+	entry := fmt.Sprintf("%s %s %s ext4 defaults,create=dir 0 0\n",
+		mountEntryPrefix, device, strings.TrimPrefix(filepath.Clean(location), "/"))
+	f, err := os.OpenFile(lxcConfigFile(instId), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot add mount entry for volume: %v", err)
+	}
+	_, err = f.WriteString(entry)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("cannot add mount entry for volume: %v", err)
+	}
+	if _, err := runCommand("lxc-stop", "-n", string(instId), "--reboot"); err != nil {
+		// A stopped container mounts the volume when it is next started.
+		logger.Warningf("cannot reboot container %q to mount volume: %v", instId, err)
+	}
+	return nil
+}
+
+// mountEntryPrefix starts each mount entry in a container's configuration.
+const mountEntryPrefix = "lxc.mount.entry ="
+
+// lxcConfigFile returns the path of the configuration of the container.
+func lxcConfigFile(instId instance.Id) string {
+	return filepath.Join(lxcContainerDir, string(instId), "config")
+}
+
+// removeMountEntries removes the mount entries for the device from the
+// configuration of every container.
+func removeMountEntries(device string) error {
+	configs, err := filepath.Glob(filepath.Join(lxcContainerDir, "*", "config"))
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		data, err := ioutil.ReadFile(config)
+		if err != nil {
+			return err
+		}
+		var kept []string
+		lines := strings.SplitAfter(string(data), "\n")
+		for _, line := range lines {
+			if !strings.HasPrefix(line, mountEntryPrefix+" "+device+" ") {
+				kept = append(kept, line)
+			}
+		}
+		if len(kept) == len(lines) {
+			continue
+		}
+		if err := ioutil.WriteFile(config, []byte(strings.Join(kept, "")), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DestroyVolume is specified in the environs.VolumeSource interface.
+func (env *localEnviron) DestroyVolume(volumeId string) error {
+	if !env.config.runningAsRoot {
+		return fmt.Errorf("destroying volumes in a local environment must be done as root")
+	}
+	file := env.volumeFile(volumeId)
+	if _, err := os.Stat(file); err != nil {
+		return fmt.Errorf("volume %q not found", volumeId)
+	}
+	// losetup -j prints lines of the form "/dev/loop0: [0801]:1234 (file)".
+	out, err := runCommand("losetup", "-j", file)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			continue
+		}
+		device := line[:i]
+		// The device will not be mounted if it holds a block volume.
+		if _, err := runCommand("umount", device); err != nil {
+			logger.Debugf("cannot unmount %s: %v", device, err)
+		}
+		if err := removeMountEntries(device); err != nil {
+			return err
+		}
+		if _, err := runCommand("losetup", "-d", device); err != nil {
+			return err
+		}
+	}
+	return os.Remove(file)
+}
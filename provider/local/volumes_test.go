@@ -0,0 +1,146 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package local_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/provider/local"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type volumesSuite struct {
+	baseProviderSuite
+	commands []string
+	vs       environs.VolumeSource
+	rootDir  string
+	lxcDir   string
+}
+
+var _ = gc.Suite(&volumesSuite{})
+
+func (s *volumesSuite) SetUpTest(c *gc.C) {
+	s.baseProviderSuite.SetUpTest(c)
+	s.commands = nil
+	s.PatchValue(local.RunCommand, func(name string, args ...string) (string, error) {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		s.commands = append(s.commands, cmd)
+		switch {
+		case strings.HasPrefix(cmd, "losetup --find"):
+			return "/dev/loop3\n", nil
+		case strings.HasPrefix(cmd, "losetup -j"):
+			return "/dev/loop3: [0801]:1234 (" + args[1] + ")\n", nil
+		}
+		return "", nil
+	})
+	s.lxcDir = c.MkDir()
+	s.PatchValue(local.LxcContainerDir, s.lxcDir)
+	restore := local.SetRootCheckFunction(func() bool { return true })
+	s.AddCleanup(func(*gc.C) { restore() })
+	s.rootDir = c.MkDir()
+	// Setting bootstrap-ip avoids the setup of local storage.
+	cfg := localConfig(c, map[string]interface{}{
+		"root-dir":     s.rootDir,
+		"bootstrap-ip": "127.0.0.1",
+	})
+	environ, err := local.Provider.Open(cfg)
+	c.Assert(err, gc.IsNil)
+	s.vs = environ.(environs.VolumeSource)
+}
+
+func (s *volumesSuite) TestCreateBlockVolume(c *gc.C) {
+	info, err := s.vs.CreateVolume(environs.VolumeParams{
+		Name:       "scratch/1",
+		Kind:       charm.StorageBlock,
+		Size:       16,
+		InstanceId: "test-machine-1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.VolumeId, jc.HasSuffix, "-scratch-1")
+	c.Assert(info.Size, gc.Equals, uint64(16))
+	c.Assert(info.Location, gc.Equals, "/dev/loop3")
+
+	file := filepath.Join(s.rootDir, "volumes", info.VolumeId+".img")
+	fi, err := os.Stat(file)
+	c.Assert(err, gc.IsNil)
+	c.Assert(fi.Size(), gc.Equals, int64(16*1024*1024))
+	c.Assert(s.commands, gc.DeepEquals, []string{"losetup --find --show " + file})
+
+	s.commands = nil
+	err = s.vs.DestroyVolume(info.VolumeId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.commands, gc.DeepEquals, []string{
+		"losetup -j " + file,
+		"umount /dev/loop3",
+		"losetup -d /dev/loop3",
+	})
+	_, err = os.Stat(file)
+	c.Assert(err, jc.Satisfies, os.IsNotExist)
+
+	err = s.vs.DestroyVolume(info.VolumeId)
+	c.Assert(err, gc.ErrorMatches, `volume ".*-scratch-1" not found`)
+}
+
+func (s *volumesSuite) TestCreateFilesystemVolume(c *gc.C) {
+	containerDir := filepath.Join(s.lxcDir, "test-machine-1")
+	err := os.MkdirAll(containerDir, 0755)
+	c.Assert(err, gc.IsNil)
+	config := filepath.Join(containerDir, "config")
+	err = ioutil.WriteFile(config, []byte("lxc.utsname = test-machine-1\n"), 0644)
+	c.Assert(err, gc.IsNil)
+
+	info, err := s.vs.CreateVolume(environs.VolumeParams{
+		Name:       "data/0",
+		Kind:       charm.StorageFilesystem,
+		Size:       16,
+		Location:   "/srv/data",
+		InstanceId: "test-machine-1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Location, gc.Equals, "/srv/data")
+
+	// The volume is mounted by an entry in the container's
+	// configuration, which takes effect when the container reboots.
+	file := filepath.Join(s.rootDir, "volumes", info.VolumeId+".img")
+	c.Assert(s.commands, gc.DeepEquals, []string{
+		"losetup --find --show " + file,
+		"mkfs -t ext4 -q /dev/loop3",
+		"lxc-stop -n test-machine-1 --reboot",
+	})
+	data, err := ioutil.ReadFile(config)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "lxc.utsname = test-machine-1\n"+
+		"lxc.mount.entry = /dev/loop3 srv/data ext4 defaults,create=dir 0 0\n")
+
+	// Destroying the volume removes the entry.
+	err = s.vs.DestroyVolume(info.VolumeId)
+	c.Assert(err, gc.IsNil)
+	data, err = ioutil.ReadFile(config)
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(data), gc.Equals, "lxc.utsname = test-machine-1\n")
+}
+
+func (s *volumesSuite) TestCreateVolumeDefaultLocation(c *gc.C) {
+	containerDir := filepath.Join(s.lxcDir, "test-machine-1")
+	err := os.MkdirAll(containerDir, 0755)
+	c.Assert(err, gc.IsNil)
+	err = ioutil.WriteFile(filepath.Join(containerDir, "config"), nil, 0644)
+	c.Assert(err, gc.IsNil)
+
+	info, err := s.vs.CreateVolume(environs.VolumeParams{
+		Name:       "data/0",
+		Kind:       charm.StorageFilesystem,
+		Size:       16,
+		InstanceId: "test-machine-1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Location, gc.Equals, filepath.Join("/srv/juju/storage", info.VolumeId))
+}
@@ -6,6 +6,7 @@ package maas
 import (
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
@@ -165,13 +166,38 @@ func convertConstraints(cons constraints.Value) url.Values {
 	if cons.CpuPower != nil {
 		logger.Warningf("ignoring unsupported constraint 'cpu-power'")
 	}
+	if include := cons.IncludeNetworks(); len(include) > 0 {
+		params.Add("networks", strings.Join(include, ","))
+	}
+	if exclude := cons.ExcludeNetworks(); len(exclude) > 0 {
+		params.Add("not_networks", strings.Join(exclude, ","))
+	}
 	return params
 }
 
-// acquireNode allocates a node from the MAAS.
-func (environ *maasEnviron) acquireNode(cons constraints.Value, possibleTools tools.List) (gomaasapi.MAASObject, *tools.Tools, error) {
+// placementParams converts a placement directive, which is either
+// "zone=<zone>" or the name of a node, into the corresponding parameter
+// of the MAAS acquire API call.
+func placementParams(placement string) url.Values {
+	params := url.Values{}
+	if strings.HasPrefix(placement, "zone=") {
+		params.Add("zone", strings.TrimPrefix(placement, "zone="))
+	} else {
+		params.Add("name", placement)
+	}
+	return params
+}
+
+// acquireNode allocates a node from the MAAS, as directed by the given
+// placement directive if it is not empty.
+func (environ *maasEnviron) acquireNode(cons constraints.Value, placement string, possibleTools tools.List) (gomaasapi.MAASObject, *tools.Tools, error) {
 	acquireParams := convertConstraints(cons)
 	acquireParams.Add("agent_name", environ.ecfg().maasAgentName())
+	if placement != "" {
+		for key, values := range placementParams(placement) {
+			acquireParams[key] = values
+		}
+	}
 	var result gomaasapi.JSONObject
 	var err error
 	for a := shortAttempt.Start(); a.Next(); {
@@ -195,6 +221,18 @@ func (environ *maasEnviron) acquireNode(cons constraints.Value, possibleTools to
 }
 
 // startNode installs and boots a node.
+// isTransientMAASError reports whether err is a MAAS error that may go
+// away if the request is repeated later: MAAS reports a conflict when
+// no node matching the request is available, which may change as
+// nodes are released, and its API may be temporarily unavailable.
+func isTransientMAASError(err error) bool {
+	serverErr, ok := err.(gomaasapi.ServerError)
+	if !ok {
+		return false
+	}
+	return serverErr.StatusCode == http.StatusConflict || serverErr.StatusCode == http.StatusServiceUnavailable
+}
+
 func (environ *maasEnviron) startNode(node gomaasapi.MAASObject, series string, userdata []byte) error {
 	userDataParam := base64.StdEncoding.EncodeToString(userdata)
 	params := url.Values{
@@ -229,13 +267,38 @@ func linkBridgeInInterfaces() string {
 	return `sed -i "s/iface eth0 inet dhcp/source \/etc\/network\/eth0.config/" /etc/network/interfaces`
 }
 
+// PrecheckPlacement is specified in the environs.InstancePlacer interface.
+func (environ *maasEnviron) PrecheckPlacement(placement string) error {
+	if placement == "zone=" {
+		return fmt.Errorf("empty availability zone in placement directive")
+	}
+	return nil
+}
+
 // StartInstance is specified in the InstanceBroker interface.
 func (environ *maasEnviron) StartInstance(cons constraints.Value, possibleTools tools.List,
 	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	return environ.startInstance("", cons, possibleTools, machineConfig)
+}
+
+// StartInstanceWithPlacement is specified in the environs.InstancePlacer interface.
+func (environ *maasEnviron) StartInstanceWithPlacement(placement string, cons constraints.Value, possibleTools tools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+	if err := environ.PrecheckPlacement(placement); err != nil {
+		return nil, nil, err
+	}
+	return environ.startInstance(placement, cons, possibleTools, machineConfig)
+}
+
+func (environ *maasEnviron) startInstance(placement string, cons constraints.Value, possibleTools tools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
 
 	var inst *maasInstance
 	var err error
-	if node, tools, err := environ.acquireNode(cons, possibleTools); err != nil {
+	if node, tools, err := environ.acquireNode(cons, placement, possibleTools); err != nil {
+		if isTransientMAASError(err) {
+			return nil, nil, environs.NewTransientError(fmt.Errorf("cannot run instances: %v", err))
+		}
 		return nil, nil, fmt.Errorf("cannot run instances: %v", err)
 	} else {
 		inst = &maasInstance{&node, environ}
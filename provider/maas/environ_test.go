@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/url"
 
 	gc "launchpad.net/gocheck"
@@ -274,7 +275,7 @@ func (suite *environSuite) TestAcquireNode(c *gc.C) {
 	env := suite.makeEnviron()
 	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
 
-	_, _, err := env.acquireNode(constraints.Value{}, tools.List{fakeTools})
+	_, _, err := env.acquireNode(constraints.Value{}, "", tools.List{fakeTools})
 
 	c.Check(err, gc.IsNil)
 	operations := suite.testMAASObject.TestServer.NodeOperations()
@@ -290,7 +291,7 @@ func (suite *environSuite) TestAcquireNodeTakesConstraintsIntoAccount(c *gc.C) {
 	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
 	constraints := constraints.Value{Arch: stringp("arm"), Mem: uint64p(1024)}
 
-	_, _, err := env.acquireNode(constraints, tools.List{fakeTools})
+	_, _, err := env.acquireNode(constraints, "", tools.List{fakeTools})
 
 	c.Check(err, gc.IsNil)
 	requestValues := suite.testMAASObject.TestServer.NodeOperationRequestValues()
@@ -306,7 +307,7 @@ func (suite *environSuite) TestAcquireNodePassedAgentName(c *gc.C) {
 	env := suite.makeEnviron()
 	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
 
-	_, _, err := env.acquireNode(constraints.Value{}, tools.List{fakeTools})
+	_, _, err := env.acquireNode(constraints.Value{}, "", tools.List{fakeTools})
 
 	c.Check(err, gc.IsNil)
 	requestValues := suite.testMAASObject.TestServer.NodeOperationRequestValues()
@@ -315,6 +316,26 @@ func (suite *environSuite) TestAcquireNodePassedAgentName(c *gc.C) {
 	c.Assert(nodeRequestValues[0].Get("agent_name"), gc.Equals, exampleAgentName)
 }
 
+func (suite *environSuite) TestAcquireNodeWithPlacement(c *gc.C) {
+	stor := NewStorage(suite.makeEnviron())
+	fakeTools := envtesting.MustUploadFakeToolsVersions(stor, version.Current)[0]
+	env := suite.makeEnviron()
+	suite.testMAASObject.TestServer.NewNode(`{"system_id": "node0", "hostname": "host0"}`)
+
+	_, _, err := env.acquireNode(constraints.Value{}, "host0", tools.List{fakeTools})
+
+	c.Check(err, gc.IsNil)
+	requestValues := suite.testMAASObject.TestServer.NodeOperationRequestValues()
+	nodeRequestValues, found := requestValues["node0"]
+	c.Assert(found, gc.Equals, true)
+	c.Assert(nodeRequestValues[0].Get("name"), gc.Equals, "host0")
+}
+
+func (*environSuite) TestPlacementParams(c *gc.C) {
+	c.Check(placementParams("zone=rack1"), gc.DeepEquals, url.Values{"zone": {"rack1"}})
+	c.Check(placementParams("host0.maas"), gc.DeepEquals, url.Values{"name": {"host0.maas"}})
+}
+
 func (*environSuite) TestConvertConstraints(c *gc.C) {
 	var testValues = []struct {
 		constraints    constraints.Value
@@ -328,6 +349,8 @@ func (*environSuite) TestConvertConstraints(c *gc.C) {
 		// RootDisk is ignored.
 		{constraints.Value{RootDisk: uint64p(8192)}, url.Values{}},
 		{constraints.Value{Tags: &[]string{"foo", "bar"}}, url.Values{"tags": {"foo,bar"}}},
+		{constraints.Value{Networks: &[]string{"db", "^dmz", "storage"}}, url.Values{"networks": {"db,storage"}, "not_networks": {"dmz"}}},
+		{constraints.Value{Networks: &[]string{"^dmz"}}, url.Values{"not_networks": {"dmz"}}},
 		{constraints.Value{Arch: stringp("arm"), CpuCores: uint64p(4), Mem: uint64p(1024), CpuPower: uint64p(1024), RootDisk: uint64p(8192), Tags: &[]string{"foo", "bar"}}, url.Values{"arch": {"arm"}, "cpu_count": {"4"}, "mem": {"1024"}, "tags": {"foo,bar"}}},
 	}
 	for _, test := range testValues {
@@ -335,6 +358,28 @@ func (*environSuite) TestConvertConstraints(c *gc.C) {
 	}
 }
 
+func (*environSuite) TestConvertNetwork(c *gc.C) {
+	obj, err := gomaasapi.Parse(gomaasapi.Client{}, []byte(
+		`{"name": "storage", "ip": "10.0.42.0", "netmask": "255.255.255.0", "vlan_tag": 42}`))
+	c.Assert(err, gc.IsNil)
+	info, err := convertNetwork(obj)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, gc.DeepEquals, instance.NetworkInfo{
+		Name:       "storage",
+		ProviderId: "storage",
+		CIDR:       "10.0.42.0/24",
+		VLANTag:    42,
+	})
+
+	obj, err = gomaasapi.Parse(gomaasapi.Client{}, []byte(
+		`{"name": "public", "ip": "192.168.0.0", "netmask": "255.255.0.0", "vlan_tag": null}`))
+	c.Assert(err, gc.IsNil)
+	info, err = convertNetwork(obj)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.CIDR, gc.Equals, "192.168.0.0/16")
+	c.Assert(info.IsVLAN(), jc.IsFalse)
+}
+
 func (suite *environSuite) getInstance(systemId string) *maasInstance {
 	input := `{"system_id": "` + systemId + `"}`
 	node := suite.testMAASObject.TestServer.NewNode(input)
@@ -491,3 +536,10 @@ func (suite *environSuite) TestGetToolsMetadataSources(c *gc.C) {
 	c.Assert(len(sources), gc.Equals, 1)
 	assertSourceContents(c, sources[0], "filename", data)
 }
+
+func (*environSuite) TestIsTransientMAASError(c *gc.C) {
+	c.Check(isTransientMAASError(gomaasapi.ServerError{StatusCode: http.StatusConflict}), gc.Equals, true)
+	c.Check(isTransientMAASError(gomaasapi.ServerError{StatusCode: http.StatusServiceUnavailable}), gc.Equals, true)
+	c.Check(isTransientMAASError(gomaasapi.ServerError{StatusCode: http.StatusBadRequest}), gc.Equals, false)
+	c.Check(isTransientMAASError(fmt.Errorf("no matching node is available")), gc.Equals, false)
+}
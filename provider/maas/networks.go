@@ -0,0 +1,164 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package maas
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"launchpad.net/gomaasapi"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+)
+
+var _ environs.Networking = (*maasEnviron)(nil)
+
+// InstanceNetworks is specified in the environs.Networking interface.
+// MAAS reports the networks a node is connected to, and which of the
+// node's MAC addresses are connected to each network. Interfaces are
+// named after the position of their MAC address on the node, with
+// virtual interfaces added for VLANs.
+func (environ *maasEnviron) InstanceNetworks(inst instance.Instance) ([]instance.NetworkInfo, []instance.InterfaceInfo, error) {
+	maasInst, ok := inst.(*maasInstance)
+	if !ok {
+		return nil, nil, fmt.Errorf("instance %s is not a MAAS instance", inst.Id())
+	}
+	macs, err := maasInst.macAddresses()
+	if err != nil {
+		return nil, nil, err
+	}
+	networks, err := environ.nodeNetworks(extractSystemId(inst.Id()))
+	if err != nil {
+		return nil, nil, err
+	}
+	var interfaces []instance.InterfaceInfo
+	for _, network := range networks {
+		connected, err := environ.connectedMACs(network.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, mac := range macs {
+			if !connected[mac] {
+				continue
+			}
+			iface := instance.InterfaceInfo{
+				MACAddress:    mac,
+				InterfaceName: fmt.Sprintf("eth%d", i),
+				NetworkName:   network.Name,
+			}
+			if network.IsVLAN() {
+				iface.InterfaceName = fmt.Sprintf("%s.%d", iface.InterfaceName, network.VLANTag)
+				iface.IsVirtual = true
+			}
+			interfaces = append(interfaces, iface)
+		}
+	}
+	return networks, interfaces, nil
+}
+
+// nodeNetworks returns the networks the node with the given system id
+// is connected to.
+func (environ *maasEnviron) nodeNetworks(systemId string) ([]instance.NetworkInfo, error) {
+	client := environ.getMAASClient().GetSubObject("networks")
+	result, err := client.CallGet("list", url.Values{"node": {systemId}})
+	if err != nil {
+		return nil, err
+	}
+	objs, err := result.GetArray()
+	if err != nil {
+		return nil, err
+	}
+	networks := make([]instance.NetworkInfo, len(objs))
+	for i, obj := range objs {
+		if networks[i], err = convertNetwork(obj); err != nil {
+			return nil, err
+		}
+	}
+	return networks, nil
+}
+
+// convertNetwork converts a network object returned by the MAAS API.
+func convertNetwork(obj gomaasapi.JSONObject) (instance.NetworkInfo, error) {
+	var info instance.NetworkInfo
+	fields, err := obj.GetMap()
+	if err != nil {
+		return info, err
+	}
+	if info.Name, err = fields["name"].GetString(); err != nil {
+		return info, fmt.Errorf("cannot get network name: %v", err)
+	}
+	info.ProviderId = info.Name
+	ip, err := fields["ip"].GetString()
+	if err != nil {
+		return info, fmt.Errorf("cannot get IP of network %q: %v", info.Name, err)
+	}
+	netmask, err := fields["netmask"].GetString()
+	if err != nil {
+		return info, fmt.Errorf("cannot get netmask of network %q: %v", info.Name, err)
+	}
+	mask := net.IPMask(net.ParseIP(netmask).To4())
+	ones, _ := mask.Size()
+	info.CIDR = fmt.Sprintf("%s/%d", ip, ones)
+	if tag, ok := fields["vlan_tag"]; ok && !tag.IsNil() {
+		vlan, err := tag.GetFloat64()
+		if err != nil {
+			return info, fmt.Errorf("cannot get VLAN tag of network %q: %v", info.Name, err)
+		}
+		info.VLANTag = int(vlan)
+	}
+	return info, nil
+}
+
+// connectedMACs returns the set of MAC addresses connected to the
+// named network.
+func (environ *maasEnviron) connectedMACs(network string) (map[string]bool, error) {
+	client := environ.getMAASClient().GetSubObject("networks").GetSubObject(network)
+	result, err := client.CallGet("list_connected_macs", nil)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := result.GetArray()
+	if err != nil {
+		return nil, err
+	}
+	macs := make(map[string]bool)
+	for _, obj := range objs {
+		fields, err := obj.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		mac, err := fields["mac_address"].GetString()
+		if err != nil {
+			return nil, err
+		}
+		macs[mac] = true
+	}
+	return macs, nil
+}
+
+// macAddresses returns the MAC addresses of the node, in the order
+// MAAS reports them.
+func (mi *maasInstance) macAddresses() ([]string, error) {
+	macArray := mi.maasObject.GetMap()["macaddress_set"]
+	if macArray.IsNil() {
+		return nil, nil
+	}
+	objs, err := macArray.GetArray()
+	if err != nil {
+		return nil, err
+	}
+	macs := make([]string, len(objs))
+	for i, obj := range objs {
+		fields, err := obj.GetMap()
+		if err != nil {
+			return nil, err
+		}
+		if macs[i], err = fields["mac_address"].GetString(); err != nil {
+			return nil, err
+		}
+	}
+	return macs, nil
+}
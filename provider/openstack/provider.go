@@ -718,7 +718,11 @@ func (e *environ) StartInstance(cons constraints.Value, possibleTools tools.List
 		}
 	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot run instance: %v", err)
+		err = fmt.Errorf("cannot run instance: %v", err)
+		if isTransientNovaError(err) {
+			err = environs.NewTransientError(err)
+		}
+		return nil, nil, err
 	}
 	detail, err := e.nova().GetServer(server.Id)
 	if err != nil {
@@ -744,6 +748,30 @@ func (e *environ) StartInstance(cons constraints.Value, possibleTools tools.List
 	return inst, inst.hardwareCharacteristics(), nil
 }
 
+// transientNovaErrors holds fragments of the messages of Nova errors
+// that may go away if the request is repeated later. Nova reports
+// both exceeded quotas and exceeded rate limits as overLimit faults,
+// and goose gives up with the last message after retrying requests
+// that were rate limited.
+var transientNovaErrors = []string{
+	"overLimit",
+	"Quota exceeded",
+	"Rate limit exceeded",
+	"Maximum number of attempts",
+}
+
+// isTransientNovaError reports whether err is a Nova error that may go
+// away if the request is repeated later.
+func isTransientNovaError(err error) bool {
+	msg := err.Error()
+	for _, fragment := range transientNovaErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *environ) StopInstances(insts []instance.Instance) error {
 	ids := make([]instance.Id, len(insts))
 	for i, inst := range insts {
@@ -5,6 +5,7 @@ package openstack_test
 
 import (
 	"flag"
+	"fmt"
 	"testing"
 
 	gc "launchpad.net/gocheck"
@@ -127,3 +128,28 @@ func (t *localTests) TestGetServerAddresses(c *gc.C) {
 		c.Assert(addr, gc.Equals, t.expected)
 	}
 }
+
+var transientNovaErrorTests = []struct {
+	message   string
+	transient bool
+}{{
+	message:   `request returned unexpected status: 413; error info: {"overLimit": {"message": "Quota exceeded for instances"}}`,
+	transient: true,
+}, {
+	message:   `Rate limit exceeded for POST /servers`,
+	transient: true,
+}, {
+	message:   `Maximum number of attempts (3) reached sending request to "https://nova/servers"`,
+	transient: true,
+}, {
+	message:   `request returned unexpected status: 400; error info: {"badRequest": {"message": "Invalid flavorRef"}}`,
+	transient: false,
+}}
+
+func (t *localTests) TestIsTransientNovaError(c *gc.C) {
+	for i, test := range transientNovaErrorTests {
+		c.Logf("test %d: %s", i, test.message)
+		err := fmt.Errorf("cannot run instance: %s", test.message)
+		c.Check(openstack.IsTransientNovaError(err), gc.Equals, test.transient)
+	}
+}
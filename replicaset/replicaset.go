@@ -0,0 +1,222 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The replicaset package provides operations on the MongoDB replica
+// set that holds the juju state database.
+package replicaset
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/utils"
+)
+
+// Name is the name of the replica set
+// spanning the juju state servers.
+const Name = "juju"
+
+// MachineIdTag is the name of the member tag that holds
+// the id of the juju machine running the member.
+const MachineIdTag = "juju-machine-id"
+
+// initiateAttempt governs how long Initiate waits for a newly
+// initiated replica set to elect a primary.
+var initiateAttempt = utils.AttemptStrategy{
+	Total: time.Minute,
+	Delay: time.Second,
+}
+
+// Member holds configuration information for a replica set member.
+//
+// See http://docs.mongodb.org/manual/reference/replica-configuration/
+// for more details.
+type Member struct {
+	// Id is a unique id for a member in a set.
+	Id int `bson:"_id"`
+
+	// Address holds the network address of the member,
+	// in the form hostname:port.
+	Address string `bson:"host"`
+
+	// Arbiter holds whether the member is an arbiter only.
+	// This value is optional; it defaults to false.
+	Arbiter *bool `bson:"arbiterOnly,omitempty"`
+
+	// Hidden holds whether the member is hidden from clients.
+	// This value is optional; it defaults to false.
+	Hidden *bool `bson:"hidden,omitempty"`
+
+	// Priority determines eligibility of a member to become primary.
+	// This value is optional; it defaults to 1.
+	Priority *float64 `bson:"priority,omitempty"`
+
+	// Tags store additional information about a replica member,
+	// often used for customizing read preferences and write concern.
+	Tags map[string]string `bson:"tags,omitempty"`
+
+	// Votes controls the number of votes a server has in a replica
+	// set election. This value is optional; it defaults to 1.
+	Votes *int `bson:"votes,omitempty"`
+}
+
+// Config is the document stored in mongodb that defines the servers
+// in the replica set.
+type Config struct {
+	Name    string   `bson:"_id"`
+	Version int      `bson:"version"`
+	Members []Member `bson:"members"`
+}
+
+// Initiate sets up a replica set with the given name, whose single
+// member is the server at the given address with the given tags, and
+// waits for that server to become primary. The session must be connected directly to
+// that server, which must have been started with the --replSet option
+// and must not already belong to a replica set.
+func Initiate(session *mgo.Session, address, name string, tags map[string]string) error {
+	session = session.Clone()
+	defer session.Close()
+	session.SetMode(mgo.Monotonic, true)
+	cfg := Config{
+		Name:    name,
+		Version: 1,
+		Members: []Member{{Id: 1, Address: address, Tags: tags}},
+	}
+	if err := session.Run(bson.D{{"replSetInitiate", cfg}}, nil); err != nil {
+		return fmt.Errorf("cannot initiate replica set: %v", err)
+	}
+	var err error
+	for a := initiateAttempt.Start(); a.Next(); {
+		var result *IsMasterResults
+		if result, err = IsMaster(session); err == nil && result.IsMaster {
+			return nil
+		}
+		// The server may close the connection as it changes state.
+		session.Refresh()
+	}
+	if err == nil {
+		err = fmt.Errorf("no primary elected")
+	}
+	return fmt.Errorf("replica set %q did not become ready: %v", name, err)
+}
+
+// CurrentConfig returns the current replica set configuration.
+func CurrentConfig(session *mgo.Session) (*Config, error) {
+	cfg := &Config{}
+	err := session.DB("local").C("system.replset").Find(nil).One(cfg)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("replica set configuration")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get replica set configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+// CurrentMembers returns the current members of the replica set.
+func CurrentMembers(session *mgo.Session) ([]Member, error) {
+	cfg, err := CurrentConfig(session)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Members, nil
+}
+
+// Set changes the current set of replica set members. The session
+// must be connected to the primary. Members that are not
+// being added or removed should keep the same Id and Address.
+func Set(session *mgo.Session, members []Member) error {
+	cfg, err := CurrentConfig(session)
+	if err != nil {
+		return err
+	}
+	cfg.Version++
+	cfg.Members = members
+	err = session.Run(bson.D{{"replSetReconfig", cfg}}, nil)
+	if err == io.EOF {
+		// The primary closes all connections when the
+		// configuration changes; this is not a failure.
+		session.Refresh()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set replica set members: %v", err)
+	}
+	return nil
+}
+
+// Add adds the given members to the replica set. Each new member is
+// given an id that is not already in use.
+func Add(session *mgo.Session, members ...Member) error {
+	current, err := CurrentMembers(session)
+	if err != nil {
+		return err
+	}
+	max := 0
+	for _, m := range current {
+		if m.Id > max {
+			max = m.Id
+		}
+	}
+	for _, m := range members {
+		max++
+		m.Id = max
+		current = append(current, m)
+	}
+	return Set(session, current)
+}
+
+// Remove removes the members with the given addresses from the
+// replica set.
+func Remove(session *mgo.Session, addrs ...string) error {
+	current, err := CurrentMembers(session)
+	if err != nil {
+		return err
+	}
+	remove := make(map[string]bool)
+	for _, addr := range addrs {
+		remove[addr] = true
+	}
+	var members []Member
+	for _, m := range current {
+		if !remove[m.Address] {
+			members = append(members, m)
+		}
+	}
+	return Set(session, members)
+}
+
+// IsMasterResults holds information about the replica set as seen
+// by the server a session is connected to.
+//
+// See http://docs.mongodb.org/manual/reference/command/isMaster/
+// for more details.
+type IsMasterResults struct {
+	// The following fields hold information about the specific mongodb
+	// node.
+	IsMaster  bool   `bson:"ismaster"`
+	Secondary bool   `bson:"secondary"`
+	Arbiter   bool   `bson:"arbiterOnly"`
+	Address   string `bson:"me"`
+
+	// The following fields hold information about the replica set.
+	ReplicaSetName string   `bson:"setName"`
+	Addresses      []string `bson:"hosts"`
+	Arbiters       []string `bson:"arbiters"`
+	PrimaryAddress string   `bson:"primary"`
+}
+
+// IsMaster returns information about the configuration of the server
+// the session is connected to.
+func IsMaster(session *mgo.Session) (*IsMasterResults, error) {
+	results := &IsMasterResults{}
+	if err := session.Run("isMaster", results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package replicaset_test
+
+import (
+	"fmt"
+	"os/exec"
+	stdtesting "testing"
+	"time"
+
+	"labix.org/v2/mgo"
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/replicaset"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+const rsName = "juju"
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type replicaSetSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&replicaSetSuite{})
+
+// mongoInstance is a mongod process started with the --replSet option.
+type mongoInstance struct {
+	cmd  *exec.Cmd
+	addr string
+}
+
+func startMongo(c *gc.C) *mongoInstance {
+	port := coretesting.FindTCPPort()
+	cmd := exec.Command("mongod",
+		"--dbpath", c.MkDir(),
+		"--bind_ip", "localhost",
+		"--port", fmt.Sprint(port),
+		"--replSet", rsName,
+		"--nssize", "1",
+		"--noprealloc",
+		"--smallfiles",
+		"--nojournal",
+		"--nounixsocket",
+	)
+	err := cmd.Start()
+	c.Assert(err, gc.IsNil)
+	return &mongoInstance{cmd: cmd, addr: fmt.Sprintf("localhost:%d", port)}
+}
+
+func (inst *mongoInstance) destroy() {
+	inst.cmd.Process.Kill()
+	inst.cmd.Wait()
+}
+
+func dialDirect(c *gc.C, addr string) *mgo.Session {
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:   []string{addr},
+		Direct:  true,
+		Timeout: coretesting.LongWait,
+	})
+	c.Assert(err, gc.IsNil)
+	session.SetMode(mgo.Monotonic, true)
+	return session
+}
+
+func (s *replicaSetSuite) TestInitiate(c *gc.C) {
+	inst := startMongo(c)
+	defer inst.destroy()
+	session := dialDirect(c, inst.addr)
+	defer session.Close()
+
+	_, err := replicaset.CurrentConfig(session)
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	err = replicaset.Initiate(session, inst.addr, rsName, map[string]string{"foo": "bar"})
+	c.Assert(err, gc.IsNil)
+
+	cfg, err := replicaset.CurrentConfig(session)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Name, gc.Equals, rsName)
+	c.Assert(cfg.Version, gc.Equals, 1)
+	c.Assert(cfg.Members, gc.DeepEquals, []replicaset.Member{{
+		Id:      1,
+		Address: inst.addr,
+		Tags:    map[string]string{"foo": "bar"},
+	}})
+
+	result, err := replicaset.IsMaster(session)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.IsMaster, gc.Equals, true)
+	c.Assert(result.ReplicaSetName, gc.Equals, rsName)
+	c.Assert(result.Address, gc.Equals, inst.addr)
+	c.Assert(result.Addresses, gc.DeepEquals, []string{inst.addr})
+}
+
+func (s *replicaSetSuite) TestAddRemoveSet(c *gc.C) {
+	insts := []*mongoInstance{startMongo(c), startMongo(c), startMongo(c)}
+	for _, inst := range insts {
+		defer inst.destroy()
+	}
+	session := dialDirect(c, insts[0].addr)
+	defer session.Close()
+	err := replicaset.Initiate(session, insts[0].addr, rsName, nil)
+	c.Assert(err, gc.IsNil)
+
+	err = replicaset.Add(session,
+		replicaset.Member{Address: insts[1].addr},
+		replicaset.Member{Address: insts[2].addr, Tags: map[string]string{"juju-machine-id": "2"}},
+	)
+	c.Assert(err, gc.IsNil)
+	members := waitForMembers(c, session, 3)
+	c.Assert(members, gc.DeepEquals, []replicaset.Member{
+		{Id: 1, Address: insts[0].addr},
+		{Id: 2, Address: insts[1].addr},
+		{Id: 3, Address: insts[2].addr, Tags: map[string]string{"juju-machine-id": "2"}},
+	})
+
+	err = replicaset.Remove(session, insts[1].addr)
+	c.Assert(err, gc.IsNil)
+	members = waitForMembers(c, session, 2)
+	c.Assert(members, gc.DeepEquals, []replicaset.Member{
+		{Id: 1, Address: insts[0].addr},
+		{Id: 3, Address: insts[2].addr, Tags: map[string]string{"juju-machine-id": "2"}},
+	})
+
+	err = replicaset.Set(session, members[:1])
+	c.Assert(err, gc.IsNil)
+	members = waitForMembers(c, session, 1)
+	c.Assert(members, gc.DeepEquals, []replicaset.Member{{Id: 1, Address: insts[0].addr}})
+
+	cfg, err := replicaset.CurrentConfig(session)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cfg.Version, gc.Equals, 4)
+}
+
+// waitForMembers waits until the replica set configuration has n
+// members, and returns them.
+func waitForMembers(c *gc.C, session *mgo.Session, n int) []replicaset.Member {
+	timeout := time.After(coretesting.LongWait)
+	for {
+		session.Refresh()
+		members, err := replicaset.CurrentMembers(session)
+		if err == nil && len(members) == n {
+			return members
+		}
+		select {
+		case <-timeout:
+			c.Fatalf("timed out waiting for %d members; got %v (%v)", n, members, err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
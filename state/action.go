@@ -0,0 +1,292 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils"
+)
+
+// actionMarker separates the name of the unit an action is queued on
+// from the sequence number in the action's id.
+const actionMarker = "_a_"
+
+// actionPrefix returns the prefix shared by the ids of all the actions
+// queued on the named unit.
+func actionPrefix(unitName string) string {
+	return unitName + actionMarker
+}
+
+// actionDoc represents a charm action queued for execution on a unit,
+// together with its outcome once the unit has run it.
+type actionDoc struct {
+	Id        string `bson:"_id"`
+	Unit      string
+	Name      string
+	Params    map[string]interface{}
+	Status    params.ActionStatus
+	Message   string
+	Results   map[string]interface{}
+	Enqueued  time.Time
+	Started   time.Time
+	Completed time.Time
+}
+
+// Action represents a charm action queued for execution on a unit.
+type Action struct {
+	st  *State
+	doc actionDoc
+}
+
+func newAction(st *State, doc *actionDoc) *Action {
+	return &Action{st: st, doc: *doc}
+}
+
+// Id returns the unique identifier of the action.
+func (a *Action) Id() string {
+	return a.doc.Id
+}
+
+// UnitName returns the name of the unit the action is queued on.
+func (a *Action) UnitName() string {
+	return a.doc.Unit
+}
+
+// Name returns the name of the charm action to run.
+func (a *Action) Name() string {
+	return a.doc.Name
+}
+
+// Params returns the validated parameters the action will be run with.
+func (a *Action) Params() map[string]interface{} {
+	return a.doc.Params
+}
+
+// Status returns the progress of the action.
+func (a *Action) Status() params.ActionStatus {
+	return a.doc.Status
+}
+
+// Message returns the message recorded when the action finished, if any.
+func (a *Action) Message() string {
+	return a.doc.Message
+}
+
+// Results returns the results recorded when the action finished.
+func (a *Action) Results() map[string]interface{} {
+	return a.doc.Results
+}
+
+// Enqueued returns the time the action was queued.
+func (a *Action) Enqueued() time.Time {
+	return a.doc.Enqueued
+}
+
+// Started returns the time the unit started running the action, or the
+// zero time if it has not yet started.
+func (a *Action) Started() time.Time {
+	return a.doc.Started
+}
+
+// Completed returns the time the action finished, or the zero time if it
+// has not yet finished.
+func (a *Action) Completed() time.Time {
+	return a.doc.Completed
+}
+
+// String returns a human readable description of the action.
+func (a *Action) String() string {
+	return fmt.Sprintf("action %s (%q on %q)", a.doc.Id, a.doc.Name, a.doc.Unit)
+}
+
+// Refresh refreshes the contents of the action from the underlying state.
+func (a *Action) Refresh() error {
+	doc := actionDoc{}
+	err := a.st.actions.FindId(a.doc.Id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("action %q", a.doc.Id)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot refresh action %q: %v", a.doc.Id, err)
+	}
+	a.doc = doc
+	return nil
+}
+
+// Begin marks the action as running. It fails if the action is not
+// pending.
+func (a *Action) Begin() (err error) {
+	defer utils.ErrorContextf(&err, "cannot begin %v", a)
+	started := time.Now()
+	ops := []txn.Op{{
+		C:      a.st.actions.Name,
+		Id:     a.doc.Id,
+		Assert: D{{"status", params.ActionPending}},
+		Update: D{{"$set", D{
+			{"status", params.ActionRunning},
+			{"started", started},
+		}}},
+	}}
+	if err := a.st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("action is not pending")
+	} else if err != nil {
+		return err
+	}
+	a.doc.Status = params.ActionRunning
+	a.doc.Started = started
+	return nil
+}
+
+// Finish records the outcome of the action, which must be either
+// ActionCompleted or ActionFailed. It fails if the action has already
+// finished.
+func (a *Action) Finish(status params.ActionStatus, results map[string]interface{}, message string) (err error) {
+	defer utils.ErrorContextf(&err, "cannot finish %v", a)
+	switch status {
+	case params.ActionCompleted, params.ActionFailed:
+	default:
+		return fmt.Errorf("invalid final status %q", status)
+	}
+	completed := time.Now()
+	ops := []txn.Op{{
+		C:  a.st.actions.Name,
+		Id: a.doc.Id,
+		Assert: D{{"status", D{{"$in", []params.ActionStatus{
+			params.ActionPending, params.ActionRunning,
+		}}}}},
+		Update: D{{"$set", D{
+			{"status", status},
+			{"results", results},
+			{"message", message},
+			{"completed", completed},
+		}}},
+	}}
+	if err := a.st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("action has already finished")
+	} else if err != nil {
+		return err
+	}
+	a.doc.Status = status
+	a.doc.Results = results
+	a.doc.Message = message
+	a.doc.Completed = completed
+	return nil
+}
+
+// Action returns the action with the given id.
+func (st *State) Action(id string) (*Action, error) {
+	doc := &actionDoc{}
+	err := st.actions.FindId(id).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("action %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get action %q: %v", id, err)
+	}
+	return newAction(st, doc), nil
+}
+
+// AddAction queues the named charm action for execution on the unit. The
+// supplied parameters are validated against the action's specification
+// in the charm the unit is running or, if the unit has not yet installed
+// a charm, the charm of its service.
+func (u *Unit) AddAction(name string, values map[string]interface{}) (action *Action, err error) {
+	defer utils.ErrorContextf(&err, "cannot add action %q to unit %q", name, u)
+	ch, err := u.actionsCharm()
+	if err != nil {
+		return nil, err
+	}
+	spec, ok := ch.Meta().Actions[name]
+	if !ok {
+		return nil, fmt.Errorf("action not defined by charm %q", ch.URL())
+	}
+	values, err = spec.ValidateParams(values)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := u.st.sequence("action")
+	if err != nil {
+		return nil, err
+	}
+	doc := &actionDoc{
+		Id:       actionPrefix(u.doc.Name) + strconv.Itoa(seq),
+		Unit:     u.doc.Name,
+		Name:     name,
+		Params:   values,
+		Status:   params.ActionPending,
+		Enqueued: time.Now(),
+	}
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+	}, {
+		C:      u.st.actions.Name,
+		Id:     doc.Id,
+		Assert: txn.DocMissing,
+		Insert: doc,
+	}}
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		return nil, errDead
+	} else if err != nil {
+		return nil, err
+	}
+	return newAction(u.st, doc), nil
+}
+
+// actionsCharm returns the charm whose actions may be queued on the unit.
+func (u *Unit) actionsCharm() (*Charm, error) {
+	if u.doc.CharmURL != nil {
+		return u.st.Charm(u.doc.CharmURL)
+	}
+	svc, err := u.Service()
+	if err != nil {
+		return nil, err
+	}
+	ch, _, err := svc.Charm()
+	return ch, err
+}
+
+// Actions returns all the actions queued on the unit, in the order they
+// were queued.
+func (u *Unit) Actions() ([]*Action, error) {
+	var docs []actionDoc
+	err := u.st.actions.Find(D{{"unit", u.doc.Name}}).Sort("enqueued").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get actions for unit %q: %v", u, err)
+	}
+	actions := make([]*Action, len(docs))
+	for i := range docs {
+		actions[i] = newAction(u.st, &docs[i])
+	}
+	return actions, nil
+}
+
+// actionsCleanupOps returns the operations that queue the removal of the
+// actions queued on the named unit. No cleanup is queued if there are no
+// such actions.
+func (st *State) actionsCleanupOps(unitName string) []txn.Op {
+	count, err := st.actions.Find(D{{"unit", unitName}}).Limit(1).Count()
+	if err == nil && count == 0 {
+		return nil
+	}
+	return []txn.Op{st.newCleanupOp("actions", actionPrefix(unitName))}
+}
+
+// cleanupActions removes the actions whose ids have the given prefix.
+func (st *State) cleanupActions(prefix string) error {
+	sel := D{{"_id", D{{"$regex", "^" + prefix}}}}
+	if _, err := st.actions.RemoveAll(sel); err != nil {
+		return fmt.Errorf("cannot remove actions marked for cleanup: %v", err)
+	}
+	return nil
+}
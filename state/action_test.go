@@ -0,0 +1,164 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type ActionSuite struct {
+	ConnSuite
+	unit *state.Unit
+}
+
+var _ = gc.Suite(&ActionSuite{})
+
+func (s *ActionSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	svc, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+	s.unit, err = svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ActionSuite) TestAddAction(c *gc.C) {
+	action, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.UnitName(), gc.Equals, "dummy/0")
+	c.Assert(action.Name(), gc.Equals, "snapshot")
+	c.Assert(action.Status(), gc.Equals, params.ActionPending)
+	c.Assert(action.Params(), gc.DeepEquals, map[string]interface{}{"outfile": "foo.bz2"})
+
+	action, err = s.State.Action(action.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Name(), gc.Equals, "snapshot")
+	c.Assert(action.Params(), gc.DeepEquals, map[string]interface{}{"outfile": "foo.bz2"})
+
+	other, err := s.unit.AddAction("snapshot", map[string]interface{}{"outfile": "bar.bz2"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(other.Id(), gc.Not(gc.Equals), action.Id())
+	c.Assert(action.Id(), jc.HasPrefix, "dummy/0_a_")
+	c.Assert(other.Params(), gc.DeepEquals, map[string]interface{}{"outfile": "bar.bz2"})
+
+	actions, err := s.unit.Actions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(actions, gc.HasLen, 2)
+	c.Assert(actions[0].Id(), gc.Equals, action.Id())
+	c.Assert(actions[1].Id(), gc.Equals, other.Id())
+}
+
+func (s *ActionSuite) TestAddActionInvalid(c *gc.C) {
+	_, err := s.unit.AddAction("explode", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add action "explode" to unit "dummy/0": action not defined by charm "local:quantal/dummy-1"`)
+	_, err = s.unit.AddAction("snapshot", map[string]interface{}{"colour": "blue"})
+	c.Assert(err, gc.ErrorMatches, `cannot add action "snapshot" to unit "dummy/0": unknown parameter "colour"`)
+}
+
+func (s *ActionSuite) TestAddActionDeadUnit(c *gc.C) {
+	err := s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	_, err = s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add action "snapshot" to unit "dummy/0": not found or dead`)
+}
+
+func (s *ActionSuite) TestActionNotFound(c *gc.C) {
+	_, err := s.State.Action("42")
+	c.Assert(err, gc.ErrorMatches, `action "42" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ActionSuite) TestBeginFinish(c *gc.C) {
+	action, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	err = action.Begin()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Status(), gc.Equals, params.ActionRunning)
+	err = action.Begin()
+	c.Assert(err, gc.ErrorMatches, `cannot begin action .*: action is not pending`)
+
+	results := map[string]interface{}{"size": "42M"}
+	err = action.Finish(params.ActionCompleted, results, "")
+	c.Assert(err, gc.IsNil)
+	err = action.Finish(params.ActionFailed, nil, "too late")
+	c.Assert(err, gc.ErrorMatches, `cannot finish action .*: action has already finished`)
+
+	err = action.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Status(), gc.Equals, params.ActionCompleted)
+	c.Assert(action.Results(), gc.DeepEquals, results)
+	c.Assert(action.Started().IsZero(), jc.IsFalse)
+	c.Assert(action.Completed().IsZero(), jc.IsFalse)
+}
+
+func (s *ActionSuite) TestFinishInvalidStatus(c *gc.C) {
+	action, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	err = action.Finish(params.ActionRunning, nil, "")
+	c.Assert(err, gc.ErrorMatches, `cannot finish action .*: invalid final status "running"`)
+}
+
+func (s *ActionSuite) TestWatchActions(c *gc.C) {
+	first, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	w := s.unit.WatchActions()
+	defer testing.AssertStop(c, w)
+	wc := testing.NewStringsWatcherC(c, s.State, w)
+	wc.AssertChange(first.Id())
+	wc.AssertNoChange()
+
+	// Starting and finishing an action is not reported.
+	err = first.Begin()
+	c.Assert(err, gc.IsNil)
+	err = first.Finish(params.ActionFailed, nil, "oops")
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	// A newly queued action is reported.
+	second, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(second.Id())
+	wc.AssertNoChange()
+
+	// Actions on other units are not.
+	svc, err := s.unit.Service()
+	c.Assert(err, gc.IsNil)
+	other, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	_, err = other.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	testing.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *ActionSuite) TestRemoveUnitRemovesActions(c *gc.C) {
+	svc, err := s.unit.Service()
+	c.Assert(err, gc.IsNil)
+	other, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	action, err := s.unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	otherAction, err := other.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	err = s.unit.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.Action(action.Id())
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	_, err = s.State.Action(otherAction.Id())
+	c.Assert(err, gc.IsNil)
+	dirty, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dirty, jc.IsFalse)
+}
@@ -6,6 +6,7 @@ package api
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"time"
 
 	"code.google.com/p/go.net/websocket"
@@ -29,6 +30,15 @@ type State struct {
 	// authTag holds the authenticated entity's tag after login.
 	authTag string
 
+	// password holds the password used to log in; it is needed to
+	// authenticate requests made outside the RPC connection.
+	password string
+
+	// addr holds the address of the connected API server, and
+	// tlsConfig the configuration used to connect to it.
+	addr      string
+	tlsConfig *tls.Config
+
 	// broken is a channel that gets closed when the connection is
 	// broken.
 	broken chan struct{}
@@ -84,13 +94,12 @@ func DefaultDialOpts() DialOpts {
 	}
 }
 
+// Open establishes a connection to the API server. Each of the
+// addresses in info.Addrs is tried in turn until one succeeds; if none
+// do, the whole set is retried until opts.Timeout has elapsed.
 func Open(info *Info, opts DialOpts) (*State, error) {
-	// TODO Select a random address from info.Addrs
-	// and only fail when we've tried all the addresses.
-	// TODO what does "origin" really mean, and is localhost always ok?
-	cfg, err := websocket.NewConfig("wss://"+info.Addrs[0]+"/", "http://localhost/")
-	if err != nil {
-		return nil, err
+	if len(info.Addrs) == 0 {
+		return nil, fmt.Errorf("no API addresses to connect to")
 	}
 	pool := x509.NewCertPool()
 	xcert, err := cert.ParseCert(info.CACert)
@@ -98,7 +107,7 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		return nil, err
 	}
 	pool.AddCert(xcert)
-	cfg.TlsConfig = &tls.Config{
+	tlsConfig := &tls.Config{
 		RootCAs:    pool,
 		ServerName: "anything",
 	}
@@ -108,12 +117,10 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		Delay: opts.RetryDelay,
 	}
 	for a := openAttempt.Start(); a.Next(); {
-		log.Infof("state/api: dialing %q", cfg.Location)
-		conn, err = websocket.DialConfig(cfg)
+		conn, err = dialAny(info.Addrs, tlsConfig)
 		if err == nil {
 			break
 		}
-		log.Errorf("state/api: %v", err)
 	}
 	if err != nil {
 		return nil, err
@@ -123,8 +130,10 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	client := rpc.NewConn(jsoncodec.NewWebsocket(conn), nil)
 	client.Start()
 	st := &State{
-		client: client,
-		conn:   conn,
+		client:    client,
+		conn:      conn,
+		addr:      conn.Config().Location.Host,
+		tlsConfig: tlsConfig,
 	}
 	if info.Tag != "" || info.Password != "" {
 		if err := st.Login(info.Tag, info.Password, info.Nonce); err != nil {
@@ -137,6 +146,28 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 	return st, nil
 }
 
+// dialAny tries to connect to each of the given addresses in turn,
+// and returns the first connection made.
+func dialAny(addrs []string, tlsConfig *tls.Config) (*websocket.Conn, error) {
+	var err error
+	for _, addr := range addrs {
+		// TODO what does "origin" really mean, and is localhost always ok?
+		var cfg *websocket.Config
+		cfg, err = websocket.NewConfig("wss://"+addr+"/", "http://localhost/")
+		if err != nil {
+			return nil, err
+		}
+		cfg.TlsConfig = tlsConfig
+		log.Infof("state/api: dialing %q", cfg.Location)
+		var conn *websocket.Conn
+		if conn, err = websocket.DialConfig(cfg); err == nil {
+			return conn, nil
+		}
+		log.Errorf("state/api: %v", err)
+	}
+	return nil, err
+}
+
 func (s *State) heartbeatMonitor() {
 	for {
 		if err := s.Ping(); err != nil {
@@ -0,0 +1,18 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// AuditLog returns the changes to the environment requested through
+// the API and selected by the filter, oldest first.
+func (c *Client) AuditLog(filter params.AuditLogFilter) ([]params.AuditLogEntry, error) {
+	var results params.AuditLogResults
+	if err := c.st.Call("Client", "", "AuditLog", filter, &results); err != nil {
+		return nil, err
+	}
+	return results.Entries, nil
+}
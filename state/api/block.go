@@ -0,0 +1,31 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// SwitchBlockOn switches on the block of the given type, which
+// prevents the operations of that type until it is switched off.
+// The message is returned in the errors of the blocked operations.
+func (c *Client) SwitchBlockOn(blockType, message string) error {
+	args := params.BlockSwitchParams{Type: blockType, Message: message}
+	return c.st.Call("Client", "", "SwitchBlockOn", args, nil)
+}
+
+// SwitchBlockOff switches off the block of the given type.
+func (c *Client) SwitchBlockOff(blockType string) error {
+	args := params.BlockSwitchParams{Type: blockType}
+	return c.st.Call("Client", "", "SwitchBlockOff", args, nil)
+}
+
+// ListBlocks returns the blocks switched on in the environment.
+func (c *Client) ListBlocks() ([]params.Block, error) {
+	var results params.BlockResults
+	if err := c.st.Call("Client", "", "ListBlocks", nil, &results); err != nil {
+		return nil, err
+	}
+	return results.Blocks, nil
+}
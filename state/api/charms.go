@@ -0,0 +1,87 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// AddLocalCharm uploads the given charm, whose URL must have the
+// "local" schema, to the API server, which adds it to the environment
+// unless a charm with the same URL has been added already. It returns
+// the URL the charm was added with.
+func (c *Client) AddLocalCharm(curl *charm.URL, ch charm.Charm) (*charm.URL, error) {
+	if curl.Schema != "local" {
+		return nil, fmt.Errorf("expected charm URL with local: schema, got %q", curl)
+	}
+	var archive *os.File
+	switch ch := ch.(type) {
+	case *charm.Dir:
+		var err error
+		if archive, err = ioutil.TempFile("", "charm"); err != nil {
+			return nil, fmt.Errorf("cannot create temp file: %v", err)
+		}
+		defer os.Remove(archive.Name())
+		defer archive.Close()
+		if err := ch.BundleTo(archive); err != nil {
+			return nil, fmt.Errorf("cannot bundle charm: %v", err)
+		}
+		if _, err := archive.Seek(0, 0); err != nil {
+			return nil, err
+		}
+	case *charm.Bundle:
+		var err error
+		if archive, err = os.Open(ch.Path); err != nil {
+			return nil, fmt.Errorf("cannot read charm bundle: %v", err)
+		}
+		defer archive.Close()
+	default:
+		return nil, fmt.Errorf("unknown charm type %T", ch)
+	}
+	info, err := archive.Stat()
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"series": {curl.Series}}
+	uploadURL := fmt.Sprintf("https://%s/charms?%s", c.st.addr, query.Encode())
+	req, err := http.NewRequest("POST", uploadURL, archive)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create upload request: %v", err)
+	}
+	req.SetBasicAuth(c.st.authTag, c.st.password)
+	req.Header.Set("Content-Type", "application/zip")
+	req.ContentLength = info.Size()
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: c.st.tlsConfig},
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot upload charm: %v", err)
+	}
+	defer resp.Body.Close()
+	var result params.CharmsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("cannot read charm upload response: %v", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("cannot upload charm: %s", result.Error)
+	}
+	return charm.ParseURL(result.CharmURL)
+}
+
+// AddCharm adds the charm store charm with the given URL, which must
+// include a revision, to the environment, unless it has been added
+// already.
+func (c *Client) AddCharm(curl *charm.URL) error {
+	args := params.CharmURL{URL: curl.String()}
+	return c.st.Call("Client", "", "AddCharm", args, nil)
+}
@@ -6,7 +6,10 @@ package api
 import (
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/names"
 	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
 )
 
 // Client represents the client-accessible part of the state.
@@ -63,6 +66,14 @@ func (c *Client) Resolved(unit string, retry bool) error {
 	return c.st.Call("Client", "", "Resolved", p, nil)
 }
 
+// CancelHook asks the agent of the given unit to kill the hook it is
+// running, leaving the unit in an error state. The request is
+// discarded if the agent is not running a hook.
+func (c *Client) CancelHook(unit string) error {
+	p := params.CancelHook{UnitName: unit}
+	return c.st.Call("Client", "", "CancelHook", p, nil)
+}
+
 // PublicAddress returns the public address of the specified
 // machine or unit.
 func (c *Client) PublicAddress(target string) (string, error) {
@@ -150,6 +161,20 @@ func (c *Client) DestroyMachines(machines ...string) error {
 	return c.st.Call("Client", "", "DestroyMachines", params, nil)
 }
 
+// RetryProvisioning queues the given machines, which failed to start,
+// to be provisioned again.
+func (c *Client) RetryProvisioning(machines ...string) ([]params.ErrorResult, error) {
+	args := params.Entities{Entities: make([]params.Entity, len(machines))}
+	for i, id := range machines {
+		args.Entities[i].Tag = names.MachineTag(id)
+	}
+	var results params.ErrorResults
+	if err := c.st.Call("Client", "", "RetryProvisioning", args, &results); err != nil {
+		return nil, err
+	}
+	return results.Results, nil
+}
+
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceExpose(service string) error {
@@ -177,6 +202,22 @@ func (c *Client) ServiceDeploy(charmUrl string, serviceName string, numUnits int
 	return c.st.Call("Client", "", "ServiceDeploy", params, nil)
 }
 
+// ServiceDeployWithParams deploys a service as described by args. The
+// charm must have been added to the environment first, unless it comes
+// from the charm store.
+func (c *Client) ServiceDeployWithParams(args params.ServiceDeploy) error {
+	return c.st.Call("Client", "", "ServiceDeploy", args, nil)
+}
+
+// DeployBundle deploys the services and relations described by the
+// given bundle data. Services that already exist with the same charm
+// are reused; units, relations and settings are only added or updated
+// as needed to match the bundle.
+func (c *Client) DeployBundle(bundleYAML string) error {
+	args := params.DeployBundle{YAML: bundleYAML}
+	return c.st.Call("Client", "", "DeployBundle", args, nil)
+}
+
 // ServiceUpdate updates the service attributes, including charm URL,
 // minimum number of units, settings and constraints.
 // TODO(frankban) deprecate redundant API calls that this supercedes.
@@ -194,12 +235,30 @@ func (c *Client) ServiceSetCharm(serviceName string, charmUrl string, force bool
 	return c.st.Call("Client", "", "ServiceSetCharm", args, nil)
 }
 
+// ServiceGetCharmURL returns the charm URL the given service is
+// running.
+func (c *Client) ServiceGetCharmURL(serviceName string) (*charm.URL, error) {
+	var result params.StringResult
+	args := params.ServiceGet{ServiceName: serviceName}
+	if err := c.st.Call("Client", "", "ServiceGetCharmURL", args, &result); err != nil {
+		return nil, err
+	}
+	return charm.ParseURL(result.Result)
+}
+
 // AddServiceUnits adds a given number of units to a service.
 func (c *Client) AddServiceUnits(service string, numUnits int, machineSpec string) ([]string, error) {
+	return c.AddServiceUnitsWithStorage(service, numUnits, machineSpec, nil)
+}
+
+// AddServiceUnitsWithStorage adds a given number of units to a service,
+// first merging the given storage constraints into those of the service.
+func (c *Client) AddServiceUnitsWithStorage(service string, numUnits int, machineSpec string, storage map[string]constraints.Storage) ([]string, error) {
 	args := params.AddServiceUnits{
 		ServiceName:   service,
 		NumUnits:      numUnits,
 		ToMachineSpec: machineSpec,
+		Storage:       storage,
 	}
 	results := new(params.AddServiceUnitsResults)
 	err := c.st.Call("Client", "", "AddServiceUnits", args, results)
@@ -330,8 +389,115 @@ func (c *Client) EnvironmentGet() (map[string]interface{}, error) {
 	return result.Config, err
 }
 
+// SetEnvironAgentVersion starts an upgrade of the environment to the
+// given version. The upgrade fails if tools of that version are not
+// available for every machine.
+func (c *Client) SetEnvironAgentVersion(vers version.Number) error {
+	args := params.SetEnvironAgentVersion{Version: vers}
+	return c.st.Call("Client", "", "SetEnvironAgentVersion", args, nil)
+}
+
+// FindTools returns the tools available to the environment that match
+// the given major and minor versions, series and architecture. A
+// negative minor version matches any minor version, and an empty
+// series or architecture matches any.
+func (c *Client) FindTools(majorVersion, minorVersion int, series, arch string) (tools.List, error) {
+	var result params.FindToolsResults
+	args := params.FindToolsParams{
+		MajorVersion: majorVersion,
+		MinorVersion: minorVersion,
+		Series:       series,
+		Arch:         arch,
+	}
+	if err := c.st.Call("Client", "", "FindTools", args, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.List, nil
+}
+
 // EnvironmentSet sets the given key-value pairs in the environment.
 func (c *Client) EnvironmentSet(config map[string]interface{}) error {
 	args := params.EnvironmentSet{Config: config}
 	return c.st.Call("Client", "", "EnvironmentSet", args, nil)
 }
+
+// EnqueueAction queues the named charm action for execution on the
+// given unit, and returns the id of the queued action.
+func (c *Client) EnqueueAction(unitName, actionName string, actionParams map[string]interface{}) (string, error) {
+	args := params.EnqueueAction{
+		UnitName:   unitName,
+		ActionName: actionName,
+		Params:     actionParams,
+	}
+	var result params.EnqueueActionResults
+	err := c.st.Call("Client", "", "EnqueueAction", args, &result)
+	return result.ActionId, err
+}
+
+// FetchAction returns the progress and outcome of the action with the
+// given id.
+func (c *Client) FetchAction(actionId string) (params.FetchActionResults, error) {
+	args := params.FetchAction{ActionId: actionId}
+	var result params.FetchActionResults
+	err := c.st.Call("Client", "", "FetchAction", args, &result)
+	return result, err
+}
+
+// EnsureAvailability adds state server machines as necessary to make
+// the number of live state servers equal to numStateServers.
+func (c *Client) EnsureAvailability(numStateServers int, cons constraints.Value, series string) error {
+	args := params.EnsureAvailability{
+		NumStateServers: numStateServers,
+		Constraints:     cons,
+		Series:          series,
+	}
+	return c.st.Call("Client", "", "EnsureAvailability", args, nil)
+}
+
+// AddUser adds a user to the environment with the given permission,
+// which is one of "read", "write" or "admin".
+func (c *Client) AddUser(username, password, permission string) error {
+	args := params.AddUser{
+		Username:   username,
+		Password:   password,
+		Permission: permission,
+	}
+	return c.st.Call("Client", "", "AddUser", args, nil)
+}
+
+// RemoveUser removes a user from the environment.
+func (c *Client) RemoveUser(username string) error {
+	args := params.User{Username: username}
+	return c.st.Call("Client", "", "RemoveUser", args, nil)
+}
+
+// DisableUser prevents a user from logging in to the environment.
+func (c *Client) DisableUser(username string) error {
+	args := params.User{Username: username}
+	return c.st.Call("Client", "", "DisableUser", args, nil)
+}
+
+// EnableUser allows a disabled user to log in to the environment again.
+func (c *Client) EnableUser(username string) error {
+	args := params.User{Username: username}
+	return c.st.Call("Client", "", "EnableUser", args, nil)
+}
+
+// SetUserPassword changes the password of a user.
+func (c *Client) SetUserPassword(username, password string) error {
+	args := params.SetUserPassword{
+		Username: username,
+		Password: password,
+	}
+	return c.st.Call("Client", "", "SetUserPassword", args, nil)
+}
+
+// Users returns details of all the users of the environment.
+func (c *Client) Users() ([]params.UserInfo, error) {
+	var result params.UsersResults
+	err := c.st.Call("Client", "", "Users", nil, &result)
+	return result.Users, err
+}
@@ -0,0 +1,93 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"code.google.com/p/go.net/websocket"
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// DebugLogParams holds the filters applied to the log records
+// returned by WatchDebugLog.
+type DebugLogParams struct {
+	// IncludeEntity and ExcludeEntity hold entity tags, which may
+	// end in "*" to match all tags starting with the preceding text.
+	IncludeEntity []string
+	ExcludeEntity []string
+
+	// IncludeModule and ExcludeModule hold logging module names; each
+	// also matches its submodules.
+	IncludeModule []string
+	ExcludeModule []string
+
+	// Level holds the minimum level of the records returned.
+	Level loggo.Level
+
+	// Replay requests all matching records already logged.
+	Replay bool
+
+	// Backlog holds the number of recent matching records returned
+	// when Replay is false.
+	Backlog int
+}
+
+func (args DebugLogParams) query() url.Values {
+	query := make(url.Values)
+	query["includeEntity"] = args.IncludeEntity
+	query["excludeEntity"] = args.ExcludeEntity
+	query["includeModule"] = args.IncludeModule
+	query["excludeModule"] = args.ExcludeModule
+	if args.Level != loggo.UNSPECIFIED {
+		query.Set("level", args.Level.String())
+	}
+	if args.Replay {
+		query.Set("replay", "true")
+	}
+	if args.Backlog > 0 {
+		query.Set("backlog", strconv.Itoa(args.Backlog))
+	}
+	return query
+}
+
+// WatchDebugLog returns a stream of the log records written by the
+// environment's agents, one per line, as selected by args. The stream
+// continues until it is closed.
+func (c *Client) WatchDebugLog(args DebugLogParams) (io.ReadCloser, error) {
+	location := &url.URL{
+		Scheme:   "wss",
+		Host:     c.st.addr,
+		Path:     "/log",
+		RawQuery: args.query().Encode(),
+	}
+	cfg, err := websocket.NewConfig(location.String(), "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	cfg.TlsConfig = c.st.tlsConfig
+	creds := base64.StdEncoding.EncodeToString([]byte(c.st.authTag + ":" + c.st.password))
+	cfg.Header = http.Header{"Authorization": {"Basic " + creds}}
+	conn, err := websocket.DialConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to debug log: %v", err)
+	}
+	var result params.ErrorResult
+	if err := websocket.JSON.Receive(conn, &result); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot read debug log status: %v", err)
+	}
+	if result.Error != nil {
+		conn.Close()
+		return nil, result.Error
+	}
+	return conn, nil
+}
@@ -69,3 +69,10 @@ func (st *State) WatchLoggingConfig(agentTag string) (watcher.NotifyWatcher, err
 	w := watcher.NewNotifyWatcher(st.caller, result)
 	return w, nil
 }
+
+// AddLogs sends log messages written by the agent to the API server,
+// which records them against the agent.
+func (st *State) AddLogs(records []params.LogRecord) error {
+	args := params.LogRecords{Records: records}
+	return st.caller.Call("Logger", "", "AddLogs", args, nil)
+}
@@ -4,13 +4,18 @@
 package logger_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
 
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/logger"
+	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/testing"
+	coretesting "launchpad.net/juju-core/testing"
 )
 
 type loggerSuite struct {
@@ -76,3 +81,29 @@ func (s *loggerSuite) TestWatchLoggingConfig(c *gc.C) {
 	testing.AssertStop(c, watcher)
 	wc.AssertClosed()
 }
+
+func (s *loggerSuite) TestAddLogs(c *gc.C) {
+	now := time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := s.logger.AddLogs([]params.LogRecord{{
+		Time:     now,
+		Module:   "juju.worker.machiner",
+		Level:    loggo.INFO,
+		Location: "machiner.go:42",
+		Message:  "hello",
+	}})
+	c.Assert(err, gc.IsNil)
+
+	tailer := s.BackingState.NewLogTailer(state.LogFilter{Replay: true})
+	defer tailer.Stop()
+	select {
+	case record := <-tailer.Logs():
+		c.Assert(record.Time.Equal(now), gc.Equals, true)
+		c.Assert(record.Entity, gc.Equals, s.rawMachine.Tag())
+		c.Assert(record.Module, gc.Equals, "juju.worker.machiner")
+		c.Assert(record.Level, gc.Equals, loggo.INFO)
+		c.Assert(record.Location, gc.Equals, "machiner.go:42")
+		c.Assert(record.Message, gc.Equals, "hello")
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("timed out waiting for log record")
+	}
+}
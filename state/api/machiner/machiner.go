@@ -51,3 +51,13 @@ func (st *State) Machine(tag string) (*Machine, error) {
 		st:   st,
 	}, nil
 }
+
+// APIAddresses returns the list of addresses used to connect to the API.
+func (st *State) APIAddresses() ([]string, error) {
+	var result params.StringsResult
+	err := st.caller.Call("Machiner", "", "APIAddresses", nil, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
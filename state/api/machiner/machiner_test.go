@@ -142,3 +142,14 @@ func (s *machinerSuite) TestWatch(c *gc.C) {
 	statetesting.AssertStop(c, w)
 	wc.AssertClosed()
 }
+
+func (s *machinerSuite) TestAPIAddresses(c *gc.C) {
+	testing.AddStateServerMachine(c, s.State)
+
+	apiAddresses, err := s.State.APIAddresses()
+	c.Assert(err, gc.IsNil)
+
+	addresses, err := s.machiner.APIAddresses()
+	c.Assert(err, gc.IsNil)
+	c.Assert(addresses, gc.DeepEquals, apiAddresses)
+}
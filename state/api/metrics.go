@@ -0,0 +1,21 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"time"
+
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// Metrics returns the metrics reported since the given time by the
+// named unit, or by the units of the named service, oldest first.
+func (c *Client) Metrics(name string, since time.Time) ([]params.MetricResult, error) {
+	var results params.MetricResults
+	args := params.Metrics{Name: name, Since: since}
+	if err := c.st.Call("Client", "", "Metrics", args, &results); err != nil {
+		return nil, err
+	}
+	return results.Metrics, nil
+}
@@ -44,6 +44,7 @@ const (
 	CodeHasAssignedUnits    = "machine has assigned units"
 	CodeNotProvisioned      = "not provisioned"
 	CodeNoAddressSet        = "no address set"
+	CodeOperationBlocked    = "operation is blocked"
 )
 
 // ErrCode returns the error code associated with
@@ -116,3 +117,7 @@ func IsCodeNotProvisioned(err error) bool {
 func IsCodeNoAddressSet(err error) bool {
 	return ErrCode(err) == CodeNoAddressSet
 }
+
+func IsCodeOperationBlocked(err error) bool {
+	return ErrCode(err) == CodeOperationBlocked
+}
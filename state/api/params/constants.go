@@ -84,3 +84,57 @@ func (status Status) Valid() bool {
 	}
 	return true
 }
+
+// WorkloadStatus describes the state of a unit's workload, as
+// reported by the charm itself rather than by the unit agent.
+type WorkloadStatus string
+
+const (
+	// The charm has not reported the state of its workload.
+	WorkloadUnknown WorkloadStatus = "unknown"
+
+	// The unit is not yet providing its service, but is actively
+	// doing work in preparation for it.
+	WorkloadMaintenance WorkloadStatus = "maintenance"
+
+	// The unit cannot continue without human intervention.
+	WorkloadBlocked WorkloadStatus = "blocked"
+
+	// The unit is unable to progress to active until something
+	// else happens, such as a related unit becoming ready.
+	WorkloadWaiting WorkloadStatus = "waiting"
+
+	// The unit believes it is correctly offering its service.
+	WorkloadActive WorkloadStatus = "active"
+)
+
+// Valid returns true if status can be set by a charm.
+func (status WorkloadStatus) Valid() bool {
+	switch status {
+	case
+		WorkloadMaintenance,
+		WorkloadBlocked,
+		WorkloadWaiting,
+		WorkloadActive:
+	default:
+		return false
+	}
+	return true
+}
+
+// ActionStatus describes the progress of a charm action queued on a unit.
+type ActionStatus string
+
+const (
+	// The action is queued and has not yet been picked up by the unit.
+	ActionPending ActionStatus = "pending"
+
+	// The unit agent is running the action.
+	ActionRunning ActionStatus = "running"
+
+	// The action ran to completion.
+	ActionCompleted ActionStatus = "completed"
+
+	// The action failed, or could not be run.
+	ActionFailed ActionStatus = "failed"
+)
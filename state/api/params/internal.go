@@ -4,6 +4,10 @@
 package params
 
 import (
+	"time"
+
+	"launchpad.net/loggo"
+
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/tools"
@@ -120,6 +124,13 @@ type BoolResults struct {
 	Results []BoolResult
 }
 
+// DurationResult holds the result of an API call that returns a
+// duration or an error.
+type DurationResult struct {
+	Error  *Error
+	Result time.Duration
+}
+
 // RelationSettings holds relation settings names and values.
 type RelationSettings map[string]string
 
@@ -219,6 +230,76 @@ type RelationResults struct {
 	Results []RelationResult
 }
 
+// ActionIds holds multiple action ids.
+type ActionIds struct {
+	ActionIds []string
+}
+
+// ActionResult holds the name and parameters of an action queued on a
+// unit, or an error.
+type ActionResult struct {
+	Error  *Error
+	Name   string
+	Params map[string]interface{}
+	Status ActionStatus
+}
+
+// ActionResults holds the result of an API call that returns
+// information about multiple actions.
+type ActionResults struct {
+	Results []ActionResult
+}
+
+// ActionFinish holds the outcome of running a single action.
+type ActionFinish struct {
+	ActionId string
+	Status   ActionStatus
+	Results  map[string]interface{}
+	Message  string
+}
+
+// ActionsFinish holds the arguments for making a FinishActions API call.
+type ActionsFinish struct {
+	Actions []ActionFinish
+}
+
+// StorageInstance describes a storage instance belonging to a unit.
+// Location is empty until the instance has been provisioned.
+type StorageInstance struct {
+	Id          string
+	StorageName string
+	Kind        string
+	Size        uint64
+	Location    string
+}
+
+// StorageInstancesResult holds the storage instances of a unit, or
+// an error.
+type StorageInstancesResult struct {
+	Error     *Error
+	Instances []StorageInstance
+}
+
+// StorageInstancesResults holds the result of an API call that
+// returns the storage instances of multiple units.
+type StorageInstancesResults struct {
+	Results []StorageInstancesResult
+}
+
+// LogRecord holds a single log message written by an agent.
+type LogRecord struct {
+	Time     time.Time
+	Module   string
+	Level    loggo.Level
+	Location string
+	Message  string
+}
+
+// LogRecords holds the arguments for making an AddLogs API call.
+type LogRecords struct {
+	Records []LogRecord
+}
+
 // EntityPort holds an entity's tag, a protocol and a port.
 type EntityPort struct {
 	Tag      string
@@ -232,6 +313,25 @@ type EntitiesPorts struct {
 	Entities []EntityPort
 }
 
+// Metric holds a named value reported by a unit at a given time.
+type Metric struct {
+	Key   string
+	Value float64
+	Time  time.Time
+}
+
+// MetricBatch holds a batch of metrics reported by the entity with
+// the given tag.
+type MetricBatch struct {
+	Tag     string
+	Metrics []Metric
+}
+
+// MetricBatches holds the parameters for making an AddMetrics call.
+type MetricBatches struct {
+	Batches []MetricBatch
+}
+
 // EntityCharmURL holds an entity's tag and a charm URL.
 type EntityCharmURL struct {
 	Tag      string
@@ -289,6 +389,24 @@ type SetProvisioned struct {
 	Machines []MachineSetProvisioned
 }
 
+// InstanceInfo holds a machine tag, provider-specific instance id, a
+// nonce, hardware characteristics, and the networks and network
+// interfaces of the machine's instance.
+type InstanceInfo struct {
+	Tag             string
+	InstanceId      instance.Id
+	Nonce           string
+	Characteristics *instance.HardwareCharacteristics
+	Networks        []instance.NetworkInfo
+	Interfaces      []instance.InterfaceInfo
+}
+
+// InstancesInfo holds the parameters for making a SetInstanceInfo
+// call for multiple machines.
+type InstancesInfo struct {
+	Machines []InstanceInfo
+}
+
 // MachineSetStatus holds a machine tag, status and extra info.
 // DEPRECATE(v1.14)
 type MachineSetStatus struct {
@@ -323,8 +441,10 @@ type SetStatus struct {
 // error.
 type StatusResult struct {
 	Error  *Error
+	Id     string
 	Status Status
 	Info   string
+	Data   StatusData
 }
 
 // StatusResults holds multiple status results.
@@ -332,6 +452,58 @@ type StatusResults struct {
 	Results []StatusResult
 }
 
+// SetEntityWorkloadStatus holds a unit tag and the status of its
+// workload, with extra info.
+type SetEntityWorkloadStatus struct {
+	Tag    string
+	Status WorkloadStatus
+	Info   string
+}
+
+// SetWorkloadStatus holds the parameters for making a
+// SetWorkloadStatus call.
+type SetWorkloadStatus struct {
+	Entities []SetEntityWorkloadStatus
+}
+
+// WorkloadStatusResult holds the status of a unit's workload and
+// extra information, or an error.
+type WorkloadStatusResult struct {
+	Error  *Error
+	Status WorkloadStatus
+	Info   string
+}
+
+// WorkloadStatusResults holds multiple workload status results.
+type WorkloadStatusResults struct {
+	Results []WorkloadStatusResult
+}
+
+// LeaderSettingsResult holds the settings published by the leader of
+// a service, or an error.
+type LeaderSettingsResult struct {
+	Error    *Error
+	Settings map[string]string
+}
+
+// LeaderSettingsResults holds multiple leader settings results.
+type LeaderSettingsResults struct {
+	Results []LeaderSettingsResult
+}
+
+// EntityLeaderSettings holds a unit tag and the leader settings it
+// should write; settings with an empty value are deleted.
+type EntityLeaderSettings struct {
+	Tag      string
+	Settings map[string]string
+}
+
+// SetLeaderSettings holds the parameters for making a
+// SetLeaderSettings call.
+type SetLeaderSettings struct {
+	Entities []EntityLeaderSettings
+}
+
 // ConstraintsResult holds machine constraints or an error.
 type ConstraintsResult struct {
 	Error       *Error
@@ -343,6 +515,17 @@ type ConstraintsResults struct {
 	Results []ConstraintsResult
 }
 
+// MachineJobsResult holds the jobs of a machine or an error.
+type MachineJobsResult struct {
+	Error *Error
+	Jobs  []MachineJob
+}
+
+// MachineJobsResults holds multiple machine jobs results.
+type MachineJobsResults struct {
+	Results []MachineJobsResult
+}
+
 // MachineAgentGetMachinesResults holds the results of a
 // machineagent.API.GetMachines call.
 // DEPRECATE(v1.14)
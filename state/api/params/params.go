@@ -7,11 +7,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
 )
 
 // ErrorResults holds the results of calling a bulk operation which
@@ -104,6 +106,17 @@ type ServiceDeploy struct {
 	ConfigYAML    string // Takes precedence over config if both are present.
 	Constraints   constraints.Value
 	ToMachineSpec string
+	Storage       map[string]constraints.Storage
+	// EndpointBindings maps relation names to the networks their
+	// traffic should use; see state.Service.SetEndpointBindings.
+	EndpointBindings map[string]string
+}
+
+// DeployBundle holds the parameters for making the DeployBundle call.
+type DeployBundle struct {
+	// YAML holds the bundle data, in the format read by
+	// charm.ReadBundleData.
+	YAML string
 }
 
 // ServiceUpdate holds the parameters for making the ServiceUpdate call.
@@ -115,6 +128,9 @@ type ServiceUpdate struct {
 	SettingsStrings map[string]string
 	SettingsYAML    string // Takes precedence over SettingsStrings if both are present.
 	Constraints     *constraints.Value
+	// RecoveryPolicy holds the service's new recovery policy, "auto"
+	// or "manual"; it is left unchanged if empty.
+	RecoveryPolicy string
 }
 
 // ServiceSetCharm sets the charm for a given service.
@@ -196,6 +212,11 @@ type Resolved struct {
 	Retry    bool
 }
 
+// CancelHook holds parameters for the CancelHook call.
+type CancelHook struct {
+	UnitName string
+}
+
 // ResolvedResults holds results of the Resolved call.
 type ResolvedResults struct {
 	Service  string
@@ -214,6 +235,9 @@ type AddServiceUnits struct {
 	ServiceName   string
 	NumUnits      int
 	ToMachineSpec string
+	// Storage, if set, is merged into the service's storage
+	// constraints before the units are added.
+	Storage map[string]constraints.Storage
 }
 
 // DestroyServiceUnits holds parameters for the DestroyUnits call.
@@ -270,6 +294,244 @@ type CharmInfo struct {
 	CharmURL string
 }
 
+// CharmURL identifies a single charm URL.
+type CharmURL struct {
+	URL string
+}
+
+// CharmsResponse is the server response to a charm upload request.
+type CharmsResponse struct {
+	Error    string `json:",omitempty"`
+	CharmURL string `json:",omitempty"`
+}
+
+// ToolsResponse is the server response to a tools upload request.
+type ToolsResponse struct {
+	Error string       `json:",omitempty"`
+	Tools *tools.Tools `json:",omitempty"`
+}
+
+// StatusParams holds parameters for the FullStatus call.
+type StatusParams struct {
+	Patterns []string
+}
+
+// BlockSwitchParams holds the parameters for switching a block on or
+// off. Message is ignored when switching a block off.
+type BlockSwitchParams struct {
+	Type    string
+	Message string
+}
+
+// Block describes a block switched on in the environment.
+type Block struct {
+	Type    string
+	Message string
+}
+
+// BlockResults holds the blocks switched on in the environment.
+type BlockResults struct {
+	Blocks []Block
+}
+
+// StatusHistory holds the parameters for the StatusHistory call.
+type StatusHistory struct {
+	Tag  string
+	Size int
+}
+
+// StatusHistoryEntry holds a status recorded in the history of a
+// unit or machine.
+type StatusHistoryEntry struct {
+	Status Status
+	Info   string
+	Data   StatusData
+	Since  time.Time
+}
+
+// StatusHistoryResults holds the results of the StatusHistory call,
+// newest first.
+type StatusHistoryResults struct {
+	Statuses []StatusHistoryEntry
+}
+
+// AuditLogFilter holds the parameters for the AuditLog call. Zero
+// values select all entries.
+type AuditLogFilter struct {
+	Since time.Time
+	Until time.Time
+	Tag   string
+	Limit int
+}
+
+// AuditLogEntry holds a change to the environment requested through
+// the API, and its outcome. Args and Result hold the JSON encodings of
+// the arguments and result of the call, with secrets masked.
+type AuditLogEntry struct {
+	Time          time.Time
+	Tag           string
+	RemoteAddress string
+	Method        string
+	Args          string
+	Result        string
+	Error         string
+	ErrorCode     string
+}
+
+// AuditLogResults holds the results of the AuditLog call, oldest first.
+type AuditLogResults struct {
+	Entries []AuditLogEntry
+}
+
+// ScalingPolicy holds how the number of units of a service is adjusted
+// automatically.
+type ScalingPolicy struct {
+	MinUnits  int
+	MaxUnits  int
+	Signal    string
+	ScaleUp   float64
+	ScaleDown float64
+	Cooldown  time.Duration
+}
+
+// ScalingDecision holds a change made to the number of units of a
+// service by applying its scaling policy.
+type ScalingDecision struct {
+	Time   time.Time
+	From   int
+	To     int
+	Reason string
+}
+
+// ServiceScaling holds the parameters for making the
+// ServiceSetScaling call. A nil Policy stops the automatic scaling of
+// the service.
+type ServiceScaling struct {
+	ServiceName string
+	Policy      *ScalingPolicy
+}
+
+// ServiceScalingResults holds the results of the ServiceGetScaling
+// call. The decisions are oldest first.
+type ServiceScalingResults struct {
+	Policy    ScalingPolicy
+	Decisions []ScalingDecision
+}
+
+// Metrics holds the parameters for making the Metrics call. Name is
+// the name of a unit or service.
+type Metrics struct {
+	Name  string
+	Since time.Time
+}
+
+// MetricResult holds a metric reported by a unit.
+type MetricResult struct {
+	Unit  string
+	Key   string
+	Value float64
+	Time  time.Time
+}
+
+// MetricResults holds the results of the Metrics call, oldest first.
+type MetricResults struct {
+	Metrics []MetricResult
+}
+
+// SetEnvironAgentVersion holds the parameters for making the
+// SetEnvironAgentVersion call.
+type SetEnvironAgentVersion struct {
+	Version version.Number
+}
+
+// FindToolsParams holds the parameters for making the FindTools call.
+type FindToolsParams struct {
+	MajorVersion int
+	MinorVersion int
+	Series       string
+	Arch         string
+}
+
+// FindToolsResults holds the results of the FindTools call.
+type FindToolsResults struct {
+	List  tools.List
+	Error *Error
+}
+
+// EnqueueAction holds the parameters for making the EnqueueAction call.
+type EnqueueAction struct {
+	UnitName   string
+	ActionName string
+	Params     map[string]interface{}
+}
+
+// EnqueueActionResults holds the results of the EnqueueAction call.
+type EnqueueActionResults struct {
+	ActionId string
+}
+
+// FetchAction holds the parameters for making the FetchAction call.
+type FetchAction struct {
+	ActionId string
+}
+
+// FetchActionResults holds the results of the FetchAction call.
+type FetchActionResults struct {
+	ActionId   string
+	UnitName   string
+	ActionName string
+	Params     map[string]interface{}
+	Status     ActionStatus
+	Message    string
+	Results    map[string]interface{}
+	Enqueued   time.Time
+	Started    time.Time
+	Completed  time.Time
+}
+
+// EnsureAvailability holds the parameters for making the
+// EnsureAvailability call.
+type EnsureAvailability struct {
+	NumStateServers int
+	Constraints     constraints.Value
+	// Series is the series of any new state server machines. If
+	// empty, the environment's default series is used.
+	Series string
+}
+
+// AddUser holds the parameters for making the AddUser call.
+type AddUser struct {
+	Username string
+	Password string
+	// Permission is one of "read", "write" or "admin". If empty,
+	// the user is given read permission.
+	Permission string
+}
+
+// User identifies a user by name.
+type User struct {
+	Username string
+}
+
+// SetUserPassword holds the parameters for making the
+// SetUserPassword call.
+type SetUserPassword struct {
+	Username string
+	Password string
+}
+
+// UserInfo holds details of a user of the environment.
+type UserInfo struct {
+	Username   string
+	Permission string
+	Disabled   bool
+}
+
+// UsersResults holds the results of the Users call.
+type UsersResults struct {
+	Users []UserInfo
+}
+
 // AllWatcherId holds the id of an AllWatcher.
 type AllWatcherId struct {
 	AllWatcherId string
@@ -420,17 +682,19 @@ func (i *ServiceInfo) EntityId() EntityId {
 }
 
 type UnitInfo struct {
-	Name           string `bson:"_id"`
-	Service        string
-	Series         string
-	CharmURL       string
-	PublicAddress  string
-	PrivateAddress string
-	MachineId      string
-	Ports          []instance.Port
-	Status         Status
-	StatusInfo     string
-	StatusData     StatusData
+	Name               string `bson:"_id"`
+	Service            string
+	Series             string
+	CharmURL           string
+	PublicAddress      string
+	PrivateAddress     string
+	MachineId          string
+	Ports              []instance.Port
+	Status             Status
+	StatusInfo         string
+	StatusData         StatusData
+	WorkloadStatus     WorkloadStatus
+	WorkloadStatusInfo string
 }
 
 func (i *UnitInfo) EntityId() EntityId {
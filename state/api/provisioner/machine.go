@@ -55,11 +55,11 @@ func (m *Machine) Refresh() error {
 }
 
 // SetStatus sets the status of the machine.
-func (m *Machine) SetStatus(status params.Status, info string) error {
+func (m *Machine) SetStatus(status params.Status, info string, data params.StatusData) error {
 	var result params.ErrorResults
 	args := params.SetStatus{
 		Entities: []params.SetEntityStatus{
-			{Tag: m.tag, Status: status, Info: info},
+			{Tag: m.tag, Status: status, Info: info, Data: data},
 		},
 	}
 	err := m.st.caller.Call("Provisioner", "", "SetStatus", args, &result)
@@ -70,23 +70,23 @@ func (m *Machine) SetStatus(status params.Status, info string) error {
 }
 
 // Status returns the status of the machine.
-func (m *Machine) Status() (params.Status, string, error) {
+func (m *Machine) Status() (params.Status, string, params.StatusData, error) {
 	var results params.StatusResults
 	args := params.Entities{
 		Entities: []params.Entity{{Tag: m.tag}},
 	}
 	err := m.st.caller.Call("Provisioner", "", "Status", args, &results)
 	if err != nil {
-		return "", "", err
+		return "", "", nil, err
 	}
 	if len(results.Results) != 1 {
-		return "", "", fmt.Errorf("expected one result, got %d", len(results.Results))
+		return "", "", nil, fmt.Errorf("expected one result, got %d", len(results.Results))
 	}
 	result := results.Results[0]
 	if result.Error != nil {
-		return "", "", result.Error
+		return "", "", nil, result.Error
 	}
-	return result.Status, result.Info, nil
+	return result.Status, result.Info, result.Data, nil
 }
 
 // Constraints returns the exact constraints that should apply when provisioning
@@ -162,6 +162,47 @@ func (m *Machine) Series() (string, error) {
 	return result.Result, nil
 }
 
+// Placement returns the provider-specific placement directive, if any,
+// that determines where the machine's instance should be started.
+func (m *Machine) Placement() (string, error) {
+	var results params.StringResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Provisioner", "", "Placement", args, &results)
+	if err != nil {
+		return "", err
+	}
+	if len(results.Results) != 1 {
+		return "", fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return result.Result, nil
+}
+
+// Jobs returns the jobs the machine is responsible for.
+func (m *Machine) Jobs() ([]params.MachineJob, error) {
+	var results params.MachineJobsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: m.tag}},
+	}
+	err := m.st.caller.Call("Provisioner", "", "Jobs", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Jobs, nil
+}
+
 // SetProvisioned sets the provider specific machine id, nonce and also metadata for
 // this machine. Once set, the instance id cannot be changed.
 func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics) error {
@@ -181,6 +222,30 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 	return result.OneError()
 }
 
+// SetInstanceInfo sets the provider specific machine id, nonce,
+// metadata, networks and network interfaces for this machine.
+func (m *Machine) SetInstanceInfo(
+	id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics,
+	networks []instance.NetworkInfo, interfaces []instance.InterfaceInfo) error {
+
+	var result params.ErrorResults
+	args := params.InstancesInfo{
+		Machines: []params.InstanceInfo{{
+			Tag:             m.tag,
+			InstanceId:      id,
+			Nonce:           nonce,
+			Characteristics: characteristics,
+			Networks:        networks,
+			Interfaces:      interfaces,
+		}},
+	}
+	err := m.st.caller.Call("Provisioner", "", "SetInstanceInfo", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // InstanceId returns the provider specific instance id for the
 // machine or an CodeNotProvisioned error, if not set.
 func (m *Machine) InstanceId() (instance.Id, error) {
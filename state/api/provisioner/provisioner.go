@@ -103,6 +103,17 @@ func (st *State) WatchEnvironMachines() (watcher.StringsWatcher, error) {
 	return w, nil
 }
 
+// MachinesWithTransientErrors returns the status of each machine that
+// failed to start with an error the provisioner should retry.
+func (st *State) MachinesWithTransientErrors() ([]params.StatusResult, error) {
+	var results params.StatusResults
+	err := st.caller.Call("Provisioner", "", "MachinesWithTransientErrors", nil, &results)
+	if err != nil {
+		return nil, err
+	}
+	return results.Results, nil
+}
+
 // StateAddresses returns the list of addresses used to connect to the state.
 func (st *State) StateAddresses() ([]string, error) {
 	var result params.StringsResult
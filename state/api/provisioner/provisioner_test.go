@@ -75,18 +75,44 @@ func (s *provisionerSuite) TestGetSetStatus(c *gc.C) {
 	apiMachine, err := s.provisioner.Machine(s.machine.Tag())
 	c.Assert(err, gc.IsNil)
 
-	status, info, err := apiMachine.Status()
+	status, info, data, err := apiMachine.Status()
 	c.Assert(err, gc.IsNil)
 	c.Assert(status, gc.Equals, params.StatusPending)
 	c.Assert(info, gc.Equals, "")
+	c.Assert(data, gc.HasLen, 0)
 
-	err = apiMachine.SetStatus(params.StatusStarted, "blah")
+	err = apiMachine.SetStatus(params.StatusStarted, "blah", nil)
 	c.Assert(err, gc.IsNil)
 
-	status, info, err = apiMachine.Status()
+	status, info, data, err = apiMachine.Status()
 	c.Assert(err, gc.IsNil)
 	c.Assert(status, gc.Equals, params.StatusStarted)
 	c.Assert(info, gc.Equals, "blah")
+	c.Assert(data, gc.HasLen, 0)
+
+	err = apiMachine.SetStatus(params.StatusError, "failed", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+
+	status, info, data, err = apiMachine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusError)
+	c.Assert(info, gc.Equals, "failed")
+	c.Assert(data, gc.DeepEquals, params.StatusData{"transient": true})
+}
+
+func (s *provisionerSuite) TestMachinesWithTransientErrors(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetStatus(params.StatusError, "blah", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.provisioner.MachinesWithTransientErrors()
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.HasLen, 1)
+	c.Assert(results[0].Id, gc.Equals, machine.Id())
+	c.Assert(results[0].Status, gc.Equals, params.StatusError)
+	c.Assert(results[0].Info, gc.Equals, "blah")
+	c.Assert(results[0].Data, gc.DeepEquals, params.StatusData{"transient": true})
 }
 
 func (s *provisionerSuite) TestEnsureDeadAndRemove(c *gc.C) {
@@ -181,6 +207,35 @@ func (s *provisionerSuite) TestSetProvisionedAndInstanceId(c *gc.C) {
 	c.Assert(instanceId, gc.Equals, instance.Id("i-manager"))
 }
 
+func (s *provisionerSuite) TestSetInstanceInfo(c *gc.C) {
+	notProvisionedMachine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	apiMachine, err := s.provisioner.Machine(notProvisionedMachine.Tag())
+	c.Assert(err, gc.IsNil)
+
+	networks := []instance.NetworkInfo{{Name: "storage", ProviderId: "vlan42", VLANTag: 42}}
+	interfaces := []instance.InterfaceInfo{{
+		MACAddress:    "aa:bb:cc:dd:ee:f0",
+		InterfaceName: "eth0.42",
+		NetworkName:   "storage",
+		IsVirtual:     true,
+	}}
+	err = apiMachine.SetInstanceInfo("i-will", "fake_nonce", nil, networks, interfaces)
+	c.Assert(err, gc.IsNil)
+
+	instanceId, err := apiMachine.InstanceId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instanceId, gc.Equals, instance.Id("i-will"))
+	ifaces, err := notProvisionedMachine.NetworkInterfaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, gc.HasLen, 1)
+	c.Assert(ifaces[0].Info(), gc.DeepEquals, interfaces[0])
+
+	// Try it again - should fail.
+	err = apiMachine.SetInstanceInfo("i-wont", "fake", nil, nil, nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set instance data for machine "1": already set`)
+}
+
 func (s *provisionerSuite) TestSeries(c *gc.C) {
 	// Create a fresh machine with different series.
 	foobarMachine, err := s.State.AddMachine("foobar", state.JobHostUnits)
@@ -200,6 +255,38 @@ func (s *provisionerSuite) TestSeries(c *gc.C) {
 	c.Assert(series, gc.Equals, "quantal")
 }
 
+func (s *provisionerSuite) TestPlacement(c *gc.C) {
+	placementMachine, err := s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:    "quantal",
+		Jobs:      []state.MachineJob{state.JobHostUnits},
+		Placement: "zone=zone1",
+	})
+	c.Assert(err, gc.IsNil)
+
+	apiMachine, err := s.provisioner.Machine(placementMachine.Tag())
+	c.Assert(err, gc.IsNil)
+	placement, err := apiMachine.Placement()
+	c.Assert(err, gc.IsNil)
+	c.Assert(placement, gc.Equals, "zone=zone1")
+
+	apiMachine, err = s.provisioner.Machine(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	placement, err = apiMachine.Placement()
+	c.Assert(err, gc.IsNil)
+	c.Assert(placement, gc.Equals, "")
+}
+
+func (s *provisionerSuite) TestJobs(c *gc.C) {
+	apiMachine, err := s.provisioner.Machine(s.machine.Tag())
+	c.Assert(err, gc.IsNil)
+	jobs, err := apiMachine.Jobs()
+	c.Assert(err, gc.IsNil)
+	c.Assert(jobs, gc.DeepEquals, []params.MachineJob{
+		params.JobManageEnviron,
+		params.JobManageState,
+	})
+}
+
 func (s *provisionerSuite) TestConstraints(c *gc.C) {
 	// Create a fresh machine with some constraints.
 	args := state.AddMachineParams{
@@ -248,7 +335,7 @@ func (s *provisionerSuite) TestWatchContainers(c *gc.C) {
 
 	// Change something other than the containers and make sure it's
 	// not detected.
-	err = apiMachine.SetStatus(params.StatusStarted, "not really")
+	err = apiMachine.SetStatus(params.StatusStarted, "not really", nil)
 	c.Assert(err, gc.IsNil)
 	wc.AssertNoChange()
 
@@ -0,0 +1,26 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// ServiceSetScaling sets the scaling policy of the service, or stops
+// its automatic scaling if policy is nil.
+func (c *Client) ServiceSetScaling(service string, policy *params.ScalingPolicy) error {
+	args := params.ServiceScaling{ServiceName: service, Policy: policy}
+	return c.st.Call("Client", "", "ServiceSetScaling", args, nil)
+}
+
+// ServiceGetScaling returns the scaling policy of the service, and the
+// most recent decisions made by applying it, oldest first.
+func (c *Client) ServiceGetScaling(service string) (*params.ServiceScalingResults, error) {
+	var results params.ServiceScalingResults
+	args := params.ServiceGet{ServiceName: service}
+	if err := c.st.Call("Client", "", "ServiceGetScaling", args, &results); err != nil {
+		return nil, err
+	}
+	return &results, nil
+}
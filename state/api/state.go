@@ -26,6 +26,7 @@ func (st *State) Login(tag, password, nonce string) error {
 	}, nil)
 	if err == nil {
 		st.authTag = tag
+		st.password = password
 	}
 	return err
 }
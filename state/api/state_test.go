@@ -4,11 +4,13 @@
 package api_test
 
 import (
+	"fmt"
 	stdtesting "testing"
 
 	gc "launchpad.net/gocheck"
 
 	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state/api"
 	coretesting "launchpad.net/juju-core/testing"
 )
 
@@ -27,3 +29,19 @@ func (s *stateSuite) TestCloseMultipleOk(c *gc.C) {
 	c.Assert(s.APIState.Close(), gc.IsNil)
 	c.Assert(s.APIState.Close(), gc.IsNil)
 }
+
+func (s *stateSuite) TestOpenTriesAllAddresses(c *gc.C) {
+	info := s.APIInfo(c)
+	unused := fmt.Sprintf("localhost:%d", coretesting.FindTCPPort())
+	info.Addrs = append([]string{unused}, info.Addrs...)
+	st, err := api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(st.Close(), gc.IsNil)
+}
+
+func (s *stateSuite) TestOpenNoAddresses(c *gc.C) {
+	info := s.APIInfo(c)
+	info.Addrs = nil
+	_, err := api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.ErrorMatches, "no API addresses to connect to")
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/version"
+)
+
+// FullStatus holds information about the status of a juju environment,
+// as reported by the status command.
+type FullStatus struct {
+	EnvironmentName string
+	// Upgrade is nil unless an upgrade is in progress or the last
+	// upgrade failed.
+	Upgrade  *UpgradeStatus
+	Machines map[string]MachineStatus
+	Services map[string]ServiceStatus
+}
+
+// UpgradeStatus holds the progress of an environment upgrade.
+type UpgradeStatus struct {
+	From          version.Number
+	To            version.Number
+	Status        string
+	Error         string
+	PendingAgents []string
+}
+
+// MachineStatus holds status information about a machine and the
+// containers it hosts. Err is not empty if the status could not be
+// determined.
+type MachineStatus struct {
+	Err            string
+	AgentState     params.Status
+	AgentStateInfo string
+	AgentVersion   string
+	DNSName        string
+	InstanceId     instance.Id
+	InstanceState  string
+	Life           string
+	Series         string
+	Id             string
+	Containers     map[string]MachineStatus
+	Hardware       string
+}
+
+// ServiceStatus holds status information about a service and its
+// units. Err is not empty if the status could not be determined.
+type ServiceStatus struct {
+	Err           string
+	Charm         string
+	Exposed       bool
+	Life          string
+	Relations     map[string][]string
+	SubordinateTo []string
+	Units         map[string]UnitStatus
+	Scaling       *ScalingStatus
+
+	// Status holds the workload status of the service, derived from
+	// those of its units.
+	Status params.WorkloadStatus
+}
+
+// ScalingStatus holds the scaling policy of a service, and the last
+// decision made by applying it, if any.
+type ScalingStatus struct {
+	MinUnits     int
+	MaxUnits     int
+	Signal       string
+	LastDecision *params.ScalingDecision
+}
+
+// UnitStatus holds status information about a unit and its
+// subordinates. Err is not empty if the status could not be
+// determined.
+type UnitStatus struct {
+	Err                string
+	AgentState         params.Status
+	AgentStateInfo     string
+	AgentVersion       string
+	WorkloadStatus     params.WorkloadStatus
+	WorkloadStatusInfo string
+	Life               string
+	Machine            string
+	OpenedPorts        []string
+	PublicAddress      string
+	Subordinates       map[string]UnitStatus
+}
+
+// FullStatus returns the status of the juju environment. If patterns
+// are given, only the services and units matching them, and the
+// machines hosting those units, are included.
+func (c *Client) FullStatus(patterns []string) (*FullStatus, error) {
+	var status FullStatus
+	args := params.StatusParams{Patterns: patterns}
+	if err := c.st.Call("Client", "", "FullStatus", args, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// StatusHistory returns at most size of the most recent statuses of
+// the unit or machine with the given tag, newest first. A size of
+// zero returns the whole recorded history.
+func (c *Client) StatusHistory(tag string, size int) ([]params.StatusHistoryEntry, error) {
+	var results params.StatusHistoryResults
+	args := params.StatusHistory{Tag: tag, Size: size}
+	if err := c.st.Call("Client", "", "StatusHistory", args, &results); err != nil {
+		return nil, err
+	}
+	return results.Statuses, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
+)
+
+// UploadTools uploads the given tools archive, a gzipped tarball of the
+// given version, to the API server, which stores it in the environment
+// for the version's series and for each of the given further series.
+// It returns the uploaded tools.
+func (c *Client) UploadTools(archive io.ReadSeeker, vers version.Binary, fakeSeries ...string) (*tools.Tools, error) {
+	size, err := archive.Seek(0, 2)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := archive.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	query := url.Values{"binaryVersion": {vers.String()}}
+	if len(fakeSeries) > 0 {
+		query.Set("series", strings.Join(fakeSeries, ","))
+	}
+	uploadURL := fmt.Sprintf("https://%s/tools?%s", c.st.addr, query.Encode())
+	req, err := http.NewRequest("POST", uploadURL, archive)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create upload request: %v", err)
+	}
+	req.SetBasicAuth(c.st.authTag, c.st.password)
+	req.Header.Set("Content-Type", "application/x-tar-gz")
+	req.ContentLength = size
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: c.st.tlsConfig},
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot upload tools: %v", err)
+	}
+	defer resp.Body.Close()
+	var result params.ToolsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("cannot read tools upload response: %v", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("cannot upload tools: %s", result.Error)
+	}
+	return result.Tools, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// Action represents a charm action queued on a unit, as seen by the
+// uniter.
+type Action struct {
+	st     *State
+	id     string
+	name   string
+	params map[string]interface{}
+	status params.ActionStatus
+}
+
+// Id returns the action's id.
+func (a *Action) Id() string {
+	return a.id
+}
+
+// Name returns the name of the charm action to run.
+func (a *Action) Name() string {
+	return a.name
+}
+
+// Params returns the parameters the action should be run with.
+func (a *Action) Params() map[string]interface{} {
+	return a.params
+}
+
+// Status returns the status of the action when it was fetched.
+func (a *Action) Status() params.ActionStatus {
+	return a.status
+}
+
+// Begin marks the action as running.
+func (a *Action) Begin() error {
+	var result params.ErrorResults
+	args := params.ActionIds{ActionIds: []string{a.id}}
+	err := a.st.caller.Call("Uniter", "", "BeginActions", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// Finish records the outcome of the action, which must be either
+// params.ActionCompleted or params.ActionFailed.
+func (a *Action) Finish(status params.ActionStatus, results map[string]interface{}, message string) error {
+	var result params.ErrorResults
+	args := params.ActionsFinish{
+		Actions: []params.ActionFinish{{
+			ActionId: a.id,
+			Status:   status,
+			Results:  results,
+			Message:  message,
+		}},
+	}
+	err := a.st.caller.Call("Uniter", "", "FinishActions", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// Action returns the action with the given id.
+func (st *State) Action(id string) (*Action, error) {
+	var results params.ActionResults
+	args := params.ActionIds{ActionIds: []string{id}}
+	err := st.caller.Call("Uniter", "", "Actions", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &Action{
+		st:     st,
+		id:     id,
+		name:   result.Name,
+		params: result.Params,
+		status: result.Status,
+	}, nil
+}
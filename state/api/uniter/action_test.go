@@ -0,0 +1,108 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/api/uniter"
+	statetesting "launchpad.net/juju-core/state/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/utils"
+)
+
+type actionSuite struct {
+	uniterSuite
+
+	dummyUnit *state.Unit
+	apiUnit   *uniter.Unit
+	dummyAPI  *uniter.State
+}
+
+var _ = gc.Suite(&actionSuite{})
+
+func (s *actionSuite) SetUpTest(c *gc.C) {
+	s.uniterSuite.SetUpTest(c)
+
+	// The wordpress charm defines no actions, so log in as a unit of
+	// the dummy charm instead.
+	_, _, _, s.dummyUnit = s.addMachineServiceCharmAndUnit(c, "dummy")
+	password, err := utils.RandomPassword()
+	c.Assert(err, gc.IsNil)
+	err = s.dummyUnit.SetPassword(password)
+	c.Assert(err, gc.IsNil)
+	st := s.OpenAPIAs(c, s.dummyUnit.Tag(), password)
+	s.dummyAPI = st.Uniter()
+	s.apiUnit, err = s.dummyAPI.Unit(s.dummyUnit.Tag())
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *actionSuite) TearDownTest(c *gc.C) {
+	s.uniterSuite.TearDownTest(c)
+}
+
+func (s *actionSuite) TestWatchActions(c *gc.C) {
+	first, err := s.dummyUnit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	w, err := s.apiUnit.WatchActions()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewStringsWatcherC(c, s.BackingState, w)
+
+	// Initial event.
+	wc.AssertChange(first.Id())
+	wc.AssertNoChange()
+
+	// Queue another action and check it's detected.
+	second, err := s.dummyUnit.AddAction("snapshot", map[string]interface{}{"outfile": "bar.bz2"})
+	c.Assert(err, gc.IsNil)
+	wc.AssertChange(second.Id())
+	wc.AssertNoChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *actionSuite) TestAction(c *gc.C) {
+	stAction, err := s.dummyUnit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	action, err := s.dummyAPI.Action(stAction.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(action.Id(), gc.Equals, stAction.Id())
+	c.Assert(action.Name(), gc.Equals, "snapshot")
+	c.Assert(action.Params(), gc.DeepEquals, map[string]interface{}{"outfile": "foo.bz2"})
+	c.Assert(action.Status(), gc.Equals, params.ActionPending)
+
+	_, err = s.dummyAPI.Action("42")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	c.Assert(err, jc.Satisfies, params.IsCodeUnauthorized)
+}
+
+func (s *actionSuite) TestBeginFinish(c *gc.C) {
+	stAction, err := s.dummyUnit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	action, err := s.dummyAPI.Action(stAction.Id())
+	c.Assert(err, gc.IsNil)
+
+	err = action.Begin()
+	c.Assert(err, gc.IsNil)
+	err = stAction.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stAction.Status(), gc.Equals, params.ActionRunning)
+
+	err = action.Finish(params.ActionFailed, map[string]interface{}{"a": "b"}, "oops")
+	c.Assert(err, gc.IsNil)
+	err = stAction.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stAction.Status(), gc.Equals, params.ActionFailed)
+	c.Assert(stAction.Message(), gc.Equals, "oops")
+	c.Assert(stAction.Results(), gc.DeepEquals, map[string]interface{}{"a": "b"})
+
+	err = action.Finish(params.ActionCompleted, nil, "")
+	c.Assert(err, gc.ErrorMatches, `cannot finish action .*: action has already finished`)
+}
@@ -4,6 +4,8 @@
 package uniter_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/juju/testing"
@@ -50,3 +52,19 @@ func (s *stateSuite) TestProviderType(c *gc.C) {
 	c.Assert(err, gc.IsNil)
 	c.Assert(providerType, gc.DeepEquals, cfg.Type())
 }
+
+func (s *stateSuite) TestHookTimeout(c *gc.C) {
+	timeout, err := s.uniter.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(timeout, gc.Equals, time.Duration(0))
+
+	oldCfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	cfg, err := oldCfg.Apply(map[string]interface{}{"hook-timeout": "1h"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	timeout, err = s.uniter.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(timeout, gc.Equals, time.Hour)
+}
@@ -69,6 +69,124 @@ func (u *Unit) SetStatus(status params.Status, info string, data params.StatusDa
 	return result.OneError()
 }
 
+// SetWorkloadStatus sets the status of the unit's workload.
+func (u *Unit) SetWorkloadStatus(status params.WorkloadStatus, info string) error {
+	var result params.ErrorResults
+	args := params.SetWorkloadStatus{
+		Entities: []params.SetEntityWorkloadStatus{
+			{Tag: u.tag, Status: status, Info: info},
+		},
+	}
+	err := u.st.caller.Call("Uniter", "", "SetWorkloadStatus", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
+// WorkloadStatus returns the status of the unit's workload, as last
+// set by its charm.
+func (u *Unit) WorkloadStatus() (params.WorkloadStatus, string, error) {
+	var results params.WorkloadStatusResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "WorkloadStatus", args, &results)
+	if err != nil {
+		return "", "", err
+	}
+	if len(results.Results) != 1 {
+		return "", "", fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return "", "", result.Error
+	}
+	return result.Status, result.Info, nil
+}
+
+// ClaimLeadership claims, or renews, the leadership of the unit's
+// service, and returns whether the unit is now the leader. The API
+// server decides how long the lease lasts.
+func (u *Unit) ClaimLeadership() (bool, error) {
+	var results params.BoolResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "ClaimLeadership", args, &results)
+	if err != nil {
+		return false, err
+	}
+	if len(results.Results) != 1 {
+		return false, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.Result, nil
+}
+
+// RenewLeadership extends the lease of the unit's service if the unit
+// holds it, and returns whether it did. It never makes the unit the
+// leader.
+func (u *Unit) RenewLeadership() (bool, error) {
+	var results params.BoolResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "RenewLeadership", args, &results)
+	if err != nil {
+		return false, err
+	}
+	if len(results.Results) != 1 {
+		return false, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.Result, nil
+}
+
+// LeaderSettings returns the settings published by the leader of the
+// unit's service.
+func (u *Unit) LeaderSettings() (map[string]string, error) {
+	var results params.LeaderSettingsResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "LeaderSettings", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Settings, nil
+}
+
+// SetLeaderSettings updates the settings published by the unit, which
+// must be the leader of its service. Settings with an empty value are
+// deleted.
+func (u *Unit) SetLeaderSettings(settings map[string]string) error {
+	var result params.ErrorResults
+	args := params.SetLeaderSettings{
+		Entities: []params.EntityLeaderSettings{
+			{Tag: u.tag, Settings: settings},
+		},
+	}
+	err := u.st.caller.Call("Uniter", "", "SetLeaderSettings", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // EnsureDead sets the unit lifecycle to Dead if it is Alive or
 // Dying. It does nothing otherwise.
 func (u *Unit) EnsureDead() error {
@@ -143,6 +261,27 @@ func (u *Unit) ConfigSettings() (charm.Settings, error) {
 	return charm.Settings(result.Settings), nil
 }
 
+// StorageInstances returns the storage instances belonging to the
+// unit. Those not yet provisioned have an empty location.
+func (u *Unit) StorageInstances() ([]params.StorageInstance, error) {
+	var results params.StorageInstancesResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "StorageInstances", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Instances, nil
+}
+
 // ServiceName returns the service name.
 func (u *Unit) ServiceName() string {
 	return names.UnitService(u.Name())
@@ -206,6 +345,27 @@ func (u *Unit) Resolved() (params.ResolvedMode, error) {
 	return result.Mode, nil
 }
 
+// HookCancelRequested returns whether the unit's agent has been asked
+// to cancel the hook it is running.
+func (u *Unit) HookCancelRequested() (bool, error) {
+	var results params.BoolResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "HookCancelRequested", args, &results)
+	if err != nil {
+		return false, err
+	}
+	if len(results.Results) != 1 {
+		return false, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.Result, nil
+}
+
 // IsPrincipal returns whether the unit is deployed in its own container,
 // and can therefore have subordinate services deployed alongside it.
 //
@@ -360,6 +520,21 @@ func (u *Unit) OpenPort(protocol string, number int) error {
 	return result.OneError()
 }
 
+// AddMetrics records a batch of metrics reported by the unit.
+func (u *Unit) AddMetrics(metrics []params.Metric) error {
+	var result params.ErrorResults
+	args := params.MetricBatches{
+		Batches: []params.MetricBatch{
+			{Tag: u.tag, Metrics: metrics},
+		},
+	}
+	err := u.st.caller.Call("Uniter", "", "AddMetrics", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // ClosePort sets the policy of the port with protocol and number to
 // be closed.
 //
@@ -443,6 +618,20 @@ func (u *Unit) ClearResolved() error {
 	return result.OneError()
 }
 
+// ClearCancelHook removes any request to cancel the hook the unit's
+// agent is running.
+func (u *Unit) ClearCancelHook() error {
+	var result params.ErrorResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "ClearCancelHook", args, &result)
+	if err != nil {
+		return err
+	}
+	return result.OneError()
+}
+
 // WatchConfigSettings returns a watcher for observing changes to the
 // unit's service configuration settings. The unit must have a charm URL
 // set before this method is called, and the returned watcher will be
@@ -466,3 +655,47 @@ func (u *Unit) WatchConfigSettings() (watcher.NotifyWatcher, error) {
 	w := watcher.NewNotifyWatcher(u.st.caller, result)
 	return w, nil
 }
+
+// WatchLeaderSettings returns a watcher for observing changes to the
+// settings published by the leader of the unit's service.
+func (u *Unit) WatchLeaderSettings() (watcher.NotifyWatcher, error) {
+	var results params.NotifyWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "WatchLeaderSettings", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewNotifyWatcher(u.st.caller, result)
+	return w, nil
+}
+
+// WatchActions returns a StringsWatcher for observing the ids of
+// pending actions queued on the unit.
+func (u *Unit) WatchActions() (watcher.StringsWatcher, error) {
+	var results params.StringsWatchResults
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: u.tag}},
+	}
+	err := u.st.caller.Call("Uniter", "", "WatchActions", args, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results.Results) != 1 {
+		return nil, fmt.Errorf("expected one result, got %d", len(results.Results))
+	}
+	result := results.Results[0]
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	w := watcher.NewStringsWatcher(u.st.caller, result)
+	return w, nil
+}
@@ -4,6 +4,8 @@
 package uniter_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
 
 	"launchpad.net/juju-core/charm"
@@ -62,6 +64,56 @@ func (s *unitSuite) TestSetStatus(c *gc.C) {
 	c.Assert(data, gc.HasLen, 0)
 }
 
+func (s *unitSuite) TestWorkloadStatus(c *gc.C) {
+	status, info, err := s.apiUnit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadUnknown)
+	c.Assert(info, gc.Equals, "")
+
+	err = s.apiUnit.SetWorkloadStatus(params.WorkloadWaiting, "syncing database")
+	c.Assert(err, gc.IsNil)
+
+	status, info, err = s.wordpressUnit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadWaiting)
+	c.Assert(info, gc.Equals, "syncing database")
+	status, info, err = s.apiUnit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadWaiting)
+	c.Assert(info, gc.Equals, "syncing database")
+
+	err = s.apiUnit.SetWorkloadStatus("busy", "")
+	c.Assert(err, gc.ErrorMatches, `cannot set workload status of unit "wordpress/0": invalid workload status "busy"`)
+}
+
+func (s *unitSuite) TestLeadership(c *gc.C) {
+	claimed, err := s.apiUnit.ClaimLeadership()
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	leader, err := s.wordpressService.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, "wordpress/0")
+
+	err = s.apiUnit.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	settings, err := s.apiUnit.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]string{"master": "10.0.0.1"})
+}
+
+func (s *unitSuite) TestRenewLeadership(c *gc.C) {
+	renewed, err := s.apiUnit.RenewLeadership()
+	c.Assert(err, gc.IsNil)
+	c.Assert(renewed, jc.IsFalse)
+
+	claimed, err := s.apiUnit.ClaimLeadership()
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	renewed, err = s.apiUnit.RenewLeadership()
+	c.Assert(err, gc.IsNil)
+	c.Assert(renewed, jc.IsTrue)
+}
+
 func (s *unitSuite) TestEnsureDead(c *gc.C) {
 	c.Assert(s.wordpressUnit.Life(), gc.Equals, state.Alive)
 
@@ -177,6 +229,24 @@ func (s *unitSuite) TestResolve(c *gc.C) {
 	c.Assert(mode, gc.Equals, params.ResolvedNone)
 }
 
+func (s *unitSuite) TestCancelHook(c *gc.C) {
+	cancel, err := s.apiUnit.HookCancelRequested()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cancel, jc.IsFalse)
+
+	err = s.wordpressUnit.CancelHook()
+	c.Assert(err, gc.IsNil)
+	cancel, err = s.apiUnit.HookCancelRequested()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cancel, jc.IsTrue)
+
+	err = s.apiUnit.ClearCancelHook()
+	c.Assert(err, gc.IsNil)
+	cancel, err = s.apiUnit.HookCancelRequested()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cancel, jc.IsFalse)
+}
+
 func (s *unitSuite) TestIsPrincipal(c *gc.C) {
 	ok, err := s.apiUnit.IsPrincipal()
 	c.Assert(err, gc.IsNil)
@@ -221,6 +291,22 @@ func (s *unitSuite) TestGetSetPrivateAddress(c *gc.C) {
 	c.Assert(address, gc.Equals, "1.2.3.4")
 }
 
+func (s *unitSuite) TestAddMetrics(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	err := s.apiUnit.AddMetrics([]params.Metric{
+		{Key: "load", Value: 0.5, Time: now},
+		{Key: "load", Value: 0.75, Time: now.Add(time.Second)},
+	})
+	c.Assert(err, gc.IsNil)
+
+	values, err := s.wordpressService.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.DeepEquals, []state.Metric{
+		{Key: "load", Value: 0.5, Time: now},
+		{Key: "load", Value: 0.75, Time: now.Add(time.Second)},
+	})
+}
+
 func (s *unitSuite) TestOpenClosePort(c *gc.C) {
 	ports := s.wordpressUnit.OpenedPorts()
 	c.Assert(ports, gc.HasLen, 0)
@@ -351,6 +437,32 @@ func (s *unitSuite) TestWatchConfigSettings(c *gc.C) {
 	wc.AssertClosed()
 }
 
+func (s *unitSuite) TestWatchLeaderSettings(c *gc.C) {
+	claimed, err := s.wordpressUnit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+
+	w, err := s.apiUnit.WatchLeaderSettings()
+	c.Assert(err, gc.IsNil)
+	defer statetesting.AssertStop(c, w)
+	wc := statetesting.NewNotifyWatcherC(c, s.BackingState, w)
+
+	// Initial event.
+	wc.AssertOneChange()
+
+	// Renewing the lease is not reported.
+	_, err = s.wordpressUnit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	wc.AssertNoChange()
+
+	err = s.wordpressUnit.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
 func (s *unitSuite) TestServiceNameAndTag(c *gc.C) {
 	c.Assert(s.apiUnit.ServiceName(), gc.Equals, "wordpress")
 	c.Assert(s.apiUnit.ServiceTag(), gc.Equals, "service-wordpress")
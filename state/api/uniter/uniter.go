@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/names"
@@ -176,3 +177,17 @@ func (st *State) APIAddresses() ([]string, error) {
 	}
 	return result.Result, nil
 }
+
+// HookTimeout returns the environment's default limit on how long a
+// hook may run. A zero duration means hooks are not limited.
+func (st *State) HookTimeout() (time.Duration, error) {
+	var result params.DurationResult
+	err := st.caller.Call("Uniter", "", "HookTimeout", nil, &result)
+	if err != nil {
+		return 0, err
+	}
+	if err := result.Error; err != nil {
+		return 0, err
+	}
+	return result.Result, nil
+}
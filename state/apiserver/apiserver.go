@@ -82,6 +82,10 @@ func (srv *Server) Wait() error {
 type requestNotifier struct {
 	connCounter int64
 	identifier  string
+	// debug holds whether requests are logged.
+	debug bool
+	// auditor records the changes requested on the connection.
+	auditor *auditor
 }
 
 var globalCounter int64
@@ -92,10 +96,25 @@ func nextCounter() int64 {
 
 func (n *requestNotifier) SetIdentifier(identifier string) {
 	n.identifier = identifier
+	n.auditor.setTag(identifier)
+}
+
+// isQuietRequest reports whether the request should not be logged.
+// Pings are too frequent to be interesting, and logging the sending of
+// log records would produce further records to send.
+func isQuietRequest(req rpc.Request) bool {
+	switch {
+	case req.Type == "Pinger" && req.Action == "Ping":
+		return true
+	case req.Type == "Logger" && req.Action == "AddLogs":
+		return true
+	}
+	return false
 }
 
 func (n requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
-	if hdr.Request.Type == "Pinger" && hdr.Request.Action == "Ping" {
+	n.auditor.request(hdr, body)
+	if !n.debug || isQuietRequest(hdr.Request) {
 		return
 	}
 	// TODO(rog) 2013-10-11 remove secrets from some requests.
@@ -103,7 +122,8 @@ func (n requestNotifier) ServerRequest(hdr *rpc.Header, body interface{}) {
 }
 
 func (n requestNotifier) ServerReply(req rpc.Request, hdr *rpc.Header, body interface{}, timeSpent time.Duration) {
-	if req.Type == "Pinger" && req.Action == "Ping" {
+	n.auditor.reply(req, hdr, body)
+	if !n.debug || isQuietRequest(req) {
 		return
 	}
 	logger.Debugf("<- [%X] %s %s %s %s[%q].%s", n.connCounter, n.identifier, timeSpent, jsoncodec.DumpRequest(hdr, body), req.Type, req.Id, req.Action)
@@ -138,8 +158,40 @@ func (srv *Server) run(lis net.Listener) {
 			logger.Errorf("error serving RPCs: %v", err)
 		}
 	})
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.Handle("/log", websocket.Handler(func(conn *websocket.Conn) {
+		srv.wg.Add(1)
+		defer srv.wg.Done()
+		if srv.tomb.Err() != tomb.ErrStillAlive {
+			return
+		}
+		if err := srv.serveDebugLog(conn); err != nil {
+			logger.Errorf("error serving debug log: %v", err)
+		}
+	}))
+	charms := &charmsHandler{srv}
+	mux.Handle("/charms", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.wg.Add(1)
+		defer srv.wg.Done()
+		if srv.tomb.Err() != tomb.ErrStillAlive {
+			http.Error(w, "API server is stopping", http.StatusServiceUnavailable)
+			return
+		}
+		charms.ServeHTTP(w, r)
+	}))
+	tools := &toolsHandler{srv}
+	mux.Handle("/tools", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.wg.Add(1)
+		defer srv.wg.Done()
+		if srv.tomb.Err() != tomb.ErrStillAlive {
+			http.Error(w, "API server is stopping", http.StatusServiceUnavailable)
+			return
+		}
+		tools.ServeHTTP(w, r)
+	}))
 	// The error from http.Serve is not interesting.
-	http.Serve(lis, handler)
+	http.Serve(lis, mux)
 }
 
 // Addr returns the address that the server is listening on.
@@ -152,15 +204,14 @@ func (srv *Server) serveConn(wsConn *websocket.Conn) error {
 	if loggo.GetLogger("juju.rpc.jsoncodec").EffectiveLogLevel() <= loggo.TRACE {
 		codec.SetLogging(true)
 	}
-	var notifier rpc.RequestNotifier
-	var loginCallback func(string)
-	if logger.EffectiveLogLevel() <= loggo.DEBUG {
-		reqNotifier := &requestNotifier{nextCounter(), "<unknown>"}
-		loginCallback = reqNotifier.SetIdentifier
-		notifier = reqNotifier
+	notifier := &requestNotifier{
+		connCounter: nextCounter(),
+		identifier:  "<unknown>",
+		debug:       logger.EffectiveLogLevel() <= loggo.DEBUG,
+		auditor:     newAuditor(srv.state, wsConn.Request().RemoteAddr),
 	}
 	conn := rpc.NewConn(codec, notifier)
-	conn.Serve(newStateServer(srv, conn, loginCallback), serverError)
+	conn.Serve(newStateServer(srv, conn, notifier.SetIdentifier), serverError)
 	conn.Start()
 	select {
 	case <-conn.Dead():
@@ -0,0 +1,252 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/rpc"
+	"launchpad.net/juju-core/state"
+)
+
+// readOnlyClientMethods holds the Client methods that do not change the
+// environment, and so are not recorded in the audit log. Any other
+// Client call is recorded.
+var readOnlyClientMethods = map[string]bool{
+	"AuditLog":                  true,
+	"CharmInfo":                 true,
+	"EnvironmentGet":            true,
+	"EnvironmentInfo":           true,
+	"FetchAction":               true,
+	"FullStatus":                true,
+	"GetAnnotations":            true,
+	"GetEnvironmentConstraints": true,
+	"GetServiceConstraints":     true,
+	"ListBlocks":                true,
+	"MachineConfig":             true,
+	"Metrics":                   true,
+	"PublicAddress":             true,
+	"ServiceCharmRelations":     true,
+	"ServiceGet":                true,
+	"ServiceGetCharmURL":        true,
+	"ServiceGetScaling":         true,
+	"Status":                    true,
+	"StatusHistory":             true,
+	"Users":                     true,
+	"WatchAll":                  true,
+}
+
+// isAuditedRequest reports whether the request should be recorded in
+// the audit log.
+func isAuditedRequest(req rpc.Request) bool {
+	return req.Type == "Client" && !readOnlyClientMethods[req.Action]
+}
+
+// secretKeyPattern matches the keys of values that are masked in the
+// audit log, in addition to the secret attributes of the environment's
+// provider.
+var secretKeyPattern = regexp.MustCompile(`(?i)password|secret|private-key|token`)
+
+// maskedValue replaces secret values in the audit log.
+const maskedValue = "*****"
+
+// auditCall holds the details of an audited request while it runs.
+type auditCall struct {
+	time time.Time
+	args string
+}
+
+// auditor records the audited requests made on a connection.
+type auditor struct {
+	st         *state.State
+	remoteAddr string
+
+	mu         sync.Mutex
+	tag        string
+	calls      map[uint64]auditCall
+	secretKeys map[string]bool
+}
+
+func newAuditor(st *state.State, remoteAddr string) *auditor {
+	return &auditor{
+		st:         st,
+		remoteAddr: remoteAddr,
+		tag:        "<unknown>",
+		calls:      make(map[uint64]auditCall),
+	}
+}
+
+// setTag records the tag of the entity that logged in on the connection.
+func (a *auditor) setTag(tag string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tag = tag
+}
+
+// request notes the arguments of an audited request.
+func (a *auditor) request(hdr *rpc.Header, body interface{}) {
+	if !isAuditedRequest(hdr.Request) || body == nil {
+		return
+	}
+	args := a.redact(body)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls[hdr.RequestId] = auditCall{time.Now(), args}
+}
+
+// reply records an audited request, and its outcome, in the audit log.
+func (a *auditor) reply(req rpc.Request, hdr *rpc.Header, body interface{}) {
+	a.mu.Lock()
+	call, ok := a.calls[hdr.RequestId]
+	delete(a.calls, hdr.RequestId)
+	tag := a.tag
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+	entry := state.AuditEntry{
+		Time:          call.time,
+		Tag:           tag,
+		RemoteAddress: a.remoteAddr,
+		Method:        req.Type + "." + req.Action,
+		Args:          call.args,
+		Error:         hdr.Error,
+		ErrorCode:     hdr.ErrorCode,
+	}
+	if hdr.Error == "" {
+		entry.Result = a.redact(body)
+	}
+	if err := a.st.AddAuditEntry(entry); err != nil {
+		logger.Errorf("%v", err)
+	}
+}
+
+// redact returns the JSON encoding of v, with the values of any
+// secret-looking keys masked.
+func (a *auditor) redact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return ""
+	}
+	generic = a.mask(generic)
+	if data, err = json.Marshal(generic); err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// mask replaces the values of secret keys anywhere within v.
+func (a *auditor) mask(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if a.isSecret(key) && value != nil {
+				v[key] = maskedValue
+			} else {
+				v[key] = a.mask(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = a.mask(value)
+		}
+	case string:
+		return a.maskYAML(v)
+	}
+	return v
+}
+
+// maskYAML masks the values of secret keys within s if it holds a YAML
+// document, as the configuration arguments of ServiceDeploy and
+// ServiceSetYAML do. Any other string is returned unchanged.
+func (a *auditor) maskYAML(s string) string {
+	if !strings.Contains(s, ":") {
+		return s
+	}
+	var doc interface{}
+	if err := goyaml.Unmarshal([]byte(s), &doc); err != nil {
+		return s
+	}
+	if !a.maskYAMLValue(doc) {
+		return s
+	}
+	data, err := goyaml.Marshal(doc)
+	if err != nil {
+		return maskedValue
+	}
+	return string(data)
+}
+
+// maskYAMLValue replaces the values of secret keys anywhere within the
+// unmarshalled YAML value v, and reports whether any were replaced.
+func (a *auditor) maskYAMLValue(v interface{}) bool {
+	masked := false
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			if key, ok := key.(string); ok && a.isSecret(key) && value != nil {
+				v[key] = maskedValue
+				masked = true
+			} else if a.maskYAMLValue(value) {
+				masked = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if a.maskYAMLValue(value) {
+				masked = true
+			}
+		}
+	}
+	return masked
+}
+
+// isSecret reports whether the value of the key should be masked.
+func (a *auditor) isSecret(key string) bool {
+	if secretKeyPattern.MatchString(key) {
+		return true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.secretKeys == nil {
+		a.secretKeys = a.providerSecretKeys()
+	}
+	return a.secretKeys[key]
+}
+
+// providerSecretKeys returns the keys of the secret attributes of the
+// environment, as reported by its provider.
+func (a *auditor) providerSecretKeys() map[string]bool {
+	keys := make(map[string]bool)
+	cfg, err := a.st.EnvironConfig()
+	if err != nil {
+		logger.Errorf("cannot get environment config to mask secrets: %v", err)
+		return keys
+	}
+	p, err := environs.Provider(cfg.Type())
+	if err != nil {
+		logger.Errorf("cannot get provider to mask secrets: %v", err)
+		return keys
+	}
+	secrets, err := p.SecretAttrs(cfg)
+	if err != nil {
+		logger.Errorf("cannot get secret attributes to mask: %v", err)
+		return keys
+	}
+	for key := range secrets {
+		keys[key] = true
+	}
+	return keys
+}
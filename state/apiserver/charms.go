@@ -0,0 +1,107 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+)
+
+// charmsHandler handles uploads of local charms. A charm is uploaded
+// by POSTing its bundle, a zip archive, to /charms, with the series
+// of the charm given by the "series" query parameter. The client
+// authenticates with HTTP basic authentication. The response is a
+// JSON-encoded params.CharmsResponse holding the URL the charm was
+// added with, or an error.
+type charmsHandler struct {
+	srv *Server
+}
+
+func (h *charmsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.srv.authenticateUser(r.Header, state.WritePermission); err != nil {
+		status := http.StatusUnauthorized
+		if err == common.ErrPerm {
+			status = http.StatusForbidden
+		}
+		h.sendError(w, status, err.Error())
+		return
+	}
+	if r.Method != "POST" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	// Uploading a charm is a change to the environment, like deploying it.
+	if err := common.CheckNotBlocked(h.srv.state, state.ChangeBlock); err != nil {
+		h.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	curl, err := h.processPost(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.sendJSON(w, http.StatusOK, &params.CharmsResponse{CharmURL: curl.String()})
+}
+
+// processPost adds the charm bundle held in the body of the request
+// to the environment, and returns its URL.
+func (h *charmsHandler) processPost(r *http.Request) (*charm.URL, error) {
+	series := r.URL.Query().Get("series")
+	if series == "" {
+		return nil, fmt.Errorf("expected series= URL argument")
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/zip" {
+		return nil, fmt.Errorf("expected Content-Type: application/zip, got: %v", contentType)
+	}
+	tempFile, err := ioutil.TempFile("", "charm")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	if _, err := io.Copy(tempFile, r.Body); err != nil {
+		return nil, fmt.Errorf("error processing file upload: %v", err)
+	}
+	bundle, err := charm.ReadBundle(tempFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid charm archive: %v", err)
+	}
+	curl, err := charm.ParseURL(fmt.Sprintf("local:%s/%s-%d", series, bundle.Meta().Name, bundle.Revision()))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := juju.NewConnFromState(h.srv.state)
+	if err != nil {
+		return nil, err
+	}
+	sch, err := conn.EnsureCharm(curl, bundle)
+	if err != nil {
+		return nil, err
+	}
+	return sch.URL(), nil
+}
+
+// sendJSON sends a JSON-encoded response to the client.
+func (h *charmsHandler) sendJSON(w http.ResponseWriter, statusCode int, response *params.CharmsResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Errorf("cannot send charm upload response: %v", err)
+	}
+}
+
+// sendError sends a JSON-encoded error response to the client.
+func (h *charmsHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	h.sendJSON(w, statusCode, &params.CharmsResponse{Error: message})
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type charmsSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&charmsSuite{})
+
+func (s *charmsSuite) TestAddLocalCharmBundle(c *gc.C) {
+	ch := coretesting.Charms.Bundle(c.MkDir(), "dummy")
+	curl := charm.MustParseURL(fmt.Sprintf("local:quantal/%s-%d", ch.Meta().Name, ch.Revision()))
+	addedURL, err := s.APIState.Client().AddLocalCharm(curl, ch)
+	c.Assert(err, gc.IsNil)
+	c.Assert(addedURL, gc.DeepEquals, curl)
+	sch, err := s.State.Charm(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(sch.Meta().Name, gc.Equals, "dummy")
+	c.Assert(sch.BundleURL(), gc.NotNil)
+
+	// Uploading the same charm again returns the charm already added.
+	addedURL, err = s.APIState.Client().AddLocalCharm(curl, ch)
+	c.Assert(err, gc.IsNil)
+	c.Assert(addedURL, gc.DeepEquals, curl)
+}
+
+func (s *charmsSuite) TestAddLocalCharmDir(c *gc.C) {
+	ch := coretesting.Charms.ClonedDir(c.MkDir(), "dummy")
+	curl := charm.MustParseURL(fmt.Sprintf("local:precise/%s-%d", ch.Meta().Name, ch.Revision()))
+	addedURL, err := s.APIState.Client().AddLocalCharm(curl, ch)
+	c.Assert(err, gc.IsNil)
+	c.Assert(addedURL, gc.DeepEquals, curl)
+	_, err = s.State.Charm(curl)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *charmsSuite) TestAddLocalCharmRequiresLocalURL(c *gc.C) {
+	ch := coretesting.Charms.Dir("dummy")
+	_, err := s.APIState.Client().AddLocalCharm(charm.MustParseURL("cs:quantal/dummy-1"), ch)
+	c.Assert(err, gc.ErrorMatches, `expected charm URL with local: schema, got "cs:quantal/dummy-1"`)
+}
+
+func (s *charmsSuite) TestWritePermissionRequired(c *gc.C) {
+	_, err := s.State.AddUserWithPermission("reader", "password", state.ReadPermission)
+	c.Assert(err, gc.IsNil)
+	st := s.OpenAPIAs(c, "user-reader", "password")
+	ch := coretesting.Charms.Dir("dummy")
+	curl := charm.MustParseURL(fmt.Sprintf("local:quantal/dummy-%d", ch.Revision()))
+	_, err = st.Client().AddLocalCharm(curl, ch)
+	c.Assert(err, gc.ErrorMatches, "cannot upload charm: permission denied")
+	_, err = s.State.Charm(curl)
+	c.Assert(err, gc.NotNil)
+}
+
+func (s *charmsSuite) TestUploadRefusedWhenChangesBlocked(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.ChangeBlock, "frozen")
+	c.Assert(err, gc.IsNil)
+	ch := coretesting.Charms.Dir("dummy")
+	curl := charm.MustParseURL(fmt.Sprintf("local:quantal/dummy-%d", ch.Revision()))
+	_, err = s.APIState.Client().AddLocalCharm(curl, ch)
+	c.Assert(err, gc.ErrorMatches, `cannot upload charm: operation blocked by "all-changes" block: frozen`)
+	_, err = s.State.Charm(curl)
+	c.Assert(err, gc.NotNil)
+}
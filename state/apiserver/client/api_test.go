@@ -159,6 +159,9 @@ var scenarioStatus = &api.Status{
 // When the scenario is initialized, we have:
 // user-admin
 // user-other
+//  permission=write
+// user-reader
+//  permission=read
 // machine-0
 //  instance-id="i-machine-0"
 //  nonce="fake_nonce"
@@ -203,7 +206,12 @@ func (s *baseSuite) setUpScenario(c *gc.C) (entities []string) {
 	setDefaultPassword(c, u)
 	add(u)
 
-	u, err = s.State.AddUser("other", "")
+	u, err = s.State.AddUserWithPermission("other", "", state.WritePermission)
+	c.Assert(err, gc.IsNil)
+	setDefaultPassword(c, u)
+	add(u)
+
+	u, err = s.State.AddUserWithPermission("reader", "", state.ReadPermission)
 	c.Assert(err, gc.IsNil)
 	setDefaultPassword(c, u)
 	add(u)
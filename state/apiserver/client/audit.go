@@ -0,0 +1,41 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// AuditLog returns the entries of the audit log selected by the filter,
+// oldest first.
+func (c *Client) AuditLog(args params.AuditLogFilter) (params.AuditLogResults, error) {
+	var results params.AuditLogResults
+	if err := c.checkPermission(state.AdminPermission); err != nil {
+		return results, err
+	}
+	entries, err := c.api.state.AuditEntries(state.AuditFilter{
+		Since: args.Since,
+		Until: args.Until,
+		Tag:   args.Tag,
+		Limit: args.Limit,
+	})
+	if err != nil {
+		return results, err
+	}
+	results.Entries = make([]params.AuditLogEntry, len(entries))
+	for i, entry := range entries {
+		results.Entries[i] = params.AuditLogEntry{
+			Time:          entry.Time,
+			Tag:           entry.Tag,
+			RemoteAddress: entry.RemoteAddress,
+			Method:        entry.Method,
+			Args:          entry.Args,
+			Result:        entry.Result,
+			Error:         entry.Error,
+			ErrorCode:     entry.ErrorCode,
+		}
+	}
+	return results, nil
+}
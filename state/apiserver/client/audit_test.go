@@ -0,0 +1,109 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type auditSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&auditSuite{})
+
+func (s *auditSuite) TestAuditLogRecordsChanges(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	start := time.Now().Add(-time.Second)
+
+	err := client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceExpose("unknown")
+	c.Assert(err, gc.NotNil)
+	// Read-only calls are not recorded.
+	_, err = client.FullStatus(nil)
+	c.Assert(err, gc.IsNil)
+
+	entries, err := client.AuditLog(params.AuditLogFilter{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 2)
+	for _, entry := range entries {
+		c.Check(entry.Tag, gc.Equals, "user-admin")
+		c.Check(entry.RemoteAddress, gc.Not(gc.Equals), "")
+		c.Check(entry.Method, gc.Equals, "Client.ServiceExpose")
+		c.Check(entry.Time.After(start), jc.IsTrue)
+	}
+	c.Check(entries[0].Args, gc.Equals, `{"ServiceName":"wordpress"}`)
+	c.Check(entries[0].Result, gc.Equals, `{}`)
+	c.Check(entries[0].Error, gc.Equals, "")
+	c.Check(entries[1].Args, gc.Equals, `{"ServiceName":"unknown"}`)
+	c.Check(entries[1].Result, gc.Equals, "")
+	c.Check(entries[1].Error, gc.Equals, `service "unknown" not found`)
+	c.Check(entries[1].ErrorCode, gc.Equals, params.CodeNotFound)
+}
+
+func (s *auditSuite) TestAuditLogMasksSecrets(c *gc.C) {
+	client := s.APIState.Client()
+	err := client.AddUser("bob", "sekrit", "read")
+	c.Assert(err, gc.IsNil)
+	err = client.EnvironmentSet(map[string]interface{}{
+		"secret":         "pork",
+		"default-series": "precise",
+	})
+	c.Assert(err, gc.IsNil)
+
+	entries, err := client.AuditLog(params.AuditLogFilter{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 2)
+	c.Check(entries[0].Method, gc.Equals, "Client.AddUser")
+	c.Check(entries[0].Args, gc.Equals, `{"Password":"*****","Permission":"read","Username":"bob"}`)
+	c.Check(entries[1].Method, gc.Equals, "Client.EnvironmentSet")
+	c.Check(entries[1].Args, gc.Equals, `{"Config":{"default-series":"precise","secret":"*****"}}`)
+}
+
+func (s *auditSuite) TestAuditLogMasksSecretsInYAML(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	// The call fails because the charm has no such options, but its
+	// arguments are recorded all the same.
+	err := client.ServiceSetYAML("wordpress", "wordpress:\n  admin-password: sekrit\n  title: blog\n")
+	c.Assert(err, gc.NotNil)
+
+	entries, err := client.AuditLog(params.AuditLogFilter{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	c.Check(entries[0].Method, gc.Equals, "Client.ServiceSetYAML")
+	c.Check(entries[0].Args, gc.Not(jc.Contains), "sekrit")
+	c.Check(entries[0].Args, jc.Contains, "admin-password")
+	c.Check(entries[0].Args, jc.Contains, "*****")
+	c.Check(entries[0].Args, jc.Contains, "title: blog")
+}
+
+func (s *auditSuite) TestAuditLogFilter(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	err := client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceUnexpose("wordpress")
+	c.Assert(err, gc.IsNil)
+
+	entries, err := client.AuditLog(params.AuditLogFilter{Limit: 1})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 1)
+	c.Assert(entries[0].Method, gc.Equals, "Client.ServiceUnexpose")
+
+	entries, err = client.AuditLog(params.AuditLogFilter{Tag: "user-other"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+
+	entries, err = client.AuditLog(params.AuditLogFilter{Since: time.Now().Add(time.Hour)})
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.HasLen, 0)
+}
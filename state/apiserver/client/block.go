@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+)
+
+// checkNotBlocked returns an error with the CodeOperationBlocked code,
+// giving the stored reason, if a block of any of the given types is
+// switched on.
+func (c *Client) checkNotBlocked(types ...state.BlockType) error {
+	return common.CheckNotBlocked(c.api.state, types...)
+}
+
+// checkCanChange returns an error unless the authenticated user holds
+// the given permission and changes to the environment are not blocked.
+func (c *Client) checkCanChange(perm state.Permission) error {
+	if err := c.checkPermission(perm); err != nil {
+		return err
+	}
+	return c.checkNotBlocked(state.ChangeBlock)
+}
+
+// checkCanRemove returns an error unless the authenticated user holds
+// the given permission and the removal of objects from the environment
+// is not blocked.
+func (c *Client) checkCanRemove(perm state.Permission) error {
+	if err := c.checkPermission(perm); err != nil {
+		return err
+	}
+	return c.checkNotBlocked(state.RemoveBlock, state.ChangeBlock)
+}
+
+// SwitchBlockOn switches on a block of the given type, which prevents
+// the operations of that type until it is switched off.
+func (c *Client) SwitchBlockOn(args params.BlockSwitchParams) error {
+	if err := c.checkPermission(state.AdminPermission); err != nil {
+		return err
+	}
+	t, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOn(t, args.Message)
+}
+
+// SwitchBlockOff switches off the block of the given type.
+func (c *Client) SwitchBlockOff(args params.BlockSwitchParams) error {
+	if err := c.checkPermission(state.AdminPermission); err != nil {
+		return err
+	}
+	t, err := state.ParseBlockType(args.Type)
+	if err != nil {
+		return err
+	}
+	return c.api.state.SwitchBlockOff(t)
+}
+
+// ListBlocks returns the blocks switched on in the environment.
+func (c *Client) ListBlocks() (params.BlockResults, error) {
+	var results params.BlockResults
+	blocks, err := c.api.state.Blocks()
+	if err != nil {
+		return results, err
+	}
+	for _, b := range blocks {
+		results.Blocks = append(results.Blocks, params.Block{
+			Type:    string(b.Type()),
+			Message: b.Message(),
+		})
+	}
+	return results, nil
+}
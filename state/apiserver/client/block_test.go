@@ -0,0 +1,98 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type blockSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&blockSuite{})
+
+func (s *blockSuite) TestListBlocks(c *gc.C) {
+	client := s.APIState.Client()
+	blocks, err := client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, gc.HasLen, 0)
+
+	err = client.SwitchBlockOn("all-changes", "product launch")
+	c.Assert(err, gc.IsNil)
+	err = client.SwitchBlockOn("destroy", "")
+	c.Assert(err, gc.IsNil)
+	blocks, err = client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, gc.DeepEquals, []params.Block{
+		{Type: "destroy"},
+		{Type: "all-changes", Message: "product launch"},
+	})
+
+	err = client.SwitchBlockOff("all-changes")
+	c.Assert(err, gc.IsNil)
+	blocks, err = client.ListBlocks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(blocks, gc.DeepEquals, []params.Block{{Type: "destroy"}})
+}
+
+func (s *blockSuite) TestSwitchUnknownBlock(c *gc.C) {
+	err := s.APIState.Client().SwitchBlockOn("everything", "")
+	c.Assert(err, gc.ErrorMatches, `unknown block type "everything"`)
+}
+
+func (s *blockSuite) TestChangesBlocked(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	err := client.SwitchBlockOn("all-changes", "product launch")
+	c.Assert(err, gc.IsNil)
+
+	err = client.ServiceExpose("wordpress")
+	c.Assert(err, gc.ErrorMatches, `operation blocked by "all-changes" block: product launch`)
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	err = client.ServiceDestroy("mysql")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	_, err = client.AddServiceUnits("wordpress", 1, "")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+
+	// Read-only operations are not blocked.
+	_, err = client.FullStatus(nil)
+	c.Assert(err, gc.IsNil)
+
+	err = client.SwitchBlockOff("all-changes")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *blockSuite) TestRemovalsBlocked(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	err := client.SwitchBlockOn("remove-object", "")
+	c.Assert(err, gc.IsNil)
+
+	err = client.ServiceDestroy("mysql")
+	c.Assert(err, gc.ErrorMatches, `operation blocked by "remove-object" block`)
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	err = client.DestroyMachines("2")
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+	err = client.DestroyServiceUnits([]string{"wordpress/0"})
+	c.Assert(err, jc.Satisfies, params.IsCodeOperationBlocked)
+
+	// Other changes are still allowed.
+	err = client.ServiceExpose("wordpress")
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *blockSuite) TestDestroyBlockAllowsRemovals(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	err := client.SwitchBlockOn("destroy", "")
+	c.Assert(err, gc.IsNil)
+	err = client.ServiceDestroy("mysql")
+	c.Assert(err, gc.IsNil)
+}
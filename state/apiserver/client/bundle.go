@@ -0,0 +1,276 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/juju"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// DeployBundle deploys the services and relations described by a
+// bundle. The whole bundle is verified against the charms it uses, and
+// against the services already in the environment, before any changes
+// are made. Existing services are reused when they use the same charm;
+// their settings, constraints and exposure are updated, and units and
+// relations are only added when missing, so that deploying the same
+// bundle twice has no further effect.
+func (c *Client) DeployBundle(args params.DeployBundle) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	bd, err := charm.ReadBundleData(strings.NewReader(args.YAML))
+	if err != nil {
+		return err
+	}
+	if err := bd.Verify(verifyConstraints); err != nil {
+		return err
+	}
+	conn, err := juju.NewConnFromState(c.api.state)
+	if err != nil {
+		return err
+	}
+	d := &bundleDeployer{
+		st:     c.api.state,
+		conn:   conn,
+		bd:     bd,
+		charms: make(map[string]charm.Charm),
+		curls:  make(map[string]*charm.URL),
+	}
+	if err := d.prepare(); err != nil {
+		return err
+	}
+	return d.deploy()
+}
+
+func verifyConstraints(s string) error {
+	_, err := constraints.Parse(s)
+	return err
+}
+
+// bundleDeployer holds the state of a single bundle deployment.
+type bundleDeployer struct {
+	st   *state.State
+	conn *juju.Conn
+	bd   *charm.BundleData
+
+	// charms and curls hold the charm used by each charm URL in
+	// the bundle, and the fully resolved URL of that charm.
+	charms map[string]charm.Charm
+	curls  map[string]*charm.URL
+}
+
+// prepare fetches the charms used by the bundle, and checks the bundle
+// against them and against the existing services, without changing
+// the environment.
+func (d *bundleDeployer) prepare() error {
+	for _, url := range d.bd.RequiredCharms() {
+		curl, ch, err := d.fetchCharm(url)
+		if err != nil {
+			return err
+		}
+		d.curls[url] = curl
+		d.charms[url] = ch
+	}
+	for _, name := range d.bd.ServiceNames() {
+		svc, err := d.st.Service(name)
+		if errors.IsNotFoundError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		url := d.bd.Services[name].Charm
+		existing, _ := svc.CharmURL()
+		if !charmMatches(url, existing) {
+			return fmt.Errorf("cannot deploy bundle: service %q already exists with charm %q, not %q", name, existing, url)
+		}
+		if *existing != *d.curls[url] {
+			// The bundle does not specify a revision; use the
+			// existing service's charm rather than the latest.
+			ch, err := d.st.Charm(existing)
+			if err != nil {
+				return err
+			}
+			d.curls[url] = existing
+			d.charms[url] = ch
+		}
+	}
+	return d.bd.VerifyWithCharms(verifyConstraints, d.charms)
+}
+
+// fetchCharm returns the fully resolved URL and contents of the charm
+// with the given URL. Charm store charms are fetched from the store;
+// local charms must already have been added to the environment.
+func (d *bundleDeployer) fetchCharm(url string) (*charm.URL, charm.Charm, error) {
+	curl, err := charm.ParseURL(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch curl.Schema {
+	case "cs":
+		if curl.Revision < 0 {
+			rev, err := CharmStore.Latest(curl)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot get latest revision of charm %q: %v", url, err)
+			}
+			curl = curl.WithRevision(rev)
+		}
+		ch, err := CharmStore.Get(curl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot get charm %q: %v", url, err)
+		}
+		return curl, ch, nil
+	case "local":
+		if curl.Revision < 0 {
+			return nil, nil, fmt.Errorf("local charm URL %q must include revision", url)
+		}
+		ch, err := d.st.Charm(curl)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot get charm %q: %v", url, err)
+		}
+		return curl, ch, nil
+	}
+	return nil, nil, fmt.Errorf("charm url has unsupported schema %q", curl.Schema)
+}
+
+// charmMatches returns whether an existing service using the charm
+// with the URL existing may be used for a bundle service using the
+// charm with the given URL. When that URL has no revision, any
+// revision of the charm matches.
+func charmMatches(url string, existing *charm.URL) bool {
+	curl := charm.MustParseURL(url)
+	if curl.Revision < 0 {
+		existing = existing.WithRevision(-1)
+	}
+	return *curl == *existing
+}
+
+// deploy makes the changes needed for the environment to match the
+// bundle.
+func (d *bundleDeployer) deploy() error {
+	order, err := d.bd.DeploymentOrder()
+	if err != nil {
+		return err
+	}
+	for _, name := range order {
+		if err := d.deployService(name); err != nil {
+			return fmt.Errorf("cannot deploy service %q: %v", name, err)
+		}
+	}
+	for _, relation := range d.bd.Relations {
+		if err := d.addRelation(relation); err != nil {
+			return fmt.Errorf("cannot add relation %q: %v", relation, err)
+		}
+	}
+	return nil
+}
+
+func (d *bundleDeployer) deployService(name string) error {
+	spec := d.bd.Services[name]
+	ch := d.charms[spec.Charm]
+	settings, err := ch.Config().ValidateSettings(spec.Options)
+	if err != nil {
+		return err
+	}
+	var cons constraints.Value
+	if spec.Constraints != "" {
+		if cons, err = constraints.Parse(spec.Constraints); err != nil {
+			return err
+		}
+	}
+	svc, err := d.st.Service(name)
+	if errors.IsNotFoundError(err) {
+		sch, err := d.putCharm(spec.Charm)
+		if err != nil {
+			return err
+		}
+		svc, err = d.conn.DeployService(juju.DeployServiceParams{
+			ServiceName:    name,
+			Charm:          sch,
+			ConfigSettings: settings,
+			Constraints:    cons,
+		})
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		if len(settings) > 0 {
+			if err := svc.UpdateConfigSettings(settings); err != nil {
+				return err
+			}
+		}
+		if spec.Constraints != "" {
+			if err := svc.SetConstraints(cons); err != nil {
+				return err
+			}
+		}
+	}
+	if spec.Expose && !svc.IsExposed() {
+		if err := svc.SetExposed(); err != nil {
+			return err
+		}
+	}
+	units, err := svc.AllUnits()
+	if err != nil {
+		return err
+	}
+	for i := len(units); i < spec.NumUnits; i++ {
+		var machineSpec string
+		if i < len(spec.To) {
+			if machineSpec, err = d.machineSpec(spec.To[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := d.conn.AddUnits(svc, 1, machineSpec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putCharm adds the charm with the given bundle URL to the
+// environment, if it is not already there.
+func (d *bundleDeployer) putCharm(url string) (*state.Charm, error) {
+	curl := d.curls[url]
+	if curl.Schema == "local" {
+		return d.st.Charm(curl)
+	}
+	return d.conn.PutCharm(curl, CharmStore, false)
+}
+
+// machineSpec returns the machine specification, as accepted by
+// juju.Conn.AddUnits, for the given bundle placement.
+func (d *bundleDeployer) machineSpec(to string) (string, error) {
+	if _, _, ok := charm.ParseBundleUnitPlacement(to); !ok {
+		return to, nil
+	}
+	unit, err := d.st.Unit(to)
+	if err != nil {
+		return "", err
+	}
+	return unit.AssignedMachineId()
+}
+
+// addRelation adds the given bundle relation, if it does not exist.
+func (d *bundleDeployer) addRelation(relation []string) error {
+	eps, err := d.st.InferEndpoints(relation)
+	if err != nil {
+		return err
+	}
+	if _, err := d.st.EndpointsRelation(eps...); err == nil {
+		return nil
+	} else if !errors.IsNotFoundError(err) {
+		return err
+	}
+	_, err = d.st.AddRelation(eps...)
+	return err
+}
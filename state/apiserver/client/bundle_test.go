@@ -0,0 +1,129 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+const testBundle = `
+services:
+    wordpress:
+        charm: cs:precise/wordpress
+        num_units: 2
+        to: ["mysql/0"]
+        expose: true
+        options:
+            blog-title: Bundled
+    mysql:
+        charm: cs:precise/mysql
+        num_units: 1
+        constraints: mem=4G
+    logging:
+        charm: cs:precise/logging
+relations:
+    - ["wordpress:db", "mysql:server"]
+    - ["wordpress:logging-dir", "logging:logging-directory"]
+`
+
+func (s *clientSuite) TestClientDeployBundle(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	addCharm(c, store, "wordpress")
+	addCharm(c, store, "mysql")
+	addCharm(c, store, "logging")
+
+	err := s.APIState.Client().DeployBundle(testBundle)
+	c.Assert(err, gc.IsNil)
+	s.assertBundleDeployed(c)
+
+	// Deploying the bundle again changes nothing.
+	err = s.APIState.Client().DeployBundle(testBundle)
+	c.Assert(err, gc.IsNil)
+	s.assertBundleDeployed(c)
+}
+
+func (s *clientSuite) assertBundleDeployed(c *gc.C) {
+	wordpress, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.IsExposed(), gc.Equals, true)
+	settings, err := wordpress.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, charm.Settings{"blog-title": "Bundled"})
+	units, err := wordpress.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 2)
+
+	mysql, err := s.State.Service("mysql")
+	c.Assert(err, gc.IsNil)
+	cons, err := mysql.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cons, gc.DeepEquals, constraints.MustParse("mem=4G"))
+	mysqlUnits, err := mysql.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(mysqlUnits, gc.HasLen, 1)
+
+	// The first wordpress unit shares the mysql unit's machine.
+	wordpressUnit, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	wordpressId, err := wordpressUnit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	mysqlId, err := mysqlUnits[0].AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpressId, gc.Equals, mysqlId)
+
+	logging, err := s.State.Service("logging")
+	c.Assert(err, gc.IsNil)
+	rels, err := logging.Relations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(rels, gc.HasLen, 1)
+	rels, err = wordpress.Relations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(rels, gc.HasLen, 2)
+}
+
+func (s *clientSuite) TestClientDeployBundleVerifiesBeforeChanges(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	addCharm(c, store, "wordpress")
+	addCharm(c, store, "mysql")
+	addCharm(c, store, "logging")
+
+	err := s.APIState.Client().DeployBundle(`
+services:
+    wordpress:
+        charm: cs:precise/wordpress
+        num_units: 1
+    mysql:
+        charm: cs:precise/mysql
+        num_units: 1
+relations:
+    - ["wordpress:cache", "mysql"]
+`)
+	c.Assert(err, gc.ErrorMatches, `no relations found for \["wordpress:cache" "mysql"\]`)
+	for _, name := range []string{"wordpress", "mysql"} {
+		_, err = s.State.Service(name)
+		c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	}
+}
+
+func (s *clientSuite) TestClientDeployBundleExistingServiceMismatch(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	addCharm(c, store, "wordpress")
+	addCharm(c, store, "mysql")
+	addCharm(c, store, "logging")
+	_, err := s.State.AddService("mysql", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+
+	err = s.APIState.Client().DeployBundle(testBundle)
+	c.Assert(err, gc.ErrorMatches, `cannot deploy bundle: service "mysql" already exists with charm "local:quantal/dummy-1", not "cs:precise/mysql"`)
+	_, err = s.State.Service("wordpress")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
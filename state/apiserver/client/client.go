@@ -10,7 +10,9 @@ import (
 	"launchpad.net/loggo"
 
 	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/environs"
+	coreerrors "launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/juju"
 	"launchpad.net/juju-core/names"
@@ -51,7 +53,7 @@ func NewAPI(st *state.State, resources *common.Resources, authorizer common.Auth
 // Client returns an object that provides access
 // to methods accessible to non-agent clients.
 func (r *API) Client(id string) (*Client, error) {
-	if !r.auth.AuthClient() {
+	if !r.auth.AuthClient() || !r.auth.AuthUserPermission(state.ReadPermission) {
 		return nil, common.ErrPerm
 	}
 	if id != "" {
@@ -61,6 +63,15 @@ func (r *API) Client(id string) (*Client, error) {
 	return r.client, nil
 }
 
+// checkPermission returns an error unless the authenticated user
+// holds the given permission.
+func (c *Client) checkPermission(perm state.Permission) error {
+	if !c.api.auth.AuthUserPermission(perm) {
+		return common.ErrPerm
+	}
+	return nil
+}
+
 func (c *Client) Status() (api.Status, error) {
 	ms, err := c.api.state.AllMachines()
 	if err != nil {
@@ -94,6 +105,9 @@ func (c *Client) WatchAll() (params.AllWatcherId, error) {
 // (Deprecated) Use NewServiceSetForClientAPI instead, to preserve values set to
 // an empty string, and use ServiceUnset to unset values.
 func (c *Client) ServiceSet(p params.ServiceSet) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -109,6 +123,9 @@ func (c *Client) ServiceSet(p params.ServiceSet) error {
 // TODO(Nate): rename this to ServiceSet (and remove the deprecated ServiceSet)
 // when the GUI handles the new behavior.
 func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -118,6 +135,9 @@ func (c *Client) NewServiceSetForClientAPI(p params.ServiceSet) error {
 
 // ServiceUnset implements the server side of Client.ServiceUnset.
 func (c *Client) ServiceUnset(p params.ServiceUnset) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -131,6 +151,9 @@ func (c *Client) ServiceUnset(p params.ServiceUnset) error {
 
 // ServiceSetYAML implements the server side of Client.ServerSetYAML.
 func (c *Client) ServiceSetYAML(p params.ServiceSetYAML) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(p.ServiceName)
 	if err != nil {
 		return err
@@ -158,6 +181,9 @@ func (c *Client) ServiceCharmRelations(p params.ServiceCharmRelations) (params.S
 
 // Resolved implements the server side of Client.Resolved.
 func (c *Client) Resolved(p params.Resolved) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	unit, err := c.api.state.Unit(p.UnitName)
 	if err != nil {
 		return err
@@ -165,6 +191,18 @@ func (c *Client) Resolved(p params.Resolved) error {
 	return unit.Resolve(p.Retry)
 }
 
+// CancelHook implements the server side of Client.CancelHook.
+func (c *Client) CancelHook(p params.CancelHook) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	unit, err := c.api.state.Unit(p.UnitName)
+	if err != nil {
+		return err
+	}
+	return unit.CancelHook()
+}
+
 // PublicAddress implements the server side of Client.PublicAddress.
 func (c *Client) PublicAddress(p params.PublicAddress) (results params.PublicAddressResults, err error) {
 	switch {
@@ -196,6 +234,9 @@ func (c *Client) PublicAddress(p params.PublicAddress) (results params.PublicAdd
 // ServiceExpose changes the juju-managed firewall to expose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceExpose(args params.ServiceExpose) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -206,6 +247,9 @@ func (c *Client) ServiceExpose(args params.ServiceExpose) error {
 // ServiceUnexpose changes the juju-managed firewall to unexpose any ports that
 // were also explicitly marked by units as open.
 func (c *Client) ServiceUnexpose(args params.ServiceUnexpose) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -215,24 +259,18 @@ func (c *Client) ServiceUnexpose(args params.ServiceUnexpose) error {
 
 var CharmStore charm.Repository = charm.Store
 
-// ServiceDeploy fetches the charm from the charm store and deploys it. Local
-// charms are not supported.
+// ServiceDeploy deploys the charm with the given URL, fetching it from the
+// charm store first if necessary. Local charms must have been uploaded
+// to the API server already.
 func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
-	curl, err := charm.ParseURL(args.CharmUrl)
-	if err != nil {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
 		return err
 	}
-	if curl.Schema != "cs" {
-		return fmt.Errorf(`charm url has unsupported schema %q`, curl.Schema)
-	}
-	if curl.Revision < 0 {
-		return fmt.Errorf("charm url must include revision")
-	}
-	conn, err := juju.NewConnFromState(c.api.state)
+	ch, err := charmForURL(c.api.state, args.CharmUrl)
 	if err != nil {
 		return err
 	}
-	ch, err := conn.PutCharm(curl, CharmStore, false)
+	conn, err := juju.NewConnFromState(c.api.state)
 	if err != nil {
 		return err
 	}
@@ -247,20 +285,25 @@ func (c *Client) ServiceDeploy(args params.ServiceDeploy) error {
 		return err
 	}
 	_, err = conn.DeployService(juju.DeployServiceParams{
-		ServiceName:    args.ServiceName,
-		Charm:          ch,
-		NumUnits:       args.NumUnits,
-		ConfigSettings: settings,
-		Constraints:    args.Constraints,
-		ToMachineSpec:  args.ToMachineSpec,
+		ServiceName:      args.ServiceName,
+		Charm:            ch,
+		NumUnits:         args.NumUnits,
+		ConfigSettings:   settings,
+		Constraints:      args.Constraints,
+		ToMachineSpec:    args.ToMachineSpec,
+		Storage:          args.Storage,
+		EndpointBindings: args.EndpointBindings,
 	})
 	return err
 }
 
 // ServiceUpdate updates the service attributes, including charm URL,
-// minimum number of units, settings and constraints.
+// minimum number of units, settings, recovery policy and constraints.
 // All parameters in params.ServiceUpdate except the service name are optional.
 func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -287,6 +330,16 @@ func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
 			return err
 		}
 	}
+	// Set the service's recovery policy.
+	if args.RecoveryPolicy != "" {
+		policy, err := state.ParseRecoveryPolicy(args.RecoveryPolicy)
+		if err != nil {
+			return err
+		}
+		if err = service.SetRecoveryPolicy(policy); err != nil {
+			return err
+		}
+	}
 	// Update service's constraints.
 	if args.Constraints != nil {
 		return service.SetConstraints(*args.Constraints)
@@ -296,25 +349,38 @@ func (c *Client) ServiceUpdate(args params.ServiceUpdate) error {
 
 // serviceSetCharm sets the charm for the given service.
 func serviceSetCharm(state *state.State, service *state.Service, url string, force bool) error {
-	curl, err := charm.ParseURL(url)
+	ch, err := charmForURL(state, url)
 	if err != nil {
 		return err
 	}
-	if curl.Schema != "cs" {
-		return fmt.Errorf(`charm url has unsupported schema %q`, curl.Schema)
+	return service.SetCharm(ch, force)
+}
+
+// charmForURL returns the charm with the given URL, which must
+// include a revision. Charms not yet in state are fetched from the
+// charm store; local charms must have been uploaded already.
+func charmForURL(st *state.State, url string) (*state.Charm, error) {
+	curl, err := charm.ParseURL(url)
+	if err != nil {
+		return nil, err
 	}
 	if curl.Revision < 0 {
-		return fmt.Errorf("charm url must include revision")
+		return nil, fmt.Errorf("charm url must include revision")
 	}
-	conn, err := juju.NewConnFromState(state)
-	if err != nil {
-		return err
+	ch, err := st.Charm(curl)
+	if err == nil {
+		return ch, nil
+	} else if !coreerrors.IsNotFoundError(err) {
+		return nil, err
 	}
-	ch, err := conn.PutCharm(curl, CharmStore, false)
+	if curl.Schema != "cs" {
+		return nil, fmt.Errorf(`charm url has unsupported schema %q`, curl.Schema)
+	}
+	conn, err := juju.NewConnFromState(st)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return service.SetCharm(ch, force)
+	return conn.PutCharm(curl, CharmStore, false)
 }
 
 // serviceSetSettingsYAML updates the settings for the given service,
@@ -368,6 +434,9 @@ func newServiceSetSettingsStringsForClientAPI(service *state.Service, settings m
 
 // ServiceSetCharm sets the charm for a given service.
 func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	service, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -375,6 +444,17 @@ func (c *Client) ServiceSetCharm(args params.ServiceSetCharm) error {
 	return serviceSetCharm(c.api.state, service, args.CharmUrl, args.Force)
 }
 
+// ServiceGetCharmURL returns the charm URL the given service is
+// running.
+func (c *Client) ServiceGetCharmURL(args params.ServiceGet) (params.StringResult, error) {
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return params.StringResult{}, err
+	}
+	curl, _ := service.CharmURL()
+	return params.StringResult{Result: curl.String()}, nil
+}
+
 // addServiceUnits adds a given number of units to a service.
 // TODO(jam): 2013-08-26 https://pad.lv/1216830
 // The functionality on conn.AddUnits should get pulled up into
@@ -392,14 +472,26 @@ func addServiceUnits(state *state.State, args params.AddServiceUnits) ([]*state.
 	if args.NumUnits < 1 {
 		return nil, errors.New("must add at least one unit")
 	}
-	if args.NumUnits > 1 && args.ToMachineSpec != "" {
-		return nil, errors.New("cannot use NumUnits with ToMachineSpec")
+	if len(args.Storage) > 0 {
+		cons := make(map[string]constraints.Storage)
+		for name, c := range service.StorageConstraints() {
+			cons[name] = c
+		}
+		for name, c := range args.Storage {
+			cons[name] = c
+		}
+		if err := service.SetStorageConstraints(cons); err != nil {
+			return nil, err
+		}
 	}
 	return conn.AddUnits(service, args.NumUnits, args.ToMachineSpec)
 }
 
 // AddServiceUnits adds a given number of units to a service.
 func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddServiceUnitsResults, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.AddServiceUnitsResults{}, err
+	}
 	units, err := addServiceUnits(c.api.state, args)
 	if err != nil {
 		return params.AddServiceUnitsResults{}, err
@@ -413,11 +505,17 @@ func (c *Client) AddServiceUnits(args params.AddServiceUnits) (params.AddService
 
 // DestroyServiceUnits removes a given set of service units.
 func (c *Client) DestroyServiceUnits(args params.DestroyServiceUnits) error {
+	if err := c.checkCanRemove(state.WritePermission); err != nil {
+		return err
+	}
 	return c.api.state.DestroyUnits(args.UnitNames...)
 }
 
 // ServiceDestroy destroys a given service.
 func (c *Client) ServiceDestroy(args params.ServiceDestroy) error {
+	if err := c.checkCanRemove(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -446,6 +544,9 @@ func (c *Client) GetEnvironmentConstraints() (params.GetConstraintsResults, erro
 
 // SetServiceConstraints sets the constraints for a given service.
 func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	svc, err := c.api.state.Service(args.ServiceName)
 	if err != nil {
 		return err
@@ -455,11 +556,17 @@ func (c *Client) SetServiceConstraints(args params.SetConstraints) error {
 
 // SetEnvironmentConstraints sets the constraints for the environment.
 func (c *Client) SetEnvironmentConstraints(args params.SetConstraints) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	return c.api.state.SetEnvironConstraints(args.Constraints)
 }
 
 // AddRelation adds a relation between the specified endpoints and returns the relation info.
 func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.AddRelationResults{}, err
+	}
 	inEps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return params.AddRelationResults{}, err
@@ -481,6 +588,9 @@ func (c *Client) AddRelation(args params.AddRelation) (params.AddRelationResults
 
 // DestroyRelation removes the relation between the specified endpoints.
 func (c *Client) DestroyRelation(args params.DestroyRelation) error {
+	if err := c.checkCanRemove(state.WritePermission); err != nil {
+		return err
+	}
 	eps, err := c.api.state.InferEndpoints(args.Endpoints)
 	if err != nil {
 		return err
@@ -520,6 +630,9 @@ func createAddMachineParameters(machineParams params.AddMachineParams,
 
 // AddMachines adds new machines with the supplied parameters.
 func (c *Client) AddMachines(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	results := params.AddMachinesResults{
 		Machines: make([]params.AddMachinesResult, len(args.MachineParams)),
 	}
@@ -548,6 +661,9 @@ func (c *Client) AddMachines(args params.AddMachines) (params.AddMachinesResults
 
 // InjectMachines injects a machine into state with provisioned status.
 func (c *Client) InjectMachines(args params.AddMachines) (params.AddMachinesResults, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.AddMachinesResults{}, err
+	}
 	results := params.AddMachinesResults{
 		Machines: make([]params.AddMachinesResult, len(args.MachineParams)),
 	}
@@ -583,6 +699,9 @@ func (c *Client) InjectMachines(args params.AddMachines) (params.AddMachinesResu
 // MachineConfig returns information from the environment config that is
 // needed for machine cloud-init (both state servers and host nodes).
 func (c *Client) MachineConfig(args params.MachineConfigParams) (params.MachineConfig, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.MachineConfig{}, err
+	}
 	result := params.MachineConfig{}
 	environConfig, err := c.api.state.EnvironConfig()
 	if err != nil {
@@ -625,9 +744,55 @@ func (c *Client) MachineConfig(args params.MachineConfigParams) (params.MachineC
 
 // DestroyMachines removes a given set of machines.
 func (c *Client) DestroyMachines(args params.DestroyMachines) error {
+	if err := c.checkCanRemove(state.WritePermission); err != nil {
+		return err
+	}
 	return c.api.state.DestroyMachines(args.MachineNames...)
 }
 
+// RetryProvisioning queues machines that failed to start to be
+// provisioned again.
+func (c *Client) RetryProvisioning(args params.Entities) (params.ErrorResults, error) {
+	results := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return results, err
+	}
+	for i, entity := range args.Entities {
+		err := c.retryProvisioning(entity.Tag)
+		results.Results[i].Error = common.ServerError(err)
+	}
+	return results, nil
+}
+
+func (c *Client) retryProvisioning(tag string) error {
+	_, id, err := names.ParseTag(tag, names.MachineTagKind)
+	if err != nil {
+		return err
+	}
+	machine, err := c.api.state.Machine(id)
+	if err != nil {
+		return err
+	}
+	status, info, _, err := machine.Status()
+	if err != nil {
+		return err
+	}
+	if status != params.StatusError {
+		return fmt.Errorf("machine %s is not in an error state", id)
+	}
+	if _, err := machine.InstanceId(); err == nil {
+		return fmt.Errorf("machine %s is already provisioned", id)
+	} else if !state.IsNotProvisionedError(err) {
+		return err
+	}
+	// Marking the error as transient, with no attempts recorded,
+	// makes the provisioner try to start the machine again right
+	// away.
+	return machine.SetStatus(status, info, params.StatusData{"transient": true})
+}
+
 // CharmInfo returns information about the requested charm.
 func (c *Client) CharmInfo(args params.CharmInfo) (api.CharmInfo, error) {
 	curl, err := charm.ParseURL(args.CharmURL)
@@ -647,6 +812,18 @@ func (c *Client) CharmInfo(args params.CharmInfo) (api.CharmInfo, error) {
 	return info, nil
 }
 
+// AddCharm adds the charm with the given URL, which must include a
+// revision, from the charm store to the environment, unless it has
+// been added already. Local charms are uploaded to the API server's
+// /charms HTTPS endpoint instead.
+func (c *Client) AddCharm(args params.CharmURL) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	_, err := charmForURL(c.api.state, args.URL)
+	return err
+}
+
 // EnvironmentInfo returns information about the current environment (default
 // series and type).
 func (c *Client) EnvironmentInfo() (api.EnvironmentInfo, error) {
@@ -697,6 +874,9 @@ func (c *Client) findEntity(tag string) (state.Annotator, error) {
 
 // SetAnnotations stores annotations about a given entity.
 func (c *Client) SetAnnotations(args params.SetAnnotations) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	entity, err := c.findEntity(args.Tag)
 	if err != nil {
 		return err
@@ -740,6 +920,11 @@ func parseSettingsCompatible(ch *state.Charm, settings map[string]string) (charm
 // EnvironmentGet implements the server-side part of the
 // get-environment CLI command.
 func (c *Client) EnvironmentGet() (params.EnvironmentGetResults, error) {
+	// The configuration includes the provider credentials,
+	// so read-only users may not see it.
+	if err := c.checkPermission(state.WritePermission); err != nil {
+		return params.EnvironmentGetResults{}, err
+	}
 	result := params.EnvironmentGetResults{}
 	// Get the existing environment config from the state.
 	config, err := c.api.state.EnvironConfig()
@@ -753,6 +938,9 @@ func (c *Client) EnvironmentGet() (params.EnvironmentGetResults, error) {
 // EnvironmentSet implements the server-side part of the
 // set-environment CLI command.
 func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
 	// TODO(dimitern,thumper): 2013-11-06 bug #1167616
 	// SetEnvironConfig should take both new and old configs.
 
@@ -786,3 +974,162 @@ func (c *Client) EnvironmentSet(args params.EnvironmentSet) error {
 	// Now try to apply the new validated config.
 	return c.api.state.SetEnvironConfig(newProviderConfig)
 }
+
+// EnqueueAction queues a charm action for execution on a unit.
+func (c *Client) EnqueueAction(args params.EnqueueAction) (params.EnqueueActionResults, error) {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return params.EnqueueActionResults{}, err
+	}
+	unit, err := c.api.state.Unit(args.UnitName)
+	if err != nil {
+		return params.EnqueueActionResults{}, err
+	}
+	action, err := unit.AddAction(args.ActionName, args.Params)
+	if err != nil {
+		return params.EnqueueActionResults{}, err
+	}
+	return params.EnqueueActionResults{ActionId: action.Id()}, nil
+}
+
+// FetchAction returns the progress and outcome of a queued charm action.
+func (c *Client) FetchAction(args params.FetchAction) (params.FetchActionResults, error) {
+	action, err := c.api.state.Action(args.ActionId)
+	if err != nil {
+		return params.FetchActionResults{}, err
+	}
+	return params.FetchActionResults{
+		ActionId:   action.Id(),
+		UnitName:   action.UnitName(),
+		ActionName: action.Name(),
+		Params:     action.Params(),
+		Status:     action.Status(),
+		Message:    action.Message(),
+		Results:    action.Results(),
+		Enqueued:   action.Enqueued(),
+		Started:    action.Started(),
+		Completed:  action.Completed(),
+	}, nil
+}
+
+// EnsureAvailability adds state server machines as necessary to make
+// the number of live state servers equal to the number requested.
+func (c *Client) EnsureAvailability(args params.EnsureAvailability) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	return c.api.state.EnsureAvailability(args.NumStateServers, args.Constraints, args.Series)
+}
+
+// AddUser adds a user to the environment with the given permission.
+func (c *Client) AddUser(args params.AddUser) error {
+	if err := c.checkCanChange(state.AdminPermission); err != nil {
+		return err
+	}
+	if args.Password == "" {
+		return fmt.Errorf("password is empty")
+	}
+	perm := state.Permission(args.Permission)
+	if perm == "" {
+		perm = state.ReadPermission
+	}
+	_, err := c.api.state.AddUserWithPermission(args.Username, args.Password, perm)
+	return err
+}
+
+// RemoveUser removes a user from the environment. Users may not
+// remove themselves.
+func (c *Client) RemoveUser(args params.User) error {
+	u, err := c.otherUser(args.Username)
+	if err != nil {
+		return err
+	}
+	if err := c.checkNotBlocked(state.RemoveBlock, state.ChangeBlock); err != nil {
+		return err
+	}
+	return u.Remove()
+}
+
+// DisableUser prevents a user from logging in to the environment.
+// Users may not disable themselves.
+func (c *Client) DisableUser(args params.User) error {
+	u, err := c.otherUser(args.Username)
+	if err != nil {
+		return err
+	}
+	if err := c.checkNotBlocked(state.ChangeBlock); err != nil {
+		return err
+	}
+	return u.Disable()
+}
+
+// EnableUser allows a previously disabled user to log in again.
+func (c *Client) EnableUser(args params.User) error {
+	u, err := c.otherUser(args.Username)
+	if err != nil {
+		return err
+	}
+	if err := c.checkNotBlocked(state.ChangeBlock); err != nil {
+		return err
+	}
+	return u.Enable()
+}
+
+// otherUser returns the named user, provided that the authenticated
+// user has admin permission and is not the named user.
+func (c *Client) otherUser(name string) (*state.User, error) {
+	if err := c.checkPermission(state.AdminPermission); err != nil {
+		return nil, err
+	}
+	u, err := c.api.state.User(name)
+	if err != nil {
+		return nil, err
+	}
+	if u.Tag() == c.api.auth.GetAuthTag() {
+		return nil, fmt.Errorf("cannot change the status of the current user")
+	}
+	return u, nil
+}
+
+// SetUserPassword changes the password of a user. Any user may
+// change their own password; only admin users may change the
+// passwords of others.
+func (c *Client) SetUserPassword(args params.SetUserPassword) error {
+	if args.Password == "" {
+		return fmt.Errorf("password is empty")
+	}
+	if "user-"+args.Username != c.api.auth.GetAuthTag() {
+		if err := c.checkPermission(state.AdminPermission); err != nil {
+			return err
+		}
+	}
+	if err := c.checkNotBlocked(state.ChangeBlock); err != nil {
+		return err
+	}
+	u, err := c.api.state.User(args.Username)
+	if err != nil {
+		return err
+	}
+	return u.SetPassword(args.Password)
+}
+
+// Users returns details of all the users of the environment.
+func (c *Client) Users() (params.UsersResults, error) {
+	if err := c.checkPermission(state.AdminPermission); err != nil {
+		return params.UsersResults{}, err
+	}
+	users, err := c.api.state.AllUsers()
+	if err != nil {
+		return params.UsersResults{}, err
+	}
+	result := params.UsersResults{
+		Users: make([]params.UserInfo, len(users)),
+	}
+	for i, u := range users {
+		result.Users[i] = params.UserInfo{
+			Username:   u.Name(),
+			Permission: string(u.Permission()),
+			Disabled:   u.IsDisabled(),
+		}
+	}
+	return result, nil
+}
@@ -159,10 +159,10 @@ var clientAddServiceUnitsTests = []struct {
 		err:   "must add at least one unit",
 	},
 	{
-		about:    "cannot mix to when adding multiple units",
-		err:      "cannot use NumUnits with ToMachineSpec",
+		about:    "cannot use more directives than units",
+		err:      `cannot add 2 unit\(s\) of service "dummy": too many placement directives`,
 		expected: []string{"dummy/0", "dummy/1"},
-		to:       "0",
+		to:       "0,0,0",
 	},
 	{
 		// Note: chained-state, we add 1 unit here, but the 3 units
@@ -203,6 +203,28 @@ func (s *clientSuite) TestClientAddServiceUnits(c *gc.C) {
 	c.Assert(assignedMachine, gc.Equals, "0")
 }
 
+func (s *clientSuite) TestClientAddServiceUnitsWithStorage(c *gc.C) {
+	service, err := s.State.AddService("storage", s.AddTestingCharm(c, "storage-filesystem"))
+	c.Assert(err, gc.IsNil)
+	storage := map[string]constraints.Storage{"data": {Size: 2048, Count: 2}}
+	units, err := s.APIState.Client().AddServiceUnitsWithStorage("storage", 1, "", storage)
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.DeepEquals, []string{"storage/0"})
+
+	err = service.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(service.StorageConstraints(), gc.DeepEquals, storage)
+	unit, err := s.State.Unit("storage/0")
+	c.Assert(err, gc.IsNil)
+	stores, err := unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(stores, gc.HasLen, 2)
+
+	storage = map[string]constraints.Storage{"nonsense": {Size: 1024}}
+	_, err = s.APIState.Client().AddServiceUnitsWithStorage("storage", 1, "", storage)
+	c.Assert(err, gc.ErrorMatches, `cannot set storage constraints: charm "storage-filesystem" has no store "nonsense"`)
+}
+
 var clientCharmInfoTests = []struct {
 	about string
 	url   string
@@ -506,6 +528,28 @@ func (s *clientSuite) TestClientDestroyMachines(c *gc.C) {
 	c.Assert(m.Life(), gc.Not(gc.Equals), state.Alive)
 }
 
+func (s *clientSuite) TestClientRetryProvisioning(c *gc.C) {
+	failed, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = failed.SetStatus(params.StatusError, "quota exceeded", params.StatusData{"attempts": 10})
+	c.Assert(err, gc.IsNil)
+	pending, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.APIState.Client().RetryProvisioning(failed.Id(), pending.Id(), "42")
+	c.Assert(err, gc.IsNil)
+	c.Assert(results, gc.DeepEquals, []params.ErrorResult{
+		{Error: nil},
+		{Error: &params.Error{Message: "machine 1 is not in an error state"}},
+		{Error: &params.Error{Message: "machine 42 not found", Code: params.CodeNotFound}},
+	})
+	status, info, data, err := failed.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusError)
+	c.Assert(info, gc.Equals, "quota exceeded")
+	c.Assert(data, gc.DeepEquals, params.StatusData{"transient": true})
+}
+
 func (s *clientSuite) TestClientDestroyUnits(c *gc.C) {
 	// Setup:
 	s.setUpScenario(c)
@@ -554,6 +598,18 @@ func (s *clientSuite) TestClientUnitResolvedRetry(c *gc.C) {
 	s.testClientUnitResolved(c, true, state.ResolvedRetryHooks)
 }
 
+func (s *clientSuite) TestClientCancelHook(c *gc.C) {
+	s.setUpScenario(c)
+	err := s.APIState.Client().CancelHook("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	u, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.HookCancelRequested(), jc.IsTrue)
+
+	err = s.APIState.Client().CancelHook("wordpress/42")
+	c.Assert(err, gc.ErrorMatches, `unit "wordpress/42" not found`)
+}
+
 func (s *clientSuite) TestClientServiceDeployCharmErrors(c *gc.C) {
 	_, restore := makeMockCharmStore()
 	defer restore()
@@ -763,6 +819,40 @@ func (s *clientSuite) TestClientServiceUpdateSetMinUnitsError(c *gc.C) {
 	c.Assert(service.MinUnits(), gc.Equals, 0)
 }
 
+func (s *clientSuite) TestClientServiceUpdateSetRecoveryPolicy(c *gc.C) {
+	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+
+	// Enable automatic recovery for the service.
+	args := params.ServiceUpdate{
+		ServiceName:    "dummy",
+		RecoveryPolicy: "auto",
+	}
+	err = s.APIState.Client().ServiceUpdate(args)
+	c.Assert(err, gc.IsNil)
+
+	// Ensure the recovery policy has been set.
+	c.Assert(service.Refresh(), gc.IsNil)
+	c.Assert(service.RecoveryPolicy(), gc.Equals, state.RecoveryAuto)
+}
+
+func (s *clientSuite) TestClientServiceUpdateSetRecoveryPolicyError(c *gc.C) {
+	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+
+	// Set an unknown recovery policy for the service.
+	args := params.ServiceUpdate{
+		ServiceName:    "dummy",
+		RecoveryPolicy: "sometimes",
+	}
+	err = s.APIState.Client().ServiceUpdate(args)
+	c.Assert(err, gc.ErrorMatches, `invalid recovery policy "sometimes"`)
+
+	// Ensure the recovery policy has not been set.
+	c.Assert(service.Refresh(), gc.IsNil)
+	c.Assert(service.RecoveryPolicy(), gc.Equals, state.RecoveryManual)
+}
+
 func (s *clientSuite) TestClientServiceUpdateSetSettingsStrings(c *gc.C) {
 	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
 	c.Assert(err, gc.IsNil)
@@ -965,6 +1055,58 @@ func (s *clientSuite) TestClientServiceSetCharmErrors(c *gc.C) {
 	}
 }
 
+func (s *clientSuite) TestClientServiceDeployLocalCharm(c *gc.C) {
+	_, restore := makeMockCharmStore()
+	defer restore()
+	sch := s.AddTestingCharm(c, "dummy")
+	err := s.APIState.Client().ServiceDeployWithParams(params.ServiceDeploy{
+		ServiceName: "service",
+		CharmUrl:    sch.URL().String(),
+		NumUnits:    1,
+		ConfigYAML:  "service:\n  title: Nearly There\n",
+	})
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.Service("service")
+	c.Assert(err, gc.IsNil)
+	curl, _ := service.CharmURL()
+	c.Assert(curl, gc.DeepEquals, sch.URL())
+	settings, err := service.ConfigSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings["title"], gc.Equals, "Nearly There")
+	units, err := service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+}
+
+func (s *clientSuite) TestClientAddCharm(c *gc.C) {
+	store, restore := makeMockCharmStore()
+	defer restore()
+	curl, _ := addCharm(c, store, "dummy")
+	client := s.APIState.Client()
+	err := client.AddCharm(curl)
+	c.Assert(err, gc.IsNil)
+	sch, err := s.State.Charm(curl)
+	c.Assert(err, gc.IsNil)
+	c.Assert(sch.URL(), gc.DeepEquals, curl)
+
+	// Adding the charm again has no further effect.
+	err = client.AddCharm(curl)
+	c.Assert(err, gc.IsNil)
+
+	err = client.AddCharm(charm.MustParseURL("local:precise/dummy-999999"))
+	c.Assert(err, gc.ErrorMatches, `charm url has unsupported schema "local"`)
+}
+
+func (s *clientSuite) TestClientServiceGetCharmURL(c *gc.C) {
+	s.setUpScenario(c)
+	curl, err := s.APIState.Client().ServiceGetCharmURL("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(curl.String(), gc.Equals, "local:quantal/wordpress-3")
+
+	_, err = s.APIState.Client().ServiceGetCharmURL("unknown")
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+}
+
 func makeMockCharmStore() (store *coretesting.MockCharmStore, restore func()) {
 	mockStore := coretesting.NewMockCharmStore()
 	origStore := client.CharmStore
@@ -1584,3 +1726,158 @@ func (s *clientSuite) TestMachineConfigNoTools(c *gc.C) {
 	_, err = s.APIState.Client().MachineConfig(machines[0].Machine, "quantal", "amd64")
 	c.Assert(err, gc.ErrorMatches, tools.ErrNoMatches.Error())
 }
+
+func (s *clientSuite) TestClientEnqueueAndFetchAction(c *gc.C) {
+	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+	unit, err := service.AddUnit()
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.APIState.Client().EnqueueAction("dummy/0", "explode", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot add action "explode" to unit "dummy/0": action not defined by charm "local:quantal/dummy-1"`)
+	_, err = s.APIState.Client().EnqueueAction("dummy/9", "snapshot", nil)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+
+	id, err := s.APIState.Client().EnqueueAction("dummy/0", "snapshot", map[string]interface{}{
+		"outfile": "bar.bz2",
+	})
+	c.Assert(err, gc.IsNil)
+	result, err := s.APIState.Client().FetchAction(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.UnitName, gc.Equals, "dummy/0")
+	c.Assert(result.ActionName, gc.Equals, "snapshot")
+	c.Assert(result.Params, gc.DeepEquals, map[string]interface{}{"outfile": "bar.bz2"})
+	c.Assert(result.Status, gc.Equals, params.ActionPending)
+
+	actions, err := unit.Actions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(actions, gc.HasLen, 1)
+	err = actions[0].Finish(params.ActionCompleted, map[string]interface{}{"size": "42M"}, "")
+	c.Assert(err, gc.IsNil)
+	result, err = s.APIState.Client().FetchAction(id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Status, gc.Equals, params.ActionCompleted)
+	c.Assert(result.Results, gc.DeepEquals, map[string]interface{}{"size": "42M"})
+
+	_, err = s.APIState.Client().FetchAction("42")
+	c.Assert(err, gc.ErrorMatches, `action "42" not found`)
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
+
+func (s *clientSuite) TestClientEnsureAvailability(c *gc.C) {
+	err := s.APIState.Client().EnsureAvailability(2, constraints.Value{}, "")
+	c.Assert(err, gc.ErrorMatches, "number of state servers must be odd and greater than zero")
+
+	cons := constraints.MustParse("mem=8G")
+	err = s.APIState.Client().EnsureAvailability(3, cons, "precise")
+	c.Assert(err, gc.IsNil)
+	machines, err := s.State.StateServerMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 3)
+	for _, m := range machines {
+		c.Assert(m.Series(), gc.Equals, "precise")
+		c.Assert(m.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobManageState})
+		mcons, err := m.Constraints()
+		c.Assert(err, gc.IsNil)
+		c.Assert(mcons, gc.DeepEquals, cons)
+	}
+}
+
+func (s *clientSuite) TestClientAddUser(c *gc.C) {
+	err := s.APIState.Client().AddUser("bob", "", "write")
+	c.Assert(err, gc.ErrorMatches, "password is empty")
+	err = s.APIState.Client().AddUser("bob", "password", "root")
+	c.Assert(err, gc.ErrorMatches, `.*invalid permission "root"`)
+
+	err = s.APIState.Client().AddUser("bob", "password", "write")
+	c.Assert(err, gc.IsNil)
+	u, err := s.State.User("bob")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.WritePermission)
+	c.Assert(u.PasswordValid("password"), jc.IsTrue)
+
+	err = s.APIState.Client().AddUser("alice", "password", "")
+	c.Assert(err, gc.IsNil)
+	u, err = s.State.User("alice")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.ReadPermission)
+}
+
+func (s *clientSuite) TestClientUsers(c *gc.C) {
+	s.setUpScenario(c)
+	u, err := s.State.User("reader")
+	c.Assert(err, gc.IsNil)
+	err = u.Disable()
+	c.Assert(err, gc.IsNil)
+
+	users, err := s.APIState.Client().Users()
+	c.Assert(err, gc.IsNil)
+	c.Assert(users, gc.DeepEquals, []params.UserInfo{
+		{Username: "admin", Permission: "admin"},
+		{Username: "other", Permission: "write"},
+		{Username: "reader", Permission: "read", Disabled: true},
+	})
+}
+
+func (s *clientSuite) TestClientDisableEnableRemoveUser(c *gc.C) {
+	s.setUpScenario(c)
+	client := s.APIState.Client()
+	err := client.DisableUser("admin")
+	c.Assert(err, gc.ErrorMatches, "cannot change the status of the current user")
+	err = client.RemoveUser("admin")
+	c.Assert(err, gc.ErrorMatches, "cannot change the status of the current user")
+	err = client.DisableUser("nobody")
+	c.Assert(err, gc.ErrorMatches, `user "nobody" not found`)
+
+	err = client.DisableUser("other")
+	c.Assert(err, gc.IsNil)
+	u, err := s.State.User("other")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsTrue)
+
+	err = client.EnableUser("other")
+	c.Assert(err, gc.IsNil)
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsFalse)
+
+	err = client.RemoveUser("other")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.User("other")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *clientSuite) TestClientSetUserPassword(c *gc.C) {
+	s.setUpScenario(c)
+	err := s.APIState.Client().SetUserPassword("other", "")
+	c.Assert(err, gc.ErrorMatches, "password is empty")
+	err = s.APIState.Client().SetUserPassword("other", "new password")
+	c.Assert(err, gc.IsNil)
+	u, err := s.State.User("other")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.PasswordValid("new password"), jc.IsTrue)
+
+	// A user without admin permission may change their own
+	// password, but nobody else's.
+	st := s.openAs(c, "user-reader")
+	defer st.Close()
+	err = st.Client().SetUserPassword("other", "another password")
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+	err = st.Client().SetUserPassword("reader", "another password")
+	c.Assert(err, gc.IsNil)
+	u, err = s.State.User("reader")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.PasswordValid("another password"), jc.IsTrue)
+}
+
+func (s *clientSuite) TestClientDisabledUserCannotLogin(c *gc.C) {
+	s.setUpScenario(c)
+	err := s.APIState.Client().DisableUser("other")
+	c.Assert(err, gc.IsNil)
+	_, info, err := s.APIConn.Environ.StateInfo()
+	c.Assert(err, gc.IsNil)
+	info.Tag = "user-other"
+	info.Password = "user-other password-1234567890"
+	_, err = api.Open(info, api.DialOpts{})
+	c.Assert(err, gc.ErrorMatches, "invalid entity name or password")
+}
@@ -0,0 +1,50 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// Metrics returns the metrics reported by a unit, or by the units of a
+// service, since the given time.
+func (c *Client) Metrics(args params.Metrics) (params.MetricResults, error) {
+	var results params.MetricResults
+	var batches []state.MetricBatch
+	switch {
+	case names.IsUnit(args.Name):
+		unit, err := c.api.state.Unit(args.Name)
+		if err != nil {
+			return results, err
+		}
+		if batches, err = unit.MetricBatches(args.Since); err != nil {
+			return results, err
+		}
+	case names.IsService(args.Name):
+		service, err := c.api.state.Service(args.Name)
+		if err != nil {
+			return results, err
+		}
+		if batches, err = service.MetricBatches(args.Since); err != nil {
+			return results, err
+		}
+	default:
+		return results, fmt.Errorf("invalid unit or service name %q", args.Name)
+	}
+	for _, batch := range batches {
+		for _, m := range batch.Metrics {
+			results.Metrics = append(results.Metrics, params.MetricResult{
+				Unit:  batch.Unit,
+				Key:   m.Key,
+				Value: m.Value,
+				Time:  m.Time,
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type metricsSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&metricsSuite{})
+
+func (s *metricsSuite) TestMetrics(c *gc.C) {
+	s.setUpScenario(c)
+	unit0, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	unit1, err := s.State.Unit("wordpress/1")
+	c.Assert(err, gc.IsNil)
+	t0 := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	err = unit0.AddMetrics([]state.Metric{{Key: "requests", Value: 10, Time: t0}})
+	c.Assert(err, gc.IsNil)
+	err = unit1.AddMetrics([]state.Metric{{Key: "requests", Value: 12.5, Time: t1}})
+	c.Assert(err, gc.IsNil)
+
+	metrics, err := s.APIState.Client().Metrics("wordpress", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.HasLen, 2)
+	for i := range metrics {
+		metrics[i].Time = metrics[i].Time.UTC()
+	}
+	c.Assert(metrics, gc.DeepEquals, []params.MetricResult{
+		{Unit: "wordpress/0", Key: "requests", Value: 10, Time: t0},
+		{Unit: "wordpress/1", Key: "requests", Value: 12.5, Time: t1},
+	})
+
+	metrics, err = s.APIState.Client().Metrics("wordpress/1", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.HasLen, 1)
+	c.Assert(metrics[0].Unit, gc.Equals, "wordpress/1")
+
+	metrics, err = s.APIState.Client().Metrics("wordpress", time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+	c.Assert(metrics, gc.HasLen, 0)
+}
+
+func (s *metricsSuite) TestMetricsErrors(c *gc.C) {
+	_, err := s.APIState.Client().Metrics("unknown", time.Time{})
+	c.Assert(err, gc.ErrorMatches, `service "unknown" not found`)
+	_, err = s.APIState.Client().Metrics("unknown/0", time.Time{})
+	c.Assert(err, gc.ErrorMatches, `unit "unknown/0" not found`)
+	_, err = s.APIState.Client().Metrics("Bad!", time.Time{})
+	c.Assert(err, gc.ErrorMatches, `invalid unit or service name "Bad!"`)
+}
@@ -5,14 +5,17 @@ package client_test
 
 import (
 	"strings"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/constraints"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/version"
 )
 
 type permSuite struct {
@@ -38,7 +41,7 @@ var operationPermTests = []struct {
 }{{
 	about: "Client.Status",
 	op:    opClientStatus,
-	allow: []string{"user-admin", "user-other"},
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.ServiceSet",
 	op:    opClientServiceSet,
@@ -50,11 +53,15 @@ var operationPermTests = []struct {
 }, {
 	about: "Client.ServiceGet",
 	op:    opClientServiceGet,
-	allow: []string{"user-admin", "user-other"},
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.Resolved",
 	op:    opClientResolved,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.CancelHook",
+	op:    opClientCancelHook,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.ServiceExpose",
 	op:    opClientServiceExpose,
@@ -67,6 +74,10 @@ var operationPermTests = []struct {
 	about: "Client.ServiceDeploy",
 	op:    opClientServiceDeploy,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.DeployBundle",
+	op:    opClientDeployBundle,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.ServiceUpdate",
 	op:    opClientServiceUpdate,
@@ -75,10 +86,22 @@ var operationPermTests = []struct {
 	about: "Client.ServiceSetCharm",
 	op:    opClientServiceSetCharm,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.ServiceSetScaling",
+	op:    opClientServiceSetScaling,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.ServiceGetScaling",
+	op:    opClientServiceGetScaling,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.RetryProvisioning",
+	op:    opClientRetryProvisioning,
+	allow: []string{"user-admin", "user-other"},
 }, {
 	about: "Client.GetAnnotations",
 	op:    opClientGetAnnotations,
-	allow: []string{"user-admin", "user-other"},
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.SetAnnotations",
 	op:    opClientSetAnnotations,
@@ -98,7 +121,7 @@ var operationPermTests = []struct {
 }, {
 	about: "Client.GetServiceConstraints",
 	op:    opClientGetServiceConstraints,
-	allow: []string{"user-admin", "user-other"},
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.SetServiceConstraints",
 	op:    opClientSetServiceConstraints,
@@ -118,11 +141,39 @@ var operationPermTests = []struct {
 }, {
 	about: "Client.WatchAll",
 	op:    opClientWatchAll,
-	allow: []string{"user-admin", "user-other"},
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.CharmInfo",
 	op:    opClientCharmInfo,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.AddCharm",
+	op:    opClientAddCharm,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.FullStatus",
+	op:    opClientFullStatus,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.StatusHistory",
+	op:    opClientStatusHistory,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.Metrics",
+	op:    opClientMetrics,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.ServiceGetCharmURL",
+	op:    opClientServiceGetCharmURL,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.SetEnvironAgentVersion",
+	op:    opClientSetEnvironAgentVersion,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.FindTools",
+	op:    opClientFindTools,
+	allow: []string{"user-admin", "user-other", "user-reader"},
 }, {
 	about: "Client.AddRelation",
 	op:    opClientAddRelation,
@@ -131,6 +182,38 @@ var operationPermTests = []struct {
 	about: "Client.DestroyRelation",
 	op:    opClientDestroyRelation,
 	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.EnqueueAction",
+	op:    opClientEnqueueAction,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.FetchAction",
+	op:    opClientFetchAction,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.EnsureAvailability",
+	op:    opClientEnsureAvailability,
+	allow: []string{"user-admin", "user-other"},
+}, {
+	about: "Client.AddUser",
+	op:    opClientAddUser,
+	allow: []string{"user-admin"},
+}, {
+	about: "Client.Users",
+	op:    opClientUsers,
+	allow: []string{"user-admin"},
+}, {
+	about: "Client.SwitchBlockOn",
+	op:    opClientSwitchBlockOn,
+	allow: []string{"user-admin"},
+}, {
+	about: "Client.ListBlocks",
+	op:    opClientListBlocks,
+	allow: []string{"user-admin", "user-other", "user-reader"},
+}, {
+	about: "Client.AuditLog",
+	op:    opClientAuditLog,
+	allow: []string{"user-admin"},
 }}
 
 // allowed returns the set of allowed entities given an allow list and a
@@ -188,6 +271,63 @@ func opClientCharmInfo(c *gc.C, st *api.State, mst *state.State) (func(), error)
 	return func() {}, nil
 }
 
+func opClientAddCharm(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().AddCharm(charm.MustParseURL("local:quantal/wordpress-3"))
+	return func() {}, err
+}
+
+func opClientFullStatus(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	status, err := st.Client().FullStatus(nil)
+	if err != nil {
+		c.Check(status, gc.IsNil)
+		return func() {}, err
+	}
+	c.Assert(status.Services, gc.HasLen, 3)
+	return func() {}, nil
+}
+
+func opClientMetrics(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().Metrics("wordpress", time.Time{})
+	return func() {}, err
+}
+
+func opClientStatusHistory(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	history, err := st.Client().StatusHistory("unit-wordpress-0", 0)
+	if err != nil {
+		c.Check(history, gc.IsNil)
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientServiceGetCharmURL(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	curl, err := st.Client().ServiceGetCharmURL("wordpress")
+	if err != nil {
+		c.Check(curl, gc.IsNil)
+		return func() {}, err
+	}
+	c.Assert(curl.String(), gc.Equals, "local:quantal/wordpress-3")
+	return func() {}, nil
+}
+
+func opClientSetEnvironAgentVersion(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	// The agents in the scenario are not running, so the upgrade
+	// never starts; only the permission check is of interest.
+	err := st.Client().SetEnvironAgentVersion(version.MustParse("1.2.3"))
+	if err != nil && strings.HasPrefix(err.Error(), "cannot upgrade to 1.2.3: ") {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientFindTools(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().FindTools(99, -1, "", "")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
 func opClientAddRelation(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	_, err := st.Client().AddRelation("nosuch1", "nosuch2")
 	if params.IsCodeNotFound(err) {
@@ -287,6 +427,19 @@ func opClientResolved(c *gc.C, st *api.State, _ *state.State) (func(), error) {
 	return func() {}, nil
 }
 
+func opClientCancelHook(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().CancelHook("wordpress/0")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {
+		u, err := mst.Unit("wordpress/0")
+		c.Assert(err, gc.IsNil)
+		err = u.ClearCancelHook()
+		c.Assert(err, gc.IsNil)
+	}, nil
+}
+
 func opClientGetAnnotations(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	ann, err := st.Client().GetAnnotations("service-wordpress")
 	if err != nil {
@@ -308,6 +461,14 @@ func opClientSetAnnotations(c *gc.C, st *api.State, mst *state.State) (func(), e
 	}, nil
 }
 
+func opClientDeployBundle(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().DeployBundle("services: {}")
+	if err != nil && err.Error() == "bundle has no services" {
+		err = nil
+	}
+	return func() {}, err
+}
+
 func opClientServiceDeploy(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	err := st.Client().ServiceDeploy("mad:bad/url-1", "x", 1, "", constraints.Value{})
 	if err.Error() == `charm URL has invalid schema: "mad:bad/url-1"` {
@@ -331,6 +492,27 @@ func opClientServiceUpdate(c *gc.C, st *api.State, mst *state.State) (func(), er
 	return func() {}, err
 }
 
+func opClientServiceSetScaling(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().ServiceSetScaling("nosuch", nil)
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientServiceGetScaling(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().ServiceGetScaling("wordpress")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientRetryProvisioning(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().RetryProvisioning("42")
+	return func() {}, err
+}
+
 func opClientServiceSetCharm(c *gc.C, st *api.State, mst *state.State) (func(), error) {
 	err := st.Client().ServiceSetCharm("nosuch", "local:quantal/wordpress", false)
 	if params.IsCodeNotFound(err) {
@@ -413,3 +595,70 @@ func opClientWatchAll(c *gc.C, st *api.State, mst *state.State) (func(), error)
 	}
 	return func() {}, err
 }
+
+func opClientEnqueueAction(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().EnqueueAction("wordpress/0", "nosuch", nil)
+	if err != nil && strings.HasSuffix(err.Error(), "action not defined by charm \"local:quantal/wordpress-3\"") {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientFetchAction(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().FetchAction("nosuch")
+	if params.IsCodeNotFound(err) {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientEnsureAvailability(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().EnsureAvailability(0, constraints.Value{}, "")
+	if err != nil && err.Error() == "number of state servers must be odd and greater than zero" {
+		err = nil
+	}
+	return func() {}, err
+}
+
+func opClientAddUser(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().AddUser("newuser", "password", "read")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {
+		u, err := mst.User("newuser")
+		c.Assert(err, gc.IsNil)
+		err = u.Remove()
+		c.Assert(err, gc.IsNil)
+	}, nil
+}
+
+func opClientSwitchBlockOn(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	err := st.Client().SwitchBlockOn("destroy", "")
+	if err != nil {
+		return func() {}, err
+	}
+	return func() {
+		err := mst.SwitchBlockOff(state.DestroyBlock)
+		c.Assert(err, gc.IsNil)
+	}, nil
+}
+
+func opClientListBlocks(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	blocks, err := st.Client().ListBlocks()
+	if err != nil {
+		c.Check(blocks, gc.IsNil)
+		return func() {}, err
+	}
+	return func() {}, nil
+}
+
+func opClientAuditLog(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().AuditLog(params.AuditLogFilter{})
+	return func() {}, err
+}
+
+func opClientUsers(c *gc.C, st *api.State, mst *state.State) (func(), error) {
+	_, err := st.Client().Users()
+	return func() {}, err
+}
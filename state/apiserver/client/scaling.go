@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// ServiceSetScaling sets the scaling policy of a service, or stops its
+// automatic scaling if no policy is given.
+func (c *Client) ServiceSetScaling(args params.ServiceScaling) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return err
+	}
+	if args.Policy == nil {
+		return service.RemoveScalingPolicy()
+	}
+	return service.SetScalingPolicy(state.ScalingPolicy{
+		MinUnits:  args.Policy.MinUnits,
+		MaxUnits:  args.Policy.MaxUnits,
+		Signal:    args.Policy.Signal,
+		ScaleUp:   args.Policy.ScaleUp,
+		ScaleDown: args.Policy.ScaleDown,
+		Cooldown:  args.Policy.Cooldown,
+	})
+}
+
+// ServiceGetScaling returns the scaling policy of a service, and the
+// most recent decisions made by applying it.
+func (c *Client) ServiceGetScaling(args params.ServiceGet) (params.ServiceScalingResults, error) {
+	var results params.ServiceScalingResults
+	if err := c.checkPermission(state.ReadPermission); err != nil {
+		return results, err
+	}
+	service, err := c.api.state.Service(args.ServiceName)
+	if err != nil {
+		return results, err
+	}
+	policy, err := service.ScalingPolicy()
+	if err != nil {
+		return results, err
+	}
+	decisions, err := service.ScalingDecisions()
+	if err != nil {
+		return results, err
+	}
+	results.Policy = params.ScalingPolicy{
+		MinUnits:  policy.MinUnits,
+		MaxUnits:  policy.MaxUnits,
+		Signal:    policy.Signal,
+		ScaleUp:   policy.ScaleUp,
+		ScaleDown: policy.ScaleDown,
+		Cooldown:  policy.Cooldown,
+	}
+	results.Decisions = make([]params.ScalingDecision, len(decisions))
+	for i, decision := range decisions {
+		results.Decisions[i] = params.ScalingDecision{
+			Time:   decision.Time,
+			From:   decision.From,
+			To:     decision.To,
+			Reason: decision.Reason,
+		}
+	}
+	return results, nil
+}
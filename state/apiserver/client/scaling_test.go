@@ -0,0 +1,96 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type scalingSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&scalingSuite{})
+
+var apiScalingPolicy = params.ScalingPolicy{
+	MinUnits:  1,
+	MaxUnits:  4,
+	Signal:    "metric:requests",
+	ScaleUp:   0.8,
+	ScaleDown: 0.2,
+	Cooldown:  5 * time.Minute,
+}
+
+func (s *scalingSuite) TestServiceSetScaling(c *gc.C) {
+	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+	policy := apiScalingPolicy
+	err = s.APIState.Client().ServiceSetScaling("dummy", &policy)
+	c.Assert(err, gc.IsNil)
+	got, err := service.ScalingPolicy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(*got, gc.DeepEquals, state.ScalingPolicy{
+		MinUnits:  1,
+		MaxUnits:  4,
+		Signal:    "metric:requests",
+		ScaleUp:   0.8,
+		ScaleDown: 0.2,
+		Cooldown:  5 * time.Minute,
+	})
+
+	err = s.APIState.Client().ServiceSetScaling("dummy", nil)
+	c.Assert(err, gc.IsNil)
+	_, err = service.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *scalingSuite) TestServiceSetScalingInvalid(c *gc.C) {
+	_, err := s.State.AddService("dummy", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+	policy := apiScalingPolicy
+	policy.MaxUnits = 0
+	err = s.APIState.Client().ServiceSetScaling("dummy", &policy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "dummy": invalid maximum number of units 0`)
+}
+
+func (s *scalingSuite) TestServiceGetScaling(c *gc.C) {
+	service, err := s.State.AddService("dummy", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.APIState.Client().ServiceGetScaling("dummy")
+	c.Assert(err, gc.ErrorMatches, `scaling policy for service "dummy" not found`)
+	c.Assert(params.ErrCode(err), gc.Equals, params.CodeNotFound)
+
+	policy := apiScalingPolicy
+	err = s.APIState.Client().ServiceSetScaling("dummy", &policy)
+	c.Assert(err, gc.IsNil)
+	now := time.Now().UTC().Round(time.Second)
+	err = service.AddScalingDecision(state.ScalingDecision{Time: now, From: 1, To: 2, Reason: "busy"})
+	c.Assert(err, gc.IsNil)
+
+	results, err := s.APIState.Client().ServiceGetScaling("dummy")
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Policy, gc.DeepEquals, apiScalingPolicy)
+	c.Assert(results.Decisions, gc.HasLen, 1)
+	c.Assert(results.Decisions[0].Time.Equal(now), gc.Equals, true)
+	c.Assert(results.Decisions[0].From, gc.Equals, 1)
+	c.Assert(results.Decisions[0].To, gc.Equals, 2)
+	c.Assert(results.Decisions[0].Reason, gc.Equals, "busy")
+
+	status, err := s.APIState.Client().FullStatus(nil)
+	c.Assert(err, gc.IsNil)
+	scaling := status.Services["dummy"].Scaling
+	c.Assert(scaling, gc.NotNil)
+	c.Assert(scaling.MinUnits, gc.Equals, 1)
+	c.Assert(scaling.MaxUnits, gc.Equals, 4)
+	c.Assert(scaling.Signal, gc.Equals, "metric:requests")
+	c.Assert(scaling.LastDecision, gc.NotNil)
+	c.Assert(scaling.LastDecision.Reason, gc.Equals, "busy")
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state/api/params"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type statusSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&statusSuite{})
+
+func (s *statusSuite) TestFullStatus(c *gc.C) {
+	s.setUpScenario(c)
+	status, err := s.APIState.Client().FullStatus(nil)
+	c.Assert(err, gc.IsNil)
+	c.Check(status.EnvironmentName, gc.Equals, "dummyenv")
+	c.Check(status.Upgrade, gc.IsNil)
+	c.Check(status.Machines, gc.HasLen, 3)
+	c.Check(status.Machines["1"].InstanceId, gc.Equals, instance.Id("i-machine-1"))
+	c.Check(status.Services, gc.HasLen, 3)
+	wordpress := status.Services["wordpress"]
+	c.Check(wordpress.Charm, gc.Equals, "local:quantal/wordpress-3")
+	c.Check(wordpress.Relations, gc.DeepEquals, map[string][]string{"juju-info": {"logging"}})
+	c.Check(wordpress.Units, gc.HasLen, 2)
+	c.Check(wordpress.Units["wordpress/0"].Machine, gc.Equals, "1")
+	c.Check(wordpress.Units["wordpress/0"].Subordinates, gc.HasLen, 1)
+	c.Check(status.Services["logging"].SubordinateTo, gc.DeepEquals, []string{"wordpress"})
+}
+
+func (s *statusSuite) TestFullStatusPatterns(c *gc.C) {
+	s.setUpScenario(c)
+	status, err := s.APIState.Client().FullStatus([]string{"wordpress/1"})
+	c.Assert(err, gc.IsNil)
+	units := status.Services["wordpress"].Units
+	c.Assert(units, gc.HasLen, 1)
+	c.Check(units["wordpress/1"].Machine, gc.Equals, "2")
+	_, ok := status.Services["mysql"]
+	c.Check(ok, jc.IsFalse)
+
+	_, err = s.APIState.Client().FullStatus([]string{"[*"})
+	c.Assert(err, gc.ErrorMatches, `pattern "\[\*" contains invalid characters`)
+}
+
+func (s *statusSuite) TestStatusHistory(c *gc.C) {
+	s.setUpScenario(c)
+	u, err := s.State.Unit("wordpress/0")
+	c.Assert(err, gc.IsNil)
+	err = u.SetStatus(params.StatusError, "hook failed", params.StatusData{"hook": "install"})
+	c.Assert(err, gc.IsNil)
+	err = u.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+
+	history, err := s.APIState.Client().StatusHistory("unit-wordpress-0", 0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Check(history[0].Status, gc.Equals, params.StatusStarted)
+	c.Check(history[1].Status, gc.Equals, params.StatusError)
+	c.Check(history[1].Info, gc.Equals, "hook failed")
+	c.Check(history[1].Data, gc.DeepEquals, params.StatusData{"hook": "install"})
+
+	history, err = s.APIState.Client().StatusHistory("unit-wordpress-0", 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Check(history[0].Status, gc.Equals, params.StatusStarted)
+}
+
+func (s *statusSuite) TestStatusHistoryNotSupported(c *gc.C) {
+	s.setUpScenario(c)
+	_, err := s.APIState.Client().StatusHistory("service-wordpress", 0)
+	c.Assert(err, gc.ErrorMatches, `entity "service-wordpress" does not support status history`)
+}
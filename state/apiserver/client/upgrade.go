@@ -0,0 +1,147 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/juju-core/environs"
+	envtools "launchpad.net/juju-core/environs/tools"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+	coretools "launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/utils/set"
+	"launchpad.net/juju-core/version"
+)
+
+// SetEnvironAgentVersion starts an upgrade of the environment to the
+// given version, once it has checked that every agent is running, that
+// no unit is in an error state and that tools of the new version are
+// available for the series and architecture of every machine.
+func (c *Client) SetEnvironAgentVersion(args params.SetEnvironAgentVersion) error {
+	if err := c.checkCanChange(state.WritePermission); err != nil {
+		return err
+	}
+	st := c.api.state
+	tools, err := findUpgradeTools(st, args.Version)
+	if err != nil {
+		return err
+	}
+	if err := checkAgents(st, args.Version, tools); err != nil {
+		return err
+	}
+	return st.StartUpgrade(args.Version)
+}
+
+// FindTools returns the tools available to the environment that match
+// the given parameters. A negative minor version matches any minor
+// version, and an empty series or architecture matches any.
+func (c *Client) FindTools(args params.FindToolsParams) (params.FindToolsResults, error) {
+	if err := c.checkPermission(state.ReadPermission); err != nil {
+		return params.FindToolsResults{}, err
+	}
+	env, err := environFromState(c.api.state)
+	if err != nil {
+		return params.FindToolsResults{}, err
+	}
+	filter := coretools.Filter{Series: args.Series, Arch: args.Arch}
+	list, err := envtools.FindTools(env, args.MajorVersion, args.MinorVersion, filter, envtools.DoNotAllowRetry)
+	return params.FindToolsResults{List: list, Error: common.ServerError(err)}, nil
+}
+
+// environFromState returns the environment described by the
+// configuration held in state.
+func environFromState(st *state.State) (environs.Environ, error) {
+	cfg, err := st.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	return environs.New(cfg)
+}
+
+// findUpgradeTools returns the tools of the given version available
+// to the environment. It returns an empty list if there are none.
+func findUpgradeTools(st *state.State, vers version.Number) (coretools.List, error) {
+	env, err := environFromState(st)
+	if err != nil {
+		return nil, err
+	}
+	filter := coretools.Filter{Number: vers}
+	tools, err := envtools.FindTools(env, vers.Major, vers.Minor, filter, envtools.DoNotAllowRetry)
+	if errors.IsNotFoundError(err) {
+		return nil, nil
+	}
+	return tools, err
+}
+
+// checkAgents returns an error if the environment cannot be upgraded to
+// the given version: every agent must be running, no unit may be in an
+// error state and the given tools must include some for the series and
+// architecture of every machine.
+func checkAgents(st *state.State, vers version.Number, tools coretools.List) error {
+	var notRunning, inError []string
+	missingTools := set.NewStrings()
+	machines, err := st.AllMachines()
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if m.Life() == state.Dead {
+			continue
+		}
+		if alive, err := m.AgentAlive(); err != nil {
+			return err
+		} else if !alive {
+			notRunning = append(notRunning, m.Tag())
+		}
+		agentTools, err := m.AgentTools()
+		if errors.IsNotFoundError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		filter := coretools.Filter{Series: agentTools.Version.Series, Arch: agentTools.Version.Arch}
+		if _, err := tools.Match(filter); err != nil {
+			missingTools.Add(fmt.Sprintf("%s/%s", filter.Series, filter.Arch))
+		}
+	}
+	services, err := st.AllServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		units, err := service.AllUnits()
+		if err != nil {
+			return err
+		}
+		for _, u := range units {
+			if u.Life() == state.Dead {
+				continue
+			}
+			if alive, err := u.AgentAlive(); err != nil {
+				return err
+			} else if !alive {
+				notRunning = append(notRunning, u.Tag())
+			}
+			if status, _, _, err := u.Status(); err != nil {
+				return err
+			} else if status == params.StatusError {
+				inError = append(inError, u.Name())
+			}
+		}
+	}
+	if !missingTools.IsEmpty() {
+		return fmt.Errorf("cannot upgrade to %s: no tools available for %s", vers, strings.Join(missingTools.SortedValues(), ", "))
+	}
+	if len(notRunning) > 0 {
+		return fmt.Errorf("cannot upgrade to %s: agents not running: %s", vers, strings.Join(notRunning, ", "))
+	}
+	if len(inError) > 0 {
+		return fmt.Errorf("cannot upgrade to %s: units in error state: %s", vers, strings.Join(inError, ", "))
+	}
+	return nil
+}
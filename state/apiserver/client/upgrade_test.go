@@ -0,0 +1,104 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package client_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	envtesting "launchpad.net/juju-core/environs/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/version"
+)
+
+type upgradeSuite struct {
+	baseSuite
+}
+
+var _ = gc.Suite(&upgradeSuite{})
+
+func (s *upgradeSuite) TestSetEnvironAgentVersion(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	target := version.Current.Number
+	target.Minor++
+
+	err = s.APIState.Client().SetEnvironAgentVersion(target)
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to .*: agents not running: machine-0")
+	_, err = s.State.UpgradeInfo()
+	c.Assert(err, gc.NotNil)
+
+	// Start the agent through the API server's own state, so that its
+	// presence watcher notices it at once.
+	apiMachine, err := s.BackingState.Machine(machine.Id())
+	c.Assert(err, gc.IsNil)
+	pinger, err := apiMachine.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Kill()
+	s.BackingState.StartSync()
+	err = apiMachine.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+	err = s.APIState.Client().SetEnvironAgentVersion(target)
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.TargetVersion(), gc.Equals, target)
+}
+
+func (s *upgradeSuite) TestSetEnvironAgentVersionChecksTools(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAgentVersion(version.MustParseBinary("1.2.3-quantal-amd64"))
+	c.Assert(err, gc.IsNil)
+	target := version.MustParse("1.2.4")
+	err = s.APIState.Client().SetEnvironAgentVersion(target)
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4: no tools available for quantal/amd64")
+}
+
+func (s *upgradeSuite) TestSetEnvironAgentVersionChecksUploadedTools(c *gc.C) {
+	machine, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = machine.SetAgentVersion(version.MustParseBinary("1.2.3-quantal-amd64"))
+	c.Assert(err, gc.IsNil)
+	envtesting.MustUploadFakeToolsVersions(s.Conn.Environ.Storage(), version.MustParseBinary("1.2.4.1-precise-amd64"))
+	target := version.MustParse("1.2.4.1")
+	err = s.APIState.Client().SetEnvironAgentVersion(target)
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4.1: no tools available for quantal/amd64")
+
+	envtesting.MustUploadFakeToolsVersions(s.Conn.Environ.Storage(), version.MustParseBinary("1.2.4.1-quantal-amd64"))
+	err = s.APIState.Client().SetEnvironAgentVersion(target)
+	c.Assert(err, gc.ErrorMatches, "cannot upgrade to 1.2.4.1: agents not running: machine-0")
+}
+
+func (s *upgradeSuite) TestFindTools(c *gc.C) {
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	cfg, err = cfg.Apply(map[string]interface{}{
+		"tools-metadata-url": "file://" + c.MkDir(),
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	stor := s.Conn.Environ.Storage()
+	envtesting.RemoveTools(c, stor)
+	envtesting.MustUploadFakeToolsVersions(stor,
+		version.MustParseBinary("2.12.0-precise-amd64"),
+		version.MustParseBinary("2.12.0-precise-i386"),
+		version.MustParseBinary("2.13.0-raring-amd64"),
+	)
+
+	list, err := s.APIState.Client().FindTools(2, 12, "precise", "amd64")
+	c.Assert(err, gc.IsNil)
+	c.Assert(list, gc.HasLen, 1)
+	c.Assert(list[0].Version, gc.Equals, version.MustParseBinary("2.12.0-precise-amd64"))
+
+	list, err = s.APIState.Client().FindTools(2, -1, "", "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(list, gc.HasLen, 3)
+
+	_, err = s.APIState.Client().FindTools(2, 14, "", "")
+	c.Assert(err, jc.Satisfies, params.IsCodeNotFound)
+}
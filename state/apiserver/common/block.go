@@ -0,0 +1,37 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package common
+
+import (
+	"fmt"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// CheckNotBlocked returns an error with the CodeOperationBlocked code,
+// giving the stored reason, if a block of any of the given types is
+// switched on in the environment.
+func CheckNotBlocked(st *state.State, types ...state.BlockType) error {
+	blocks, err := st.Blocks()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		for _, t := range types {
+			if b.Type() != t {
+				continue
+			}
+			message := fmt.Sprintf("operation blocked by %q block", t)
+			if b.Message() != "" {
+				message += ": " + b.Message()
+			}
+			return &params.Error{
+				Message: message,
+				Code:    params.CodeOperationBlocked,
+			}
+		}
+	}
+	return nil
+}
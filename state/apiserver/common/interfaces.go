@@ -38,6 +38,10 @@ type Authorizer interface {
 	// is a client user.
 	AuthClient() bool
 
+	// AuthUserPermission returns whether the authenticated entity
+	// is a client user holding at least the given permission.
+	AuthUserPermission(perm state.Permission) bool
+
 	// GetAuthTag returns the tag of the authenticated entity.
 	GetAuthTag() string
 
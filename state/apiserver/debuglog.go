@@ -0,0 +1,161 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"code.google.com/p/go.net/websocket"
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+)
+
+// serveDebugLog streams the log records written by the agents to a
+// client. The client authenticates with HTTP basic authentication,
+// and chooses the records it sees with the query parameters of the
+// request URL. The first message sent is a JSON-encoded
+// params.ErrorResult; unless it holds an error, it is followed by
+// the matching log records, one per line.
+func (srv *Server) serveDebugLog(conn *websocket.Conn) error {
+	defer conn.Close()
+	req := conn.Request()
+	err := srv.authenticateUser(req.Header, state.ReadPermission)
+	var filter state.LogFilter
+	if err == nil {
+		filter, err = debugLogFilter(req.URL.Query())
+	}
+	result := params.ErrorResult{Error: common.ServerError(err)}
+	if err := websocket.JSON.Send(conn, &result); err != nil {
+		return fmt.Errorf("cannot send debug log status: %v", err)
+	}
+	if err != nil {
+		return nil
+	}
+	tailer := srv.state.NewLogTailer(filter)
+	defer tailer.Stop()
+
+	// The client sends nothing, so reads return only when the
+	// connection has been closed.
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		close(closed)
+	}()
+	for {
+		select {
+		case <-srv.tomb.Dying():
+			return nil
+		case <-closed:
+			return nil
+		case record, ok := <-tailer.Logs():
+			if !ok {
+				return tailer.Err()
+			}
+			if _, err := io.WriteString(conn, formatLogRecord(record)); err != nil {
+				// The client has gone away.
+				return nil
+			}
+		}
+	}
+}
+
+// formatLogRecord returns the line sent to debug-log clients for
+// the given record.
+func formatLogRecord(r *state.LogRecord) string {
+	return fmt.Sprintf("%s: %s %s %s %s %s\n",
+		r.Entity,
+		r.Time.UTC().Format("2006-01-02 15:04:05"),
+		r.Level,
+		r.Module,
+		r.Location,
+		r.Message,
+	)
+}
+
+// authenticateUser checks that the basic authentication credentials
+// in the given header belong to a user with the given permission.
+func (srv *Server) authenticateUser(header http.Header, perm state.Permission) error {
+	tag, password, ok := parseBasicAuth(header)
+	if !ok {
+		return common.ErrBadCreds
+	}
+	entity0, err := srv.state.FindEntity(tag)
+	if err != nil && !errors.IsNotFoundError(err) {
+		return err
+	}
+	// As with Login, we return the same error for a missing entity as
+	// for a bad password.
+	entity, ok := entity0.(taggedAuthenticator)
+	if !ok || err != nil || !entity.PasswordValid(password) {
+		return common.ErrBadCreds
+	}
+	user, ok := entity.(*state.User)
+	if !ok || !user.Permission().Includes(perm) {
+		return common.ErrPerm
+	}
+	return nil
+}
+
+// parseBasicAuth returns the user name and password held in the
+// Authorization header, if it uses basic authentication.
+func parseBasicAuth(header http.Header) (tag, password string, ok bool) {
+	parts := strings.Fields(header.Get("Authorization"))
+	if len(parts) != 2 || parts[0] != "Basic" {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", "", false
+	}
+	creds := strings.SplitN(string(decoded), ":", 2)
+	if len(creds) != 2 {
+		return "", "", false
+	}
+	return creds[0], creds[1], true
+}
+
+// debugLogFilter returns the filter described by the query
+// parameters of a debug log request.
+func debugLogFilter(query url.Values) (state.LogFilter, error) {
+	filter := state.LogFilter{
+		IncludeEntity: query["includeEntity"],
+		ExcludeEntity: query["excludeEntity"],
+		IncludeModule: query["includeModule"],
+		ExcludeModule: query["excludeModule"],
+	}
+	if value := query.Get("level"); value != "" {
+		level, ok := loggo.ParseLevel(value)
+		if !ok || level < loggo.TRACE || level > loggo.CRITICAL {
+			return state.LogFilter{}, fmt.Errorf("level value %q is not one of %q, %q, %q, %q, %q, %q",
+				value, loggo.TRACE, loggo.DEBUG, loggo.INFO, loggo.WARNING, loggo.ERROR, loggo.CRITICAL)
+		}
+		filter.Level = level
+	}
+	if value := query.Get("replay"); value != "" {
+		replay, err := strconv.ParseBool(value)
+		if err != nil {
+			return state.LogFilter{}, fmt.Errorf("replay value %q is not a boolean", value)
+		}
+		filter.Replay = replay
+	}
+	if value := query.Get("backlog"); value != "" {
+		backlog, err := strconv.Atoi(value)
+		if err != nil || backlog < 0 {
+			return state.LogFilter{}, fmt.Errorf("backlog value %q is not a non-negative integer", value)
+		}
+		filter.Backlog = backlog
+	}
+	return filter, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bufio"
+	"io"
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
+
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type debugLogSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&debugLogSuite{})
+
+var debugLogTime = time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func (s *debugLogSuite) addLogs(c *gc.C) {
+	err := s.State.AddLogs(state.LogRecord{
+		Time:     debugLogTime,
+		Entity:   "machine-0",
+		Module:   "juju.worker.provisioner",
+		Level:    loggo.INFO,
+		Location: "provisioner.go:10",
+		Message:  "starting",
+	}, state.LogRecord{
+		Time:     debugLogTime,
+		Entity:   "unit-mysql-0",
+		Module:   "juju.worker.uniter",
+		Level:    loggo.ERROR,
+		Location: "uniter.go:20",
+		Message:  "hook failed",
+	})
+	c.Assert(err, gc.IsNil)
+}
+
+// readLines reads the given number of lines from the stream.
+func readLines(c *gc.C, stream io.Reader, n int) []string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReader(stream)
+		for i := 0; i < n; i++ {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+	var result []string
+	for i := 0; i < n; i++ {
+		select {
+		case line, ok := <-lines:
+			c.Assert(ok, gc.Equals, true)
+			result = append(result, line)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for log line %d", i)
+		}
+	}
+	return result
+}
+
+func (s *debugLogSuite) TestReplay(c *gc.C) {
+	s.addLogs(c)
+	stream, err := s.APIState.Client().WatchDebugLog(api.DebugLogParams{Replay: true})
+	c.Assert(err, gc.IsNil)
+	defer stream.Close()
+	c.Assert(readLines(c, stream, 2), gc.DeepEquals, []string{
+		"machine-0: 2014-03-01 12:00:00 INFO juju.worker.provisioner provisioner.go:10 starting\n",
+		"unit-mysql-0: 2014-03-01 12:00:00 ERROR juju.worker.uniter uniter.go:20 hook failed\n",
+	})
+}
+
+func (s *debugLogSuite) TestFilterAndFollow(c *gc.C) {
+	s.addLogs(c)
+	stream, err := s.APIState.Client().WatchDebugLog(api.DebugLogParams{
+		IncludeEntity: []string{"unit-*"},
+		Level:         loggo.WARNING,
+		Backlog:       5,
+	})
+	c.Assert(err, gc.IsNil)
+	defer stream.Close()
+	c.Assert(readLines(c, stream, 1), gc.DeepEquals, []string{
+		"unit-mysql-0: 2014-03-01 12:00:00 ERROR juju.worker.uniter uniter.go:20 hook failed\n",
+	})
+
+	err = s.State.AddLogs(state.LogRecord{
+		Time:    debugLogTime,
+		Entity:  "unit-mysql-1",
+		Module:  "juju.worker.uniter",
+		Level:   loggo.INFO,
+		Message: "too quiet",
+	}, state.LogRecord{
+		Time:    debugLogTime,
+		Entity:  "unit-mysql-1",
+		Module:  "unit.mysql/1.install",
+		Level:   loggo.WARNING,
+		Message: "disk full",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(readLines(c, stream, 1), gc.DeepEquals, []string{
+		"unit-mysql-1: 2014-03-01 12:00:00 WARNING unit.mysql/1.install  disk full\n",
+	})
+}
+
+func (s *debugLogSuite) TestBadFilter(c *gc.C) {
+	_, err := s.APIState.Client().WatchDebugLog(api.DebugLogParams{Level: loggo.Level(42)})
+	c.Assert(err, gc.ErrorMatches, `level value .* is not one of .*`)
+}
+
+func (s *debugLogSuite) TestReadPermissionRequired(c *gc.C) {
+	_, err := s.State.AddUserWithPermission("reader", "password", state.ReadPermission)
+	c.Assert(err, gc.IsNil)
+	st := s.OpenAPIAs(c, "user-reader", "password")
+	stream, err := st.Client().WatchDebugLog(api.DebugLogParams{})
+	c.Assert(err, gc.IsNil)
+	stream.Close()
+
+	machineSt, _ := s.OpenAPIAsNewMachine(c)
+	_, err = machineSt.Client().WatchDebugLog(api.DebugLogParams{})
+	c.Assert(err, gc.ErrorMatches, "permission denied")
+}
@@ -21,6 +21,7 @@ var logger = loggo.GetLogger("juju.api.logger")
 type Logger interface {
 	WatchLoggingConfig(args params.Entities) params.NotifyWatchResults
 	LoggingConfig(args params.Entities) params.StringResults
+	AddLogs(args params.LogRecords) error
 }
 
 // LoggerAPI implements the Logger interface and is the concrete
@@ -91,3 +92,21 @@ func (api *LoggerAPI) LoggingConfig(arg params.Entities) params.StringResults {
 	}
 	return params.StringResults{results}
 }
+
+// AddLogs records log messages written by the connected agent. The
+// records are attributed to the agent, whatever it claims.
+func (api *LoggerAPI) AddLogs(args params.LogRecords) error {
+	entity := api.authorizer.GetAuthTag()
+	records := make([]state.LogRecord, len(args.Records))
+	for i, r := range args.Records {
+		records[i] = state.LogRecord{
+			Time:     r.Time,
+			Entity:   entity,
+			Module:   r.Module,
+			Level:    r.Level,
+			Location: r.Location,
+			Message:  r.Message,
+		}
+	}
+	return api.state.AddLogs(records...)
+}
@@ -4,7 +4,10 @@
 package logger_test
 
 import (
+	"time"
+
 	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
 
 	jujutesting "launchpad.net/juju-core/juju/testing"
 	"launchpad.net/juju-core/state"
@@ -13,6 +16,7 @@ import (
 	"launchpad.net/juju-core/state/apiserver/logger"
 	apiservertesting "launchpad.net/juju-core/state/apiserver/testing"
 	statetesting "launchpad.net/juju-core/state/testing"
+	coretesting "launchpad.net/juju-core/testing"
 )
 
 type loggerSuite struct {
@@ -144,3 +148,31 @@ func (s *loggerSuite) TestLoggingConfigForAgent(c *gc.C) {
 	c.Assert(result.Error, gc.IsNil)
 	c.Assert(result.Result, gc.Equals, loggingConfig)
 }
+
+func (s *loggerSuite) TestAddLogsUsesAgentTag(c *gc.C) {
+	args := params.LogRecords{Records: []params.LogRecord{{
+		Time:    time.Now(),
+		Module:  "juju.worker",
+		Level:   loggo.WARNING,
+		Message: "first",
+	}, {
+		Time:    time.Now(),
+		Module:  "juju.worker",
+		Level:   loggo.ERROR,
+		Message: "second",
+	}}}
+	err := s.logger.AddLogs(args)
+	c.Assert(err, gc.IsNil)
+
+	tailer := s.State.NewLogTailer(state.LogFilter{Replay: true})
+	defer tailer.Stop()
+	for _, expect := range []string{"first", "second"} {
+		select {
+		case record := <-tailer.Logs():
+			c.Assert(record.Entity, gc.Equals, s.rawMachine.Tag())
+			c.Assert(record.Message, gc.Equals, expect)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for log record")
+		}
+	}
+}
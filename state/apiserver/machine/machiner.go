@@ -16,6 +16,7 @@ type MachinerAPI struct {
 	*common.StatusSetter
 	*common.DeadEnsurer
 	*common.AgentEntityWatcher
+	*common.APIAddresser
 
 	st   *state.State
 	auth common.Authorizer
@@ -34,6 +35,7 @@ func NewMachinerAPI(st *state.State, resources *common.Resources, authorizer com
 		StatusSetter:       common.NewStatusSetter(st, getCanRead),
 		DeadEnsurer:        common.NewDeadEnsurer(st, getCanRead),
 		AgentEntityWatcher: common.NewAgentEntityWatcher(st, resources, getCanRead),
+		APIAddresser:       common.NewAPIAddresser(st),
 		st:                 st,
 		auth:               authorizer,
 	}, nil
@@ -211,13 +211,53 @@ func (p *ProvisionerAPI) Status(args params.Entities) (params.StatusResults, err
 		machine, err := p.getMachine(canAccess, entity.Tag)
 		if err == nil {
 			r := &result.Results[i]
-			r.Status, r.Info, _, err = machine.Status()
+			r.Status, r.Info, r.Data, err = machine.Status()
 		}
 		result.Results[i].Error = common.ServerError(err)
 	}
 	return result, nil
 }
 
+// MachinesWithTransientErrors returns the status of each alive,
+// unprovisioned machine accessible to the provisioner that failed to
+// start with an error the provisioner should retry.
+func (p *ProvisionerAPI) MachinesWithTransientErrors() (params.StatusResults, error) {
+	result := params.StatusResults{}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	machines, err := p.st.MachinesWithTransientErrors()
+	if err != nil {
+		return result, err
+	}
+	for _, machine := range machines {
+		if !canAccess(machine.Tag()) {
+			continue
+		}
+		if _, err := machine.InstanceId(); err == nil {
+			continue
+		} else if !state.IsNotProvisionedError(err) {
+			return result, err
+		}
+		status, info, data, err := machine.Status()
+		if err != nil {
+			return result, err
+		}
+		// The status may have changed since the query.
+		if status != params.StatusError || data["transient"] != true {
+			continue
+		}
+		result.Results = append(result.Results, params.StatusResult{
+			Id:     machine.Id(),
+			Status: status,
+			Info:   info,
+			Data:   data,
+		})
+	}
+	return result, nil
+}
+
 // Series returns the deployed series for each given machine entity.
 func (p *ProvisionerAPI) Series(args params.Entities) (params.StringResults, error) {
 	result := params.StringResults{
@@ -237,6 +277,26 @@ func (p *ProvisionerAPI) Series(args params.Entities) (params.StringResults, err
 	return result, nil
 }
 
+// Placement returns the placement directive, if any, for each given
+// machine entity.
+func (p *ProvisionerAPI) Placement(args params.Entities) (params.StringResults, error) {
+	result := params.StringResults{
+		Results: make([]params.StringResult, len(args.Entities)),
+	}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	for i, entity := range args.Entities {
+		machine, err := p.getMachine(canAccess, entity.Tag)
+		if err == nil {
+			result.Results[i].Result = machine.Placement()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // Constraints returns the constraints for each given machine entity.
 func (p *ProvisionerAPI) Constraints(args params.Entities) (params.ConstraintsResults, error) {
 	result := params.ConstraintsResults{
@@ -260,6 +320,29 @@ func (p *ProvisionerAPI) Constraints(args params.Entities) (params.ConstraintsRe
 	return result, nil
 }
 
+// Jobs returns the jobs assigned to each given machine entity.
+func (p *ProvisionerAPI) Jobs(args params.Entities) (params.MachineJobsResults, error) {
+	result := params.MachineJobsResults{
+		Results: make([]params.MachineJobsResult, len(args.Entities)),
+	}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	for i, entity := range args.Entities {
+		machine, err := p.getMachine(canAccess, entity.Tag)
+		if err == nil {
+			jobs := machine.Jobs()
+			result.Results[i].Jobs = make([]params.MachineJob, len(jobs))
+			for j, job := range jobs {
+				result.Results[i].Jobs[j] = job.ToParams()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // SetProvisioned sets the provider specific machine id, nonce and
 // metadata for each given machine. Once set, the instance id cannot
 // be changed.
@@ -281,6 +364,28 @@ func (p *ProvisionerAPI) SetProvisioned(args params.SetProvisioned) (params.Erro
 	return result, nil
 }
 
+// SetInstanceInfo sets the provider specific machine id, nonce,
+// metadata, networks and network interfaces for each given machine.
+func (p *ProvisionerAPI) SetInstanceInfo(args params.InstancesInfo) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Machines)),
+	}
+	canAccess, err := p.getAuthFunc()
+	if err != nil {
+		return result, err
+	}
+	for i, arg := range args.Machines {
+		machine, err := p.getMachine(canAccess, arg.Tag)
+		if err == nil {
+			err = machine.SetInstanceInfo(
+				arg.InstanceId, arg.Nonce, arg.Characteristics,
+				arg.Networks, arg.Interfaces)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // InstanceId returns the provider specific instance id for each given
 // machine or an CodeNotProvisioned error, if not set.
 func (p *ProvisionerAPI) InstanceId(args params.Entities) (params.StringResults, error) {
@@ -488,6 +488,29 @@ func (s *provisionerSuite) TestStatus(c *gc.C) {
 	})
 }
 
+func (s *provisionerSuite) TestMachinesWithTransientErrors(c *gc.C) {
+	err := s.machines[0].SetProvisioned("i-am", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	err = s.machines[0].SetStatus(params.StatusError, "not provisioned", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+	err = s.machines[1].SetStatus(params.StatusError, "transient error",
+		params.StatusData{"transient": true, "attempts": 2})
+	c.Assert(err, gc.IsNil)
+	err = s.machines[2].SetStatus(params.StatusError, "error", params.StatusData{"attempts": 1})
+	c.Assert(err, gc.IsNil)
+
+	result, err := s.provisioner.MachinesWithTransientErrors()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StatusResults{
+		Results: []params.StatusResult{{
+			Id:     "1",
+			Status: params.StatusError,
+			Info:   "transient error",
+			Data:   params.StatusData{"transient": true, "attempts": 2},
+		}},
+	})
+}
+
 func (s *provisionerSuite) TestSeries(c *gc.C) {
 	// Add a machine with different series.
 	foobarMachine, err := s.State.AddMachine("foobar", state.JobHostUnits)
@@ -515,6 +538,55 @@ func (s *provisionerSuite) TestSeries(c *gc.C) {
 	})
 }
 
+func (s *provisionerSuite) TestPlacement(c *gc.C) {
+	placementMachine, err := s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:    "quantal",
+		Jobs:      []state.MachineJob{state.JobHostUnits},
+		Placement: "zone=zone1",
+	})
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.machines[0].Tag()},
+		{Tag: placementMachine.Tag()},
+		{Tag: "machine-42"},
+		{Tag: "unit-foo-0"},
+		{Tag: "service-bar"},
+	}}
+	result, err := s.provisioner.Placement(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StringResults{
+		Results: []params.StringResult{
+			{Result: ""},
+			{Result: "zone=zone1"},
+			{Error: apiservertesting.NotFoundError("machine 42")},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *provisionerSuite) TestJobs(c *gc.C) {
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: s.machines[0].Tag()},
+		{Tag: s.machines[1].Tag()},
+		{Tag: "machine-42"},
+		{Tag: "unit-foo-0"},
+		{Tag: "service-bar"},
+	}}
+	result, err := s.provisioner.Jobs(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.MachineJobsResults{
+		Results: []params.MachineJobsResult{
+			{Jobs: []params.MachineJob{params.JobManageState}},
+			{Jobs: []params.MachineJob{params.JobHostUnits}},
+			{Error: apiservertesting.NotFoundError("machine 42")},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *provisionerSuite) TestConstraints(c *gc.C) {
 	// Add a machine with some constraints.
 	machineParams := state.AddMachineParams{
@@ -594,6 +666,57 @@ func (s *provisionerSuite) TestSetProvisioned(c *gc.C) {
 	c.Check(gotHardware, gc.DeepEquals, &hwChars)
 }
 
+func (s *provisionerSuite) TestSetInstanceInfo(c *gc.C) {
+	// Provision machine 0 first.
+	err := s.machines[0].SetProvisioned("i-am", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+
+	networks := []instance.NetworkInfo{{
+		Name:       "storage",
+		ProviderId: "vlan42",
+		CIDR:       "10.0.42.0/24",
+		VLANTag:    42,
+	}}
+	interfaces := []instance.InterfaceInfo{{
+		MACAddress:    "aa:bb:cc:dd:ee:f0",
+		InterfaceName: "eth0.42",
+		NetworkName:   "storage",
+		Address:       "10.0.42.3",
+		IsVirtual:     true,
+	}}
+	args := params.InstancesInfo{Machines: []params.InstanceInfo{
+		{Tag: s.machines[0].Tag(), InstanceId: "i-was", Nonce: "fake_nonce"},
+		{Tag: s.machines[1].Tag(), InstanceId: "i-will", Nonce: "fake_nonce", Networks: networks, Interfaces: interfaces},
+		{Tag: "machine-42", InstanceId: "", Nonce: ""},
+		{Tag: "unit-foo-0", InstanceId: "", Nonce: ""},
+	}}
+	result, err := s.provisioner.SetInstanceInfo(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{&params.Error{
+				Message: `cannot set instance data for machine "0": already set`,
+			}},
+			{nil},
+			{apiservertesting.NotFoundError("machine 42")},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify machine 1 was provisioned with its network interface.
+	c.Assert(s.machines[1].Refresh(), gc.IsNil)
+	instanceId, err := s.machines[1].InstanceId()
+	c.Assert(err, gc.IsNil)
+	c.Check(instanceId, gc.Equals, instance.Id("i-will"))
+	network, err := s.State.Network("storage")
+	c.Assert(err, gc.IsNil)
+	c.Check(network.Info(), gc.DeepEquals, networks[0])
+	ifaces, err := s.machines[1].NetworkInterfaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, gc.HasLen, 1)
+	c.Check(ifaces[0].Info(), gc.DeepEquals, interfaces[0])
+}
+
 func (s *provisionerSuite) TestInstanceId(c *gc.C) {
 	// Provision 2 machines first.
 	err := s.machines[0].SetProvisioned("i-am", "fake_nonce", nil)
@@ -268,6 +268,22 @@ func (r *srvRoot) AuthClient() bool {
 	return !isAgent(r.entity)
 }
 
+// AuthUserPermission returns whether the authenticated entity is a
+// client user holding at least the given permission. The user is
+// fetched afresh from the state, so that disabling or removing a user,
+// or reducing their permission, takes effect immediately.
+func (r *srvRoot) AuthUserPermission(perm state.Permission) bool {
+	u, ok := r.entity.(*state.User)
+	if !ok {
+		return false
+	}
+	u, err := r.srv.state.User(u.Name())
+	if err != nil {
+		return false
+	}
+	return !u.IsDisabled() && u.Permission().Includes(perm)
+}
+
 // GetAuthTag returns the tag of the authenticated entity.
 func (r *srvRoot) GetAuthTag() string {
 	return r.entity.Tag()
@@ -20,6 +20,7 @@ var allowedDiscardedMethods = []string{
 	"AuthMachineAgent",
 	"AuthOwner",
 	"AuthUnitAgent",
+	"AuthUserPermission",
 	"GetAuthEntity",
 	"GetAuthTag",
 }
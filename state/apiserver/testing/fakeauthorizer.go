@@ -15,6 +15,7 @@ type FakeAuthorizer struct {
 	MachineAgent bool
 	UnitAgent    bool
 	Client       bool
+	Permission   state.Permission
 	Entity       state.Entity
 }
 
@@ -38,6 +39,10 @@ func (fa FakeAuthorizer) AuthClient() bool {
 	return fa.Client
 }
 
+func (fa FakeAuthorizer) AuthUserPermission(perm state.Permission) bool {
+	return fa.Client && fa.Permission.Includes(perm)
+}
+
 func (fa FakeAuthorizer) GetAuthTag() string {
 	return fa.Tag
 }
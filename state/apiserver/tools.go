@@ -0,0 +1,154 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/simplestreams"
+	envtools "launchpad.net/juju-core/environs/tools"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/state/apiserver/common"
+	coretools "launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
+)
+
+// toolsHandler handles uploads of tools. Tools are uploaded by POSTing
+// their archive, a gzipped tarball, to /tools, with the version of the
+// tools given by the "binaryVersion" query parameter. The optional
+// "series" parameter holds a comma-separated list of further series
+// the same tools are stored for. The client authenticates with HTTP
+// basic authentication. The response is a JSON-encoded
+// params.ToolsResponse holding the uploaded tools, or an error.
+type toolsHandler struct {
+	srv *Server
+}
+
+func (h *toolsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.srv.authenticateUser(r.Header, state.WritePermission); err != nil {
+		status := http.StatusUnauthorized
+		if err == common.ErrPerm {
+			status = http.StatusForbidden
+		}
+		h.sendError(w, status, err.Error())
+		return
+	}
+	if r.Method != "POST" {
+		h.sendError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method: %q", r.Method))
+		return
+	}
+	// Uploading tools is a change to the environment, like upgrading it.
+	if err := common.CheckNotBlocked(h.srv.state, state.ChangeBlock); err != nil {
+		h.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	tools, err := h.processPost(r)
+	if err != nil {
+		h.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.sendJSON(w, http.StatusOK, &params.ToolsResponse{Tools: tools})
+}
+
+// processPost stores the tools archive held in the body of the request
+// in the environment's storage, once for each series requested, and
+// returns the tools of the given version.
+func (h *toolsHandler) processPost(r *http.Request) (*coretools.Tools, error) {
+	query := r.URL.Query()
+	binaryVersion := query.Get("binaryVersion")
+	if binaryVersion == "" {
+		return nil, fmt.Errorf("expected binaryVersion= URL argument")
+	}
+	toolsVersion, err := version.ParseBinary(binaryVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tools version %q: %v", binaryVersion, err)
+	}
+	versions := []version.Binary{toolsVersion}
+	if series := query.Get("series"); series != "" {
+		for _, series := range strings.Split(series, ",") {
+			if series == "" {
+				return nil, fmt.Errorf("invalid series %q", series)
+			}
+			if _, err := simplestreams.SeriesVersion(series); err != nil {
+				return nil, err
+			}
+			if series != toolsVersion.Series {
+				vers := toolsVersion
+				vers.Series = series
+				versions = append(versions, vers)
+			}
+		}
+	}
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/x-tar-gz" {
+		return nil, fmt.Errorf("expected Content-Type: application/x-tar-gz, got: %v", contentType)
+	}
+	tempFile, err := ioutil.TempFile("", "tools")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temp file: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+	sha256hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, sha256hash), r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error processing file upload: %v", err)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("no tools uploaded")
+	}
+	cfg, err := h.srv.state.EnvironConfig()
+	if err != nil {
+		return nil, err
+	}
+	env, err := environs.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	stor := env.Storage()
+	var toolsList coretools.List
+	for _, vers := range versions {
+		if _, err := tempFile.Seek(0, 0); err != nil {
+			return nil, err
+		}
+		if err := stor.Put(envtools.StorageName(vers), tempFile, size); err != nil {
+			return nil, fmt.Errorf("cannot store tools %s: %v", vers, err)
+		}
+		toolsList = append(toolsList, &coretools.Tools{
+			Version: vers,
+			Size:    size,
+			SHA256:  fmt.Sprintf("%x", sha256hash.Sum(nil)),
+		})
+	}
+	if err := envtools.MergeAndWriteMetadata(stor, toolsList, envtools.DoNotWriteMirrors); err != nil {
+		return nil, fmt.Errorf("cannot write tools metadata: %v", err)
+	}
+	tools := toolsList[0]
+	if tools.URL, err = stor.URL(envtools.StorageName(tools.Version)); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
+
+// sendJSON sends a JSON-encoded response to the client.
+func (h *toolsHandler) sendJSON(w http.ResponseWriter, statusCode int, response *params.ToolsResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Errorf("cannot send tools upload response: %v", err)
+	}
+}
+
+// sendError sends a JSON-encoded error response to the client.
+func (h *toolsHandler) sendError(w http.ResponseWriter, statusCode int, message string) {
+	h.sendJSON(w, statusCode, &params.ToolsResponse{Error: message})
+}
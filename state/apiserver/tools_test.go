@@ -0,0 +1,80 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiserver_test
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/environs/storage"
+	envtools "launchpad.net/juju-core/environs/tools"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/version"
+)
+
+type toolsSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&toolsSuite{})
+
+func fakeToolsArchive(vers version.Binary) ([]byte, string) {
+	return coretesting.TarGz(coretesting.NewTarFile("jujud", 0777, "jujud contents "+vers.String()))
+}
+
+func (s *toolsSuite) TestUploadTools(c *gc.C) {
+	vers := version.MustParseBinary("1.9.0.1-quantal-amd64")
+	data, checksum := fakeToolsArchive(vers)
+	tools, err := s.APIState.Client().UploadTools(bytes.NewReader(data), vers, "precise", "quantal")
+	c.Assert(err, gc.IsNil)
+	c.Assert(tools.Version, gc.Equals, vers)
+	c.Assert(tools.SHA256, gc.Equals, checksum)
+	c.Assert(tools.Size, gc.Equals, int64(len(data)))
+
+	stor := s.Conn.Environ.Storage()
+	for _, series := range []string{"quantal", "precise"} {
+		v := vers
+		v.Series = series
+		r, err := storage.Get(stor, envtools.StorageName(v))
+		c.Assert(err, gc.IsNil)
+		stored, err := ioutil.ReadAll(r)
+		r.Close()
+		c.Assert(err, gc.IsNil)
+		c.Assert(stored, gc.DeepEquals, data)
+	}
+	metadata, err := envtools.ReadMetadata(stor)
+	c.Assert(err, gc.IsNil)
+	var releases []string
+	for _, m := range metadata {
+		if m.Version == vers.Number.String() {
+			c.Check(m.SHA256, gc.Equals, checksum)
+			releases = append(releases, m.Release)
+		}
+	}
+	c.Assert(releases, jc.SameContents, []string{"quantal", "precise"})
+}
+
+func (s *toolsSuite) TestUploadToolsInvalidSeries(c *gc.C) {
+	vers := version.MustParseBinary("1.9.0.1-quantal-amd64")
+	data, _ := fakeToolsArchive(vers)
+	_, err := s.APIState.Client().UploadTools(bytes.NewReader(data), vers, "precise", "no-such-series")
+	c.Assert(err, gc.ErrorMatches, `cannot upload tools: .*no-such-series.*`)
+}
+
+func (s *toolsSuite) TestWritePermissionRequired(c *gc.C) {
+	_, err := s.State.AddUserWithPermission("reader", "password", state.ReadPermission)
+	c.Assert(err, gc.IsNil)
+	st := s.OpenAPIAs(c, "user-reader", "password")
+	vers := version.MustParseBinary("1.9.0.1-quantal-amd64")
+	data, _ := fakeToolsArchive(vers)
+	_, err = st.Client().UploadTools(bytes.NewReader(data), vers)
+	c.Assert(err, gc.ErrorMatches, "cannot upload tools: permission denied")
+	_, err = storage.Get(s.Conn.Environ.Storage(), envtools.StorageName(vers))
+	c.Assert(err, gc.NotNil)
+}
@@ -7,6 +7,7 @@ package uniter
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/errors"
@@ -17,6 +18,12 @@ import (
 	"launchpad.net/juju-core/state/watcher"
 )
 
+// LeaseDuration holds how long the leadership of a service lasts once
+// one of its units has claimed or renewed it. It is decided here, and
+// not by the unit agents, so that no unit can hold the leadership for
+// longer without renewing it.
+var LeaseDuration = time.Minute
+
 // UniterAPI implements the API used by the uniter worker.
 type UniterAPI struct {
 	*common.LifeGetter
@@ -80,6 +87,14 @@ func (u *UniterAPI) getService(tag string) (*state.Service, error) {
 	return entity.(*state.Service), nil
 }
 
+func (u *UniterAPI) getServiceOfUnit(tag string) (*state.Service, error) {
+	unit, err := u.getUnit(tag)
+	if err != nil {
+		return nil, err
+	}
+	return unit.Service()
+}
+
 // PublicAddress returns the public address for each given unit, if set.
 func (u *UniterAPI) PublicAddress(args params.Entities) (params.StringResults, error) {
 	result := params.StringResults{
@@ -228,6 +243,199 @@ func (u *UniterAPI) ClearResolved(args params.Entities) (params.ErrorResults, er
 	return result, nil
 }
 
+// HookCancelRequested returns whether each given unit's agent has been
+// asked to cancel the hook it is running.
+func (u *UniterAPI) HookCancelRequested(args params.Entities) (params.BoolResults, error) {
+	result := params.BoolResults{
+		Results: make([]params.BoolResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.BoolResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				result.Results[i].Result = unit.HookCancelRequested()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// ClearCancelHook removes any request to cancel the running hook from
+// each given unit.
+func (u *UniterAPI) ClearCancelHook(args params.Entities) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.ClearCancelHook()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// SetWorkloadStatus sets the status of each given unit's workload.
+func (u *UniterAPI) SetWorkloadStatus(args params.SetWorkloadStatus) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.SetWorkloadStatus(entity.Status, entity.Info)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// WorkloadStatus returns the status of each given unit's workload.
+func (u *UniterAPI) WorkloadStatus(args params.Entities) (params.WorkloadStatusResults, error) {
+	result := params.WorkloadStatusResults{
+		Results: make([]params.WorkloadStatusResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.WorkloadStatusResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				r := &result.Results[i]
+				r.Status, r.Info, err = unit.WorkloadStatus()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// ClaimLeadership claims, or renews, the leadership of each given
+// unit's service for LeaseDuration, and reports whether the unit is
+// now the leader.
+func (u *UniterAPI) ClaimLeadership(args params.Entities) (params.BoolResults, error) {
+	result := params.BoolResults{
+		Results: make([]params.BoolResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.BoolResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				result.Results[i].Result, err = unit.ClaimLeadership(LeaseDuration)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// RenewLeadership extends the lease of each given unit's service for
+// LeaseDuration, and reports whether the unit held the lease. It never
+// makes a unit the leader.
+func (u *UniterAPI) RenewLeadership(args params.Entities) (params.BoolResults, error) {
+	result := params.BoolResults{
+		Results: make([]params.BoolResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.BoolResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				result.Results[i].Result, err = unit.RenewLeadership(LeaseDuration)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// LeaderSettings returns the settings published by the leader of
+// each given unit's service.
+func (u *UniterAPI) LeaderSettings(args params.Entities) (params.LeaderSettingsResults, error) {
+	result := params.LeaderSettingsResults{
+		Results: make([]params.LeaderSettingsResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.LeaderSettingsResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var service *state.Service
+			service, err = u.getServiceOfUnit(entity.Tag)
+			if err == nil {
+				result.Results[i].Settings, err = service.LeaderSettings()
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// SetLeaderSettings updates the settings published by each given
+// unit, which must be the leader of its service.
+func (u *UniterAPI) SetLeaderSettings(args params.SetLeaderSettings) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				err = unit.SetLeaderSettings(entity.Settings)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // GetPrincipal returns the result of calling PrincipalName() and
 // converting it to a tag, on each given unit.
 func (u *UniterAPI) GetPrincipal(args params.Entities) (params.StringBoolResults, error) {
@@ -424,6 +632,34 @@ func (u *UniterAPI) OpenPort(args params.EntitiesPorts) (params.ErrorResults, er
 	return result, nil
 }
 
+// AddMetrics records the batches of metrics reported by the given
+// units.
+func (u *UniterAPI) AddMetrics(args params.MetricBatches) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Batches)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, batch := range args.Batches {
+		err := common.ErrPerm
+		if canAccess(batch.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(batch.Tag)
+			if err == nil {
+				metrics := make([]state.Metric, len(batch.Metrics))
+				for j, m := range batch.Metrics {
+					metrics[j] = state.Metric{Key: m.Key, Value: m.Value, Time: m.Time}
+				}
+				err = unit.AddMetrics(metrics)
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // ClosePort sets the policy of the port with protocol and number to
 // be closed, for all given units.
 func (u *UniterAPI) ClosePort(args params.EntitiesPorts) (params.ErrorResults, error) {
@@ -490,6 +726,44 @@ func (u *UniterAPI) WatchConfigSettings(args params.Entities) (params.NotifyWatc
 	return result, nil
 }
 
+func (u *UniterAPI) watchOneUnitLeaderSettings(tag string) (string, error) {
+	service, err := u.getServiceOfUnit(tag)
+	if err != nil {
+		return "", err
+	}
+	watch := service.WatchLeaderSettings()
+	// Consume the initial event. Technically, API
+	// calls to Watch 'transmit' the initial event
+	// in the Watch response. But NotifyWatchers
+	// have no state to transmit.
+	if _, ok := <-watch.Changes(); ok {
+		return u.resources.Register(watch), nil
+	}
+	return "", watcher.MustErr(watch)
+}
+
+// WatchLeaderSettings returns a NotifyWatcher for observing changes
+// to the settings published by the leader of each unit's service.
+func (u *UniterAPI) WatchLeaderSettings(args params.Entities) (params.NotifyWatchResults, error) {
+	result := params.NotifyWatchResults{
+		Results: make([]params.NotifyWatchResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.NotifyWatchResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		watcherId := ""
+		if canAccess(entity.Tag) {
+			watcherId, err = u.watchOneUnitLeaderSettings(entity.Tag)
+		}
+		result.Results[i].NotifyWatcherId = watcherId
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 // ConfigSettings returns the complete set of service charm config
 // settings available to each given unit.
 func (u *UniterAPI) ConfigSettings(args params.Entities) (params.ConfigSettingsResults, error) {
@@ -518,6 +792,52 @@ func (u *UniterAPI) ConfigSettings(args params.Entities) (params.ConfigSettingsR
 	return result, nil
 }
 
+func storageInstanceParams(store *state.StorageInstance) params.StorageInstance {
+	result := params.StorageInstance{
+		Id:          store.Id(),
+		StorageName: store.StorageName(),
+		Kind:        string(store.Kind()),
+		Size:        store.Size(),
+	}
+	if info, ok := store.Info(); ok {
+		result.Size = info.Size
+		result.Location = info.Location
+	}
+	return result
+}
+
+// StorageInstances returns the storage instances belonging to each
+// given unit, including those not yet provisioned.
+func (u *UniterAPI) StorageInstances(args params.Entities) (params.StorageInstancesResults, error) {
+	result := params.StorageInstancesResults{
+		Results: make([]params.StorageInstancesResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.StorageInstancesResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			var unit *state.Unit
+			unit, err = u.getUnit(entity.Tag)
+			if err == nil {
+				var stores []*state.StorageInstance
+				stores, err = unit.StorageInstances()
+				if err == nil {
+					instances := make([]params.StorageInstance, len(stores))
+					for j, store := range stores {
+						instances[j] = storageInstanceParams(store)
+					}
+					result.Results[i].Instances = instances
+				}
+			}
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
 func (u *UniterAPI) watchOneServiceRelations(tag string) (params.StringsWatchResult, error) {
 	nothing := params.StringsWatchResult{}
 	service, err := u.getService(tag)
@@ -757,6 +1077,17 @@ func (u *UniterAPI) ProviderType() (params.StringResult, error) {
 	return result, err
 }
 
+// HookTimeout returns the environment's default limit on how long a
+// hook may run. A zero duration means hooks are not limited.
+func (u *UniterAPI) HookTimeout() (params.DurationResult, error) {
+	result := params.DurationResult{}
+	cfg, err := u.st.EnvironConfig()
+	if err == nil {
+		result.Result = cfg.HookTimeout()
+	}
+	return result, err
+}
+
 // EnterScope ensures each unit has entered its scope in the relation,
 // for all of the given relation/unit pairs. See also
 // state.RelationUnit.EnterScope().
@@ -952,3 +1283,114 @@ func (u *UniterAPI) WatchRelationUnits(args params.RelationUnits) (params.Relati
 	}
 	return result, nil
 }
+
+func (u *UniterAPI) watchOneUnitActions(tag string) (params.StringsWatchResult, error) {
+	nothing := params.StringsWatchResult{}
+	unit, err := u.getUnit(tag)
+	if err != nil {
+		return nothing, err
+	}
+	watch := unit.WatchActions()
+	// Consume the initial event and forward it to the result.
+	if changes, ok := <-watch.Changes(); ok {
+		return params.StringsWatchResult{
+			StringsWatcherId: u.resources.Register(watch),
+			Changes:          changes,
+		}, nil
+	}
+	return nothing, watcher.MustErr(watch)
+}
+
+// WatchActions returns a StringsWatcher, for each given unit, that
+// notifies of the ids of pending actions queued on that unit.
+func (u *UniterAPI) WatchActions(args params.Entities) (params.StringsWatchResults, error) {
+	result := params.StringsWatchResults{
+		Results: make([]params.StringsWatchResult, len(args.Entities)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.StringsWatchResults{}, err
+	}
+	for i, entity := range args.Entities {
+		err := common.ErrPerm
+		if canAccess(entity.Tag) {
+			result.Results[i], err = u.watchOneUnitActions(entity.Tag)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// getAction returns the action with the given id, if it is queued on a
+// unit the caller can access.
+func (u *UniterAPI) getAction(canAccess common.AuthFunc, id string) (*state.Action, error) {
+	action, err := u.st.Action(id)
+	if errors.IsNotFoundError(err) {
+		return nil, common.ErrPerm
+	} else if err != nil {
+		return nil, err
+	}
+	if !canAccess(names.UnitTag(action.UnitName())) {
+		return nil, common.ErrPerm
+	}
+	return action, nil
+}
+
+// Actions returns the name and parameters of each given action.
+func (u *UniterAPI) Actions(args params.ActionIds) (params.ActionResults, error) {
+	result := params.ActionResults{
+		Results: make([]params.ActionResult, len(args.ActionIds)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ActionResults{}, err
+	}
+	for i, id := range args.ActionIds {
+		action, err := u.getAction(canAccess, id)
+		if err == nil {
+			result.Results[i].Name = action.Name()
+			result.Results[i].Params = action.Params()
+			result.Results[i].Status = action.Status()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// BeginActions marks each given action as running.
+func (u *UniterAPI) BeginActions(args params.ActionIds) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.ActionIds)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, id := range args.ActionIds {
+		action, err := u.getAction(canAccess, id)
+		if err == nil {
+			err = action.Begin()
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
+
+// FinishActions records the outcome of each given action.
+func (u *UniterAPI) FinishActions(args params.ActionsFinish) (params.ErrorResults, error) {
+	result := params.ErrorResults{
+		Results: make([]params.ErrorResult, len(args.Actions)),
+	}
+	canAccess, err := u.accessUnit()
+	if err != nil {
+		return params.ErrorResults{}, err
+	}
+	for i, arg := range args.Actions {
+		action, err := u.getAction(canAccess, arg.ActionId)
+		if err == nil {
+			err = action.Finish(arg.Status, arg.Results, arg.Message)
+		}
+		result.Results[i].Error = common.ServerError(err)
+	}
+	return result, nil
+}
@@ -5,6 +5,7 @@ package uniter_test
 
 import (
 	stdtesting "testing"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -485,6 +486,205 @@ func (s *uniterSuite) TestClearResolved(c *gc.C) {
 	c.Assert(mode, gc.Equals, state.ResolvedNone)
 }
 
+func (s *uniterSuite) TestHookCancelRequested(c *gc.C) {
+	err := s.wordpressUnit.CancelHook()
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.HookCancelRequested(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.BoolResults{
+		Results: []params.BoolResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: true},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestClearCancelHook(c *gc.C) {
+	err := s.wordpressUnit.CancelHook()
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.ClearCancelHook(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	err = s.wordpressUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.wordpressUnit.HookCancelRequested(), jc.IsFalse)
+}
+
+func (s *uniterSuite) TestSetWorkloadStatus(c *gc.C) {
+	args := params.SetWorkloadStatus{Entities: []params.SetEntityWorkloadStatus{
+		{Tag: "unit-mysql-0", Status: params.WorkloadActive},
+		{Tag: "unit-wordpress-0", Status: params.WorkloadWaiting, Info: "syncing database"},
+		{Tag: "unit-foo-42", Status: params.WorkloadActive},
+	}}
+	result, err := s.uniter.SetWorkloadStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	status, info, err := s.wordpressUnit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadWaiting)
+	c.Assert(info, gc.Equals, "syncing database")
+}
+
+func (s *uniterSuite) TestWorkloadStatus(c *gc.C) {
+	err := s.wordpressUnit.SetWorkloadStatus(params.WorkloadBlocked, "needs a database")
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.WorkloadStatus(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.WorkloadStatusResults{
+		Results: []params.WorkloadStatusResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Status: params.WorkloadBlocked, Info: "needs a database"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestClaimLeadership(c *gc.C) {
+	args := params.Entities{
+		Entities: []params.Entity{
+			{Tag: "unit-mysql-0"},
+			{Tag: "unit-wordpress-0"},
+			{Tag: "unit-foo-42"},
+		},
+	}
+	result, err := s.uniter.ClaimLeadership(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.BoolResults{
+		Results: []params.BoolResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: true},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	leader, err := s.wordpress.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, "wordpress/0")
+}
+
+func (s *uniterSuite) TestClaimLeadershipLeaseDuration(c *gc.C) {
+	// The lease lasts as long as the API server decides.
+	s.PatchValue(&uniter.LeaseDuration, time.Nanosecond)
+	args := params.Entities{Entities: []params.Entity{{Tag: "unit-wordpress-0"}}}
+	result, err := s.uniter.ClaimLeadership(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results, gc.DeepEquals, []params.BoolResult{{Result: true}})
+	leader, err := s.wordpress.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, "")
+}
+
+func (s *uniterSuite) TestRenewLeadership(c *gc.C) {
+	args := params.Entities{
+		Entities: []params.Entity{
+			{Tag: "unit-mysql-0"},
+			{Tag: "unit-wordpress-0"},
+			{Tag: "unit-foo-42"},
+		},
+	}
+	// The unit is not the leader, so there is nothing to renew.
+	result, err := s.uniter.RenewLeadership(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.BoolResults{
+		Results: []params.BoolResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Result: false},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+	leader, err := s.wordpress.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, "")
+
+	claimed, err := s.wordpressUnit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	result, err = s.uniter.RenewLeadership(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results[1], gc.DeepEquals, params.BoolResult{Result: true})
+}
+
+func (s *uniterSuite) TestSetLeaderSettings(c *gc.C) {
+	claimed, err := s.wordpressUnit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+
+	args := params.SetLeaderSettings{Entities: []params.EntityLeaderSettings{
+		{Tag: "unit-mysql-0", Settings: map[string]string{"foo": "bar"}},
+		{Tag: "unit-wordpress-0", Settings: map[string]string{"master": "10.0.0.1"}},
+		{Tag: "unit-foo-42", Settings: map[string]string{"foo": "bar"}},
+	}}
+	result, err := s.uniter.SetLeaderSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	settings, err := s.wordpress.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]string{"master": "10.0.0.1"})
+}
+
+func (s *uniterSuite) TestLeaderSettings(c *gc.C) {
+	claimed, err := s.wordpressUnit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, jc.IsTrue)
+	err = s.wordpressUnit.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.LeaderSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.LeaderSettingsResults{
+		Results: []params.LeaderSettingsResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Settings: map[string]string{"master": "10.0.0.1"}},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *uniterSuite) TestGetPrincipal(c *gc.C) {
 	// Add a subordinate to wordpressUnit.
 	_, _, subordinate := s.addRelatedService(c, "wordpress", "logging", s.wordpressUnit)
@@ -730,6 +930,33 @@ func (s *uniterSuite) TestOpenPort(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestAddMetrics(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	metrics := []params.Metric{{Key: "load", Value: 0.5, Time: now}}
+	args := params.MetricBatches{Batches: []params.MetricBatch{
+		{Tag: "unit-mysql-0", Metrics: metrics},
+		{Tag: "unit-wordpress-0", Metrics: metrics},
+		{Tag: "unit-foo-42", Metrics: metrics},
+	}}
+	result, err := s.uniter.AddMetrics(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{apiservertesting.ErrUnauthorized},
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify only the wordpressUnit's metrics were recorded.
+	values, err := s.wordpress.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.DeepEquals, []state.Metric{{Key: "load", Value: 0.5, Time: now}})
+	values, err = s.mysql.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.HasLen, 0)
+}
+
 func (s *uniterSuite) TestClosePort(c *gc.C) {
 	// Open port udp:4321 in advance on wordpressUnit.
 	err := s.wordpressUnit.OpenPort("udp", 4321)
@@ -795,6 +1022,35 @@ func (s *uniterSuite) TestWatchConfigSettings(c *gc.C) {
 	wc.AssertNoChange()
 }
 
+func (s *uniterSuite) TestWatchLeaderSettings(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.WatchLeaderSettings(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.NotifyWatchResults{
+		Results: []params.NotifyWatchResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{NotifyWatcherId: "1"},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+
+	// Verify the resource was registered and stop when done
+	c.Assert(s.resources.Count(), gc.Equals, 1)
+	resource := s.resources.Get("1")
+	defer statetesting.AssertStop(c, resource)
+
+	// Check that the Watch has consumed the initial event ("returned" in
+	// the Watch call)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, resource.(state.NotifyWatcher))
+	wc.AssertNoChange()
+}
+
 func (s *uniterSuite) TestConfigSettings(c *gc.C) {
 	err := s.wordpressUnit.SetCharmURL(s.wpCharm.URL())
 	c.Assert(err, gc.IsNil)
@@ -818,6 +1074,23 @@ func (s *uniterSuite) TestConfigSettings(c *gc.C) {
 	})
 }
 
+func (s *uniterSuite) TestStorageInstances(c *gc.C) {
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-mysql-0"},
+		{Tag: "unit-wordpress-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := s.uniter.StorageInstances(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.StorageInstancesResults{
+		Results: []params.StorageInstancesResult{
+			{Error: apiservertesting.ErrUnauthorized},
+			{Instances: []params.StorageInstance{}},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
 func (s *uniterSuite) TestWatchServiceRelations(c *gc.C) {
 	c.Assert(s.resources.Count(), gc.Equals, 0)
 
@@ -991,6 +1264,22 @@ func (s *uniterSuite) TestProviderType(c *gc.C) {
 	c.Assert(result, gc.DeepEquals, params.StringResult{Result: cfg.Type()})
 }
 
+func (s *uniterSuite) TestHookTimeout(c *gc.C) {
+	result, err := s.uniter.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.DurationResult{})
+
+	oldCfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	cfg, err := oldCfg.Apply(map[string]interface{}{"hook-timeout": "20m"})
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+	result, err = s.uniter.HookTimeout()
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.DurationResult{Result: 20 * time.Minute})
+}
+
 func (s *uniterSuite) assertInScope(c *gc.C, relUnit *state.RelationUnit, inScope bool) {
 	ok, err := relUnit.InScope()
 	c.Assert(err, gc.IsNil)
@@ -1408,3 +1697,101 @@ func (s *uniterSuite) TestAPIAddresses(c *gc.C) {
 		Result: apiAddresses,
 	})
 }
+
+// setUpActions adds a unit of the dummy charm, which defines actions,
+// and returns a uniter API authorized as that unit, with one action
+// queued on the unit and another on a second unit.
+func (s *uniterSuite) setUpActions(c *gc.C) (*uniter.UniterAPI, *state.Action, *state.Action) {
+	dummy, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+	unit0, err := dummy.AddUnit()
+	c.Assert(err, gc.IsNil)
+	unit1, err := dummy.AddUnit()
+	c.Assert(err, gc.IsNil)
+	action0, err := unit0.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	action1, err := unit1.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	authorizer := s.authorizer
+	authorizer.Tag = unit0.Tag()
+	authorizer.Entity = unit0
+	api, err := uniter.NewUniterAPI(s.State, s.resources, authorizer)
+	c.Assert(err, gc.IsNil)
+	return api, action0, action1
+}
+
+func (s *uniterSuite) TestWatchActions(c *gc.C) {
+	c.Assert(s.resources.Count(), gc.Equals, 0)
+	api, action0, _ := s.setUpActions(c)
+
+	args := params.Entities{Entities: []params.Entity{
+		{Tag: "unit-dummy-1"},
+		{Tag: "unit-dummy-0"},
+		{Tag: "unit-foo-42"},
+	}}
+	result, err := api.WatchActions(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Results[1].Changes, gc.DeepEquals, []string{action0.Id()})
+	s.assertOneStringsWatcher(c, result, err)
+}
+
+func (s *uniterSuite) TestActions(c *gc.C) {
+	api, action0, action1 := s.setUpActions(c)
+
+	args := params.ActionIds{ActionIds: []string{action0.Id(), action1.Id(), "42"}}
+	result, err := api.Actions(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ActionResults{
+		Results: []params.ActionResult{
+			{
+				Name:   "snapshot",
+				Params: map[string]interface{}{"outfile": "foo.bz2"},
+				Status: params.ActionPending,
+			},
+			{Error: apiservertesting.ErrUnauthorized},
+			{Error: apiservertesting.ErrUnauthorized},
+		},
+	})
+}
+
+func (s *uniterSuite) TestBeginAndFinishActions(c *gc.C) {
+	api, action0, action1 := s.setUpActions(c)
+
+	args := params.ActionIds{ActionIds: []string{action0.Id(), action1.Id(), "42"}}
+	result, err := api.BeginActions(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	err = action0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action0.Status(), gc.Equals, params.ActionRunning)
+
+	results := map[string]interface{}{"size": "42M"}
+	finishArgs := params.ActionsFinish{Actions: []params.ActionFinish{
+		{ActionId: action0.Id(), Status: params.ActionCompleted, Results: results},
+		{ActionId: action1.Id(), Status: params.ActionFailed, Message: "nope"},
+		{ActionId: "42", Status: params.ActionFailed},
+	}}
+	result, err = api.FinishActions(finishArgs)
+	c.Assert(err, gc.IsNil)
+	c.Assert(result, gc.DeepEquals, params.ErrorResults{
+		Results: []params.ErrorResult{
+			{nil},
+			{apiservertesting.ErrUnauthorized},
+			{apiservertesting.ErrUnauthorized},
+		},
+	})
+	err = action0.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action0.Status(), gc.Equals, params.ActionCompleted)
+	c.Assert(action0.Results(), gc.DeepEquals, results)
+	err = action1.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(action1.Status(), gc.Equals, params.ActionPending)
+}
@@ -6,7 +6,10 @@ package upgrader
 import (
 	"errors"
 
+	"launchpad.net/loggo"
+
 	"launchpad.net/juju-core/environs/config"
+	coreerrors "launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/state"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/apiserver/common"
@@ -14,6 +17,8 @@ import (
 	"launchpad.net/juju-core/version"
 )
 
+var logger = loggo.GetLogger("juju.state.apiserver.upgrader")
+
 // UpgraderAPI provides access to the Upgrader API facade.
 type UpgraderAPI struct {
 	*common.ToolsGetter
@@ -44,7 +49,9 @@ func NewUpgraderAPI(
 }
 
 // WatchAPIVersion starts a watcher to track if there is a new version
-// of the API that we want to upgrade to
+// of the API that we want to upgrade to. It also notifies when an
+// upgrade of the environment progresses, as the desired version of
+// an agent depends on it.
 func (u *UpgraderAPI) WatchAPIVersion(args params.Entities) (params.NotifyWatchResults, error) {
 	result := params.NotifyWatchResults{
 		Results: make([]params.NotifyWatchResult, len(args.Entities)),
@@ -52,7 +59,7 @@ func (u *UpgraderAPI) WatchAPIVersion(args params.Entities) (params.NotifyWatchR
 	for i, agent := range args.Entities {
 		err := common.ErrPerm
 		if u.authorizer.AuthOwner(agent.Tag) {
-			watch := u.st.WatchForEnvironConfigChanges()
+			watch := u.st.WatchForAgentVersionChanges()
 			// Consume the initial event. Technically, API
 			// calls to Watch 'transmit' the initial event
 			// in the Watch response. But NotifyWatchers
@@ -82,6 +89,21 @@ func (u *UpgraderAPI) getGlobalAgentVersion() (version.Number, *config.Config, e
 	return agentVersion, cfg, nil
 }
 
+// upgradeInfo returns the progress of the current upgrade of the
+// environment, or nil if there is none.
+func (u *UpgraderAPI) upgradeInfo() (*state.UpgradeInfo, error) {
+	info, err := u.st.UpgradeInfo()
+	if coreerrors.IsNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !info.InProgress() {
+		return nil, nil
+	}
+	return info, nil
+}
+
 // DesiredVersion reports the Agent Version that we want that agent to be running
 func (u *UpgraderAPI) DesiredVersion(args params.Entities) (params.VersionResults, error) {
 	results := make([]params.VersionResult, len(args.Entities))
@@ -92,17 +114,45 @@ func (u *UpgraderAPI) DesiredVersion(args params.Entities) (params.VersionResult
 	if err != nil {
 		return params.VersionResults{}, common.ServerError(err)
 	}
+	info, err := u.upgradeInfo()
+	if err != nil {
+		return params.VersionResults{}, common.ServerError(err)
+	}
 	for i, entity := range args.Entities {
 		err := common.ErrPerm
 		if u.authorizer.AuthOwner(entity.Tag) {
-			results[i].Version = &agentVersion
-			err = nil
+			var vers version.Number
+			vers, err = u.desiredVersion(entity.Tag, agentVersion, info)
+			if err == nil {
+				results[i].Version = &vers
+			}
 		}
 		results[i].Error = common.ServerError(err)
 	}
 	return params.VersionResults{results}, nil
 }
 
+// desiredVersion returns the version the agent with the given tag
+// should be running. While the state servers have yet to migrate the
+// data in state, other agents keep running the previous version.
+func (u *UpgraderAPI) desiredVersion(tag string, agentVersion version.Number, info *state.UpgradeInfo) (version.Number, error) {
+	if info == nil || info.TargetVersion() != agentVersion || !info.StateServersFirst() {
+		return agentVersion, nil
+	}
+	entity, err := u.st.FindEntity(tag)
+	if err != nil {
+		return version.Number{}, err
+	}
+	if machine, ok := entity.(*state.Machine); ok {
+		for _, job := range machine.Jobs() {
+			if job == state.JobManageState {
+				return agentVersion, nil
+			}
+		}
+	}
+	return info.PreviousVersion(), nil
+}
+
 // SetTools updates the recorded tools version for the agents.
 func (u *UpgraderAPI) SetTools(args params.EntitiesVersion) (params.ErrorResults, error) {
 	results := params.ErrorResults{
@@ -112,9 +162,22 @@ func (u *UpgraderAPI) SetTools(args params.EntitiesVersion) (params.ErrorResults
 		err := u.setOneAgentVersion(agentTools.Tag, agentTools.Tools.Version)
 		results.Results[i].Error = common.ServerError(err)
 	}
+	if err := u.completeUpgrade(); err != nil {
+		logger.Warningf("cannot complete upgrade: %v", err)
+	}
 	return results, nil
 }
 
+// completeUpgrade records that the current upgrade of the environment
+// is complete, if every agent is running the target version.
+func (u *UpgraderAPI) completeUpgrade() error {
+	info, err := u.upgradeInfo()
+	if err != nil || info == nil {
+		return err
+	}
+	return info.CompleteIfDone()
+}
+
 func (u *UpgraderAPI) setOneAgentVersion(tag string, vers version.Binary) error {
 	if !u.authorizer.AuthOwner(tag) {
 		return common.ErrPerm
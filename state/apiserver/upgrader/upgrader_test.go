@@ -280,3 +280,106 @@ func (s *upgraderSuite) TestDesiredVersionForAgent(c *gc.C) {
 	c.Assert(agentVersion, gc.NotNil)
 	c.Check(*agentVersion, gc.DeepEquals, version.Current.Number)
 }
+
+func (s *upgraderSuite) startUpgrade(c *gc.C) (previous, target version.Number) {
+	previous = version.Current.Number
+	err := s.rawMachine.SetAgentVersion(version.Current)
+	c.Assert(err, gc.IsNil)
+	target = previous
+	target.Minor++
+	err = s.State.StartUpgrade(target)
+	c.Assert(err, gc.IsNil)
+	return previous, target
+}
+
+func (s *upgraderSuite) TestDesiredVersionDuringUpgrade(c *gc.C) {
+	stateServer, err := s.State.AddMachine("quantal", state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	err = stateServer.SetAgentVersion(version.Current)
+	c.Assert(err, gc.IsNil)
+	previous, target := s.startUpgrade(c)
+
+	serverAuthorizer := s.authorizer
+	serverAuthorizer.Tag = stateServer.Tag()
+	serverUpgrader, err := upgrader.NewUpgraderAPI(s.State, s.resources, serverAuthorizer)
+	c.Assert(err, gc.IsNil)
+	assertDesiredVersion := func(u *upgrader.UpgraderAPI, tag string, expect version.Number) {
+		args := params.Entities{Entities: []params.Entity{{Tag: tag}}}
+		results, err := u.DesiredVersion(args)
+		c.Assert(err, gc.IsNil)
+		c.Assert(results.Results, gc.HasLen, 1)
+		c.Assert(results.Results[0].Error, gc.IsNil)
+		c.Assert(results.Results[0].Version, gc.NotNil)
+		c.Assert(*results.Results[0].Version, gc.Equals, expect)
+	}
+
+	// State servers upgrade first; other agents wait for the data
+	// in state to be migrated.
+	assertDesiredVersion(serverUpgrader, stateServer.Tag(), target)
+	assertDesiredVersion(s.upgrader, s.rawMachine.Tag(), previous)
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	ok, err := info.BeginMigration(stateServer.Id())
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+	assertDesiredVersion(s.upgrader, s.rawMachine.Tag(), previous)
+
+	err = info.EndMigration()
+	c.Assert(err, gc.IsNil)
+	assertDesiredVersion(serverUpgrader, stateServer.Tag(), target)
+	assertDesiredVersion(s.upgrader, s.rawMachine.Tag(), target)
+}
+
+func (s *upgraderSuite) TestWatchAPIVersionNoticesUpgradeProgress(c *gc.C) {
+	s.startUpgrade(c)
+	args := params.Entities{
+		Entities: []params.Entity{{Tag: s.rawMachine.Tag()}},
+	}
+	results, err := s.upgrader.WatchAPIVersion(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+	resource := s.resources.Get(results.Results[0].NotifyWatcherId)
+	w := resource.(state.NotifyWatcher)
+	wc := statetesting.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertNoChange()
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	ok, err := info.BeginMigration("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+	wc.AssertOneChange()
+	err = info.EndMigration()
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+	statetesting.AssertStop(c, w)
+	wc.AssertClosed()
+}
+
+func (s *upgraderSuite) TestSetToolsCompletesUpgrade(c *gc.C) {
+	_, target := s.startUpgrade(c)
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	ok, err := info.BeginMigration("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+	err = info.EndMigration()
+	c.Assert(err, gc.IsNil)
+
+	newTools := version.Current
+	newTools.Number = target
+	args := params.EntitiesVersion{
+		AgentTools: []params.EntityVersion{{
+			Tag:   s.rawMachine.Tag(),
+			Tools: &params.Version{Version: newTools},
+		}},
+	}
+	results, err := s.upgrader.SetTools(args)
+	c.Assert(err, gc.IsNil)
+	c.Assert(results.Results[0].Error, gc.IsNil)
+
+	err = info.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeComplete)
+}
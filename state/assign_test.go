@@ -337,6 +337,31 @@ func (s *AssignSuite) TestAssignUnitToNewMachine(c *gc.C) {
 	s.assertAssignedUnit(c, unit)
 }
 
+func (s *AssignSuite) TestAssignUnitToNewMachineWithPlacement(c *gc.C) {
+	unit, err := s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToNewMachineWithPlacement("", "zone=zone1")
+	c.Assert(err, gc.IsNil)
+	machineId := s.assertAssignedUnit(c, unit)
+	machine, err := s.State.Machine(machineId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(machine.Placement(), gc.Equals, "zone=zone1")
+
+	// With a container, the directive applies to the new host machine.
+	unit, err = s.wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToNewMachineWithPlacement(instance.LXC, "zone=zone2")
+	c.Assert(err, gc.IsNil)
+	machineId = s.assertAssignedUnit(c, unit)
+	c.Assert(state.ContainerTypeFromId(machineId), gc.Equals, instance.LXC)
+	container, err := s.State.Machine(machineId)
+	c.Assert(err, gc.IsNil)
+	c.Assert(container.Placement(), gc.Equals, "")
+	host, err := s.State.Machine(state.ParentId(machineId))
+	c.Assert(err, gc.IsNil)
+	c.Assert(host.Placement(), gc.Equals, "zone=zone2")
+}
+
 func (s *AssignSuite) assertAssignUnitToNewMachineContainerConstraint(c *gc.C) {
 	unit, err := s.wordpress.AddUnit()
 	c.Assert(err, gc.IsNil)
@@ -0,0 +1,160 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo/bson"
+)
+
+// AuditEntry records a change to the environment requested through the
+// API, and its outcome.
+type AuditEntry struct {
+	// Time holds the time the request was received.
+	Time time.Time
+	// Tag holds the tag of the authenticated entity that made the
+	// request.
+	Tag string
+	// RemoteAddress holds the address the request came from.
+	RemoteAddress string
+	// Method holds the name of the API method called, such as
+	// "Client.ServiceDeploy".
+	Method string
+	// Args holds the JSON encoding of the arguments of the call, with
+	// secrets masked.
+	Args string
+	// Result holds the JSON encoding of the result of a successful
+	// call, with secrets masked.
+	Result string
+	// Error and ErrorCode hold the error returned by a failed call.
+	Error     string
+	ErrorCode string
+}
+
+// auditEntryDoc is the stored form of an AuditEntry. The documents are
+// written directly rather than by a transaction, because they are never
+// updated, and they are removed only by PruneAuditLog.
+type auditEntryDoc struct {
+	Id            bson.ObjectId `bson:"_id"`
+	Time          time.Time
+	Tag           string
+	RemoteAddress string
+	Method        string
+	Args          string
+	Result        string
+	Error         string
+	ErrorCode     string
+}
+
+// AddAuditEntry adds the entry to the audit log.
+func (st *State) AddAuditEntry(entry AuditEntry) error {
+	doc := &auditEntryDoc{
+		Id:            bson.NewObjectId(),
+		Time:          entry.Time.UTC(),
+		Tag:           entry.Tag,
+		RemoteAddress: entry.RemoteAddress,
+		Method:        entry.Method,
+		Args:          entry.Args,
+		Result:        entry.Result,
+		Error:         entry.Error,
+		ErrorCode:     entry.ErrorCode,
+	}
+	if err := st.auditLog.Insert(doc); err != nil {
+		return fmt.Errorf("cannot add audit entry for %q: %v", entry.Method, err)
+	}
+	return nil
+}
+
+// AuditFilter selects entries from the audit log.
+type AuditFilter struct {
+	// Since and Until, if not zero, select only the entries made at
+	// or after, and before, the given times.
+	Since time.Time
+	Until time.Time
+	// Tag, if not empty, selects only the entries of requests made by
+	// the entity with the given tag.
+	Tag string
+	// Limit, if not zero, selects only the most recent Limit entries.
+	Limit int
+}
+
+// AuditEntries returns the entries in the audit log selected by the
+// filter, oldest first.
+func (st *State) AuditEntries(filter AuditFilter) ([]AuditEntry, error) {
+	sel := D{}
+	if filter.Tag != "" {
+		sel = append(sel, bson.DocElem{"tag", filter.Tag})
+	}
+	timeSel := D{}
+	if !filter.Since.IsZero() {
+		timeSel = append(timeSel, bson.DocElem{"$gte", filter.Since.UTC()})
+	}
+	if !filter.Until.IsZero() {
+		timeSel = append(timeSel, bson.DocElem{"$lt", filter.Until.UTC()})
+	}
+	if len(timeSel) > 0 {
+		sel = append(sel, bson.DocElem{"time", timeSel})
+	}
+	query := st.auditLog.Find(sel).Sort("-time", "-_id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	var docs []auditEntryDoc
+	if err := query.All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get audit log: %v", err)
+	}
+	entries := make([]AuditEntry, len(docs))
+	for i, doc := range docs {
+		entries[len(docs)-1-i] = AuditEntry{
+			Time:          doc.Time,
+			Tag:           doc.Tag,
+			RemoteAddress: doc.RemoteAddress,
+			Method:        doc.Method,
+			Args:          doc.Args,
+			Result:        doc.Result,
+			Error:         doc.Error,
+			ErrorCode:     doc.ErrorCode,
+		}
+	}
+	return entries, nil
+}
+
+// auditLogNow returns the time against which the age of audit entries
+// is measured. It is patched in tests.
+var auditLogNow = time.Now
+
+// PruneAuditLog removes the audit entries older than maxAge, and all
+// but the newest maxEntries entries. A zero value for either limit
+// disables it.
+func (st *State) PruneAuditLog(maxAge time.Duration, maxEntries int) error {
+	if maxAge > 0 {
+		cutoff := auditLogNow().UTC().Add(-maxAge)
+		if _, err := st.auditLog.RemoveAll(D{{"time", D{{"$lt", cutoff}}}}); err != nil {
+			return fmt.Errorf("cannot prune audit log: %v", err)
+		}
+	}
+	if maxEntries <= 0 {
+		return nil
+	}
+	var docs []struct {
+		Id bson.ObjectId `bson:"_id"`
+	}
+	err := st.auditLog.Find(nil).Sort("-time", "-_id").Skip(maxEntries).Select(D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return fmt.Errorf("cannot prune audit log: %v", err)
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	ids := make([]bson.ObjectId, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.Id
+	}
+	if _, err := st.auditLog.RemoveAll(D{{"_id", D{{"$in", ids}}}}); err != nil {
+		return fmt.Errorf("cannot prune audit log: %v", err)
+	}
+	return nil
+}
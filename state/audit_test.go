@@ -0,0 +1,118 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+)
+
+type AuditSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&AuditSuite{})
+
+var auditEpoch = time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func (s *AuditSuite) addEntries(c *gc.C) []state.AuditEntry {
+	entries := []state.AuditEntry{{
+		Time:          auditEpoch,
+		Tag:           "user-admin",
+		RemoteAddress: "10.0.0.1:4321",
+		Method:        "Client.ServiceDeploy",
+		Args:          `{"ServiceName":"wordpress"}`,
+	}, {
+		Time:          auditEpoch.Add(time.Minute),
+		Tag:           "user-bob",
+		RemoteAddress: "10.0.0.2:4321",
+		Method:        "Client.ServiceDestroy",
+		Args:          `{"ServiceName":"mysql"}`,
+		Error:         `service "mysql" not found`,
+		ErrorCode:     "not found",
+	}, {
+		Time:          auditEpoch.Add(2 * time.Minute),
+		Tag:           "user-admin",
+		RemoteAddress: "10.0.0.1:4321",
+		Method:        "Client.AddMachines",
+		Args:          `{"MachineParams":[{}]}`,
+		Result:        `{"Machines":[{"Machine":"1","Error":null}]}`,
+	}}
+	// Add the entries out of order, to check that they are sorted.
+	for _, i := range []int{2, 0, 1} {
+		err := s.State.AddAuditEntry(entries[i])
+		c.Assert(err, gc.IsNil)
+	}
+	return entries
+}
+
+func (s *AuditSuite) TestAuditEntries(c *gc.C) {
+	entries := s.addEntries(c)
+	for i, test := range []struct {
+		about  string
+		filter state.AuditFilter
+		expect []state.AuditEntry
+	}{{
+		about:  "all entries",
+		expect: entries,
+	}, {
+		about:  "entries by tag",
+		filter: state.AuditFilter{Tag: "user-admin"},
+		expect: []state.AuditEntry{entries[0], entries[2]},
+	}, {
+		about:  "entries since a time",
+		filter: state.AuditFilter{Since: auditEpoch.Add(time.Minute)},
+		expect: entries[1:],
+	}, {
+		about:  "entries until a time",
+		filter: state.AuditFilter{Until: auditEpoch.Add(time.Minute)},
+		expect: entries[:1],
+	}, {
+		about:  "most recent entries",
+		filter: state.AuditFilter{Limit: 2},
+		expect: entries[1:],
+	}, {
+		about:  "no matching entries",
+		filter: state.AuditFilter{Tag: "user-nobody"},
+		expect: []state.AuditEntry{},
+	}} {
+		c.Logf("test %d: %s", i, test.about)
+		got, err := s.State.AuditEntries(test.filter)
+		c.Assert(err, gc.IsNil)
+		c.Assert(got, gc.HasLen, len(test.expect))
+		for j, entry := range got {
+			c.Check(entry.Time.Equal(test.expect[j].Time), gc.Equals, true)
+			entry.Time = test.expect[j].Time
+			c.Check(entry, gc.DeepEquals, test.expect[j])
+		}
+	}
+}
+
+func (s *AuditSuite) TestPruneAuditLogByAge(c *gc.C) {
+	entries := s.addEntries(c)
+	s.PatchValue(state.AuditLogNow, func() time.Time {
+		return auditEpoch.Add(5 * time.Minute)
+	})
+	// Entries made 4 minutes ago or earlier are pruned.
+	err := s.State.PruneAuditLog(210*time.Second, 0)
+	c.Assert(err, gc.IsNil)
+	got, err := s.State.AuditEntries(state.AuditFilter{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 1)
+	c.Assert(got[0].Method, gc.Equals, entries[2].Method)
+}
+
+func (s *AuditSuite) TestPruneAuditLogBySize(c *gc.C) {
+	entries := s.addEntries(c)
+	err := s.State.PruneAuditLog(0, 2)
+	c.Assert(err, gc.IsNil)
+	got, err := s.State.AuditEntries(state.AuditFilter{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 2)
+	c.Assert(got[0].Method, gc.Equals, entries[1].Method)
+	c.Assert(got[1].Method, gc.Equals, entries[2].Method)
+}
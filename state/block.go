@@ -0,0 +1,130 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo/txn"
+)
+
+// BlockType specifies the operations prevented by a block.
+type BlockType string
+
+const (
+	// DestroyBlock prevents the environment from being destroyed.
+	DestroyBlock BlockType = "destroy"
+
+	// RemoveBlock prevents the removal of machines, services, units,
+	// relations and users, as well as the destruction of the
+	// environment.
+	RemoveBlock BlockType = "remove-object"
+
+	// ChangeBlock prevents all changes to the environment.
+	ChangeBlock BlockType = "all-changes"
+)
+
+// AllBlockTypes holds every block type, from the least to the most
+// restrictive.
+var AllBlockTypes = []BlockType{DestroyBlock, RemoveBlock, ChangeBlock}
+
+// ParseBlockType returns the block type with the given name.
+func ParseBlockType(name string) (BlockType, error) {
+	for _, t := range AllBlockTypes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown block type %q", name)
+}
+
+// blockDoc represents a block switched on in the environment. There is
+// at most one block of each type.
+type blockDoc struct {
+	Type    BlockType `bson:"_id"`
+	Message string
+}
+
+// Block prevents some kind of operation on the environment until it
+// is switched off.
+type Block struct {
+	doc blockDoc
+}
+
+// Type returns the type of the block.
+func (b *Block) Type() BlockType {
+	return b.doc.Type
+}
+
+// Message returns the reason given when the block was switched on.
+func (b *Block) Message() string {
+	return b.doc.Message
+}
+
+// SwitchBlockOn switches on the block of the given type, recording
+// message as the reason. If the block is already on, only its message
+// is changed.
+func (st *State) SwitchBlockOn(t BlockType, message string) error {
+	if _, err := ParseBlockType(string(t)); err != nil {
+		return err
+	}
+	for i := 0; i < 5; i++ {
+		var op txn.Op
+		if count, err := st.blocks.FindId(t).Count(); err != nil {
+			return fmt.Errorf("cannot switch on %q block: %v", t, err)
+		} else if count == 0 {
+			op = txn.Op{
+				C:      st.blocks.Name,
+				Id:     t,
+				Assert: txn.DocMissing,
+				Insert: &blockDoc{Type: t, Message: message},
+			}
+		} else {
+			op = txn.Op{
+				C:      st.blocks.Name,
+				Id:     t,
+				Assert: txn.DocExists,
+				Update: D{{"$set", D{{"message", message}}}},
+			}
+		}
+		if err := st.runTransaction([]txn.Op{op}); err == nil {
+			return nil
+		} else if err != txn.ErrAborted {
+			return fmt.Errorf("cannot switch on %q block: %v", t, err)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// SwitchBlockOff switches off the block of the given type. It is not
+// an error if the block is already off.
+func (st *State) SwitchBlockOff(t BlockType) error {
+	ops := []txn.Op{{
+		C:      st.blocks.Name,
+		Id:     t,
+		Remove: true,
+	}}
+	if err := st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot switch off %q block: %v", t, err)
+	}
+	return nil
+}
+
+// Blocks returns the blocks that are switched on, from the least to
+// the most restrictive.
+func (st *State) Blocks() ([]*Block, error) {
+	var docs []blockDoc
+	if err := st.blocks.Find(nil).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get blocks: %v", err)
+	}
+	var blocks []*Block
+	for _, t := range AllBlockTypes {
+		for _, doc := range docs {
+			if doc.Type == t {
+				blocks = append(blocks, &Block{doc})
+			}
+		}
+	}
+	return blocks, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+)
+
+type BlockSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&BlockSuite{})
+
+type blockInfo struct {
+	Type    state.BlockType
+	Message string
+}
+
+func (s *BlockSuite) assertBlocks(c *gc.C, expect ...blockInfo) {
+	blocks, err := s.State.Blocks()
+	c.Assert(err, gc.IsNil)
+	var got []blockInfo
+	for _, b := range blocks {
+		got = append(got, blockInfo{b.Type(), b.Message()})
+	}
+	c.Assert(got, gc.DeepEquals, expect)
+}
+
+func (s *BlockSuite) TestSwitchBlocks(c *gc.C) {
+	s.assertBlocks(c)
+
+	err := s.State.SwitchBlockOn(state.ChangeBlock, "product launch")
+	c.Assert(err, gc.IsNil)
+	err = s.State.SwitchBlockOn(state.DestroyBlock, "")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c,
+		blockInfo{state.DestroyBlock, ""},
+		blockInfo{state.ChangeBlock, "product launch"},
+	)
+
+	// Switching a block on again changes its message.
+	err = s.State.SwitchBlockOn(state.ChangeBlock, "end of quarter")
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c,
+		blockInfo{state.DestroyBlock, ""},
+		blockInfo{state.ChangeBlock, "end of quarter"},
+	)
+
+	err = s.State.SwitchBlockOff(state.ChangeBlock)
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, blockInfo{state.DestroyBlock, ""})
+
+	// Switching a block off again is not an error.
+	err = s.State.SwitchBlockOff(state.ChangeBlock)
+	c.Assert(err, gc.IsNil)
+	s.assertBlocks(c, blockInfo{state.DestroyBlock, ""})
+}
+
+func (s *BlockSuite) TestSwitchUnknownBlock(c *gc.C) {
+	err := s.State.SwitchBlockOn(state.BlockType("everything"), "")
+	c.Assert(err, gc.ErrorMatches, `unknown block type "everything"`)
+	s.assertBlocks(c)
+}
+
+func (s *BlockSuite) TestParseBlockType(c *gc.C) {
+	for _, t := range state.AllBlockTypes {
+		parsed, err := state.ParseBlockType(string(t))
+		c.Assert(err, gc.IsNil)
+		c.Assert(parsed, gc.Equals, t)
+	}
+	_, err := state.ParseBlockType("destroy-everything")
+	c.Assert(err, gc.ErrorMatches, `unknown block type "destroy-everything"`)
+}
@@ -23,6 +23,7 @@ type constraintsDoc struct {
 	RootDisk  *uint64
 	Container *instance.ContainerType
 	Tags      *[]string ",omitempty"
+	Networks  *[]string ",omitempty"
 }
 
 func (doc constraintsDoc) value() constraints.Value {
@@ -34,6 +35,7 @@ func (doc constraintsDoc) value() constraints.Value {
 		RootDisk:  doc.RootDisk,
 		Container: doc.Container,
 		Tags:      doc.Tags,
+		Networks:  doc.Networks,
 	}
 }
 
@@ -46,6 +48,7 @@ func newConstraintsDoc(cons constraints.Value) constraintsDoc {
 		RootDisk:  cons.RootDisk,
 		Container: cons.Container,
 		Tags:      cons.Tags,
+		Networks:  cons.Networks,
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"path/filepath"
+	"time"
 
 	"labix.org/v2/mgo"
 	gc "launchpad.net/gocheck"
@@ -185,6 +186,18 @@ func GetPasswordHash(e Authenticator) string {
 
 func init() {
 	logSize = logSizeTests
+	agentLogSize = agentLogSizeTests
+	statusHistorySize = statusHistorySizeTests
+}
+
+// SetLogTailTimeouts changes how long LogTailers wait on tailing
+// cursors, and returns a function that restores the original values.
+func SetLogTailTimeouts(timeout, retryDelay time.Duration) (restore func()) {
+	oldTimeout, oldRetryDelay := tailTimeout, tailRetryDelay
+	tailTimeout, tailRetryDelay = timeout, retryDelay
+	return func() {
+		tailTimeout, tailRetryDelay = oldTimeout, oldRetryDelay
+	}
 }
 
 // MinUnitsRevno returns the Revno of the minUnits document
@@ -205,3 +218,19 @@ func ParseTag(st *State, tag string) (string, string, error) {
 func GetUserPasswordSaltAndHash(u *User) (string, string) {
 	return u.doc.PasswordSalt, u.doc.PasswordHash
 }
+
+// StatusHistoryNow allows tests to patch the time recorded for status
+// changes.
+var StatusHistoryNow = &statusHistoryNow
+
+// AuditLogNow allows tests to patch the time against which the age of
+// audit entries is measured.
+var AuditLogNow = &auditLogNow
+
+// MetricsNow allows tests to patch the time recorded for batches of
+// metrics.
+var MetricsNow = &metricsNow
+
+// LeadershipNow allows tests to patch the time leadership leases are
+// checked against.
+var LeadershipNow = &leadershipNow
@@ -0,0 +1,305 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/utils"
+)
+
+// leaseDoc records which unit of a service holds the lease that makes
+// it the service's leader, and when the lease expires unless renewed.
+type leaseDoc struct {
+	ServiceName string `bson:"_id"`
+	Leader      string
+	Expiry      time.Time
+	TxnRevno    int64 `bson:"txn-revno"`
+}
+
+// leaderSettingsDoc holds the settings published by the leader of a
+// service to the other units of the service. It is kept apart from
+// the lease, so that renewing the lease does not disturb watchers of
+// the settings.
+type leaderSettingsDoc struct {
+	Id       string `bson:"_id"`
+	Settings map[string]string
+	TxnRevno int64 `bson:"txn-revno"`
+}
+
+// leaderSettingsKey returns the key of the leader settings document
+// of the named service.
+func leaderSettingsKey(serviceName string) string {
+	return serviceName + "#settings"
+}
+
+// leadershipNow returns the time leases are checked against. It is
+// patched in tests.
+var leadershipNow = time.Now
+
+// ClaimLeadership claims, or renews, the leadership of the unit's
+// service for the given duration, and returns whether the unit is now
+// the leader. A unit can take over the leadership when the lease of
+// the current leader has expired, or when the current leader's agent
+// is no longer alive. Units that are not alive never become leader.
+func (u *Unit) ClaimLeadership(duration time.Duration) (claimed bool, err error) {
+	defer utils.ErrorContextf(&err, "cannot claim leadership of service %q for unit %q", u.doc.Service, u)
+	if duration <= 0 {
+		return false, fmt.Errorf("invalid lease duration %v", duration)
+	}
+	unitOp := txn.Op{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: isAliveDoc,
+	}
+	for i := 0; i < 3; i++ {
+		now := leadershipNow().UTC()
+		fields := D{
+			{"leader", u.doc.Name},
+			{"expiry", now.Add(duration)},
+		}
+		var leaseOp txn.Op
+		doc, err := u.st.leaseDoc(u.doc.Service)
+		if errors.IsNotFoundError(err) {
+			leaseOp = txn.Op{
+				C:      u.st.leadership.Name,
+				Id:     u.doc.Service,
+				Assert: txn.DocMissing,
+				Insert: &leaseDoc{
+					ServiceName: u.doc.Service,
+					Leader:      u.doc.Name,
+					Expiry:      now.Add(duration),
+				},
+			}
+		} else if err != nil {
+			return false, err
+		} else {
+			if doc.Leader != u.doc.Name && now.Before(doc.Expiry) {
+				present, err := u.st.leaderPresent(doc.Leader)
+				if err != nil {
+					return false, err
+				} else if present {
+					return false, nil
+				}
+			}
+			leaseOp = txn.Op{
+				C:      u.st.leadership.Name,
+				Id:     u.doc.Service,
+				Assert: D{{"txn-revno", doc.TxnRevno}},
+				Update: D{{"$set", fields}},
+			}
+		}
+		ops := []txn.Op{unitOp, leaseOp}
+		if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+			return err == nil, err
+		}
+		if alive, err := isAlive(u.st.units, u.doc.Name); err != nil {
+			return false, err
+		} else if !alive {
+			return false, nil
+		}
+	}
+	return false, ErrExcessiveContention
+}
+
+// RenewLeadership extends the lease of the unit's service by the given
+// duration if the unit is the recorded holder of the lease, and returns
+// whether it was. Unlike ClaimLeadership, it never makes the unit the
+// leader of its service.
+func (u *Unit) RenewLeadership(duration time.Duration) (renewed bool, err error) {
+	defer utils.ErrorContextf(&err, "cannot renew leadership of service %q for unit %q", u.doc.Service, u)
+	if duration <= 0 {
+		return false, fmt.Errorf("invalid lease duration %v", duration)
+	}
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: isAliveDoc,
+	}, {
+		C:      u.st.leadership.Name,
+		Id:     u.doc.Service,
+		Assert: D{{"leader", u.doc.Name}},
+		Update: D{{"$set", D{{"expiry", leadershipNow().UTC().Add(duration)}}}},
+	}}
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		// Either the unit is no longer alive, or another unit
+		// has taken over the lease.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// leaderPresent returns whether the named unit, which holds an
+// unexpired lease, is still able to act as leader.
+func (st *State) leaderPresent(unitName string) (bool, error) {
+	unit, err := st.Unit(unitName)
+	if errors.IsNotFoundError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if unit.Life() == Dead {
+		return false, nil
+	}
+	return unit.AgentAlive()
+}
+
+// Leader returns the name of the unit holding the leadership of the
+// service, or an empty string if no unit holds an unexpired lease.
+func (s *Service) Leader() (string, error) {
+	doc, err := s.st.leaseDoc(s.doc.Name)
+	if errors.IsNotFoundError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	if !leadershipNow().Before(doc.Expiry) {
+		return "", nil
+	}
+	return doc.Leader, nil
+}
+
+func (st *State) leaseDoc(serviceName string) (*leaseDoc, error) {
+	var doc leaseDoc
+	err := st.leadership.FindId(serviceName).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("leadership of service %q", serviceName)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get leadership of service %q: %v", serviceName, err)
+	}
+	return &doc, nil
+}
+
+// LeaderSettings returns the settings published by the leader of the
+// service.
+func (s *Service) LeaderSettings() (map[string]string, error) {
+	doc, err := s.st.leaderSettingsDoc(s.doc.Name)
+	if errors.IsNotFoundError(err) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return doc.Settings, nil
+}
+
+// SetLeaderSettings updates the settings published by the leader of
+// the unit's service with the given values; settings with an empty
+// value are deleted. It fails unless the unit holds the leadership.
+func (u *Unit) SetLeaderSettings(settings map[string]string) (err error) {
+	defer utils.ErrorContextf(&err, "cannot write leader settings of service %q", u.doc.Service)
+	for key := range settings {
+		if key == "" {
+			return fmt.Errorf("empty setting key")
+		}
+	}
+	key := leaderSettingsKey(u.doc.Service)
+	for i := 0; i < 3; i++ {
+		leaseOp := txn.Op{
+			C:  u.st.leadership.Name,
+			Id: u.doc.Service,
+			Assert: D{
+				{"leader", u.doc.Name},
+				{"expiry", D{{"$gt", leadershipNow().UTC()}}},
+			},
+		}
+		merged := make(map[string]string)
+		var settingsOp txn.Op
+		doc, err := u.st.leaderSettingsDoc(u.doc.Service)
+		if errors.IsNotFoundError(err) {
+			settingsOp = txn.Op{
+				C:      u.st.leadership.Name,
+				Id:     key,
+				Assert: txn.DocMissing,
+			}
+		} else if err != nil {
+			return err
+		} else {
+			for k, v := range doc.Settings {
+				merged[k] = v
+			}
+			settingsOp = txn.Op{
+				C:      u.st.leadership.Name,
+				Id:     key,
+				Assert: D{{"txn-revno", doc.TxnRevno}},
+			}
+		}
+		for k, v := range settings {
+			if v == "" {
+				delete(merged, k)
+			} else {
+				merged[k] = v
+			}
+		}
+		escaped := replaceStringKeys(merged, escapeReplacer.Replace)
+		if doc == nil {
+			settingsOp.Insert = &leaderSettingsDoc{Id: key, Settings: escaped}
+		} else {
+			settingsOp.Update = D{{"$set", D{{"settings", escaped}}}}
+		}
+		ops := []txn.Op{leaseOp, settingsOp}
+		if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+		svc, err := u.Service()
+		if err != nil {
+			return err
+		}
+		if leader, err := svc.Leader(); err != nil {
+			return err
+		} else if leader != u.doc.Name {
+			return fmt.Errorf("unit %q is not the leader", u)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+func (st *State) leaderSettingsDoc(serviceName string) (*leaderSettingsDoc, error) {
+	var doc leaderSettingsDoc
+	err := st.leadership.FindId(leaderSettingsKey(serviceName)).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("leader settings of service %q", serviceName)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get leader settings of service %q: %v", serviceName, err)
+	}
+	doc.Settings = replaceStringKeys(doc.Settings, unescapeReplacer.Replace)
+	return &doc, nil
+}
+
+// replaceStringKeys returns a copy of m with each key replaced by the
+// result of calling replace on it.
+func replaceStringKeys(m map[string]string, replace func(string) string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[replace(k)] = v
+	}
+	return result
+}
+
+// WatchLeaderSettings returns a watcher that notifies when the
+// settings published by the service's leader change.
+func (s *Service) WatchLeaderSettings() NotifyWatcher {
+	return newEntityWatcher(s.st, s.st.leadership, leaderSettingsKey(s.doc.Name))
+}
+
+// leadershipRemoveOps returns the operations required to remove the
+// lease and the leader settings of the named service, either of which
+// may not exist.
+func leadershipRemoveOps(st *State, serviceName string) []txn.Op {
+	return []txn.Op{{
+		C:      st.leadership.Name,
+		Id:     serviceName,
+		Remove: true,
+	}, {
+		C:      st.leadership.Name,
+		Id:     leaderSettingsKey(serviceName),
+		Remove: true,
+	}}
+}
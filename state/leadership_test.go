@@ -0,0 +1,228 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/testing"
+	coretesting "launchpad.net/juju-core/testing"
+)
+
+type LeadershipSuite struct {
+	ConnSuite
+	charm   *state.Charm
+	service *state.Service
+	unit0   *state.Unit
+	unit1   *state.Unit
+	now     time.Time
+}
+
+var _ = gc.Suite(&LeadershipSuite{})
+
+func (s *LeadershipSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.now = time.Date(2014, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(state.LeadershipNow, func() time.Time { return s.now })
+	s.charm = s.AddTestingCharm(c, "wordpress")
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.charm)
+	c.Assert(err, gc.IsNil)
+	s.unit0, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.unit1, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *LeadershipSuite) assertLeader(c *gc.C, expect string) {
+	leader, err := s.service.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, expect)
+}
+
+func (s *LeadershipSuite) claim(c *gc.C, u *state.Unit, expect bool) {
+	claimed, err := u.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, gc.Equals, expect)
+}
+
+func (s *LeadershipSuite) TestNoLeader(c *gc.C) {
+	s.assertLeader(c, "")
+	settings, err := s.service.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.HasLen, 0)
+}
+
+func (s *LeadershipSuite) TestClaimAndRenew(c *gc.C) {
+	s.claim(c, s.unit0, true)
+	s.assertLeader(c, "wordpress/0")
+
+	// The lease can be renewed by the leader before it expires.
+	s.now = s.now.Add(50 * time.Second)
+	s.claim(c, s.unit0, true)
+	s.now = s.now.Add(50 * time.Second)
+	s.assertLeader(c, "wordpress/0")
+
+	// Without renewal, the lease expires.
+	s.now = s.now.Add(20 * time.Second)
+	s.assertLeader(c, "")
+}
+
+func (s *LeadershipSuite) renew(c *gc.C, u *state.Unit, expect bool) {
+	renewed, err := u.RenewLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(renewed, gc.Equals, expect)
+}
+
+func (s *LeadershipSuite) TestRenewOnlyByHolder(c *gc.C) {
+	// Renewing never elects a leader.
+	s.renew(c, s.unit0, false)
+	s.assertLeader(c, "")
+
+	s.claim(c, s.unit0, true)
+	s.now = s.now.Add(50 * time.Second)
+	s.renew(c, s.unit0, true)
+	s.renew(c, s.unit1, false)
+	s.now = s.now.Add(50 * time.Second)
+	s.assertLeader(c, "wordpress/0")
+
+	// Once another unit has taken over, the old leader cannot renew.
+	s.claim(c, s.unit1, true)
+	s.renew(c, s.unit0, false)
+	s.assertLeader(c, "wordpress/1")
+}
+
+func (s *LeadershipSuite) TestRenewWhenDead(c *gc.C) {
+	s.claim(c, s.unit0, true)
+	err := s.unit0.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	s.renew(c, s.unit0, false)
+}
+
+func (s *LeadershipSuite) TestRenewInvalidDuration(c *gc.C) {
+	_, err := s.unit0.RenewLeadership(0)
+	c.Assert(err, gc.ErrorMatches, `cannot renew leadership of service "wordpress" for unit "wordpress/0": invalid lease duration 0`)
+}
+
+func (s *LeadershipSuite) TestClaimInvalidDuration(c *gc.C) {
+	_, err := s.unit0.ClaimLeadership(0)
+	c.Assert(err, gc.ErrorMatches, `cannot claim leadership of service "wordpress" for unit "wordpress/0": invalid lease duration 0`)
+}
+
+func (s *LeadershipSuite) TestClaimRefusedWhileLeaderAlive(c *gc.C) {
+	pinger, err := s.unit0.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = s.unit0.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	s.claim(c, s.unit0, true)
+	s.claim(c, s.unit1, false)
+	s.assertLeader(c, "wordpress/0")
+}
+
+func (s *LeadershipSuite) TestFailoverWhenLeaderAgentDown(c *gc.C) {
+	// The leader's agent is not running, so the lease can be taken
+	// over before it expires.
+	s.claim(c, s.unit0, true)
+	s.claim(c, s.unit1, true)
+	s.assertLeader(c, "wordpress/1")
+	s.claim(c, s.unit0, false)
+}
+
+func (s *LeadershipSuite) TestFailoverWhenLeaseExpired(c *gc.C) {
+	pinger, err := s.unit0.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+	err = s.unit0.WaitAgentAlive(coretesting.LongWait)
+	c.Assert(err, gc.IsNil)
+
+	s.claim(c, s.unit0, true)
+	s.now = s.now.Add(2 * time.Minute)
+	s.claim(c, s.unit1, true)
+	s.assertLeader(c, "wordpress/1")
+}
+
+func (s *LeadershipSuite) TestFailoverWhenLeaderDead(c *gc.C) {
+	s.claim(c, s.unit0, true)
+	err := s.unit0.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	s.claim(c, s.unit0, false)
+	s.claim(c, s.unit1, true)
+	s.assertLeader(c, "wordpress/1")
+}
+
+func (s *LeadershipSuite) TestLeaderSettings(c *gc.C) {
+	s.claim(c, s.unit0, true)
+	err := s.unit0.SetLeaderSettings(map[string]string{"master": "10.0.0.1", "a.b": "c"})
+	c.Assert(err, gc.IsNil)
+	err = s.unit0.SetLeaderSettings(map[string]string{"master": "", "port": "3306"})
+	c.Assert(err, gc.IsNil)
+
+	settings, err := s.service.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]string{"a.b": "c", "port": "3306"})
+}
+
+func (s *LeadershipSuite) TestLeaderSettingsNotLeader(c *gc.C) {
+	err := s.unit1.SetLeaderSettings(map[string]string{"master": "10.0.0.2"})
+	c.Assert(err, gc.ErrorMatches, `cannot write leader settings of service "wordpress": unit "wordpress/1" is not the leader`)
+
+	s.claim(c, s.unit0, true)
+	err = s.unit1.SetLeaderSettings(map[string]string{"master": "10.0.0.2"})
+	c.Assert(err, gc.ErrorMatches, `cannot write leader settings of service "wordpress": unit "wordpress/1" is not the leader`)
+
+	// Once the lease has expired, the former leader cannot write.
+	s.now = s.now.Add(2 * time.Minute)
+	err = s.unit0.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.ErrorMatches, `cannot write leader settings of service "wordpress": unit "wordpress/0" is not the leader`)
+}
+
+func (s *LeadershipSuite) TestDestroyServiceRemovesLeadership(c *gc.C) {
+	s.claim(c, s.unit0, true)
+	err := s.unit0.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	for _, u := range []*state.Unit{s.unit0, s.unit1} {
+		err = u.EnsureDead()
+		c.Assert(err, gc.IsNil)
+		err = u.Remove()
+		c.Assert(err, gc.IsNil)
+	}
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+
+	// A new service with the same name starts without a leader.
+	s.service, err = s.State.AddService("wordpress", s.charm)
+	c.Assert(err, gc.IsNil)
+	s.assertLeader(c, "")
+	settings, err := s.service.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.HasLen, 0)
+}
+
+func (s *LeadershipSuite) TestWatchLeaderSettings(c *gc.C) {
+	w := s.service.WatchLeaderSettings()
+	defer testing.AssertStop(c, w)
+
+	// Initial event.
+	wc := testing.NewNotifyWatcherC(c, s.State, w)
+	wc.AssertOneChange()
+
+	// Claiming and renewing the lease does not notify.
+	s.claim(c, s.unit0, true)
+	s.claim(c, s.unit0, true)
+	wc.AssertNoChange()
+
+	err := s.unit0.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	wc.AssertOneChange()
+
+	testing.AssertStop(c, w)
+	wc.AssertClosed()
+}
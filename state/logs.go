@@ -0,0 +1,298 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+)
+
+// LogRecord holds a single log message written by an agent.
+type LogRecord struct {
+	Time     time.Time
+	Entity   string
+	Module   string
+	Level    loggo.Level
+	Location string
+	Message  string
+}
+
+// logDoc is the stored form of a LogRecord. The logs collection is
+// capped, so its documents are ordered by insertion and are never
+// modified after being written.
+type logDoc struct {
+	Id       bson.ObjectId `bson:"_id"`
+	Time     time.Time
+	Entity   string
+	Module   string
+	Level    int
+	Location string
+	Message  string
+}
+
+func (doc *logDoc) record() *LogRecord {
+	return &LogRecord{
+		Time:     doc.Time,
+		Entity:   doc.Entity,
+		Module:   doc.Module,
+		Level:    loggo.Level(doc.Level),
+		Location: doc.Location,
+		Message:  doc.Message,
+	}
+}
+
+// AddLogs records the given log messages. They are inserted directly
+// rather than by a transaction: the logs collection is capped, and
+// documents in a capped collection cannot grow to hold the fields
+// that mgo/txn adds.
+func (st *State) AddLogs(records ...LogRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(records))
+	for i, r := range records {
+		docs[i] = &logDoc{
+			Id:       bson.NewObjectId(),
+			Time:     r.Time,
+			Entity:   r.Entity,
+			Module:   r.Module,
+			Level:    int(r.Level),
+			Location: r.Location,
+			Message:  r.Message,
+		}
+	}
+	if err := st.logs.Insert(docs...); err != nil {
+		return fmt.Errorf("cannot add log records: %v", err)
+	}
+	return nil
+}
+
+// LogFilter describes the log records to be returned by a LogTailer.
+type LogFilter struct {
+	// IncludeEntity and ExcludeEntity hold entity tags. A tag ending
+	// in "*" matches any tag starting with the preceding text, so
+	// "unit-mysql-*" matches every mysql unit. When IncludeEntity is
+	// empty, all entities are included.
+	IncludeEntity []string
+	ExcludeEntity []string
+
+	// IncludeModule and ExcludeModule hold logging module names. A
+	// module also matches all of its submodules, so "juju.worker"
+	// matches "juju.worker.uniter". When IncludeModule is empty, all
+	// modules are included.
+	IncludeModule []string
+	ExcludeModule []string
+
+	// Level holds the minimum level of the records returned.
+	Level loggo.Level
+
+	// Replay causes all matching records already in the log to be
+	// returned before any new ones.
+	Replay bool
+
+	// Backlog holds the number of most recent matching records to
+	// return before any new ones when Replay is false.
+	Backlog int
+}
+
+func entityPattern(tag string) bson.RegEx {
+	if strings.HasSuffix(tag, "*") {
+		return bson.RegEx{Pattern: "^" + regexp.QuoteMeta(tag[:len(tag)-1])}
+	}
+	return bson.RegEx{Pattern: "^" + regexp.QuoteMeta(tag) + "$"}
+}
+
+func modulePattern(module string) bson.RegEx {
+	return bson.RegEx{Pattern: "^" + regexp.QuoteMeta(module) + `(\..*)?$`}
+}
+
+func matchAny(include, exclude []string, pattern func(string) bson.RegEx) bson.M {
+	patterns := func(values []string) []bson.RegEx {
+		result := make([]bson.RegEx, len(values))
+		for i, v := range values {
+			result[i] = pattern(v)
+		}
+		return result
+	}
+	cond := bson.M{}
+	if len(include) > 0 {
+		cond["$in"] = patterns(include)
+	}
+	if len(exclude) > 0 {
+		cond["$nin"] = patterns(exclude)
+	}
+	return cond
+}
+
+// query returns the mongo query selecting the records that match f.
+func (f *LogFilter) query() bson.M {
+	query := bson.M{}
+	if cond := matchAny(f.IncludeEntity, f.ExcludeEntity, entityPattern); len(cond) > 0 {
+		query["entity"] = cond
+	}
+	if cond := matchAny(f.IncludeModule, f.ExcludeModule, modulePattern); len(cond) > 0 {
+		query["module"] = cond
+	}
+	if f.Level > loggo.UNSPECIFIED {
+		query["level"] = bson.M{"$gte": int(f.Level)}
+	}
+	return query
+}
+
+// tailTimeout is how long a tailing cursor waits for new records
+// before the LogTailer checks whether it has been stopped.
+var tailTimeout = 2 * time.Second
+
+// tailRetryDelay is how long the LogTailer waits before replacing a
+// tailing cursor that was invalidated, as happens immediately when
+// the logs collection is empty.
+var tailRetryDelay = 500 * time.Millisecond
+
+// LogTailer delivers log records matching a LogFilter as they are
+// added to the state.
+type LogTailer struct {
+	tomb   tomb.Tomb
+	logs   *mgo.Collection
+	filter LogFilter
+	out    chan *LogRecord
+	lastId bson.ObjectId
+}
+
+// NewLogTailer returns a LogTailer that delivers the log records
+// matching filter.
+func (st *State) NewLogTailer(filter LogFilter) *LogTailer {
+	t := &LogTailer{
+		logs:   st.logs,
+		filter: filter,
+		out:    make(chan *LogRecord),
+	}
+	go func() {
+		defer t.tomb.Done()
+		defer close(t.out)
+		t.tomb.Kill(t.loop())
+	}()
+	return t
+}
+
+// Logs returns the channel on which log records are delivered. It is
+// closed when the tailer stops.
+func (t *LogTailer) Logs() <-chan *LogRecord {
+	return t.out
+}
+
+// Stop stops the tailer and returns any error it encountered.
+func (t *LogTailer) Stop() error {
+	t.tomb.Kill(nil)
+	return t.tomb.Wait()
+}
+
+// Err returns any error encountered by the tailer, or
+// tomb.ErrStillAlive if it is still running.
+func (t *LogTailer) Err() error {
+	return t.tomb.Err()
+}
+
+func (t *LogTailer) send(doc *logDoc) error {
+	select {
+	case <-t.tomb.Dying():
+		return tomb.ErrDying
+	case t.out <- doc.record():
+	}
+	t.lastId = doc.Id
+	return nil
+}
+
+// sendBacklog sends the most recent records requested by the filter
+// and leaves lastId at the newest record in the log, so that tailing
+// starts from there.
+func (t *LogTailer) sendBacklog(logs *mgo.Collection, query bson.M) error {
+	var last logDoc
+	err := logs.Find(nil).Sort("-$natural").One(&last)
+	if err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if t.filter.Backlog > 0 {
+		var docs []logDoc
+		err := logs.Find(query).Sort("-$natural").Limit(t.filter.Backlog).All(&docs)
+		if err != nil {
+			return err
+		}
+		for i := len(docs) - 1; i >= 0; i-- {
+			if err := t.send(&docs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	t.lastId = last.Id
+	return nil
+}
+
+func (t *LogTailer) loop() error {
+	// Tailing cursors block, so use a session of our own.
+	session := t.logs.Database.Session.Copy()
+	defer session.Close()
+	logs := t.logs.With(session)
+
+	query := t.filter.query()
+	if !t.filter.Replay {
+		if err := t.sendBacklog(logs, query); err != nil {
+			return err
+		}
+	}
+	newIter := func() *mgo.Iter {
+		q := bson.M{}
+		for k, v := range query {
+			q[k] = v
+		}
+		// Records are written by the API servers, so their ids are
+		// ordered as well as their clocks are.
+		if t.lastId != "" {
+			q["_id"] = bson.M{"$gt": t.lastId}
+		}
+		return logs.Find(q).Sort("$natural").Tail(tailTimeout)
+	}
+	iter := newIter()
+	defer func() {
+		iter.Close()
+	}()
+	var doc logDoc
+	for {
+		for iter.Next(&doc) {
+			if err := t.send(&doc); err != nil {
+				return err
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if !iter.Timeout() {
+			// The cursor is no longer valid; start another
+			// from the last record seen.
+			if err := iter.Close(); err != nil {
+				return err
+			}
+			select {
+			case <-t.tomb.Dying():
+				return tomb.ErrDying
+			case <-time.After(tailRetryDelay):
+			}
+			iter = newIter()
+			continue
+		}
+		select {
+		case <-t.tomb.Dying():
+			return tomb.ErrDying
+		default:
+		}
+	}
+}
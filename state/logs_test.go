@@ -0,0 +1,173 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/testing"
+)
+
+type LogsSuite struct {
+	ConnSuite
+	restore func()
+}
+
+var _ = gc.Suite(&LogsSuite{})
+
+func (s *LogsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	s.restore = state.SetLogTailTimeouts(50*time.Millisecond, 10*time.Millisecond)
+}
+
+func (s *LogsSuite) TearDownTest(c *gc.C) {
+	s.restore()
+	s.ConnSuite.TearDownTest(c)
+}
+
+var logsTestTime = time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func logRecord(entity, module string, level loggo.Level, message string) state.LogRecord {
+	return state.LogRecord{
+		Time:     logsTestTime,
+		Entity:   entity,
+		Module:   module,
+		Level:    level,
+		Location: "file.go:42",
+		Message:  message,
+	}
+}
+
+var logsTestRecords = []state.LogRecord{
+	logRecord("machine-0", "juju.worker.provisioner", loggo.INFO, "starting"),
+	logRecord("machine-1", "juju.worker", loggo.DEBUG, "waiting"),
+	logRecord("unit-mysql-0", "juju.worker.uniter", loggo.ERROR, "hook failed"),
+	logRecord("unit-mysql-1", "juju.worker.uniter", loggo.WARNING, "retrying"),
+	logRecord("unit-wordpress-0", "unit.wordpress/0.install", loggo.INFO, "installed"),
+}
+
+// assertLogs checks that the tailer delivers exactly the expected
+// messages, in order.
+func assertLogs(c *gc.C, tailer *state.LogTailer, expect ...string) {
+	for _, message := range expect {
+		select {
+		case record, ok := <-tailer.Logs():
+			c.Assert(ok, gc.Equals, true)
+			c.Assert(record.Message, gc.Equals, message)
+		case <-time.After(testing.LongWait):
+			c.Fatalf("timed out waiting for %q", message)
+		}
+	}
+	select {
+	case record := <-tailer.Logs():
+		c.Fatalf("unexpected log record %#v", record)
+	case <-time.After(testing.ShortWait):
+	}
+}
+
+func (s *LogsSuite) TestAddLogsAndReplay(c *gc.C) {
+	err := s.State.AddLogs(logsTestRecords...)
+	c.Assert(err, gc.IsNil)
+
+	tailer := s.State.NewLogTailer(state.LogFilter{Replay: true})
+	defer tailer.Stop()
+	select {
+	case record := <-tailer.Logs():
+		c.Assert(record.Time.Equal(logsTestTime), gc.Equals, true)
+		record.Time = logsTestTime
+		c.Assert(*record, gc.DeepEquals, logsTestRecords[0])
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for log record")
+	}
+	assertLogs(c, tailer, "waiting", "hook failed", "retrying", "installed")
+	c.Assert(tailer.Stop(), gc.IsNil)
+}
+
+var logFilterTests = []struct {
+	about  string
+	filter state.LogFilter
+	expect []string
+}{{
+	about:  "include entity",
+	filter: state.LogFilter{IncludeEntity: []string{"machine-1"}},
+	expect: []string{"waiting"},
+}, {
+	about:  "include entity wildcard",
+	filter: state.LogFilter{IncludeEntity: []string{"unit-mysql-*"}},
+	expect: []string{"hook failed", "retrying"},
+}, {
+	about:  "exclude entity",
+	filter: state.LogFilter{ExcludeEntity: []string{"unit-*", "machine-0"}},
+	expect: []string{"waiting"},
+}, {
+	about:  "include module and submodules",
+	filter: state.LogFilter{IncludeModule: []string{"juju.worker"}},
+	expect: []string{"starting", "waiting", "hook failed", "retrying"},
+}, {
+	about:  "exclude module",
+	filter: state.LogFilter{ExcludeModule: []string{"juju.worker.uniter", "unit"}},
+	expect: []string{"starting", "waiting"},
+}, {
+	about:  "module prefix is not a submodule",
+	filter: state.LogFilter{IncludeModule: []string{"juju.work"}},
+	expect: nil,
+}, {
+	about:  "minimum level",
+	filter: state.LogFilter{Level: loggo.WARNING},
+	expect: []string{"hook failed", "retrying"},
+}, {
+	about: "combined",
+	filter: state.LogFilter{
+		IncludeEntity: []string{"unit-*"},
+		ExcludeEntity: []string{"unit-mysql-1"},
+		Level:         loggo.INFO,
+	},
+	expect: []string{"hook failed", "installed"},
+}}
+
+func (s *LogsSuite) TestLogFilter(c *gc.C) {
+	err := s.State.AddLogs(logsTestRecords...)
+	c.Assert(err, gc.IsNil)
+	for i, t := range logFilterTests {
+		c.Logf("test %d: %s", i, t.about)
+		t.filter.Replay = true
+		tailer := s.State.NewLogTailer(t.filter)
+		assertLogs(c, tailer, t.expect...)
+		c.Assert(tailer.Stop(), gc.IsNil)
+	}
+}
+
+func (s *LogsSuite) TestBacklog(c *gc.C) {
+	err := s.State.AddLogs(logsTestRecords...)
+	c.Assert(err, gc.IsNil)
+	tailer := s.State.NewLogTailer(state.LogFilter{
+		IncludeEntity: []string{"machine-*", "unit-mysql-0"},
+		Backlog:       2,
+	})
+	defer tailer.Stop()
+	assertLogs(c, tailer, "waiting", "hook failed")
+
+	err = s.State.AddLogs(
+		logRecord("machine-0", "juju", loggo.INFO, "later"),
+		logRecord("unit-mysql-1", "juju", loggo.INFO, "ignored"),
+	)
+	c.Assert(err, gc.IsNil)
+	assertLogs(c, tailer, "later")
+	c.Assert(tailer.Stop(), gc.IsNil)
+}
+
+func (s *LogsSuite) TestTailEmptyLog(c *gc.C) {
+	tailer := s.State.NewLogTailer(state.LogFilter{Backlog: 10})
+	defer tailer.Stop()
+	assertLogs(c, tailer)
+
+	err := s.State.AddLogs(logsTestRecords[0])
+	c.Assert(err, gc.IsNil)
+	assertLogs(c, tailer, "starting")
+	c.Assert(tailer.Stop(), gc.IsNil)
+}
@@ -88,6 +88,10 @@ type machineDoc struct {
 	PasswordHash  string
 	Clean         bool
 	Addresses     []address
+	Placement     string
+	// ReplacementId holds the id of the machine to which the units of
+	// the machine are moved when it is recovered after failing.
+	ReplacementId string `bson:",omitempty"`
 	// Deprecated. InstanceId, now lives on instanceData.
 	// This attribute is retained so that data from existing machines can be read.
 	// SCHEMACHANGE
@@ -118,6 +122,12 @@ func (m *Machine) Series() string {
 	return m.doc.Series
 }
 
+// Placement returns the provider-specific placement directive the
+// provisioner follows when starting the machine, if any.
+func (m *Machine) Placement() string {
+	return m.doc.Placement
+}
+
 // ContainerType returns the type of container hosting this machine.
 func (m *Machine) ContainerType() instance.ContainerType {
 	return instance.ContainerType(m.doc.ContainerType)
@@ -243,9 +253,18 @@ func (m *Machine) SetAgentVersion(v version.Binary) (err error) {
 
 // SetMongoPassword sets the password the agent responsible for the machine
 // should use to communicate with the state servers.  Previous passwords
-// are invalidated.
+// are invalidated. The agent of a state server machine is also given
+// administrative access, which it needs to manage the replica set.
 func (m *Machine) SetMongoPassword(password string) error {
-	return m.st.setMongoPassword(m.Tag(), password)
+	if err := m.st.setMongoPassword(m.Tag(), password); err != nil {
+		return err
+	}
+	for _, job := range m.doc.Jobs {
+		if job == JobManageState {
+			return m.st.setAdminMongoPassword(m.Tag(), password)
+		}
+	}
+	return nil
 }
 
 // SetPassword sets the password for the machine's agent.
@@ -495,6 +514,11 @@ func (m *Machine) Remove() (err error) {
 		annotationRemoveOp(m.st, m.globalKey()),
 	}
 	ops = append(ops, removeContainerRefOps(m.st, m.Id())...)
+	ifaceOps, err := removeNetworkInterfacesOps(m.st, m.doc.Id)
+	if err != nil {
+		return err
+	}
+	ops = append(ops, ifaceOps...)
 	// The only abort conditions in play indicate that the machine has already
 	// been removed.
 	return onAbort(m.st.runTransaction(ops), nil)
@@ -639,7 +663,7 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 		// TODO(wallyworld) - remove this backward compatibility code when schema upgrades are possible
 		// (InstanceId is stored on the instanceData document but we duplicate the value on the machineDoc.
 		m.doc.InstanceId = id
-		return nil
+		return m.clearProvisioningError()
 	} else if err != txn.ErrAborted {
 		return err
 	} else if alive, err := isAlive(m.st.machines, m.doc.Id); err != nil {
@@ -650,6 +674,117 @@ func (m *Machine) SetProvisioned(id instance.Id, nonce string, characteristics *
 	return fmt.Errorf("already set")
 }
 
+// clearProvisioningError resets the machine's status to pending if it
+// was left in error by an earlier failed attempt to provision it, which
+// no longer applies now that an instance has been recorded.
+func (m *Machine) clearProvisioningError() error {
+	doc := statusDoc{Status: params.StatusPending}
+	ops := []txn.Op{{
+		C:      m.st.statuses.Name,
+		Id:     m.globalKey(),
+		Assert: D{{"status", params.StatusError}},
+		Update: D{{"$set", doc}},
+	}}
+	if err := m.st.runTransaction(ops); err == txn.ErrAborted {
+		// The machine was not in error.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	recordStatusHistory(m.st, m.globalKey(), doc)
+	return nil
+}
+
+// SetInstanceInfo is used to provision a machine and in one step set
+// its instance id, nonce, hardware characteristics, and the networks
+// and network interfaces reported by the provider. Networks not yet
+// known to the environment are added.
+func (m *Machine) SetInstanceInfo(
+	id instance.Id, nonce string, characteristics *instance.HardwareCharacteristics,
+	networks []instance.NetworkInfo, interfaces []instance.InterfaceInfo) error {
+
+	for _, info := range networks {
+		if err := validateNetworkInfo(info); err != nil {
+			return fmt.Errorf("cannot add network %q: %v", info.Name, err)
+		}
+	}
+	for _, info := range interfaces {
+		if err := validateInterfaceInfo(info); err != nil {
+			return fmt.Errorf("cannot add network interface %q: %v", info.InterfaceName, err)
+		}
+	}
+	if err := m.SetProvisioned(id, nonce, characteristics); err != nil {
+		return err
+	}
+	for _, info := range networks {
+		_, err := m.st.AddNetwork(info)
+		if err == nil {
+			continue
+		}
+		// The network may already have been added for another machine.
+		if _, err := m.st.Network(info.Name); err != nil {
+			return err
+		}
+	}
+	for _, info := range interfaces {
+		if _, err := m.AddNetworkInterface(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddNetworkInterface records a network interface of the machine,
+// connecting it to an existing network.
+func (m *Machine) AddNetworkInterface(info instance.InterfaceInfo) (iface *NetworkInterface, err error) {
+	defer utils.ErrorContextf(&err, "cannot add network interface %q to machine %s", info.InterfaceName, m.doc.Id)
+	if err := validateInterfaceInfo(info); err != nil {
+		return nil, err
+	}
+	ops := []txn.Op{{
+		C:      m.st.machines.Name,
+		Id:     m.doc.Id,
+		Assert: notDeadDoc,
+	}}
+	ops = append(ops, addNetworkInterfaceOps(m.st, m.doc.Id, info)...)
+	switch err := m.st.runTransaction(ops); err {
+	case nil:
+		doc := &networkInterfaceDoc{}
+		id := networkInterfaceId(m.doc.Id, info.InterfaceName)
+		if err := m.st.networkInterfaces.FindId(id).One(doc); err != nil {
+			return nil, err
+		}
+		return newNetworkInterface(m.st, doc), nil
+	case txn.ErrAborted:
+	default:
+		return nil, err
+	}
+	if err := m.Refresh(); err != nil {
+		return nil, err
+	} else if m.doc.Life == Dead {
+		return nil, errNotAlive
+	}
+	if _, err := m.st.Network(info.NetworkName); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("interface already exists")
+}
+
+// NetworkInterfaces returns the network interfaces of the machine,
+// ordered by interface name.
+func (m *Machine) NetworkInterfaces() ([]*NetworkInterface, error) {
+	docs := []networkInterfaceDoc{}
+	err := m.st.networkInterfaces.Find(D{{"machineid", m.doc.Id}}).Sort("interfacename").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get network interfaces of machine %s: %v", m.doc.Id, err)
+	}
+	ifaces := make([]*NetworkInterface, len(docs))
+	for i := range docs {
+		ifaces[i] = newNetworkInterface(m.st, &docs[i])
+	}
+	return ifaces, nil
+}
+
 // notProvisionedError records an error when a machine is not provisioned.
 type notProvisionedError struct {
 	machineId string
@@ -786,9 +921,16 @@ func (m *Machine) SetStatus(status params.Status, info string, data params.Statu
 	if err := m.st.runTransaction(ops); err != nil {
 		return fmt.Errorf("cannot set status of machine %q: %v", m, onAbort(err, errNotAlive))
 	}
+	recordStatusHistory(m.st, m.globalKey(), doc)
 	return nil
 }
 
+// StatusHistory returns at most size of the most recent statuses set
+// on the machine, newest first. A size of zero returns them all.
+func (m *Machine) StatusHistory(size int) ([]StatusInfo, error) {
+	return statusHistory(m.st, m.globalKey(), size)
+}
+
 // Clean returns true if the machine does not have any deployed units or containers.
 func (m *Machine) Clean() bool {
 	return m.doc.Clean
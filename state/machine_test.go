@@ -279,6 +279,39 @@ func (s *MachineSuite) TestSetMongoPassword(c *gc.C) {
 	})
 }
 
+func (s *MachineSuite) TestSetMongoPasswordStateServerIsAdmin(c *gc.C) {
+	info := state.TestingStateInfo()
+	st, err := state.Open(info, state.TestingDialOpts())
+	c.Assert(err, gc.IsNil)
+	defer st.Close()
+	// Turn on fully-authenticated mode.
+	err = st.SetAdminMongoPassword("admin-secret")
+	c.Assert(err, gc.IsNil)
+	defer func() {
+		err := st.SetAdminMongoPassword("")
+		c.Assert(err, gc.IsNil)
+	}()
+
+	for i, job := range []state.MachineJob{state.JobHostUnits, state.JobManageState} {
+		c.Logf("test %d: %s", i, job)
+		m, err := st.AddMachine("quantal", job)
+		c.Assert(err, gc.IsNil)
+		err = m.SetMongoPassword("foo")
+		c.Assert(err, gc.IsNil)
+
+		info.Tag, info.Password = m.Tag(), "foo"
+		st1, err := state.Open(info, state.TestingDialOpts())
+		c.Assert(err, gc.IsNil)
+		err = st1.MongoSession().Run("listDatabases", nil)
+		if job == state.JobManageState {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, ".*unauthorized.*")
+		}
+		st1.Close()
+	}
+}
+
 func (s *MachineSuite) TestSetPassword(c *gc.C) {
 	testSetPassword(c, func() (state.Authenticator, error) {
 		return s.State.Machine(s.machine.Id())
@@ -432,6 +465,21 @@ func (s *MachineSuite) TestMachineSetCheckProvisioned(c *gc.C) {
 	c.Assert(s.machine.CheckProvisioned("not-really"), gc.Equals, false)
 }
 
+func (s *MachineSuite) TestMachineSetProvisionedClearsErrorStatus(c *gc.C) {
+	err := s.machine.SetStatus(params.StatusError, "cannot start instance", params.StatusData{
+		"transient": true,
+		"attempts":  3,
+	})
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetProvisioned("umbrella/0", "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	status, info, data, err := s.machine.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusPending)
+	c.Assert(info, gc.Equals, "")
+	c.Assert(data, gc.HasLen, 0)
+}
+
 func (s *MachineSuite) TestMachineSetProvisionedWhenNotAlive(c *gc.C) {
 	testWhenDying(c, s.machine, notAliveErr, notAliveErr, func() error {
 		return s.machine.SetProvisioned("umbrella/0", "fake_nonce", nil)
@@ -97,11 +97,19 @@ func (u *backingUnit) updated(st *State, store *multiwatcher.Store, id interface
 		}
 		info.Status = sdoc.Status
 		info.StatusInfo = sdoc.StatusInfo
+		wdoc, _, err := getWorkloadStatus(st, unitGlobalKey(u.Name))
+		if err != nil {
+			return err
+		}
+		info.WorkloadStatus = wdoc.Status
+		info.WorkloadStatusInfo = wdoc.StatusInfo
 	} else {
 		// The entry already exists, so preserve the current status.
 		oldInfo := oldInfo.(*params.UnitInfo)
 		info.Status = oldInfo.Status
 		info.StatusInfo = oldInfo.StatusInfo
+		info.WorkloadStatus = oldInfo.WorkloadStatus
+		info.WorkloadStatusInfo = oldInfo.WorkloadStatusInfo
 	}
 	store.Update(info)
 	return nil
@@ -238,7 +246,11 @@ func (a *backingAnnotation) mongoId() interface{} {
 type backingStatus statusDoc
 
 func (s *backingStatus) updated(st *State, store *multiwatcher.Store, id interface{}) error {
-	parentId, ok := backingEntityIdForGlobalKey(id.(string))
+	key := id.(string)
+	if strings.HasSuffix(key, workloadKeySuffix) {
+		return s.updatedWorkload(store, strings.TrimSuffix(key, workloadKeySuffix))
+	}
+	parentId, ok := backingEntityIdForGlobalKey(key)
 	if !ok {
 		return nil
 	}
@@ -266,6 +278,28 @@ func (s *backingStatus) updated(st *State, store *multiwatcher.Store, id interfa
 	return nil
 }
 
+// updatedWorkload records the workload status held in s on the unit
+// with the given global key.
+func (s *backingStatus) updatedWorkload(store *multiwatcher.Store, globalKey string) error {
+	parentId, ok := backingEntityIdForGlobalKey(globalKey)
+	if !ok {
+		return nil
+	}
+	switch info := store.Get(parentId).(type) {
+	case nil:
+		// The unit info doesn't exist. Ignore the status until it does.
+		return nil
+	case *params.UnitInfo:
+		newInfo := *info
+		newInfo.WorkloadStatus = params.WorkloadStatus(s.Status)
+		newInfo.WorkloadStatusInfo = s.StatusInfo
+		store.Update(&newInfo)
+	default:
+		panic(fmt.Errorf("workload status for unexpected entity with id %q; type %T", globalKey, info))
+	}
+	return nil
+}
+
 func (s *backingStatus) removed(st *State, store *multiwatcher.Store, id interface{}) error {
 	// If the status is removed, the parent will follow not long after,
 	// so do nothing.
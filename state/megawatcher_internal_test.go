@@ -138,12 +138,13 @@ func (s *storeManagerStateSuite) setUpScenario(c *gc.C) (entities entityInfoSlic
 		c.Assert(m.Tag(), gc.Equals, fmt.Sprintf("machine-%d", i+1))
 
 		add(&params.UnitInfo{
-			Name:      fmt.Sprintf("wordpress/%d", i),
-			Service:   wordpress.Name(),
-			Series:    m.Series(),
-			MachineId: m.Id(),
-			Ports:     []instance.Port{},
-			Status:    params.StatusPending,
+			Name:           fmt.Sprintf("wordpress/%d", i),
+			Service:        wordpress.Name(),
+			Series:         m.Series(),
+			MachineId:      m.Id(),
+			Ports:          []instance.Port{},
+			Status:         params.StatusPending,
+			WorkloadStatus: params.WorkloadUnknown,
 		})
 		pairs := map[string]string{"name": fmt.Sprintf("bar %d", i)}
 		err = wu.SetAnnotations(pairs)
@@ -185,11 +186,12 @@ func (s *storeManagerStateSuite) setUpScenario(c *gc.C) (entities entityInfoSlic
 		c.Assert(ok, gc.Equals, true)
 		c.Assert(deployer, gc.Equals, fmt.Sprintf("unit-wordpress-%d", i))
 		add(&params.UnitInfo{
-			Name:    fmt.Sprintf("logging/%d", i),
-			Service: "logging",
-			Series:  "quantal",
-			Ports:   []instance.Port{},
-			Status:  params.StatusPending,
+			Name:           fmt.Sprintf("logging/%d", i),
+			Service:        "logging",
+			Series:         "quantal",
+			Ports:          []instance.Port{},
+			Status:         params.StatusPending,
+			WorkloadStatus: params.WorkloadUnknown,
 		})
 	}
 	return
@@ -357,6 +359,7 @@ var allWatcherChangedTests = []struct {
 				Ports:          []instance.Port{{"tcp", 12345}},
 				Status:         params.StatusError,
 				StatusInfo:     "failure",
+				WorkloadStatus: params.WorkloadUnknown,
 			},
 		},
 	}, {
@@ -685,6 +688,49 @@ var allWatcherChangedTests = []struct {
 			},
 		},
 	},
+	// Unit workload status changes
+	{
+		about: "no unit in store -> ignore workload status",
+		setUp: func(c *gc.C, st *State) {
+			wordpress, err := st.AddService("wordpress", AddTestingCharm(c, st, "wordpress"))
+			c.Assert(err, gc.IsNil)
+			u, err := wordpress.AddUnit()
+			c.Assert(err, gc.IsNil)
+			err = u.SetWorkloadStatus(params.WorkloadWaiting, "syncing database")
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "statuses",
+			Id: "u#wordpress/0#workload",
+		},
+	}, {
+		about: "workload status is changed if the unit exists in the store",
+		add: []params.EntityInfo{&params.UnitInfo{
+			Name:           "wordpress/0",
+			Status:         params.StatusStarted,
+			WorkloadStatus: params.WorkloadUnknown,
+		}},
+		setUp: func(c *gc.C, st *State) {
+			wordpress, err := st.AddService("wordpress", AddTestingCharm(c, st, "wordpress"))
+			c.Assert(err, gc.IsNil)
+			u, err := wordpress.AddUnit()
+			c.Assert(err, gc.IsNil)
+			err = u.SetWorkloadStatus(params.WorkloadWaiting, "syncing database")
+			c.Assert(err, gc.IsNil)
+		},
+		change: watcher.Change{
+			C:  "statuses",
+			Id: "u#wordpress/0#workload",
+		},
+		expectContents: []params.EntityInfo{
+			&params.UnitInfo{
+				Name:               "wordpress/0",
+				Status:             params.StatusStarted,
+				WorkloadStatus:     params.WorkloadWaiting,
+				WorkloadStatusInfo: "syncing database",
+			},
+		},
+	},
 	// Machine status changes
 	{
 		about: "no machine in state -> do nothing",
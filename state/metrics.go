@@ -0,0 +1,203 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+)
+
+// Metric is a named value reported by a unit at a given time.
+type Metric struct {
+	Key   string
+	Value float64
+	Time  time.Time
+}
+
+// metricBatchDoc holds the metrics reported together by a unit. The
+// documents are written directly rather than by a transaction, because
+// they are never updated. They are removed by PruneMetrics, or by a
+// cleanup queued when their unit or service is removed.
+type metricBatchDoc struct {
+	Id      bson.ObjectId `bson:"_id"`
+	Unit    string
+	Service string
+	Created time.Time
+	Metrics []Metric
+}
+
+// metricsNow returns the time a batch of metrics is recorded. It is
+// patched in tests.
+var metricsNow = time.Now
+
+// AddMetrics records a batch of metrics reported by the unit. Metrics
+// without a time are given the time the batch is recorded.
+func (u *Unit) AddMetrics(metrics []Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	now := metricsNow().UTC()
+	doc := &metricBatchDoc{
+		Id:      bson.NewObjectId(),
+		Unit:    u.Name(),
+		Service: u.ServiceName(),
+		Created: now,
+		Metrics: make([]Metric, len(metrics)),
+	}
+	for i, m := range metrics {
+		if m.Key == "" {
+			return fmt.Errorf("cannot add metrics for unit %q: metric has no key", u)
+		}
+		if m.Time.IsZero() {
+			m.Time = now
+		}
+		m.Time = m.Time.UTC()
+		doc.Metrics[i] = m
+	}
+	if err := u.st.metrics.Insert(doc); err != nil {
+		return fmt.Errorf("cannot add metrics for unit %q: %v", u, err)
+	}
+	return nil
+}
+
+// MetricValues returns the values of the metric with the given key
+// reported by the service's units at or after the given time, oldest
+// first.
+func (s *Service) MetricValues(key string, since time.Time) ([]Metric, error) {
+	since = since.UTC()
+	var docs []metricBatchDoc
+	sel := D{
+		{"service", s.doc.Name},
+		{"metrics", D{{"$elemMatch", D{
+			{"key", key},
+			{"time", D{{"$gte", since}}},
+		}}}},
+	}
+	if err := s.st.metrics.Find(sel).Sort("created", "_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get metric %q of service %q: %v", key, s, err)
+	}
+	var values []Metric
+	for _, doc := range docs {
+		for _, m := range doc.Metrics {
+			if m.Key == key && !m.Time.Before(since) {
+				m.Time = m.Time.UTC()
+				values = append(values, m)
+			}
+		}
+	}
+	return values, nil
+}
+
+// MetricBatch holds the metrics reported together by a unit.
+type MetricBatch struct {
+	Unit    string
+	Created time.Time
+	Metrics []Metric
+}
+
+// MetricBatches returns the batches of metrics reported by the unit
+// that were recorded at or after the given time, oldest first.
+func (u *Unit) MetricBatches(since time.Time) ([]MetricBatch, error) {
+	batches, err := u.st.metricBatches(D{{"unit", u.Name()}}, since)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metrics of unit %q: %v", u, err)
+	}
+	return batches, nil
+}
+
+// MetricBatches returns the batches of metrics reported by the
+// service's units that were recorded at or after the given time,
+// oldest first.
+func (s *Service) MetricBatches(since time.Time) ([]MetricBatch, error) {
+	batches, err := s.st.metricBatches(D{{"service", s.doc.Name}}, since)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get metrics of service %q: %v", s, err)
+	}
+	return batches, nil
+}
+
+func (st *State) metricBatches(sel D, since time.Time) ([]MetricBatch, error) {
+	sel = append(sel, bson.DocElem{"created", D{{"$gte", since.UTC()}}})
+	var docs []metricBatchDoc
+	if err := st.metrics.Find(sel).Sort("created", "_id").All(&docs); err != nil {
+		return nil, err
+	}
+	batches := make([]MetricBatch, len(docs))
+	for i, doc := range docs {
+		for j := range doc.Metrics {
+			doc.Metrics[j].Time = doc.Metrics[j].Time.UTC()
+		}
+		batches[i] = MetricBatch{
+			Unit:    doc.Unit,
+			Created: doc.Created.UTC(),
+			Metrics: doc.Metrics,
+		}
+	}
+	return batches, nil
+}
+
+// PruneMetrics removes the batches of metrics recorded longer than
+// maxAge ago, and all but the newest maxBatches batches of each unit.
+// A zero value for either limit disables it.
+func (st *State) PruneMetrics(maxAge time.Duration, maxBatches int) error {
+	if maxAge > 0 {
+		cutoff := metricsNow().UTC().Add(-maxAge)
+		if _, err := st.metrics.RemoveAll(D{{"created", D{{"$lt", cutoff}}}}); err != nil {
+			return fmt.Errorf("cannot prune metrics: %v", err)
+		}
+	}
+	if maxBatches <= 0 {
+		return nil
+	}
+	var units []string
+	if err := st.metrics.Find(nil).Distinct("unit", &units); err != nil {
+		return fmt.Errorf("cannot prune metrics: %v", err)
+	}
+	for _, unit := range units {
+		var docs []struct {
+			Id bson.ObjectId `bson:"_id"`
+		}
+		err := st.metrics.Find(D{{"unit", unit}}).
+			Sort("-created", "-_id").Skip(maxBatches).Select(D{{"_id", 1}}).All(&docs)
+		if err != nil {
+			return fmt.Errorf("cannot prune metrics of unit %q: %v", unit, err)
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		ids := make([]bson.ObjectId, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.Id
+		}
+		if _, err := st.metrics.RemoveAll(D{{"_id", D{{"$in", ids}}}}); err != nil {
+			return fmt.Errorf("cannot prune metrics of unit %q: %v", unit, err)
+		}
+	}
+	return nil
+}
+
+// metricsCleanupOps returns the operations that queue the removal of
+// the metric batches whose field has the given value, using a cleanup
+// of the given kind. No cleanup is queued if there are no such batches.
+func (st *State) metricsCleanupOps(kind, field, value string) []txn.Op {
+	count, err := st.metrics.Find(D{{field, value}}).Limit(1).Count()
+	if err == nil && count == 0 {
+		return nil
+	}
+	return []txn.Op{st.newCleanupOp(kind, value)}
+}
+
+// cleanupMetrics removes the metric batches whose field has the value
+// held in the cleanup document. Batches recorded after the cleanup was
+// queued, by a new unit or service with the same name, are kept.
+func (st *State) cleanupMetrics(field string, doc cleanupDoc) error {
+	sel := D{{field, doc.Prefix}, {"_id", D{{"$lt", doc.Id}}}}
+	if _, err := st.metrics.RemoveAll(sel); err != nil {
+		return fmt.Errorf("cannot remove metrics marked for cleanup: %v", err)
+	}
+	return nil
+}
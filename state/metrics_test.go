@@ -0,0 +1,180 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+)
+
+type MetricsSuite struct {
+	ConnSuite
+	service *state.Service
+	unit    *state.Unit
+}
+
+var _ = gc.Suite(&MetricsSuite{})
+
+func (s *MetricsSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	s.unit, err = s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *MetricsSuite) TestAddMetrics(c *gc.C) {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(state.MetricsNow, func() time.Time { return now })
+	earlier := now.Add(-time.Minute)
+	err := s.unit.AddMetrics([]state.Metric{
+		{Key: "load", Value: 0.5, Time: earlier},
+		{Key: "requests", Value: 42},
+		{Key: "load", Value: 0.75},
+	})
+	c.Assert(err, gc.IsNil)
+
+	values, err := s.service.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.DeepEquals, []state.Metric{
+		{Key: "load", Value: 0.5, Time: earlier},
+		{Key: "load", Value: 0.75, Time: now},
+	})
+	values, err = s.service.MetricValues("load", now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.DeepEquals, []state.Metric{
+		{Key: "load", Value: 0.75, Time: now},
+	})
+	values, err = s.service.MetricValues("latency", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.HasLen, 0)
+}
+
+func (s *MetricsSuite) TestAddMetricsWithoutKey(c *gc.C) {
+	err := s.unit.AddMetrics([]state.Metric{{Value: 1}})
+	c.Assert(err, gc.ErrorMatches, `cannot add metrics for unit "wordpress/0": metric has no key`)
+	values, err := s.service.MetricValues("", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.HasLen, 0)
+}
+
+func (s *MetricsSuite) TestMetricBatches(c *gc.C) {
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	first := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+	now := first
+	s.PatchValue(state.MetricsNow, func() time.Time { return now })
+	err = s.unit.AddMetrics([]state.Metric{{Key: "load", Value: 0.5}})
+	c.Assert(err, gc.IsNil)
+	now = second
+	err = other.AddMetrics([]state.Metric{{Key: "load", Value: 1.5}, {Key: "requests", Value: 3}})
+	c.Assert(err, gc.IsNil)
+
+	batches, err := s.service.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.DeepEquals, []state.MetricBatch{{
+		Unit:    "wordpress/0",
+		Created: first,
+		Metrics: []state.Metric{{Key: "load", Value: 0.5, Time: first}},
+	}, {
+		Unit:    "wordpress/1",
+		Created: second,
+		Metrics: []state.Metric{
+			{Key: "load", Value: 1.5, Time: second},
+			{Key: "requests", Value: 3, Time: second},
+		},
+	}})
+	batches, err = s.service.MetricBatches(second)
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Unit, gc.Equals, "wordpress/1")
+
+	batches, err = s.unit.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Unit, gc.Equals, "wordpress/0")
+}
+
+func (s *MetricsSuite) TestMetricsRemovedWithUnitAndService(c *gc.C) {
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	for _, u := range []*state.Unit{s.unit, other} {
+		err := u.AddMetrics([]state.Metric{{Key: "load", Value: 0.5}})
+		c.Assert(err, gc.IsNil)
+	}
+
+	err = s.unit.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	batches, err := s.service.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+	c.Assert(batches[0].Unit, gc.Equals, other.Name())
+
+	err = other.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.State.Cleanup()
+	c.Assert(err, gc.IsNil)
+	batches, err = s.service.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 0)
+	dirty, err := s.State.NeedsCleanup()
+	c.Assert(err, gc.IsNil)
+	c.Assert(dirty, gc.Equals, false)
+}
+
+// addBatches records a batch of metrics for the unit each minute for
+// the given number of minutes, ending at the returned time.
+func (s *MetricsSuite) addBatches(c *gc.C, u *state.Unit, count int) time.Time {
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(state.MetricsNow, func() time.Time { return now })
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			now = now.Add(time.Minute)
+		}
+		err := u.AddMetrics([]state.Metric{{Key: "load", Value: float64(i)}})
+		c.Assert(err, gc.IsNil)
+	}
+	return now
+}
+
+func (s *MetricsSuite) TestPruneMetricsByAge(c *gc.C) {
+	last := s.addBatches(c, s.unit, 5)
+	// Batches recorded 3 minutes ago or earlier are pruned.
+	err := s.State.PruneMetrics(150*time.Second, 0)
+	c.Assert(err, gc.IsNil)
+	batches, err := s.unit.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 3)
+	c.Assert(batches[0].Created, gc.Equals, last.Add(-2*time.Minute))
+}
+
+func (s *MetricsSuite) TestPruneMetricsBySize(c *gc.C) {
+	last := s.addBatches(c, s.unit, 5)
+	other, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = other.AddMetrics([]state.Metric{{Key: "load", Value: 1}})
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.PruneMetrics(0, 2)
+	c.Assert(err, gc.IsNil)
+	batches, err := s.unit.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 2)
+	c.Assert(batches[0].Created, gc.Equals, last.Add(-time.Minute))
+	c.Assert(batches[1].Created, gc.Equals, last)
+
+	// Each unit keeps its own batches.
+	batches, err = other.MetricBatches(time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(batches, gc.HasLen, 1)
+}
@@ -0,0 +1,265 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/utils"
+)
+
+// networkDoc represents a network known to the environment.
+type networkDoc struct {
+	Name       string `bson:"_id"`
+	ProviderId string
+	CIDR       string
+	VLANTag    int
+}
+
+// Network represents a named network, which machines may be
+// connected to through their network interfaces, and to which
+// service endpoints may be bound.
+type Network struct {
+	st  *State
+	doc networkDoc
+}
+
+func newNetwork(st *State, doc *networkDoc) *Network {
+	return &Network{st: st, doc: *doc}
+}
+
+// Name returns the juju name of the network.
+func (n *Network) Name() string {
+	return n.doc.Name
+}
+
+// ProviderId returns the provider-specific id of the network.
+func (n *Network) ProviderId() string {
+	return n.doc.ProviderId
+}
+
+// CIDR returns the network's CIDR, in 123.45.67.89/24 format.
+func (n *Network) CIDR() string {
+	return n.doc.CIDR
+}
+
+// VLANTag returns the network's VLAN tag, or 0 if it is not a VLAN.
+func (n *Network) VLANTag() int {
+	return n.doc.VLANTag
+}
+
+// IsVLAN returns whether the network is a VLAN.
+func (n *Network) IsVLAN() bool {
+	return n.doc.VLANTag > 0
+}
+
+// Info returns the network's details.
+func (n *Network) Info() instance.NetworkInfo {
+	return instance.NetworkInfo{
+		Name:       n.doc.Name,
+		ProviderId: n.doc.ProviderId,
+		CIDR:       n.doc.CIDR,
+		VLANTag:    n.doc.VLANTag,
+	}
+}
+
+func validateNetworkInfo(info instance.NetworkInfo) error {
+	if !names.IsNetwork(info.Name) {
+		return fmt.Errorf("invalid network name %q", info.Name)
+	}
+	if info.VLANTag < 0 || info.VLANTag > 4094 {
+		return fmt.Errorf("invalid VLAN tag %d: must be between 0 and 4094", info.VLANTag)
+	}
+	return nil
+}
+
+func addNetworkOp(st *State, info instance.NetworkInfo) txn.Op {
+	return txn.Op{
+		C:      st.networks.Name,
+		Id:     info.Name,
+		Assert: txn.DocMissing,
+		Insert: &networkDoc{
+			Name:       info.Name,
+			ProviderId: info.ProviderId,
+			CIDR:       info.CIDR,
+			VLANTag:    info.VLANTag,
+		},
+	}
+}
+
+// AddNetwork adds a new network to the environment. It fails if a
+// network with the same name already exists.
+func (st *State) AddNetwork(info instance.NetworkInfo) (n *Network, err error) {
+	defer utils.ErrorContextf(&err, "cannot add network %q", info.Name)
+	if err := validateNetworkInfo(info); err != nil {
+		return nil, err
+	}
+	ops := []txn.Op{addNetworkOp(st, info)}
+	switch err := st.runTransaction(ops); err {
+	case txn.ErrAborted:
+		return nil, fmt.Errorf("network already exists")
+	case nil:
+		return st.Network(info.Name)
+	default:
+		return nil, err
+	}
+}
+
+// Network returns the network with the given name.
+func (st *State) Network(name string) (*Network, error) {
+	doc := &networkDoc{}
+	err := st.networks.FindId(name).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("network %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get network %q: %v", name, err)
+	}
+	return newNetwork(st, doc), nil
+}
+
+// AllNetworks returns all the networks known to the environment.
+func (st *State) AllNetworks() (networks []*Network, err error) {
+	docs := []networkDoc{}
+	err = st.networks.Find(nil).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get all networks: %v", err)
+	}
+	for i := range docs {
+		networks = append(networks, newNetwork(st, &docs[i]))
+	}
+	return networks, nil
+}
+
+// networkInterfaceDoc represents a network interface of a machine.
+type networkInterfaceDoc struct {
+	Id            string `bson:"_id"`
+	MachineId     string
+	MACAddress    string
+	InterfaceName string
+	NetworkName   string
+	Address       string
+	IsVirtual     bool
+}
+
+// NetworkInterface represents a network interface of a machine,
+// connecting it to a network.
+type NetworkInterface struct {
+	st  *State
+	doc networkInterfaceDoc
+}
+
+func newNetworkInterface(st *State, doc *networkInterfaceDoc) *NetworkInterface {
+	return &NetworkInterface{st: st, doc: *doc}
+}
+
+// MachineId returns the id of the machine the interface belongs to.
+func (ni *NetworkInterface) MachineId() string {
+	return ni.doc.MachineId
+}
+
+// MACAddress returns the interface's hardware MAC address.
+func (ni *NetworkInterface) MACAddress() string {
+	return ni.doc.MACAddress
+}
+
+// InterfaceName returns the OS-specific network device name.
+func (ni *NetworkInterface) InterfaceName() string {
+	return ni.doc.InterfaceName
+}
+
+// NetworkName returns the name of the network the interface is
+// connected to.
+func (ni *NetworkInterface) NetworkName() string {
+	return ni.doc.NetworkName
+}
+
+// Address returns the address of the machine on the interface's
+// network, or the empty string if it is not known.
+func (ni *NetworkInterface) Address() string {
+	return ni.doc.Address
+}
+
+// IsVirtual returns whether the interface is a virtual device.
+func (ni *NetworkInterface) IsVirtual() bool {
+	return ni.doc.IsVirtual
+}
+
+// Info returns the interface's details.
+func (ni *NetworkInterface) Info() instance.InterfaceInfo {
+	return instance.InterfaceInfo{
+		MACAddress:    ni.doc.MACAddress,
+		InterfaceName: ni.doc.InterfaceName,
+		NetworkName:   ni.doc.NetworkName,
+		Address:       ni.doc.Address,
+		IsVirtual:     ni.doc.IsVirtual,
+	}
+}
+
+// networkInterfaceId returns the document id of the interface with
+// the given name on the given machine.
+func networkInterfaceId(machineId, interfaceName string) string {
+	return machineId + ":" + interfaceName
+}
+
+// addNetworkInterfaceOps returns the operations needed to add the
+// given interface to the machine. The interface's network must exist.
+func addNetworkInterfaceOps(st *State, machineId string, info instance.InterfaceInfo) []txn.Op {
+	return []txn.Op{{
+		C:      st.networks.Name,
+		Id:     info.NetworkName,
+		Assert: txn.DocExists,
+	}, {
+		C:      st.networkInterfaces.Name,
+		Id:     networkInterfaceId(machineId, info.InterfaceName),
+		Assert: txn.DocMissing,
+		Insert: &networkInterfaceDoc{
+			Id:            networkInterfaceId(machineId, info.InterfaceName),
+			MachineId:     machineId,
+			MACAddress:    info.MACAddress,
+			InterfaceName: info.InterfaceName,
+			NetworkName:   info.NetworkName,
+			Address:       info.Address,
+			IsVirtual:     info.IsVirtual,
+		},
+	}}
+}
+
+func validateInterfaceInfo(info instance.InterfaceInfo) error {
+	if info.InterfaceName == "" {
+		return fmt.Errorf("interface name is empty")
+	}
+	if info.MACAddress == "" {
+		return fmt.Errorf("MAC address is empty")
+	}
+	if !names.IsNetwork(info.NetworkName) {
+		return fmt.Errorf("invalid network name %q", info.NetworkName)
+	}
+	return nil
+}
+
+// removeNetworkInterfacesOps returns the operations needed to remove
+// all the network interfaces of the machine with the given id.
+func removeNetworkInterfacesOps(st *State, machineId string) ([]txn.Op, error) {
+	var docs []networkInterfaceDoc
+	err := st.networkInterfaces.Find(D{{"machineid", machineId}}).Select(D{{"_id", 1}}).All(&docs)
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]txn.Op, len(docs))
+	for i, doc := range docs {
+		ops[i] = txn.Op{
+			C:      st.networkInterfaces.Name,
+			Id:     doc.Id,
+			Remove: true,
+		}
+	}
+	return ops, nil
+}
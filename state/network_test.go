@@ -0,0 +1,251 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type NetworkSuite struct {
+	ConnSuite
+	machine *state.Machine
+}
+
+var _ = gc.Suite(&NetworkSuite{})
+
+var (
+	storageNetwork = instance.NetworkInfo{
+		Name:       "storage",
+		ProviderId: "vlan42",
+		CIDR:       "10.0.42.0/24",
+		VLANTag:    42,
+	}
+	publicNetwork = instance.NetworkInfo{
+		Name:       "public",
+		ProviderId: "net1",
+		CIDR:       "0.1.2.0/24",
+	}
+	machineInterfaces = []instance.InterfaceInfo{{
+		MACAddress:    "aa:bb:cc:dd:ee:f0",
+		InterfaceName: "eth0",
+		NetworkName:   "public",
+		Address:       "0.1.2.3",
+	}, {
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "eth0.42",
+		NetworkName:   "storage",
+		Address:       "10.0.42.3",
+		IsVirtual:     true,
+	}}
+)
+
+func (s *NetworkSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *NetworkSuite) TestAddNetwork(c *gc.C) {
+	_, err := s.State.Network("storage")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	n, err := s.State.AddNetwork(storageNetwork)
+	c.Assert(err, gc.IsNil)
+	c.Assert(n.Name(), gc.Equals, "storage")
+	c.Assert(n.ProviderId(), gc.Equals, "vlan42")
+	c.Assert(n.CIDR(), gc.Equals, "10.0.42.0/24")
+	c.Assert(n.VLANTag(), gc.Equals, 42)
+	c.Assert(n.IsVLAN(), jc.IsTrue)
+	c.Assert(n.Info(), gc.DeepEquals, storageNetwork)
+
+	_, err = s.State.AddNetwork(storageNetwork)
+	c.Assert(err, gc.ErrorMatches, `cannot add network "storage": network already exists`)
+
+	_, err = s.State.AddNetwork(instance.NetworkInfo{Name: "bad name"})
+	c.Assert(err, gc.ErrorMatches, `cannot add network "bad name": invalid network name "bad name"`)
+	_, err = s.State.AddNetwork(instance.NetworkInfo{Name: "vlan", VLANTag: 4095})
+	c.Assert(err, gc.ErrorMatches, `cannot add network "vlan": invalid VLAN tag 4095: must be between 0 and 4094`)
+
+	_, err = s.State.AddNetwork(publicNetwork)
+	c.Assert(err, gc.IsNil)
+	networks, err := s.State.AllNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(networks, gc.HasLen, 2)
+	c.Assert(networks[0].Info(), gc.DeepEquals, publicNetwork)
+	c.Assert(networks[1].Info(), gc.DeepEquals, storageNetwork)
+}
+
+func (s *NetworkSuite) TestAddNetworkInterface(c *gc.C) {
+	_, err := s.machine.AddNetworkInterface(machineInterfaces[1])
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth0.42" to machine 0: network "storage" not found`)
+
+	_, err = s.State.AddNetwork(storageNetwork)
+	c.Assert(err, gc.IsNil)
+	iface, err := s.machine.AddNetworkInterface(machineInterfaces[1])
+	c.Assert(err, gc.IsNil)
+	c.Assert(iface.MachineId(), gc.Equals, "0")
+	c.Assert(iface.InterfaceName(), gc.Equals, "eth0.42")
+	c.Assert(iface.MACAddress(), gc.Equals, "aa:bb:cc:dd:ee:f1")
+	c.Assert(iface.NetworkName(), gc.Equals, "storage")
+	c.Assert(iface.Address(), gc.Equals, "10.0.42.3")
+	c.Assert(iface.IsVirtual(), jc.IsTrue)
+
+	_, err = s.machine.AddNetworkInterface(machineInterfaces[1])
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth0.42" to machine 0: interface already exists`)
+	_, err = s.machine.AddNetworkInterface(instance.InterfaceInfo{InterfaceName: "eth1", NetworkName: "storage"})
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth1" to machine 0: MAC address is empty`)
+}
+
+func (s *NetworkSuite) TestSetInstanceInfo(c *gc.C) {
+	_, err := s.State.AddNetwork(publicNetwork)
+	c.Assert(err, gc.IsNil)
+	networks := []instance.NetworkInfo{publicNetwork, storageNetwork}
+	err = s.machine.SetInstanceInfo("umbrella/0", "fake_nonce", nil, networks, machineInterfaces)
+	c.Assert(err, gc.IsNil)
+
+	id, err := s.machine.InstanceId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, instance.Id("umbrella/0"))
+	all, err := s.State.AllNetworks()
+	c.Assert(err, gc.IsNil)
+	c.Assert(all, gc.HasLen, 2)
+	ifaces, err := s.machine.NetworkInterfaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, gc.HasLen, 2)
+	for i, iface := range ifaces {
+		c.Check(iface.Info(), gc.DeepEquals, machineInterfaces[i])
+	}
+
+	// Removing the machine removes its interfaces.
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.Remove()
+	c.Assert(err, gc.IsNil)
+	ifaces, err = s.machine.NetworkInterfaces()
+	c.Assert(err, gc.IsNil)
+	c.Assert(ifaces, gc.HasLen, 0)
+}
+
+func (s *NetworkSuite) TestSetInstanceInfoInvalid(c *gc.C) {
+	bad := []instance.InterfaceInfo{{InterfaceName: "eth0", MACAddress: "aa:bb:cc:dd:ee:f0"}}
+	err := s.machine.SetInstanceInfo("umbrella/0", "fake_nonce", nil, nil, bad)
+	c.Assert(err, gc.ErrorMatches, `cannot add network interface "eth0": invalid network name ""`)
+	_, err = s.machine.InstanceId()
+	c.Assert(err, jc.Satisfies, state.IsNotProvisionedError)
+}
+
+func (s *NetworkSuite) TestEndpointBindings(c *gc.C) {
+	wordpress, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.EndpointBindings(), gc.HasLen, 0)
+	c.Assert(wordpress.EndpointBinding("db"), gc.Equals, "")
+
+	err = wordpress.SetEndpointBindings(map[string]string{"": "public", "db": "storage"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.EndpointBinding("db"), gc.Equals, "storage")
+	c.Assert(wordpress.EndpointBinding("url"), gc.Equals, "public")
+
+	err = wordpress.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.EndpointBindings(), gc.DeepEquals, map[string]string{"": "public", "db": "storage"})
+
+	err = wordpress.SetEndpointBindings(map[string]string{"database": "storage"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": charm "local:quantal/wordpress-3" has no relation "database"`)
+	err = wordpress.SetEndpointBindings(map[string]string{"db": "bad network"})
+	c.Assert(err, gc.ErrorMatches, `cannot set endpoint bindings for service "wordpress": invalid network name "bad network"`)
+
+	err = wordpress.SetEndpointBindings(nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(wordpress.EndpointBindings(), gc.HasLen, 0)
+}
+
+func (s *NetworkSuite) TestPrivateAddressFollowsBindings(c *gc.C) {
+	err := s.machine.SetInstanceInfo("umbrella/0", "fake_nonce", nil,
+		[]instance.NetworkInfo{publicNetwork, storageNetwork}, machineInterfaces)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetAddresses([]instance.Address{
+		instance.NewAddress("0.1.2.3"),
+	})
+	c.Assert(err, gc.IsNil)
+
+	wordpress, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddService("mysql", s.AddTestingCharm(c, "mysql"))
+	c.Assert(err, gc.IsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	addr, ok := unit.PrivateAddressOnNetwork("storage")
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "10.0.42.3")
+	_, ok = unit.PrivateAddressOnNetwork("dmz")
+	c.Assert(ok, jc.IsFalse)
+
+	eps, err := s.State.InferEndpoints([]string{"wordpress", "mysql"})
+	c.Assert(err, gc.IsNil)
+	rel, err := s.State.AddRelation(eps...)
+	c.Assert(err, gc.IsNil)
+	ru, err := rel.Unit(unit)
+	c.Assert(err, gc.IsNil)
+
+	// Without bindings, the machine's private address is used.
+	addr, ok = unit.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "0.1.2.3")
+	addr, ok = ru.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "0.1.2.3")
+
+	// Binding the db endpoint changes the relation address only.
+	err = wordpress.SetEndpointBindings(map[string]string{"db": "storage"})
+	c.Assert(err, gc.IsNil)
+	addr, ok = unit.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "0.1.2.3")
+	addr, ok = ru.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "10.0.42.3")
+
+	// The default binding applies to the unit's address.
+	err = wordpress.SetEndpointBindings(map[string]string{"": "storage"})
+	c.Assert(err, gc.IsNil)
+	addr, ok = unit.PrivateAddress()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "10.0.42.3")
+}
+
+func (s *NetworkSuite) TestPrivateAddressOnNetworkUsesCIDR(c *gc.C) {
+	ifaces := []instance.InterfaceInfo{{
+		MACAddress:    "aa:bb:cc:dd:ee:f1",
+		InterfaceName: "eth1",
+		NetworkName:   "storage",
+	}}
+	err := s.machine.SetInstanceInfo("umbrella/0", "fake_nonce", nil,
+		[]instance.NetworkInfo{storageNetwork}, ifaces)
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetAddresses([]instance.Address{
+		instance.NewAddress("10.0.3.7"),
+		instance.NewAddress("10.0.42.7"),
+	})
+	c.Assert(err, gc.IsNil)
+
+	wordpress, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(s.machine)
+	c.Assert(err, gc.IsNil)
+
+	addr, ok := unit.PrivateAddressOnNetwork("storage")
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(addr, gc.Equals, "10.0.42.7")
+}
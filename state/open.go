@@ -65,6 +65,27 @@ func DefaultDialOpts() DialOpts {
 // It returns unauthorizedError if access is unauthorized.
 func Open(info *Info, opts DialOpts) (*State, error) {
 	logger.Infof("opening state; mongo addresses: %q; entity %q", info.Addrs, info.Tag)
+	di, err := DialInfo(info, opts)
+	if err != nil {
+		return nil, err
+	}
+	session, err := mgo.DialWithInfo(di)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("connection established")
+	st, err := newState(session, info)
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return st, nil
+}
+
+// DialInfo returns information on how to dial
+// the state's mongo server with the given info
+// and dial options.
+func DialInfo(info *Info, opts DialOpts) (*mgo.DialInfo, error) {
 	if len(info.Addrs) == 0 {
 		return nil, stderrors.New("no mongo addresses")
 	}
@@ -94,21 +115,11 @@ func Open(info *Info, opts DialOpts) (*State, error) {
 		}
 		return cc, nil
 	}
-	session, err := mgo.DialWithInfo(&mgo.DialInfo{
+	return &mgo.DialInfo{
 		Addrs:   info.Addrs,
 		Timeout: opts.Timeout,
 		Dial:    dial,
-	})
-	if err != nil {
-		return nil, err
-	}
-	logger.Infof("connection established")
-	st, err := newState(session, info)
-	if err != nil {
-		session.Close()
-		return nil, err
-	}
-	return st, nil
+	}, nil
 }
 
 // Initialize sets up an initial empty state and returns it.
@@ -167,6 +178,14 @@ var indexes = []struct {
 	{"units", []string{"principal"}},
 	{"units", []string{"machineid"}},
 	{"users", []string{"name"}},
+	{"actions", []string{"unit"}},
+	{"storageinstances", []string{"unit"}},
+	{"networkinterfaces", []string{"machineid"}},
+	{"statushistory", []string{"entityid", "-updated"}},
+	{"auditlog", []string{"time"}},
+	{"auditlog", []string{"tag", "time"}},
+	{"metrics", []string{"service", "created"}},
+	{"metrics", []string{"unit", "created"}},
 }
 
 // The capped collection used for transaction logs defaults to 10MB.
@@ -177,6 +196,21 @@ var (
 	logSizeTests = 1000000
 )
 
+// The capped collection holding agent log records defaults to 100MB,
+// and is similarly reduced in tests.
+var (
+	agentLogSize      = 100000000
+	agentLogSizeTests = 1000000
+)
+
+// The capped collection holding the status history of machines and
+// units defaults to 50MB, and is similarly reduced in tests. Entries
+// older than the pruner's age limit are removed before that.
+var (
+	statusHistorySize      = 50000000
+	statusHistorySizeTests = 1000000
+)
+
 func maybeUnauthorized(err error, msg string) error {
 	if err == nil {
 		return nil
@@ -214,6 +248,12 @@ func newState(session *mgo.Session, info *Info) (*State, error) {
 		if err := pdb.Login(info.Tag, info.Password); err != nil {
 			return nil, maybeUnauthorized(err, fmt.Sprintf("cannot log in to presence database as %q", info.Tag))
 		}
+		// State server agents also have administrative access,
+		// which they use to manage the replica set; other
+		// agents do not, so failure here is not an error.
+		if err := session.DB("admin").Login(info.Tag, info.Password); err != nil {
+			logger.Debugf("cannot log in to admin database as %q: %v", info.Tag, err)
+		}
 	} else if info.Password != "" {
 		admin := session.DB("admin")
 		if err := admin.Login("admin", info.Password); err != nil {
@@ -221,26 +261,39 @@ func newState(session *mgo.Session, info *Info) (*State, error) {
 		}
 	}
 	st := &State{
-		info:           info,
-		db:             db,
-		environments:   db.C("environments"),
-		charms:         db.C("charms"),
-		machines:       db.C("machines"),
-		containerRefs:  db.C("containerRefs"),
-		instanceData:   db.C("instanceData"),
-		relations:      db.C("relations"),
-		relationScopes: db.C("relationscopes"),
-		services:       db.C("services"),
-		minUnits:       db.C("minunits"),
-		settings:       db.C("settings"),
-		settingsrefs:   db.C("settingsrefs"),
-		constraints:    db.C("constraints"),
-		units:          db.C("units"),
-		users:          db.C("users"),
-		presence:       pdb.C("presence"),
-		cleanups:       db.C("cleanups"),
-		annotations:    db.C("annotations"),
-		statuses:       db.C("statuses"),
+		info:              info,
+		db:                db,
+		environments:      db.C("environments"),
+		charms:            db.C("charms"),
+		machines:          db.C("machines"),
+		containerRefs:     db.C("containerRefs"),
+		instanceData:      db.C("instanceData"),
+		relations:         db.C("relations"),
+		relationScopes:    db.C("relationscopes"),
+		services:          db.C("services"),
+		minUnits:          db.C("minunits"),
+		settings:          db.C("settings"),
+		settingsrefs:      db.C("settingsrefs"),
+		constraints:       db.C("constraints"),
+		units:             db.C("units"),
+		users:             db.C("users"),
+		presence:          pdb.C("presence"),
+		cleanups:          db.C("cleanups"),
+		annotations:       db.C("annotations"),
+		statuses:          db.C("statuses"),
+		statusHistory:     db.C("statushistory"),
+		actions:           db.C("actions"),
+		storageInstances:  db.C("storageinstances"),
+		logs:              db.C("logs"),
+		networks:          db.C("networks"),
+		networkInterfaces: db.C("networkinterfaces"),
+		upgradeInfo:       db.C("upgradeinfo"),
+		blocks:            db.C("blocks"),
+		auditLog:          db.C("auditlog"),
+		metrics:           db.C("metrics"),
+		scaling:           db.C("scaling"),
+		leadership:        db.C("leadership"),
+		stateServers:      db.C("stateServers"),
 	}
 	log := db.C("txns.log")
 	logInfo := mgo.CollectionInfo{Capped: true, MaxBytes: logSize}
@@ -250,6 +303,16 @@ func newState(session *mgo.Session, info *Info) (*State, error) {
 	if err != nil && err.Error() != "collection already exists" {
 		return nil, maybeUnauthorized(err, "cannot create log collection")
 	}
+	agentLogInfo := mgo.CollectionInfo{Capped: true, MaxBytes: agentLogSize}
+	err = st.logs.Create(&agentLogInfo)
+	if err != nil && err.Error() != "collection already exists" {
+		return nil, maybeUnauthorized(err, "cannot create agent log collection")
+	}
+	statusHistoryInfo := mgo.CollectionInfo{Capped: true, MaxBytes: statusHistorySize}
+	err = st.statusHistory.Create(&statusHistoryInfo)
+	if err != nil && err.Error() != "collection already exists" {
+		return nil, maybeUnauthorized(err, "cannot create status history collection")
+	}
 	st.runner = txn.NewRunner(db.C("txns"))
 	st.runner.ChangeLog(db.C("txns.log"))
 	st.watcher = watcher.New(db.C("txns.log"))
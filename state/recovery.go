@@ -0,0 +1,228 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/utils"
+)
+
+// RecoveryPolicy describes what happens to a service's units when the
+// machine they are deployed to fails.
+type RecoveryPolicy string
+
+const (
+	// RecoveryManual leaves the units on the failed machine, for the
+	// operator to deal with.
+	RecoveryManual RecoveryPolicy = "manual"
+
+	// RecoveryAuto moves the units to a replacement machine.
+	RecoveryAuto RecoveryPolicy = "auto"
+)
+
+// ParseRecoveryPolicy returns the recovery policy named by s.
+func ParseRecoveryPolicy(s string) (RecoveryPolicy, error) {
+	switch policy := RecoveryPolicy(s); policy {
+	case RecoveryManual, RecoveryAuto:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid recovery policy %q", s)
+}
+
+// RecoveryPolicy returns the service's recovery policy.
+func (s *Service) RecoveryPolicy() RecoveryPolicy {
+	if s.doc.RecoveryPolicy == "" {
+		return RecoveryManual
+	}
+	return s.doc.RecoveryPolicy
+}
+
+// SetRecoveryPolicy changes the service's recovery policy.
+func (s *Service) SetRecoveryPolicy(policy RecoveryPolicy) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set recovery policy for service %q", s)
+	if _, err := ParseRecoveryPolicy(string(policy)); err != nil {
+		return err
+	}
+	ops := []txn.Op{{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: isAliveDoc,
+		Update: D{{"$set", D{{"recoverypolicy", policy}}}},
+	}}
+	if err := s.st.runTransaction(ops); err != nil {
+		return onAbort(err, errors.New("service is no longer alive"))
+	}
+	s.doc.RecoveryPolicy = policy
+	return nil
+}
+
+// RecoverMachine marks the machine as failed, for the given reason,
+// and moves the alive principal units of its services whose recovery
+// policy is RecoveryAuto to a new machine with the same series,
+// constraints and jobs. It returns the new machine, or nil if there
+// were no units to move. Units of other services are left in place.
+// State servers and containers are never recovered.
+//
+// The replacement machine is recorded on the failed machine in the
+// same transaction that adds it, so if recovery is interrupted, or
+// attempted concurrently, calling RecoverMachine again moves any
+// remaining units to the same replacement.
+func (st *State) RecoverMachine(m *Machine, reason string) (replacement *Machine, err error) {
+	defer utils.ErrorContextf(&err, "cannot recover machine %v", m)
+	for _, job := range m.Jobs() {
+		if job == JobManageState || job == JobManageEnviron {
+			return nil, errors.New("machine is a state server")
+		}
+	}
+	if m.ContainerType() != "" {
+		return nil, errors.New("machine is a container")
+	}
+	if m.Life() != Alive {
+		return nil, errors.New("machine is not alive")
+	}
+	units, err := st.autoRecoveredUnits(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(units) == 0 {
+		return nil, nil
+	}
+	replacement, err = st.replacementMachine(m)
+	if err != nil {
+		return nil, err
+	}
+	info := fmt.Sprintf("machine failed: %s", reason)
+	data := params.StatusData{"replacement": replacement.Id()}
+	if err := m.SetStatus(params.StatusError, info, data); err != nil {
+		return nil, err
+	}
+	for _, u := range units {
+		if err := u.moveToMachine(m, replacement); err != nil {
+			return nil, err
+		}
+	}
+	return replacement, nil
+}
+
+// replacementMachine returns the machine to which the units of the
+// failed machine m are moved, adding it if it has not been added
+// already.
+func (st *State) replacementMachine(m *Machine) (*Machine, error) {
+	for i := 0; i < 3; i++ {
+		if err := m.Refresh(); err != nil {
+			return nil, err
+		}
+		if m.Life() != Alive {
+			return nil, errors.New("machine is not alive")
+		}
+		if m.doc.ReplacementId != "" {
+			replacement, err := st.Machine(m.doc.ReplacementId)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get replacement machine: %v", err)
+			}
+			return replacement, nil
+		}
+		cons, err := m.Constraints()
+		if err != nil {
+			return nil, err
+		}
+		mdoc, ops, err := st.newMachineOps(&AddMachineParams{
+			Series:      m.Series(),
+			Constraints: cons,
+			Jobs:        m.Jobs(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot add replacement machine: %v", err)
+		}
+		ops = append(ops, txn.Op{
+			C:      st.machines.Name,
+			Id:     m.doc.Id,
+			Assert: append(isAliveDoc, D{{"replacementid", D{{"$exists", false}}}}...),
+			Update: D{{"$set", D{{"replacementid", mdoc.Id}}}},
+		})
+		if err := st.runTransaction(ops); err == nil {
+			replacement := newMachine(st, mdoc)
+			// Refresh to pick the txn-revno.
+			if err := replacement.Refresh(); err != nil {
+				return nil, err
+			}
+			return replacement, nil
+		} else if err != txn.ErrAborted {
+			return nil, err
+		}
+	}
+	return nil, ErrExcessiveContention
+}
+
+// autoRecoveredUnits returns the alive principal units assigned to m
+// whose services have the RecoveryAuto policy.
+func (st *State) autoRecoveredUnits(m *Machine) ([]*Unit, error) {
+	units, err := m.Units()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[string]RecoveryPolicy)
+	var recovered []*Unit
+	for _, u := range units {
+		if !u.IsPrincipal() || u.Life() != Alive {
+			continue
+		}
+		policy, ok := policies[u.ServiceName()]
+		if !ok {
+			svc, err := u.Service()
+			if err != nil {
+				return nil, err
+			}
+			policy = svc.RecoveryPolicy()
+			policies[u.ServiceName()] = policy
+		}
+		if policy == RecoveryAuto {
+			recovered = append(recovered, u)
+		}
+	}
+	return recovered, nil
+}
+
+// moveToMachine reassigns the unit from one machine to another,
+// forgetting the addresses it had on the old one. It does nothing if
+// the unit has already been moved.
+func (u *Unit) moveToMachine(from, to *Machine) error {
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: append(isAliveDoc, D{{"machineid", from.Id()}}...),
+		Update: D{{"$set", D{
+			{"machineid", to.Id()},
+			{"publicaddress", ""},
+			{"privateaddress", ""},
+		}}},
+	}, {
+		C:      u.st.machines.Name,
+		Id:     from.Id(),
+		Assert: txn.DocExists,
+		Update: D{{"$pull", D{{"principals", u.doc.Name}}}},
+	}, {
+		C:      u.st.machines.Name,
+		Id:     to.Id(),
+		Assert: isAliveDoc,
+		Update: D{{"$addToSet", D{{"principals", u.doc.Name}}}, {"$set", D{{"clean", false}}}},
+	}}
+	if err := u.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := u.Refresh(); err == nil && u.doc.MachineId == to.Id() {
+			return nil
+		}
+		return fmt.Errorf("cannot move unit %q to machine %v: unit or machine has changed", u, to)
+	} else if err != nil {
+		return fmt.Errorf("cannot move unit %q to machine %v: %v", u, to, err)
+	}
+	u.doc.MachineId = to.Id()
+	u.doc.PublicAddress = ""
+	u.doc.PrivateAddress = ""
+	return nil
+}
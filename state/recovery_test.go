@@ -0,0 +1,206 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type RecoverySuite struct {
+	ConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&RecoverySuite{})
+
+func (s *RecoverySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *RecoverySuite) TestParseRecoveryPolicy(c *gc.C) {
+	policy, err := state.ParseRecoveryPolicy("auto")
+	c.Assert(err, gc.IsNil)
+	c.Assert(policy, gc.Equals, state.RecoveryAuto)
+	policy, err = state.ParseRecoveryPolicy("manual")
+	c.Assert(err, gc.IsNil)
+	c.Assert(policy, gc.Equals, state.RecoveryManual)
+	_, err = state.ParseRecoveryPolicy("sometimes")
+	c.Assert(err, gc.ErrorMatches, `invalid recovery policy "sometimes"`)
+}
+
+func (s *RecoverySuite) TestSetRecoveryPolicy(c *gc.C) {
+	c.Assert(s.service.RecoveryPolicy(), gc.Equals, state.RecoveryManual)
+	err := s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.RecoveryPolicy(), gc.Equals, state.RecoveryAuto)
+
+	svc, err := s.State.Service("wordpress")
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.RecoveryPolicy(), gc.Equals, state.RecoveryAuto)
+
+	err = s.service.SetRecoveryPolicy("sometimes")
+	c.Assert(err, gc.ErrorMatches, `cannot set recovery policy for service "wordpress": invalid recovery policy "sometimes"`)
+}
+
+func (s *RecoverySuite) TestSetRecoveryPolicyServiceNotAlive(c *gc.C) {
+	_, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.ErrorMatches, `cannot set recovery policy for service "wordpress": service is no longer alive`)
+}
+
+func (s *RecoverySuite) addMachineWithUnit(c *gc.C, svc *state.Service) (*state.Machine, *state.Unit) {
+	m, err := s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:      "quantal",
+		Constraints: constraints.MustParse("mem=4G"),
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+	})
+	c.Assert(err, gc.IsNil)
+	u, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = u.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+	return m, u
+}
+
+func (s *RecoverySuite) TestRecoverMachine(c *gc.C) {
+	err := s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.IsNil)
+	m, u := s.addMachineWithUnit(c, s.service)
+	manual, err := s.State.AddService("mysql", s.AddTestingCharm(c, "mysql"))
+	c.Assert(err, gc.IsNil)
+	manualUnit, err := manual.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = manualUnit.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+
+	replacement, err := s.State.RecoverMachine(m, "instance missing")
+	c.Assert(err, gc.IsNil)
+	c.Assert(replacement, gc.NotNil)
+	c.Assert(replacement.Id(), gc.Not(gc.Equals), m.Id())
+	c.Assert(replacement.Series(), gc.Equals, "quantal")
+	c.Assert(replacement.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobHostUnits})
+	cons, err := replacement.Constraints()
+	c.Assert(err, gc.IsNil)
+	c.Assert(cons, gc.DeepEquals, constraints.MustParse("mem=4G"))
+
+	status, info, data, err := m.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusError)
+	c.Assert(info, gc.Equals, "machine failed: instance missing")
+	c.Assert(data, gc.DeepEquals, params.StatusData{"replacement": replacement.Id()})
+
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	id, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, replacement.Id())
+	id, err = manualUnit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, m.Id())
+
+	units, err := m.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+	c.Assert(units[0].Name(), gc.Equals, manualUnit.Name())
+	units, err = replacement.Units()
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.HasLen, 1)
+	c.Assert(units[0].Name(), gc.Equals, u.Name())
+
+	// With nothing left to move, no further machine is added.
+	replacement, err = s.State.RecoverMachine(m, "instance missing")
+	c.Assert(err, gc.IsNil)
+	c.Assert(replacement, gc.IsNil)
+}
+
+func (s *RecoverySuite) TestRecoverMachineReusesReplacement(c *gc.C) {
+	err := s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.IsNil)
+	m, _ := s.addMachineWithUnit(c, s.service)
+	replacement, err := s.State.RecoverMachine(m, "agent down")
+	c.Assert(err, gc.IsNil)
+	c.Assert(replacement, gc.NotNil)
+
+	// A unit left behind by an interrupted recovery is moved to the
+	// same replacement.
+	u, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = u.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+	again, err := s.State.RecoverMachine(m, "agent down")
+	c.Assert(err, gc.IsNil)
+	c.Assert(again, gc.NotNil)
+	c.Assert(again.Id(), gc.Equals, replacement.Id())
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	id, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, replacement.Id())
+
+	machines, err := s.State.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 2)
+}
+
+func (s *RecoverySuite) TestRecoverMachineConcurrent(c *gc.C) {
+	err := s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.IsNil)
+	m, u := s.addMachineWithUnit(c, s.service)
+	var other *state.Machine
+	defer state.SetBeforeHooks(c, s.State, func() {
+		dup, err := s.State.Machine(m.Id())
+		c.Assert(err, gc.IsNil)
+		other, err = s.State.RecoverMachine(dup, "agent down")
+		c.Assert(err, gc.IsNil)
+		c.Assert(other, gc.NotNil)
+	}).Check()
+
+	replacement, err := s.State.RecoverMachine(m, "agent down")
+	c.Assert(err, gc.IsNil)
+	c.Assert(replacement, gc.NotNil)
+	c.Assert(replacement.Id(), gc.Equals, other.Id())
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	id, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, replacement.Id())
+
+	machines, err := s.State.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 2)
+}
+
+func (s *RecoverySuite) TestRecoverMachineManualPolicy(c *gc.C) {
+	m, u := s.addMachineWithUnit(c, s.service)
+	replacement, err := s.State.RecoverMachine(m, "agent down")
+	c.Assert(err, gc.IsNil)
+	c.Assert(replacement, gc.IsNil)
+
+	id, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, m.Id())
+	status, _, _, err := m.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusPending)
+	machines, err := s.State.AllMachines()
+	c.Assert(err, gc.IsNil)
+	c.Assert(machines, gc.HasLen, 1)
+}
+
+func (s *RecoverySuite) TestRecoverMachineStateServer(c *gc.C) {
+	m, err := s.State.AddMachine("quantal", state.JobManageEnviron, state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.RecoverMachine(m, "agent down")
+	c.Assert(err, gc.ErrorMatches, `cannot recover machine 0: machine is a state server`)
+}
@@ -39,8 +39,17 @@ func (ru *RelationUnit) Endpoint() Endpoint {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// When the unit's service binds the relation's endpoint to a network,
+// the address on that network is preferred.
 func (ru *RelationUnit) PrivateAddress() (string, bool) {
-	return ru.unit.PrivateAddress()
+	if svc, err := ru.unit.Service(); err == nil {
+		if network := svc.EndpointBinding(ru.endpoint.Name); network != "" {
+			if addr, ok := ru.unit.PrivateAddressOnNetwork(network); ok {
+				return addr, true
+			}
+		}
+	}
+	return ru.unit.privateAddress()
 }
 
 // ErrCannotEnterScope indicates that a relation unit failed to enter its scope
@@ -0,0 +1,321 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/utils"
+)
+
+// ScalingPolicy describes how the number of units of a service is
+// adjusted automatically. It is independent of the service's MinUnits.
+type ScalingPolicy struct {
+	// MinUnits and MaxUnits bound the number of alive units.
+	MinUnits int
+	MaxUnits int
+
+	// Signal names the source of the number of units the service
+	// should have, in the form "<kind>:<argument>", for example
+	// "metric:load" or "schedule:08:00=4,20:00=2".
+	Signal string
+
+	// ScaleUp and ScaleDown hold the values a metric signal must
+	// rise above, or fall below, for a unit to be added or removed.
+	ScaleUp   float64
+	ScaleDown float64
+
+	// Cooldown holds how long to wait after changing the number of
+	// units before changing it again.
+	Cooldown time.Duration
+}
+
+// SignalKind returns the kind and the argument of the policy's signal.
+func (p *ScalingPolicy) SignalKind() (kind, arg string) {
+	parts := strings.SplitN(p.Signal, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// Validate returns an error if the policy is not valid.
+func (p *ScalingPolicy) Validate() error {
+	switch {
+	case p.MinUnits < 0:
+		return fmt.Errorf("invalid minimum number of units %d", p.MinUnits)
+	case p.MaxUnits < 1 || p.MaxUnits < p.MinUnits:
+		return fmt.Errorf("invalid maximum number of units %d", p.MaxUnits)
+	case p.ScaleDown > p.ScaleUp:
+		return fmt.Errorf("scale down threshold %v is above scale up threshold %v", p.ScaleDown, p.ScaleUp)
+	case p.Cooldown < 0:
+		return fmt.Errorf("invalid cooldown %v", p.Cooldown)
+	}
+	kind, arg := p.SignalKind()
+	switch {
+	case kind == "" || arg == "":
+		return fmt.Errorf("invalid signal %q", p.Signal)
+	case kind == "schedule":
+		if _, err := ParseSchedule(arg); err != nil {
+			return err
+		}
+	case kind != "metric":
+		return fmt.Errorf("unknown signal kind %q", kind)
+	}
+	return nil
+}
+
+// ScheduleEntry holds the number of units a schedule signal asks for
+// from a time of day, given in minutes since midnight UTC.
+type ScheduleEntry struct {
+	Minute int
+	Units  int
+}
+
+// ParseSchedule parses the argument of a schedule signal, a
+// comma-separated list of "HH:MM=N" entries, returning its entries in
+// time order.
+func ParseSchedule(schedule string) ([]ScheduleEntry, error) {
+	var entries []ScheduleEntry
+	for _, field := range strings.Split(schedule, ",") {
+		parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid schedule entry %q", field)
+		}
+		t, err := time.Parse("15:04", parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule entry %q", field)
+		}
+		units, err := strconv.Atoi(parts[1])
+		if err != nil || units < 0 {
+			return nil, fmt.Errorf("invalid schedule entry %q", field)
+		}
+		entries = append(entries, ScheduleEntry{t.Hour()*60 + t.Minute(), units})
+	}
+	sort.Sort(byMinute(entries))
+	return entries, nil
+}
+
+type byMinute []ScheduleEntry
+
+func (b byMinute) Len() int           { return len(b) }
+func (b byMinute) Less(i, j int) bool { return b[i].Minute < b[j].Minute }
+func (b byMinute) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// ScalingDecision records a change made to the number of units of a
+// service by the scaler.
+type ScalingDecision struct {
+	Time   time.Time
+	From   int
+	To     int
+	Reason string
+}
+
+// maxScalingDecisions holds the number of decisions kept for each
+// service.
+const maxScalingDecisions = 20
+
+// scalingDoc holds the scaling policy of a service, and the most
+// recent decisions made by applying it, oldest first.
+type scalingDoc struct {
+	ServiceName string `bson:"_id"`
+	MinUnits    int
+	MaxUnits    int
+	Signal      string
+	ScaleUp     float64
+	ScaleDown   float64
+	Cooldown    time.Duration
+	Decisions   []ScalingDecision
+	TxnRevno    int64 `bson:"txn-revno"`
+}
+
+func (doc *scalingDoc) policy() ScalingPolicy {
+	return ScalingPolicy{
+		MinUnits:  doc.MinUnits,
+		MaxUnits:  doc.MaxUnits,
+		Signal:    doc.Signal,
+		ScaleUp:   doc.ScaleUp,
+		ScaleDown: doc.ScaleDown,
+		Cooldown:  doc.Cooldown,
+	}
+}
+
+// SetScalingPolicy sets the scaling policy of the service, keeping
+// the decisions made under any previous policy.
+func (s *Service) SetScalingPolicy(policy ScalingPolicy) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set scaling policy for service %q", s)
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if kind, metric := policy.SignalKind(); kind == "metric" {
+		// Only metrics declared by the charm are recorded.
+		ch, _, err := s.Charm()
+		if err != nil {
+			return err
+		}
+		if _, ok := ch.Meta().Metrics[metric]; !ok {
+			return fmt.Errorf("metric %q not declared by charm %q", metric, ch.URL())
+		}
+	}
+	serviceOp := txn.Op{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: isAliveDoc,
+	}
+	fields := D{
+		{"minunits", policy.MinUnits},
+		{"maxunits", policy.MaxUnits},
+		{"signal", policy.Signal},
+		{"scaleup", policy.ScaleUp},
+		{"scaledown", policy.ScaleDown},
+		{"cooldown", policy.Cooldown},
+	}
+	// Racing clients trying to create the document generate one
+	// failure, but the second attempt updates it.
+	for i := 0; i < 2; i++ {
+		var scalingOp txn.Op
+		if _, err := s.scalingDoc(); errors.IsNotFoundError(err) {
+			scalingOp = txn.Op{
+				C:      s.st.scaling.Name,
+				Id:     s.doc.Name,
+				Assert: txn.DocMissing,
+				Insert: &scalingDoc{
+					ServiceName: s.doc.Name,
+					MinUnits:    policy.MinUnits,
+					MaxUnits:    policy.MaxUnits,
+					Signal:      policy.Signal,
+					ScaleUp:     policy.ScaleUp,
+					ScaleDown:   policy.ScaleDown,
+					Cooldown:    policy.Cooldown,
+				},
+			}
+		} else if err != nil {
+			return err
+		} else {
+			scalingOp = txn.Op{
+				C:      s.st.scaling.Name,
+				Id:     s.doc.Name,
+				Assert: txn.DocExists,
+				Update: D{{"$set", fields}},
+			}
+		}
+		ops := []txn.Op{serviceOp, scalingOp}
+		if err := s.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+		if alive, err := isAlive(s.st.services, s.doc.Name); err != nil {
+			return err
+		} else if !alive {
+			return stderrors.New("service is no longer alive")
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// RemoveScalingPolicy stops the automatic scaling of the service, and
+// forgets the decisions made.
+func (s *Service) RemoveScalingPolicy() error {
+	ops := []txn.Op{scalingRemoveOp(s.st, s.doc.Name)}
+	if err := s.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot remove scaling policy for service %q: %v", s, err)
+	}
+	return nil
+}
+
+// ScalingPolicy returns the scaling policy of the service. It returns
+// an error that satisfies errors.IsNotFoundError if the service is
+// not scaled automatically.
+func (s *Service) ScalingPolicy() (*ScalingPolicy, error) {
+	doc, err := s.scalingDoc()
+	if err != nil {
+		return nil, err
+	}
+	policy := doc.policy()
+	return &policy, nil
+}
+
+// ScalingDecisions returns the most recent decisions made by applying
+// the service's scaling policy, oldest first.
+func (s *Service) ScalingDecisions() ([]ScalingDecision, error) {
+	doc, err := s.scalingDoc()
+	if err != nil {
+		return nil, err
+	}
+	for i := range doc.Decisions {
+		doc.Decisions[i].Time = doc.Decisions[i].Time.UTC()
+	}
+	return doc.Decisions, nil
+}
+
+// AddScalingDecision records a decision made by applying the
+// service's scaling policy, forgetting the oldest decisions if there
+// are too many.
+func (s *Service) AddScalingDecision(decision ScalingDecision) (err error) {
+	defer utils.ErrorContextf(&err, "cannot add scaling decision for service %q", s)
+	decision.Time = decision.Time.UTC()
+	for i := 0; i < 3; i++ {
+		doc, err := s.scalingDoc()
+		if err != nil {
+			return err
+		}
+		decisions := append(doc.Decisions, decision)
+		if len(decisions) > maxScalingDecisions {
+			decisions = decisions[len(decisions)-maxScalingDecisions:]
+		}
+		ops := []txn.Op{{
+			C:      s.st.scaling.Name,
+			Id:     s.doc.Name,
+			Assert: D{{"txn-revno", doc.TxnRevno}},
+			Update: D{{"$set", D{{"decisions", decisions}}}},
+		}}
+		if err := s.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+	}
+	return ErrExcessiveContention
+}
+
+func (s *Service) scalingDoc() (*scalingDoc, error) {
+	var doc scalingDoc
+	err := s.st.scaling.FindId(s.doc.Name).One(&doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("scaling policy for service %q", s)
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get scaling policy for service %q: %v", s, err)
+	}
+	return &doc, nil
+}
+
+// ScalingPolicies returns the scaling policies of all the services
+// that have one, keyed by service name.
+func (st *State) ScalingPolicies() (map[string]ScalingPolicy, error) {
+	var docs []scalingDoc
+	if err := st.scaling.Find(nil).All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get scaling policies: %v", err)
+	}
+	policies := make(map[string]ScalingPolicy, len(docs))
+	for _, doc := range docs {
+		policies[doc.ServiceName] = doc.policy()
+	}
+	return policies, nil
+}
+
+// scalingRemoveOp returns the operation required to remove the
+// scaling document of the service, which may not exist.
+func scalingRemoveOp(st *State, serviceName string) txn.Op {
+	return txn.Op{
+		C:      st.scaling.Name,
+		Id:     serviceName,
+		Remove: true,
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type ScalingSuite struct {
+	ConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&ScalingSuite{})
+
+func (s *ScalingSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.AddTestingCharm(c, "metered"))
+	c.Assert(err, gc.IsNil)
+}
+
+var validPolicy = state.ScalingPolicy{
+	MinUnits:  1,
+	MaxUnits:  5,
+	Signal:    "metric:requests",
+	ScaleUp:   0.8,
+	ScaleDown: 0.2,
+	Cooldown:  5 * time.Minute,
+}
+
+var scalingPolicyValidateTests = []struct {
+	about  string
+	change func(*state.ScalingPolicy)
+	err    string
+}{{
+	about:  "valid policy",
+	change: func(*state.ScalingPolicy) {},
+}, {
+	about:  "negative minimum",
+	change: func(p *state.ScalingPolicy) { p.MinUnits = -1 },
+	err:    "invalid minimum number of units -1",
+}, {
+	about:  "maximum below minimum",
+	change: func(p *state.ScalingPolicy) { p.MaxUnits = 0 },
+	err:    "invalid maximum number of units 0",
+}, {
+	about:  "inverted thresholds",
+	change: func(p *state.ScalingPolicy) { p.ScaleDown = 0.9 },
+	err:    "scale down threshold 0.9 is above scale up threshold 0.8",
+}, {
+	about:  "negative cooldown",
+	change: func(p *state.ScalingPolicy) { p.Cooldown = -time.Second },
+	err:    "invalid cooldown -1s",
+}, {
+	about:  "signal without argument",
+	change: func(p *state.ScalingPolicy) { p.Signal = "metric" },
+	err:    `invalid signal "metric"`,
+}, {
+	about:  "unknown signal kind",
+	change: func(p *state.ScalingPolicy) { p.Signal = "weather:rain" },
+	err:    `unknown signal kind "weather"`,
+}, {
+	about:  "valid schedule",
+	change: func(p *state.ScalingPolicy) { p.Signal = "schedule:20:00=2,08:00=4" },
+}, {
+	about:  "invalid schedule",
+	change: func(p *state.ScalingPolicy) { p.Signal = "schedule:20:00=2,8am=4" },
+	err:    `invalid schedule entry "8am=4"`,
+}}
+
+func (s *ScalingSuite) TestValidate(c *gc.C) {
+	for i, test := range scalingPolicyValidateTests {
+		c.Logf("test %d: %s", i, test.about)
+		policy := validPolicy
+		test.change(&policy)
+		err := policy.Validate()
+		if test.err == "" {
+			c.Check(err, gc.IsNil)
+		} else {
+			c.Check(err, gc.ErrorMatches, test.err)
+		}
+	}
+}
+
+func (s *ScalingSuite) TestSetScalingPolicy(c *gc.C) {
+	_, err := s.service.ScalingPolicy()
+	c.Assert(err, gc.ErrorMatches, `scaling policy for service "wordpress" not found`)
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	err = s.service.SetScalingPolicy(validPolicy)
+	c.Assert(err, gc.IsNil)
+	policy, err := s.service.ScalingPolicy()
+	c.Assert(err, gc.IsNil)
+	c.Assert(*policy, gc.Equals, validPolicy)
+
+	changed := validPolicy
+	changed.Signal = "schedule:08:00=4,20:00=2"
+	err = s.service.SetScalingPolicy(changed)
+	c.Assert(err, gc.IsNil)
+	policies, err := s.State.ScalingPolicies()
+	c.Assert(err, gc.IsNil)
+	c.Assert(policies, gc.DeepEquals, map[string]state.ScalingPolicy{"wordpress": changed})
+
+	err = s.service.RemoveScalingPolicy()
+	c.Assert(err, gc.IsNil)
+	_, err = s.service.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	err = s.service.RemoveScalingPolicy()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyInvalid(c *gc.C) {
+	policy := validPolicy
+	policy.MaxUnits = 0
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "wordpress": invalid maximum number of units 0`)
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyUndeclaredMetric(c *gc.C) {
+	policy := validPolicy
+	policy.Signal = "metric:load"
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "wordpress": metric "load" not declared by charm "local:quantal/metered-1"`)
+	_, err = s.service.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyUnknownSignalKind(c *gc.C) {
+	policy := validPolicy
+	policy.Signal = "weather:rain"
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "wordpress": unknown signal kind "weather"`)
+	_, err = s.service.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyInvalidSchedule(c *gc.C) {
+	policy := validPolicy
+	policy.Signal = "schedule:8am=4"
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "wordpress": invalid schedule entry "8am=4"`)
+	_, err = s.service.ScalingPolicy()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *ScalingSuite) TestParseSchedule(c *gc.C) {
+	entries, err := state.ParseSchedule("20:00=2, 08:30=4")
+	c.Assert(err, gc.IsNil)
+	c.Assert(entries, gc.DeepEquals, []state.ScheduleEntry{{Minute: 510, Units: 4}, {Minute: 1200, Units: 2}})
+	for _, schedule := range []string{"", "08:00", "8am=4", "08:00=-1", "08:00=four"} {
+		_, err := state.ParseSchedule(schedule)
+		c.Check(err, gc.ErrorMatches, `invalid schedule entry ".*"`)
+	}
+}
+
+func (s *ScalingSuite) TestSetScalingPolicyServiceNotAlive(c *gc.C) {
+	_, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetScalingPolicy(validPolicy)
+	c.Assert(err, gc.ErrorMatches, `cannot set scaling policy for service "wordpress": service is no longer alive`)
+}
+
+func (s *ScalingSuite) TestDestroyServiceRemovesPolicy(c *gc.C) {
+	err := s.service.SetScalingPolicy(validPolicy)
+	c.Assert(err, gc.IsNil)
+	err = s.service.Destroy()
+	c.Assert(err, gc.IsNil)
+	policies, err := s.State.ScalingPolicies()
+	c.Assert(err, gc.IsNil)
+	c.Assert(policies, gc.HasLen, 0)
+}
+
+func (s *ScalingSuite) TestAddScalingDecision(c *gc.C) {
+	err := s.service.AddScalingDecision(state.ScalingDecision{From: 1, To: 2})
+	c.Assert(err, gc.ErrorMatches, `cannot add scaling decision for service "wordpress": scaling policy for service "wordpress" not found`)
+
+	err = s.service.SetScalingPolicy(validPolicy)
+	c.Assert(err, gc.IsNil)
+	start := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+	var expect []state.ScalingDecision
+	for i := 0; i < 25; i++ {
+		decision := state.ScalingDecision{
+			Time:   start.Add(time.Duration(i) * time.Minute),
+			From:   i,
+			To:     i + 1,
+			Reason: fmt.Sprintf("reason %d", i),
+		}
+		err := s.service.AddScalingDecision(decision)
+		c.Assert(err, gc.IsNil)
+		expect = append(expect, decision)
+	}
+	decisions, err := s.service.ScalingDecisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(decisions, gc.DeepEquals, expect[5:])
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/bson"
@@ -42,7 +43,17 @@ type serviceDoc struct {
 	RelationCount int
 	Exposed       bool
 	MinUnits      int
-	TxnRevno      int64 `bson:"txn-revno"`
+	// StorageConstraints holds the storage constraints of the
+	// service, keyed by store name.
+	StorageConstraints map[string]constraints.Storage `bson:",omitempty"`
+	// EndpointBindings maps relation names to the networks their
+	// traffic should use. The binding for the empty relation name
+	// applies to all endpoints without a binding of their own.
+	EndpointBindings map[string]string `bson:",omitempty"`
+	// RecoveryPolicy holds what happens to the service's units when
+	// their machine fails; empty means RecoveryManual.
+	RecoveryPolicy RecoveryPolicy `bson:",omitempty"`
+	TxnRevno       int64          `bson:"txn-revno"`
 }
 
 func newService(st *State, doc *serviceDoc) *Service {
@@ -148,7 +159,11 @@ func (s *Service) destroyOps() ([]txn.Op, error) {
 		// asserts on relationcount and on each known relation, below.
 		return nil, errRefresh
 	}
-	ops := []txn.Op{minUnitsRemoveOp(s.st, s.doc.Name)}
+	ops := []txn.Op{
+		minUnitsRemoveOp(s.st, s.doc.Name),
+		scalingRemoveOp(s.st, s.doc.Name),
+	}
+	ops = append(ops, leadershipRemoveOps(s.st, s.doc.Name)...)
 	removeCount := 0
 	for _, rel := range rels {
 		relOps, isRemove, err := rel.destroyOps(s.doc.Name)
@@ -224,6 +239,7 @@ func (s *Service) removeOps(asserts D) []txn.Op {
 		Remove: true,
 	}}
 	ops = append(ops, removeConstraintsOp(s.st, s.globalKey()))
+	ops = append(ops, s.st.metricsCleanupOps("servicemetrics", "service", s.doc.Name)...)
 	return append(ops, annotationRemoveOp(s.st, s.globalKey()))
 }
 
@@ -260,6 +276,67 @@ func (s *Service) setExposed(exposed bool) (err error) {
 	return nil
 }
 
+// EndpointBindings returns the networks the service's endpoints are
+// bound to, keyed by relation name. The binding for the empty relation
+// name applies to all endpoints without a binding of their own.
+func (s *Service) EndpointBindings() map[string]string {
+	bindings := make(map[string]string, len(s.doc.EndpointBindings))
+	for name, network := range s.doc.EndpointBindings {
+		bindings[name] = network
+	}
+	return bindings
+}
+
+// EndpointBinding returns the network the named endpoint is bound to,
+// falling back to the service's default binding. It returns the empty
+// string if the endpoint is not bound to any network.
+func (s *Service) EndpointBinding(relationName string) string {
+	if network, ok := s.doc.EndpointBindings[relationName]; ok {
+		return network
+	}
+	return s.doc.EndpointBindings[""]
+}
+
+// SetEndpointBindings replaces the service's endpoint bindings. Each
+// key must be the name of a relation defined by the service's charm,
+// or the empty string to set the default binding; each value must be
+// a valid network name.
+func (s *Service) SetEndpointBindings(bindings map[string]string) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set endpoint bindings for service %q", s)
+	ch, _, err := s.Charm()
+	if err != nil {
+		return err
+	}
+	meta := ch.Meta()
+	for relName, network := range bindings {
+		if relName != "" && relName != "juju-info" {
+			_, provides := meta.Provides[relName]
+			_, requires := meta.Requires[relName]
+			_, peers := meta.Peers[relName]
+			if !provides && !requires && !peers {
+				return fmt.Errorf("charm %q has no relation %q", s.doc.CharmURL, relName)
+			}
+		}
+		if !names.IsNetwork(network) {
+			return fmt.Errorf("invalid network name %q", network)
+		}
+	}
+	if len(bindings) == 0 {
+		bindings = nil
+	}
+	ops := []txn.Op{{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: append(isAliveDoc, D{{"charmurl", s.doc.CharmURL}}...),
+		Update: D{{"$set", D{{"endpointbindings", bindings}}}},
+	}}
+	if err := s.st.runTransaction(ops); err != nil {
+		return onAbort(err, fmt.Errorf("service is not alive or its charm has changed"))
+	}
+	s.doc.EndpointBindings = bindings
+	return nil
+}
+
 // Charm returns the service's charm and whether units should upgrade to that
 // charm even if they are in an error state.
 func (s *Service) Charm() (ch *Charm, force bool, err error) {
@@ -351,11 +428,12 @@ func (s *Service) extraPeerRelations(newMeta *charm.Meta) map[string]charm.Relat
 func (s *Service) checkRelationsOps(ch *Charm, relations []*Relation) ([]txn.Op, error) {
 	asserts := make([]txn.Op, 0, len(relations))
 	// All relations must still exist and their endpoints are implemented by the charm.
+	var broken []string
 	for _, rel := range relations {
 		if ep, err := rel.Endpoint(s.doc.Name); err != nil {
 			return nil, err
 		} else if !ep.ImplementedBy(ch) {
-			return nil, fmt.Errorf("cannot upgrade service %q to charm %q: would break relation %q", s, ch, rel)
+			broken = append(broken, fmt.Sprintf("%q", rel))
 		}
 		asserts = append(asserts, txn.Op{
 			C:      s.st.relations.Name,
@@ -363,7 +441,13 @@ func (s *Service) checkRelationsOps(ch *Charm, relations []*Relation) ([]txn.Op,
 			Assert: txn.DocExists,
 		})
 	}
-	return asserts, nil
+	switch len(broken) {
+	case 0:
+		return asserts, nil
+	case 1:
+		return nil, fmt.Errorf("cannot upgrade service %q to charm %q: would break relation %s", s, ch, broken[0])
+	}
+	return nil, fmt.Errorf("cannot upgrade service %q to charm %q: would break relations %s", s, ch, strings.Join(broken, ", "))
 }
 
 // changeCharmOps returns the operations necessary to set a service's
@@ -374,7 +458,10 @@ func (s *Service) changeCharmOps(ch *Charm, force bool) ([]txn.Op, error) {
 	if err != nil {
 		return nil, err
 	}
-	newSettings := ch.Config().FilterSettings(oldSettings.Map())
+	newSettings, lost := ch.Config().MigrateSettings(oldSettings.Map())
+	if len(lost) > 0 {
+		logger.Infof("upgrading service %q to charm %q drops settings: %s", s, ch, strings.Join(lost, ", "))
+	}
 
 	// Create or replace service settings.
 	var settingsOp txn.Op
@@ -600,6 +687,11 @@ func (s *Service) addUnitOps(principalName string, asserts D) (string, []txn.Op,
 		cons := scons.WithFallbacks(econs)
 		ops = append(ops, createConstraintsOp(s.st, globalKey, cons))
 	}
+	storageOps, err := s.addUnitStorageOps(name)
+	if err != nil {
+		return "", nil, err
+	}
+	ops = append(ops, storageOps...)
 	return name, ops, nil
 }
 
@@ -656,8 +748,16 @@ func (s *Service) removeUnitOps(u *Unit, asserts D) ([]txn.Op, error) {
 	},
 		removeConstraintsOp(s.st, u.globalKey()),
 		removeStatusOp(s.st, u.globalKey()),
+		removeStatusOp(s.st, workloadGlobalKey(u.globalKey())),
 		annotationRemoveOp(s.st, u.globalKey()),
 	)
+	ops = append(ops, s.st.metricsCleanupOps("unitmetrics", "unit", u.doc.Name)...)
+	ops = append(ops, s.st.actionsCleanupOps(u.doc.Name)...)
+	storageOps, err := u.removeUnitStorageOps()
+	if err != nil {
+		return nil, err
+	}
+	ops = append(ops, storageOps...)
 	if u.doc.CharmURL != nil {
 		decOps, err := settingsDecRefOps(s.st, s.doc.Name, u.doc.CharmURL)
 		if errors.IsNotFoundError(err) {
@@ -267,6 +267,12 @@ var setCharmConfigTests = []struct {
 		startconfig: stringConfig,
 		startvalues: charm.Settings{"key": "value"},
 		endconfig:   floatConfig,
+	}, {
+		summary:     "change key type and convert value",
+		startconfig: stringConfig,
+		startvalues: charm.Settings{"key": "0.5"},
+		endconfig:   floatConfig,
+		endvalues:   charm.Settings{"key": 0.5},
 	},
 }
 
@@ -45,30 +45,43 @@ const BootstrapNonce = "user-admin:bootstrap"
 // State represents the state of an environment
 // managed by juju.
 type State struct {
-	info             *Info
-	db               *mgo.Database
-	environments     *mgo.Collection
-	charms           *mgo.Collection
-	machines         *mgo.Collection
-	instanceData     *mgo.Collection
-	containerRefs    *mgo.Collection
-	relations        *mgo.Collection
-	relationScopes   *mgo.Collection
-	services         *mgo.Collection
-	minUnits         *mgo.Collection
-	settings         *mgo.Collection
-	settingsrefs     *mgo.Collection
-	constraints      *mgo.Collection
-	units            *mgo.Collection
-	users            *mgo.Collection
-	presence         *mgo.Collection
-	cleanups         *mgo.Collection
-	annotations      *mgo.Collection
-	statuses         *mgo.Collection
-	runner           *txn.Runner
-	transactionHooks chan ([]transactionHook)
-	watcher          *watcher.Watcher
-	pwatcher         *presence.Watcher
+	info              *Info
+	db                *mgo.Database
+	environments      *mgo.Collection
+	charms            *mgo.Collection
+	machines          *mgo.Collection
+	instanceData      *mgo.Collection
+	containerRefs     *mgo.Collection
+	relations         *mgo.Collection
+	relationScopes    *mgo.Collection
+	services          *mgo.Collection
+	minUnits          *mgo.Collection
+	settings          *mgo.Collection
+	settingsrefs      *mgo.Collection
+	constraints       *mgo.Collection
+	units             *mgo.Collection
+	users             *mgo.Collection
+	presence          *mgo.Collection
+	cleanups          *mgo.Collection
+	annotations       *mgo.Collection
+	statuses          *mgo.Collection
+	statusHistory     *mgo.Collection
+	actions           *mgo.Collection
+	storageInstances  *mgo.Collection
+	logs              *mgo.Collection
+	networks          *mgo.Collection
+	networkInterfaces *mgo.Collection
+	upgradeInfo       *mgo.Collection
+	blocks            *mgo.Collection
+	auditLog          *mgo.Collection
+	metrics           *mgo.Collection
+	scaling           *mgo.Collection
+	leadership        *mgo.Collection
+	stateServers      *mgo.Collection
+	runner            *txn.Runner
+	transactionHooks  chan ([]transactionHook)
+	watcher           *watcher.Watcher
+	pwatcher          *presence.Watcher
 	// mu guards allManager.
 	mu         sync.Mutex
 	allManager *multiwatcher.StoreManager
@@ -176,23 +189,9 @@ func (st *State) checkCanUpgrade(currentVersion, newVersion string) error {
 			{{"tools.version", D{{"$not", bson.RegEx{matchNew, ""}}}}},
 		}}},
 	}}}
-	var agentTags []string
-	for _, collection := range []*mgo.Collection{st.machines, st.units} {
-		var doc struct {
-			Id string `bson:"_id"`
-		}
-		iter := collection.Find(sel).Select(D{{"_id", 1}}).Iter()
-		for iter.Next(&doc) {
-			switch collection.Name {
-			case "machines":
-				agentTags = append(agentTags, names.MachineTag(doc.Id))
-			case "units":
-				agentTags = append(agentTags, names.UnitTag(doc.Id))
-			}
-		}
-		if err := iter.Err(); err != nil {
-			return err
-		}
+	agentTags, err := st.agentTags(sel)
+	if err != nil {
+		return err
 	}
 	if len(agentTags) > 0 {
 		return newVersionInconsistentError(version.MustParse(currentVersion), agentTags)
@@ -392,6 +391,11 @@ type AddMachineParams struct {
 	HardwareCharacteristics instance.HardwareCharacteristics
 	Nonce                   string
 	Jobs                    []MachineJob
+	// Placement holds a provider-specific placement directive, such
+	// as an availability zone, which the provisioner follows when
+	// starting the machine. When a container is added to a new
+	// machine, the directive applies to the new machine.
+	Placement string
 }
 
 // addMachineContainerOps returns txn operations and associated Mongo records used to create a new machine,
@@ -401,6 +405,9 @@ type AddMachineParams struct {
 // a machine to state so that it is provisioned normally, the instance id is not known at this point.
 // 2. AssignToNewMachine, which is used to create a new machine on which to deploy a unit.
 func (st *State) addMachineContainerOps(params *AddMachineParams, cons constraints.Value) ([]txn.Op, *instanceData, *containerRefParams, error) {
+	if params.Placement != "" && (params.ParentId != "" || params.InstanceId != "") {
+		return nil, nil, nil, fmt.Errorf("cannot use placement directive with an existing machine")
+	}
 	var instData *instanceData
 	if params.InstanceId != "" {
 		instData = &instanceData{
@@ -421,9 +428,10 @@ func (st *State) addMachineContainerOps(params *AddMachineParams, cons constrain
 		if params.ParentId == "" {
 			// No parent machine is specified so create one.
 			mdoc := &machineDoc{
-				Series: params.Series,
-				Jobs:   params.Jobs,
-				Clean:  true,
+				Series:    params.Series,
+				Jobs:      params.Jobs,
+				Clean:     true,
+				Placement: params.Placement,
 			}
 			mdoc, parentOps, err := st.addMachineOps(mdoc, instData, cons, &containerRefParams{})
 			if err != nil {
@@ -451,15 +459,34 @@ func (st *State) addMachine(params *AddMachineParams) (m *Machine, err error) {
 	}
 	defer utils.ErrorContextf(&err, msg)
 
-	cons, err := st.EnvironConstraints()
+	mdoc, ops, err := st.newMachineOps(params)
+	if err != nil {
+		return nil, err
+	}
+	err = st.runTransaction(ops)
 	if err != nil {
 		return nil, err
 	}
+	// Refresh to pick the txn-revno.
+	m = newMachine(st, mdoc)
+	if err = m.Refresh(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// newMachineOps returns the document of a new machine configured
+// according to params, and the txn operations that add it.
+func (st *State) newMachineOps(params *AddMachineParams) (*machineDoc, []txn.Op, error) {
+	cons, err := st.EnvironConstraints()
+	if err != nil {
+		return nil, nil, err
+	}
 	cons = params.Constraints.WithFallbacks(cons)
 
 	ops, instData, containerParams, err := st.addMachineContainerOps(params, cons)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	mdoc := &machineDoc{
 		Series:        params.Series,
@@ -470,23 +497,13 @@ func (st *State) addMachine(params *AddMachineParams) (m *Machine, err error) {
 	if mdoc.ContainerType == "" {
 		mdoc.InstanceId = params.InstanceId
 		mdoc.Nonce = params.Nonce
+		mdoc.Placement = params.Placement
 	}
 	mdoc, machineOps, err := st.addMachineOps(mdoc, instData, cons, containerParams)
 	if err != nil {
-		return nil, err
-	}
-	ops = append(ops, machineOps...)
-
-	err = st.runTransaction(ops)
-	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	// Refresh to pick the txn-revno.
-	m = newMachine(st, mdoc)
-	if err = m.Refresh(); err != nil {
-		return nil, err
-	}
-	return m, nil
+	return mdoc, append(ops, machineOps...), nil
 }
 
 var errDead = fmt.Errorf("not found or dead")
@@ -514,6 +531,42 @@ func (st *State) AllMachines() (machines []*Machine, err error) {
 	return
 }
 
+// MachinesWithTransientErrors returns all alive machines whose status is
+// error with the "transient" status data set, ordered by id. The statuses
+// collection is queried directly, so the cost depends on the number of
+// failed machines rather than on the size of the environment.
+func (st *State) MachinesWithTransientErrors() ([]*Machine, error) {
+	var sdocs []struct {
+		Id string `bson:"_id"`
+	}
+	sel := D{
+		{"_id", bson.RegEx{Pattern: "^" + regexp.QuoteMeta(machineGlobalKey(""))}},
+		{"status", params.StatusError},
+		{"statusdata.transient", true},
+	}
+	if err := st.statuses.Find(sel).Select(D{{"_id", 1}}).All(&sdocs); err != nil {
+		return nil, fmt.Errorf("cannot get machines with transient errors: %v", err)
+	}
+	if len(sdocs) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, len(sdocs))
+	for i, sdoc := range sdocs {
+		ids[i] = strings.TrimPrefix(sdoc.Id, machineGlobalKey(""))
+	}
+	mdocs := machineDocSlice{}
+	sel = D{{"_id", D{{"$in", ids}}}, {"life", Alive}}
+	if err := st.machines.Find(sel).All(&mdocs); err != nil {
+		return nil, fmt.Errorf("cannot get machines with transient errors: %v", err)
+	}
+	sort.Sort(mdocs)
+	machines := make([]*Machine, len(mdocs))
+	for i := range mdocs {
+		machines[i] = newMachine(st, &mdocs[i])
+	}
+	return machines, nil
+}
+
 type machineDocSlice []machineDoc
 
 func (ms machineDocSlice) Len() int      { return len(ms) }
@@ -1181,6 +1234,13 @@ func (st *State) setMongoPassword(name, password string) error {
 	return nil
 }
 
+func (st *State) setAdminMongoPassword(name, password string) error {
+	if err := st.db.Session.DB("admin").AddUser(name, password, false); err != nil {
+		return fmt.Errorf("cannot set password in admin db for %q: %v", name, err)
+	}
+	return nil
+}
+
 // cleanupDoc represents a potentially large set of documents that should be
 // removed.
 type cleanupDoc struct {
@@ -1226,6 +1286,12 @@ func (st *State) Cleanup() error {
 			err = st.cleanupSettings(doc.Prefix)
 		case "units":
 			err = st.cleanupUnits(doc.Prefix)
+		case "unitmetrics":
+			err = st.cleanupMetrics("unit", doc)
+		case "servicemetrics":
+			err = st.cleanupMetrics("service", doc)
+		case "actions":
+			err = st.cleanupActions(doc.Prefix)
 		default:
 			err = fmt.Errorf("unknown cleanup kind %q", doc.Kind)
 		}
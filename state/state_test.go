@@ -227,6 +227,42 @@ func (s *StateSuite) TestAddMachineExtraConstraints(c *gc.C) {
 	c.Assert(mcons, gc.DeepEquals, expectedCons)
 }
 
+func (s *StateSuite) TestAddMachineWithPlacement(c *gc.C) {
+	oneJob := []state.MachineJob{state.JobHostUnits}
+	m, err := s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:    "quantal",
+		Jobs:      oneJob,
+		Placement: "zone=zone1",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Placement(), gc.Equals, "zone=zone1")
+	err = m.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Placement(), gc.Equals, "zone=zone1")
+
+	// The directive applies to the new host of a new container.
+	m, err = s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:        "quantal",
+		ContainerType: instance.LXC,
+		Jobs:          oneJob,
+		Placement:     "zone=zone2",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Placement(), gc.Equals, "")
+	host, err := s.State.Machine(state.ParentId(m.Id()))
+	c.Assert(err, gc.IsNil)
+	c.Assert(host.Placement(), gc.Equals, "zone=zone2")
+
+	_, err = s.State.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:        "quantal",
+		ContainerType: instance.LXC,
+		ParentId:      host.Id(),
+		Jobs:          oneJob,
+		Placement:     "zone=zone1",
+	})
+	c.Assert(err, gc.ErrorMatches, "cannot add a new container: cannot use placement directive with an existing machine")
+}
+
 func (s *StateSuite) assertMachineContainers(c *gc.C, m *state.Machine, containers []string) {
 	mc, err := m.Containers()
 	c.Assert(err, gc.IsNil)
@@ -496,6 +532,39 @@ func (s *StateSuite) TestAllMachines(c *gc.C) {
 	}
 }
 
+func (s *StateSuite) TestMachinesWithTransientErrors(c *gc.C) {
+	var machines []*state.Machine
+	for i := 0; i < 4; i++ {
+		m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+		c.Assert(err, gc.IsNil)
+		machines = append(machines, m)
+	}
+	err := machines[0].SetStatus(params.StatusError, "error", params.StatusData{"attempts": 1})
+	c.Assert(err, gc.IsNil)
+	err = machines[1].SetStatus(params.StatusError, "transient", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+	err = machines[2].SetStatus(params.StatusError, "transient", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+	err = machines[2].Destroy()
+	c.Assert(err, gc.IsNil)
+	err = machines[3].SetStatus(params.StatusError, "transient", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+	wordpress, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	unit, err := wordpress.AddUnit()
+	c.Assert(err, gc.IsNil)
+	err = unit.SetStatus(params.StatusError, "transient", params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+
+	ms, err := s.State.MachinesWithTransientErrors()
+	c.Assert(err, gc.IsNil)
+	var ids []string
+	for _, m := range ms {
+		ids = append(ids, m.Id())
+	}
+	c.Assert(ids, gc.DeepEquals, []string{machines[1].Id(), machines[3].Id()})
+}
+
 func (s *StateSuite) TestAddService(c *gc.C) {
 	charm := s.AddTestingCharm(c, "dummy")
 	_, err := s.State.AddService("haha/borken", charm)
@@ -0,0 +1,131 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/constraints"
+)
+
+// StateServerMachines returns all the machines that are not dead and
+// have the JobManageState job, sorted by id.
+func (st *State) StateServerMachines() ([]*Machine, error) {
+	mdocs := machineDocSlice{}
+	sel := D{{"jobs", JobManageState}, {"life", D{{"$ne", Dead}}}}
+	if err := st.machines.Find(sel).All(&mdocs); err != nil {
+		return nil, fmt.Errorf("cannot get state server machines: %v", err)
+	}
+	sort.Sort(mdocs)
+	machines := make([]*Machine, len(mdocs))
+	for i := range mdocs {
+		machines[i] = newMachine(st, &mdocs[i])
+	}
+	return machines, nil
+}
+
+// stateServersKey is the id of the document recording the machines
+// added by EnsureAvailability.
+const stateServersKey = "e"
+
+// stateServersDoc records the state server machines added by
+// EnsureAvailability. Its txn-revno is asserted by each call, so that
+// concurrent calls cannot both add machines.
+type stateServersDoc struct {
+	Id         string `bson:"_id"`
+	MachineIds []string
+	TxnRevno   int64 `bson:"txn-revno"`
+}
+
+// EnsureAvailability adds state server machines as necessary to make
+// the number of live state servers equal to numStateServers. New
+// machines are created with the given constraints and series. The
+// number of state servers must be odd, so that the MongoDB replica
+// set spanning them can always elect a primary, and it may not be
+// reduced.
+func (st *State) EnsureAvailability(numStateServers int, cons constraints.Value, series string) error {
+	if numStateServers <= 0 || numStateServers%2 != 1 {
+		return fmt.Errorf("number of state servers must be odd and greater than zero")
+	}
+	if series == "" {
+		cfg, err := st.EnvironConfig()
+		if err != nil {
+			return err
+		}
+		series = cfg.DefaultSeries()
+	}
+	for i := 0; i < 3; i++ {
+		var doc stateServersDoc
+		serversOp := txn.Op{
+			C:  st.stateServers.Name,
+			Id: stateServersKey,
+		}
+		err := st.stateServers.FindId(stateServersKey).One(&doc)
+		if err == mgo.ErrNotFound {
+			serversOp.Assert = txn.DocMissing
+		} else if err != nil {
+			return err
+		} else {
+			serversOp.Assert = D{{"txn-revno", doc.TxnRevno}}
+		}
+		current, err := st.StateServerMachines()
+		if err != nil {
+			return err
+		}
+		live := 0
+		for _, m := range current {
+			if m.Life() == Alive {
+				live++
+			}
+		}
+		if numStateServers < live {
+			return fmt.Errorf("cannot reduce state server count")
+		}
+		if numStateServers == live {
+			return nil
+		}
+		var ops []txn.Op
+		var added []string
+		for j := live; j < numStateServers; j++ {
+			mdoc, machineOps, err := st.newMachineOps(&AddMachineParams{
+				Series:      series,
+				Constraints: cons,
+				Jobs:        []MachineJob{JobManageState},
+			})
+			if err != nil {
+				return fmt.Errorf("cannot add state server machine: %v", err)
+			}
+			ops = append(ops, machineOps...)
+			added = append(added, mdoc.Id)
+		}
+		if serversOp.Assert == txn.DocMissing {
+			serversOp.Insert = &stateServersDoc{
+				Id:         stateServersKey,
+				MachineIds: added,
+			}
+		} else {
+			machineIds := append(doc.MachineIds, added...)
+			serversOp.Update = D{{"$set", D{{"machineids", machineIds}}}}
+		}
+		ops = append(ops, serversOp)
+		if err := st.runTransaction(ops); err == nil {
+			logger.Infof("added state server machines %v", added)
+			return nil
+		} else if err != txn.ErrAborted {
+			return fmt.Errorf("cannot add state server machines: %v", err)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// MongoSession returns the underlying session used by st. It is
+// intended for workers that manage the MongoDB servers themselves;
+// callers must not close it.
+func (st *State) MongoSession() *mgo.Session {
+	return st.db.Session
+}
@@ -0,0 +1,102 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/state"
+)
+
+type StateServerSuite struct {
+	ConnSuite
+}
+
+var _ = gc.Suite(&StateServerSuite{})
+
+func (s *StateServerSuite) stateServerIds(c *gc.C) []string {
+	machines, err := s.State.StateServerMachines()
+	c.Assert(err, gc.IsNil)
+	ids := make([]string, len(machines))
+	for i, m := range machines {
+		ids[i] = m.Id()
+	}
+	return ids
+}
+
+func (s *StateServerSuite) TestStateServerMachines(c *gc.C) {
+	c.Assert(s.stateServerIds(c), gc.HasLen, 0)
+	_, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	m1, err := s.State.AddMachine("quantal", state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddMachine("quantal", state.JobManageEnviron, state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.stateServerIds(c), gc.DeepEquals, []string{"1", "2"})
+
+	err = m1.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.stateServerIds(c), gc.DeepEquals, []string{"2"})
+}
+
+func (s *StateServerSuite) TestEnsureAvailabilityAddsMachines(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobManageEnviron, state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	cons := constraints.MustParse("mem=4G")
+	err = s.State.EnsureAvailability(3, cons, "precise")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.stateServerIds(c), gc.DeepEquals, []string{"0", "1", "2"})
+
+	for _, id := range []string{"1", "2"} {
+		m, err := s.State.Machine(id)
+		c.Assert(err, gc.IsNil)
+		c.Assert(m.Jobs(), gc.DeepEquals, []state.MachineJob{state.JobManageState})
+		c.Assert(m.Series(), gc.Equals, "precise")
+		mcons, err := m.Constraints()
+		c.Assert(err, gc.IsNil)
+		c.Assert(mcons, gc.DeepEquals, cons)
+	}
+
+	// A second call with the same count is a no-op.
+	err = s.State.EnsureAvailability(3, constraints.Value{}, "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.stateServerIds(c), gc.HasLen, 3)
+}
+
+func (s *StateServerSuite) TestEnsureAvailabilityDefaultSeries(c *gc.C) {
+	err := s.State.EnsureAvailability(1, constraints.Value{}, "")
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.Machine("0")
+	c.Assert(err, gc.IsNil)
+	cfg, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	c.Assert(m.Series(), gc.Equals, cfg.DefaultSeries())
+}
+
+func (s *StateServerSuite) TestEnsureAvailabilityErrors(c *gc.C) {
+	for _, n := range []int{-1, 0, 2, 4} {
+		err := s.State.EnsureAvailability(n, constraints.Value{}, "")
+		c.Check(err, gc.ErrorMatches, "number of state servers must be odd and greater than zero")
+	}
+	err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	err = s.State.EnsureAvailability(1, constraints.Value{}, "quantal")
+	c.Assert(err, gc.ErrorMatches, "cannot reduce state server count")
+}
+
+func (s *StateServerSuite) TestEnsureAvailabilityConcurrent(c *gc.C) {
+	_, err := s.State.AddMachine("quantal", state.JobManageEnviron, state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	defer state.SetBeforeHooks(c, s.State, func() {
+		err := s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+		c.Assert(err, gc.IsNil)
+	}).Check()
+
+	// The concurrent call adds the machines; this one, on retrying,
+	// finds there is nothing left to do.
+	err = s.State.EnsureAvailability(3, constraints.Value{}, "quantal")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.stateServerIds(c), gc.HasLen, 3)
+}
@@ -5,8 +5,10 @@ package state
 
 import (
 	"fmt"
+	"time"
 
 	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
 	"labix.org/v2/mgo/txn"
 
 	"launchpad.net/juju-core/errors"
@@ -89,3 +91,190 @@ func removeStatusOp(st *State, globalKey string) txn.Op {
 		Remove: true,
 	}
 }
+
+// workloadStatusDoc holds the status of a unit's workload, as set by
+// its charm. It is kept in the statuses collection under the key
+// returned by workloadGlobalKey, apart from the agent status. Its
+// fields match those of statusDoc, so the AllWatcher reads both kinds
+// of document alike.
+type workloadStatusDoc struct {
+	Status     params.WorkloadStatus
+	StatusInfo string
+}
+
+// workloadKeySuffix is appended to the global key of an entity to
+// form the key of its workload status document.
+const workloadKeySuffix = "#workload"
+
+// workloadGlobalKey returns the key of the workload status document
+// of the entity with the given global key.
+func workloadGlobalKey(globalKey string) string {
+	return globalKey + workloadKeySuffix
+}
+
+// getWorkloadStatus retrieves the workload status document of the
+// entity with the given global key. A missing document means that the
+// workload status was never set, and yields params.WorkloadUnknown.
+func getWorkloadStatus(st *State, globalKey string) (doc workloadStatusDoc, found bool, err error) {
+	key := workloadGlobalKey(globalKey)
+	err = st.statuses.FindId(key).One(&doc)
+	if err == mgo.ErrNotFound {
+		return workloadStatusDoc{Status: params.WorkloadUnknown}, false, nil
+	}
+	if err != nil {
+		return workloadStatusDoc{}, false, fmt.Errorf("cannot get status %q: %v", key, err)
+	}
+	return doc, true, nil
+}
+
+// setWorkloadStatusOp returns the operation needed to write the given
+// workload status document of the entity with the given global key,
+// depending on whether the document already exists.
+func setWorkloadStatusOp(st *State, globalKey string, doc workloadStatusDoc, exists bool) txn.Op {
+	op := txn.Op{
+		C:  st.statuses.Name,
+		Id: workloadGlobalKey(globalKey),
+	}
+	if exists {
+		op.Assert = txn.DocExists
+		op.Update = D{{"$set", doc}}
+	} else {
+		op.Assert = txn.DocMissing
+		op.Insert = doc
+	}
+	return op
+}
+
+// workloadStatusPriority orders the workload statuses by how much
+// attention they need, so that the status of a service reflects its
+// most troubled unit.
+var workloadStatusPriority = map[params.WorkloadStatus]int{
+	params.WorkloadUnknown:     0,
+	params.WorkloadActive:      1,
+	params.WorkloadWaiting:     2,
+	params.WorkloadMaintenance: 3,
+	params.WorkloadBlocked:     4,
+}
+
+// AggregateWorkloadStatus returns the status of a service whose units
+// report the given workload statuses: the status of the unit needing
+// the most attention, where blocked comes before maintenance, then
+// waiting, then active. It returns params.WorkloadUnknown if no unit
+// has reported its status.
+func AggregateWorkloadStatus(statuses []params.WorkloadStatus) params.WorkloadStatus {
+	result := params.WorkloadUnknown
+	for _, status := range statuses {
+		if workloadStatusPriority[status] > workloadStatusPriority[result] {
+			result = status
+		}
+	}
+	return result
+}
+
+// StatusInfo holds a status recorded in the history of an entity.
+type StatusInfo struct {
+	Status params.Status
+	Info   string
+	Data   params.StatusData
+	Since  time.Time
+}
+
+// historicalStatusDoc records a status set on the entity with the
+// given global key. The documents are written directly rather than
+// by a transaction, because they are never updated. The collection
+// is capped, so the oldest entries are dropped once it is full; until
+// then they are removed by PruneStatusHistory.
+type historicalStatusDoc struct {
+	Id         bson.ObjectId `bson:"_id"`
+	EntityId   string
+	Status     params.Status
+	StatusInfo string
+	StatusData params.StatusData
+	Updated    time.Time
+}
+
+// statusHistoryNow returns the time recorded for a status change.
+// It is patched in tests.
+var statusHistoryNow = time.Now
+
+// recordStatusHistory adds the given status document to the history
+// of the entity with the given global key. Failing to do so is
+// logged rather than returned, because the status itself has already
+// been changed.
+func recordStatusHistory(st *State, globalKey string, doc statusDoc) {
+	hdoc := &historicalStatusDoc{
+		Id:         bson.NewObjectId(),
+		EntityId:   globalKey,
+		Status:     doc.Status,
+		StatusInfo: doc.StatusInfo,
+		StatusData: doc.StatusData,
+		Updated:    statusHistoryNow().UTC(),
+	}
+	if err := st.statusHistory.Insert(hdoc); err != nil {
+		logger.Errorf("cannot record status history of %q: %v", globalKey, err)
+	}
+}
+
+// statusHistory returns at most size of the most recent statuses
+// recorded for the entity with the given global key, newest first.
+func statusHistory(st *State, globalKey string, size int) ([]StatusInfo, error) {
+	var docs []historicalStatusDoc
+	query := st.statusHistory.Find(D{{"entityid", globalKey}}).Sort("-updated", "-_id")
+	if size > 0 {
+		query = query.Limit(size)
+	}
+	if err := query.All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get status history of %q: %v", globalKey, err)
+	}
+	results := make([]StatusInfo, len(docs))
+	for i, doc := range docs {
+		results[i] = StatusInfo{
+			Status: doc.Status,
+			Info:   doc.StatusInfo,
+			Data:   doc.StatusData,
+			Since:  doc.Updated,
+		}
+	}
+	return results, nil
+}
+
+// PruneStatusHistory removes the status history entries older than
+// maxAge, and all but the newest maxEntries entries of each entity.
+// A zero value for either limit disables it.
+func (st *State) PruneStatusHistory(maxAge time.Duration, maxEntries int) error {
+	if maxAge > 0 {
+		cutoff := statusHistoryNow().UTC().Add(-maxAge)
+		_, err := st.statusHistory.RemoveAll(D{{"updated", D{{"$lt", cutoff}}}})
+		if err != nil {
+			return fmt.Errorf("cannot prune status history: %v", err)
+		}
+	}
+	if maxEntries <= 0 {
+		return nil
+	}
+	var entityIds []string
+	if err := st.statusHistory.Find(nil).Distinct("entityid", &entityIds); err != nil {
+		return fmt.Errorf("cannot prune status history: %v", err)
+	}
+	for _, entityId := range entityIds {
+		var docs []struct {
+			Id bson.ObjectId `bson:"_id"`
+		}
+		err := st.statusHistory.Find(D{{"entityid", entityId}}).
+			Sort("-updated", "-_id").Skip(maxEntries).Select(D{{"_id", 1}}).All(&docs)
+		if err != nil {
+			return fmt.Errorf("cannot prune status history of %q: %v", entityId, err)
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		ids := make([]bson.ObjectId, len(docs))
+		for i, doc := range docs {
+			ids[i] = doc.Id
+		}
+		if _, err := st.statusHistory.RemoveAll(D{{"_id", D{{"$in", ids}}}}); err != nil {
+			return fmt.Errorf("cannot prune status history of %q: %v", entityId, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"time"
+
+	"labix.org/v2/mgo/bson"
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+type StatusHistorySuite struct {
+	ConnSuite
+	unit    *state.Unit
+	machine *state.Machine
+	now     time.Time
+}
+
+var _ = gc.Suite(&StatusHistorySuite{})
+
+func (s *StatusHistorySuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	service, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.now = time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.PatchValue(state.StatusHistoryNow, func() time.Time { return s.now })
+}
+
+// setUnitStatus sets the status of the unit, advancing the recorded
+// time by a minute first.
+func (s *StatusHistorySuite) setUnitStatus(c *gc.C, status params.Status, info string) {
+	s.now = s.now.Add(time.Minute)
+	err := s.unit.SetStatus(status, info, nil)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StatusHistorySuite) TestUnitStatusHistory(c *gc.C) {
+	history, err := s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+
+	s.setUnitStatus(c, params.StatusStarted, "")
+	s.now = s.now.Add(time.Minute)
+	err = s.unit.SetStatus(params.StatusError, "hook failed", params.StatusData{"hook": "start"})
+	c.Assert(err, gc.IsNil)
+	s.setUnitStatus(c, params.StatusStarted, "")
+
+	history, err = s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	start := time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.Assert(history, gc.DeepEquals, []state.StatusInfo{{
+		Status: params.StatusStarted,
+		Since:  start.Add(3 * time.Minute),
+	}, {
+		Status: params.StatusError,
+		Info:   "hook failed",
+		Data:   params.StatusData{"hook": "start"},
+		Since:  start.Add(2 * time.Minute),
+	}, {
+		Status: params.StatusStarted,
+		Since:  start.Add(time.Minute),
+	}})
+
+	history, err = s.unit.StatusHistory(1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 1)
+	c.Assert(history[0].Since, gc.Equals, start.Add(3*time.Minute))
+}
+
+func (s *StatusHistorySuite) TestFailedSetStatusNotRecorded(c *gc.C) {
+	err := s.unit.SetStatus(params.StatusError, "", nil)
+	c.Assert(err, gc.ErrorMatches, `cannot set status "error" without info`)
+	err = s.machine.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.NotNil)
+
+	history, err := s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+	history, err = s.machine.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *StatusHistorySuite) TestMachineStatusHistory(c *gc.C) {
+	err := s.machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+	history, err := s.machine.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.DeepEquals, []state.StatusInfo{{
+		Status: params.StatusStarted,
+		Since:  s.now,
+	}})
+
+	// The unit's history is kept separately.
+	history, err = s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 0)
+}
+
+func (s *StatusHistorySuite) TestPruneStatusHistoryByAge(c *gc.C) {
+	for i := 0; i < 5; i++ {
+		s.setUnitStatus(c, params.StatusStarted, "")
+	}
+	// Entries set 3 minutes ago or earlier are pruned.
+	err := s.State.PruneStatusHistory(150*time.Second, 0)
+	c.Assert(err, gc.IsNil)
+	history, err := s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 3)
+	c.Assert(history[2].Since, gc.Equals, s.now.Add(-2*time.Minute))
+}
+
+func (s *StatusHistorySuite) TestPruneStatusHistoryBySize(c *gc.C) {
+	for i := 0; i < 5; i++ {
+		s.setUnitStatus(c, params.StatusStarted, "")
+	}
+	err := s.machine.SetStatus(params.StatusStarted, "", nil)
+	c.Assert(err, gc.IsNil)
+
+	err = s.State.PruneStatusHistory(0, 2)
+	c.Assert(err, gc.IsNil)
+	history, err := s.unit.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 2)
+	c.Assert(history[0].Since, gc.Equals, s.now)
+	c.Assert(history[1].Since, gc.Equals, s.now.Add(-time.Minute))
+
+	// Each entity keeps its own entries.
+	history, err = s.machine.StatusHistory(0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(history, gc.HasLen, 1)
+}
+
+func (s *StatusHistorySuite) TestStatusHistoryCollectionCapped(c *gc.C) {
+	var stats struct {
+		Capped bool `bson:"capped"`
+	}
+	db := s.State.MongoSession().DB("juju")
+	err := db.Run(bson.D{{"collStats", "statushistory"}}, &stats)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Capped, gc.Equals, true)
+}
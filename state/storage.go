@@ -0,0 +1,334 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/utils"
+)
+
+// StorageInstanceInfo holds the details of the volume provisioned for
+// a storage instance.
+type StorageInstanceInfo struct {
+	// VolumeId is the provider's identifier for the volume.
+	VolumeId string
+
+	// Size is the size of the volume, in megabytes. It may be larger
+	// than the size requested.
+	Size uint64
+
+	// Location is the path through which the unit may access the
+	// storage: the mount point of filesystem storage, or the device
+	// path of block storage.
+	Location string
+}
+
+// storageInstanceDoc represents an instance of a store declared in a
+// charm's metadata, belonging to a single unit.
+type storageInstanceDoc struct {
+	Id          string `bson:"_id"`
+	StorageName string
+	Kind        charm.StorageType
+	Unit        string
+	Life        Life
+	Size        uint64
+	Location    string
+	Info        *StorageInstanceInfo `bson:",omitempty"`
+}
+
+// StorageInstance represents an instance of a store declared in a
+// charm's metadata, belonging to a single unit. When the unit is
+// removed, its storage instances become Dying, and are removed once
+// their volumes have been destroyed.
+type StorageInstance struct {
+	st  *State
+	doc storageInstanceDoc
+}
+
+func newStorageInstance(st *State, doc *storageInstanceDoc) *StorageInstance {
+	return &StorageInstance{st: st, doc: *doc}
+}
+
+// Id returns the unique identifier of the storage instance, of the
+// form "<storage name>/<sequence>".
+func (s *StorageInstance) Id() string {
+	return s.doc.Id
+}
+
+// StorageName returns the name of the store in the charm metadata.
+func (s *StorageInstance) StorageName() string {
+	return s.doc.StorageName
+}
+
+// Kind returns the type of the storage.
+func (s *StorageInstance) Kind() charm.StorageType {
+	return s.doc.Kind
+}
+
+// UnitName returns the name of the unit that owns the storage instance.
+func (s *StorageInstance) UnitName() string {
+	return s.doc.Unit
+}
+
+// Life returns whether the storage instance is Alive or Dying.
+func (s *StorageInstance) Life() Life {
+	return s.doc.Life
+}
+
+// Size returns the requested size of the storage instance, in megabytes.
+func (s *StorageInstance) Size() uint64 {
+	return s.doc.Size
+}
+
+// Location returns the location at which the charm requires filesystem
+// storage to be mounted, or the empty string if it has no preference.
+func (s *StorageInstance) Location() string {
+	return s.doc.Location
+}
+
+// Info returns the details of the volume provisioned for the storage
+// instance, and whether it has been provisioned yet.
+func (s *StorageInstance) Info() (StorageInstanceInfo, bool) {
+	if s.doc.Info == nil {
+		return StorageInstanceInfo{}, false
+	}
+	return *s.doc.Info, true
+}
+
+// String returns a human readable description of the storage instance.
+func (s *StorageInstance) String() string {
+	return fmt.Sprintf("storage instance %q", s.doc.Id)
+}
+
+// SetInfo records the details of the volume provisioned for the
+// storage instance. It fails if the storage instance has already
+// been provisioned.
+func (s *StorageInstance) SetInfo(info StorageInstanceInfo) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set info for %v", s)
+	if info.VolumeId == "" {
+		return fmt.Errorf("volume id is empty")
+	}
+	ops := []txn.Op{{
+		C:      s.st.storageInstances.Name,
+		Id:     s.doc.Id,
+		Assert: D{{"info", D{{"$exists", false}}}},
+		Update: D{{"$set", D{{"info", &info}}}},
+	}}
+	if err := s.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := s.Refresh(); err != nil {
+			return err
+		}
+		return fmt.Errorf("already provisioned")
+	} else if err != nil {
+		return err
+	}
+	s.doc.Info = &info
+	return nil
+}
+
+// Remove removes the storage instance. The storage instance must be
+// Dying; that is, its unit must already have been removed.
+func (s *StorageInstance) Remove() (err error) {
+	defer utils.ErrorContextf(&err, "cannot remove %v", s)
+	ops := []txn.Op{{
+		C:      s.st.storageInstances.Name,
+		Id:     s.doc.Id,
+		Assert: D{{"life", Dying}},
+		Remove: true,
+	}}
+	if err := s.st.runTransaction(ops); err == txn.ErrAborted {
+		if err := s.Refresh(); errors.IsNotFoundError(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return fmt.Errorf("storage instance is still in use")
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Refresh refreshes the contents of the storage instance from the
+// underlying state.
+func (s *StorageInstance) Refresh() error {
+	doc := storageInstanceDoc{}
+	err := s.st.storageInstances.FindId(s.doc.Id).One(&doc)
+	if err == mgo.ErrNotFound {
+		return errors.NotFoundf("storage instance %q", s.doc.Id)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot refresh %v: %v", s, err)
+	}
+	s.doc = doc
+	return nil
+}
+
+// StorageInstance returns the storage instance with the given id.
+func (st *State) StorageInstance(id string) (*StorageInstance, error) {
+	doc := &storageInstanceDoc{}
+	err := st.storageInstances.FindId(id).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("storage instance %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get storage instance %q: %v", id, err)
+	}
+	return newStorageInstance(st, doc), nil
+}
+
+// AllStorageInstances returns all the storage instances in the
+// environment.
+func (st *State) AllStorageInstances() ([]*StorageInstance, error) {
+	return st.storageInstancesMatching(nil)
+}
+
+// StorageInstances returns the storage instances belonging to the unit.
+func (u *Unit) StorageInstances() ([]*StorageInstance, error) {
+	return u.st.storageInstancesMatching(D{{"unit", u.doc.Name}})
+}
+
+func (st *State) storageInstancesMatching(sel D) ([]*StorageInstance, error) {
+	var docs []storageInstanceDoc
+	if err := st.storageInstances.Find(sel).Sort("_id").All(&docs); err != nil {
+		return nil, fmt.Errorf("cannot get storage instances: %v", err)
+	}
+	instances := make([]*StorageInstance, len(docs))
+	for i := range docs {
+		instances[i] = newStorageInstance(st, &docs[i])
+	}
+	return instances, nil
+}
+
+// addUnitStorageOps returns the operations that create the storage
+// instances required by the charm of a new unit of the service.
+func (s *Service) addUnitStorageOps(unitName string) ([]txn.Op, error) {
+	ch, err := s.st.Charm(s.doc.CharmURL)
+	if err != nil {
+		return nil, err
+	}
+	var ops []txn.Op
+	for _, store := range sortedStorage(ch.Meta().Storage) {
+		cons := s.doc.StorageConstraints[store.Name]
+		count := store.Count
+		if cons.Count > count {
+			count = cons.Count
+		}
+		size := store.MinimumSize
+		if cons.Size > size {
+			size = cons.Size
+		}
+		for i := 0; i < count; i++ {
+			seq, err := s.st.sequence("storage")
+			if err != nil {
+				return nil, err
+			}
+			id := fmt.Sprintf("%s/%d", store.Name, seq)
+			ops = append(ops, txn.Op{
+				C:      s.st.storageInstances.Name,
+				Id:     id,
+				Assert: txn.DocMissing,
+				Insert: &storageInstanceDoc{
+					Id:          id,
+					StorageName: store.Name,
+					Kind:        store.Type,
+					Unit:        unitName,
+					Life:        Alive,
+					Size:        size,
+					Location:    store.Location,
+				},
+			})
+		}
+	}
+	return ops, nil
+}
+
+// removeUnitStorageOps returns the operations that mark the storage
+// instances of the unit as Dying, so that their volumes are destroyed.
+func (u *Unit) removeUnitStorageOps() ([]txn.Op, error) {
+	instances, err := u.StorageInstances()
+	if err != nil {
+		return nil, err
+	}
+	ops := make([]txn.Op, len(instances))
+	for i, s := range instances {
+		ops[i] = txn.Op{
+			C:      u.st.storageInstances.Name,
+			Id:     s.doc.Id,
+			Assert: txn.DocExists,
+			Update: D{{"$set", D{{"life", Dying}}}},
+		}
+	}
+	return ops, nil
+}
+
+// sortedStorage returns the stores in the map, sorted by name, so that
+// storage instance ids are allocated predictably.
+func sortedStorage(stores map[string]charm.Storage) []charm.Storage {
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]charm.Storage, len(names))
+	for i, name := range names {
+		result[i] = stores[name]
+	}
+	return result
+}
+
+// StorageConstraints returns the storage constraints of the service,
+// keyed by store name.
+func (s *Service) StorageConstraints() map[string]constraints.Storage {
+	return s.doc.StorageConstraints
+}
+
+// SetStorageConstraints replaces the storage constraints of the
+// service. They apply to units added subsequently. Each constraint
+// must refer to a store declared by the service's charm, and may not
+// ask for less than the charm requires.
+func (s *Service) SetStorageConstraints(cons map[string]constraints.Storage) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set storage constraints")
+	if s.doc.Life != Alive {
+		return errNotAlive
+	}
+	ch, err := s.st.Charm(s.doc.CharmURL)
+	if err != nil {
+		return err
+	}
+	stores := ch.Meta().Storage
+	for name, c := range cons {
+		store, ok := stores[name]
+		if !ok {
+			return fmt.Errorf("charm %q has no store %q", ch.Meta().Name, name)
+		}
+		if c.Size != 0 && c.Size < store.MinimumSize {
+			return fmt.Errorf("store %q requires at least %dM", name, store.MinimumSize)
+		}
+		if c.Count != 0 && c.Count < store.Count {
+			return fmt.Errorf("store %q requires at least %d instances", name, store.Count)
+		}
+	}
+	ops := []txn.Op{{
+		C:      s.st.services.Name,
+		Id:     s.doc.Name,
+		Assert: append(isAliveDoc, D{{"charmurl", s.doc.CharmURL}}...),
+		Update: D{{"$set", D{{"storageconstraints", cons}}}},
+	}}
+	if err := s.st.runTransaction(ops); err == txn.ErrAborted {
+		return fmt.Errorf("service is not alive or its charm has changed")
+	} else if err != nil {
+		return err
+	}
+	s.doc.StorageConstraints = cons
+	return nil
+}
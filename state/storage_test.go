@@ -0,0 +1,146 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+)
+
+type StorageSuite struct {
+	ConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&StorageSuite{})
+
+func (s *StorageSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("storage-filesystem", s.AddTestingCharm(c, "storage-filesystem"))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StorageSuite) TestAddUnitCreatesStorageInstances(c *gc.C) {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	instances, err := unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instances, gc.HasLen, 1)
+	inst := instances[0]
+	c.Assert(inst.Id(), gc.Equals, "data/0")
+	c.Assert(inst.StorageName(), gc.Equals, "data")
+	c.Assert(inst.Kind(), gc.Equals, charm.StorageFilesystem)
+	c.Assert(inst.UnitName(), gc.Equals, unit.Name())
+	c.Assert(inst.Life(), gc.Equals, state.Alive)
+	c.Assert(inst.Size(), gc.Equals, uint64(1024))
+	c.Assert(inst.Location(), gc.Equals, "/srv/data")
+	_, ok := inst.Info()
+	c.Assert(ok, jc.IsFalse)
+
+	// Units of services whose charms declare no storage have none.
+	svc, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+	unit, err = svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	instances, err = unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instances, gc.HasLen, 0)
+}
+
+func (s *StorageSuite) TestStorageConstraints(c *gc.C) {
+	c.Assert(s.service.StorageConstraints(), gc.HasLen, 0)
+	cons := map[string]constraints.Storage{"data": {Size: 4096, Count: 2}}
+	err := s.service.SetStorageConstraints(cons)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.service.StorageConstraints(), gc.DeepEquals, cons)
+	svc, err := s.State.Service(s.service.Name())
+	c.Assert(err, gc.IsNil)
+	c.Assert(svc.StorageConstraints(), gc.DeepEquals, cons)
+
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	instances, err := unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instances, gc.HasLen, 2)
+	for _, inst := range instances {
+		c.Assert(inst.Size(), gc.Equals, uint64(4096))
+	}
+}
+
+func (s *StorageSuite) TestSetStorageConstraintsErrors(c *gc.C) {
+	err := s.service.SetStorageConstraints(map[string]constraints.Storage{"logs": {Size: 4096}})
+	c.Assert(err, gc.ErrorMatches, `cannot set storage constraints: charm "storage-filesystem" has no store "logs"`)
+	err = s.service.SetStorageConstraints(map[string]constraints.Storage{"data": {Size: 512}})
+	c.Assert(err, gc.ErrorMatches, `cannot set storage constraints: store "data" requires at least 1024M`)
+}
+
+func (s *StorageSuite) TestSetInfo(c *gc.C) {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	instances, err := unit.StorageInstances()
+	c.Assert(err, gc.IsNil)
+	inst := instances[0]
+
+	err = inst.SetInfo(state.StorageInstanceInfo{})
+	c.Assert(err, gc.ErrorMatches, `cannot set info for storage instance "data/0": volume id is empty`)
+
+	info := state.StorageInstanceInfo{VolumeId: "vol-0", Size: 2048, Location: "/srv/data"}
+	err = inst.SetInfo(info)
+	c.Assert(err, gc.IsNil)
+	got, ok := inst.Info()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(got, gc.Equals, info)
+
+	inst, err = s.State.StorageInstance("data/0")
+	c.Assert(err, gc.IsNil)
+	got, ok = inst.Info()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(got, gc.Equals, info)
+
+	err = inst.SetInfo(info)
+	c.Assert(err, gc.ErrorMatches, `cannot set info for storage instance "data/0": already provisioned`)
+}
+
+func (s *StorageSuite) TestRemoveUnitMakesStorageDying(c *gc.C) {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	inst, err := s.State.StorageInstance("data/0")
+	c.Assert(err, gc.IsNil)
+	err = inst.Remove()
+	c.Assert(err, gc.ErrorMatches, `cannot remove storage instance "data/0": storage instance is still in use`)
+
+	err = unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = unit.Remove()
+	c.Assert(err, gc.IsNil)
+	err = inst.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(inst.Life(), gc.Equals, state.Dying)
+
+	err = inst.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.StorageInstance("data/0")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	// Removing it again is not an error.
+	err = inst.Remove()
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *StorageSuite) TestAllStorageInstances(c *gc.C) {
+	for i := 0; i < 2; i++ {
+		_, err := s.service.AddUnit()
+		c.Assert(err, gc.IsNil)
+	}
+	instances, err := s.State.AllStorageInstances()
+	c.Assert(err, gc.IsNil)
+	c.Assert(instances, gc.HasLen, 2)
+	c.Assert(instances[0].UnitName(), gc.Equals, "storage-filesystem/0")
+	c.Assert(instances[1].UnitName(), gc.Equals, "storage-filesystem/1")
+}
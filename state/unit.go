@@ -6,6 +6,7 @@ package state
 import (
 	stderrors "errors"
 	"fmt"
+	"net"
 	"sort"
 	"time"
 
@@ -74,6 +75,7 @@ type unitDoc struct {
 	PrivateAddress string
 	MachineId      string
 	Resolved       ResolvedMode
+	CancelHook     bool         `bson:",omitempty"`
 	Tools          *tools.Tools `bson:",omitempty"`
 	Ports          []instance.Port
 	Life           Life
@@ -521,7 +523,22 @@ func (u *Unit) PublicAddress() (string, bool) {
 }
 
 // PrivateAddress returns the private address of the unit and whether it is valid.
+// When the unit's service has a default endpoint binding, the address on
+// the bound network is preferred.
 func (u *Unit) PrivateAddress() (string, bool) {
+	if svc, err := u.Service(); err == nil {
+		if network := svc.EndpointBinding(""); network != "" {
+			if addr, ok := u.PrivateAddressOnNetwork(network); ok {
+				return addr, true
+			}
+		}
+	}
+	return u.privateAddress()
+}
+
+// privateAddress returns the private address of the unit, without
+// regard to endpoint bindings, and whether it is valid.
+func (u *Unit) privateAddress() (string, bool) {
 	privateAddress := u.doc.PrivateAddress
 	addresses := u.addressesOfMachine()
 	if len(addresses) > 0 {
@@ -530,6 +547,54 @@ func (u *Unit) PrivateAddress() (string, bool) {
 	return privateAddress, privateAddress != ""
 }
 
+// PrivateAddressOnNetwork returns the address of the unit on the named
+// network, and whether it is known. The addresses recorded on the
+// network interfaces of the unit's machine are used first, then any
+// machine address associated with the network, then any machine
+// address within the network's CIDR.
+func (u *Unit) PrivateAddressOnNetwork(network string) (string, bool) {
+	id := u.doc.MachineId
+	if id == "" {
+		return "", false
+	}
+	m, err := u.st.Machine(id)
+	if err != nil {
+		unitLogger.Errorf("unit %v misses machine id %v", u, id)
+		return "", false
+	}
+	ifaces, err := m.NetworkInterfaces()
+	if err != nil {
+		unitLogger.Errorf("cannot get network interfaces of machine %v: %v", id, err)
+		return "", false
+	}
+	for _, iface := range ifaces {
+		if iface.NetworkName() == network && iface.Address() != "" {
+			return iface.Address(), true
+		}
+	}
+	addresses := m.Addresses()
+	for _, addr := range addresses {
+		if addr.NetworkName == network && addr.NetworkScope != instance.NetworkPublic {
+			return addr.Value, true
+		}
+	}
+	// Fall back to any machine address within the network's CIDR.
+	n, err := u.st.Network(network)
+	if err != nil || n.CIDR() == "" {
+		return "", false
+	}
+	_, ipNet, err := net.ParseCIDR(n.CIDR())
+	if err != nil {
+		return "", false
+	}
+	for _, addr := range addresses {
+		if ip := net.ParseIP(addr.Value); ip != nil && ipNet.Contains(ip) {
+			return addr.Value, true
+		}
+	}
+	return "", false
+}
+
 // Refresh refreshes the contents of the Unit from the underlying
 // state. It an error that satisfies IsNotFound if the unit has been removed.
 func (u *Unit) Refresh() error {
@@ -577,9 +642,63 @@ func (u *Unit) SetStatus(status params.Status, info string, data params.StatusDa
 	if err != nil {
 		return fmt.Errorf("cannot set status of unit %q: %v", u, onAbort(err, errDead))
 	}
+	recordStatusHistory(u.st, u.globalKey(), doc)
 	return nil
 }
 
+// WorkloadStatus returns the status of the unit's workload, as set by
+// its charm. It returns params.WorkloadUnknown if the charm has never
+// set it.
+func (u *Unit) WorkloadStatus() (status params.WorkloadStatus, info string, err error) {
+	doc, _, err := getWorkloadStatus(u.st, u.globalKey())
+	if err != nil {
+		return "", "", err
+	}
+	return doc.Status, doc.StatusInfo, nil
+}
+
+// SetWorkloadStatus sets the status of the unit's workload. It is
+// independent of the status of the unit agent.
+func (u *Unit) SetWorkloadStatus(status params.WorkloadStatus, info string) (err error) {
+	defer utils.ErrorContextf(&err, "cannot set workload status of unit %q", u)
+	if !status.Valid() {
+		return fmt.Errorf("invalid workload status %q", status)
+	}
+	doc := workloadStatusDoc{
+		Status:     status,
+		StatusInfo: info,
+	}
+	unitOp := txn.Op{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+	}
+	// Racing clients trying to create the document generate one
+	// failure, but the second attempt updates it.
+	for i := 0; i < 2; i++ {
+		_, exists, err := getWorkloadStatus(u.st, u.globalKey())
+		if err != nil {
+			return err
+		}
+		ops := []txn.Op{unitOp, setWorkloadStatusOp(u.st, u.globalKey(), doc, exists)}
+		if err := u.st.runTransaction(ops); err != txn.ErrAborted {
+			return err
+		}
+		if notDead, err := isNotDead(u.st.units, u.doc.Name); err != nil {
+			return err
+		} else if !notDead {
+			return errDead
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// StatusHistory returns at most size of the most recent statuses set
+// on the unit, newest first. A size of zero returns them all.
+func (u *Unit) StatusHistory(size int) ([]StatusInfo, error) {
+	return statusHistory(u.st, u.globalKey(), size)
+}
+
 // OpenPort sets the policy of the port with protocol and number to be opened.
 func (u *Unit) OpenPort(protocol string, number int) (err error) {
 	port := instance.Port{Protocol: protocol, Number: number}
@@ -897,6 +1016,9 @@ func (u *Unit) assignToNewMachine(params *AddMachineParams, cons constraints.Val
 		Principals:    []string{u.doc.Name},
 		Clean:         false,
 	}
+	if params.ContainerType == "" {
+		mdoc.Placement = params.Placement
+	}
 	mdoc, machineOps, err := u.st.addMachineOps(mdoc, instData, cons, containerParams)
 	if err != nil {
 		return err
@@ -1031,6 +1153,15 @@ func (u *Unit) AssignToNewMachineOrContainer() (err error) {
 // determined according to the service and environment constraints at the
 // time of unit creation.
 func (u *Unit) AssignToNewMachine() (err error) {
+	return u.AssignToNewMachineWithPlacement("", "")
+}
+
+// AssignToNewMachineWithPlacement assigns the unit to a new machine, as
+// AssignToNewMachine does. The provisioner starts the new machine as
+// directed by the given provider-specific placement directive, if any.
+// If containerType is not empty, the unit is assigned to a new container
+// of that type on the new machine, regardless of the unit's constraints.
+func (u *Unit) AssignToNewMachineWithPlacement(containerType instance.ContainerType, placement string) (err error) {
 	defer assignContextf(&err, u, "new machine")
 	if u.doc.Principal != "" {
 		return fmt.Errorf("unit is a subordinate")
@@ -1041,15 +1172,15 @@ func (u *Unit) AssignToNewMachine() (err error) {
 	if err != nil {
 		return err
 	}
-	var containerType instance.ContainerType
 	// Configure to create a new container if required.
-	if cons.HasContainer() {
+	if containerType == "" && cons.HasContainer() {
 		containerType = *cons.Container
 	}
 	params := &AddMachineParams{
 		Series:        u.doc.Series,
 		ContainerType: containerType,
 		Jobs:          []MachineJob{JobHostUnits},
+		Placement:     placement,
 	}
 	err = u.assignToNewMachine(params, *cons)
 	return err
@@ -1344,6 +1475,47 @@ func (u *Unit) ClearResolved() error {
 	return nil
 }
 
+// HookCancelRequested returns whether the unit's agent has been asked
+// to cancel the hook it is running.
+func (u *Unit) HookCancelRequested() bool {
+	return u.doc.CancelHook
+}
+
+// CancelHook asks the unit's agent to cancel the hook it is running.
+// The request is discarded if the agent is not running a hook.
+func (u *Unit) CancelHook() (err error) {
+	defer utils.ErrorContextf(&err, "cannot cancel hook for unit %q", u)
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: notDeadDoc,
+		Update: D{{"$set", D{{"cancelhook", true}}}},
+	}}
+	if err := u.st.runTransaction(ops); err == nil {
+		u.doc.CancelHook = true
+		return nil
+	} else if err != txn.ErrAborted {
+		return err
+	}
+	return errDead
+}
+
+// ClearCancelHook removes any request to cancel the hook the unit's
+// agent is running.
+func (u *Unit) ClearCancelHook() error {
+	ops := []txn.Op{{
+		C:      u.st.units.Name,
+		Id:     u.doc.Name,
+		Assert: txn.DocExists,
+		Update: D{{"$set", D{{"cancelhook", false}}}},
+	}}
+	if err := u.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot clear hook cancellation for unit %q: %v", u, onAbort(err, errors.NotFoundf("unit")))
+	}
+	u.doc.CancelHook = false
+	return nil
+}
+
 type portSlice []instance.Port
 
 func (p portSlice) Len() int      { return len(p) }
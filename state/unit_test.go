@@ -403,6 +403,68 @@ func (s *UnitSuite) TestGetSetStatusDataChange(c *gc.C) {
 	c.Assert(data, gc.HasLen, 0)
 }
 
+func (s *UnitSuite) TestGetSetWorkloadStatus(c *gc.C) {
+	status, info, err := s.unit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadUnknown)
+	c.Assert(info, gc.Equals, "")
+
+	err = s.unit.SetWorkloadStatus(params.WorkloadUnknown, "")
+	c.Assert(err, gc.ErrorMatches, `cannot set workload status of unit "wordpress/0": invalid workload status "unknown"`)
+	err = s.unit.SetWorkloadStatus("busy", "")
+	c.Assert(err, gc.ErrorMatches, `cannot set workload status of unit "wordpress/0": invalid workload status "busy"`)
+
+	err = s.unit.SetWorkloadStatus(params.WorkloadMaintenance, "installing packages")
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetWorkloadStatus(params.WorkloadWaiting, "syncing database")
+	c.Assert(err, gc.IsNil)
+	status, info, err = s.unit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadWaiting)
+	c.Assert(info, gc.Equals, "syncing database")
+
+	// The agent status is unaffected.
+	agentStatus, _, _, err := s.unit.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(agentStatus, gc.Equals, params.StatusPending)
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetWorkloadStatus(params.WorkloadActive, "")
+	c.Assert(err, gc.ErrorMatches, `cannot set workload status of unit "wordpress/0": not found or dead`)
+
+	// The workload status is removed along with the unit.
+	err = s.unit.Remove()
+	c.Assert(err, gc.IsNil)
+	status, _, err = s.unit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadUnknown)
+}
+
+func (s *UnitSuite) TestAggregateWorkloadStatus(c *gc.C) {
+	for i, test := range []struct {
+		statuses []params.WorkloadStatus
+		expect   params.WorkloadStatus
+	}{{
+		expect: params.WorkloadUnknown,
+	}, {
+		statuses: []params.WorkloadStatus{params.WorkloadUnknown, params.WorkloadActive},
+		expect:   params.WorkloadActive,
+	}, {
+		statuses: []params.WorkloadStatus{params.WorkloadActive, params.WorkloadWaiting},
+		expect:   params.WorkloadWaiting,
+	}, {
+		statuses: []params.WorkloadStatus{params.WorkloadWaiting, params.WorkloadMaintenance, params.WorkloadActive},
+		expect:   params.WorkloadMaintenance,
+	}, {
+		statuses: []params.WorkloadStatus{params.WorkloadMaintenance, params.WorkloadBlocked, params.WorkloadActive},
+		expect:   params.WorkloadBlocked,
+	}} {
+		c.Logf("test %d: %v", i, test.statuses)
+		c.Assert(state.AggregateWorkloadStatus(test.statuses), gc.Equals, test.expect)
+	}
+}
+
 func (s *UnitSuite) TestUnitCharm(c *gc.C) {
 	preventUnitDestroyRemove(c, s.unit)
 	curl, ok := s.unit.CharmURL()
@@ -864,6 +926,28 @@ func (s *UnitSuite) TestGetSetClearResolved(c *gc.C) {
 	c.Assert(err, gc.ErrorMatches, `cannot set resolved mode for unit "wordpress/0": invalid error resolution mode: "foo"`)
 }
 
+func (s *UnitSuite) TestCancelHook(c *gc.C) {
+	c.Assert(s.unit.HookCancelRequested(), jc.IsFalse)
+	err := s.unit.CancelHook()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.HookCancelRequested(), jc.IsTrue)
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.HookCancelRequested(), jc.IsTrue)
+
+	err = s.unit.ClearCancelHook()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.HookCancelRequested(), jc.IsFalse)
+	err = s.unit.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.unit.HookCancelRequested(), jc.IsFalse)
+
+	err = s.unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = s.unit.CancelHook()
+	c.Assert(err, gc.ErrorMatches, `cannot cancel hook for unit "wordpress/0": not found or dead`)
+}
+
 func (s *UnitSuite) TestOpenedPorts(c *gc.C) {
 	// Verify no open ports before activity.
 	c.Assert(s.unit.OpenedPorts(), gc.HasLen, 0)
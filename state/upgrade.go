@@ -0,0 +1,360 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+	"labix.org/v2/mgo/txn"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/version"
+)
+
+// UpgradeStatus describes the progress of an environment upgrade.
+type UpgradeStatus string
+
+const (
+	// UpgradeStarted indicates that the environment's agent version
+	// has been changed, and the state servers are upgrading.
+	UpgradeStarted UpgradeStatus = "started"
+
+	// UpgradeMigrating indicates that a state server running the new
+	// version is migrating the data in state.
+	UpgradeMigrating UpgradeStatus = "migrating"
+
+	// UpgradeAgents indicates that the data in state has been
+	// migrated, and the remaining agents are upgrading.
+	UpgradeAgents UpgradeStatus = "upgrading-agents"
+
+	// UpgradeComplete indicates that all agents are running the new
+	// version.
+	UpgradeComplete UpgradeStatus = "complete"
+
+	// UpgradeFailed indicates that the data migration failed, and the
+	// environment's agent version has been reverted.
+	UpgradeFailed UpgradeStatus = "failed"
+)
+
+// upgradeInfoKey is the id of the single document that describes the
+// most recent upgrade of the environment.
+const upgradeInfoKey = "current"
+
+// upgradeInfoDoc represents the progress of an environment upgrade.
+type upgradeInfoDoc struct {
+	Id              string `bson:"_id"`
+	PreviousVersion version.Number
+	TargetVersion   version.Number
+	Status          UpgradeStatus
+	Started         time.Time
+	// MigratingMachine holds the id of the state server machine
+	// that migrates the data in state.
+	MigratingMachine string
+	Error            string
+}
+
+// UpgradeInfo describes the progress of the most recent upgrade of
+// the environment.
+type UpgradeInfo struct {
+	st  *State
+	doc upgradeInfoDoc
+}
+
+// PreviousVersion returns the agent version from which the
+// environment is being upgraded.
+func (info *UpgradeInfo) PreviousVersion() version.Number {
+	return info.doc.PreviousVersion
+}
+
+// TargetVersion returns the agent version to which the environment
+// is being upgraded.
+func (info *UpgradeInfo) TargetVersion() version.Number {
+	return info.doc.TargetVersion
+}
+
+// Status returns the progress of the upgrade.
+func (info *UpgradeInfo) Status() UpgradeStatus {
+	return info.doc.Status
+}
+
+// Started returns the time at which the upgrade was started.
+func (info *UpgradeInfo) Started() time.Time {
+	return info.doc.Started
+}
+
+// Error returns the reason the upgrade failed, if it did.
+func (info *UpgradeInfo) Error() string {
+	return info.doc.Error
+}
+
+// InProgress returns whether the upgrade has yet to complete or fail.
+func (info *UpgradeInfo) InProgress() bool {
+	return info.doc.Status != UpgradeComplete && info.doc.Status != UpgradeFailed
+}
+
+// StateServersFirst returns whether agents that are not state servers
+// should keep running the previous version, because the state servers
+// have not yet migrated the data in state.
+func (info *UpgradeInfo) StateServersFirst() bool {
+	return info.doc.Status == UpgradeStarted || info.doc.Status == UpgradeMigrating
+}
+
+// Refresh refreshes the contents of the upgrade info from the
+// underlying state.
+func (info *UpgradeInfo) Refresh() error {
+	doc, err := info.st.upgradeInfoDoc()
+	if err != nil {
+		return err
+	}
+	info.doc = *doc
+	return nil
+}
+
+func (st *State) upgradeInfoDoc() (*upgradeInfoDoc, error) {
+	doc := &upgradeInfoDoc{}
+	err := st.upgradeInfo.FindId(upgradeInfoKey).One(doc)
+	if err == mgo.ErrNotFound {
+		return nil, errors.NotFoundf("upgrade info")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot get upgrade info: %v", err)
+	}
+	return doc, nil
+}
+
+// UpgradeInfo returns the progress of the most recent upgrade of the
+// environment. It returns an error that satisfies IsNotFound if the
+// environment has never been upgraded.
+func (st *State) UpgradeInfo() (*UpgradeInfo, error) {
+	doc, err := st.upgradeInfoDoc()
+	if err != nil {
+		return nil, err
+	}
+	return &UpgradeInfo{st: st, doc: *doc}, nil
+}
+
+var finishedUpgradeDoc = D{{"status", D{{"$in", []UpgradeStatus{UpgradeComplete, UpgradeFailed}}}}}
+
+// StartUpgrade starts upgrading the environment to the given agent
+// version. It fails if an upgrade is already in progress, or if any
+// agent is not running the current version. State server agents are
+// upgraded first; other agents keep running the current version until
+// the state servers have migrated the data in state.
+func (st *State) StartUpgrade(target version.Number) error {
+	for i := 0; i < 5; i++ {
+		settings, err := readSettings(st, environGlobalKey)
+		if err != nil {
+			return err
+		}
+		agentVersion, ok := settings.Get("agent-version")
+		if !ok {
+			return fmt.Errorf("no agent version set in the environment")
+		}
+		current, err := version.Parse(fmt.Sprint(agentVersion))
+		if err != nil {
+			return fmt.Errorf("invalid agent version %q: %v", agentVersion, err)
+		}
+		if current == target {
+			return fmt.Errorf("environment is already running version %s", target)
+		}
+		if err := st.checkCanUpgrade(current.String(), target.String()); err != nil {
+			return err
+		}
+		doc := &upgradeInfoDoc{
+			Id:              upgradeInfoKey,
+			PreviousVersion: current,
+			TargetVersion:   target,
+			Status:          UpgradeStarted,
+			Started:         time.Now(),
+		}
+		infoOp := txn.Op{
+			C:      st.upgradeInfo.Name,
+			Id:     upgradeInfoKey,
+			Assert: txn.DocMissing,
+			Insert: doc,
+		}
+		existing, err := st.UpgradeInfo()
+		if err == nil {
+			if existing.InProgress() {
+				return fmt.Errorf("upgrade to %s is already in progress", existing.TargetVersion())
+			}
+			infoOp.Assert = finishedUpgradeDoc
+			infoOp.Insert = nil
+			infoOp.Update = D{{"$set", D{
+				{"previousversion", doc.PreviousVersion},
+				{"targetversion", doc.TargetVersion},
+				{"status", doc.Status},
+				{"started", doc.Started},
+				{"migratingmachine", ""},
+				{"error", ""},
+			}}}
+		} else if !errors.IsNotFoundError(err) {
+			return err
+		}
+		ops := []txn.Op{{
+			C:      st.settings.Name,
+			Id:     environGlobalKey,
+			Assert: D{{"txn-revno", settings.txnRevno}},
+			Update: D{{"$set", D{{"agent-version", target.String()}}}},
+		}, infoOp}
+		if err := st.runTransaction(ops); err == nil {
+			return nil
+		} else if err != txn.ErrAborted {
+			return fmt.Errorf("cannot start upgrade: %v", err)
+		}
+	}
+	return ErrExcessiveContention
+}
+
+// BeginMigration records that the state server machine with the given
+// id is migrating the data in state for the upgrade. It returns false
+// if the upgrade is not waiting for a migration, or another machine is
+// already migrating the data.
+func (info *UpgradeInfo) BeginMigration(machineId string) (bool, error) {
+	ops := []txn.Op{{
+		C:  info.st.upgradeInfo.Name,
+		Id: upgradeInfoKey,
+		Assert: D{
+			{"targetversion", info.doc.TargetVersion},
+			{"$or", []D{
+				{{"status", UpgradeStarted}},
+				{{"status", UpgradeMigrating}, {"migratingmachine", machineId}},
+			}},
+		},
+		Update: D{{"$set", D{
+			{"status", UpgradeMigrating},
+			{"migratingmachine", machineId},
+		}}},
+	}}
+	switch err := info.st.runTransaction(ops); err {
+	case nil:
+		info.doc.Status = UpgradeMigrating
+		info.doc.MigratingMachine = machineId
+		return true, nil
+	case txn.ErrAborted:
+		return false, nil
+	default:
+		return false, fmt.Errorf("cannot begin migration: %v", err)
+	}
+}
+
+// EndMigration records that the data in state has been migrated, so
+// that the remaining agents may upgrade.
+func (info *UpgradeInfo) EndMigration() error {
+	return info.setStatus(UpgradeMigrating, UpgradeAgents)
+}
+
+func (info *UpgradeInfo) setStatus(from, to UpgradeStatus) error {
+	if err := info.st.runTransaction(info.setStatusOps(from, to)); err != nil {
+		return fmt.Errorf("cannot set upgrade status to %q: %v", to, onAbort(err, fmt.Errorf("upgrade is not %s", from)))
+	}
+	info.doc.Status = to
+	return nil
+}
+
+func (info *UpgradeInfo) setStatusOps(from, to UpgradeStatus) []txn.Op {
+	return []txn.Op{{
+		C:      info.st.upgradeInfo.Name,
+		Id:     upgradeInfoKey,
+		Assert: D{{"targetversion", info.doc.TargetVersion}, {"status", from}},
+		Update: D{{"$set", D{{"status", to}}}},
+	}}
+}
+
+// Abort marks the upgrade as failed for the given reason, and reverts
+// the environment's agent version to the previous version, so that
+// agents that have already upgraded roll back to the previous tools.
+// Data migration steps must leave the data in state usable by the
+// previous version, as they are not reverted.
+func (info *UpgradeInfo) Abort(reason error) error {
+	ops := []txn.Op{{
+		C:      info.st.settings.Name,
+		Id:     environGlobalKey,
+		Assert: D{{"agent-version", info.doc.TargetVersion.String()}},
+		Update: D{{"$set", D{{"agent-version", info.doc.PreviousVersion.String()}}}},
+	}, {
+		C:  info.st.upgradeInfo.Name,
+		Id: upgradeInfoKey,
+		Assert: D{
+			{"targetversion", info.doc.TargetVersion},
+			{"status", D{{"$in", []UpgradeStatus{UpgradeStarted, UpgradeMigrating}}}},
+		},
+		Update: D{{"$set", D{
+			{"status", UpgradeFailed},
+			{"error", reason.Error()},
+		}}},
+	}}
+	if err := info.st.runTransaction(ops); err != nil {
+		return fmt.Errorf("cannot abort upgrade: %v", onAbort(err, fmt.Errorf("upgrade has changed")))
+	}
+	info.doc.Status = UpgradeFailed
+	info.doc.Error = reason.Error()
+	return nil
+}
+
+// PendingAgents returns the tags of the agents that are not yet
+// running the upgrade's target version. Agents that have never
+// reported their tools, such as those of machines still being
+// provisioned, start with the target version and are not counted;
+// nor are the agents of dead machines and units.
+func (info *UpgradeInfo) PendingAgents() ([]string, error) {
+	match := "^" + regexp.QuoteMeta(info.doc.TargetVersion.String()) + "-"
+	sel := D{
+		{"life", D{{"$ne", Dead}}},
+		{"tools", D{{"$exists", true}}},
+		{"tools.version", D{{"$not", bson.RegEx{match, ""}}}},
+	}
+	return info.st.agentTags(sel)
+}
+
+// CompleteIfDone marks the upgrade as complete if the state servers
+// have migrated the data in state, and every agent is running the
+// target version.
+func (info *UpgradeInfo) CompleteIfDone() error {
+	if info.doc.Status != UpgradeAgents {
+		return nil
+	}
+	pending, err := info.PendingAgents()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return nil
+	}
+	// Another agent may have completed the upgrade already.
+	err = info.st.runTransaction(info.setStatusOps(UpgradeAgents, UpgradeComplete))
+	if err == nil {
+		info.doc.Status = UpgradeComplete
+	}
+	return onAbort(err, nil)
+}
+
+// agentTags returns the tags of the machines and units matching the
+// given selector.
+func (st *State) agentTags(sel D) ([]string, error) {
+	var agentTags []string
+	for _, collection := range []*mgo.Collection{st.machines, st.units} {
+		var doc struct {
+			Id string `bson:"_id"`
+		}
+		iter := collection.Find(sel).Select(D{{"_id", 1}}).Iter()
+		for iter.Next(&doc) {
+			switch collection.Name {
+			case "machines":
+				agentTags = append(agentTags, names.MachineTag(doc.Id))
+			case "units":
+				agentTags = append(agentTags, names.UnitTag(doc.Id))
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return agentTags, nil
+}
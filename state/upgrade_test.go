@@ -0,0 +1,196 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package state_test
+
+import (
+	"fmt"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/version"
+)
+
+type UpgradeSuite struct {
+	ConnSuite
+	current version.Number
+	target  version.Number
+	machine *state.Machine
+	unit    *state.Unit
+}
+
+var _ = gc.Suite(&UpgradeSuite{})
+
+func (s *UpgradeSuite) SetUpTest(c *gc.C) {
+	s.ConnSuite.SetUpTest(c)
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	var ok bool
+	s.current, ok = envConfig.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	s.target = s.current
+	s.target.Minor++
+
+	s.machine, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	service, err := s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	s.unit, err = service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.setAgentVersions(c, s.current)
+}
+
+func (s *UpgradeSuite) setAgentVersions(c *gc.C, vers version.Number) {
+	tools := version.Binary{Number: vers, Series: "quantal", Arch: "amd64"}
+	err := s.machine.SetAgentVersion(tools)
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetAgentVersion(tools)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *UpgradeSuite) assertAgentVersion(c *gc.C, vers version.Number) {
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	agentVersion, ok := envConfig.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(agentVersion, gc.Equals, vers)
+}
+
+func (s *UpgradeSuite) TestStartUpgrade(c *gc.C) {
+	_, err := s.State.UpgradeInfo()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	err = s.State.StartUpgrade(s.target)
+	c.Assert(err, gc.IsNil)
+	s.assertAgentVersion(c, s.target)
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.PreviousVersion(), gc.Equals, s.current)
+	c.Assert(info.TargetVersion(), gc.Equals, s.target)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeStarted)
+	c.Assert(info.InProgress(), jc.IsTrue)
+	c.Assert(info.StateServersFirst(), jc.IsTrue)
+	c.Assert(info.Error(), gc.Equals, "")
+
+	other := s.target
+	other.Patch++
+	err = s.State.StartUpgrade(other)
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf("upgrade to %s is already in progress", s.target))
+}
+
+func (s *UpgradeSuite) TestStartUpgradeErrors(c *gc.C) {
+	err := s.State.StartUpgrade(s.current)
+	c.Assert(err, gc.ErrorMatches, fmt.Sprintf("environment is already running version %s", s.current))
+
+	err = s.unit.SetAgentVersion(version.MustParseBinary("0.0.1-quantal-amd64"))
+	c.Assert(err, gc.IsNil)
+	err = s.State.StartUpgrade(s.target)
+	c.Assert(err, jc.Satisfies, state.IsVersionInconsistentError)
+	s.assertAgentVersion(c, s.current)
+	_, err = s.State.UpgradeInfo()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+}
+
+func (s *UpgradeSuite) TestUpgradeCompletes(c *gc.C) {
+	err := s.State.StartUpgrade(s.target)
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+
+	// Only one state server migrates the data.
+	ok, err := info.BeginMigration("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeMigrating)
+	other, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	ok, err = other.BeginMigration("1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsFalse)
+	// The migrating machine may resume after a restart.
+	ok, err = other.BeginMigration("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+
+	err = info.EndMigration()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeAgents)
+	c.Assert(info.StateServersFirst(), jc.IsFalse)
+	err = info.EndMigration()
+	c.Assert(err, gc.ErrorMatches, `cannot set upgrade status to "upgrading-agents": upgrade is not migrating`)
+
+	pending, err := info.PendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.DeepEquals, []string{"machine-0", "unit-wordpress-0"})
+	err = info.CompleteIfDone()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeAgents)
+
+	// Machines that are still being provisioned, and whose agents have
+	// not yet reported their tools, do not hold up the upgrade.
+	_, err = s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	s.setAgentVersions(c, s.target)
+	pending, err = info.PendingAgents()
+	c.Assert(err, gc.IsNil)
+	c.Assert(pending, gc.HasLen, 0)
+	err = info.CompleteIfDone()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeComplete)
+	c.Assert(info.InProgress(), jc.IsFalse)
+
+	// Completing twice is harmless.
+	err = other.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(other.Status(), gc.Equals, state.UpgradeComplete)
+	err = other.CompleteIfDone()
+	c.Assert(err, gc.IsNil)
+
+	// Another upgrade may now start.
+	next := s.target
+	next.Minor++
+	err = s.State.StartUpgrade(next)
+	c.Assert(err, gc.IsNil)
+	err = info.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.PreviousVersion(), gc.Equals, s.target)
+	c.Assert(info.TargetVersion(), gc.Equals, next)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeStarted)
+}
+
+func (s *UpgradeSuite) TestAbort(c *gc.C) {
+	err := s.State.StartUpgrade(s.target)
+	c.Assert(err, gc.IsNil)
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	ok, err := info.BeginMigration("0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(ok, jc.IsTrue)
+
+	err = info.Abort(fmt.Errorf("boom"))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeFailed)
+	c.Assert(info.Error(), gc.Equals, "boom")
+	s.assertAgentVersion(c, s.current)
+
+	err = info.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeFailed)
+	c.Assert(info.Error(), gc.Equals, "boom")
+	c.Assert(info.InProgress(), jc.IsFalse)
+
+	err = info.Abort(fmt.Errorf("boom"))
+	c.Assert(err, gc.ErrorMatches, "cannot abort upgrade: upgrade has changed")
+
+	// The upgrade may be retried.
+	err = s.State.StartUpgrade(s.target)
+	c.Assert(err, gc.IsNil)
+	err = info.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeStarted)
+	c.Assert(info.Error(), gc.Equals, "")
+}
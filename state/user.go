@@ -6,6 +6,7 @@ package state
 import (
 	"fmt"
 	"regexp"
+	"sort"
 
 	"labix.org/v2/mgo"
 	"labix.org/v2/mgo/txn"
@@ -16,11 +17,56 @@ import (
 
 var validUser = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9]*$")
 
-// AddUser adds a user to the state.
+// Permission defines what a user may do in the environment.
+type Permission string
+
+const (
+	// ReadPermission allows a user to inspect the environment.
+	ReadPermission Permission = "read"
+
+	// WritePermission additionally allows a user to change
+	// the environment.
+	WritePermission Permission = "write"
+
+	// AdminPermission additionally allows a user to manage
+	// other users.
+	AdminPermission Permission = "admin"
+)
+
+var permissionLevels = map[Permission]int{
+	ReadPermission:  1,
+	WritePermission: 2,
+	AdminPermission: 3,
+}
+
+// Validate returns an error if p is not a known permission.
+func (p Permission) Validate() error {
+	if _, ok := permissionLevels[p]; !ok {
+		return fmt.Errorf("invalid permission %q", p)
+	}
+	return nil
+}
+
+// Includes returns whether p grants everything granted by other.
+func (p Permission) Includes(other Permission) bool {
+	level, ok := permissionLevels[p]
+	return ok && level >= permissionLevels[other]
+}
+
+// AddUser adds a user with admin permission to the state.
 func (st *State) AddUser(name, password string) (*User, error) {
+	return st.AddUserWithPermission(name, password, AdminPermission)
+}
+
+// AddUserWithPermission adds a user with the given permission
+// to the state.
+func (st *State) AddUserWithPermission(name, password string, perm Permission) (*User, error) {
 	if !validUser.MatchString(name) {
 		return nil, fmt.Errorf("invalid user name %q", name)
 	}
+	if err := perm.Validate(); err != nil {
+		return nil, err
+	}
 	salt, err := utils.RandomSalt()
 	if err != nil {
 		return nil, err
@@ -31,6 +77,7 @@ func (st *State) AddUser(name, password string) (*User, error) {
 			Name:         name,
 			PasswordHash: utils.UserPasswordHash(password, salt),
 			PasswordSalt: salt,
+			Permission:   perm,
 		},
 	}
 	ops := []txn.Op{{
@@ -68,6 +115,26 @@ func (st *State) User(name string) (*User, error) {
 	return u, nil
 }
 
+// AllUsers returns all the users in the state, sorted by name.
+func (st *State) AllUsers() ([]*User, error) {
+	var udocs []userDoc
+	if err := st.users.Find(nil).All(&udocs); err != nil {
+		return nil, fmt.Errorf("cannot get all users: %v", err)
+	}
+	users := make([]*User, len(udocs))
+	for i := range udocs {
+		users[i] = &User{st: st, doc: udocs[i]}
+	}
+	sort.Sort(userSlice(users))
+	return users, nil
+}
+
+type userSlice []*User
+
+func (s userSlice) Len() int           { return len(s) }
+func (s userSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s userSlice) Less(i, j int) bool { return s[i].Name() < s[j].Name() }
+
 // User represents a juju client user.
 type User struct {
 	st  *State
@@ -78,6 +145,11 @@ type userDoc struct {
 	Name         string `bson:"_id_"`
 	PasswordHash string
 	PasswordSalt string
+	// Permission is empty for users added before
+	// permissions were introduced, who have admin
+	// permission.
+	Permission Permission
+	Disabled   bool
 }
 
 // Name returns the user name,
@@ -91,6 +163,83 @@ func (u *User) Tag() string {
 	return "user-" + u.doc.Name
 }
 
+// Permission returns the user's permission.
+func (u *User) Permission() Permission {
+	if u.doc.Permission == "" {
+		return AdminPermission
+	}
+	return u.doc.Permission
+}
+
+// SetPermission changes the user's permission.
+func (u *User) SetPermission(perm Permission) error {
+	if err := perm.Validate(); err != nil {
+		return err
+	}
+	if err := u.update(D{{"permission", perm}}); err != nil {
+		return fmt.Errorf("cannot set permission of user %q: %v", u.Name(), err)
+	}
+	u.doc.Permission = perm
+	return nil
+}
+
+// IsDisabled returns whether the user has been disabled. A disabled
+// user cannot log in.
+func (u *User) IsDisabled() bool {
+	return u.doc.Disabled
+}
+
+// Disable prevents the user from logging in.
+func (u *User) Disable() error {
+	return u.setDisabled(true)
+}
+
+// Enable allows a disabled user to log in again.
+func (u *User) Enable() error {
+	return u.setDisabled(false)
+}
+
+func (u *User) setDisabled(disabled bool) error {
+	if err := u.update(D{{"disabled", disabled}}); err != nil {
+		return fmt.Errorf("cannot update user %q: %v", u.Name(), err)
+	}
+	u.doc.Disabled = disabled
+	return nil
+}
+
+// update sets the given fields of the user document.
+func (u *User) update(fields D) error {
+	ops := []txn.Op{{
+		C:      u.st.users.Name,
+		Id:     u.Name(),
+		Assert: txn.DocExists,
+		Update: D{{"$set", fields}},
+	}}
+	err := u.st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		return errors.NotFoundf("user %q", u.Name())
+	}
+	return err
+}
+
+// Remove removes the user from the state.
+func (u *User) Remove() error {
+	ops := []txn.Op{{
+		C:      u.st.users.Name,
+		Id:     u.Name(),
+		Assert: txn.DocExists,
+		Remove: true,
+	}}
+	err := u.st.runTransaction(ops)
+	if err == txn.ErrAborted {
+		return errors.NotFoundf("user %q", u.Name())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot remove user %q: %v", u.Name(), err)
+	}
+	return nil
+}
+
 // SetPassword sets the password associated with the user.
 func (u *User) SetPassword(password string) error {
 	salt, err := utils.RandomSalt()
@@ -119,8 +268,12 @@ func (u *User) SetPasswordHash(pwHash string, pwSalt string) error {
 }
 
 // PasswordValid returns whether the given password
-// is valid for the user.
+// is valid for the user. It always returns false
+// for a disabled user.
 func (u *User) PasswordValid(password string) bool {
+	if u.doc.Disabled {
+		return false
+	}
 	// Since these are potentially set by a User, we intentionally use the
 	// slower pbkdf2 style hashing. Also, we don't expect to have thousands
 	// of Users trying to log in at the same time (which we *do* expect of
@@ -6,6 +6,7 @@ package state_test
 import (
 	gc "launchpad.net/gocheck"
 
+	"launchpad.net/juju-core/errors"
 	"launchpad.net/juju-core/state"
 	jc "launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/utils"
@@ -146,3 +147,100 @@ func (s *UserSuite) TestName(c *gc.C) {
 	c.Assert(u.Name(), gc.Equals, "someuser")
 	c.Assert(u.Tag(), gc.Equals, "user-someuser")
 }
+
+func (s *UserSuite) TestAddUserWithPermission(c *gc.C) {
+	u, err := s.State.AddUser("admin1", "")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.AdminPermission)
+
+	u, err = s.State.AddUserWithPermission("reader", "", state.ReadPermission)
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.ReadPermission)
+	u, err = s.State.User("reader")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.ReadPermission)
+
+	_, err = s.State.AddUserWithPermission("bad", "", "superuser")
+	c.Assert(err, gc.ErrorMatches, `invalid permission "superuser"`)
+}
+
+func (s *UserSuite) TestPermissionIncludes(c *gc.C) {
+	for i, test := range []struct {
+		perm, other state.Permission
+		expect      bool
+	}{
+		{state.AdminPermission, state.WritePermission, true},
+		{state.AdminPermission, state.AdminPermission, true},
+		{state.WritePermission, state.ReadPermission, true},
+		{state.WritePermission, state.AdminPermission, false},
+		{state.ReadPermission, state.WritePermission, false},
+		{"", state.ReadPermission, false},
+	} {
+		c.Logf("test %d: %q includes %q", i, test.perm, test.other)
+		c.Check(test.perm.Includes(test.other), gc.Equals, test.expect)
+	}
+}
+
+func (s *UserSuite) TestSetPermission(c *gc.C) {
+	u, err := s.State.AddUserWithPermission("someuser", "", state.ReadPermission)
+	c.Assert(err, gc.IsNil)
+	err = u.SetPermission(state.WritePermission)
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.WritePermission)
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.Permission(), gc.Equals, state.WritePermission)
+
+	err = u.SetPermission("bad")
+	c.Assert(err, gc.ErrorMatches, `invalid permission "bad"`)
+}
+
+func (s *UserSuite) TestDisable(c *gc.C) {
+	u, err := s.State.AddUser("someuser", "password")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsFalse)
+
+	err = u.Disable()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsTrue)
+	c.Assert(u.PasswordValid("password"), jc.IsFalse)
+
+	u, err = s.State.User("someuser")
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsTrue)
+
+	err = u.Enable()
+	c.Assert(err, gc.IsNil)
+	c.Assert(u.IsDisabled(), jc.IsFalse)
+	c.Assert(u.PasswordValid("password"), jc.IsTrue)
+}
+
+func (s *UserSuite) TestRemove(c *gc.C) {
+	u, err := s.State.AddUser("someuser", "")
+	c.Assert(err, gc.IsNil)
+	err = u.Remove()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.User("someuser")
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+
+	err = u.Remove()
+	c.Assert(err, jc.Satisfies, errors.IsNotFoundError)
+	err = u.Disable()
+	c.Assert(err, gc.ErrorMatches, `cannot update user "someuser": user "someuser" not found`)
+}
+
+func (s *UserSuite) TestAllUsers(c *gc.C) {
+	before, err := s.State.AllUsers()
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddUser("zed", "")
+	c.Assert(err, gc.IsNil)
+	_, err = s.State.AddUser("abe", "")
+	c.Assert(err, gc.IsNil)
+
+	users, err := s.State.AllUsers()
+	c.Assert(err, gc.IsNil)
+	c.Assert(users, gc.HasLen, len(before)+2)
+	for i := 1; i < len(users); i++ {
+		c.Assert(users[i-1].Name() < users[i].Name(), jc.IsTrue)
+	}
+}
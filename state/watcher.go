@@ -438,6 +438,112 @@ func (w *minUnitsWatcher) Changes() <-chan []string {
 	return w.out
 }
 
+// actionsWatcher notifies about charm actions queued on a unit. The first
+// event returned by the watcher holds the ids of all the unit's pending
+// actions; subsequent events hold the ids of actions newly queued on the
+// unit.
+type actionsWatcher struct {
+	commonWatcher
+	unitName string
+	prefix   string
+	known    set.Strings
+	out      chan []string
+}
+
+var _ Watcher = (*actionsWatcher)(nil)
+
+func newActionsWatcher(st *State, unitName string) StringsWatcher {
+	w := &actionsWatcher{
+		commonWatcher: commonWatcher{st: st},
+		unitName:      unitName,
+		prefix:        actionPrefix(unitName),
+		out:           make(chan []string),
+	}
+	go func() {
+		defer w.tomb.Done()
+		defer close(w.out)
+		w.tomb.Kill(w.loop())
+	}()
+	return w
+}
+
+// WatchActions returns a StringsWatcher that notifies of the ids of
+// pending charm actions queued on the unit.
+func (u *Unit) WatchActions() StringsWatcher {
+	return newActionsWatcher(u.st, u.doc.Name)
+}
+
+func (w *actionsWatcher) initial() (*set.Strings, error) {
+	ids := new(set.Strings)
+	doc := &actionDoc{}
+	sel := D{{"unit", w.unitName}, {"status", params.ActionPending}}
+	iter := w.st.actions.Find(sel).Iter()
+	for iter.Next(doc) {
+		w.known.Add(doc.Id)
+		ids.Add(doc.Id)
+	}
+	return ids, iter.Err()
+}
+
+func (w *actionsWatcher) merge(ids *set.Strings, change watcher.Change) error {
+	id := change.Id.(string)
+	if !strings.HasPrefix(id, w.prefix) {
+		return nil
+	}
+	if change.Revno == -1 {
+		w.known.Remove(id)
+		ids.Remove(id)
+		return nil
+	}
+	if w.known.Contains(id) {
+		return nil
+	}
+	doc := actionDoc{}
+	if err := w.st.actions.FindId(id).One(&doc); err == mgo.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	w.known.Add(id)
+	if doc.Status == params.ActionPending {
+		ids.Add(id)
+	}
+	return nil
+}
+
+func (w *actionsWatcher) loop() (err error) {
+	ch := make(chan watcher.Change)
+	w.st.watcher.WatchCollection(w.st.actions.Name, ch)
+	defer w.st.watcher.UnwatchCollection(w.st.actions.Name, ch)
+	ids, err := w.initial()
+	if err != nil {
+		return err
+	}
+	out := w.out
+	for {
+		select {
+		case <-w.tomb.Dying():
+			return tomb.ErrDying
+		case <-w.st.watcher.Dead():
+			return stateWatcherDeadError(w.st.watcher.Err())
+		case change := <-ch:
+			if err = w.merge(ids, change); err != nil {
+				return err
+			}
+			if !ids.IsEmpty() {
+				out = w.out
+			}
+		case out <- ids.SortedValues():
+			out = nil
+			ids = new(set.Strings)
+		}
+	}
+}
+
+func (w *actionsWatcher) Changes() <-chan []string {
+	return w.out
+}
+
 // RelationScopeWatcher observes changes to the set of units
 // in a particular relation scope.
 type RelationScopeWatcher struct {
@@ -1080,7 +1186,28 @@ func (u *Unit) WatchConfigSettings() (NotifyWatcher, error) {
 	return newEntityWatcher(u.st, u.st.settings, settingsKey), nil
 }
 
+// WatchForAgentVersionChanges returns a NotifyWatcher waiting for the
+// environment's agent version, or the progress of an upgrade, to change.
+func (st *State) WatchForAgentVersionChanges() NotifyWatcher {
+	return newDocWatcher(st, []docKey{
+		{st.settings, environGlobalKey},
+		{st.upgradeInfo, upgradeInfoKey},
+	})
+}
+
+// docKey identifies a document watched by an entityWatcher.
+type docKey struct {
+	coll *mgo.Collection
+	key  string
+}
+
 func newEntityWatcher(st *State, coll *mgo.Collection, key string) NotifyWatcher {
+	return newDocWatcher(st, []docKey{{coll, key}})
+}
+
+// newDocWatcher returns a NotifyWatcher that notifies when any of the
+// given documents changes.
+func newDocWatcher(st *State, docKeys []docKey) NotifyWatcher {
 	w := &entityWatcher{
 		commonWatcher: commonWatcher{st: st},
 		out:           make(chan struct{}),
@@ -1088,7 +1215,7 @@ func newEntityWatcher(st *State, coll *mgo.Collection, key string) NotifyWatcher
 	go func() {
 		defer w.tomb.Done()
 		defer close(w.out)
-		w.tomb.Kill(w.loop(coll, key))
+		w.tomb.Kill(w.loop(docKeys))
 	}()
 	return w
 }
@@ -1098,19 +1225,21 @@ func (w *entityWatcher) Changes() <-chan struct{} {
 	return w.out
 }
 
-func (w *entityWatcher) loop(coll *mgo.Collection, key string) (err error) {
-	doc := &struct {
-		TxnRevno int64 `bson:"txn-revno"`
-	}{}
-	fields := D{{"txn-revno", 1}}
-	if err := coll.FindId(key).Select(fields).One(doc); err == mgo.ErrNotFound {
-		doc.TxnRevno = -1
-	} else if err != nil {
-		return err
-	}
+func (w *entityWatcher) loop(docKeys []docKey) (err error) {
 	in := make(chan watcher.Change)
-	w.st.watcher.Watch(coll.Name, key, doc.TxnRevno, in)
-	defer w.st.watcher.Unwatch(coll.Name, key, in)
+	for _, k := range docKeys {
+		doc := &struct {
+			TxnRevno int64 `bson:"txn-revno"`
+		}{}
+		fields := D{{"txn-revno", 1}}
+		if err := k.coll.FindId(k.key).Select(fields).One(doc); err == mgo.ErrNotFound {
+			doc.TxnRevno = -1
+		} else if err != nil {
+			return err
+		}
+		w.st.watcher.Watch(k.coll.Name, k.key, doc.TxnRevno, in)
+		defer w.st.watcher.Unwatch(k.coll.Name, k.key, in)
+	}
 	out := w.out
 	for {
 		select {
@@ -0,0 +1,154 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"labix.org/v2/mgo"
+	"labix.org/v2/mgo/bson"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/log"
+)
+
+// BundleSeries is the series used in the URLs of bundles, as in
+// cs:bundle/wordpress-simple, which keeps them apart from charms.
+const BundleSeries = "bundle"
+
+// bundleDoc represents the document stored in MongoDB for a bundle.
+type bundleDoc struct {
+	URL      *charm.URL
+	Revision int
+	Digest   string
+	Sha256   string
+	Data     []byte
+}
+
+// BundleInfo holds a bundle published in the store.
+type BundleInfo struct {
+	revision int
+	digest   string
+	sha256   string
+	data     []byte
+}
+
+// Revision returns the store bundle's revision.
+func (bi *BundleInfo) Revision() int {
+	return bi.revision
+}
+
+// Digest returns the unique identifier that represents the bundle
+// data imported. This is typically set to the VCS revision digest.
+func (bi *BundleInfo) Digest() string {
+	return bi.digest
+}
+
+// Sha256 returns the sha256 checksum of the bundle data.
+func (bi *BundleInfo) Sha256() string {
+	return bi.sha256
+}
+
+// Data returns the bundle data, in YAML format.
+func (bi *BundleInfo) Data() []byte {
+	return bi.data
+}
+
+// BundleData returns the parsed bundle data.
+func (bi *BundleInfo) BundleData() (*charm.BundleData, error) {
+	return charm.ReadBundleData(bytes.NewReader(bi.data))
+}
+
+// PublishBundle verifies the bundle data and makes it available in the
+// store at url, which must have the bundle series and no revision.
+// The digest parameter must contain the unique identifier that
+// represents the bundle data being imported (e.g. the VCS revision
+// sha1). ErrRedundantUpdate is returned if the latest revision of the
+// bundle already has that digest.
+func (s *Store) PublishBundle(url *charm.URL, data []byte, digest string) (revision int, err error) {
+	log.Infof("store: Trying to add bundle %v with key %q...", url, digest)
+	if err = mustLackRevision("PublishBundle", url); err != nil {
+		return 0, err
+	}
+	if url.Series != BundleSeries {
+		return 0, fmt.Errorf("PublishBundle: got URL with series %q, not %q: %s", url.Series, BundleSeries, url)
+	}
+	bd, err := charm.ReadBundleData(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	err = bd.Verify(func(cons string) error {
+		_, err := constraints.Parse(cons)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("invalid bundle %s: %v", url, err)
+	}
+	session := s.session.Copy()
+	defer session.Close()
+
+	bundles := session.Bundles()
+	var doc bundleDoc
+	err = bundles.Find(bson.D{{"url", url}}).Sort("-revision").One(&doc)
+	switch {
+	case err == mgo.ErrNotFound:
+		log.Infof("store: Bundle %s not yet in the store.", url)
+		doc.Revision = -1
+	case err != nil:
+		log.Errorf("store: Unknown error looking for bundle %s: %s", url, err)
+		return 0, err
+	case doc.Digest == digest:
+		log.Infof("store: Bundle %s already has revision key %q. Nothing to update.", url, digest)
+		return 0, ErrRedundantUpdate
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	doc = bundleDoc{
+		URL:      url,
+		Revision: doc.Revision + 1,
+		Digest:   digest,
+		Sha256:   hex.EncodeToString(hash.Sum(nil)),
+		Data:     data,
+	}
+	if err := bundles.Insert(&doc); err != nil {
+		err = maybeConflict(err)
+		log.Errorf("store: Failed to insert new revision of bundle %v: %v", url, err)
+		return 0, err
+	}
+	return doc.Revision, nil
+}
+
+// BundleInfo retrieves the bundle at url. If url has no revision,
+// the latest revision is returned.
+func (s *Store) BundleInfo(url *charm.URL) (*BundleInfo, error) {
+	session := s.session.Copy()
+	defer session.Close()
+
+	log.Debugf("store: Retrieving bundle %s", url)
+	rev := url.Revision
+	url = url.WithRevision(-1)
+
+	var qdoc interface{}
+	if rev == -1 {
+		qdoc = bson.D{{"url", url}}
+	} else {
+		qdoc = bson.D{{"url", url}, {"revision", rev}}
+	}
+	var doc bundleDoc
+	err := session.Bundles().Find(qdoc).Sort("-revision").One(&doc)
+	if err != nil {
+		log.Errorf("store: Failed to find bundle %s: %v", url, err)
+		return nil, ErrNotFound
+	}
+	return &BundleInfo{
+		revision: doc.Revision,
+		digest:   doc.Digest,
+		sha256:   doc.Sha256,
+		data:     doc.Data,
+	}, nil
+}
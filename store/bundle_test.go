@@ -0,0 +1,69 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package store_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/store"
+)
+
+const testBundleData = `
+services:
+    wordpress:
+        charm: cs:precise/wordpress
+        num_units: 1
+    mysql:
+        charm: cs:precise/mysql
+        num_units: 1
+relations:
+    - ["wordpress", "mysql"]
+`
+
+func (s *StoreSuite) TestPublishBundle(c *gc.C) {
+	url := charm.MustParseURL("cs:bundle/wordpress-simple")
+	rev, err := s.store.PublishBundle(url, []byte(testBundleData), "digest-0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(rev, gc.Equals, 0)
+
+	// Publishing the same digest again is redundant.
+	_, err = s.store.PublishBundle(url, []byte(testBundleData), "digest-0")
+	c.Assert(err, gc.Equals, store.ErrRedundantUpdate)
+
+	rev, err = s.store.PublishBundle(url, []byte(testBundleData+"    - [\"wordpress\", \"mysql:server\"]\n"), "digest-1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(rev, gc.Equals, 1)
+
+	info, err := s.store.BundleInfo(url)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 1)
+	c.Assert(info.Digest(), gc.Equals, "digest-1")
+
+	info, err = s.store.BundleInfo(url.WithRevision(0))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Revision(), gc.Equals, 0)
+	c.Assert(string(info.Data()), gc.Equals, testBundleData)
+	c.Assert(info.Sha256(), gc.HasLen, 64)
+	bd, err := info.BundleData()
+	c.Assert(err, gc.IsNil)
+	c.Assert(bd.ServiceNames(), gc.DeepEquals, []string{"mysql", "wordpress"})
+
+	_, err = s.store.BundleInfo(url.WithRevision(2))
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+}
+
+func (s *StoreSuite) TestPublishBundleErrors(c *gc.C) {
+	_, err := s.store.PublishBundle(charm.MustParseURL("cs:bundle/wordpress-simple-1"), []byte(testBundleData), "digest")
+	c.Assert(err, gc.ErrorMatches, "PublishBundle: got charm URL with revision: cs:bundle/wordpress-simple-1")
+
+	_, err = s.store.PublishBundle(charm.MustParseURL("cs:precise/wordpress-simple"), []byte(testBundleData), "digest")
+	c.Assert(err, gc.ErrorMatches, `PublishBundle: got URL with series "precise", not "bundle": cs:precise/wordpress-simple`)
+
+	_, err = s.store.PublishBundle(charm.MustParseURL("cs:bundle/wordpress-simple"), []byte("services: {}"), "digest")
+	c.Assert(err, gc.ErrorMatches, "invalid bundle cs:bundle/wordpress-simple: bundle has no services")
+
+	_, err = s.store.BundleInfo(charm.MustParseURL("cs:bundle/wordpress-simple"))
+	c.Assert(err, gc.Equals, store.ErrNotFound)
+}
@@ -38,6 +38,9 @@ func NewServer(store *Store) (*Server, error) {
 	s.mux.HandleFunc("/charm/", func(w http.ResponseWriter, r *http.Request) {
 		s.serveCharm(w, r)
 	})
+	s.mux.HandleFunc("/bundle/", func(w http.ResponseWriter, r *http.Request) {
+		s.serveBundle(w, r)
+	})
 	s.mux.HandleFunc("/stats/counter/", func(w http.ResponseWriter, r *http.Request) {
 		s.serveStats(w, r)
 	})
@@ -197,6 +200,37 @@ func (s *Server) serveCharm(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveBundle serves the data of the bundle named in the request
+// path, as in /bundle/~user/bundle/wordpress-simple-3.
+func (s *Server) serveBundle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/bundle/") {
+		panic("serveBundle: bad url")
+	}
+	curl, err := charm.ParseURL("cs:" + r.URL.Path[len("/bundle/"):])
+	if err != nil || curl.Series != BundleSeries {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	info, err := s.store.BundleInfo(curl)
+	if err == ErrNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Errorf("store: cannot get bundle %q: %v", curl, err)
+		return
+	}
+	if statsEnabled(r) {
+		go s.store.IncCounter(charmStatsKey(curl, "bundle"))
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(info.Data())))
+	if _, err := w.Write(info.Data()); err != nil {
+		log.Errorf("store: failed to send bundle %q: %v", curl, err)
+	}
+}
+
 func (s *Server) serveStats(w http.ResponseWriter, r *http.Request) {
 	// TODO: Adopt a smarter mux that simplifies this logic.
 	const dir = "/stats/counter/"
@@ -217,6 +217,28 @@ func (s *StoreSuite) TestCharmStreaming(c *gc.C) {
 	s.checkCounterSum(c, []string{"charm-bundle", curl.Series, curl.Name}, false, 1)
 }
 
+func (s *StoreSuite) TestBundleServing(c *gc.C) {
+	curl := charm.MustParseURL("cs:bundle/wordpress-simple")
+	_, err := s.store.PublishBundle(curl, []byte(testBundleData), "some-digest")
+	c.Assert(err, gc.IsNil)
+	server, err := store.NewServer(s.store)
+	c.Assert(err, gc.IsNil)
+
+	for _, path := range []string{"/bundle/bundle/wordpress-simple", "/bundle/bundle/wordpress-simple-0"} {
+		req, err := http.NewRequest("GET", path, nil)
+		c.Assert(err, gc.IsNil)
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		c.Assert(rec.Code, gc.Equals, 200)
+		c.Assert(rec.Body.String(), gc.Equals, testBundleData)
+		c.Assert(rec.Header().Get("Content-Type"), gc.Equals, "application/x-yaml")
+		c.Assert(rec.Header().Get("Content-Length"), gc.Equals, strconv.Itoa(len(testBundleData)))
+	}
+
+	// Check that it was accounted for in statistics.
+	s.checkCounterSum(c, []string{"bundle", curl.Series, curl.Name}, false, 2)
+}
+
 func (s *StoreSuite) TestDisableStats(c *gc.C) {
 	server, curl := s.prepareServer(c)
 
@@ -250,6 +272,9 @@ func (s *StoreSuite) TestServerStatus(c *gc.C) {
 		{"/charm-info/any", 404},
 		{"/charm/bad-url", 404},
 		{"/charm/bad-series/wordpress", 404},
+		{"/bundle/bad-url", 404},
+		{"/bundle/precise/wordpress", 404},
+		{"/bundle/bundle/missing", 404},
 		{"/stats/counter/", 403},
 		{"/stats/counter/*", 403},
 		{"/stats/counter/any/", 404},
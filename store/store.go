@@ -31,6 +31,7 @@ import (
 //     juju.events        - Log of events relating to the lifecycle of charms
 //     juju.charms        - Information about the stored charms
 //     juju.charmfs.*     - GridFS with the charm files
+//     juju.bundles       - Information about the stored bundles, and their data
 //     juju.locks         - Has unique keys with url of updating charms
 //     juju.stat.counters - Counters for statistics
 //     juju.stat.tokens   - Tokens used in statistics counter keys
@@ -103,6 +104,9 @@ func (s *Store) ensureIndexes() error {
 	}, {
 		session.Charms(),
 		mgo.Index{Key: []string{"urls", "revision"}, Unique: true},
+	}, {
+		session.Bundles(),
+		mgo.Index{Key: []string{"url", "revision"}, Unique: true},
 	}, {
 		session.Events(),
 		mgo.Index{Key: []string{"urls", "digest"}},
@@ -907,6 +911,11 @@ func (s *storeSession) CharmFS() *mgo.GridFS {
 	return s.DB("juju").GridFS("charmfs")
 }
 
+// Bundles returns the mongo collection where bundles are stored.
+func (s *storeSession) Bundles() *mgo.Collection {
+	return s.DB("juju").C("bundles")
+}
+
 // Events returns the mongo collection where charm events are stored.
 func (s *storeSession) Events() *mgo.Collection {
 	return s.DB("juju").C("events")
@@ -0,0 +1,87 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The upgrades package holds the versioned steps that migrate the
+// data in state when the environment is upgraded.
+package upgrades
+
+import (
+	"fmt"
+
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/version"
+)
+
+var logger = loggo.GetLogger("juju.upgrades")
+
+// Step defines an idempotent operation that migrates the data in
+// state. A step must leave the data usable by the previous version,
+// because a failed upgrade rolls the agents back to it.
+type Step interface {
+	// Description is a human readable description of what the step does.
+	Description() string
+
+	// Run performs the step.
+	Run(st *state.State) error
+}
+
+// Operation holds the steps that must be run when upgrading to the
+// given version.
+type Operation struct {
+	TargetVersion version.Number
+	Steps         []Step
+}
+
+// upgradeOperations returns the ordered operations that migrate the
+// data in state. It is a variable so that tests can replace it.
+var upgradeOperations = func() []Operation {
+	return []Operation{}
+}
+
+// PerformUpgrade runs the steps of every operation that targets a
+// version later than from, up to and including the current version.
+func PerformUpgrade(from version.Number, st *state.State) error {
+	for _, op := range upgradeOperations() {
+		if !from.Less(op.TargetVersion) || version.Current.Number.Less(op.TargetVersion) {
+			continue
+		}
+		for _, step := range op.Steps {
+			logger.Infof("running upgrade step to %s: %s", op.TargetVersion, step.Description())
+			if err := step.Run(st); err != nil {
+				return fmt.Errorf("upgrade step %q failed: %v", step.Description(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateState migrates the data in state if the environment is being
+// upgraded to the current version, and no other state server machine
+// has claimed the migration. If the migration fails, the upgrade is
+// aborted so that every agent rolls back to the previous version; the
+// error is logged rather than returned, so that the state server keeps
+// serving the API the agents need to roll back.
+func MigrateState(st *state.State, machineId string) error {
+	info, err := st.UpgradeInfo()
+	if errors.IsNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.TargetVersion() != version.Current.Number {
+		return nil
+	}
+	if ok, err := info.BeginMigration(machineId); err != nil || !ok {
+		return err
+	}
+	logger.Infof("migrating state from %s to %s", info.PreviousVersion(), info.TargetVersion())
+	if err := PerformUpgrade(info.PreviousVersion(), st); err != nil {
+		logger.Errorf("upgrade to %s failed, rolling back to %s: %v", info.TargetVersion(), info.PreviousVersion(), err)
+		return info.Abort(err)
+	}
+	logger.Infof("state migrated to %s", info.TargetVersion())
+	return info.EndMigration()
+}
@@ -0,0 +1,187 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package upgrades_test
+
+import (
+	"fmt"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/upgrades"
+	"launchpad.net/juju-core/version"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type upgradesSuite struct {
+	testing.JujuConnSuite
+	ran []string
+}
+
+var _ = gc.Suite(&upgradesSuite{})
+
+type mockStep struct {
+	description string
+	err         error
+	ran         *[]string
+}
+
+func (step *mockStep) Description() string {
+	return step.description
+}
+
+func (step *mockStep) Run(st *state.State) error {
+	*step.ran = append(*step.ran, step.description)
+	return step.err
+}
+
+func (s *upgradesSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.ran = nil
+}
+
+func (s *upgradesSuite) patchOperations(failing string) {
+	step := func(description string) upgrades.Step {
+		step := &mockStep{description: description, ran: &s.ran}
+		if description == failing {
+			step.err = fmt.Errorf("boom")
+		}
+		return step
+	}
+	s.PatchValue(upgrades.UpgradeOperations, func() []upgrades.Operation {
+		return []upgrades.Operation{{
+			TargetVersion: version.MustParse("1.16.0"),
+			Steps:         []upgrades.Step{step("step 1")},
+		}, {
+			TargetVersion: version.MustParse("1.17.0"),
+			Steps:         []upgrades.Step{step("step 2"), step("step 3")},
+		}, {
+			TargetVersion: version.MustParse("1.18.0"),
+			Steps:         []upgrades.Step{step("step 4")},
+		}}
+	})
+}
+
+func (s *upgradesSuite) patchCurrentVersion(vers string) {
+	current := version.Current
+	current.Number = version.MustParse(vers)
+	s.PatchValue(&version.Current, current)
+}
+
+var performUpgradeTests = []struct {
+	about   string
+	from    string
+	current string
+	ran     []string
+}{{
+	about:   "steps up to the current version are run",
+	from:    "1.15.0",
+	current: "1.17.0",
+	ran:     []string{"step 1", "step 2", "step 3"},
+}, {
+	about:   "steps up to the previous version are not run",
+	from:    "1.16.0",
+	current: "1.18.0",
+	ran:     []string{"step 2", "step 3", "step 4"},
+}, {
+	about:   "no steps are run without a version change",
+	from:    "1.17.0",
+	current: "1.17.0",
+}}
+
+func (s *upgradesSuite) TestPerformUpgrade(c *gc.C) {
+	s.patchOperations("")
+	for i, test := range performUpgradeTests {
+		c.Logf("test %d: %s", i, test.about)
+		s.ran = nil
+		s.patchCurrentVersion(test.current)
+		err := upgrades.PerformUpgrade(version.MustParse(test.from), s.State)
+		c.Check(err, gc.IsNil)
+		c.Check(s.ran, gc.DeepEquals, test.ran)
+	}
+}
+
+func (s *upgradesSuite) TestPerformUpgradeStopsOnError(c *gc.C) {
+	s.patchOperations("step 2")
+	s.patchCurrentVersion("1.18.0")
+	err := upgrades.PerformUpgrade(version.MustParse("1.15.0"), s.State)
+	c.Assert(err, gc.ErrorMatches, `upgrade step "step 2" failed: boom`)
+	c.Assert(s.ran, gc.DeepEquals, []string{"step 1", "step 2"})
+}
+
+func (s *upgradesSuite) startUpgrade(c *gc.C, from, to string) {
+	s.patchCurrentVersion(to)
+	err := s.State.SetEnvironAgentVersion(version.MustParse(from))
+	c.Assert(err, gc.IsNil)
+	err = s.State.StartUpgrade(version.MustParse(to))
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *upgradesSuite) assertAgentVersion(c *gc.C, vers string) {
+	envConfig, err := s.State.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	agentVersion, ok := envConfig.AgentVersion()
+	c.Assert(ok, jc.IsTrue)
+	c.Assert(agentVersion.String(), gc.Equals, vers)
+}
+
+func (s *upgradesSuite) TestMigrateStateWithoutUpgrade(c *gc.C) {
+	s.patchOperations("")
+	err := upgrades.MigrateState(s.State, "0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.ran, gc.HasLen, 0)
+}
+
+func (s *upgradesSuite) TestMigrateState(c *gc.C) {
+	s.patchOperations("")
+	s.startUpgrade(c, "1.16.0", "1.18.0")
+	err := upgrades.MigrateState(s.State, "0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.ran, gc.DeepEquals, []string{"step 2", "step 3", "step 4"})
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeAgents)
+	s.assertAgentVersion(c, "1.18.0")
+
+	// The data is only migrated once.
+	s.ran = nil
+	err = upgrades.MigrateState(s.State, "1")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.ran, gc.HasLen, 0)
+}
+
+func (s *upgradesSuite) TestMigrateStateIgnoresOtherVersions(c *gc.C) {
+	s.patchOperations("")
+	s.startUpgrade(c, "1.16.0", "1.18.0")
+	s.patchCurrentVersion("1.16.0")
+	err := upgrades.MigrateState(s.State, "0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.ran, gc.HasLen, 0)
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeStarted)
+}
+
+func (s *upgradesSuite) TestMigrateStateRollsBack(c *gc.C) {
+	s.patchOperations("step 3")
+	s.startUpgrade(c, "1.16.0", "1.18.0")
+	err := upgrades.MigrateState(s.State, "0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.ran, gc.DeepEquals, []string{"step 2", "step 3"})
+
+	info, err := s.State.UpgradeInfo()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Status(), gc.Equals, state.UpgradeFailed)
+	c.Assert(info.Error(), gc.Equals, `upgrade step "step 3" failed: boom`)
+	s.assertAgentVersion(c, "1.16.0")
+}
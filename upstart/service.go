@@ -40,6 +40,18 @@ func MongoUpstartService(name, dataDir, dbDir string, port int) *Conf {
 	}
 }
 
+// MongoReplicaSetUpstartService returns the upstart config for the
+// mongo state service running as a member of the named replica set.
+// The members of the set authenticate to each other with the contents
+// of keyFile.
+func MongoReplicaSetUpstartService(name, dataDir, dbDir string, port int, replicaSet, keyFile string) *Conf {
+	conf := MongoUpstartService(name, dataDir, dbDir, port)
+	conf.Cmd = conf.Cmd +
+		" --replSet " + utils.ShQuote(replicaSet) +
+		" --keyFile " + utils.ShQuote(keyFile)
+	return conf
+}
+
 // MachineAgentUpstartService returns the upstart config for a machine agent
 // based on the tag and machineId passed in.
 func MachineAgentUpstartService(name, toolsDir, dataDir, logDir, tag, machineId string, env map[string]string) *Conf {
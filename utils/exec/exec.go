@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The exec package provides a way of running a block of shell commands
+// and capturing their output and exit code.
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RunParams holds the commands to run, and the directory and
+// environment to run them in. If Timeout is non-zero, the commands,
+// and any processes they start, are killed once they have run for
+// that long.
+type RunParams struct {
+	Commands    string
+	WorkingDir  string
+	Environment []string
+	Timeout     time.Duration
+}
+
+// ExecResponse holds the output and exit code of a block of commands.
+type ExecResponse struct {
+	Code   int
+	Stdout []byte
+	Stderr []byte
+}
+
+// RunCommands executes the commands in a bash shell, returning their
+// output and exit code. An error is returned only if the shell could
+// not be run, or was killed for running too long; a non-zero exit code
+// is not an error.
+func RunCommands(run RunParams) (*ExecResponse, error) {
+	ps := exec.Command("/bin/bash", "-s")
+	ps.Env = run.Environment
+	ps.Dir = run.WorkingDir
+	ps.Stdin = strings.NewReader(run.Commands)
+	// Run the shell in its own process group, so that any processes
+	// it starts can be killed along with it.
+	ps.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var stdout, stderr bytes.Buffer
+	ps.Stdout = &stdout
+	ps.Stderr = &stderr
+	if err := ps.Start(); err != nil {
+		return nil, err
+	}
+	code := 0
+	if err := wait(ps, run.Timeout); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, err
+		}
+		code = 1
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			code = status.ExitStatus()
+		}
+	}
+	return &ExecResponse{
+		Code:   code,
+		Stdout: stdout.Bytes(),
+		Stderr: stderr.Bytes(),
+	}, nil
+}
+
+// wait waits for the started shell to exit. If it runs for longer than
+// the given timeout, if any, its process group is killed.
+func wait(ps *exec.Cmd, timeout time.Duration) error {
+	if timeout <= 0 {
+		return ps.Wait()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- ps.Wait()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+	syscall.Kill(-ps.Process.Pid, syscall.SIGKILL)
+	<-done
+	return fmt.Errorf("commands timed out after %v", timeout)
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package exec_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/utils/exec"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type execSuite struct{}
+
+var _ = gc.Suite(&execSuite{})
+
+var runCommandsTests = []struct {
+	about    string
+	commands string
+	stdout   string
+	stderr   string
+	code     int
+}{{
+	about:    "output is captured",
+	commands: "echo hello\necho world >&2",
+	stdout:   "hello\n",
+	stderr:   "world\n",
+}, {
+	about:    "exit code is reported",
+	commands: "echo failing; exit 42",
+	stdout:   "failing\n",
+	code:     42,
+}, {
+	about:    "failure of an earlier command does not stop later ones",
+	commands: "false\necho done",
+	stdout:   "done\n",
+}}
+
+func (*execSuite) TestRunCommands(c *gc.C) {
+	for i, test := range runCommandsTests {
+		c.Logf("test %d: %s", i, test.about)
+		result, err := exec.RunCommands(exec.RunParams{Commands: test.commands})
+		c.Assert(err, gc.IsNil)
+		c.Check(string(result.Stdout), gc.Equals, test.stdout)
+		c.Check(string(result.Stderr), gc.Equals, test.stderr)
+		c.Check(result.Code, gc.Equals, test.code)
+	}
+}
+
+func (*execSuite) TestRunCommandsWithDirAndEnvironment(c *gc.C) {
+	dir := c.MkDir()
+	result, err := exec.RunCommands(exec.RunParams{
+		Commands:    "pwd; echo $GREETING",
+		WorkingDir:  dir,
+		Environment: []string{"GREETING=hi"},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Check(string(result.Stdout), gc.Equals, dir+"\nhi\n")
+	c.Check(result.Code, gc.Equals, 0)
+}
+
+func (*execSuite) TestRunCommandsTimeout(c *gc.C) {
+	start := time.Now()
+	_, err := exec.RunCommands(exec.RunParams{
+		Commands: "sleep 10 & wait",
+		Timeout:  100 * time.Millisecond,
+	})
+	c.Assert(err, gc.ErrorMatches, "commands timed out after 100ms")
+	c.Assert(time.Since(start) < 5*time.Second, gc.Equals, true)
+}
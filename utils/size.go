@@ -0,0 +1,40 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ParseSize parses the string as a size, in mebibytes.
+//
+// The string must be a non-negative float with an optional
+// M, G, T or P suffix, denoting mebibytes, gibibytes, tebibytes
+// or pebibytes respectively. The result is rounded up to the
+// nearest mebibyte. An empty string is parsed as zero.
+func ParseSize(str string) (MB uint64, err error) {
+	if str == "" {
+		return 0, nil
+	}
+	mult := 1.0
+	if m, ok := mbSuffixes[str[len(str)-1:]]; ok {
+		str = str[:len(str)-1]
+		mult = m
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("must be a non-negative float with optional M/G/T/P suffix")
+	}
+	val *= mult
+	return uint64(math.Ceil(val)), nil
+}
+
+var mbSuffixes = map[string]float64{
+	"M": 1,
+	"G": 1024,
+	"T": 1024 * 1024,
+	"P": 1024 * 1024 * 1024,
+}
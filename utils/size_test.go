@@ -0,0 +1,45 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package utils_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/utils"
+)
+
+type sizeSuite struct{}
+
+var _ = gc.Suite(sizeSuite{})
+
+func (sizeSuite) TestParseSize(c *gc.C) {
+	type test struct {
+		in  string
+		out uint64
+		err string
+	}
+	tests := []test{
+		{in: "", out: 0},
+		{in: "0", out: 0},
+		{in: "512", out: 512},
+		{in: "0.5", out: 1},
+		{in: "512M", out: 512},
+		{in: "1.5G", out: 1536},
+		{in: "1T", out: 1024 * 1024},
+		{in: "1P", out: 1024 * 1024 * 1024},
+		{in: "-1", err: "must be a non-negative float with optional M/G/T/P suffix"},
+		{in: "1K", err: "must be a non-negative float with optional M/G/T/P suffix"},
+		{in: "G", err: "must be a non-negative float with optional M/G/T/P suffix"},
+	}
+	for i, t := range tests {
+		c.Logf("test %d: %q", i, t.in)
+		size, err := utils.ParseSize(t.in)
+		if t.err != "" {
+			c.Check(err, gc.ErrorMatches, t.err)
+		} else {
+			c.Check(err, gc.IsNil)
+			c.Check(size, gc.Equals, t.out)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The ssh package runs commands on, and copies files to, remote hosts
+// with the system's ssh client, checking the hosts' keys against a
+// known hosts file.
+package ssh
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Command returns a command that runs the given command line as user
+// on host. Host keys are checked against the keys recorded in
+// knownHostsFile: the key of a host that has not been seen before is
+// recorded there on first use, and after that a connection is refused
+// if the host presents a different key.
+func Command(user, host, command, knownHostsFile string) (*exec.Cmd, error) {
+	options, err := hostKeyOptions(host, knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-l", user}, options...)
+	args = append(args, host, command)
+	return exec.Command("ssh", args...), nil
+}
+
+// CopyCommand returns a command that copies the local file at
+// localPath to remotePath on host, as user. Host keys are checked as
+// they are by Command.
+func CopyCommand(user, host, localPath, remotePath, knownHostsFile string) (*exec.Cmd, error) {
+	options, err := hostKeyOptions(host, knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	args := append(options, localPath, user+"@"+host+":"+remotePath)
+	return exec.Command("scp", args...), nil
+}
+
+// hostKeyOptions returns the ssh options that check the key of host
+// against knownHostsFile, recording it there if host has not been
+// seen before.
+func hostKeyOptions(host, knownHostsFile string) ([]string, error) {
+	known, err := HostKnown(knownHostsFile, host)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(knownHostsFile), 0700); err != nil {
+		return nil, err
+	}
+	strict := "no"
+	if known {
+		strict = "yes"
+	}
+	return []string{
+		"-o", "PasswordAuthentication no",
+		"-o", "UserKnownHostsFile " + knownHostsFile,
+		"-o", "HashKnownHosts no",
+		"-o", "StrictHostKeyChecking " + strict,
+	}, nil
+}
+
+// HostKnown reports whether a key for host is recorded in the known
+// hosts file at path. Hashed entries are not recognised.
+func HostKnown(path, host string) (bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+			// Skip a @cert-authority or @revoked marker.
+			fields = fields[1:]
+		}
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, pattern := range strings.Split(fields[0], ",") {
+			if pattern == host {
+				return true, nil
+			}
+		}
+	}
+	return false, scanner.Err()
+}
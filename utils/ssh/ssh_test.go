@@ -0,0 +1,101 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package ssh_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/utils/ssh"
+)
+
+func Test(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type sshSuite struct{}
+
+var _ = gc.Suite(&sshSuite{})
+
+const knownHosts = `
+# a comment
+10.0.0.1,alpha.invalid ssh-rsa AAAAB3Nza
+@cert-authority *.invalid ssh-rsa AAAAB3Nzb
+|1|aGFzaGVk|aGFzaGVk ssh-rsa AAAAB3Nzc
+beta.invalid
+`
+
+var hostKnownTests = []struct {
+	host  string
+	known bool
+}{
+	{"10.0.0.1", true},
+	{"alpha.invalid", true},
+	{"beta.invalid", false},
+	{"gamma.invalid", false},
+}
+
+func (*sshSuite) TestHostKnown(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "known_hosts")
+	err := ioutil.WriteFile(path, []byte(knownHosts), 0600)
+	c.Assert(err, gc.IsNil)
+	for i, test := range hostKnownTests {
+		c.Logf("test %d: %s", i, test.host)
+		known, err := ssh.HostKnown(path, test.host)
+		c.Check(err, gc.IsNil)
+		c.Check(known, gc.Equals, test.known)
+	}
+}
+
+func (*sshSuite) TestHostKnownMissingFile(c *gc.C) {
+	known, err := ssh.HostKnown(filepath.Join(c.MkDir(), "known_hosts"), "alpha.invalid")
+	c.Assert(err, gc.IsNil)
+	c.Assert(known, gc.Equals, false)
+}
+
+func (*sshSuite) TestCommandRecordsUnknownHost(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "ssh", "known_hosts")
+	cmd, err := ssh.Command("ubuntu", "alpha.invalid", "uname -a", path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmd.Args, gc.DeepEquals, []string{
+		"ssh", "-l", "ubuntu",
+		"-o", "PasswordAuthentication no",
+		"-o", "UserKnownHostsFile " + path,
+		"-o", "HashKnownHosts no",
+		"-o", "StrictHostKeyChecking no",
+		"alpha.invalid", "uname -a",
+	})
+	info, err := os.Stat(filepath.Dir(path))
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode()&os.ModePerm, gc.Equals, os.FileMode(0700))
+}
+
+func (*sshSuite) TestCommandChecksKnownHost(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "known_hosts")
+	err := ioutil.WriteFile(path, []byte(knownHosts), 0600)
+	c.Assert(err, gc.IsNil)
+	cmd, err := ssh.Command("ubuntu", "alpha.invalid", "uname -a", path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmd.Args[len(cmd.Args)-3], gc.Equals, "StrictHostKeyChecking yes")
+}
+
+func (*sshSuite) TestCopyCommand(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "known_hosts")
+	err := ioutil.WriteFile(path, []byte(knownHosts), 0600)
+	c.Assert(err, gc.IsNil)
+	cmd, err := ssh.CopyCommand("ubuntu", "alpha.invalid", "/tmp/backup.tgz", "/home/ubuntu/backup.tgz", path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(cmd.Args, gc.DeepEquals, []string{
+		"scp",
+		"-o", "PasswordAuthentication no",
+		"-o", "UserKnownHostsFile " + path,
+		"-o", "HashKnownHosts no",
+		"-o", "StrictHostKeyChecking yes",
+		"/tmp/backup.tgz", "ubuntu@alpha.invalid:/home/ubuntu/backup.tgz",
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The apiaddressupdater package implements a worker that keeps the API
+// server addresses in an agent's configuration in line with the state
+// servers running in the environment.
+package apiaddressupdater
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.apiaddressupdater")
+
+// pollInterval is the interval at which the API addresses are checked.
+var pollInterval = time.Minute
+
+// APIAddressGetter is implemented by API facades that report the
+// addresses of the API servers.
+type APIAddressGetter interface {
+	APIAddresses() ([]string, error)
+}
+
+// APIAddressSetter is implemented by agent configurations that hold
+// the addresses of the API servers.
+type APIAddressSetter interface {
+	APIAddresses() []string
+	SetAPIAddresses(addrs []string)
+	Write() error
+}
+
+// APIAddressUpdater records the API server addresses reported by the
+// API in an agent configuration, so that the agent can reconnect to
+// any of the state servers when it restarts.
+type APIAddressUpdater struct {
+	tomb   tomb.Tomb
+	getter APIAddressGetter
+	setter APIAddressSetter
+}
+
+// NewAPIAddressUpdater returns a worker that periodically fetches the
+// API addresses from getter and, when they change, stores them in
+// setter and writes it.
+func NewAPIAddressUpdater(getter APIAddressGetter, setter APIAddressSetter) worker.Worker {
+	u := &APIAddressUpdater{
+		getter: getter,
+		setter: setter,
+	}
+	go func() {
+		defer u.tomb.Done()
+		u.tomb.Kill(u.loop())
+	}()
+	return u
+}
+
+func (u *APIAddressUpdater) String() string {
+	return "API address updater"
+}
+
+// Kill implements worker.Worker.Kill.
+func (u *APIAddressUpdater) Kill() {
+	u.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (u *APIAddressUpdater) Wait() error {
+	return u.tomb.Wait()
+}
+
+func (u *APIAddressUpdater) loop() error {
+	for {
+		if err := u.update(); err != nil {
+			return err
+		}
+		select {
+		case <-u.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// update stores the current API addresses in the agent configuration
+// if they differ from those already held there.
+func (u *APIAddressUpdater) update() error {
+	addrs, err := u.getter.APIAddresses()
+	if err != nil {
+		return fmt.Errorf("cannot get API addresses: %v", err)
+	}
+	if len(addrs) == 0 || sameAddresses(addrs, u.setter.APIAddresses()) {
+		return nil
+	}
+	logger.Infof("API addresses changed to %v", addrs)
+	u.setter.SetAPIAddresses(addrs)
+	if err := u.setter.Write(); err != nil {
+		return fmt.Errorf("cannot write agent configuration: %v", err)
+	}
+	return nil
+}
+
+// sameAddresses reports whether a and b hold the same addresses,
+// ignoring order.
+func sameAddresses(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiaddressupdater_test
+
+import (
+	"fmt"
+	"sync"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/testing/testbase"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/apiaddressupdater"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type updaterSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&updaterSuite{})
+
+type fakeGetter struct {
+	mu    sync.Mutex
+	addrs []string
+	err   error
+}
+
+func (g *fakeGetter) set(addrs []string, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addrs, g.err = addrs, err
+}
+
+func (g *fakeGetter) APIAddresses() ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.addrs, g.err
+}
+
+type fakeSetter struct {
+	mu     sync.Mutex
+	addrs  []string
+	writes chan []string
+}
+
+func (s *fakeSetter) APIAddresses() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addrs
+}
+
+func (s *fakeSetter) SetAPIAddresses(addrs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrs = addrs
+}
+
+func (s *fakeSetter) Write() error {
+	s.writes <- s.APIAddresses()
+	return nil
+}
+
+func (s *updaterSuite) TestUpdatesAddresses(c *gc.C) {
+	s.PatchValue(apiaddressupdater.PollInterval, 10*time.Millisecond)
+	getter := &fakeGetter{addrs: []string{"0.1.2.3:17070"}}
+	setter := &fakeSetter{
+		addrs:  []string{"localhost:17070"},
+		writes: make(chan []string, 10),
+	}
+	u := apiaddressupdater.NewAPIAddressUpdater(getter, setter)
+	defer func() { c.Assert(worker.Stop(u), gc.IsNil) }()
+
+	assertWrite := func(expect []string) {
+		select {
+		case addrs := <-setter.writes:
+			c.Assert(addrs, gc.DeepEquals, expect)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for configuration to be written")
+		}
+	}
+	assertNoWrite := func() {
+		select {
+		case addrs := <-setter.writes:
+			c.Fatalf("unexpected write of %v", addrs)
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	assertWrite([]string{"0.1.2.3:17070"})
+	assertNoWrite()
+
+	getter.set([]string{"0.1.2.4:17070", "0.1.2.3:17070"}, nil)
+	assertWrite([]string{"0.1.2.4:17070", "0.1.2.3:17070"})
+
+	// Reordered addresses are not rewritten.
+	getter.set([]string{"0.1.2.3:17070", "0.1.2.4:17070"}, nil)
+	assertNoWrite()
+
+	// An empty address list is never written.
+	getter.set(nil, nil)
+	assertNoWrite()
+	c.Assert(setter.APIAddresses(), gc.DeepEquals, []string{"0.1.2.4:17070", "0.1.2.3:17070"})
+}
+
+func (s *updaterSuite) TestGetterError(c *gc.C) {
+	getter := &fakeGetter{err: fmt.Errorf("boom")}
+	setter := &fakeSetter{writes: make(chan []string, 1)}
+	u := apiaddressupdater.NewAPIAddressUpdater(getter, setter)
+	err := u.Wait()
+	c.Assert(err, gc.ErrorMatches, "cannot get API addresses: boom")
+}
@@ -0,0 +1,6 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package apiaddressupdater
+
+var PollInterval = &pollInterval
@@ -0,0 +1,86 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package auditlogpruner
+
+import (
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.worker.auditlogpruner")
+
+// LogPruner defines the interface for types capable of pruning the
+// audit log.
+type LogPruner interface {
+	// PruneAuditLog removes the entries older than maxAge, and all
+	// but the newest maxEntries entries.
+	PruneAuditLog(maxAge time.Duration, maxEntries int) error
+}
+
+// Params holds the limits applied to the audit log, and how often
+// they are applied.
+type Params struct {
+	MaxAge     time.Duration
+	MaxEntries int
+	Interval   time.Duration
+}
+
+// DefaultParams keeps 90 days of the audit log, and at most 100000
+// entries, pruning it every 5 minutes.
+var DefaultParams = Params{
+	MaxAge:     90 * 24 * time.Hour,
+	MaxEntries: 100000,
+	Interval:   5 * time.Minute,
+}
+
+// Pruner periodically prunes the audit log.
+type Pruner struct {
+	tomb   tomb.Tomb
+	lp     LogPruner
+	params Params
+}
+
+// New returns a Pruner that prunes the audit log held by lp according
+// to params.
+func New(lp LogPruner, params Params) *Pruner {
+	p := &Pruner{lp: lp, params: params}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *Pruner) String() string {
+	return "auditlogpruner"
+}
+
+func (p *Pruner) Kill() {
+	p.tomb.Kill(nil)
+}
+
+func (p *Pruner) Stop() error {
+	p.tomb.Kill(nil)
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) loop() error {
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(p.params.Interval):
+			err := p.lp.PruneAuditLog(p.params.MaxAge, p.params.MaxEntries)
+			if err != nil {
+				logger.Errorf("cannot prune audit log: %v", err)
+			}
+		}
+	}
+}
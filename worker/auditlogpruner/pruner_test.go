@@ -0,0 +1,65 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package auditlogpruner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/auditlogpruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PrunerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+func (s *PrunerSuite) TestRunStopWithState(c *gc.C) {
+	// Test with state ensures that state fulfills the
+	// LogPruner interface.
+	p := auditlogpruner.New(s.State, auditlogpruner.DefaultParams)
+	c.Assert(p.Stop(), gc.IsNil)
+}
+
+type pruneCall struct {
+	maxAge     time.Duration
+	maxEntries int
+}
+
+type logPrunerMock struct {
+	calls chan pruneCall
+}
+
+func (m *logPrunerMock) PruneAuditLog(maxAge time.Duration, maxEntries int) error {
+	m.calls <- pruneCall{maxAge, maxEntries}
+	return nil
+}
+
+func (s *PrunerSuite) TestPrunerCalls(c *gc.C) {
+	lp := &logPrunerMock{make(chan pruneCall, 10)}
+	p := auditlogpruner.New(lp, auditlogpruner.Params{
+		MaxAge:     time.Hour,
+		MaxEntries: 5,
+		Interval:   10 * time.Millisecond,
+	})
+	defer func() { c.Assert(p.Stop(), gc.IsNil) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case call := <-lp.calls:
+			c.Assert(call, gc.Equals, pruneCall{time.Hour, 5})
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for the audit log to be pruned")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The logsender package implements a worker that sends the log
+// messages written by an agent to the API server, which aggregates
+// them for debug-log.
+package logsender
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/worker"
+)
+
+// maxBatchSize holds the largest number of records sent in a single
+// API call.
+const maxBatchSize = 512
+
+// BufferedLogWriter is a loggo.Writer that buffers log records until
+// they are sent by a log sender worker. When the buffer is full,
+// further records are dropped rather than blocking the writer.
+type BufferedLogWriter struct {
+	out     chan *params.LogRecord
+	dropped uint64
+}
+
+// NewBufferedLogWriter returns a BufferedLogWriter holding up to
+// size records.
+func NewBufferedLogWriter(size int) *BufferedLogWriter {
+	return &BufferedLogWriter{
+		out: make(chan *params.LogRecord, size),
+	}
+}
+
+// Write implements loggo.Writer.
+func (w *BufferedLogWriter) Write(level loggo.Level, module, filename string, line int, timestamp time.Time, message string) {
+	record := &params.LogRecord{
+		Time:     timestamp,
+		Module:   module,
+		Level:    level,
+		Location: fmt.Sprintf("%s:%d", filepath.Base(filename), line),
+		Message:  message,
+	}
+	select {
+	case w.out <- record:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+}
+
+// takeDropped returns the number of records dropped since it was
+// last called.
+func (w *BufferedLogWriter) takeDropped() uint64 {
+	return atomic.SwapUint64(&w.dropped, 0)
+}
+
+// LogSender is implemented by the API facade used to send log
+// records.
+type LogSender interface {
+	AddLogs(records []params.LogRecord) error
+}
+
+type logSender struct {
+	tomb   tomb.Tomb
+	api    LogSender
+	buffer *BufferedLogWriter
+}
+
+// New returns a worker that sends the records collected by buffer
+// using api.
+func New(buffer *BufferedLogWriter, api LogSender) worker.Worker {
+	s := &logSender{
+		api:    api,
+		buffer: buffer,
+	}
+	go func() {
+		defer s.tomb.Done()
+		s.tomb.Kill(s.loop())
+	}()
+	return s
+}
+
+func (s *logSender) String() string {
+	return "log sender"
+}
+
+// Kill implements worker.Worker.Kill.
+func (s *logSender) Kill() {
+	s.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (s *logSender) Wait() error {
+	return s.tomb.Wait()
+}
+
+func (s *logSender) loop() error {
+	for {
+		var batch []params.LogRecord
+		select {
+		case <-s.tomb.Dying():
+			return tomb.ErrDying
+		case record := <-s.buffer.out:
+			batch = append(batch, *record)
+		}
+		// Send any other records already waiting along with the
+		// first, rather than making a call for each one.
+	collect:
+		for len(batch) < maxBatchSize {
+			select {
+			case record := <-s.buffer.out:
+				batch = append(batch, *record)
+			default:
+				break collect
+			}
+		}
+		if dropped := s.buffer.takeDropped(); dropped > 0 {
+			batch = append(batch, params.LogRecord{
+				Time:    time.Now(),
+				Module:  "juju.worker.logsender",
+				Level:   loggo.WARNING,
+				Message: fmt.Sprintf("%d log messages were dropped", dropped),
+			})
+		}
+		if err := s.api.AddLogs(batch); err != nil {
+			return fmt.Errorf("cannot send log messages: %v", err)
+		}
+	}
+}
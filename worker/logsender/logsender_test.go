@@ -0,0 +1,94 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package logsender_test
+
+import (
+	"fmt"
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/logsender"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type logSenderSuite struct{}
+
+var _ = gc.Suite(&logSenderSuite{})
+
+// fakeAPI records the batches of log records sent to it.
+type fakeAPI struct {
+	batches chan []params.LogRecord
+	err     error
+}
+
+func (api *fakeAPI) AddLogs(records []params.LogRecord) error {
+	api.batches <- records
+	return api.err
+}
+
+func (api *fakeAPI) nextBatch(c *gc.C) []params.LogRecord {
+	select {
+	case batch := <-api.batches:
+		return batch
+	case <-time.After(testing.LongWait):
+		c.Fatalf("timed out waiting for log records")
+	}
+	panic("unreachable")
+}
+
+func (s *logSenderSuite) TestSendsRecords(c *gc.C) {
+	buffer := logsender.NewBufferedLogWriter(10)
+	when := time.Date(2014, 3, 1, 12, 0, 0, 0, time.UTC)
+	buffer.Write(loggo.INFO, "juju.worker", "/src/juju/worker/runner.go", 42, when, "hello")
+
+	api := &fakeAPI{batches: make(chan []params.LogRecord, 1)}
+	w := logsender.New(buffer, api)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	c.Assert(api.nextBatch(c), gc.DeepEquals, []params.LogRecord{{
+		Time:     when,
+		Module:   "juju.worker",
+		Level:    loggo.INFO,
+		Location: "runner.go:42",
+		Message:  "hello",
+	}})
+}
+
+func (s *logSenderSuite) TestDroppedRecordsReported(c *gc.C) {
+	buffer := logsender.NewBufferedLogWriter(2)
+	for i := 0; i < 5; i++ {
+		buffer.Write(loggo.DEBUG, "juju", "file.go", i, time.Now(), fmt.Sprint(i))
+	}
+	api := &fakeAPI{batches: make(chan []params.LogRecord, 1)}
+	w := logsender.New(buffer, api)
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	batch := api.nextBatch(c)
+	c.Assert(batch, gc.HasLen, 3)
+	c.Assert(batch[0].Message, gc.Equals, "0")
+	c.Assert(batch[1].Message, gc.Equals, "1")
+	c.Assert(batch[2].Level, gc.Equals, loggo.WARNING)
+	c.Assert(batch[2].Message, gc.Equals, "3 log messages were dropped")
+}
+
+func (s *logSenderSuite) TestAPIErrorStopsWorker(c *gc.C) {
+	buffer := logsender.NewBufferedLogWriter(10)
+	buffer.Write(loggo.INFO, "juju", "file.go", 1, time.Now(), "hello")
+	api := &fakeAPI{
+		batches: make(chan []params.LogRecord, 1),
+		err:     fmt.Errorf("boom"),
+	}
+	w := logsender.New(buffer, api)
+	api.nextBatch(c)
+	c.Assert(w.Wait(), gc.ErrorMatches, "cannot send log messages: boom")
+}
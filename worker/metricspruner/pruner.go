@@ -0,0 +1,86 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metricspruner
+
+import (
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.worker.metricspruner")
+
+// MetricsPruner defines the interface for types capable of pruning the
+// metrics reported by units.
+type MetricsPruner interface {
+	// PruneMetrics removes the batches of metrics older than maxAge,
+	// and all but the newest maxBatches batches of each unit.
+	PruneMetrics(maxAge time.Duration, maxBatches int) error
+}
+
+// Params holds the limits applied to the metrics, and how often they
+// are applied.
+type Params struct {
+	MaxAge     time.Duration
+	MaxBatches int
+	Interval   time.Duration
+}
+
+// DefaultParams keeps a day of metrics, and at most 1000 batches for
+// each unit, pruning them every 5 minutes.
+var DefaultParams = Params{
+	MaxAge:     24 * time.Hour,
+	MaxBatches: 1000,
+	Interval:   5 * time.Minute,
+}
+
+// Pruner periodically prunes the metrics.
+type Pruner struct {
+	tomb   tomb.Tomb
+	mp     MetricsPruner
+	params Params
+}
+
+// New returns a Pruner that prunes the metrics held by mp according
+// to params.
+func New(mp MetricsPruner, params Params) *Pruner {
+	p := &Pruner{mp: mp, params: params}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *Pruner) String() string {
+	return "metricspruner"
+}
+
+func (p *Pruner) Kill() {
+	p.tomb.Kill(nil)
+}
+
+func (p *Pruner) Stop() error {
+	p.tomb.Kill(nil)
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) loop() error {
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(p.params.Interval):
+			err := p.mp.PruneMetrics(p.params.MaxAge, p.params.MaxBatches)
+			if err != nil {
+				logger.Errorf("cannot prune metrics: %v", err)
+			}
+		}
+	}
+}
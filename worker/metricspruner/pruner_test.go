@@ -0,0 +1,65 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package metricspruner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/metricspruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PrunerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+func (s *PrunerSuite) TestRunStopWithState(c *gc.C) {
+	// Test with state ensures that state fulfills the
+	// MetricsPruner interface.
+	p := metricspruner.New(s.State, metricspruner.DefaultParams)
+	c.Assert(p.Stop(), gc.IsNil)
+}
+
+type pruneCall struct {
+	maxAge     time.Duration
+	maxBatches int
+}
+
+type metricsPrunerMock struct {
+	calls chan pruneCall
+}
+
+func (m *metricsPrunerMock) PruneMetrics(maxAge time.Duration, maxBatches int) error {
+	m.calls <- pruneCall{maxAge, maxBatches}
+	return nil
+}
+
+func (s *PrunerSuite) TestPrunerCalls(c *gc.C) {
+	mp := &metricsPrunerMock{make(chan pruneCall, 10)}
+	p := metricspruner.New(mp, metricspruner.Params{
+		MaxAge:     time.Hour,
+		MaxBatches: 5,
+		Interval:   10 * time.Millisecond,
+	})
+	defer func() { c.Assert(p.Stop(), gc.IsNil) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case call := <-mp.calls:
+			c.Assert(call, gc.Equals, pruneCall{time.Hour, 5})
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for the metrics to be pruned")
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package peergrouper
+
+import (
+	"sort"
+
+	"launchpad.net/juju-core/replicaset"
+)
+
+// desiredPeerGroup returns the replica set members that should make up
+// the peer group, given its current members and the state server
+// machines, held as a map from machine id to the address of the
+// machine's mongo server. An empty address means that the address is
+// not yet known. It also reports whether the returned members differ
+// from the current ones.
+//
+// Members that were not added by juju, and so have no machine id
+// tag, are left alone. Members whose machine is no longer a state
+// server are removed, and machines with a known address that are not
+// yet members are added.
+func desiredPeerGroup(members []replicaset.Member, machines map[string]string) ([]replicaset.Member, bool) {
+	changed := false
+	maxId := 0
+	seen := make(map[string]bool)
+	var desired []replicaset.Member
+	for _, m := range members {
+		if m.Id > maxId {
+			maxId = m.Id
+		}
+		id, ok := m.Tags[replicaset.MachineIdTag]
+		if !ok {
+			desired = append(desired, m)
+			continue
+		}
+		addr, ok := machines[id]
+		if !ok {
+			changed = true
+			continue
+		}
+		seen[id] = true
+		if addr != "" && addr != m.Address {
+			m.Address = addr
+			changed = true
+		}
+		desired = append(desired, m)
+	}
+	var ids []string
+	for id, addr := range machines {
+		if !seen[id] && addr != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		maxId++
+		desired = append(desired, replicaset.Member{
+			Id:      maxId,
+			Address: machines[id],
+			Tags:    map[string]string{replicaset.MachineIdTag: id},
+		})
+		changed = true
+	}
+	if len(desired) == 0 {
+		// A replica set can never be empty.
+		return members, false
+	}
+	return desired, changed
+}
+
+// isPrimary reports whether the member of the replica set added for
+// the machine with the given id is the primary, which has the given
+// address.
+func isPrimary(members []replicaset.Member, machineId, primaryAddr string) bool {
+	for _, m := range members {
+		if m.Tags[replicaset.MachineIdTag] == machineId {
+			return m.Address == primaryAddr
+		}
+	}
+	return false
+}
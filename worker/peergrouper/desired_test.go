@@ -0,0 +1,113 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package peergrouper
+
+import (
+	stdtesting "testing"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/replicaset"
+	"launchpad.net/juju-core/testing/testbase"
+)
+
+func TestPackage(t *stdtesting.T) {
+	gc.TestingT(t)
+}
+
+type desiredPeerGroupSuite struct {
+	testbase.LoggingSuite
+}
+
+var _ = gc.Suite(&desiredPeerGroupSuite{})
+
+func member(id int, addr, machineId string) replicaset.Member {
+	m := replicaset.Member{Id: id, Address: addr}
+	if machineId != "" {
+		m.Tags = map[string]string{replicaset.MachineIdTag: machineId}
+	}
+	return m
+}
+
+var desiredPeerGroupTests = []struct {
+	about    string
+	members  []replicaset.Member
+	machines map[string]string
+	expect   []replicaset.Member
+	changed  bool
+}{{
+	about:    "bootstrap member gets its machine address",
+	members:  []replicaset.Member{member(1, "myhost:37017", "0")},
+	machines: map[string]string{"0": "10.0.0.1:37017"},
+	expect:   []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+	changed:  true,
+}, {
+	about:    "nothing to do",
+	members:  []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+	machines: map[string]string{"0": "10.0.0.1:37017"},
+	expect:   []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+}, {
+	about:    "unknown address leaves member alone",
+	members:  []replicaset.Member{member(1, "myhost:37017", "0")},
+	machines: map[string]string{"0": ""},
+	expect:   []replicaset.Member{member(1, "myhost:37017", "0")},
+}, {
+	about:   "new machines are added",
+	members: []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+	machines: map[string]string{
+		"0": "10.0.0.1:37017",
+		"2": "10.0.0.3:37017",
+		"1": "10.0.0.2:37017",
+		"3": "",
+	},
+	expect: []replicaset.Member{
+		member(1, "10.0.0.1:37017", "0"),
+		member(2, "10.0.0.2:37017", "1"),
+		member(3, "10.0.0.3:37017", "2"),
+	},
+	changed: true,
+}, {
+	about: "departed machines are removed, others are kept",
+	members: []replicaset.Member{
+		member(1, "10.0.0.1:37017", "0"),
+		member(2, "10.0.0.2:37017", "1"),
+		member(5, "10.0.0.9:37017", ""),
+	},
+	machines: map[string]string{
+		"0": "10.0.0.1:37017",
+		"4": "10.0.0.4:37017",
+	},
+	expect: []replicaset.Member{
+		member(1, "10.0.0.1:37017", "0"),
+		member(5, "10.0.0.9:37017", ""),
+		member(6, "10.0.0.4:37017", "4"),
+	},
+	changed: true,
+}, {
+	about:    "never remove all members",
+	members:  []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+	machines: map[string]string{},
+	expect:   []replicaset.Member{member(1, "10.0.0.1:37017", "0")},
+}}
+
+func (*desiredPeerGroupSuite) TestDesiredPeerGroup(c *gc.C) {
+	for i, test := range desiredPeerGroupTests {
+		c.Logf("test %d: %s", i, test.about)
+		members, changed := desiredPeerGroup(test.members, test.machines)
+		c.Check(members, gc.DeepEquals, test.expect)
+		c.Check(changed, gc.Equals, test.changed)
+	}
+}
+
+func (*desiredPeerGroupSuite) TestIsPrimary(c *gc.C) {
+	members := []replicaset.Member{
+		member(1, "10.0.0.1:37017", "0"),
+		member(2, "10.0.0.2:37017", "1"),
+		member(3, "10.0.0.9:37017", ""),
+	}
+	c.Assert(isPrimary(members, "0", "10.0.0.1:37017"), gc.Equals, true)
+	c.Assert(isPrimary(members, "1", "10.0.0.1:37017"), gc.Equals, false)
+	c.Assert(isPrimary(members, "2", "10.0.0.1:37017"), gc.Equals, false)
+	c.Assert(isPrimary(members, "2", "10.0.0.9:37017"), gc.Equals, false)
+}
@@ -0,0 +1,126 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The peergrouper package implements a worker that maintains the
+// MongoDB replica set so that it spans the state server machines in
+// the environment.
+package peergrouper
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/replicaset"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.peergrouper")
+
+// pollInterval is the interval at which the replica set
+// is checked against the state server machines.
+var pollInterval = time.Minute
+
+type peerGrouper struct {
+	tomb      tomb.Tomb
+	st        *state.State
+	machineId string
+}
+
+// New returns a worker that periodically adds state server machines
+// to the replica set and removes those that are no longer state
+// servers. The worker runs on every state server machine, but only
+// the one with the given id whose mongo server is the replica set's
+// primary changes the replica set; elsewhere it does nothing.
+func New(st *state.State, machineId string) worker.Worker {
+	pg := &peerGrouper{st: st, machineId: machineId}
+	go func() {
+		defer pg.tomb.Done()
+		pg.tomb.Kill(pg.loop())
+	}()
+	return pg
+}
+
+func (pg *peerGrouper) String() string {
+	return "peer grouper"
+}
+
+// Kill implements worker.Worker.Kill.
+func (pg *peerGrouper) Kill() {
+	pg.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (pg *peerGrouper) Wait() error {
+	return pg.tomb.Wait()
+}
+
+func (pg *peerGrouper) loop() error {
+	for {
+		if err := pg.update(); err != nil {
+			return err
+		}
+		select {
+		case <-pg.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// update changes the members of the replica set if they
+// do not match the state server machines.
+func (pg *peerGrouper) update() error {
+	machines, err := pg.st.StateServerMachines()
+	if err != nil {
+		return err
+	}
+	cfg, err := pg.st.EnvironConfig()
+	if err != nil {
+		return err
+	}
+	port := fmt.Sprint(cfg.StatePort())
+	addrs := make(map[string]string)
+	for _, m := range machines {
+		addr := instance.SelectInternalAddress(m.Addresses(), false)
+		if addr != "" {
+			addr = net.JoinHostPort(addr, port)
+		}
+		addrs[m.Id()] = addr
+	}
+	session := pg.st.MongoSession().Clone()
+	defer session.Close()
+	members, err := replicaset.CurrentMembers(session)
+	if errors.IsNotFoundError(err) {
+		// The state server is not running as
+		// a replica set, so there is nothing to do.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status, err := replicaset.IsMaster(session)
+	if err != nil {
+		return err
+	}
+	if !isPrimary(members, pg.machineId, status.PrimaryAddress) {
+		return nil
+	}
+	desired, changed := desiredPeerGroup(members, addrs)
+	if !changed {
+		return nil
+	}
+	logger.Infof("changing replica set members to %v", desired)
+	if err := replicaset.Set(session, desired); err != nil {
+		// The new members may not be running yet;
+		// we will try again next time.
+		logger.Warningf("%v", err)
+	}
+	return nil
+}
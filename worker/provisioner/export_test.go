@@ -12,3 +12,12 @@ func (o *configObserver) SetObserver(observer chan<- *config.Config) {
 	o.observer = observer
 	o.Unlock()
 }
+
+// SetRetryStrategy sets the strategy used to retry starting machines
+// that failed with a transient error, and returns a function that
+// restores the original.
+func SetRetryStrategy(strategy RetryStrategy) (restore func()) {
+	old := retryStrategy
+	retryStrategy = strategy
+	return func() { retryStrategy = old }
+}
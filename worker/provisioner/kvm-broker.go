@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner
+
+import (
+	"launchpad.net/loggo"
+
+	"launchpad.net/juju-core/agent"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
+	"launchpad.net/juju-core/container/kvm"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/cloudinit"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/tools"
+)
+
+var kvmLogger = loggo.GetLogger("juju.provisioner.kvm")
+
+var _ environs.InstanceBroker = (*kvmBroker)(nil)
+var _ tools.HasTools = (*kvmBroker)(nil)
+
+func NewKvmBroker(api APICalls, tools *tools.Tools, agentConfig agent.Config) environs.InstanceBroker {
+	return &kvmBroker{
+		manager:     kvm.NewContainerManager(container.ManagerConfig{Name: "juju"}),
+		api:         api,
+		tools:       tools,
+		agentConfig: agentConfig,
+	}
+}
+
+type kvmBroker struct {
+	manager     container.ContainerManager
+	api         APICalls
+	tools       *tools.Tools
+	agentConfig agent.Config
+}
+
+func (broker *kvmBroker) Tools() tools.List {
+	return tools.List{broker.tools}
+}
+
+// StartInstance is specified in the Broker interface.
+func (broker *kvmBroker) StartInstance(cons constraints.Value, possibleTools tools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+
+	machineId := machineConfig.MachineId
+	kvmLogger.Infof("starting kvm container for machineId: %s", machineId)
+
+	network := container.BridgeNetworkConfig(kvm.DefaultKvmBridge)
+
+	series := possibleTools.OneSeries()
+	machineConfig.MachineContainerType = instance.KVM
+	machineConfig.Tools = possibleTools[0]
+	// The kvm container manager sizes the virtual machine using
+	// the constraints in the machine config.
+	machineConfig.Constraints = cons
+
+	config, err := broker.api.ContainerConfig()
+	if err != nil {
+		kvmLogger.Errorf("failed to get container config: %v", err)
+		return nil, nil, err
+	}
+	if err := environs.PopulateMachineConfig(
+		machineConfig,
+		config.ProviderType,
+		config.AuthorizedKeys,
+		config.SSLHostnameVerification,
+	); err != nil {
+		kvmLogger.Errorf("failed to populate machine config: %v", err)
+		return nil, nil, err
+	}
+
+	inst, err := broker.manager.StartContainer(machineConfig, series, network)
+	if err != nil {
+		kvmLogger.Errorf("failed to start container: %v", err)
+		return nil, nil, err
+	}
+	kvmLogger.Infof("started kvm container for machineId: %s, %s", machineId, inst.Id())
+	return inst, hardwareCharacteristics(cons, machineConfig.Tools.Version.Arch), nil
+}
+
+// hardwareCharacteristics returns the hardware of a kvm container
+// started with the given constraints.
+func hardwareCharacteristics(cons constraints.Value, arch string) *instance.HardwareCharacteristics {
+	params := kvm.ParseConstraintsToStartParams(cons)
+	rootDisk := params.RootDisk * 1024
+	return &instance.HardwareCharacteristics{
+		Arch:     &arch,
+		Mem:      &params.Memory,
+		CpuCores: &params.CpuCores,
+		RootDisk: &rootDisk,
+	}
+}
+
+// StopInstances shuts down the given instances.
+func (broker *kvmBroker) StopInstances(instances []instance.Instance) error {
+	// TODO: potentially parallelise.
+	for _, instance := range instances {
+		kvmLogger.Infof("stopping kvm container for instance: %s", instance.Id())
+		if err := broker.manager.StopContainer(instance); err != nil {
+			kvmLogger.Errorf("container did not stop: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// AllInstances only returns running containers.
+func (broker *kvmBroker) AllInstances() (result []instance.Instance, err error) {
+	return broker.manager.ListContainers()
+}
@@ -0,0 +1,256 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package provisioner_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/agent"
+	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container/kvm"
+	"launchpad.net/juju-core/container/kvm/mock"
+	kvmtesting "launchpad.net/juju-core/container/kvm/testing"
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/environs/config"
+	"launchpad.net/juju-core/instance"
+	instancetest "launchpad.net/juju-core/instance/testing"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	coretools "launchpad.net/juju-core/tools"
+	"launchpad.net/juju-core/version"
+	"launchpad.net/juju-core/worker/provisioner"
+)
+
+type kvmSuite struct {
+	kvmtesting.TestSuite
+	events chan mock.Event
+}
+
+type kvmBrokerSuite struct {
+	kvmSuite
+	broker      environs.InstanceBroker
+	agentConfig agent.Config
+}
+
+var _ = gc.Suite(&kvmBrokerSuite{})
+
+func (s *kvmSuite) SetUpTest(c *gc.C) {
+	s.TestSuite.SetUpTest(c)
+	s.events = make(chan mock.Event)
+	go func() {
+		for event := range s.events {
+			c.Output(3, fmt.Sprintf("kvm event: <%s, %s>", event.Action, event.InstanceId))
+		}
+	}()
+	s.TestSuite.Factory.AddListener(s.events)
+}
+
+func (s *kvmSuite) TearDownTest(c *gc.C) {
+	close(s.events)
+	s.TestSuite.TearDownTest(c)
+}
+
+func (s *kvmBrokerSuite) SetUpTest(c *gc.C) {
+	s.kvmSuite.SetUpTest(c)
+	tools := &coretools.Tools{
+		Version: version.MustParseBinary("2.3.4-foo-bar"),
+		URL:     "http://tools.testing.invalid/2.3.4-foo-bar.tgz",
+	}
+	var err error
+	s.agentConfig, err = agent.NewAgentConfig(
+		agent.AgentConfigParams{
+			DataDir:      "/not/used/here",
+			Tag:          "tag",
+			Password:     "dummy-secret",
+			Nonce:        "nonce",
+			APIAddresses: []string{"10.0.0.1:1234"},
+			CACert:       []byte(coretesting.CACert),
+		})
+	c.Assert(err, gc.IsNil)
+	s.broker = provisioner.NewKvmBroker(&fakeAPI{}, tools, s.agentConfig)
+}
+
+func (s *kvmBrokerSuite) startInstance(c *gc.C, machineId string, cons constraints.Value) (instance.Instance, *instance.HardwareCharacteristics) {
+	machineNonce := "fake-nonce"
+	stateInfo := jujutesting.FakeStateInfo(machineId)
+	apiInfo := jujutesting.FakeAPIInfo(machineId)
+	machineConfig := environs.NewMachineConfig(machineId, machineNonce, stateInfo, apiInfo)
+	possibleTools := s.broker.(coretools.HasTools).Tools()
+	inst, hc, err := s.broker.StartInstance(cons, possibleTools, machineConfig)
+	c.Assert(err, gc.IsNil)
+	return inst, hc
+}
+
+func (s *kvmBrokerSuite) TestStartInstance(c *gc.C) {
+	machineId := "1/kvm/0"
+	inst, hc := s.startInstance(c, machineId, constraints.Value{})
+	c.Assert(inst.Id(), gc.Equals, instance.Id("juju-machine-1-kvm-0"))
+	c.Assert(filepath.Join(s.ContainerDir, string(inst.Id())), jc.IsDirectory)
+	s.assertInstances(c, inst)
+	c.Assert(hc.String(), gc.Equals, "arch=bar cpu-cores=1 mem=512M root-disk=8192M")
+
+	mockContainer := s.Factory.New(string(inst.Id())).(*mock.MockContainer)
+	c.Assert(mockContainer.StartParams.Network.Device, gc.Equals, kvm.DefaultKvmBridge)
+}
+
+func (s *kvmBrokerSuite) TestStartInstanceWithConstraints(c *gc.C) {
+	cons := constraints.MustParse("mem=2G cpu-cores=2 root-disk=16G")
+	inst, hc := s.startInstance(c, "1/kvm/0", cons)
+	c.Assert(hc.String(), gc.Equals, "arch=bar cpu-cores=2 mem=2048M root-disk=16384M")
+
+	mockContainer := s.Factory.New(string(inst.Id())).(*mock.MockContainer)
+	c.Assert(mockContainer.StartParams.Memory, gc.Equals, uint64(2048))
+	c.Assert(mockContainer.StartParams.CpuCores, gc.Equals, uint64(2))
+	c.Assert(mockContainer.StartParams.RootDisk, gc.Equals, uint64(16))
+}
+
+func (s *kvmBrokerSuite) TestStopInstance(c *gc.C) {
+	kvm0, _ := s.startInstance(c, "1/kvm/0", constraints.Value{})
+	kvm1, _ := s.startInstance(c, "1/kvm/1", constraints.Value{})
+	kvm2, _ := s.startInstance(c, "1/kvm/2", constraints.Value{})
+
+	err := s.broker.StopInstances([]instance.Instance{kvm0})
+	c.Assert(err, gc.IsNil)
+	s.assertInstances(c, kvm1, kvm2)
+	c.Assert(filepath.Join(s.ContainerDir, string(kvm0.Id())), jc.DoesNotExist)
+	c.Assert(filepath.Join(s.RemovedDir, string(kvm0.Id())), jc.IsDirectory)
+
+	err = s.broker.StopInstances([]instance.Instance{kvm1, kvm2})
+	c.Assert(err, gc.IsNil)
+	s.assertInstances(c)
+}
+
+func (s *kvmBrokerSuite) assertInstances(c *gc.C, inst ...instance.Instance) {
+	results, err := s.broker.AllInstances()
+	c.Assert(err, gc.IsNil)
+	instancetest.MatchInstances(c, results, inst...)
+}
+
+type kvmProvisionerSuite struct {
+	CommonProvisionerSuite
+	kvmSuite
+	parentMachineId string
+	events          chan mock.Event
+}
+
+var _ = gc.Suite(&kvmProvisionerSuite{})
+
+func (s *kvmProvisionerSuite) SetUpSuite(c *gc.C) {
+	s.CommonProvisionerSuite.SetUpSuite(c)
+	s.kvmSuite.SetUpSuite(c)
+}
+
+func (s *kvmProvisionerSuite) TearDownSuite(c *gc.C) {
+	s.kvmSuite.TearDownSuite(c)
+	s.CommonProvisionerSuite.TearDownSuite(c)
+}
+
+func (s *kvmProvisionerSuite) SetUpTest(c *gc.C) {
+	s.CommonProvisionerSuite.SetUpTest(c)
+	s.kvmSuite.SetUpTest(c)
+
+	// The kvm provisioner actually needs the machine it is being created on
+	// to be in state, in order to get the watcher.
+	m, err := s.State.AddMachine(config.DefaultSeries, state.JobHostUnits, state.JobManageState)
+	c.Assert(err, gc.IsNil)
+	err = m.SetAddresses([]instance.Address{
+		instance.NewAddress("0.1.2.3"),
+	})
+	c.Assert(err, gc.IsNil)
+	s.parentMachineId = m.Id()
+	s.APILogin(c, m)
+	err = m.SetAgentVersion(version.Current)
+	c.Assert(err, gc.IsNil)
+
+	s.events = make(chan mock.Event, 25)
+	s.Factory.AddListener(s.events)
+}
+
+func (s *kvmProvisionerSuite) expectStarted(c *gc.C, machine *state.Machine) string {
+	s.State.StartSync()
+	event := <-s.events
+	c.Assert(event.Action, gc.Equals, mock.Started)
+	err := machine.Refresh()
+	c.Assert(err, gc.IsNil)
+	s.waitInstanceId(c, machine, instance.Id(event.InstanceId))
+	return event.InstanceId
+}
+
+func (s *kvmProvisionerSuite) expectStopped(c *gc.C, instId string) {
+	s.State.StartSync()
+	event := <-s.events
+	c.Assert(event.Action, gc.Equals, mock.Stopped)
+	c.Assert(event.InstanceId, gc.Equals, instId)
+}
+
+func (s *kvmProvisionerSuite) expectNoEvents(c *gc.C) {
+	select {
+	case event := <-s.events:
+		c.Fatalf("unexpected event %#v", event)
+	case <-time.After(coretesting.ShortWait):
+		return
+	}
+}
+
+func (s *kvmProvisionerSuite) TearDownTest(c *gc.C) {
+	close(s.events)
+	s.kvmSuite.TearDownTest(c)
+	s.CommonProvisionerSuite.TearDownTest(c)
+}
+
+func (s *kvmProvisionerSuite) newKvmProvisioner(c *gc.C) *provisioner.Provisioner {
+	parentMachineTag := names.MachineTag(s.parentMachineId)
+	agentConfig := s.AgentConfigForTag(c, parentMachineTag)
+	return provisioner.NewProvisioner(provisioner.KVM, s.provisioner, agentConfig)
+}
+
+func (s *kvmProvisionerSuite) TestProvisionerStartStop(c *gc.C) {
+	p := s.newKvmProvisioner(c)
+	c.Assert(p.Stop(), gc.IsNil)
+}
+
+func (s *kvmProvisionerSuite) TestDoesNotStartEnvironMachines(c *gc.C) {
+	p := s.newKvmProvisioner(c)
+	defer stop(c, p)
+
+	// Check that an instance is not provisioned when the machine is created.
+	_, err := s.State.AddMachine(config.DefaultSeries, state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+
+	s.expectNoEvents(c)
+}
+
+func (s *kvmProvisionerSuite) TestContainerStartedAndStopped(c *gc.C) {
+	p := s.newKvmProvisioner(c)
+	defer stop(c, p)
+
+	params := state.AddMachineParams{
+		ParentId:      s.parentMachineId,
+		ContainerType: instance.KVM,
+		Series:        config.DefaultSeries,
+		Jobs:          []state.MachineJob{state.JobHostUnits},
+		Constraints:   constraints.MustParse("mem=1G"),
+	}
+	container, err := s.State.AddMachineWithConstraints(&params)
+	c.Assert(err, gc.IsNil)
+	instId := s.expectStarted(c, container)
+
+	mockContainer := s.Factory.New(instId).(*mock.MockContainer)
+	c.Assert(mockContainer.StartParams.Memory, gc.Equals, uint64(1024))
+	hc, err := container.HardwareCharacteristics()
+	c.Assert(err, gc.IsNil)
+	c.Assert(*hc.Mem, gc.Equals, uint64(1024))
+
+	// ...and removed, along with the machine, when the machine is Dead.
+	c.Assert(container.EnsureDead(), gc.IsNil)
+	s.expectStopped(c, instId)
+	s.waitRemoved(c, container)
+}
@@ -8,6 +8,7 @@ import (
 
 	"launchpad.net/juju-core/agent"
 	"launchpad.net/juju-core/constraints"
+	"launchpad.net/juju-core/container"
 	"launchpad.net/juju-core/container/lxc"
 	"launchpad.net/juju-core/environs"
 	"launchpad.net/juju-core/environs/cloudinit"
@@ -27,7 +28,7 @@ type APICalls interface {
 
 func NewLxcBroker(api APICalls, tools *tools.Tools, agentConfig agent.Config) environs.InstanceBroker {
 	return &lxcBroker{
-		manager:     lxc.NewContainerManager(lxc.ManagerConfig{Name: "juju"}),
+		manager:     lxc.NewContainerManager(container.ManagerConfig{Name: "juju"}),
 		api:         api,
 		tools:       tools,
 		agentConfig: agentConfig,
@@ -35,7 +36,7 @@ func NewLxcBroker(api APICalls, tools *tools.Tools, agentConfig agent.Config) en
 }
 
 type lxcBroker struct {
-	manager     lxc.ContainerManager
+	manager     container.ContainerManager
 	api         APICalls
 	tools       *tools.Tools
 	agentConfig agent.Config
@@ -57,7 +58,7 @@ func (broker *lxcBroker) StartInstance(cons constraints.Value, possibleTools too
 	if bridgeDevice == "" {
 		bridgeDevice = lxc.DefaultLxcBridge
 	}
-	network := lxc.BridgeNetworkConfig(bridgeDevice)
+	network := container.BridgeNetworkConfig(bridgeDevice)
 
 	series := possibleTools.OneSeries()
 	machineConfig.MachineContainerType = instance.LXC
@@ -30,6 +30,8 @@ var (
 	ENVIRON ProvisionerType = "environ"
 	// LXC provisioners create lxc containers on their parent machine
 	LXC ProvisionerType = "lxc"
+	// KVM provisioners create kvm containers on their parent machine
+	KVM ProvisionerType = "kvm"
 )
 
 // Provisioner represents a running provisioning worker.
@@ -98,7 +100,7 @@ func (p *Provisioner) loop() error {
 		return err
 	}
 
-	// Start a new worker for the environment or lxc provisioner,
+	// Start a new worker for the environment or container provisioner,
 	// it depends on the provisioner type passed in NewProvisioner.
 
 	// Start responding to changes in machines, and to any further updates
@@ -111,12 +113,18 @@ func (p *Provisioner) loop() error {
 	if err != nil {
 		return err
 	}
+	safeMode := false
+	if p.environ != nil {
+		safeMode = p.environ.Config().ProvisionerSafeMode()
+	}
 	task := NewProvisionerTask(
 		p.agentConfig.Tag(),
 		p.st,
 		machineWatcher,
 		instanceBroker,
-		auth)
+		auth,
+		safeMode,
+		p.stateServingInfo())
 	defer watcher.Stop(task, &p.tomb)
 
 	for {
@@ -143,6 +151,22 @@ func (p *Provisioner) loop() error {
 	}
 }
 
+// stateServingInfo returns the information needed to start new
+// state server machines, or nil if they cannot be started by
+// this provisioner.
+func (p *Provisioner) stateServingInfo() *StateServingInfo {
+	apiPort, cert, key := p.agentConfig.APIServerDetails()
+	if p.environ == nil || len(cert) == 0 {
+		return nil
+	}
+	return &StateServingInfo{
+		Cert:      cert,
+		Key:       key,
+		StatePort: p.environ.Config().StatePort(),
+		APIPort:   apiPort,
+	}
+}
+
 func (p *Provisioner) getMachine() (*apiprovisioner.Machine, error) {
 	if p.machine == nil {
 		var err error
@@ -164,6 +188,12 @@ func (p *Provisioner) getWatcher() (Watcher, error) {
 			return nil, err
 		}
 		return machine.WatchContainers(instance.LXC)
+	case KVM:
+		machine, err := p.getMachine()
+		if err != nil {
+			return nil, err
+		}
+		return machine.WatchContainers(instance.KVM)
 	}
 	return nil, fmt.Errorf("unknown provisioner type")
 }
@@ -179,6 +209,13 @@ func (p *Provisioner) getBroker() (environs.InstanceBroker, error) {
 			return nil, err
 		}
 		return NewLxcBroker(p.st, tools, p.agentConfig), nil
+	case KVM:
+		tools, err := p.getAgentTools()
+		if err != nil {
+			logger.Errorf("cannot get tools from machine for kvm broker")
+			return nil, err
+		}
+		return NewKvmBroker(p.st, tools, p.agentConfig), nil
 	}
 	return nil, fmt.Errorf("unknown provisioner type")
 }
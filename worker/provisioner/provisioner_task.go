@@ -5,6 +5,7 @@ package provisioner
 
 import (
 	"fmt"
+	"time"
 
 	"launchpad.net/tomb"
 
@@ -14,6 +15,7 @@ import (
 	"launchpad.net/juju-core/environs/tools"
 	"launchpad.net/juju-core/instance"
 	"launchpad.net/juju-core/names"
+	"launchpad.net/juju-core/provider/common"
 	"launchpad.net/juju-core/state/api/params"
 	apiprovisioner "launchpad.net/juju-core/state/api/provisioner"
 	"launchpad.net/juju-core/state/watcher"
@@ -37,6 +39,50 @@ type Watcher interface {
 
 type MachineGetter interface {
 	Machine(tag string) (*apiprovisioner.Machine, error)
+	MachinesWithTransientErrors() ([]params.StatusResult, error)
+}
+
+// RetryStrategy defines how the provisioner retries starting machines
+// that failed with a transient error.
+type RetryStrategy struct {
+	// MaxAttempts holds the number of attempts made to start a
+	// machine before its error is considered permanent.
+	MaxAttempts int
+
+	// Delay holds how long to wait after the first failed attempt.
+	// The delay doubles after each further failure, up to MaxDelay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// delay returns how long to wait after the given number of failed
+// attempts.
+func (r RetryStrategy) delay(attempts int) time.Duration {
+	delay := r.Delay
+	for i := 1; i < attempts && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	return delay
+}
+
+// retryStrategy is the RetryStrategy used by provisioner tasks. It is
+// patched in tests.
+var retryStrategy = RetryStrategy{
+	MaxAttempts: 10,
+	Delay:       10 * time.Second,
+	MaxDelay:    5 * time.Minute,
+}
+
+// StateServingInfo holds the information needed to start
+// machines that run a state server.
+type StateServingInfo struct {
+	Cert      []byte
+	Key       []byte
+	StatePort int
+	APIPort   int
 }
 
 func NewProvisionerTask(
@@ -45,6 +91,8 @@ func NewProvisionerTask(
 	watcher Watcher,
 	broker environs.InstanceBroker,
 	auth AuthenticationProvider,
+	safeMode bool,
+	servingInfo *StateServingInfo,
 ) ProvisionerTask {
 	task := &provisionerTask{
 		machineTag:     machineTag,
@@ -52,6 +100,8 @@ func NewProvisionerTask(
 		machineWatcher: watcher,
 		broker:         broker,
 		auth:           auth,
+		safeMode:       safeMode,
+		servingInfo:    servingInfo,
 		machines:       make(map[string]*apiprovisioner.Machine),
 	}
 	go func() {
@@ -69,6 +119,14 @@ type provisionerTask struct {
 	tomb           tomb.Tomb
 	auth           AuthenticationProvider
 
+	// safeMode, if set, prevents the task from stopping instances
+	// that have no corresponding machine.
+	safeMode bool
+
+	// servingInfo, if set, allows the task to start
+	// machines that run a state server.
+	servingInfo *StateServingInfo
+
 	// instance id -> instance
 	instances map[instance.Id]instance.Instance
 	// machine id -> machine
@@ -105,6 +163,7 @@ func (task *provisionerTask) loop() error {
 	// When the watcher is started, it will have the initial changes be all
 	// the machines that are relevant. Also, since this is available straight
 	// away, we know there will be some changes right off the bat.
+	retryTimer := time.After(retryStrategy.Delay)
 	for {
 		select {
 		case <-task.tomb.Dying():
@@ -120,10 +179,41 @@ func (task *provisionerTask) loop() error {
 				logger.Errorf("Process machines failed: %v", err)
 				return err
 			}
+		case <-retryTimer:
+			if err := task.retryMachines(); err != nil {
+				logger.Errorf("cannot retry machines: %v", err)
+			}
+			retryTimer = time.After(retryStrategy.Delay)
 		}
 	}
 }
 
+// retryMachines starts the machines that failed to start with a
+// transient error, and are due to be retried.
+func (task *provisionerTask) retryMachines() error {
+	results, err := task.machineGetter.MachinesWithTransientErrors()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var machines []*apiprovisioner.Machine
+	for _, result := range results {
+		// Only retry machines managed by this task.
+		machine, ok := task.machines[result.Id]
+		if !ok {
+			continue
+		}
+		// A machine with no recorded attempts has been queued for
+		// provisioning again by the user, and is retried right away.
+		if now.Before(retryTime(result.Data)) {
+			continue
+		}
+		logger.Infof("retrying machine %q: %s", machine, result.Info)
+		machines = append(machines, machine)
+	}
+	return task.startMachines(machines)
+}
+
 func (task *provisionerTask) processMachines(ids []string) error {
 	logger.Tracef("processMachines(%v)", ids)
 	// Populate the tasks maps of current instances and machines.
@@ -229,7 +319,7 @@ func (task *provisionerTask) pendingOrDead(ids []string) (pending, dead []*apipr
 				logger.Errorf("failed to load machine %q instance id: %v", machine, err)
 				continue
 			}
-			status, _, err := machine.Status()
+			status, _, _, err := machine.Status()
 			if err != nil {
 				logger.Infof("cannot get machine %q status: %v", machine, err)
 				continue
@@ -273,6 +363,10 @@ func (task *provisionerTask) findUnknownInstances(stopping []instance.Instance)
 		unknown = append(unknown, i)
 	}
 	logger.Tracef("unknown: %v", unknown)
+	if task.safeMode && len(unknown) > 0 {
+		logger.Infof("provisioner safe mode: not stopping unknown instances %v", unknown)
+		return nil, nil
+	}
 	return unknown, nil
 }
 
@@ -334,13 +428,17 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 	if err != nil {
 		return err
 	}
-	inst, metadata, err := task.broker.StartInstance(cons, possibleTools, machineConfig)
+	placement, err := machine.Placement()
+	if err != nil {
+		return err
+	}
+	inst, metadata, err := task.startInstance(placement, cons, possibleTools, machineConfig)
 	if err != nil {
 		// Set the state to error, so the machine will be skipped next
-		// time until the error is resolved, but don't return an
-		// error; just keep going with the other machines.
+		// time until the error is resolved or it is retried, but don't
+		// return an error; just keep going with the other machines.
 		logger.Errorf("cannot start instance for machine %q: %v", machine, err)
-		if err1 := machine.SetStatus(params.StatusError, err.Error()); err1 != nil {
+		if err1 := task.setErrorStatus(machine, err); err1 != nil {
 			// Something is wrong with this machine, better report it back.
 			logger.Errorf("cannot set error status for machine %q: %v", machine, err1)
 			return err1
@@ -348,7 +446,7 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 		return nil
 	}
 	nonce := machineConfig.MachineNonce
-	if err := machine.SetProvisioned(inst.Id(), nonce, metadata); err != nil {
+	if err := task.setInstanceInfo(machine, inst, nonce, metadata); err != nil {
 		logger.Errorf("cannot register instance for machine %v: %v", machine, err)
 		// The machine is started, but we can't record the mapping in
 		// state. It'll keep running while we fail out and restart,
@@ -365,9 +463,122 @@ func (task *provisionerTask) startMachine(machine *apiprovisioner.Machine) error
 		return err
 	}
 	logger.Infof("started machine %s as instance %s with hardware %q", machine, inst.Id(), metadata)
+	if machineConfig.StateServer {
+		task.recordStateServer(inst.Id())
+	}
 	return nil
 }
 
+// setErrorStatus records in the machine's status that starting it
+// failed with the given error, along with the number of attempts made.
+// Transient errors are marked to be retried, unless too many attempts
+// have failed, and the time of the failure is recorded so that the
+// retry is delayed even if the provisioner restarts meanwhile.
+func (task *provisionerTask) setErrorStatus(machine *apiprovisioner.Machine, startErr error) error {
+	_, _, data, err := machine.Status()
+	if err != nil {
+		return err
+	}
+	attempts := statusAttempts(data) + 1
+	data = params.StatusData{"attempts": attempts}
+	if environs.IsTransientError(startErr) {
+		if attempts < retryStrategy.MaxAttempts {
+			logger.Infof("retrying machine %q in %v", machine, retryStrategy.delay(attempts))
+			data["transient"] = true
+			data["failed-at"] = time.Now().UTC().Format(time.RFC3339Nano)
+		} else {
+			logger.Errorf("giving up on machine %q after %d attempts", machine, attempts)
+		}
+	}
+	return machine.SetStatus(params.StatusError, startErr.Error(), data)
+}
+
+// statusAttempts returns the number of failed attempts to start a
+// machine recorded in its status data.
+func statusAttempts(data params.StatusData) int {
+	// Numbers are decoded as float64 when the data has been
+	// transmitted as JSON.
+	switch attempts := data["attempts"].(type) {
+	case int:
+		return attempts
+	case int64:
+		return int(attempts)
+	case float64:
+		return int(attempts)
+	}
+	return 0
+}
+
+// retryTime returns the time after which a machine whose status holds
+// the given data is due to be retried: the time of the last failed
+// attempt, delayed according to the number of attempts made. A machine
+// with no recorded attempts or failure time is due straight away.
+func retryTime(data params.StatusData) time.Time {
+	attempts := statusAttempts(data)
+	failedAt, _ := data["failed-at"].(string)
+	if attempts == 0 || failedAt == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, failedAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Add(retryStrategy.delay(attempts))
+}
+
+// startInstance starts an instance for a machine, as directed by the
+// machine's placement directive if it has one.
+func (task *provisionerTask) startInstance(
+	placement string, cons constraints.Value, possibleTools coretools.List,
+	machineConfig *cloudinit.MachineConfig) (instance.Instance, *instance.HardwareCharacteristics, error) {
+
+	if placement == "" {
+		return task.broker.StartInstance(cons, possibleTools, machineConfig)
+	}
+	placer, ok := task.broker.(environs.InstancePlacer)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot use placement directive %q: provider does not support placement directives", placement)
+	}
+	return placer.StartInstanceWithPlacement(placement, cons, possibleTools, machineConfig)
+}
+
+// setInstanceInfo records the given instance as provisioned for the
+// machine. When the broker can report the networks the instance is
+// connected to, they are recorded along with it.
+func (task *provisionerTask) setInstanceInfo(
+	machine *apiprovisioner.Machine, inst instance.Instance, nonce string,
+	metadata *instance.HardwareCharacteristics) error {
+
+	networking, ok := task.broker.(environs.Networking)
+	if !ok {
+		return machine.SetProvisioned(inst.Id(), nonce, metadata)
+	}
+	networks, interfaces, err := networking.InstanceNetworks(inst)
+	if err != nil {
+		return fmt.Errorf("cannot get networks of instance %s: %v", inst.Id(), err)
+	}
+	return machine.SetInstanceInfo(inst.Id(), nonce, metadata, networks, interfaces)
+}
+
+// recordStateServer adds the given instance to the state servers
+// recorded in the environment's provider state, so that clients
+// can connect to it.
+func (task *provisionerTask) recordStateServer(id instance.Id) {
+	env, ok := task.broker.(environs.Environ)
+	if !ok {
+		return
+	}
+	bsState, err := common.LoadState(env.Storage())
+	if err != nil {
+		logger.Warningf("cannot record state server instance %s: %v", id, err)
+		return
+	}
+	bsState.StateInstances = append(bsState.StateInstances, id)
+	if err := common.SaveState(env.Storage(), bsState); err != nil {
+		logger.Warningf("cannot record state server instance %s: %v", id, err)
+	}
+}
+
 func (task *provisionerTask) possibleTools(series string, cons constraints.Value) (coretools.List, error) {
 	if env, ok := task.broker.(environs.Environ); ok {
 		agentVersion, ok := env.Config().AgentVersion()
@@ -397,5 +608,22 @@ func (task *provisionerTask) machineConfig(machine *apiprovisioner.Machine) (*cl
 	}
 	nonce := fmt.Sprintf("%s:%s", task.machineTag, uuid.String())
 	machineConfig := environs.NewMachineConfig(machine.Id(), nonce, stateInfo, apiInfo)
+	jobs, err := machine.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job != params.JobManageState {
+			continue
+		}
+		if task.servingInfo == nil {
+			return nil, fmt.Errorf("cannot start state server machine: no state serving information available")
+		}
+		machineConfig.StateServer = true
+		machineConfig.StateServerCert = task.servingInfo.Cert
+		machineConfig.StateServerKey = task.servingInfo.Key
+		machineConfig.StatePort = task.servingInfo.StatePort
+		machineConfig.APIPort = task.servingInfo.APIPort
+	}
 	return machineConfig, nil
 }
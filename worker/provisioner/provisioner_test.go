@@ -155,6 +155,7 @@ func (s *CommonProvisionerSuite) checkStartInstanceCustom(c *gc.C, m *state.Mach
 				c.Assert(nonceParts[1], jc.Satisfies, utils.IsValidUUIDString)
 				c.Assert(o.Secret, gc.Equals, secret)
 				c.Assert(o.Constraints, gc.DeepEquals, cons)
+				c.Assert(o.Placement, gc.Equals, m.Placement())
 
 				// All provisioned machines in this test suite have their hardware characteristics
 				// attributes set to the same values as the constraints due to the dummy environment being used.
@@ -355,6 +356,22 @@ func (s *ProvisionerSuite) TestConstraints(c *gc.C) {
 	s.checkStartInstanceCustom(c, m, "pork", cons)
 }
 
+func (s *ProvisionerSuite) TestPlacement(c *gc.C) {
+	// Create a machine with a placement directive.
+	m, err := s.BackingState.AddMachineWithConstraints(&state.AddMachineParams{
+		Series:      config.DefaultSeries,
+		Jobs:        []state.MachineJob{state.JobHostUnits},
+		Constraints: s.defaultConstraints,
+		Placement:   "zone=zone2",
+	})
+	c.Assert(err, gc.IsNil)
+
+	// Start a provisioner and check the directive is used.
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+	s.checkStartInstance(c, m)
+}
+
 func (s *ProvisionerSuite) TestProvisionerSetsErrorStatusWhenStartInstanceFailed(c *gc.C) {
 	brokenMsg := breakDummyProvider(c, s.State, "StartInstance")
 	p := s.newEnvironProvisioner(c)
@@ -390,6 +407,143 @@ func (s *ProvisionerSuite) TestProvisionerSetsErrorStatusWhenStartInstanceFailed
 	s.checkNoOperations(c)
 }
 
+// waitErrorStatus waits for the machine's status to be set to error,
+// and returns its status data.
+func (s *CommonProvisionerSuite) waitErrorStatus(c *gc.C, m *state.Machine, info string) params.StatusData {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		status, statusInfo, data, err := m.Status()
+		c.Assert(err, gc.IsNil)
+		if status == params.StatusError {
+			c.Assert(statusInfo, gc.Equals, info)
+			return data
+		}
+	}
+	c.Fatalf("timed out waiting for machine %v error status", m)
+	panic("unreachable")
+}
+
+func (s *ProvisionerSuite) TestProvisionerRetriesTransientStartInstanceFailure(c *gc.C) {
+	defer provisioner.SetRetryStrategy(provisioner.RetryStrategy{
+		MaxAttempts: 100,
+		Delay:       10 * time.Millisecond,
+		MaxDelay:    50 * time.Millisecond,
+	})()
+	breakDummyProvider(c, s.State, "StartInstance/transient")
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	data := s.waitErrorStatus(c, m, "dummy.StartInstance is broken")
+	c.Assert(data["transient"], gc.Equals, true)
+	c.Assert(data["attempts"], gc.NotNil)
+
+	// Once the provider recovers, the machine is started and the
+	// error is cleared.
+	err = s.fixEnvironment()
+	c.Assert(err, gc.IsNil)
+	s.checkStartInstance(c, m)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		status, info, data, err := m.Status()
+		c.Assert(err, gc.IsNil)
+		if status == params.StatusPending {
+			c.Assert(info, gc.Equals, "")
+			c.Assert(data, gc.HasLen, 0)
+			break
+		}
+		if !a.HasNext() {
+			c.Fatalf("machine %v still has status %q", m, status)
+		}
+	}
+}
+
+func (s *ProvisionerSuite) TestProvisionerGivesUpAfterMaxAttempts(c *gc.C) {
+	defer provisioner.SetRetryStrategy(provisioner.RetryStrategy{
+		MaxAttempts: 2,
+		Delay:       10 * time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	})()
+	breakDummyProvider(c, s.State, "StartInstance/transient")
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		data := s.waitErrorStatus(c, m, "dummy.StartInstance is broken")
+		if data["transient"] == nil {
+			// Numbers in status data set through the API are
+			// decoded from JSON as float64.
+			c.Assert(data["attempts"], gc.Equals, float64(2))
+			break
+		}
+		if !a.HasNext() {
+			c.Fatalf("machine %v still marked as transient", m)
+		}
+	}
+
+	// The machine is no longer retried.
+	err = s.fixEnvironment()
+	c.Assert(err, gc.IsNil)
+	s.checkNoOperations(c)
+}
+
+func (s *ProvisionerSuite) TestProvisionerDelaysRetryAfterRestart(c *gc.C) {
+	defer provisioner.SetRetryStrategy(provisioner.RetryStrategy{
+		MaxAttempts: 100,
+		Delay:       10 * time.Millisecond,
+		MaxDelay:    time.Hour,
+	})()
+	// The machine failed to start just before the provisioner started,
+	// after enough attempts that its next retry is an hour away.
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	err = m.SetStatus(params.StatusError, "quota exceeded", params.StatusData{
+		"transient": true,
+		"attempts":  20,
+		"failed-at": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	c.Assert(err, gc.IsNil)
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+	s.checkNoOperations(c)
+
+	// Once the delay has passed, the machine is retried.
+	err = m.SetStatus(params.StatusError, "quota exceeded", params.StatusData{
+		"transient": true,
+		"attempts":  20,
+		"failed-at": time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano),
+	})
+	c.Assert(err, gc.IsNil)
+	s.checkStartInstance(c, m)
+}
+
+func (s *ProvisionerSuite) TestProvisionerRetriesRequeuedMachine(c *gc.C) {
+	brokenMsg := breakDummyProvider(c, s.State, "StartInstance")
+	defer provisioner.SetRetryStrategy(provisioner.RetryStrategy{
+		MaxAttempts: 10,
+		Delay:       10 * time.Millisecond,
+		MaxDelay:    10 * time.Millisecond,
+	})()
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	m, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	data := s.waitErrorStatus(c, m, brokenMsg)
+	c.Assert(data, gc.DeepEquals, params.StatusData{"attempts": float64(1)})
+
+	err = s.fixEnvironment()
+	c.Assert(err, gc.IsNil)
+	s.checkNoOperations(c)
+
+	// Queue the machine for provisioning again, as the
+	// retry-provisioning command does.
+	err = m.SetStatus(params.StatusError, brokenMsg, params.StatusData{"transient": true})
+	c.Assert(err, gc.IsNil)
+	s.checkStartInstance(c, m)
+}
+
 func (s *ProvisionerSuite) TestProvisioningDoesNotOccurForContainers(c *gc.C) {
 	p := s.newEnvironProvisioner(c)
 	defer stop(c, p)
@@ -527,6 +681,49 @@ func (s *ProvisionerSuite) TestProvisioningStopsInstances(c *gc.C) {
 	s.waitRemoved(c, m0)
 }
 
+func (s *ProvisionerSuite) TestProvisioningSafeMode(c *gc.C) {
+	attrs := s.cfg.AllAttrs()
+	attrs["provisioner-safe-mode"] = true
+	cfg, err := config.New(config.NoDefaults, attrs)
+	c.Assert(err, gc.IsNil)
+	err = s.State.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+
+	p := s.newEnvironProvisioner(c)
+	defer stop(c, p)
+
+	// create two machines
+	m0, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	i0 := s.checkStartInstance(c, m0)
+	m1, err := s.addMachine()
+	c.Assert(err, gc.IsNil)
+	s.checkStartInstance(c, m1)
+	stop(c, p)
+
+	// mark the first machine as dead, and remove the second entirely
+	c.Assert(m0.EnsureDead(), gc.IsNil)
+	c.Assert(m1.EnsureDead(), gc.IsNil)
+	c.Assert(m1.Remove(), gc.IsNil)
+
+	// start a new provisioner; only the dead machine's instance is
+	// stopped, because the second instance is unknown.
+	p = s.newEnvironProvisioner(c)
+	defer stop(c, p)
+	s.BackingState.StartSync()
+	select {
+	case o := <-s.op:
+		stopOp, ok := o.(dummy.OpStopInstances)
+		c.Assert(ok, jc.IsTrue)
+		c.Assert(stopOp.Instances, gc.HasLen, 1)
+		c.Assert(stopOp.Instances[0].Id(), gc.Equals, i0.Id())
+	case <-time.After(coretesting.LongWait):
+		c.Fatalf("provisioner did not stop an instance")
+	}
+	s.waitRemoved(c, m0)
+	s.checkNoOperations(c)
+}
+
 func (s *ProvisionerSuite) TestDyingMachines(c *gc.C) {
 	p := s.newEnvironProvisioner(c)
 	defer stop(c, p)
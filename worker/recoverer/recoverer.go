@@ -0,0 +1,222 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package recoverer
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/watcher"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.recoverer")
+
+// Params holds how often machines are checked, and how long a machine
+// agent may be down, or its instance missing, before the machine is
+// considered failed.
+type Params struct {
+	Interval           time.Duration
+	AgentDownThreshold time.Duration
+}
+
+// DefaultParams checks machines every minute, and considers a machine
+// failed when its agent has been down for 15 minutes.
+var DefaultParams = Params{
+	Interval:           time.Minute,
+	AgentDownThreshold: 15 * time.Minute,
+}
+
+// Recoverer periodically looks for machines whose instance has
+// disappeared from the provider, or whose agent has been down for too
+// long, and moves the units of services with the state.RecoveryAuto
+// policy from them to replacement machines. State servers and
+// containers are left alone.
+type Recoverer struct {
+	tomb   tomb.Tomb
+	st     *state.State
+	params Params
+
+	// downSince holds when each machine was first seen with its agent
+	// down or its instance missing, keyed by machine id.
+	downSince map[string]time.Time
+}
+
+// New returns a Recoverer that recovers the machines in st according
+// to params.
+func New(st *state.State, params Params) *Recoverer {
+	r := &Recoverer{
+		st:        st,
+		params:    params,
+		downSince: make(map[string]time.Time),
+	}
+	go func() {
+		defer r.tomb.Done()
+		r.tomb.Kill(r.loop())
+	}()
+	return r
+}
+
+func (r *Recoverer) String() string {
+	return "recoverer"
+}
+
+func (r *Recoverer) Kill() {
+	r.tomb.Kill(nil)
+}
+
+func (r *Recoverer) Stop() error {
+	r.tomb.Kill(nil)
+	return r.tomb.Wait()
+}
+
+func (r *Recoverer) Wait() error {
+	return r.tomb.Wait()
+}
+
+func (r *Recoverer) loop() error {
+	environWatcher := r.st.WatchForEnvironConfigChanges()
+	defer watcher.Stop(environWatcher, &r.tomb)
+	environ, err := worker.WaitForEnviron(environWatcher, r.st, r.tomb.Dying())
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-r.tomb.Dying():
+			return tomb.ErrDying
+		case _, ok := <-environWatcher.Changes():
+			if !ok {
+				return watcher.MustErr(environWatcher)
+			}
+			config, err := r.st.EnvironConfig()
+			if err != nil {
+				return err
+			}
+			if err := environ.SetConfig(config); err != nil {
+				logger.Errorf("loaded invalid environment configuration: %v", err)
+			}
+		case <-time.After(r.params.Interval):
+			if err := r.recoverMachines(environ); err != nil {
+				logger.Errorf("cannot recover failed machines: %v", err)
+			}
+		}
+	}
+}
+
+// recoverMachines recovers every failed machine.
+func (r *Recoverer) recoverMachines(environ environs.Environ) error {
+	failed, err := r.failedMachines(environ)
+	if err != nil {
+		return err
+	}
+	for m, reason := range failed {
+		replacement, err := r.st.RecoverMachine(m, reason)
+		if err != nil {
+			logger.Errorf("%v", err)
+			continue
+		}
+		if replacement != nil {
+			logger.Infof("machine %v failed (%s): units moved to machine %v", m, reason, replacement)
+		}
+	}
+	return nil
+}
+
+// failedMachines returns the provisioned machines that may be
+// recovered and have failed, along with the reason for each failure.
+func (r *Recoverer) failedMachines(environ environs.Environ) (map[*state.Machine]string, error) {
+	all, err := r.st.AllMachines()
+	if err != nil {
+		return nil, err
+	}
+	var machines []*state.Machine
+	var ids []instance.Id
+	seen := make(map[string]bool)
+	for _, m := range all {
+		if !recoverable(m) {
+			continue
+		}
+		id, err := m.InstanceId()
+		if state.IsNotProvisionedError(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		machines = append(machines, m)
+		ids = append(ids, id)
+		seen[m.Id()] = true
+	}
+	for id := range r.downSince {
+		if !seen[id] {
+			delete(r.downSince, id)
+		}
+	}
+	if len(machines) == 0 {
+		return nil, nil
+	}
+	insts, err := environ.Instances(ids)
+	switch err {
+	case nil, environs.ErrPartialInstances:
+	case environs.ErrNoInstances:
+		insts = make([]instance.Instance, len(ids))
+	default:
+		// Without knowing which instances exist, fall back to
+		// relying on the machine agents alone.
+		logger.Warningf("cannot get instances: %v", err)
+		insts = nil
+	}
+	failed := make(map[*state.Machine]string)
+	now := time.Now()
+	for i, m := range machines {
+		problem := ""
+		if insts != nil && insts[i] == nil {
+			problem = fmt.Sprintf("instance %q missing", ids[i])
+		} else {
+			alive, err := m.AgentAlive()
+			if err != nil {
+				return nil, err
+			}
+			if !alive {
+				problem = "agent down"
+			}
+		}
+		if problem == "" {
+			delete(r.downSince, m.Id())
+			continue
+		}
+		// The provider may briefly fail to report an instance that
+		// exists, so a missing instance is given as long to come back
+		// as a down agent.
+		since, ok := r.downSince[m.Id()]
+		if !ok {
+			r.downSince[m.Id()] = now
+			continue
+		}
+		if now.Sub(since) >= r.params.AgentDownThreshold {
+			failed[m] = fmt.Sprintf("%s since %s", problem, since.Format(time.RFC3339))
+		}
+	}
+	return failed, nil
+}
+
+// recoverable returns whether m is an alive machine that is neither a
+// container nor a state server.
+func recoverable(m *state.Machine) bool {
+	if m.Life() != state.Alive || m.ContainerType() != "" {
+		return false
+	}
+	for _, job := range m.Jobs() {
+		if job == state.JobManageState || job == state.JobManageEnviron {
+			return false
+		}
+	}
+	return true
+}
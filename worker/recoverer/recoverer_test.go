@@ -0,0 +1,153 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package recoverer_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/instance"
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/api/params"
+	coretesting "launchpad.net/juju-core/testing"
+	jc "launchpad.net/juju-core/testing/checkers"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/recoverer"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type RecovererSuite struct {
+	testing.JujuConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&RecovererSuite{})
+
+func (s *RecovererSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+	err = s.service.SetRecoveryPolicy(state.RecoveryAuto)
+	c.Assert(err, gc.IsNil)
+}
+
+// addMachine adds a machine with the given jobs, provisioned with a
+// real instance unless instId is set, and a wordpress unit if it can
+// host units.
+func (s *RecovererSuite) addMachine(c *gc.C, instId instance.Id, jobs ...state.MachineJob) (*state.Machine, *state.Unit) {
+	m, err := s.State.AddMachine("quantal", jobs...)
+	c.Assert(err, gc.IsNil)
+	if instId == "" {
+		inst, _ := testing.AssertStartInstance(c, s.Conn.Environ, m.Id())
+		instId = inst.Id()
+	}
+	err = m.SetProvisioned(instId, "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	for _, job := range jobs {
+		if job == state.JobHostUnits {
+			u, err := s.service.AddUnit()
+			c.Assert(err, gc.IsNil)
+			err = u.AssignToMachine(m)
+			c.Assert(err, gc.IsNil)
+			return m, u
+		}
+	}
+	return m, nil
+}
+
+func (s *RecovererSuite) waitForMove(c *gc.C, u *state.Unit, from *state.Machine) string {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		id, err := u.AssignedMachineId()
+		c.Assert(err, gc.IsNil)
+		if id != from.Id() {
+			return id
+		}
+		if !a.HasNext() {
+			c.Fatalf("timed out waiting for unit %v to move", u)
+		}
+		err = u.Refresh()
+		c.Assert(err, gc.IsNil)
+	}
+	panic("unreachable")
+}
+
+func (s *RecovererSuite) assertNotFailed(c *gc.C, m *state.Machine) {
+	status, _, _, err := m.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.StatusPending)
+}
+
+func (s *RecovererSuite) TestRecoversMissingInstance(c *gc.C) {
+	stateServer, _ := s.addMachine(c, "i-gone-too", state.JobManageEnviron)
+	alive, aliveUnit := s.addMachine(c, "", state.JobHostUnits)
+	pinger, err := alive.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	missing, missingUnit := s.addMachine(c, "i-gone", state.JobHostUnits)
+	s.State.StartSync()
+
+	r := recoverer.New(s.State, recoverer.Params{
+		Interval:           10 * time.Millisecond,
+		AgentDownThreshold: 50 * time.Millisecond,
+	})
+	defer func() { c.Assert(worker.Stop(r), gc.IsNil) }()
+
+	id := s.waitForMove(c, missingUnit, missing)
+	_, info, data, err := missing.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.HasPrefix, `machine failed: instance "i-gone" missing since `)
+	c.Assert(data, gc.DeepEquals, params.StatusData{"replacement": id})
+
+	err = aliveUnit.Refresh()
+	c.Assert(err, gc.IsNil)
+	aliveId, err := aliveUnit.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(aliveId, gc.Equals, alive.Id())
+	s.assertNotFailed(c, alive)
+	s.assertNotFailed(c, stateServer)
+}
+
+func (s *RecovererSuite) TestRecoversDownAgent(c *gc.C) {
+	m, u := s.addMachine(c, "", state.JobHostUnits)
+	r := recoverer.New(s.State, recoverer.Params{
+		Interval:           10 * time.Millisecond,
+		AgentDownThreshold: 50 * time.Millisecond,
+	})
+	defer func() { c.Assert(worker.Stop(r), gc.IsNil) }()
+
+	id := s.waitForMove(c, u, m)
+	c.Assert(id, gc.Not(gc.Equals), m.Id())
+	_, info, _, err := m.Status()
+	c.Assert(err, gc.IsNil)
+	c.Assert(info, jc.HasPrefix, "machine failed: agent down since ")
+}
+
+func (s *RecovererSuite) TestMissingInstanceGivenTimeToReturn(c *gc.C) {
+	m, u := s.addMachine(c, "i-gone", state.JobHostUnits)
+	pinger, err := m.SetAgentAlive()
+	c.Assert(err, gc.IsNil)
+	defer pinger.Stop()
+	s.State.StartSync()
+
+	r := recoverer.New(s.State, recoverer.Params{
+		Interval:           10 * time.Millisecond,
+		AgentDownThreshold: time.Hour,
+	})
+	defer func() { c.Assert(worker.Stop(r), gc.IsNil) }()
+
+	time.Sleep(coretesting.ShortWait)
+	err = u.Refresh()
+	c.Assert(err, gc.IsNil)
+	id, err := u.AssignedMachineId()
+	c.Assert(err, gc.IsNil)
+	c.Assert(id, gc.Equals, m.Id())
+	s.assertNotFailed(c, m)
+}
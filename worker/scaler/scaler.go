@@ -0,0 +1,221 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scaler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+)
+
+var logger = loggo.GetLogger("juju.worker.scaler")
+
+// Params holds how often scaling policies are applied, and the signals
+// they may use, keyed by signal kind.
+type Params struct {
+	Interval time.Duration
+	Signals  map[string]Signal
+}
+
+// DefaultParams applies scaling policies every minute, with metric
+// signals averaged over five minutes.
+var DefaultParams = Params{
+	Interval: time.Minute,
+	Signals: map[string]Signal{
+		"metric":   MetricSignal{Window: 5 * time.Minute},
+		"schedule": ScheduleSignal{},
+	},
+}
+
+// Scaler periodically applies the scaling policy of each service,
+// adding or removing units as its signal demands, within the bounds
+// of the policy and no sooner than its cooldown allows. Every change
+// is recorded as a state.ScalingDecision. Decisions are not
+// coordinated between Scalers, so only one may run in an environment.
+type Scaler struct {
+	tomb   tomb.Tomb
+	st     *state.State
+	params Params
+}
+
+// New returns a Scaler that scales the services in st according to
+// params.
+func New(st *state.State, params Params) *Scaler {
+	s := &Scaler{
+		st:     st,
+		params: params,
+	}
+	go func() {
+		defer s.tomb.Done()
+		s.tomb.Kill(s.loop())
+	}()
+	return s
+}
+
+func (s *Scaler) String() string {
+	return "scaler"
+}
+
+func (s *Scaler) Kill() {
+	s.tomb.Kill(nil)
+}
+
+func (s *Scaler) Stop() error {
+	s.tomb.Kill(nil)
+	return s.tomb.Wait()
+}
+
+func (s *Scaler) Wait() error {
+	return s.tomb.Wait()
+}
+
+func (s *Scaler) loop() error {
+	for {
+		select {
+		case <-s.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(s.params.Interval):
+			if err := s.scaleServices(time.Now()); err != nil {
+				logger.Errorf("cannot scale services: %v", err)
+			}
+		}
+	}
+}
+
+// scaleServices applies every scaling policy.
+func (s *Scaler) scaleServices(now time.Time) error {
+	policies, err := s.st.ScalingPolicies()
+	if err != nil {
+		return err
+	}
+	for name, policy := range policies {
+		if err := s.scaleService(name, policy, now); err != nil {
+			logger.Errorf("cannot scale service %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// scaleService applies the scaling policy of the named service.
+func (s *Scaler) scaleService(name string, policy state.ScalingPolicy, now time.Time) error {
+	service, err := s.st.Service(name)
+	if errors.IsNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if service.Life() != state.Alive || !service.IsPrincipal() {
+		return nil
+	}
+	units, err := aliveUnits(service)
+	if err != nil {
+		return err
+	}
+	current := len(units)
+	kind, _ := policy.SignalKind()
+	signal, ok := s.params.Signals[kind]
+	if !ok {
+		return fmt.Errorf("unknown signal %q", policy.Signal)
+	}
+	desired, reason, err := signal.DesiredUnits(service, policy, current, now)
+	if err != nil {
+		return err
+	}
+	// Bounds are enforced regardless of the cooldown.
+	bounded := true
+	switch {
+	case desired < policy.MinUnits:
+		desired, reason = policy.MinUnits, limitReason(reason, "minimum", policy.MinUnits)
+	case desired > policy.MaxUnits:
+		desired, reason = policy.MaxUnits, limitReason(reason, "maximum", policy.MaxUnits)
+	default:
+		bounded = false
+	}
+	if desired == current {
+		return nil
+	}
+	outOfBounds := current < policy.MinUnits || current > policy.MaxUnits
+	if !(bounded && outOfBounds) {
+		decisions, err := service.ScalingDecisions()
+		if err != nil {
+			return err
+		}
+		if n := len(decisions); n > 0 && now.Sub(decisions[n-1].Time) < policy.Cooldown {
+			logger.Debugf("service %q wants %d units, cooling down", name, desired)
+			return nil
+		}
+	}
+	if desired > current {
+		for i := current; i < desired; i++ {
+			unit, err := service.AddUnit()
+			if err != nil {
+				return err
+			}
+			if err := s.st.AssignUnit(unit, state.AssignNew); err != nil {
+				return err
+			}
+		}
+	} else {
+		// The most recently added units are removed first.
+		sort.Sort(sort.Reverse(byNumber(units)))
+		for _, unit := range units[:current-desired] {
+			if err := unit.Destroy(); err != nil {
+				return err
+			}
+		}
+	}
+	logger.Infof("scaled service %q from %d to %d units: %s", name, current, desired, reason)
+	return service.AddScalingDecision(state.ScalingDecision{
+		Time:   now,
+		From:   current,
+		To:     desired,
+		Reason: reason,
+	})
+}
+
+// limitReason returns the reason for a change in the number of units
+// that was limited by a bound of the policy.
+func limitReason(reason, bound string, units int) string {
+	limit := fmt.Sprintf("%s of %d units", bound, units)
+	if reason == "" {
+		return limit
+	}
+	return fmt.Sprintf("%s, limited to %s", reason, limit)
+}
+
+// aliveUnits returns the alive units of the service.
+func aliveUnits(service *state.Service) ([]*state.Unit, error) {
+	all, err := service.AllUnits()
+	if err != nil {
+		return nil, err
+	}
+	var units []*state.Unit
+	for _, unit := range all {
+		if unit.Life() == state.Alive {
+			units = append(units, unit)
+		}
+	}
+	return units, nil
+}
+
+// byNumber sorts units of a single service by unit number.
+type byNumber []*state.Unit
+
+func (b byNumber) Len() int           { return len(b) }
+func (b byNumber) Less(i, j int) bool { return unitNumber(b[i]) < unitNumber(b[j]) }
+func (b byNumber) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+func unitNumber(unit *state.Unit) int {
+	name := unit.Name()
+	n, _ := strconv.Atoi(name[strings.LastIndex(name, "/")+1:])
+	return n
+}
@@ -0,0 +1,142 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scaler_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/scaler"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type ScalerSuite struct {
+	testing.JujuConnSuite
+	service *state.Service
+}
+
+var _ = gc.Suite(&ScalerSuite{})
+
+func (s *ScalerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	var err error
+	s.service, err = s.State.AddService("wordpress", s.AddTestingCharm(c, "wordpress"))
+	c.Assert(err, gc.IsNil)
+}
+
+// fakeSignal always wants the same number of units. It stands in for
+// the schedule signal, as the policies set in state must use one of
+// the known signal kinds.
+type fakeSignal struct {
+	units  int
+	reason string
+}
+
+func (f fakeSignal) DesiredUnits(*state.Service, state.ScalingPolicy, int, time.Time) (int, string, error) {
+	return f.units, f.reason, nil
+}
+
+func (s *ScalerSuite) startScaler(c *gc.C, units int, policy state.ScalingPolicy) worker.Worker {
+	policy.Signal = "schedule:00:00=1"
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.IsNil)
+	return scaler.New(s.State, scaler.Params{
+		Interval: 10 * time.Millisecond,
+		Signals: map[string]scaler.Signal{
+			"schedule": fakeSignal{units, "fake reason"},
+		},
+	})
+}
+
+func (s *ScalerSuite) waitForDecision(c *gc.C) state.ScalingDecision {
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		decisions, err := s.service.ScalingDecisions()
+		c.Assert(err, gc.IsNil)
+		if len(decisions) > 0 {
+			c.Assert(decisions, gc.HasLen, 1)
+			return decisions[0]
+		}
+	}
+	c.Fatalf("timed out waiting for scaling decision")
+	panic("unreachable")
+}
+
+func (s *ScalerSuite) aliveUnitNames(c *gc.C) []string {
+	units, err := s.service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	var names []string
+	for _, u := range units {
+		if u.Life() == state.Alive {
+			names = append(names, u.Name())
+		}
+	}
+	return names
+}
+
+func (s *ScalerSuite) TestScaleUp(c *gc.C) {
+	w := s.startScaler(c, 3, state.ScalingPolicy{MinUnits: 1, MaxUnits: 5})
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	decision := s.waitForDecision(c)
+	c.Assert(decision.From, gc.Equals, 0)
+	c.Assert(decision.To, gc.Equals, 3)
+	c.Assert(decision.Reason, gc.Equals, "fake reason")
+	c.Assert(s.aliveUnitNames(c), gc.HasLen, 3)
+	units, err := s.service.AllUnits()
+	c.Assert(err, gc.IsNil)
+	for _, u := range units {
+		_, err := u.AssignedMachineId()
+		c.Assert(err, gc.IsNil)
+	}
+}
+
+func (s *ScalerSuite) TestScaleDownRemovesNewestUnits(c *gc.C) {
+	for i := 0; i < 3; i++ {
+		_, err := s.service.AddUnit()
+		c.Assert(err, gc.IsNil)
+	}
+	w := s.startScaler(c, 1, state.ScalingPolicy{MinUnits: 1, MaxUnits: 5})
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	decision := s.waitForDecision(c)
+	c.Assert(decision.From, gc.Equals, 3)
+	c.Assert(decision.To, gc.Equals, 1)
+	c.Assert(s.aliveUnitNames(c), gc.DeepEquals, []string{"wordpress/0"})
+}
+
+func (s *ScalerSuite) TestScaleWithinBounds(c *gc.C) {
+	w := s.startScaler(c, 10, state.ScalingPolicy{MinUnits: 1, MaxUnits: 2})
+	defer func() { c.Assert(worker.Stop(w), gc.IsNil) }()
+
+	decision := s.waitForDecision(c)
+	c.Assert(decision.To, gc.Equals, 2)
+	c.Assert(decision.Reason, gc.Equals, "fake reason, limited to maximum of 2 units")
+	c.Assert(s.aliveUnitNames(c), gc.HasLen, 2)
+}
+
+func (s *ScalerSuite) TestCooldown(c *gc.C) {
+	policy := state.ScalingPolicy{MinUnits: 0, MaxUnits: 5, Signal: "schedule:00:00=1", Cooldown: time.Hour}
+	err := s.service.SetScalingPolicy(policy)
+	c.Assert(err, gc.IsNil)
+	err = s.service.AddScalingDecision(state.ScalingDecision{Time: time.Now(), From: 1, To: 0})
+	c.Assert(err, gc.IsNil)
+
+	w := s.startScaler(c, 3, policy)
+	time.Sleep(coretesting.ShortWait)
+	c.Assert(worker.Stop(w), gc.IsNil)
+
+	c.Assert(s.aliveUnitNames(c), gc.HasLen, 0)
+	decisions, err := s.service.ScalingDecisions()
+	c.Assert(err, gc.IsNil)
+	c.Assert(decisions, gc.HasLen, 1)
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scaler
+
+import (
+	"fmt"
+	"time"
+
+	"launchpad.net/juju-core/state"
+)
+
+// Signal decides how many units a service should have.
+type Signal interface {
+	// DesiredUnits returns the number of units the service should
+	// have according to the policy, given that it currently has
+	// current alive units, along with the reason for any change.
+	DesiredUnits(service *state.Service, policy state.ScalingPolicy, current int, now time.Time) (int, string, error)
+}
+
+// MetricSignal adds a unit when the average of the metric named by the
+// policy's signal argument, as reported by the service's units over
+// Window, is above the policy's ScaleUp threshold, and removes one when
+// it is below the ScaleDown threshold.
+type MetricSignal struct {
+	Window time.Duration
+}
+
+// DesiredUnits implements Signal.
+func (s MetricSignal) DesiredUnits(service *state.Service, policy state.ScalingPolicy, current int, now time.Time) (int, string, error) {
+	_, key := policy.SignalKind()
+	values, err := service.MetricValues(key, now.Add(-s.Window))
+	if err != nil {
+		return 0, "", err
+	}
+	if len(values) == 0 {
+		return current, "", nil
+	}
+	var total float64
+	for _, m := range values {
+		total += m.Value
+	}
+	average := total / float64(len(values))
+	switch {
+	case average > policy.ScaleUp:
+		return current + 1, fmt.Sprintf("average %s %v over %v above %v", key, average, s.Window, policy.ScaleUp), nil
+	case average < policy.ScaleDown:
+		return current - 1, fmt.Sprintf("average %s %v over %v below %v", key, average, s.Window, policy.ScaleDown), nil
+	}
+	return current, "", nil
+}
+
+// ScheduleSignal sets the number of units according to the time of
+// day, as given by the policy's signal argument, a comma-separated list
+// of "HH:MM=N" entries in UTC. Each entry applies until the next one;
+// the last entry of the day applies until the first.
+type ScheduleSignal struct{}
+
+// DesiredUnits implements Signal.
+func (ScheduleSignal) DesiredUnits(service *state.Service, policy state.ScalingPolicy, current int, now time.Time) (int, string, error) {
+	_, schedule := policy.SignalKind()
+	entries, err := state.ParseSchedule(schedule)
+	if err != nil {
+		return 0, "", err
+	}
+	now = now.UTC()
+	minute := now.Hour()*60 + now.Minute()
+	entry := entries[len(entries)-1]
+	for _, e := range entries {
+		if e.Minute > minute {
+			break
+		}
+		entry = e
+	}
+	reason := fmt.Sprintf("scheduled %d units from %02d:%02d", entry.Units, entry.Minute/60, entry.Minute%60)
+	return entry.Units, reason, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package scaler_test
+
+import (
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/worker/scaler"
+)
+
+var scheduleTests = []struct {
+	time   string
+	units  int
+	reason string
+}{
+	{"07:59", 2, "scheduled 2 units from 20:00"},
+	{"08:00", 4, "scheduled 4 units from 08:00"},
+	{"12:30", 4, "scheduled 4 units from 08:00"},
+	{"20:00", 2, "scheduled 2 units from 20:00"},
+	{"23:59", 2, "scheduled 2 units from 20:00"},
+}
+
+func (s *ScalerSuite) TestScheduleSignal(c *gc.C) {
+	policy := state.ScalingPolicy{Signal: "schedule:20:00=2,08:00=4"}
+	for i, t := range scheduleTests {
+		c.Logf("test %d: %s", i, t.time)
+		now, err := time.Parse("2006-01-02 15:04", "2014-03-01 "+t.time)
+		c.Assert(err, gc.IsNil)
+		units, reason, err := scaler.ScheduleSignal{}.DesiredUnits(s.service, policy, 3, now)
+		c.Assert(err, gc.IsNil)
+		c.Check(units, gc.Equals, t.units)
+		c.Check(reason, gc.Equals, t.reason)
+	}
+}
+
+func (s *ScalerSuite) TestScheduleSignalInvalid(c *gc.C) {
+	policy := state.ScalingPolicy{Signal: "schedule:8am=4"}
+	_, _, err := scaler.ScheduleSignal{}.DesiredUnits(s.service, policy, 3, time.Now())
+	c.Assert(err, gc.ErrorMatches, `invalid schedule entry "8am=4"`)
+}
+
+func (s *ScalerSuite) TestMetricSignal(c *gc.C) {
+	unit, err := s.service.AddUnit()
+	c.Assert(err, gc.IsNil)
+	signal := scaler.MetricSignal{Window: time.Minute}
+	policy := state.ScalingPolicy{Signal: "metric:load", ScaleUp: 0.8, ScaleDown: 0.5}
+	now := time.Now()
+
+	units, reason, err := signal.DesiredUnits(s.service, policy, 2, now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.Equals, 2)
+	c.Assert(reason, gc.Equals, "")
+
+	err = unit.AddMetrics([]state.Metric{
+		{Key: "load", Value: 0.1, Time: now.Add(-2 * time.Minute)},
+		{Key: "load", Value: 0.9, Time: now.Add(-10 * time.Second)},
+		{Key: "load", Value: 1.1, Time: now.Add(-5 * time.Second)},
+	})
+	c.Assert(err, gc.IsNil)
+	units, reason, err = signal.DesiredUnits(s.service, policy, 2, now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.Equals, 3)
+	c.Assert(reason, gc.Equals, "average load 1 over 1m0s above 0.8")
+
+	err = unit.AddMetrics([]state.Metric{
+		{Key: "load", Value: 0, Time: now.Add(-time.Second)},
+		{Key: "load", Value: 0, Time: now},
+		{Key: "load", Value: 0, Time: now},
+	})
+	c.Assert(err, gc.IsNil)
+	units, reason, err = signal.DesiredUnits(s.service, policy, 2, now)
+	c.Assert(err, gc.IsNil)
+	c.Assert(units, gc.Equals, 1)
+	c.Assert(reason, gc.Equals, "average load 0.4 over 1m0s below 0.5")
+}
@@ -0,0 +1,86 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package statushistorypruner
+
+import (
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+)
+
+var logger = loggo.GetLogger("juju.worker.statushistorypruner")
+
+// HistoryPruner defines the interface for types capable of pruning
+// the status history of units and machines.
+type HistoryPruner interface {
+	// PruneStatusHistory removes the entries older than maxAge, and
+	// all but the newest maxEntries entries of each entity.
+	PruneStatusHistory(maxAge time.Duration, maxEntries int) error
+}
+
+// Params holds the limits applied to the status history, and how
+// often they are applied.
+type Params struct {
+	MaxAge     time.Duration
+	MaxEntries int
+	Interval   time.Duration
+}
+
+// DefaultParams keeps a week of history, and at most 100 entries for
+// each entity, pruning it every 5 minutes.
+var DefaultParams = Params{
+	MaxAge:     7 * 24 * time.Hour,
+	MaxEntries: 100,
+	Interval:   5 * time.Minute,
+}
+
+// Pruner periodically prunes the status history.
+type Pruner struct {
+	tomb   tomb.Tomb
+	hp     HistoryPruner
+	params Params
+}
+
+// New returns a Pruner that prunes the status history held by hp
+// according to params.
+func New(hp HistoryPruner, params Params) *Pruner {
+	p := &Pruner{hp: hp, params: params}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *Pruner) String() string {
+	return "statushistorypruner"
+}
+
+func (p *Pruner) Kill() {
+	p.tomb.Kill(nil)
+}
+
+func (p *Pruner) Stop() error {
+	p.tomb.Kill(nil)
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *Pruner) loop() error {
+	for {
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(p.params.Interval):
+			err := p.hp.PruneStatusHistory(p.params.MaxAge, p.params.MaxEntries)
+			if err != nil {
+				logger.Errorf("cannot prune status history: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package statushistorypruner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/juju/testing"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/statushistorypruner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type PrunerSuite struct {
+	testing.JujuConnSuite
+}
+
+var _ = gc.Suite(&PrunerSuite{})
+
+func (s *PrunerSuite) TestRunStopWithState(c *gc.C) {
+	// Test with state ensures that state fulfills the
+	// HistoryPruner interface.
+	p := statushistorypruner.New(s.State, statushistorypruner.DefaultParams)
+	c.Assert(p.Stop(), gc.IsNil)
+}
+
+type pruneCall struct {
+	maxAge     time.Duration
+	maxEntries int
+}
+
+type historyPrunerMock struct {
+	calls chan pruneCall
+}
+
+func (m *historyPrunerMock) PruneStatusHistory(maxAge time.Duration, maxEntries int) error {
+	m.calls <- pruneCall{maxAge, maxEntries}
+	return nil
+}
+
+func (s *PrunerSuite) TestPrunerCalls(c *gc.C) {
+	hp := &historyPrunerMock{make(chan pruneCall, 10)}
+	p := statushistorypruner.New(hp, statushistorypruner.Params{
+		MaxAge:     time.Hour,
+		MaxEntries: 5,
+		Interval:   10 * time.Millisecond,
+	})
+	defer func() { c.Assert(p.Stop(), gc.IsNil) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case call := <-hp.calls:
+			c.Assert(call, gc.Equals, pruneCall{time.Hour, 5})
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out waiting for the status history to be pruned")
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+// The storageprovisioner package implements a worker that creates the
+// volumes required by the storage instances of units, and destroys
+// them when they are no longer needed.
+package storageprovisioner
+
+import (
+	"time"
+
+	"launchpad.net/loggo"
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/environs"
+	"launchpad.net/juju-core/errors"
+	"launchpad.net/juju-core/state"
+	"launchpad.net/juju-core/state/watcher"
+	"launchpad.net/juju-core/worker"
+)
+
+var logger = loggo.GetLogger("juju.worker.storageprovisioner")
+
+// pollInterval is the interval at which storage instances
+// are checked for volumes to create or destroy.
+var pollInterval = 30 * time.Second
+
+type storageProvisioner struct {
+	tomb tomb.Tomb
+	st   *state.State
+}
+
+// New returns a worker that provisions volumes for the storage
+// instances of units once the units' machines have been provisioned,
+// and destroys the volumes of storage instances whose units have
+// been removed.
+func New(st *state.State) worker.Worker {
+	p := &storageProvisioner{st: st}
+	go func() {
+		defer p.tomb.Done()
+		p.tomb.Kill(p.loop())
+	}()
+	return p
+}
+
+func (p *storageProvisioner) String() string {
+	return "storage provisioner"
+}
+
+// Kill implements worker.Worker.Kill.
+func (p *storageProvisioner) Kill() {
+	p.tomb.Kill(nil)
+}
+
+// Wait implements worker.Worker.Wait.
+func (p *storageProvisioner) Wait() error {
+	return p.tomb.Wait()
+}
+
+func (p *storageProvisioner) loop() error {
+	environWatcher := p.st.WatchForEnvironConfigChanges()
+	environ, err := worker.WaitForEnviron(environWatcher, p.st, p.tomb.Dying())
+	watcher.Stop(environWatcher, &p.tomb)
+	if err != nil {
+		return err
+	}
+	vs, ok := environ.(environs.VolumeSource)
+	if !ok {
+		logger.Infof("environment provider does not support storage")
+		<-p.tomb.Dying()
+		return tomb.ErrDying
+	}
+	for {
+		if err := p.update(vs); err != nil {
+			return err
+		}
+		select {
+		case <-p.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// update creates volumes for the storage instances that need them, and
+// destroys the volumes of storage instances that are dying.
+func (p *storageProvisioner) update(vs environs.VolumeSource) error {
+	instances, err := p.st.AllStorageInstances()
+	if err != nil {
+		return err
+	}
+	for _, inst := range instances {
+		var err error
+		if inst.Life() == state.Dying {
+			err = p.destroy(vs, inst)
+		} else if _, ok := inst.Info(); !ok {
+			err = p.provision(vs, inst)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// provision creates a volume for the storage instance, if its unit's
+// machine has been provisioned.
+func (p *storageProvisioner) provision(vs environs.VolumeSource, inst *state.StorageInstance) error {
+	unit, err := p.st.Unit(inst.UnitName())
+	if errors.IsNotFoundError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	machineId, err := unit.AssignedMachineId()
+	if state.IsNotAssigned(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	machine, err := p.st.Machine(machineId)
+	if err != nil {
+		return err
+	}
+	instId, err := machine.InstanceId()
+	if state.IsNotProvisionedError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	info, err := vs.CreateVolume(environs.VolumeParams{
+		Name:       inst.Id(),
+		Kind:       inst.Kind(),
+		Size:       inst.Size(),
+		Location:   inst.Location(),
+		InstanceId: instId,
+	})
+	if err != nil {
+		// We will try again next time.
+		logger.Warningf("cannot create volume for %v: %v", inst, err)
+		return nil
+	}
+	logger.Infof("created volume %q for %v", info.VolumeId, inst)
+	return inst.SetInfo(state.StorageInstanceInfo{
+		VolumeId: info.VolumeId,
+		Size:     info.Size,
+		Location: info.Location,
+	})
+}
+
+// destroy destroys the volume of the dying storage instance, if it has
+// one, and removes the storage instance.
+func (p *storageProvisioner) destroy(vs environs.VolumeSource, inst *state.StorageInstance) error {
+	if info, ok := inst.Info(); ok {
+		if err := vs.DestroyVolume(info.VolumeId); err != nil {
+			// We will try again next time.
+			logger.Warningf("cannot destroy volume %q for %v: %v", info.VolumeId, inst, err)
+			return nil
+		}
+		logger.Infof("destroyed volume %q for %v", info.VolumeId, inst)
+	}
+	return inst.Remove()
+}
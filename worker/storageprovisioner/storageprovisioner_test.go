@@ -0,0 +1,89 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package storageprovisioner_test
+
+import (
+	stdtesting "testing"
+	"time"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/errors"
+	jujutesting "launchpad.net/juju-core/juju/testing"
+	"launchpad.net/juju-core/state"
+	coretesting "launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker"
+	"launchpad.net/juju-core/worker/storageprovisioner"
+)
+
+func TestPackage(t *stdtesting.T) {
+	coretesting.MgoTestPackage(t)
+}
+
+type storageProvisionerSuite struct {
+	jujutesting.JujuConnSuite
+}
+
+var _ = gc.Suite(&storageProvisionerSuite{})
+
+func (s *storageProvisionerSuite) SetUpTest(c *gc.C) {
+	s.JujuConnSuite.SetUpTest(c)
+	s.PatchValue(storageprovisioner.PollInterval, 10*time.Millisecond)
+}
+
+func (s *storageProvisionerSuite) TestProvisionAndDestroy(c *gc.C) {
+	svc, err := s.State.AddService("storage-filesystem", s.AddTestingCharm(c, "storage-filesystem"))
+	c.Assert(err, gc.IsNil)
+	unit, err := svc.AddUnit()
+	c.Assert(err, gc.IsNil)
+	m, err := s.State.AddMachine("quantal", state.JobHostUnits)
+	c.Assert(err, gc.IsNil)
+	err = unit.AssignToMachine(m)
+	c.Assert(err, gc.IsNil)
+
+	w := storageprovisioner.New(s.State)
+	defer func() {
+		c.Assert(worker.Stop(w), gc.IsNil)
+	}()
+
+	// Nothing is provisioned until the unit's machine is.
+	inst, err := s.State.StorageInstance("data/0")
+	c.Assert(err, gc.IsNil)
+	time.Sleep(coretesting.ShortWait)
+	err = inst.Refresh()
+	c.Assert(err, gc.IsNil)
+	_, ok := inst.Info()
+	c.Assert(ok, gc.Equals, false)
+
+	envInst, _ := jujutesting.AssertStartInstance(c, s.Conn.Environ, m.Id())
+	err = m.SetProvisioned(envInst.Id(), "fake_nonce", nil)
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err = inst.Refresh()
+		c.Assert(err, gc.IsNil)
+		if _, ok = inst.Info(); ok {
+			break
+		}
+	}
+	info, ok := inst.Info()
+	c.Assert(ok, gc.Equals, true)
+	c.Assert(info, gc.Equals, state.StorageInstanceInfo{
+		VolumeId: "vol-0",
+		Size:     1024,
+		Location: "/srv/data",
+	})
+
+	err = unit.EnsureDead()
+	c.Assert(err, gc.IsNil)
+	err = unit.Remove()
+	c.Assert(err, gc.IsNil)
+	for a := coretesting.LongAttempt.Start(); a.Next(); {
+		err = inst.Refresh()
+		if errors.IsNotFoundError(err) {
+			return
+		}
+		c.Assert(err, gc.IsNil)
+	}
+	c.Fatalf("storage instance not removed")
+}
@@ -5,6 +5,7 @@ package uniter
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,11 +14,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/state/api/params"
 	"launchpad.net/juju-core/state/api/uniter"
+	utilexec "launchpad.net/juju-core/utils/exec"
 	unitdebug "launchpad.net/juju-core/worker/uniter/debug"
 	"launchpad.net/juju-core/worker/uniter/jujuc"
 )
@@ -59,11 +62,69 @@ type HookContext struct {
 
 	// apiAddrs contains the API server addresses.
 	apiAddrs []string
+
+	// actionData holds the state of the action being run. It is nil
+	// unless the context is running an action.
+	actionData *actionData
+
+	// definedMetrics holds the metrics declared by the charm, which
+	// are the only ones the hook may add.
+	definedMetrics map[string]charm.MetricSpec
+
+	// metrics holds the metrics added by the hook, which are recorded
+	// if it completes successfully.
+	metrics []params.Metric
+
+	// hookTimeout holds how long the hook may run before it is killed.
+	// If it is zero, the hook is not limited.
+	hookTimeout time.Duration
+
+	// cancel, when closed, causes the running hook to be killed.
+	cancel <-chan struct{}
+}
+
+// hookTimeoutError is returned when a hook is killed for running for
+// longer than allowed.
+type hookTimeoutError struct {
+	timeout time.Duration
+}
+
+func (e *hookTimeoutError) Error() string {
+	return fmt.Sprintf("hook timed out after %v", e.timeout)
+}
+
+// errHookCancelled is returned when a hook is killed at the user's
+// request.
+var errHookCancelled = errors.New("hook cancelled")
+
+// isHookKilled returns whether err indicates that a hook was killed
+// before it completed.
+func isHookKilled(err error) bool {
+	if _, ok := err.(*hookTimeoutError); ok {
+		return true
+	}
+	return err == errHookCancelled
+}
+
+// actionData holds the parameters and outcome of the action run in a
+// HookContext.
+type actionData struct {
+	action  *uniter.Action
+	results map[string]interface{}
+	failed  bool
+	message string
+}
+
+func newActionData(action *uniter.Action) *actionData {
+	return &actionData{
+		action:  action,
+		results: make(map[string]interface{}),
+	}
 }
 
 func NewHookContext(unit *uniter.Unit, id, uuid string, relationId int,
 	remoteUnitName string, relations map[int]*ContextRelation,
-	apiAddrs []string) (*HookContext, error) {
+	apiAddrs []string, definedMetrics map[string]charm.MetricSpec) (*HookContext, error) {
 	ctx := &HookContext{
 		unit:           unit,
 		id:             id,
@@ -72,6 +133,7 @@ func NewHookContext(unit *uniter.Unit, id, uuid string, relationId int,
 		remoteUnitName: remoteUnitName,
 		relations:      relations,
 		apiAddrs:       apiAddrs,
+		definedMetrics: definedMetrics,
 	}
 	// Get and cache the addresses.
 	var err error
@@ -106,6 +168,28 @@ func (ctx *HookContext) ClosePort(protocol string, port int) error {
 	return ctx.unit.ClosePort(protocol, port)
 }
 
+func (ctx *HookContext) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	return ctx.unit.SetWorkloadStatus(status, message)
+}
+
+func (ctx *HookContext) WorkloadStatus() (params.WorkloadStatus, string, error) {
+	return ctx.unit.WorkloadStatus()
+}
+
+func (ctx *HookContext) IsLeader() (bool, error) {
+	// Leaders are only elected by the uniter, which then runs the
+	// leader-elected hook; here the lease is at most renewed.
+	return ctx.unit.RenewLeadership()
+}
+
+func (ctx *HookContext) LeaderSettings() (map[string]string, error) {
+	return ctx.unit.LeaderSettings()
+}
+
+func (ctx *HookContext) WriteLeaderSettings(settings map[string]string) error {
+	return ctx.unit.SetLeaderSettings(settings)
+}
+
 func (ctx *HookContext) ConfigSettings() (charm.Settings, error) {
 	if ctx.configSettings == nil {
 		var err error
@@ -142,6 +226,61 @@ func (ctx *HookContext) RelationIds() []int {
 	return ids
 }
 
+func (ctx *HookContext) StorageInstances() ([]params.StorageInstance, error) {
+	return ctx.unit.StorageInstances()
+}
+
+var errNotRunningAction = fmt.Errorf("not running an action")
+
+func (ctx *HookContext) ActionParams() (map[string]interface{}, error) {
+	if ctx.actionData == nil {
+		return nil, errNotRunningAction
+	}
+	return ctx.actionData.action.Params(), nil
+}
+
+func (ctx *HookContext) UpdateActionResults(keys []string, value string) error {
+	if ctx.actionData == nil {
+		return errNotRunningAction
+	}
+	target := ctx.actionData.results
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := target[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			target[key] = next
+		}
+		target = next
+	}
+	target[keys[len(keys)-1]] = value
+	return nil
+}
+
+func (ctx *HookContext) SetActionFailed(message string) error {
+	if ctx.actionData == nil {
+		return errNotRunningAction
+	}
+	ctx.actionData.failed = true
+	ctx.actionData.message = message
+	return nil
+}
+
+func (ctx *HookContext) AddMetric(key string, value float64, created time.Time) error {
+	spec, ok := ctx.definedMetrics[key]
+	if !ok {
+		return fmt.Errorf("metric %q not declared by the charm", key)
+	}
+	if err := spec.ValidateValue(value); err != nil {
+		return fmt.Errorf("invalid value for metric %q: %v", key, err)
+	}
+	ctx.metrics = append(ctx.metrics, params.Metric{
+		Key:   key,
+		Value: value,
+		Time:  created,
+	})
+	return nil
+}
+
 // hookVars returns an os.Environ-style list of strings necessary to run a hook
 // such that it can know what environment it's operating in, and can call back
 // into ctx.
@@ -163,6 +302,10 @@ func (ctx *HookContext) hookVars(charmDir, toolsDir, socketPath string) []string
 		name, _ := ctx.RemoteUnitName()
 		vars = append(vars, "JUJU_REMOTE_UNIT="+name)
 	}
+	if ctx.actionData != nil {
+		vars = append(vars, "JUJU_ACTION_NAME="+ctx.actionData.action.Name())
+		vars = append(vars, "JUJU_ACTION_ID="+ctx.actionData.action.Id())
+	}
 	return vars
 }
 
@@ -172,11 +315,13 @@ func (ctx *HookContext) RunHook(hookName, charmDir, toolsDir, socketPath string)
 	var err error
 	env := ctx.hookVars(charmDir, toolsDir, socketPath)
 	debugctx := unitdebug.NewHooksContext(ctx.unit.Name())
-	if session, _ := debugctx.FindSession(); session != nil && session.MatchHook(hookName) {
+	if ctx.actionData != nil {
+		err = ctx.runCharmAction(hookName, charmDir, env)
+	} else if session, _ := debugctx.FindSession(); session != nil && session.MatchHook(hookName) {
 		logger.Infof("executing %s via debug-hooks", hookName)
 		err = session.RunHook(hookName, charmDir, env)
 	} else {
-		err = runCharmHook(hookName, charmDir, env)
+		err = ctx.runCharmHook(hookName, charmDir, env)
 	}
 	write := err == nil
 	for id, rctx := range ctx.relations {
@@ -194,13 +339,83 @@ func (ctx *HookContext) RunHook(hookName, charmDir, toolsDir, socketPath string)
 		}
 		rctx.ClearCache()
 	}
+	if write {
+		ctx.flushMetrics()
+	}
 	return err
 }
 
-func runCharmHook(hookName, charmDir string, env []string) error {
-	ps := exec.Command(filepath.Join(charmDir, "hooks", hookName))
+// flushMetrics records the metrics added by the hook. Failing to do so
+// is logged rather than failing the hook.
+func (ctx *HookContext) flushMetrics() {
+	if len(ctx.metrics) == 0 {
+		return
+	}
+	if err := ctx.unit.AddMetrics(ctx.metrics); err != nil {
+		logger.Errorf("could not add metrics: %v", err)
+	}
+	ctx.metrics = nil
+}
+
+// RunCommands executes the commands in an environment which allows them
+// to call back into ctx to execute jujuc tools, and returns their output
+// and exit code. Like a hook, the commands are killed if they run for
+// longer than the hook timeout.
+func (ctx *HookContext) RunCommands(commands, charmDir, toolsDir, socketPath string) (*utilexec.ExecResponse, error) {
+	env := ctx.hookVars(charmDir, toolsDir, socketPath)
+	result, err := utilexec.RunCommands(utilexec.RunParams{
+		Commands:    commands,
+		WorkingDir:  charmDir,
+		Environment: env,
+		Timeout:     ctx.hookTimeout,
+	})
+	for id, rctx := range ctx.relations {
+		if err == nil && result.Code == 0 {
+			if e := rctx.WriteSettings(); e != nil {
+				e = fmt.Errorf(
+					"could not write settings from commands to relation %d: %v",
+					id, e,
+				)
+				logger.Errorf("%v", e)
+				err = e
+			}
+		}
+		rctx.ClearCache()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.Code == 0 {
+		ctx.flushMetrics()
+	}
+	return result, nil
+}
+
+func (ctx *HookContext) runCharmHook(hookName, charmDir string, env []string) error {
+	err := ctx.runCharmScript(filepath.Join(charmDir, "hooks", hookName), charmDir, env)
+	if ee, ok := err.(*exec.Error); ok && err != nil {
+		if os.IsNotExist(ee.Err) {
+			// Missing hook is perfectly valid, but worth mentioning.
+			logger.Infof("skipped %q hook (not implemented)", hookName)
+			return nil
+		}
+	}
+	return err
+}
+
+// runCharmAction runs the named action script. Unlike a hook, an action
+// declared by the charm must be implemented.
+func (ctx *HookContext) runCharmAction(actionName, charmDir string, env []string) error {
+	return ctx.runCharmScript(filepath.Join(charmDir, "actions", actionName), charmDir, env)
+}
+
+func (ctx *HookContext) runCharmScript(path, charmDir string, env []string) error {
+	ps := exec.Command(path)
 	ps.Env = env
 	ps.Dir = charmDir
+	// Run the script in its own process group, so that any processes
+	// it starts can be killed along with it.
+	ps.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	outReader, outWriter, err := os.Pipe()
 	if err != nil {
 		return fmt.Errorf("cannot make logging pipe: %v", err)
@@ -215,16 +430,40 @@ func runCharmHook(hookName, charmDir string, env []string) error {
 	err = ps.Start()
 	outWriter.Close()
 	if err == nil {
-		err = ps.Wait()
+		err = ctx.wait(ps)
 	}
 	hookLogger.stop()
-	if ee, ok := err.(*exec.Error); ok && err != nil {
-		if os.IsNotExist(ee.Err) {
-			// Missing hook is perfectly valid, but worth mentioning.
-			logger.Infof("skipped %q hook (not implemented)", hookName)
-			return nil
-		}
+	return err
+}
+
+// wait waits for the started script to exit. If the script runs for
+// longer than the hook timeout, or the hook is cancelled, its process
+// group is killed.
+func (ctx *HookContext) wait(ps *exec.Cmd) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- ps.Wait()
+	}()
+	var timedOut <-chan time.Time
+	if ctx.hookTimeout > 0 {
+		timer := time.NewTimer(ctx.hookTimeout)
+		defer timer.Stop()
+		timedOut = timer.C
+	}
+	var err error
+	select {
+	case err := <-done:
+		return err
+	case <-timedOut:
+		err = &hookTimeoutError{ctx.hookTimeout}
+	case <-ctx.cancel:
+		err = errHookCancelled
+	}
+	logger.Infof("killing hook: %v", err)
+	if err := syscall.Kill(-ps.Process.Pid, syscall.SIGKILL); err != nil {
+		logger.Errorf("cannot kill hook processes: %v", err)
 	}
+	<-done
 	return err
 }
 
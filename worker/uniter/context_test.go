@@ -121,6 +121,12 @@ const lineBufferSize = 4096
 var apiAddrs = []string{"a1:123", "a2:123"}
 var expectedApiAddrs = strings.Join(apiAddrs, " ")
 
+// testMetrics holds the metrics declared by the charm of test contexts.
+var testMetrics = map[string]charm.MetricSpec{
+	"load":        {Type: charm.MetricTypeGauge},
+	"connections": {Type: charm.MetricTypeAbsolute},
+}
+
 var runHookTests = []struct {
 	summary string
 	relid   int
@@ -329,6 +335,143 @@ func (s *RunHookSuite) TestRunHookRelationFlushing(c *gc.C) {
 	})
 }
 
+func (s *RunHookSuite) TestRunHookMetricsFlushing(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.GetHookContext(c, uuid.String(), -1, "")
+	now := time.Date(2014, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Metrics added by a failing hook are discarded.
+	charmDir, _ := makeCharm(c, hookSpec{
+		name: "something-happened",
+		perm: 0700,
+		code: 123,
+	})
+	err = ctx.AddMetric("load", 0.25, now)
+	c.Assert(err, gc.IsNil)
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.ErrorMatches, "exit status 123")
+	values, err := s.service.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.HasLen, 0)
+
+	// Metrics added by a working hook are recorded.
+	charmDir, _ = makeCharm(c, hookSpec{
+		name: "something-happened",
+		perm: 0700,
+	})
+	err = ctx.AddMetric("load", 0.75, now)
+	c.Assert(err, gc.IsNil)
+	err = ctx.RunHook("something-happened", charmDir, c.MkDir(), "/path/to/socket")
+	c.Assert(err, gc.IsNil)
+	values, err = s.service.MetricValues("load", time.Time{})
+	c.Assert(err, gc.IsNil)
+	c.Assert(values, gc.DeepEquals, []state.Metric{{Key: "load", Value: 0.75, Time: now}})
+}
+
+func (s *RunHookSuite) TestAddMetricValidation(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.GetHookContext(c, uuid.String(), -1, "")
+	now := time.Now()
+	err = ctx.AddMetric("connections", 3, now)
+	c.Assert(err, gc.IsNil)
+	err = ctx.AddMetric("requests", 1, now)
+	c.Assert(err, gc.ErrorMatches, `metric "requests" not declared by the charm`)
+	err = ctx.AddMetric("connections", -1, now)
+	c.Assert(err, gc.ErrorMatches, `invalid value for metric "connections": value -1 is negative`)
+}
+
+func (s *RunHookSuite) TestWorkloadStatus(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.GetHookContext(c, uuid.String(), -1, "")
+	status, message, err := ctx.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadUnknown)
+	c.Assert(message, gc.Equals, "")
+
+	// The status is set immediately, rather than when the hook completes.
+	err = ctx.SetWorkloadStatus(params.WorkloadMaintenance, "installing packages")
+	c.Assert(err, gc.IsNil)
+	status, message, err = s.unit.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadMaintenance)
+	c.Assert(message, gc.Equals, "installing packages")
+	status, message, err = ctx.WorkloadStatus()
+	c.Assert(err, gc.IsNil)
+	c.Assert(status, gc.Equals, params.WorkloadMaintenance)
+	c.Assert(message, gc.Equals, "installing packages")
+}
+
+func (s *RunHookSuite) TestLeadership(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.GetHookContext(c, uuid.String(), -1, "")
+
+	// Only the leader can publish settings.
+	err = ctx.WriteLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.ErrorMatches, `cannot write leader settings of service "u": unit "u/0" is not the leader`)
+
+	// Asking does not make the unit the leader.
+	isLeader, err := ctx.IsLeader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(isLeader, gc.Equals, false)
+	leader, err := s.service.Leader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(leader, gc.Equals, "")
+
+	claimed, err := s.unit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, gc.Equals, true)
+	isLeader, err = ctx.IsLeader()
+	c.Assert(err, gc.IsNil)
+	c.Assert(isLeader, gc.Equals, true)
+
+	err = ctx.WriteLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	settings, err := ctx.LeaderSettings()
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings, gc.DeepEquals, map[string]string{"master": "10.0.0.1"})
+}
+
+func (s *RunHookSuite) TestRunCommands(c *gc.C) {
+	uuid, err := utils.NewUUID()
+	c.Assert(err, gc.IsNil)
+	ctx := s.GetHookContext(c, uuid.String(), 1, "")
+	charmDir := c.MkDir()
+	toolsDir := c.MkDir()
+
+	node1, err := s.relctxs[1].Settings()
+	c.Assert(err, gc.IsNil)
+	node1.Set("ran", "yes")
+
+	commands := "echo $JUJU_UNIT_NAME $JUJU_RELATION_ID; pwd\necho oops >&2; exit 3"
+	result, err := ctx.RunCommands(commands, charmDir, toolsDir, "/path/to/socket")
+	c.Assert(err, gc.IsNil)
+	c.Assert(string(result.Stdout), gc.Equals, "u/0 db:1\n"+charmDir+"\n")
+	c.Assert(string(result.Stderr), gc.Equals, "oops\n")
+	c.Assert(result.Code, gc.Equals, 3)
+
+	// The commands failed, so the settings change was discarded.
+	settings1, err := s.relunits[1].ReadSettings("u/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings1, gc.DeepEquals, map[string]interface{}{"relation-name": "db1"})
+
+	node1, err = s.relctxs[1].Settings()
+	c.Assert(err, gc.IsNil)
+	node1.Set("ran", "yes")
+	result, err = ctx.RunCommands("true", charmDir, toolsDir, "/path/to/socket")
+	c.Assert(err, gc.IsNil)
+	c.Assert(result.Code, gc.Equals, 0)
+	settings1, err = s.relunits[1].ReadSettings("u/0")
+	c.Assert(err, gc.IsNil)
+	c.Assert(settings1, gc.DeepEquals, map[string]interface{}{
+		"relation-name": "db1",
+		"ran":           "yes",
+	})
+}
+
 type ContextRelationSuite struct {
 	testing.JujuConnSuite
 	svc *state.Service
@@ -698,7 +841,7 @@ func (s *HookContextSuite) GetHookContext(c *gc.C, uuid string, relid int,
 		c.Assert(found, gc.Equals, true)
 	}
 	context, err := uniter.NewHookContext(s.apiUnit, "TestCtx", uuid, relid, remote,
-		s.relctxs, apiAddrs)
+		s.relctxs, apiAddrs, testMetrics)
 	c.Assert(err, gc.IsNil)
 	return context
 }
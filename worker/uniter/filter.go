@@ -35,14 +35,20 @@ type filter struct {
 	// The out* chans, when set to the corresponding out*On chan (rather than
 	// nil) indicate that an event of the appropriate type is ready to send
 	// to the client.
-	outConfig      chan struct{}
-	outConfigOn    chan struct{}
-	outUpgrade     chan *charm.URL
-	outUpgradeOn   chan *charm.URL
-	outResolved    chan params.ResolvedMode
-	outResolvedOn  chan params.ResolvedMode
-	outRelations   chan []int
-	outRelationsOn chan []int
+	outConfig           chan struct{}
+	outConfigOn         chan struct{}
+	outUpgrade          chan *charm.URL
+	outUpgradeOn        chan *charm.URL
+	outResolved         chan params.ResolvedMode
+	outResolvedOn       chan params.ResolvedMode
+	outRelations        chan []int
+	outRelationsOn      chan []int
+	outAction           chan string
+	outActionOn         chan string
+	outCancel           chan struct{}
+	outCancelOn         chan struct{}
+	outLeaderSettings   chan struct{}
+	outLeaderSettingsOn chan struct{}
 
 	// The want* chans are used to indicate that the filter should send
 	// events if it has them available.
@@ -53,6 +59,10 @@ type filter struct {
 	// should be discarded.
 	discardConfig chan struct{}
 
+	// discardCancel is used to indicate that any pending request to
+	// cancel a hook should be discarded.
+	discardCancel chan struct{}
+
 	// setCharm is used to request that the unit's charm URL be set to
 	// a new value. This must be done in the filter's goroutine, so
 	// that config watches can be stopped and restarted pointing to
@@ -87,29 +97,37 @@ type filter struct {
 	upgradeAvailable serviceCharm
 	upgrade          *charm.URL
 	relations        []int
+	actions          []string
 }
 
 // newFilter returns a filter that handles state changes pertaining to the
 // supplied unit.
 func newFilter(st *uniter.State, unitTag string) (*filter, error) {
 	f := &filter{
-		st:                st,
-		outUnitDying:      make(chan struct{}),
-		outConfig:         make(chan struct{}),
-		outConfigOn:       make(chan struct{}),
-		outUpgrade:        make(chan *charm.URL),
-		outUpgradeOn:      make(chan *charm.URL),
-		outResolved:       make(chan params.ResolvedMode),
-		outResolvedOn:     make(chan params.ResolvedMode),
-		outRelations:      make(chan []int),
-		outRelationsOn:    make(chan []int),
-		wantForcedUpgrade: make(chan bool),
-		wantResolved:      make(chan struct{}),
-		discardConfig:     make(chan struct{}),
-		setCharm:          make(chan *charm.URL),
-		didSetCharm:       make(chan struct{}),
-		clearResolved:     make(chan struct{}),
-		didClearResolved:  make(chan struct{}),
+		st:                  st,
+		outUnitDying:        make(chan struct{}),
+		outConfig:           make(chan struct{}),
+		outConfigOn:         make(chan struct{}),
+		outUpgrade:          make(chan *charm.URL),
+		outUpgradeOn:        make(chan *charm.URL),
+		outResolved:         make(chan params.ResolvedMode),
+		outResolvedOn:       make(chan params.ResolvedMode),
+		outRelations:        make(chan []int),
+		outRelationsOn:      make(chan []int),
+		outAction:           make(chan string),
+		outActionOn:         make(chan string),
+		outCancel:           make(chan struct{}),
+		outCancelOn:         make(chan struct{}),
+		outLeaderSettings:   make(chan struct{}),
+		outLeaderSettingsOn: make(chan struct{}),
+		wantForcedUpgrade:   make(chan bool),
+		wantResolved:        make(chan struct{}),
+		discardConfig:       make(chan struct{}),
+		discardCancel:       make(chan struct{}),
+		setCharm:            make(chan *charm.URL),
+		didSetCharm:         make(chan struct{}),
+		clearResolved:       make(chan struct{}),
+		didClearResolved:    make(chan struct{}),
 	}
 	go func() {
 		defer f.tomb.Done()
@@ -165,6 +183,25 @@ func (f *filter) RelationsEvents() <-chan []int {
 	return f.outRelationsOn
 }
 
+// ActionEvents returns a channel that will receive the id of each action
+// queued on the unit, in the order they were queued.
+func (f *filter) ActionEvents() <-chan string {
+	return f.outActionOn
+}
+
+// CancelEvents returns a channel that will receive a signal when the
+// unit's agent is asked to cancel the hook it is running.
+func (f *filter) CancelEvents() <-chan struct{} {
+	return f.outCancelOn
+}
+
+// LeaderSettingsEvents returns a channel that will receive a signal
+// whenever the settings published by the leader of the unit's service
+// change.
+func (f *filter) LeaderSettingsEvents() <-chan struct{} {
+	return f.outLeaderSettingsOn
+}
+
 // WantUpgradeEvent controls whether the filter will generate upgrade
 // events for unforced service charm changes.
 func (f *filter) WantUpgradeEvent(mustForce bool) {
@@ -237,6 +274,16 @@ func (f *filter) DiscardConfigEvent() {
 	}
 }
 
+// DiscardCancelEvent indicates that the filter should discard any
+// pending request to cancel a hook, which cannot apply to a hook that
+// has yet to start.
+func (f *filter) DiscardCancelEvent() {
+	select {
+	case <-f.tomb.Dying():
+	case f.discardCancel <- nothing:
+	}
+}
+
 func (f *filter) maybeStopWatcher(w watcher.Stopper) {
 	if w != nil {
 		watcher.Stop(w, &f.tomb)
@@ -298,6 +345,19 @@ func (f *filter) loop(unitTag string) (err error) {
 			watcher.Stop(relationsw, &f.tomb)
 		}
 	}()
+	actionsw, err := f.unit.WatchActions()
+	if err != nil {
+		return err
+	}
+	defer f.maybeStopWatcher(actionsw)
+	leaderSettingsw, err := f.unit.WatchLeaderSettings()
+	if err != nil {
+		return err
+	}
+	defer f.maybeStopWatcher(leaderSettingsw)
+	// The initial leader settings event is not interesting: the
+	// uniter only needs to know when the settings change.
+	seenLeaderSettings := false
 
 	// Config events cannot be meaningfully discarded until one is available;
 	// once we receive the initial change, we unblock discard requests by
@@ -353,6 +413,22 @@ func (f *filter) loop(unitTag string) (err error) {
 				}
 			}
 			f.relationsChanged(ids)
+		case ids, ok := <-actionsw.Changes():
+			filterLogger.Debugf("got actions change")
+			if !ok {
+				return watcher.MustErr(actionsw)
+			}
+			f.actionsChanged(ids)
+		case _, ok = <-leaderSettingsw.Changes():
+			filterLogger.Debugf("got leader settings change")
+			if !ok {
+				return watcher.MustErr(leaderSettingsw)
+			}
+			if seenLeaderSettings {
+				filterLogger.Debugf("preparing new leader settings event")
+				f.outLeaderSettings = f.outLeaderSettingsOn
+			}
+			seenLeaderSettings = true
 
 		// Send events on active out chans.
 		case f.outUpgrade <- f.upgrade:
@@ -368,6 +444,18 @@ func (f *filter) loop(unitTag string) (err error) {
 			filterLogger.Debugf("sent relations event")
 			f.outRelations = nil
 			f.relations = nil
+		case f.outCancel <- nothing:
+			filterLogger.Debugf("sent cancel event")
+			f.outCancel = nil
+		case f.outLeaderSettings <- nothing:
+			filterLogger.Debugf("sent leader settings event")
+			f.outLeaderSettings = nil
+		case f.outAction <- f.nextAction():
+			filterLogger.Debugf("sent action event")
+			f.actions = f.actions[1:]
+			if len(f.actions) == 0 {
+				f.outAction = nil
+			}
 
 		// Handle explicit requests.
 		case curl := <-f.setCharm:
@@ -436,6 +524,9 @@ func (f *filter) loop(unitTag string) (err error) {
 		case <-discardConfig:
 			filterLogger.Debugf("discarded config event")
 			f.outConfig = nil
+		case <-f.discardCancel:
+			filterLogger.Debugf("discarded cancel event")
+			f.outCancel = nil
 		}
 	}
 }
@@ -469,6 +560,19 @@ func (f *filter) unitChanged() error {
 			f.outResolved = f.outResolvedOn
 		}
 	}
+	cancel, err := f.unit.HookCancelRequested()
+	if err != nil {
+		return err
+	}
+	if cancel {
+		// The request is handled at most once, so clear it right
+		// away; a further request generates a further event.
+		filterLogger.Debugf("preparing new cancel event")
+		f.outCancel = f.outCancelOn
+		if err := f.unit.ClearCancelHook(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -542,6 +646,45 @@ outer:
 	}
 }
 
+// actionsChanged responds to newly queued actions.
+func (f *filter) actionsChanged(ids []string) {
+	// Action ids are allocated in sequence, so queue them in numeric
+	// rather than lexical order.
+	sort.Sort(actionIds(ids))
+outer:
+	for _, id := range ids {
+		for _, existing := range f.actions {
+			if id == existing {
+				continue outer
+			}
+		}
+		f.actions = append(f.actions, id)
+	}
+	if len(f.actions) != 0 {
+		f.outAction = f.outActionOn
+	}
+}
+
+// nextAction returns the id of the next action to be sent, if any.
+func (f *filter) nextAction() string {
+	if len(f.actions) == 0 {
+		return ""
+	}
+	return f.actions[0]
+}
+
+// actionIds sorts action ids numerically.
+type actionIds []string
+
+func (ids actionIds) Len() int      { return len(ids) }
+func (ids actionIds) Swap(i, j int) { ids[i], ids[j] = ids[j], ids[i] }
+func (ids actionIds) Less(i, j int) bool {
+	if len(ids[i]) != len(ids[j]) {
+		return len(ids[i]) < len(ids[j])
+	}
+	return ids[i] < ids[j]
+}
+
 // serviceCharm holds information about a charm.
 type serviceCharm struct {
 	url   *charm.URL
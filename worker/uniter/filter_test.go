@@ -461,3 +461,87 @@ func (s *FilterSuite) addRelation(c *gc.C) *state.Relation {
 	c.Assert(err, gc.IsNil)
 	return rel
 }
+
+func (s *FilterSuite) TestActionEvents(c *gc.C) {
+	dummy, err := s.State.AddService("dummy", s.AddTestingCharm(c, "dummy"))
+	c.Assert(err, gc.IsNil)
+	unit, err := dummy.AddUnit()
+	c.Assert(err, gc.IsNil)
+	s.APILogin(c, unit)
+	first, err := unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+
+	f, err := newFilter(s.uniter, unit.Tag())
+	c.Assert(err, gc.IsNil)
+	defer f.Stop()
+
+	assertNoChange := func() {
+		s.BackingState.StartSync()
+		select {
+		case id := <-f.ActionEvents():
+			c.Fatalf("unexpected action event %q", id)
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	assertChange := func(expect string) {
+		s.BackingState.StartSync()
+		select {
+		case got := <-f.ActionEvents():
+			c.Assert(got, gc.Equals, expect)
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out")
+		}
+	}
+
+	// Check the initial event.
+	assertChange(first.Id())
+	assertNoChange()
+
+	// Queue a couple more actions; check they are delivered in order.
+	second, err := unit.AddAction("snapshot", nil)
+	c.Assert(err, gc.IsNil)
+	third, err := unit.AddAction("snapshot", map[string]interface{}{"outfile": "bar.bz2"})
+	c.Assert(err, gc.IsNil)
+	assertChange(second.Id())
+	assertChange(third.Id())
+	assertNoChange()
+}
+
+func (s *FilterSuite) TestLeaderSettingsEvents(c *gc.C) {
+	f, err := newFilter(s.uniter, s.unit.Tag())
+	c.Assert(err, gc.IsNil)
+	defer f.Stop()
+
+	assertNoChange := func() {
+		s.BackingState.StartSync()
+		select {
+		case <-f.LeaderSettingsEvents():
+			c.Fatalf("unexpected leader settings event")
+		case <-time.After(coretesting.ShortWait):
+		}
+	}
+	assertChange := func() {
+		s.BackingState.StartSync()
+		select {
+		case <-f.LeaderSettingsEvents():
+		case <-time.After(coretesting.LongWait):
+			c.Fatalf("timed out")
+		}
+	}
+
+	// There is no initial event, and claiming the leadership does not
+	// change the settings.
+	assertNoChange()
+	claimed, err := s.unit.ClaimLeadership(time.Minute)
+	c.Assert(err, gc.IsNil)
+	c.Assert(claimed, gc.Equals, true)
+	assertNoChange()
+
+	// Change the settings a couple of times; check a single event.
+	err = s.unit.SetLeaderSettings(map[string]string{"master": "10.0.0.1"})
+	c.Assert(err, gc.IsNil)
+	err = s.unit.SetLeaderSettings(map[string]string{"port": "3306"})
+	c.Assert(err, gc.IsNil)
+	assertChange()
+	assertNoChange()
+}
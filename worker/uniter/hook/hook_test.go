@@ -36,16 +36,23 @@ var validateTests = []struct {
 	}, {
 		hook.Info{Kind: hooks.Kind("grok")},
 		`unknown hook kind "grok"`,
+	}, {
+		hook.Info{Kind: hooks.ActionRequested},
+		`"action-requested" hook requires an action id`,
 	},
 	{hook.Info{Kind: hooks.Install}, ""},
 	{hook.Info{Kind: hooks.Start}, ""},
 	{hook.Info{Kind: hooks.ConfigChanged}, ""},
 	{hook.Info{Kind: hooks.UpgradeCharm}, ""},
 	{hook.Info{Kind: hooks.Stop}, ""},
+	{hook.Info{Kind: hooks.CollectMetrics}, ""},
+	{hook.Info{Kind: hooks.LeaderElected}, ""},
+	{hook.Info{Kind: hooks.LeaderSettingsChanged}, ""},
 	{hook.Info{Kind: hooks.RelationJoined, RemoteUnit: "x"}, ""},
 	{hook.Info{Kind: hooks.RelationChanged, RemoteUnit: "x"}, ""},
 	{hook.Info{Kind: hooks.RelationDeparted, RemoteUnit: "x"}, ""},
 	{hook.Info{Kind: hooks.RelationBroken}, ""},
+	{hook.Info{Kind: hooks.ActionRequested, ActionId: "0"}, ""},
 }
 
 func (s *InfoSuite) TestValidate(c *gc.C) {
@@ -0,0 +1,50 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// ActionFailCommand implements the action-fail command.
+type ActionFailCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Message string
+}
+
+func NewActionFailCommand(ctx Context) cmd.Command {
+	return &ActionFailCommand{ctx: ctx}
+}
+
+func (c *ActionFailCommand) Info() *cmd.Info {
+	doc := `
+Mark the running action as failed, with an optional message. The action's
+results are still recorded, and the action script continues to run.
+`
+	return &cmd.Info{
+		Name:    "action-fail",
+		Args:    `["<failure message>"]`,
+		Purpose: "mark the action as failed",
+		Doc:     doc,
+	}
+}
+
+func (c *ActionFailCommand) SetFlags(f *gnuflag.FlagSet) {
+}
+
+func (c *ActionFailCommand) Init(args []string) error {
+	c.Message = "action failed without reason given, check action for errors"
+	if len(args) > 0 {
+		c.Message = args[0]
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ActionFailCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.SetActionFailed(c.Message)
+}
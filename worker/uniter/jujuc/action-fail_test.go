@@ -0,0 +1,56 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type ActionFailSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&ActionFailSuite{})
+
+var actionFailTests = []struct {
+	args    []string
+	message string
+}{
+	{nil, "action failed without reason given, check action for errors"},
+	{[]string{"disk full"}, "disk full"},
+}
+
+func (s *ActionFailSuite) TestActionFail(c *gc.C) {
+	for i, t := range actionFailTests {
+		c.Logf("test %d: %v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		hctx.action = &ContextAction{}
+		com, err := jujuc.NewCommand(hctx, "action-fail")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(hctx.action.failed, gc.Equals, t.message)
+	}
+}
+
+func (s *ActionFailSuite) TestNotRunningAction(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "action-fail")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, nil)
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: not running an action\n")
+}
+
+func (s *ActionFailSuite) TestUnknownArg(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "action-fail")
+	c.Assert(err, gc.IsNil)
+	testing.TestInit(c, com, []string{"oops", "blah"}, `unrecognized args: \["blah"\]`)
+}
@@ -0,0 +1,67 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// ActionGetCommand implements the action-get command.
+type ActionGetCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	Keys []string // The path to the value to show. If empty, show all.
+	out  cmd.Output
+}
+
+func NewActionGetCommand(ctx Context) cmd.Command {
+	return &ActionGetCommand{ctx: ctx}
+}
+
+func (c *ActionGetCommand) Info() *cmd.Info {
+	doc := `
+When no <key> is supplied, all parameters of the running action are printed.
+Nested values may be selected by joining keys with dots, for example
+"outfile.name".
+`
+	return &cmd.Info{
+		Name:    "action-get",
+		Args:    "[<key>[.<key>...]]",
+		Purpose: "print action parameters",
+		Doc:     doc,
+	}
+}
+
+func (c *ActionGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *ActionGetCommand) Init(args []string) error {
+	if len(args) > 0 {
+		c.Keys = strings.Split(args[0], ".")
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *ActionGetCommand) Run(ctx *cmd.Context) error {
+	params, err := c.ctx.ActionParams()
+	if err != nil {
+		return err
+	}
+	var value interface{} = params
+	for _, key := range c.Keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = m[key]
+	}
+	return c.out.Write(ctx, value)
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type ActionGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&ActionGetSuite{})
+
+var actionGetTests = []struct {
+	args []string
+	out  string
+}{
+	{[]string{"outfile"}, "foo.bz2\n"},
+	{[]string{"--format", "json", "outfile"}, `"foo.bz2"` + "\n"},
+	{[]string{"compression.kind"}, "gzip\n"},
+	{[]string{"compression.level"}, "9\n"},
+	{[]string{"compression.nosuch"}, ""},
+	{[]string{"outfile.nosuch"}, ""},
+	{[]string{"nosuch"}, ""},
+	{[]string{"--format", "json"}, `{"compression":{"kind":"gzip","level":9},"outfile":"foo.bz2"}` + "\n"},
+}
+
+func (s *ActionGetSuite) createCommand(c *gc.C, hctx *Context) cmd.Command {
+	com, err := jujuc.NewCommand(hctx, "action-get")
+	c.Assert(err, gc.IsNil)
+	return com
+}
+
+func (s *ActionGetSuite) TestActionGet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.action = &ContextAction{
+		params: map[string]interface{}{
+			"outfile": "foo.bz2",
+			"compression": map[string]interface{}{
+				"kind":  "gzip",
+				"level": 9,
+			},
+		},
+	}
+	for i, t := range actionGetTests {
+		c.Logf("test %d: %v", i, t.args)
+		com := s.createCommand(c, hctx)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *ActionGetSuite) TestNotRunningAction(c *gc.C) {
+	com := s.createCommand(c, s.GetHookContext(c, -1, ""))
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, nil)
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: not running an action\n")
+}
+
+func (s *ActionGetSuite) TestUnknownArg(c *gc.C) {
+	com := s.createCommand(c, s.GetHookContext(c, -1, ""))
+	testing.TestInit(c, com, []string{"outfile", "blah"}, `unrecognized args: \["blah"\]`)
+}
@@ -0,0 +1,74 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+var validActionResultKey = regexp.MustCompile("^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$")
+
+// ActionSetCommand implements the action-set command.
+type ActionSetCommand struct {
+	cmd.CommandBase
+	ctx  Context
+	args [][]string
+}
+
+func NewActionSetCommand(ctx Context) cmd.Command {
+	return &ActionSetCommand{ctx: ctx}
+}
+
+func (c *ActionSetCommand) Info() *cmd.Info {
+	doc := `
+Set values to be reported as the results of the running action. Keys may be
+nested by joining them with dots, for example "outfile.size=42M"; each key
+must consist of lowercase alphanumerics and hyphens.
+`
+	return &cmd.Info{
+		Name:    "action-set",
+		Args:    "<key>=<value> [<key>=<value> ...]",
+		Purpose: "set action results",
+		Doc:     doc,
+	}
+}
+
+func (c *ActionSetCommand) SetFlags(f *gnuflag.FlagSet) {
+}
+
+func (c *ActionSetCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no key=value pairs specified")
+	}
+	for _, kv := range args {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return fmt.Errorf(`expected "key=value", got %q`, kv)
+		}
+		keys := strings.Split(parts[0], ".")
+		for _, key := range keys {
+			if !validActionResultKey.MatchString(key) {
+				return fmt.Errorf("invalid key %q", parts[0])
+			}
+		}
+		c.args = append(c.args, append(keys, parts[1]))
+	}
+	return nil
+}
+
+func (c *ActionSetCommand) Run(ctx *cmd.Context) error {
+	for _, arg := range c.args {
+		keys, value := arg[:len(arg)-1], arg[len(arg)-1]
+		if err := c.ctx.UpdateActionResults(keys, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
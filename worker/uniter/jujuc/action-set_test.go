@@ -0,0 +1,66 @@
+// Copyright 2013 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type ActionSetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&ActionSetSuite{})
+
+var actionSetInitTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no key=value pairs specified"},
+	{[]string{"size"}, `expected "key=value", got "size"`},
+	{[]string{"=42M"}, `expected "key=value", got "=42M"`},
+	{[]string{"Size=42M"}, `invalid key "Size"`},
+	{[]string{"outfile..size=42M"}, `invalid key "outfile..size"`},
+	{[]string{"outfile.size-=42M"}, `invalid key "outfile.size-"`},
+}
+
+func (s *ActionSetSuite) TestInit(c *gc.C) {
+	for i, t := range actionSetInitTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "action-set")
+		c.Assert(err, gc.IsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *ActionSetSuite) TestActionSet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.action = &ContextAction{}
+	com, err := jujuc.NewCommand(hctx, "action-set")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"outcome=done", "outfile.name=foo.bz2", "outfile.size=42M"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.action.results, gc.DeepEquals, map[string]interface{}{
+		"outcome": "done",
+		"outfile": map[string]interface{}{
+			"name": "foo.bz2",
+			"size": "42M",
+		},
+	})
+}
+
+func (s *ActionSetSuite) TestNotRunningAction(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "action-set")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"outcome=done"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: not running an action\n")
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/charm"
+	"launchpad.net/juju-core/cmd"
+)
+
+// Metric holds a single metric given to add-metric.
+type Metric struct {
+	Key   string
+	Value float64
+}
+
+// AddMetricCommand implements the add-metric command.
+type AddMetricCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Metrics []Metric
+}
+
+func NewAddMetricCommand(ctx Context) cmd.Command {
+	return &AddMetricCommand{ctx: ctx}
+}
+
+func (c *AddMetricCommand) Info() *cmd.Info {
+	doc := `
+Record numeric metrics for the unit, such as "load=0.75". Only metrics
+declared in the charm's metrics.yaml may be added, and those of type
+"absolute" may not be negative. The metrics are stored once the hook
+completes successfully; they may be read with juju metrics, and used to
+scale the service. Charms that declare metrics have their collect-metrics
+hook run periodically, in which to add them.
+`
+	return &cmd.Info{
+		Name:    "add-metric",
+		Args:    "<key>=<value> [<key>=<value> ...]",
+		Purpose: "add metrics",
+		Doc:     doc,
+	}
+}
+
+func (c *AddMetricCommand) SetFlags(f *gnuflag.FlagSet) {
+}
+
+func (c *AddMetricCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no metrics specified")
+	}
+	for _, kv := range args {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return fmt.Errorf(`expected "key=value", got %q`, kv)
+		}
+		if !charm.IsValidMetricName(parts[0]) {
+			return fmt.Errorf("invalid key %q", parts[0])
+		}
+		value, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %q: not a number", parts[1], parts[0])
+		}
+		c.Metrics = append(c.Metrics, Metric{Key: parts[0], Value: value})
+	}
+	return nil
+}
+
+func (c *AddMetricCommand) Run(ctx *cmd.Context) error {
+	now := time.Now()
+	for _, m := range c.Metrics {
+		if err := c.ctx.AddMetric(m.Key, m.Value, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type AddMetricSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&AddMetricSuite{})
+
+var addMetricInitTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no metrics specified"},
+	{[]string{"load"}, `expected "key=value", got "load"`},
+	{[]string{"=0.5"}, `expected "key=value", got "=0.5"`},
+	{[]string{"Load=0.5"}, `invalid key "Load"`},
+	{[]string{"1load=0.5"}, `invalid key "1load"`},
+	{[]string{"load=high"}, `invalid value "high" for "load": not a number`},
+}
+
+func (s *AddMetricSuite) TestInit(c *gc.C) {
+	for i, t := range addMetricInitTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "add-metric")
+		c.Assert(err, gc.IsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *AddMetricSuite) TestAddMetric(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "add-metric")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"load=0.75", "requests.per-sec=120"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.metrics, gc.HasLen, 2)
+	c.Assert(hctx.metrics[0].Key, gc.Equals, "load")
+	c.Assert(hctx.metrics[0].Value, gc.Equals, 0.75)
+	c.Assert(hctx.metrics[1].Key, gc.Equals, "requests.per-sec")
+	c.Assert(hctx.metrics[1].Value, gc.Equals, 120.0)
+	c.Assert(hctx.metrics[0].Time.IsZero(), gc.Equals, false)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"launchpad.net/juju-core/charm"
 	"launchpad.net/juju-core/state/api/params"
@@ -52,6 +53,50 @@ type Context interface {
 	// RelationIds returns the ids of all relations the executing unit is
 	// currently participating in.
 	RelationIds() []int
+
+	// StorageInstances returns the storage instances belonging to the
+	// executing unit.
+	StorageInstances() ([]params.StorageInstance, error)
+
+	// ActionParams returns the parameters of the action being run, or an
+	// error if the context is not running an action.
+	ActionParams() (map[string]interface{}, error)
+
+	// UpdateActionResults sets the value found in the results of the action
+	// being run by following the supplied keys through nested maps, creating
+	// maps as required. It returns an error if the context is not running
+	// an action.
+	UpdateActionResults(keys []string, value string) error
+
+	// SetActionFailed marks the action being run as failed, with the
+	// supplied message. It returns an error if the context is not running
+	// an action.
+	SetActionFailed(message string) error
+
+	// AddMetric records a metric to be reported for the executing unit
+	// once the hook completes successfully.
+	AddMetric(key string, value float64, created time.Time) error
+
+	// SetWorkloadStatus sets the status of the executing unit's
+	// workload, as reported to the user.
+	SetWorkloadStatus(status params.WorkloadStatus, message string) error
+
+	// WorkloadStatus returns the status of the executing unit's
+	// workload, and the message set with it.
+	WorkloadStatus() (params.WorkloadStatus, string, error)
+
+	// IsLeader returns whether the executing unit is the leader of its
+	// service, renewing its lease if it is.
+	IsLeader() (bool, error)
+
+	// LeaderSettings returns the settings published by the leader of
+	// the executing unit's service.
+	LeaderSettings() (map[string]string, error)
+
+	// WriteLeaderSettings updates the settings published by the
+	// executing unit, which must be the leader of its service.
+	// Settings with an empty value are deleted.
+	WriteLeaderSettings(settings map[string]string) error
 }
 
 // ContextRelation expresses the capabilities of a hook with respect to a relation.
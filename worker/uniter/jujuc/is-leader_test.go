@@ -0,0 +1,50 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type IsLeaderSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&IsLeaderSuite{})
+
+var isLeaderTests = []struct {
+	isLeader bool
+	args     []string
+	out      string
+}{
+	{true, nil, "True\n"},
+	{false, nil, "False\n"},
+	{true, []string{"--format", "json"}, "true\n"},
+	{false, []string{"--format", "yaml"}, "false\n"},
+}
+
+func (s *IsLeaderSuite) TestIsLeader(c *gc.C) {
+	for i, t := range isLeaderTests {
+		c.Logf("test %d: %v %v", i, t.isLeader, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		hctx.leader.IsLeader = t.isLeader
+		com, err := jujuc.NewCommand(hctx, "is-leader")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *IsLeaderSuite) TestInit(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "is-leader")
+	c.Assert(err, gc.IsNil)
+	testing.TestInit(c, com, []string{"foo"}, `unrecognized args: \["foo"\]`)
+}
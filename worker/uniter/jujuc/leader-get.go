@@ -0,0 +1,61 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// LeaderGetCommand implements the leader-get command.
+type LeaderGetCommand struct {
+	cmd.CommandBase
+	ctx Context
+	Key string // The key to show. If empty, show all.
+	out cmd.Output
+}
+
+func NewLeaderGetCommand(ctx Context) cmd.Command {
+	return &LeaderGetCommand{ctx: ctx}
+}
+
+func (c *LeaderGetCommand) Info() *cmd.Info {
+	doc := `
+Print the settings published by the leader of the unit's service with
+leader-set. When no <key> is supplied, all settings are printed.
+`
+	return &cmd.Info{
+		Name:    "leader-get",
+		Args:    "[<key>]",
+		Purpose: "print service leadership settings",
+		Doc:     doc,
+	}
+}
+
+func (c *LeaderGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *LeaderGetCommand) Init(args []string) error {
+	if args == nil {
+		return nil
+	}
+	c.Key = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+func (c *LeaderGetCommand) Run(ctx *cmd.Context) error {
+	settings, err := c.ctx.LeaderSettings()
+	if err != nil {
+		return err
+	}
+	if c.Key == "" {
+		return c.out.Write(ctx, settings)
+	}
+	if value, ok := settings[c.Key]; ok {
+		return c.out.Write(ctx, value)
+	}
+	return c.out.Write(ctx, nil)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type LeaderGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&LeaderGetSuite{})
+
+var leaderGetTests = []struct {
+	args []string
+	out  string
+}{
+	{nil, "master: 10.0.0.1\nport: \"3306\"\n"},
+	{[]string{"--format", "json"}, `{"master":"10.0.0.1","port":"3306"}` + "\n"},
+	{[]string{"master"}, "10.0.0.1\n"},
+	{[]string{"--format", "json", "port"}, `"3306"` + "\n"},
+	{[]string{"missing"}, ""},
+	{[]string{"--format", "json", "missing"}, "null\n"},
+}
+
+func (s *LeaderGetSuite) TestLeaderGet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.leader.Settings = map[string]string{"master": "10.0.0.1", "port": "3306"}
+	for i, t := range leaderGetTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(hctx, "leader-get")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *LeaderGetSuite) TestInit(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "leader-get")
+	c.Assert(err, gc.IsNil)
+	testing.TestInit(c, com, []string{"foo", "bar"}, `unrecognized args: \["bar"\]`)
+}
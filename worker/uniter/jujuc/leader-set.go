@@ -0,0 +1,57 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+	"strings"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// LeaderSetCommand implements the leader-set command.
+type LeaderSetCommand struct {
+	cmd.CommandBase
+	ctx      Context
+	Settings map[string]string
+}
+
+func NewLeaderSetCommand(ctx Context) cmd.Command {
+	return &LeaderSetCommand{ctx: ctx, Settings: map[string]string{}}
+}
+
+func (c *LeaderSetCommand) Info() *cmd.Info {
+	doc := `
+Publish settings to the other units of the service, which can read them
+with leader-get and are notified of changes by the leader-settings-changed
+hook. Only the leader of the service can set them; a setting with an empty
+value is deleted.
+`
+	return &cmd.Info{
+		Name:    "leader-set",
+		Args:    "key=value [key=value ...]",
+		Purpose: "set service leadership settings",
+		Doc:     doc,
+	}
+}
+
+func (c *LeaderSetCommand) SetFlags(f *gnuflag.FlagSet) {
+}
+
+func (c *LeaderSetCommand) Init(args []string) error {
+	for _, kv := range args {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return fmt.Errorf(`expected "key=value", got %q`, kv)
+		}
+		c.Settings[parts[0]] = parts[1]
+	}
+	return nil
+}
+
+func (c *LeaderSetCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.WriteLeaderSettings(c.Settings)
+}
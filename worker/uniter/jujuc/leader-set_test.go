@@ -0,0 +1,67 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type LeaderSetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&LeaderSetSuite{})
+
+var leaderSetInitTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, ""},
+	{[]string{"master=10.0.0.1"}, ""},
+	{[]string{"master=10.0.0.1", "port="}, ""},
+	{[]string{"master"}, `expected "key=value", got "master"`},
+	{[]string{"=10.0.0.1"}, `expected "key=value", got "=10.0.0.1"`},
+}
+
+func (s *LeaderSetSuite) TestInit(c *gc.C) {
+	for i, t := range leaderSetInitTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "leader-set")
+		c.Assert(err, gc.IsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *LeaderSetSuite) TestLeaderSet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.leader = ContextLeader{
+		IsLeader: true,
+		Settings: map[string]string{"master": "10.0.0.1", "port": "3306"},
+	}
+	com, err := jujuc.NewCommand(hctx, "leader-set")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"master=10.0.0.2", "port=", "mode=cluster"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.leader.Settings, gc.DeepEquals, map[string]string{
+		"master": "10.0.0.2",
+		"mode":   "cluster",
+	})
+}
+
+func (s *LeaderSetSuite) TestLeaderSetNotLeader(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "leader-set")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"master=10.0.0.2"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: not the leader\n")
+	c.Assert(hctx.leader.Settings, gc.HasLen, 0)
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// StatusGetCommand implements the status-get command.
+type StatusGetCommand struct {
+	cmd.CommandBase
+	ctx            Context
+	IncludeMessage bool
+	out            cmd.Output
+}
+
+func NewStatusGetCommand(ctx Context) cmd.Command {
+	return &StatusGetCommand{ctx: ctx}
+}
+
+func (c *StatusGetCommand) Info() *cmd.Info {
+	doc := `
+Print the status of the unit's workload, as last set by status-set, or
+"unknown" if it has never been set. With --include-message, the message
+set along with the status is printed too.
+`
+	return &cmd.Info{
+		Name:    "status-get",
+		Purpose: "print the status of the unit's workload",
+		Doc:     doc,
+	}
+}
+
+func (c *StatusGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+	f.BoolVar(&c.IncludeMessage, "include-message", false, "print the status message too")
+}
+
+func (c *StatusGetCommand) Init(args []string) error {
+	return cmd.CheckEmpty(args)
+}
+
+func (c *StatusGetCommand) Run(ctx *cmd.Context) error {
+	status, message, err := c.ctx.WorkloadStatus()
+	if err != nil {
+		return err
+	}
+	if !c.IncludeMessage {
+		return c.out.Write(ctx, string(status))
+	}
+	return c.out.Write(ctx, map[string]string{
+		"status":  string(status),
+		"message": message,
+	})
+}
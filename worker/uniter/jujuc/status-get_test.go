@@ -0,0 +1,60 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type StatusGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&StatusGetSuite{})
+
+var statusGetTests = []struct {
+	args []string
+	out  string
+}{
+	{nil, "waiting\n"},
+	{[]string{"--format", "json"}, `"waiting"` + "\n"},
+	{[]string{"--include-message", "--format", "json"}, `{"message":"syncing database","status":"waiting"}` + "\n"},
+	{[]string{"--include-message", "--format", "yaml"}, "message: syncing database\nstatus: waiting\n"},
+}
+
+func (s *StatusGetSuite) TestStatusGet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	hctx.status = ContextStatus{params.WorkloadWaiting, "syncing database"}
+	for i, t := range statusGetTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(hctx, "status-get")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Check(code, gc.Equals, 0)
+		c.Check(bufferString(ctx.Stderr), gc.Equals, "")
+		c.Check(bufferString(ctx.Stdout), gc.Equals, t.out)
+	}
+}
+
+func (s *StatusGetSuite) TestStatusGetUnset(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "status-get")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, nil)
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stdout), gc.Equals, "unknown\n")
+}
+
+func (s *StatusGetSuite) TestInit(c *gc.C) {
+	com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "status-get")
+	c.Assert(err, gc.IsNil)
+	testing.TestInit(c, com, []string{"foo"}, `unrecognized args: \["foo"\]`)
+}
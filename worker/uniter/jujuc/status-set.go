@@ -0,0 +1,71 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
+)
+
+// StatusSetCommand implements the status-set command.
+type StatusSetCommand struct {
+	cmd.CommandBase
+	ctx     Context
+	Status  params.WorkloadStatus
+	Message string
+}
+
+func NewStatusSetCommand(ctx Context) cmd.Command {
+	return &StatusSetCommand{ctx: ctx}
+}
+
+func (c *StatusSetCommand) Info() *cmd.Info {
+	doc := `
+Set the status of the unit's workload, as shown by juju status alongside
+the state of the unit agent. The status is one of:
+
+    maintenance: the unit is not yet providing its service, but is
+                 actively working towards it.
+    blocked:     the unit cannot continue without human intervention.
+    waiting:     the unit is waiting for something outside its control,
+                 such as a related unit.
+    active:      the unit is providing its service.
+
+The optional message explains the status to the user.
+`
+	return &cmd.Info{
+		Name:    "status-set",
+		Args:    `<maintenance|blocked|waiting|active> ["<message>"]`,
+		Purpose: "set the status of the unit's workload",
+		Doc:     doc,
+	}
+}
+
+func (c *StatusSetCommand) SetFlags(f *gnuflag.FlagSet) {
+}
+
+func (c *StatusSetCommand) Init(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no status specified")
+	}
+	status := params.WorkloadStatus(args[0])
+	if !status.Valid() {
+		return fmt.Errorf("invalid status %q, expected one of maintenance, blocked, waiting or active", args[0])
+	}
+	c.Status = status
+	args = args[1:]
+	if len(args) > 0 {
+		c.Message = args[0]
+		args = args[1:]
+	}
+	return cmd.CheckEmpty(args)
+}
+
+func (c *StatusSetCommand) Run(ctx *cmd.Context) error {
+	return c.ctx.SetWorkloadStatus(c.Status, c.Message)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/state/api/params"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type StatusSetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&StatusSetSuite{})
+
+var statusSetInitTests = []struct {
+	args []string
+	err  string
+}{
+	{nil, "no status specified"},
+	{[]string{"busy"}, `invalid status "busy", expected one of maintenance, blocked, waiting or active`},
+	{[]string{"unknown"}, `invalid status "unknown", expected one of maintenance, blocked, waiting or active`},
+	{[]string{"active", "ready", "now"}, `unrecognized args: \["now"\]`},
+	{[]string{"active"}, ""},
+	{[]string{"waiting", "syncing database"}, ""},
+}
+
+func (s *StatusSetSuite) TestInit(c *gc.C) {
+	for i, t := range statusSetInitTests {
+		c.Logf("test %d: %v", i, t.args)
+		com, err := jujuc.NewCommand(s.GetHookContext(c, -1, ""), "status-set")
+		c.Assert(err, gc.IsNil)
+		testing.TestInit(c, com, t.args, t.err)
+	}
+}
+
+func (s *StatusSetSuite) TestStatusSet(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "status-set")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"waiting", "syncing database"})
+	c.Assert(code, gc.Equals, 0)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+	c.Assert(hctx.status, gc.Equals, ContextStatus{params.WorkloadWaiting, "syncing database"})
+}
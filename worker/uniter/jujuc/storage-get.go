@@ -0,0 +1,83 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc
+
+import (
+	"fmt"
+
+	"launchpad.net/gnuflag"
+
+	"launchpad.net/juju-core/cmd"
+)
+
+// StorageGetCommand implements the storage-get command.
+type StorageGetCommand struct {
+	cmd.CommandBase
+	ctx         Context
+	StorageName string // The store to show. If empty, show all.
+	out         cmd.Output
+}
+
+func NewStorageGetCommand(ctx Context) cmd.Command {
+	return &StorageGetCommand{ctx: ctx}
+}
+
+func (c *StorageGetCommand) Info() *cmd.Info {
+	doc := `
+Prints the storage instances belonging to the unit, keyed on storage
+instance id. When <store> is supplied, only the instances of that store
+(as named in the charm's metadata) are printed. The location of an instance
+is its mount point for filesystem storage, or its device path for block
+storage; it is omitted until the instance has been provisioned.
+`
+	return &cmd.Info{
+		Name:    "storage-get",
+		Args:    "[<store>]",
+		Purpose: "print unit storage instances",
+		Doc:     doc,
+	}
+}
+
+func (c *StorageGetCommand) SetFlags(f *gnuflag.FlagSet) {
+	c.out.AddFlags(f, "smart", cmd.DefaultFormatters)
+}
+
+func (c *StorageGetCommand) Init(args []string) error {
+	if args == nil {
+		return nil
+	}
+	c.StorageName = args[0]
+	return cmd.CheckEmpty(args[1:])
+}
+
+// storageInstance is the output format of a single storage instance.
+type storageInstance struct {
+	Name     string `yaml:"name" json:"name"`
+	Kind     string `yaml:"kind" json:"kind"`
+	Size     uint64 `yaml:"size" json:"size"`
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+}
+
+func (c *StorageGetCommand) Run(ctx *cmd.Context) error {
+	instances, err := c.ctx.StorageInstances()
+	if err != nil {
+		return err
+	}
+	result := make(map[string]storageInstance)
+	for _, inst := range instances {
+		if c.StorageName != "" && inst.StorageName != c.StorageName {
+			continue
+		}
+		result[inst.Id] = storageInstance{
+			Name:     inst.StorageName,
+			Kind:     inst.Kind,
+			Size:     inst.Size,
+			Location: inst.Location,
+		}
+	}
+	if c.StorageName != "" && len(result) == 0 {
+		return fmt.Errorf("no storage instances for store %q", c.StorageName)
+	}
+	return c.out.Write(ctx, result)
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package jujuc_test
+
+import (
+	"encoding/json"
+
+	gc "launchpad.net/gocheck"
+	"launchpad.net/goyaml"
+
+	"launchpad.net/juju-core/cmd"
+	"launchpad.net/juju-core/testing"
+	"launchpad.net/juju-core/worker/uniter/jujuc"
+)
+
+type StorageGetSuite struct {
+	ContextSuite
+}
+
+var _ = gc.Suite(&StorageGetSuite{})
+
+var (
+	storageGetData0 = map[string]interface{}{
+		"name":     "data",
+		"kind":     "filesystem",
+		"size":     1024,
+		"location": "/srv/data",
+	}
+	storageGetData1 = map[string]interface{}{
+		"name": "data",
+		"kind": "filesystem",
+		"size": 1024,
+	}
+	storageGetRaw2 = map[string]interface{}{
+		"name":     "raw",
+		"kind":     "block",
+		"size":     2048,
+		"location": "/dev/loop0",
+	}
+)
+
+var storageGetTests = []struct {
+	args   []string
+	format int
+	out    map[string]map[string]interface{}
+}{{
+	nil, formatYaml, map[string]map[string]interface{}{
+		"data/0": storageGetData0,
+		"data/1": storageGetData1,
+		"raw/2":  storageGetRaw2,
+	},
+}, {
+	[]string{"--format", "yaml", "data"}, formatYaml, map[string]map[string]interface{}{
+		"data/0": storageGetData0,
+		"data/1": storageGetData1,
+	},
+}, {
+	[]string{"--format", "json", "raw"}, formatJson, map[string]map[string]interface{}{
+		"raw/2": {
+			"name":     "raw",
+			"kind":     "block",
+			"size":     2048.0,
+			"location": "/dev/loop0",
+		},
+	},
+}}
+
+func (s *StorageGetSuite) TestOutputFormat(c *gc.C) {
+	for i, t := range storageGetTests {
+		c.Logf("test %d: %#v", i, t.args)
+		hctx := s.GetHookContext(c, -1, "")
+		com, err := jujuc.NewCommand(hctx, "storage-get")
+		c.Assert(err, gc.IsNil)
+		ctx := testing.Context(c)
+		code := cmd.Main(com, ctx, t.args)
+		c.Assert(code, gc.Equals, 0)
+		c.Assert(bufferString(ctx.Stderr), gc.Equals, "")
+
+		out := map[string]map[string]interface{}{}
+		switch t.format {
+		case formatYaml:
+			c.Assert(goyaml.Unmarshal(bufferBytes(ctx.Stdout), &out), gc.IsNil)
+		case formatJson:
+			c.Assert(json.Unmarshal(bufferBytes(ctx.Stdout), &out), gc.IsNil)
+		}
+		c.Assert(out, gc.DeepEquals, t.out)
+	}
+}
+
+func (s *StorageGetSuite) TestUnknownStore(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "storage-get")
+	c.Assert(err, gc.IsNil)
+	ctx := testing.Context(c)
+	code := cmd.Main(com, ctx, []string{"logs"})
+	c.Assert(code, gc.Equals, 1)
+	c.Assert(bufferString(ctx.Stderr), gc.Equals, "error: no storage instances for store \"logs\"\n")
+}
+
+func (s *StorageGetSuite) TestUnknownArg(c *gc.C) {
+	hctx := s.GetHookContext(c, -1, "")
+	com, err := jujuc.NewCommand(hctx, "storage-get")
+	c.Assert(err, gc.IsNil)
+	testing.TestInit(c, com, []string{"data", "raw"}, `unrecognized args: \["raw"\]`)
+}
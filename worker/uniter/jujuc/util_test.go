@@ -9,6 +9,7 @@ import (
 	"io"
 	"sort"
 	"testing"
+	"time"
 
 	gc "launchpad.net/gocheck"
 
@@ -76,10 +77,40 @@ func setSettings(c *gc.C, ru *state.RelationUnit, settings map[string]interface{
 }
 
 type Context struct {
-	ports  set.Strings
-	relid  int
-	remote string
-	rels   map[int]*ContextRelation
+	ports   set.Strings
+	relid   int
+	remote  string
+	rels    map[int]*ContextRelation
+	action  *ContextAction
+	metrics []ContextMetric
+	status  ContextStatus
+	leader  ContextLeader
+}
+
+// ContextLeader holds the leadership state of a test Context.
+type ContextLeader struct {
+	IsLeader bool
+	Settings map[string]string
+}
+
+// ContextStatus holds the workload status set in a test Context.
+type ContextStatus struct {
+	Status  params.WorkloadStatus
+	Message string
+}
+
+// ContextMetric holds a metric added to a test Context.
+type ContextMetric struct {
+	Key   string
+	Value float64
+	Time  time.Time
+}
+
+// ContextAction holds the state of an action run in a test Context.
+type ContextAction struct {
+	params  map[string]interface{}
+	results map[string]interface{}
+	failed  string
 }
 
 func (c *Context) UnitName() string {
@@ -135,6 +166,108 @@ func (c *Context) RelationIds() []int {
 	return ids
 }
 
+func (c *Context) StorageInstances() ([]params.StorageInstance, error) {
+	return []params.StorageInstance{{
+		Id:          "data/0",
+		StorageName: "data",
+		Kind:        "filesystem",
+		Size:        1024,
+		Location:    "/srv/data",
+	}, {
+		Id:          "data/1",
+		StorageName: "data",
+		Kind:        "filesystem",
+		Size:        1024,
+	}, {
+		Id:          "raw/2",
+		StorageName: "raw",
+		Kind:        "block",
+		Size:        2048,
+		Location:    "/dev/loop0",
+	}}, nil
+}
+
+func (c *Context) ActionParams() (map[string]interface{}, error) {
+	if c.action == nil {
+		return nil, fmt.Errorf("not running an action")
+	}
+	return c.action.params, nil
+}
+
+func (c *Context) UpdateActionResults(keys []string, value string) error {
+	if c.action == nil {
+		return fmt.Errorf("not running an action")
+	}
+	if c.action.results == nil {
+		c.action.results = map[string]interface{}{}
+	}
+	m := c.action.results
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+	return nil
+}
+
+func (c *Context) SetActionFailed(message string) error {
+	if c.action == nil {
+		return fmt.Errorf("not running an action")
+	}
+	c.action.failed = message
+	return nil
+}
+
+func (c *Context) AddMetric(key string, value float64, created time.Time) error {
+	c.metrics = append(c.metrics, ContextMetric{key, value, created})
+	return nil
+}
+
+func (c *Context) SetWorkloadStatus(status params.WorkloadStatus, message string) error {
+	c.status = ContextStatus{status, message}
+	return nil
+}
+
+func (c *Context) WorkloadStatus() (params.WorkloadStatus, string, error) {
+	if c.status.Status == "" {
+		return params.WorkloadUnknown, "", nil
+	}
+	return c.status.Status, c.status.Message, nil
+}
+
+func (c *Context) IsLeader() (bool, error) {
+	return c.leader.IsLeader, nil
+}
+
+func (c *Context) LeaderSettings() (map[string]string, error) {
+	settings := map[string]string{}
+	for k, v := range c.leader.Settings {
+		settings[k] = v
+	}
+	return settings, nil
+}
+
+func (c *Context) WriteLeaderSettings(settings map[string]string) error {
+	if !c.leader.IsLeader {
+		return fmt.Errorf("not the leader")
+	}
+	if c.leader.Settings == nil {
+		c.leader.Settings = map[string]string{}
+	}
+	for k, v := range settings {
+		if v == "" {
+			delete(c.leader.Settings, k)
+		} else {
+			c.leader.Settings[k] = v
+		}
+	}
+	return nil
+}
+
 type ContextRelation struct {
 	id    int
 	name  string
@@ -0,0 +1,91 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"sync"
+	"time"
+
+	"launchpad.net/tomb"
+
+	"launchpad.net/juju-core/state/api/uniter"
+)
+
+// leadershipTracker keeps track of whether the unit leads its service.
+// Once the unit has been elected, its lease is renewed in a goroutine
+// of its own, so that it does not lapse while hooks run or while the
+// uniter is in any mode other than ModeAbide.
+type leadershipTracker struct {
+	tomb     tomb.Tomb
+	unit     *uniter.Unit
+	mu       sync.Mutex
+	isLeader bool
+}
+
+func newLeadershipTracker(unit *uniter.Unit) *leadershipTracker {
+	t := &leadershipTracker{unit: unit}
+	go func() {
+		defer t.tomb.Done()
+		t.tomb.Kill(t.loop())
+	}()
+	return t
+}
+
+func (t *leadershipTracker) Stop() error {
+	t.tomb.Kill(nil)
+	return t.tomb.Wait()
+}
+
+func (t *leadershipTracker) Wait() error {
+	return t.tomb.Wait()
+}
+
+// IsLeader returns whether the unit held the leadership of its service
+// when it was last claimed or renewed.
+func (t *leadershipTracker) IsLeader() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isLeader
+}
+
+// Claim claims the leadership of the unit's service, unless the unit
+// already holds it, and returns whether the unit has just become the
+// leader. It is only called by the uniter, which must run the
+// leader-elected hook when it returns true.
+func (t *leadershipTracker) Claim() (elected bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isLeader {
+		return false, nil
+	}
+	t.isLeader, err = t.unit.ClaimLeadership()
+	return t.isLeader, err
+}
+
+// renew extends the unit's lease if it holds the leadership.
+func (t *leadershipTracker) renew() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.isLeader {
+		return nil
+	}
+	t.isLeader, err = t.unit.RenewLeadership()
+	if err == nil && !t.isLeader {
+		logger.Infof("unit %q is no longer the leader", t.unit)
+	}
+	return err
+}
+
+func (t *leadershipTracker) loop() error {
+	for {
+		select {
+		case <-t.tomb.Dying():
+			return tomb.ErrDying
+		case <-time.After(leaseRenewInterval):
+			if err := t.renew(); err != nil {
+				return err
+			}
+		}
+	}
+}
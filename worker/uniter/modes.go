@@ -6,6 +6,7 @@ package uniter
 import (
 	stderrors "errors"
 	"fmt"
+	"time"
 
 	"launchpad.net/tomb"
 
@@ -111,6 +112,15 @@ func ModeContinue(u *Uniter) (next Mode, err error) {
 			}
 			return ModeContinue, nil
 		}
+		if u.s.Hook.Kind.IsAction() {
+			// An action that was interrupted cannot be retried safely, so
+			// report it as failed rather than awaiting resolution.
+			logger.Infof("found interrupted action %q", u.s.Hook.ActionId)
+			if err = u.failInterruptedAction(*u.s.Hook); err != nil {
+				return nil, err
+			}
+			return ModeContinue, nil
+		}
 		logger.Infof("awaiting error resolution for %q hook", u.s.Hook.Kind)
 		return ModeHookError, nil
 	}
@@ -264,8 +274,26 @@ func ModeAbide(u *Uniter) (next Mode, err error) {
 // modeAbideAliveLoop handles all state changes for ModeAbide when the unit
 // is in an Alive state.
 func modeAbideAliveLoop(u *Uniter) (Mode, error) {
+	meta, err := u.charmMeta()
+	if err != nil {
+		return nil, err
+	}
+	metrics := meta.Metrics
+	if u.lastCollectMetrics.IsZero() {
+		u.lastCollectMetrics = time.Now()
+	}
 	for {
 		hi := hook.Info{}
+		// Charms that declare no metrics are never asked to collect them.
+		var collectMetrics <-chan time.Time
+		if len(metrics) > 0 {
+			next := u.lastCollectMetrics.Add(collectMetricsInterval)
+			collectMetrics = time.After(next.Sub(time.Now()))
+		}
+		// Claiming does nothing while the unit leads its service;
+		// its lease is then renewed by u.leadership.
+		nextClaim := u.lastLeadershipClaim.Add(leaseRenewInterval)
+		claimLeadership := time.After(nextClaim.Sub(time.Now()))
 		select {
 		case <-u.tomb.Dying():
 			return nil, tomb.ErrDying
@@ -285,6 +313,29 @@ func modeAbideAliveLoop(u *Uniter) (Mode, error) {
 			continue
 		case curl := <-u.f.UpgradeEvents():
 			return ModeUpgrading(curl), nil
+		case id := <-u.f.ActionEvents():
+			hi = hook.Info{Kind: hooks.ActionRequested, ActionId: id}
+		case <-collectMetrics:
+			u.lastCollectMetrics = time.Now()
+			if err := u.collectMetrics(); err != nil {
+				return nil, err
+			}
+			continue
+		case <-claimLeadership:
+			elected, err := u.claimLeadership()
+			if err != nil {
+				return nil, err
+			}
+			if !elected {
+				continue
+			}
+			hi = hook.Info{Kind: hooks.LeaderElected}
+		case <-u.f.LeaderSettingsEvents():
+			// The leader wrote the settings itself.
+			if u.leadership.IsLeader() {
+				continue
+			}
+			hi = hook.Info{Kind: hooks.LeaderSettingsChanged}
 		}
 		if err := u.runHook(hi); err == errHookFailed {
 			return ModeHookError, nil
@@ -339,6 +390,9 @@ func ModeHookError(u *Uniter) (next Mode, err error) {
 		return nil, fmt.Errorf("insane uniter state: %#v", u.s)
 	}
 	msg := fmt.Sprintf("hook failed: %q", u.currentHookName())
+	if u.s.HookKilled != "" {
+		msg = u.s.HookKilled
+	}
 	// Create error information for status.
 	data := params.StatusData{"hook": u.currentHookName()}
 	if u.s.Hook.Kind.IsRelation() {
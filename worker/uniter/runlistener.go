@@ -0,0 +1,145 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter
+
+import (
+	"net"
+	"net/rpc"
+	"os"
+	"sync"
+
+	utilexec "launchpad.net/juju-core/utils/exec"
+)
+
+// RunSocketName is the name of the socket, within a unit agent's
+// directory, on which the uniter accepts requests to run commands.
+const RunSocketName = "run.socket"
+
+// JujuRunEndpoint is the name of the RPC method called to run commands
+// in a unit's hook context.
+const JujuRunEndpoint = "JujuRunServer.RunCommands"
+
+// RunCommandsArgs holds the commands to run in a unit's hook context.
+type RunCommandsArgs struct {
+	// Commands holds the commands, which are run by bash.
+	Commands string
+	// RelationId holds the id of the relation in whose context the
+	// commands run, or -1 if they run outside any relation context.
+	RelationId int
+}
+
+// CommandRunner runs commands in a unit's hook context.
+type CommandRunner interface {
+	RunCommands(args RunCommandsArgs) (*utilexec.ExecResponse, error)
+}
+
+// JujuRunServer implements the RPC server through which commands are run.
+type JujuRunServer struct {
+	runner CommandRunner
+}
+
+// RunCommands runs the commands in args and fills in result.
+func (r *JujuRunServer) RunCommands(args RunCommandsArgs, result *utilexec.ExecResponse) error {
+	response, err := r.runner.RunCommands(args)
+	if err != nil {
+		return err
+	}
+	*result = *response
+	return nil
+}
+
+// RunListener serves requests to run commands on a socket.
+type RunListener struct {
+	listener net.Listener
+	server   *rpc.Server
+	closed   chan bool
+	closing  chan bool
+	wg       sync.WaitGroup
+}
+
+// NewRunListener returns a listener on the given network and address
+// which serves requests to run commands by passing them to runner. It
+// will not accept requests until Run is called.
+func NewRunListener(runner CommandRunner, netType, localAddr string) (*RunListener, error) {
+	server := rpc.NewServer()
+	if err := server.Register(&JujuRunServer{runner}); err != nil {
+		return nil, err
+	}
+	if netType == "unix" {
+		if err := removeStaleSocket(localAddr); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen(netType, localAddr)
+	if err != nil {
+		logger.Errorf("failed to listen on %s %s: %v", netType, localAddr, err)
+		return nil, err
+	}
+	if netType == "unix" {
+		// Anyone who can connect can run commands as the unit agent's
+		// user, so only that user may use the socket.
+		if err := os.Chmod(localAddr, 0700); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return &RunListener{
+		listener: listener,
+		server:   server,
+		closed:   make(chan bool),
+		closing:  make(chan bool),
+	}, nil
+}
+
+// removeStaleSocket removes the socket file at path if it was left
+// behind by a listener that has gone away. A socket that is still being
+// listened on is left alone.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Run accepts new connections until it encounters an error, or until Close
+// is called, and then blocks until all existing connections have been closed.
+func (s *RunListener) Run() (err error) {
+	var conn net.Conn
+	for {
+		conn, err = s.listener.Accept()
+		if err != nil {
+			break
+		}
+		s.wg.Add(1)
+		go func(conn net.Conn) {
+			s.server.ServeConn(conn)
+			s.wg.Done()
+		}(conn)
+	}
+	select {
+	case <-s.closing:
+		// The error from Accept is a direct result of the listener
+		// being closed, and can therefore be safely ignored.
+		err = nil
+	default:
+	}
+	s.wg.Wait()
+	close(s.closed)
+	return
+}
+
+// Close immediately stops accepting connections, and blocks until all
+// existing connections have been closed.
+func (s *RunListener) Close() {
+	close(s.closing)
+	s.listener.Close()
+	<-s.closed
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 Canonical Ltd.
+// Licensed under the AGPLv3, see LICENCE file for details.
+
+package uniter_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/rpc"
+	"os"
+	"path/filepath"
+
+	gc "launchpad.net/gocheck"
+
+	"launchpad.net/juju-core/testing/testbase"
+	utilexec "launchpad.net/juju-core/utils/exec"
+	"launchpad.net/juju-core/worker/uniter"
+)
+
+type ListenerSuite struct {
+	testbase.LoggingSuite
+	socketPath string
+}
+
+var _ = gc.Suite(&ListenerSuite{})
+
+// Mirror the params to uniter.NewRunListener, but add cleanup to close it.
+func (s *ListenerSuite) NewRunListener(c *gc.C, runner uniter.CommandRunner) *uniter.RunListener {
+	listener, err := uniter.NewRunListener(runner, "unix", s.socketPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(listener, gc.NotNil)
+	go listener.Run()
+	s.AddCleanup(func(*gc.C) {
+		listener.Close()
+	})
+	return listener
+}
+
+func (s *ListenerSuite) SetUpTest(c *gc.C) {
+	s.LoggingSuite.SetUpTest(c)
+	s.socketPath = filepath.Join(c.MkDir(), "test.listener")
+}
+
+func (s *ListenerSuite) TestNewRunListenerOnExistingSocketFails(c *gc.C) {
+	// Create a socket and leave it around.
+	s.NewRunListener(c, &mockRunner{c: c})
+	_, err := uniter.NewRunListener(&mockRunner{c: c}, "unix", s.socketPath)
+	c.Assert(err, gc.ErrorMatches, ".* address already in use")
+}
+
+func (s *ListenerSuite) TestNewRunListenerRemovesStaleSocket(c *gc.C) {
+	err := ioutil.WriteFile(s.socketPath, nil, 0666)
+	c.Assert(err, gc.IsNil)
+	s.NewRunListener(c, &mockRunner{c: c})
+	client, err := rpc.Dial("unix", s.socketPath)
+	c.Assert(err, gc.IsNil)
+	client.Close()
+}
+
+func (s *ListenerSuite) TestSocketOnlyAccessibleByOwner(c *gc.C) {
+	s.NewRunListener(c, &mockRunner{c: c})
+	info, err := os.Stat(s.socketPath)
+	c.Assert(err, gc.IsNil)
+	c.Assert(info.Mode()&os.ModePerm, gc.Equals, os.FileMode(0700))
+}
+
+func (s *ListenerSuite) TestClientCall(c *gc.C) {
+	s.NewRunListener(c, &mockRunner{c: c})
+
+	client, err := rpc.Dial("unix", s.socketPath)
+	c.Assert(err, gc.IsNil)
+	defer client.Close()
+
+	var result utilexec.ExecResponse
+	args := uniter.RunCommandsArgs{Commands: "some-command", RelationId: 2}
+	err = client.Call(uniter.JujuRunEndpoint, args, &result)
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(string(result.Stdout), gc.Equals, "some-command stdout")
+	c.Assert(string(result.Stderr), gc.Equals, "some-command stderr")
+	c.Assert(result.Code, gc.Equals, 2)
+}
+
+func (s *ListenerSuite) TestClientCallError(c *gc.C) {
+	s.NewRunListener(c, &mockRunner{c: c})
+
+	client, err := rpc.Dial("unix", s.socketPath)
+	c.Assert(err, gc.IsNil)
+	defer client.Close()
+
+	var result utilexec.ExecResponse
+	args := uniter.RunCommandsArgs{Commands: "fail", RelationId: -1}
+	err = client.Call(uniter.JujuRunEndpoint, args, &result)
+	c.Assert(err, gc.ErrorMatches, "cannot run fail")
+}
+
+type mockRunner struct {
+	c *gc.C
+}
+
+var _ uniter.CommandRunner = (*mockRunner)(nil)
+
+func (r *mockRunner) RunCommands(args uniter.RunCommandsArgs) (*utilexec.ExecResponse, error) {
+	if args.Commands == "fail" {
+		return nil, fmt.Errorf("cannot run %s", args.Commands)
+	}
+	r.c.Log("mock runner: " + args.Commands)
+	return &utilexec.ExecResponse{
+		Code:   args.RelationId,
+		Stdout: []byte(args.Commands + " stdout"),
+		Stderr: []byte(args.Commands + " stderr"),
+	}, nil
+}
@@ -70,6 +70,11 @@ type State struct {
 	// Charm describes the charm being deployed by an Install or Upgrade
 	// operation, and is otherwise blank.
 	CharmURL *charm.URL `yaml:"charm,omitempty"`
+
+	// HookKilled holds why the hook of a pending RunHook operation was
+	// killed, if it was, so that the reason can be reported until the
+	// resulting error is resolved.
+	HookKilled string `yaml:"hook-killed,omitempty"`
 }
 
 // validate returns an error if the state violates expectations.
@@ -101,6 +106,9 @@ func (st State) validate() (err error) {
 	default:
 		return fmt.Errorf("unknown operation step %q", st.OpStep)
 	}
+	if st.HookKilled != "" && (st.Op != RunHook || st.OpStep != Pending) {
+		return fmt.Errorf("unexpected hook kill reason")
+	}
 	if hasHook {
 		return st.Hook.Validate()
 	}
@@ -134,14 +142,17 @@ func (f *StateFile) Read() (*State, error) {
 	return &st, nil
 }
 
-// Write stores the supplied state to the file.
-func (f *StateFile) Write(started bool, op Op, step OpStep, hi *uhook.Info, url *charm.URL) error {
+// Write stores the supplied state to the file. The hookKilled reason
+// must be empty unless the hook of a pending RunHook operation was
+// killed.
+func (f *StateFile) Write(started bool, op Op, step OpStep, hi *uhook.Info, url *charm.URL, hookKilled string) error {
 	st := &State{
-		Started:  started,
-		Op:       op,
-		OpStep:   step,
-		Hook:     hi,
-		CharmURL: url,
+		Started:    started,
+		Op:         op,
+		OpStep:     step,
+		Hook:       hi,
+		CharmURL:   url,
+		HookKilled: hookKilled,
 	}
 	if err := st.validate(); err != nil {
 		panic(err)
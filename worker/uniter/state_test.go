@@ -97,6 +97,21 @@ var stateTests = []struct {
 			OpStep: uniter.Pending,
 			Hook:   relhook,
 		},
+	}, {
+		st: uniter.State{
+			Op:         uniter.RunHook,
+			OpStep:     uniter.Pending,
+			Hook:       &hook.Info{Kind: hooks.ConfigChanged},
+			HookKilled: "hook timed out after 1s",
+		},
+	}, {
+		st: uniter.State{
+			Op:         uniter.RunHook,
+			OpStep:     uniter.Done,
+			Hook:       &hook.Info{Kind: hooks.ConfigChanged},
+			HookKilled: "hook timed out after 1s",
+		},
+		err: `unexpected hook kill reason`,
 	},
 	// Upgrade operation.
 	{
@@ -151,7 +166,7 @@ func (s *StateFileSuite) TestStates(c *gc.C) {
 		_, err := file.Read()
 		c.Assert(err, gc.Equals, uniter.ErrNoStateFile)
 		write := func() {
-			err := file.Write(t.st.Started, t.st.Op, t.st.OpStep, t.st.Hook, t.st.CharmURL)
+			err := file.Write(t.st.Started, t.st.Op, t.st.OpStep, t.st.Hook, t.st.CharmURL, t.st.HookKilled)
 			c.Assert(err, gc.IsNil)
 		}
 		if t.err != "" {
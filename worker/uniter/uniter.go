@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"launchpad.net/loggo"
@@ -23,6 +24,7 @@ import (
 	"launchpad.net/juju-core/state/api/uniter"
 	"launchpad.net/juju-core/state/watcher"
 	"launchpad.net/juju-core/utils"
+	utilexec "launchpad.net/juju-core/utils/exec"
 	"launchpad.net/juju-core/utils/fslock"
 	"launchpad.net/juju-core/worker/uniter/charm"
 	"launchpad.net/juju-core/worker/uniter/hook"
@@ -32,6 +34,15 @@ import (
 
 var logger = loggo.GetLogger("juju.worker.uniter")
 
+// collectMetricsInterval holds how often the collect-metrics hook is
+// run, for charms that declare metrics.
+var collectMetricsInterval = 5 * time.Minute
+
+// leaseRenewInterval holds how often the leader renews the leadership
+// of its service, and how often other units try to claim it. It must
+// be shorter than the lease duration decided by the API server.
+var leaseRenewInterval = 30 * time.Second
+
 // Uniter implements the capabilities of the unit agent. It is not intended to
 // implement the actual *behaviour* of the unit agent; that responsibility is
 // delegated to Mode values, which are expected to react to events and direct
@@ -44,6 +55,10 @@ type Uniter struct {
 	service       *uniter.Service
 	relationers   map[int]*Relationer
 	relationHooks chan hook.Info
+	// relationersMu guards changes to relationers, which are made only
+	// by the uniter's own goroutine, against concurrent reads by
+	// RunCommands.
+	relationersMu sync.Mutex
 	uuid          string
 
 	dataDir      string
@@ -59,6 +74,16 @@ type Uniter struct {
 	hookLock     *fslock.Lock
 
 	ranConfigChanged bool
+
+	// lastCollectMetrics holds when the collect-metrics hook was last
+	// run.
+	lastCollectMetrics time.Time
+
+	// leadership renews the unit's lease while it leads its service;
+	// lastLeadershipClaim holds when the uniter last tried to become
+	// the leader.
+	leadership          *leadershipTracker
+	lastLeadershipClaim time.Time
 }
 
 // NewUniter creates a new Uniter which will install, run, and upgrade
@@ -92,6 +117,22 @@ func (u *Uniter) loop(unitTag string) (err error) {
 		u.tomb.Kill(u.f.Wait())
 	}()
 
+	// Keep the unit's leadership, once claimed, for as long as the
+	// uniter runs, whatever mode it is in.
+	u.leadership = newLeadershipTracker(u.unit)
+	defer watcher.Stop(u.leadership, &u.tomb)
+	go func() {
+		u.tomb.Kill(u.leadership.Wait())
+	}()
+
+	// Serve requests to run commands in the unit's hook context.
+	runListener, err := NewRunListener(u, "unix", u.runSocketPath())
+	if err != nil {
+		return err
+	}
+	go runListener.Run()
+	defer runListener.Close()
+
 	// Run modes until we encounter an error.
 	mode := ModeInit
 	for err == nil {
@@ -144,6 +185,11 @@ func (u *Uniter) init(unitTag string) (err error) {
 	if err := os.MkdirAll(u.relationsDir, 0755); err != nil {
 		return err
 	}
+	// The unit's directory holds the socket through which commands are
+	// run in its hook context, so it must not be reachable by other users.
+	if err := os.Chmod(u.baseDir, 0700); err != nil {
+		return err
+	}
 	u.service, err = u.st.Service(u.unit.ServiceTag())
 	if err != nil {
 		return err
@@ -187,14 +233,27 @@ func (u *Uniter) Dead() <-chan struct{} {
 // writeState saves uniter state with the supplied values, and infers the appropriate
 // value of Started.
 func (u *Uniter) writeState(op Op, step OpStep, hi *hook.Info, url *corecharm.URL) error {
-	s := State{
+	return u.saveState(State{
 		Started:  op == RunHook && hi.Kind == hooks.Start || u.s != nil && u.s.Started,
 		Op:       op,
 		OpStep:   step,
 		Hook:     hi,
 		CharmURL: url,
-	}
-	if err := u.sf.Write(s.Started, s.Op, s.OpStep, s.Hook, s.CharmURL); err != nil {
+	})
+}
+
+// writeHookKilled records in the current RunHook operation why its
+// hook was killed, so that the reason is still reported if the uniter
+// restarts before the resulting error is resolved.
+func (u *Uniter) writeHookKilled(reason error) error {
+	s := *u.s
+	s.HookKilled = reason.Error()
+	return u.saveState(s)
+}
+
+// saveState writes s to the state file, and makes it the current state.
+func (u *Uniter) saveState(s State) error {
+	if err := u.sf.Write(s.Started, s.Op, s.OpStep, s.Hook, s.CharmURL, s.HookKilled); err != nil {
 		return err
 	}
 	u.s = &s
@@ -275,7 +334,9 @@ func (u *Uniter) deploy(curl *corecharm.URL, reason Op) error {
 var errHookFailed = stderrors.New("hook execution failed")
 
 // runHook executes the supplied hook.Info in an appropriate hook context. If
-// the hook itself fails to execute, it returns errHookFailed.
+// the hook itself fails to execute, it returns errHookFailed. An action that
+// fails to execute is instead recorded as failed, and does not prevent the
+// uniter from continuing.
 func (u *Uniter) runHook(hi hook.Info) (err error) {
 	// Prepare context.
 	if err = hi.Validate(); err != nil {
@@ -284,13 +345,119 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 
 	hookName := string(hi.Kind)
 	relationId := -1
+	var action *uniter.Action
 	if hi.Kind.IsRelation() {
 		relationId = hi.RelationId
 		if hookName, err = u.relationers[relationId].PrepareHook(hi); err != nil {
 			return err
 		}
+	} else if hi.Kind.IsAction() {
+		if action, err = u.st.Action(hi.ActionId); err != nil {
+			return err
+		}
+		hookName = action.Name()
 	}
-	hctxId := fmt.Sprintf("%s:%s:%d", u.unit.Name(), hookName, u.rand.Int63())
+	lockMessage := fmt.Sprintf("%s: running hook %q", u.unit.Name(), hookName)
+	if err = u.acquireHookLock(lockMessage); err != nil {
+		return err
+	}
+	defer u.hookLock.Unlock()
+
+	hctx, err := u.getHookContext(hookName, relationId, hi.RemoteUnit)
+	if err != nil {
+		return err
+	}
+	if hctx.hookTimeout, err = u.hookTimeout(); err != nil {
+		return err
+	}
+	srv, socketPath, err := u.startJujucServer(hctx)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+
+	// Kill the hook if the user asks for it to be cancelled while it
+	// is running; earlier requests do not apply to it.
+	u.f.DiscardCancelEvent()
+	cancel := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-u.f.CancelEvents():
+			close(cancel)
+		case <-finished:
+		}
+	}()
+	hctx.cancel = cancel
+
+	// Run the hook.
+	if err := u.writeState(RunHook, Pending, &hi, nil); err != nil {
+		return err
+	}
+	if action != nil {
+		if err := action.Begin(); err != nil {
+			return err
+		}
+		hctx.actionData = newActionData(action)
+		logger.Infof("running %q action", hookName)
+		runErr := hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath)
+		return u.finishAction(hi, hctx.actionData, runErr)
+	}
+	logger.Infof("running %q hook", hookName)
+	if err := hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath); err != nil {
+		logger.Errorf("hook failed: %s", err)
+		if isHookKilled(err) {
+			if err := u.writeHookKilled(err); err != nil {
+				return err
+			}
+		}
+		return errHookFailed
+	}
+	if err := u.writeState(RunHook, Done, &hi, nil); err != nil {
+		return err
+	}
+	logger.Infof("ran %q hook", hookName)
+	return u.commitHook(hi)
+}
+
+// collectMetrics runs the collect-metrics hook. It runs outside the
+// persistent hook state machine: it is not recorded in the uniter's
+// state, no charm snapshot is taken, and a failure is logged rather
+// than putting the unit into an error state, because the hook only
+// reports on the unit and will run again soon.
+func (u *Uniter) collectMetrics() error {
+	hookName := string(hooks.CollectMetrics)
+	lockMessage := fmt.Sprintf("%s: running hook %q", u.unit.Name(), hookName)
+	if err := u.acquireHookLock(lockMessage); err != nil {
+		return err
+	}
+	defer u.hookLock.Unlock()
+
+	hctx, err := u.getHookContext(hookName, -1, "")
+	if err != nil {
+		return err
+	}
+	if hctx.hookTimeout, err = u.hookTimeout(); err != nil {
+		return err
+	}
+	srv, socketPath, err := u.startJujucServer(hctx)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+	logger.Infof("running %q hook", hookName)
+	if err := hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath); err != nil {
+		logger.Errorf("hook failed: %s", err)
+		return nil
+	}
+	logger.Infof("ran %q hook", hookName)
+	return nil
+}
+
+// acquireHookLock takes the machine-wide hook execution lock, giving up
+// if the uniter is stopped while waiting for it.
+func (u *Uniter) acquireHookLock(message string) error {
 	// We want to make sure we don't block forever when locking, but take the
 	// tomb into account.
 	checkTomb := func() error {
@@ -302,32 +469,69 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 		}
 		return nil
 	}
-	lockMessage := fmt.Sprintf("%s: running hook %q", u.unit.Name(), hookName)
-	if err = u.hookLock.LockWithFunc(lockMessage, checkTomb); err != nil {
-		return err
-	}
-	defer u.hookLock.Unlock()
+	return u.hookLock.LockWithFunc(message, checkTomb)
+}
 
+// getHookContext returns a context in which to run the named hook, or
+// commands, for the relation with the given id (-1 if none) and the
+// given remote unit.
+func (u *Uniter) getHookContext(hookName string, relationId int, remoteUnit string) (*HookContext, error) {
+	hctxId := fmt.Sprintf("%s:%s:%d", u.unit.Name(), hookName, u.rand.Int63())
+	u.relationersMu.Lock()
 	ctxRelations := map[int]*ContextRelation{}
 	for id, r := range u.relationers {
 		ctxRelations[id] = r.Context()
 	}
+	u.relationersMu.Unlock()
 	apiAddrs, err := u.st.APIAddresses()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	hctx, err := NewHookContext(u.unit, hctxId, u.uuid, relationId, hi.RemoteUnit,
-		ctxRelations, apiAddrs)
+	meta, err := u.charmMeta()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return NewHookContext(u.unit, hctxId, u.uuid, relationId, remoteUnit,
+		ctxRelations, apiAddrs, meta.Metrics)
+}
+
+// charmMeta returns the metadata of the deployed charm.
+func (u *Uniter) charmMeta() (*corecharm.Meta, error) {
+	dir, err := corecharm.ReadDir(u.charm.Path())
+	if err != nil {
+		return nil, err
 	}
+	return dir.Meta(), nil
+}
+
+// claimLeadership tries to make the unit the leader of its service,
+// and returns whether it has just become the leader.
+func (u *Uniter) claimLeadership() (elected bool, err error) {
+	u.lastLeadershipClaim = time.Now()
+	return u.leadership.Claim()
+}
 
-	// Prepare server.
+// hookTimeout returns how long the deployed charm's hooks may run: the
+// timeout declared by the charm if any, or the environment's default.
+func (u *Uniter) hookTimeout() (time.Duration, error) {
+	meta, err := u.charmMeta()
+	if err != nil {
+		return 0, err
+	}
+	if meta.HookTimeout > 0 {
+		return meta.HookTimeout, nil
+	}
+	return u.st.HookTimeout()
+}
+
+// startJujucServer starts a server through which code run in hctx can
+// execute jujuc tools, and returns it along with the path of its socket.
+func (u *Uniter) startJujucServer(hctx *HookContext) (*jujuc.Server, string, error) {
 	getCmd := func(ctxId, cmdName string) (cmd.Command, error) {
 		// TODO: switch to long-running server with single context;
 		// use nonce in place of context id.
-		if ctxId != hctxId {
-			return nil, fmt.Errorf("expected context id %q, got %q", hctxId, ctxId)
+		if ctxId != hctx.id {
+			return nil, fmt.Errorf("expected context id %q, got %q", hctx.id, ctxId)
 		}
 		return jujuc.NewCommand(hctx, cmdName)
 	}
@@ -336,24 +540,95 @@ func (u *Uniter) runHook(hi hook.Info) (err error) {
 	socketPath = "@" + socketPath
 	srv, err := jujuc.NewServer(getCmd, socketPath)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 	go srv.Run()
+	return srv, socketPath, nil
+}
+
+// runSocketPath returns the path of the socket on which the uniter
+// accepts requests to run commands.
+func (u *Uniter) runSocketPath() string {
+	return filepath.Join(u.baseDir, RunSocketName)
+}
+
+// RunCommands executes the supplied commands in the unit's hook context,
+// optionally within the context of one of its relations. The commands
+// never run at the same time as a hook.
+func (u *Uniter) RunCommands(args RunCommandsArgs) (*utilexec.ExecResponse, error) {
+	logger.Infof("running commands: %q", args.Commands)
+	if _, err := os.Stat(u.charm.Path()); err != nil {
+		return nil, fmt.Errorf("charm not installed")
+	}
+	if args.RelationId != -1 {
+		u.relationersMu.Lock()
+		_, found := u.relationers[args.RelationId]
+		u.relationersMu.Unlock()
+		if !found {
+			return nil, fmt.Errorf("unknown relation id: %d", args.RelationId)
+		}
+	}
+	lockMessage := fmt.Sprintf("%s: running commands", u.unit.Name())
+	if err := u.acquireHookLock(lockMessage); err != nil {
+		return nil, err
+	}
+	defer u.hookLock.Unlock()
+
+	hctx, err := u.getHookContext("run-commands", args.RelationId, "")
+	if err != nil {
+		return nil, err
+	}
+	// The commands hold the hook lock, so they are limited like hooks
+	// are, lest they keep hooks from running indefinitely.
+	if hctx.hookTimeout, err = u.hookTimeout(); err != nil {
+		return nil, err
+	}
+	srv, socketPath, err := u.startJujucServer(hctx)
+	if err != nil {
+		return nil, err
+	}
 	defer srv.Close()
+	return hctx.RunCommands(args.Commands, u.charm.Path(), u.toolsDir, socketPath)
+}
 
-	// Run the hook.
-	if err := u.writeState(RunHook, Pending, &hi, nil); err != nil {
+// finishAction records the outcome of the action run for the supplied
+// hook.Info, and commits the hook.
+func (u *Uniter) finishAction(hi hook.Info, data *actionData, runErr error) error {
+	status, message := params.ActionCompleted, ""
+	if runErr != nil {
+		logger.Errorf("action failed: %s", runErr)
+		status, message = params.ActionFailed, runErr.Error()
+	} else if data.failed {
+		logger.Infof("action reported failure: %s", data.message)
+		status, message = params.ActionFailed, data.message
+	}
+	if err := data.action.Finish(status, data.results, message); err != nil {
 		return err
 	}
-	logger.Infof("running %q hook", hookName)
-	if err := hctx.RunHook(hookName, u.charm.Path(), u.toolsDir, socketPath); err != nil {
-		logger.Errorf("hook failed: %s", err)
-		return errHookFailed
-	}
 	if err := u.writeState(RunHook, Done, &hi, nil); err != nil {
 		return err
 	}
-	logger.Infof("ran %q hook", hookName)
+	logger.Infof("ran %q action", data.action.Name())
+	return u.commitHook(hi)
+}
+
+// failInterruptedAction records the action run for the supplied hook.Info
+// as failed, because the uniter was interrupted while running it, and
+// commits the hook. If the uniter was interrupted after the action's
+// outcome was recorded, that outcome is left alone.
+func (u *Uniter) failInterruptedAction(hi hook.Info) error {
+	action, err := u.st.Action(hi.ActionId)
+	if err != nil {
+		return err
+	}
+	switch action.Status() {
+	case params.ActionCompleted, params.ActionFailed:
+		logger.Infof("interrupted action %q had already finished", hi.ActionId)
+	default:
+		if err := action.Finish(params.ActionFailed, nil, "action interrupted"); err != nil {
+			return err
+		}
+	}
 	return u.commitHook(hi)
 }
 
@@ -366,7 +641,9 @@ func (u *Uniter) commitHook(hi hook.Info) error {
 			return err
 		}
 		if hi.Kind == hooks.RelationBroken {
+			u.relationersMu.Lock()
 			delete(u.relationers, hi.RelationId)
+			u.relationersMu.Unlock()
 		}
 	}
 	if err := u.charm.Snapshotf("Completed %q hook.", hi.Kind); err != nil {
@@ -443,7 +720,9 @@ func (u *Uniter) updateRelations(ids []int) (added []*Relationer, err error) {
 				if err := r.SetDying(); err != nil {
 					return nil, err
 				} else if r.IsImplicit() {
+					u.relationersMu.Lock()
 					delete(u.relationers, id)
+					u.relationersMu.Unlock()
 				}
 			}
 			continue
@@ -541,7 +820,9 @@ func (u *Uniter) addRelation(rel *uniter.Relation, dir *relation.StateDir) error
 				return err
 			}
 			logger.Infof("joined relation %q", rel)
+			u.relationersMu.Lock()
 			u.relationers[rel.Id()] = r
+			u.relationersMu.Unlock()
 			return nil
 		}
 	}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/rpc"
 	"net/url"
 	"os"
 	"os/exec"
@@ -31,9 +32,12 @@ import (
 	"launchpad.net/juju-core/state/api"
 	"launchpad.net/juju-core/state/api/params"
 	apiuniter "launchpad.net/juju-core/state/api/uniter"
+	apiserveruniter "launchpad.net/juju-core/state/apiserver/uniter"
+	"launchpad.net/juju-core/state/presence"
 	coretesting "launchpad.net/juju-core/testing"
 	"launchpad.net/juju-core/testing/checkers"
 	"launchpad.net/juju-core/utils"
+	utilexec "launchpad.net/juju-core/utils/exec"
 	"launchpad.net/juju-core/utils/fslock"
 	"launchpad.net/juju-core/worker"
 	"launchpad.net/juju-core/worker/uniter"
@@ -1022,6 +1026,295 @@ func (s *UniterSuite) TestUniterSubordinates(c *gc.C) {
 	s.runUniterTests(c, subordinatesTests)
 }
 
+var runCommandsTests = []uniterTest{
+	ut(
+		"commands run in the unit's hook context",
+		quickStart{},
+		runCommands{
+			commands:   "echo $JUJU_UNIT_NAME\nconfig-get blog-title",
+			relationId: -1,
+			stdout:     "u/0\nMy Title\n",
+		},
+	), ut(
+		"commands run in a relation's context",
+		quickStartRelation{},
+		runCommands{
+			commands:   "echo $JUJU_RELATION_ID; exit 3",
+			relationId: 0,
+			stdout:     "db:0\n",
+			code:       3,
+		},
+	), ut(
+		"commands refer to an unknown relation",
+		quickStart{},
+		runCommands{
+			commands:   "echo never",
+			relationId: 3,
+			err:        "unknown relation id: 3",
+		},
+	), ut(
+		"commands killed after the hook timeout",
+		quickStart{},
+		setHookTimeout("300ms"),
+		runCommands{
+			commands:   "sleep 60",
+			relationId: -1,
+			err:        "commands timed out after 300ms",
+		},
+	),
+}
+
+func (s *UniterSuite) TestUniterRunCommands(c *gc.C) {
+	s.runUniterTests(c, runCommandsTests)
+}
+
+var collectMetricsTests = []uniterTest{
+	ut(
+		"collect-metrics hook runs for charms that declare metrics",
+		createCharm{
+			customize: func(c *gc.C, ctx *context, path string) {
+				metrics := "metrics:\n  load:\n    type: gauge\n"
+				err := ioutil.WriteFile(filepath.Join(path, "metrics.yaml"), []byte(metrics), 0644)
+				c.Assert(err, gc.IsNil)
+				ctx.writeHook(c, filepath.Join(path, "hooks", "collect-metrics"), true)
+				appendHook(c, path, "collect-metrics", "add-metric load=0.5\n")
+			},
+		},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start", "collect-metrics"},
+		waitMetric{"load"},
+	), ut(
+		"a failed collect-metrics hook does not put the unit in error",
+		createCharm{
+			customize: func(c *gc.C, ctx *context, path string) {
+				metrics := "metrics:\n  load:\n    type: gauge\n"
+				err := ioutil.WriteFile(filepath.Join(path, "metrics.yaml"), []byte(metrics), 0644)
+				c.Assert(err, gc.IsNil)
+				ctx.writeHook(c, filepath.Join(path, "hooks", "collect-metrics"), false)
+			},
+		},
+		serveCharm{},
+		createUniter{},
+		waitHooks{"install", "config-changed", "start", "fail-collect-metrics"},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+	), ut(
+		"collect-metrics hook never runs for charms that declare no metrics",
+		quickStart{},
+		waitHooks{},
+	),
+}
+
+func (s *UniterSuite) TestUniterCollectMetrics(c *gc.C) {
+	s.PatchValue(uniter.CollectMetricsInterval, 10*time.Millisecond)
+	s.runUniterTests(c, collectMetricsTests)
+}
+
+func leadershipTests() []uniterTest {
+	// The pinger keeps the agent of the other leader alive, so that
+	// the uniter's unit cannot take over its leadership.
+	var pinger *presence.Pinger
+	return []uniterTest{
+		ut(
+			"leader-elected hook runs when the unit becomes leader",
+			createCharm{
+				customize: func(c *gc.C, ctx *context, path string) {
+					ctx.writeHook(c, filepath.Join(path, "hooks", "leader-elected"), true)
+				},
+			},
+			serveCharm{},
+			createUniter{},
+			waitUnit{
+				status: params.StatusStarted,
+			},
+			waitHooks{"install", "config-changed", "start", "leader-elected"},
+			custom{func(c *gc.C, ctx *context) {
+				leader, err := ctx.svc.Leader()
+				c.Assert(err, gc.IsNil)
+				c.Assert(leader, gc.Equals, "u/0")
+			}},
+		), ut(
+			"leader keeps its lease while a hook error is unresolved",
+			createCharm{
+				customize: func(c *gc.C, ctx *context, path string) {
+					ctx.writeHook(c, filepath.Join(path, "hooks", "leader-elected"), true)
+				},
+			},
+			serveCharm{},
+			createUniter{},
+			waitUnit{
+				status: params.StatusStarted,
+			},
+			waitHooks{"install", "config-changed", "start", "leader-elected"},
+			custom{func(c *gc.C, ctx *context) {
+				ctx.writeHook(c, filepath.Join(ctx.path, "charm", "hooks", "config-changed"), false)
+			}},
+			changeConfig{"blog-title": "Goodness Gracious Me"},
+			waitHooks{"fail-config-changed"},
+			waitUnit{
+				status: params.StatusError,
+				info:   `hook failed: "config-changed"`,
+				data: params.StatusData{
+					"hook": "config-changed",
+				},
+			},
+			custom{func(c *gc.C, ctx *context) {
+				// Outlive several leases.
+				time.Sleep(3 * apiserveruniter.LeaseDuration)
+				leader, err := ctx.svc.Leader()
+				c.Assert(err, gc.IsNil)
+				c.Assert(leader, gc.Equals, "u/0")
+			}},
+		), ut(
+			"leader-settings-changed hook runs when the leader changes its settings",
+			createCharm{
+				customize: func(c *gc.C, ctx *context, path string) {
+					ctx.writeHook(c, filepath.Join(path, "hooks", "leader-elected"), true)
+					ctx.writeHook(c, filepath.Join(path, "hooks", "leader-settings-changed"), true)
+				},
+			},
+			serveCharm{},
+			ensureStateWorker{},
+			createServiceAndUnit{},
+			custom{func(c *gc.C, ctx *context) {
+				leader, err := ctx.svc.AddUnit()
+				c.Assert(err, gc.IsNil)
+				backing, err := ctx.s.BackingState.Unit(leader.Name())
+				c.Assert(err, gc.IsNil)
+				pinger, err = backing.SetAgentAlive()
+				c.Assert(err, gc.IsNil)
+				ctx.s.BackingState.StartSync()
+				err = backing.WaitAgentAlive(coretesting.LongWait)
+				c.Assert(err, gc.IsNil)
+				claimed, err := leader.ClaimLeadership(time.Hour)
+				c.Assert(err, gc.IsNil)
+				c.Assert(claimed, gc.Equals, true)
+			}},
+			startUniter{},
+			waitAddresses{},
+			waitUnit{
+				status: params.StatusStarted,
+			},
+			waitHooks{"install", "config-changed", "start"},
+			custom{func(c *gc.C, ctx *context) {
+				leader, err := ctx.st.Unit("u/1")
+				c.Assert(err, gc.IsNil)
+				err = leader.SetLeaderSettings(map[string]string{"master": "10.0.0.2"})
+				c.Assert(err, gc.IsNil)
+			}},
+			waitHooks{"leader-settings-changed"},
+			custom{func(c *gc.C, ctx *context) {
+				c.Assert(pinger.Stop(), gc.IsNil)
+			}},
+		),
+	}
+}
+
+func (s *UniterSuite) TestUniterLeadership(c *gc.C) {
+	s.PatchValue(&apiserveruniter.LeaseDuration, 250*time.Millisecond)
+	s.PatchValue(uniter.LeaseRenewInterval, 50*time.Millisecond)
+	s.runUniterTests(c, leadershipTests())
+}
+
+var slowHook = `
+#!/bin/bash --norc
+sleep 60
+`[1:]
+
+// writeSlowInstallHook replaces the charm's install hook with one that
+// runs for much longer than any test.
+func writeSlowInstallHook(c *gc.C, ctx *context, path string) {
+	err := ioutil.WriteFile(filepath.Join(path, "hooks", "install"), []byte(slowHook), 0755)
+	c.Assert(err, gc.IsNil)
+}
+
+var hookTimeoutTests = []uniterTest{
+	ut(
+		"hook killed after the charm's hook timeout",
+		createCharm{
+			customize: func(c *gc.C, ctx *context, path string) {
+				writeSlowInstallHook(c, ctx, path)
+				f, err := os.OpenFile(filepath.Join(path, "metadata.yaml"), os.O_WRONLY|os.O_APPEND, 0644)
+				c.Assert(err, gc.IsNil)
+				defer f.Close()
+				_, err = f.Write([]byte("hook-timeout: 1s\n"))
+				c.Assert(err, gc.IsNil)
+			},
+		},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusError,
+			info:   "hook timed out after 1s",
+			data: params.StatusData{
+				"hook": "install",
+			},
+		},
+		fixHook{"install"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+	), ut(
+		"hook killed after the environment's hook timeout",
+		setHookTimeout("300ms"),
+		createCharm{customize: writeSlowInstallHook},
+		serveCharm{},
+		createUniter{},
+		waitUnit{
+			status: params.StatusError,
+			info:   "hook timed out after 300ms",
+			data: params.StatusData{
+				"hook": "install",
+			},
+		},
+	), ut(
+		"hook killed when cancelled",
+		createCharm{customize: writeSlowInstallHook},
+		serveCharm{},
+		createUniter{},
+		cancelHook{},
+		waitUnit{
+			status: params.StatusError,
+			info:   "hook cancelled",
+			data: params.StatusData{
+				"hook": "install",
+			},
+		},
+		// The reason the hook was killed is reported again
+		// when the uniter restarts.
+		stopUniter{},
+		custom{func(c *gc.C, ctx *context) {
+			err := ctx.unit.SetStatus(params.StatusError, "hook failed", nil)
+			c.Assert(err, gc.IsNil)
+		}},
+		startUniter{},
+		waitUnit{
+			status: params.StatusError,
+			info:   "hook cancelled",
+			data: params.StatusData{
+				"hook": "install",
+			},
+		},
+		fixHook{"install"},
+		resolveError{state.ResolvedRetryHooks},
+		waitUnit{
+			status: params.StatusStarted,
+		},
+		waitHooks{"install", "config-changed", "start"},
+	),
+}
+
+func (s *UniterSuite) TestUniterHookTimeout(c *gc.C) {
+	s.runUniterTests(c, hookTimeoutTests)
+}
+
 func (s *UniterSuite) runUniterTests(c *gc.C, uniterTests []uniterTest) {
 	for i, t := range uniterTests {
 		c.Logf("\ntest %d: %s\n", i, t.summary)
@@ -1508,6 +1801,26 @@ func (s waitHooks) step(c *gc.C, ctx *context) {
 	}
 }
 
+type waitMetric struct {
+	key string
+}
+
+func (s waitMetric) step(c *gc.C, ctx *context) {
+	timeout := time.After(worstCase)
+	for {
+		values, err := ctx.svc.MetricValues(s.key, time.Time{})
+		c.Assert(err, gc.IsNil)
+		if len(values) > 0 {
+			return
+		}
+		select {
+		case <-time.After(coretesting.ShortWait):
+		case <-timeout:
+			c.Fatalf("metric %q never recorded", s.key)
+		}
+	}
+}
+
 type fixHook struct {
 	name string
 }
@@ -1517,6 +1830,41 @@ func (s fixHook) step(c *gc.C, ctx *context) {
 	ctx.writeHook(c, path, true)
 }
 
+type setHookTimeout string
+
+func (s setHookTimeout) step(c *gc.C, ctx *context) {
+	oldCfg, err := ctx.st.EnvironConfig()
+	c.Assert(err, gc.IsNil)
+	cfg, err := oldCfg.Apply(map[string]interface{}{"hook-timeout": string(s)})
+	c.Assert(err, gc.IsNil)
+	err = ctx.st.SetEnvironConfig(cfg)
+	c.Assert(err, gc.IsNil)
+}
+
+// cancelHook asks the unit's agent to cancel its running hook until
+// the unit reports that the hook was cancelled. Requests made before
+// the hook starts are discarded.
+type cancelHook struct{}
+
+func (s cancelHook) step(c *gc.C, ctx *context) {
+	timeout := time.After(worstCase)
+	for {
+		err := ctx.unit.CancelHook()
+		c.Assert(err, gc.IsNil)
+		ctx.s.BackingState.StartSync()
+		select {
+		case <-time.After(coretesting.ShortWait):
+			_, info, _, err := ctx.unit.Status()
+			c.Assert(err, gc.IsNil)
+			if info == "hook cancelled" {
+				return
+			}
+		case <-timeout:
+			c.Fatalf("hook never cancelled")
+		}
+	}
+}
+
 type changeConfig map[string]interface{}
 
 func (s changeConfig) step(c *gc.C, ctx *context) {
@@ -1808,6 +2156,31 @@ func (s chmod) step(c *gc.C, ctx *context) {
 	c.Assert(err, gc.IsNil)
 }
 
+type runCommands struct {
+	commands   string
+	relationId int
+	stdout     string
+	code       int
+	err        string
+}
+
+func (s runCommands) step(c *gc.C, ctx *context) {
+	socketPath := filepath.Join(ctx.path, uniter.RunSocketName)
+	client, err := rpc.Dial("unix", socketPath)
+	c.Assert(err, gc.IsNil)
+	defer client.Close()
+	var result utilexec.ExecResponse
+	args := uniter.RunCommandsArgs{Commands: s.commands, RelationId: s.relationId}
+	err = client.Call(uniter.JujuRunEndpoint, args, &result)
+	if s.err != "" {
+		c.Assert(err, gc.ErrorMatches, s.err)
+		return
+	}
+	c.Assert(err, gc.IsNil)
+	c.Check(string(result.Stdout), gc.Equals, s.stdout)
+	c.Check(result.Code, gc.Equals, s.code)
+}
+
 type custom struct {
 	f func(*gc.C, *context)
 }